@@ -18,6 +18,10 @@ package v1beta1
 
 import clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 
+// The condition types and reasons of this file are part of the API version v1beta1. The values of existing
+// constants do not change within it, so that other controllers and dashboards can switch on them instead of
+// matching the messages of conditions and events, which may change. New constants may be added in any release.
+
 const (
 	// LoadBalancerAttached reports on whether the load balancer is attached.
 	LoadBalancerAttached clusterv1.ConditionType = "LoadBalancerAttached"
@@ -34,6 +38,13 @@ const (
 	LoadBalancerNoNetworkFoundReason = "LoadBalancerNoNetworkFound"
 )
 
+const (
+	// LoadBalancerTargetsHealthyCondition reports on whether all targets of the load balancer pass the health checks.
+	LoadBalancerTargetsHealthyCondition clusterv1.ConditionType = "LoadBalancerTargetsHealthy"
+	// LoadBalancerTargetsUnhealthyReason is used when targets of the load balancer fail the health checks.
+	LoadBalancerTargetsUnhealthyReason = "LoadBalancerTargetsUnhealthy"
+)
+
 const (
 	// InstanceReadyCondition reports on current status of the instance. Ready indicates the instance is in a Running state.
 	InstanceReadyCondition clusterv1.ConditionType = "InstanceReady"
@@ -41,10 +52,31 @@ const (
 	InstanceTerminatedReason = "InstanceTerminated"
 	// InstanceHasNonExistingPlacementGroupReason instance has a placement group name that does not exist.
 	InstanceHasNonExistingPlacementGroupReason = "InstanceHasNonExistingPlacementGroup"
+	// InstanceHasNoPlacementGroupLabelReason instance has an automatic placement group, but its Machine does not have the label.
+	InstanceHasNoPlacementGroupLabelReason = "InstanceHasNoPlacementGroupLabel"
+	// InstanceHasNoMatchingPlacementGroupReason instance has a placement group selector, but no selected placement group has room left.
+	InstanceHasNoMatchingPlacementGroupReason = "InstanceHasNoMatchingPlacementGroup"
+	// ISONotFoundReason instance has an ISO that does not exist.
+	ISONotFoundReason = "ISONotFound"
+	// InstanceInRescueModeReason instance has been booted into the rescue system for debugging.
+	InstanceInRescueModeReason = "InstanceInRescueMode"
 	// ServerOffReason instance is off.
 	ServerOffReason = "ServerOff"
 	// InstanceAsControlPlaneUnreachableReason control plane is (not yet) reachable.
 	InstanceAsControlPlaneUnreachableReason = "InstanceAsControlPlaneUnreachable"
+	// PublicNetworkChangingReason instance is shut down to change its public network.
+	PublicNetworkChangingReason = "PublicNetworkChanging"
+	// ServerTypeChangingReason instance is shut down to change its server type.
+	ServerTypeChangingReason = "ServerTypeChanging"
+	// LocationNotAllowedReason indicates that the location of the instance is not allowed by the placement constraints.
+	LocationNotAllowedReason = "LocationNotAllowed"
+	// PrivateNetworkRequiredReason indicates that an instance without public IPs cannot be created without private network.
+	PrivateNetworkRequiredReason = "PrivateNetworkRequired"
+	// SubnetNotFoundReason indicates that the subnet that is selected by the instance is not a subnet of the network.
+	SubnetNotFoundReason = "SubnetNotFound"
+	// NoRouteToInternetReason indicates that the private network has no default route, so that an instance without
+	// public IPs could not reach the internet.
+	NoRouteToInternetReason = "NoRouteToInternet"
 )
 
 const (
@@ -61,6 +93,14 @@ const (
 	NetworkDisabledReason = "NetworkDisabled"
 	// NetworkUnreachableReason indicates that network is unreachable.
 	NetworkUnreachableReason = "NetworkUnreachable"
+	// NetworkNotFoundReason indicates that the existing network that is referenced by the cluster does not exist.
+	NetworkNotFoundReason = "NetworkNotFound"
+	// NetworkSubnetNotFoundReason indicates that the existing network has no subnet in the network zone of the cluster.
+	NetworkSubnetNotFoundReason = "NetworkSubnetNotFound"
+	// SubnetIPsAvailableCondition reports whether the subnet of the network has free IPs for new servers.
+	SubnetIPsAvailableCondition clusterv1.ConditionType = "SubnetIPsAvailable"
+	// SubnetExhaustedReason indicates that all IPs of the subnet of the network are used.
+	SubnetExhaustedReason = "SubnetExhausted"
 )
 
 const (
@@ -70,6 +110,23 @@ const (
 	PlacementGroupsUnreachableReason = "PlacementGroupsUnreachable"
 )
 
+const (
+	// FirewallsSyncedCondition reports whether the firewalls of the HetznerCluster are in sync with HCloud.
+	FirewallsSyncedCondition clusterv1.ConditionType = "FirewallsSynced"
+	// FirewallsSyncFailedReason indicates that the firewalls could not be created, updated or deleted.
+	FirewallsSyncFailedReason = "FirewallsSyncFailed"
+)
+
+const (
+	// ControlPlaneFloatingIPReadyCondition reports whether the floating IP of the control plane exists and is
+	// assigned to a control plane server.
+	ControlPlaneFloatingIPReadyCondition clusterv1.ConditionType = "ControlPlaneFloatingIPReady"
+	// FloatingIPReconcileFailedReason indicates that the floating IP could not be created, assigned or deleted.
+	FloatingIPReconcileFailedReason = "FloatingIPReconcileFailed"
+	// FloatingIPNotAssignedReason indicates that no running control plane server exists to assign the floating IP to.
+	FloatingIPNotAssignedReason = "FloatingIPNotAssigned"
+)
+
 const (
 	// HetznerClusterReady reports on whether the Hetzner cluster is in ready state.
 	HetznerClusterReady clusterv1.ConditionType = "HetznerClusterReady"
@@ -86,6 +143,14 @@ const (
 	RateLimitNotReachedReason = "RateLimitNotReached"
 )
 
+const (
+	// QuotaExceeded reports whether a resource could not be created, because it would exceed the limits of the
+	// HCloud project.
+	QuotaExceeded clusterv1.ConditionType = "QuotaExceeded"
+	// ResourceLimitReachedReason indicates that the limit of a resource in the HCloud project is reached.
+	ResourceLimitReachedReason = "ResourceLimitReached"
+)
+
 const (
 	// HetznerBareMetalHostReady reports on whether the Hetzner cluster is in ready state.
 	HetznerBareMetalHostReady clusterv1.ConditionType = "HetznerBareMetalHostReady"
@@ -93,7 +158,247 @@ const (
 	RobotCredentialsInvalidReason = "RobotCredentialsInvalid" // #nosec
 )
 
+const (
+	// HostHealthyCondition reports whether the node of the HetznerBareMetalHost reports problems of the hardware.
+	HostHealthyCondition clusterv1.ConditionType = "HostHealthy"
+	// HostProblemDetectedReason indicates that a node condition of HostHealthNodeConditions reports a problem.
+	HostProblemDetectedReason = "HostProblemDetected"
+)
+
+const (
+	// KubeletServingCertificateValidCondition reports whether the serving certificate of the kubelet of the node
+	// is valid and gets rotated in time.
+	KubeletServingCertificateValidCondition clusterv1.ConditionType = "KubeletServingCertificateValid"
+	// KubeletServingCertificateRotationOverdueReason indicates that the certificate should have been rotated already.
+	KubeletServingCertificateRotationOverdueReason = "KubeletServingCertificateRotationOverdue"
+	// KubeletServingCertificateExpiredReason indicates that the certificate has expired.
+	KubeletServingCertificateExpiredReason = "KubeletServingCertificateExpired"
+	// KubeletServingCertificateDeniedReason indicates that the last CSR for a new certificate has been denied.
+	KubeletServingCertificateDeniedReason = "KubeletServingCertificateDenied"
+)
+
+const (
+	// CSRValidationFailedReason is the reason of the condition Denied of a CSR of a kubelet that failed the
+	// validation against its machine.
+	CSRValidationFailedReason = "CSRValidationFailed"
+	// CSRValidationSucceedReason is the reason of the condition Approved of a CSR of a kubelet that has been
+	// validated against its machine.
+	CSRValidationSucceedReason = "CSRValidationSucceed"
+)
+
+const (
+	// ProvisioningChecksSucceededCondition reports whether the provisioning checks of a HetznerBareMetalHost
+	// succeeded after cloud init.
+	ProvisioningChecksSucceededCondition clusterv1.ConditionType = "ProvisioningChecksSucceeded"
+	// ProvisioningChecksFailedReason indicates that at least one provisioning check failed.
+	ProvisioningChecksFailedReason = "ProvisioningChecksFailed"
+)
+
+const (
+	// RedundantBootloaderCondition reports whether the bootloader of a HetznerBareMetalHost is installed on
+	// every disk of the software RAID1 of the operating system.
+	RedundantBootloaderCondition clusterv1.ConditionType = "RedundantBootloader"
+	// BootloaderMissingReason indicates that the bootloader is missing on at least one disk of the RAID1.
+	BootloaderMissingReason = "BootloaderMissing"
+)
+
+const (
+	// LoadBalancerInSyncCondition reports whether the control plane load balancer matches the spec of the HetznerCluster.
+	LoadBalancerInSyncCondition clusterv1.ConditionType = "LoadBalancerInSync"
+	// ServerInSyncCondition reports whether the server of an HCloudMachine matches its spec.
+	ServerInSyncCondition clusterv1.ConditionType = "ServerInSync"
+	// DriftAdoptedReason indicates that changes that were made outside of the controller have been kept,
+	// because the drift policy is Adopt.
+	DriftAdoptedReason = "DriftAdopted"
+)
+
+const (
+	// ProvisioningSlotAvailableCondition reports whether a HetznerBareMetalHost can start its next provisioning
+	// operation, or waits because the limit of the provisioning throttle of its datacenter is reached.
+	ProvisioningSlotAvailableCondition clusterv1.ConditionType = "ProvisioningSlotAvailable"
+	// RescueLimitReachedReason indicates that the limit of concurrent rescue activations in the datacenter is reached.
+	RescueLimitReachedReason = "RescueLimitReached"
+	// ImagingLimitReachedReason indicates that the limit of concurrent imaging operations in the datacenter is reached.
+	ImagingLimitReachedReason = "ImagingLimitReached"
+)
+
+const (
+	// ReprovisionApprovedCondition reports whether a HetznerBareMetalHost waits for the approval to be reimaged
+	// with another image or partitioning. It is removed when the reprovisioning starts.
+	ReprovisionApprovedCondition clusterv1.ConditionType = "ReprovisionApproved"
+	// ReprovisionApprovalRequiredReason indicates that the reprovisioning waits for the approval annotation.
+	ReprovisionApprovalRequiredReason = "ReprovisionApprovalRequired"
+)
+
+const (
+	// DeprovisionApprovedCondition reports whether a HetznerBareMetalHost whose installImage has been removed waits
+	// for the confirmation to be deprovisioned. It is removed when the deprovisioning starts.
+	DeprovisionApprovedCondition clusterv1.ConditionType = "DeprovisionApproved"
+	// DeprovisionApprovalRequiredReason indicates that the deprovisioning waits for the confirmation annotation.
+	DeprovisionApprovalRequiredReason = "DeprovisionApprovalRequired"
+)
+
+const (
+	// EgressIPsDiscoveredCondition reports whether the egress IPs of the controllers in the status of the
+	// HetznerCluster are up to date.
+	EgressIPsDiscoveredCondition clusterv1.ConditionType = "EgressIPsDiscovered"
+	// EgressIPDiscoveryFailedReason indicates that no endpoint of the egress IP discovery answered.
+	EgressIPDiscoveryFailedReason = "EgressIPDiscoveryFailed"
+)
+
+const (
+	// ProvisioningFirewallRulesCondition reports whether the rules of the provisioning firewall have been added to
+	// the Robot firewall of a HetznerBareMetalHost. It is removed together with the rules.
+	ProvisioningFirewallRulesCondition clusterv1.ConditionType = "ProvisioningFirewallRules"
+	// FirewallInProcessReason indicates that Robot is applying a change of the firewall.
+	FirewallInProcessReason = "FirewallInProcess"
+	// FirewallRuleLimitReachedReason indicates that the firewall cannot take the rules of the provisioning firewall.
+	FirewallRuleLimitReachedReason = "FirewallRuleLimitReached"
+)
+
+const (
+	// HostFencedCondition reports whether Robot confirmed that a fenced HetznerBareMetalHost is powered off.
+	HostFencedCondition clusterv1.ConditionType = "HostFenced"
+	// FencingInProgressReason indicates that the power-off of the host has been requested and is not confirmed yet.
+	FencingInProgressReason = "FencingInProgress"
+	// FencingNotSupportedReason indicates that Robot does not report the power state of the server, so that
+	// the power-off cannot be confirmed.
+	FencingNotSupportedReason = "FencingNotSupported"
+	// FencingFailedReason indicates that the server is still running after the power-off has been requested,
+	// so that it has to be powered off manually.
+	FencingFailedReason = "FencingFailed"
+)
+
+const (
+	// ActionSucceededCondition reports whether the last action of the state machine of a HetznerBareMetalHost succeeded.
+	ActionSucceededCondition clusterv1.ConditionType = "ActionSucceeded"
+	// ActionErrorBackoffReason indicates that an action returned an error and is retried after a backoff.
+	ActionErrorBackoffReason = "ActionErrorBackoff"
+	// ActionRateLimitedReason indicates that an action hit the rate limit of an API and is retried after a backoff.
+	ActionRateLimitedReason = "ActionRateLimited"
+	// ActionConfigurationErrorReason indicates that an action failed because of the configuration of the host or machine.
+	ActionConfigurationErrorReason = "ActionConfigurationError"
+	// ActionHardwareErrorReason indicates that an action failed because of the hardware of the server.
+	ActionHardwareErrorReason = "ActionHardwareError"
+)
+
 const (
 	// AssociateBMHCondition reports on whether the Hetzner cluster is in ready state.
 	AssociateBMHCondition clusterv1.ConditionType = "AssociateBMHCondition"
 )
+
+const (
+	// PreDeleteHookSucceededCondition reports whether the pre-delete hooks of a deleted machine have been removed,
+	// so that its server can be deleted.
+	PreDeleteHookSucceededCondition clusterv1.ConditionType = "PreDeleteHookSucceeded"
+)
+
+const (
+	// HCloudAPIReachableCondition reports whether the HCloud API could be reached.
+	HCloudAPIReachableCondition clusterv1.ConditionType = "HCloudAPIReachable"
+	// HCloudAPIUnreachableReason indicates that the HCloud API could not be reached.
+	HCloudAPIUnreachableReason = "HCloudAPIUnreachable"
+	// ServerOrphanedReason indicates that the server was orphaned after the HCloud API remained unreachable.
+	ServerOrphanedReason = "ServerOrphaned"
+	// ForceCleanupReason indicates that the HCloud resources were left behind because of the force-cleanup annotation.
+	ForceCleanupReason = "ForceCleanup"
+)
+
+const (
+	// PrimaryIPReadyCondition reports on whether the primary IP exists in HCloud.
+	PrimaryIPReadyCondition clusterv1.ConditionType = "PrimaryIPReady"
+	// PrimaryIPNotFoundReason indicates that the primary IP to adopt could not be found.
+	PrimaryIPNotFoundReason = "PrimaryIPNotFound"
+	// PrimaryIPCreateFailedReason indicates that the primary IP could not be created.
+	PrimaryIPCreateFailedReason = "PrimaryIPCreateFailed"
+	// PrimaryIPNotAvailableReason indicates that no HCloudPrimaryIP matching the selector of the machine is available.
+	PrimaryIPNotAvailableReason = "PrimaryIPNotAvailable"
+)
+
+const (
+	// VolumeNotAvailableReason indicates that a volume of the machine is attached to another server or in
+	// another location than the server.
+	VolumeNotAvailableReason = "VolumeNotAvailable"
+	// VolumeCreateFailedReason indicates that a volume of the machine could not be created.
+	VolumeCreateFailedReason = "VolumeCreateFailed"
+)
+
+const (
+	// ServerTypeAvailableCondition reports whether the server type of an HCloudMachineTemplate is available
+	// without deprecation.
+	ServerTypeAvailableCondition clusterv1.ConditionType = "ServerTypeAvailable"
+	// ServerTypeDeprecatedReason indicates that the server type is deprecated and will be retired by HCloud.
+	ServerTypeDeprecatedReason = "ServerTypeDeprecated"
+)
+
+const (
+	// ServerTypeChangedCondition reports whether the type of the server of an HCloudMachine could be changed to
+	// the type of its spec. It is only set after a change has failed.
+	ServerTypeChangedCondition clusterv1.ConditionType = "ServerTypeChanged"
+	// ServerTypeChangeFailedReason indicates that HCloud failed to change the server type, so that the server has
+	// been powered on again with its previous type.
+	ServerTypeChangeFailedReason = "ServerTypeChangeFailed"
+)
+
+const (
+	// ImageArchitectureMatchesCondition reports whether an image of the architecture of the server type of an
+	// HCloudMachine exists.
+	ImageArchitectureMatchesCondition clusterv1.ConditionType = "ImageArchitectureMatches"
+	// ImageArchitectureMismatchReason indicates that the image exists only for another architecture than the one
+	// of the server type.
+	ImageArchitectureMismatchReason = "ImageArchitectureMismatch"
+)
+
+const (
+	// ConsoleUserInSyncCondition reports whether the console user of a machine matches the spec of the HetznerCluster.
+	ConsoleUserInSyncCondition clusterv1.ConditionType = "ConsoleUserInSync"
+	// ConsoleUserChangedReason indicates that the console user of the HetznerCluster has been changed or removed
+	// after the machine was provisioned.
+	ConsoleUserChangedReason = "ConsoleUserChanged"
+)
+
+const (
+	// HostMaintenanceFreeCondition reports whether the host of the server of an HCloudMachine is free of
+	// announced or running maintenances.
+	HostMaintenanceFreeCondition clusterv1.ConditionType = "HostMaintenanceFree"
+	// MaintenanceScheduledReason indicates that a maintenance of the host has been announced.
+	MaintenanceScheduledReason = "MaintenanceScheduled"
+	// MaintenanceWindowInvalidReason indicates that the maintenance window annotation cannot be parsed.
+	MaintenanceWindowInvalidReason = "MaintenanceWindowInvalid"
+	// ServerMigratingReason indicates that the server is being migrated to another host.
+	ServerMigratingReason = "ServerMigrating"
+)
+
+const (
+	// ImageUpToDateCondition reports whether the server of an HCloudMachine has been created from the current
+	// image of its image channel.
+	ImageUpToDateCondition clusterv1.ConditionType = "ImageUpToDate"
+	// ImagePromotedReason indicates that another image has been promoted in the image channel of the machine.
+	ImagePromotedReason = "ImagePromoted"
+	// ImageRolloutPendingReason indicates that the machine waits for the replacement of other machines of its
+	// MachineDeployment before it is replaced with the promoted image.
+	ImageRolloutPendingReason = "ImageRolloutPending"
+)
+
+const (
+	// MachineImageReadyCondition reports on whether a snapshot of the HCloudMachineImage has been built.
+	MachineImageReadyCondition clusterv1.ConditionType = "MachineImageReady"
+	// MachineImageBuildingReason indicates that a snapshot is being built.
+	MachineImageBuildingReason = "MachineImageBuilding"
+	// MachineImageBuildFailedReason indicates that the last build of a snapshot failed.
+	MachineImageBuildFailedReason = "MachineImageBuildFailed"
+)
+
+const (
+	// MachinesDeletedCondition reports on whether the machines of a deleted HetznerCluster have been deleted. The
+	// conditions of the other deletion steps are named after the step, e.g. LoadBalancerDeleted.
+	MachinesDeletedCondition clusterv1.ConditionType = "MachinesDeleted"
+	// MachinesRunningReason indicates that machines of the cluster still exist.
+	MachinesRunningReason = "MachinesRunning"
+	// DeletionStepPendingReason indicates that the deletion step waits for a previous step that failed.
+	DeletionStepPendingReason = "DeletionStepPending"
+	// DeletionStepSkippedReason indicates that the deletion step is skipped by the deletion policy.
+	DeletionStepSkippedReason = "DeletionStepSkipped"
+	// DeletionStepFailedReason indicates that the deletion step failed and is retried.
+	DeletionStepFailedReason = "DeletionStepFailed"
+)
@@ -17,6 +17,8 @@ limitations under the License.
 package v1beta1
 
 import (
+	"time"
+
 	"github.com/hetznercloud/hcloud-go/hcloud"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -29,6 +31,29 @@ const (
 	// resources associated with HCloudMachine before removing it from the
 	// apiserver.
 	MachineFinalizer = "hcloudmachine.infrastructure.cluster.x-k8s.io"
+
+	// PropagatedLabelsAnnotation lists the keys of the labels that have been propagated from the Machine
+	// to its HCloudMachine or HetznerBareMetalMachine.
+	PropagatedLabelsAnnotation = "propagated-labels.infrastructure.cluster.x-k8s.io"
+
+	// PropagatedAnnotationsAnnotation lists the keys of the annotations that have been propagated from the Machine
+	// to its HCloudMachine or HetznerBareMetalMachine.
+	PropagatedAnnotationsAnnotation = "propagated-annotations.infrastructure.cluster.x-k8s.io"
+
+	// RescueAnnotation is the key for an annotation that boots the server of an HCloudMachine into the rescue
+	// system with the SSH keys of the machine, e.g. to debug a broken node. The server is not reconciled while the
+	// annotation is set. Removing it boots the server from its disk again.
+	RescueAnnotation = "rescue.hcloudmachine.infrastructure.cluster.x-k8s.io"
+
+	// MaintenanceWindowAnnotation announces a maintenance of the host of the server of an HCloudMachine, during
+	// which the server is rebooted or migrated. The value is the start of the window in RFC 3339 format, optionally
+	// followed by "/" and its end. HCloud announces maintenances by email, so the annotation is set by external
+	// systems, e.g. a receiver of the notifications.
+	MaintenanceWindowAnnotation = "maintenance-window.hcloudmachine.infrastructure.cluster.x-k8s.io"
+
+	// ImageChannelLabel is the label of the images in HCloud that are published in a release channel. Its value is
+	// the name of the channel that is selected by imageChannel.
+	ImageChannelLabel = NameHetznerProviderPrefix + "image-channel"
 )
 
 // HCloudMachineSpec defines the desired state of HCloudMachine.
@@ -37,24 +62,199 @@ type HCloudMachineSpec struct {
 	// +optional
 	ProviderID *string `json:"providerID,omitempty"`
 
-	// Type is the HCloud Machine Type for this machine.
-	// +kubebuilder:validation:Enum=cpx11;cx21;cpx21;cx31;cpx31;cx41;cpx41;cx51;cpx51;ccx11;ccx12;ccx21;ccx22;ccx31;ccx32;ccx41;ccx42;ccx51;ccx52;ccx62;
+	// Type is the HCloud Machine Type for this machine. It can be changed to a type of the same architecture,
+	// which resizes the existing server. The server is shut down while its type is changed.
+	// +kubebuilder:validation:Enum=cpx11;cx21;cpx21;cx31;cpx31;cx41;cpx41;cx51;cpx51;ccx11;ccx12;ccx21;ccx22;ccx31;ccx32;ccx41;ccx42;ccx51;ccx52;ccx62;cax11;cax21;cax31;cax41;
 	Type HCloudMachineType `json:"type"`
 
 	// ImageName is the reference to the Machine Image from which to create the machine instance.
+	// Exactly one of imageName, imageSelector and imageChannel has to be set.
 	// +kubebuilder:validation:MinLength=1
-	ImageName string `json:"imageName"`
+	// +optional
+	ImageName string `json:"imageName,omitempty"`
+
+	// ImageSelector selects the image by the labels of the images in HCloud. The most recent matching image for
+	// the architecture of the server type is chosen when the server is created.
+	// +optional
+	ImageSelector *metav1.LabelSelector `json:"imageSelector,omitempty"`
+
+	// ImageChannel is the name of a release channel of images. The most recent image for the architecture of the
+	// server type with the label caph-image-channel set to the channel is used when the server is created. If the
+	// label is moved to another image, i.e. the image is promoted in the channel, the servers of the channel are
+	// replaced one by one in each MachineDeployment.
+	// +optional
+	ImageChannel string `json:"imageChannel,omitempty"`
+
+	// ISO is the name or ID of an ISO that is attached to the server when it is created, so that the server boots
+	// from it, e.g. for Talos or appliance images that cannot be provisioned from snapshots. The ISO is detached
+	// once the server is running, so that it boots from its disk afterwards.
+	// +optional
+	ISO string `json:"iso,omitempty"`
 
 	// define Machine specific SSH keys, overrides cluster wide SSH keys
 	// +optional
 	SSHKeys []SSHKey `json:"sshKeys,omitempty"`
 
+	// AdditionalSSHKeys are installed on the server in addition to the SSH keys of the machine or the cluster,
+	// e.g. break-glass keys of a team. They reference SSH keys in HCloud by name or public keys in secrets.
+	// +optional
+	AdditionalSSHKeys []SSHKeyReference `json:"additionalSSHKeys,omitempty"`
+
 	// +optional
 	PlacementGroupName *string `json:"placementGroupName,omitempty"`
 
+	// AutomaticPlacementGroup puts the machine into a spread placement group that is shared by all machines with
+	// the same value of a label of their Machine, by default the MachineDeployment. The placement group is created
+	// on demand and deleted once it has no servers. It cannot be combined with placementGroupName.
+	// +optional
+	AutomaticPlacementGroup *AutomaticPlacementGroupSpec `json:"automaticPlacementGroup,omitempty"`
+
+	// PlacementGroupSelector selects pre-created placement groups in HCloud by their labels, e.g. groups that are
+	// approved for regulated workloads. The server is put into the matching placement group with the fewest
+	// servers that has room left. It cannot be combined with placementGroupName and automaticPlacementGroup.
+	// +optional
+	PlacementGroupSelector *metav1.LabelSelector `json:"placementGroupSelector,omitempty"`
+
 	// PublicNetwork specifies information for public networks
 	// +optional
 	PublicNetwork *PublicNetworkSpec `json:"publicNetwork,omitempty"`
+
+	// Subnet is the name of the subnet of the HCloud network of the cluster to which the server is attached.
+	// The subnet has to be defined in hcloudNetwork.subnets of the HetznerCluster. If it is not set, the server is
+	// attached to the subnet subnetCidrBlock.
+	// +optional
+	Subnet string `json:"subnet,omitempty"`
+
+	// DeletionPolicy defines the behavior on deletion if the HCloud API is unreachable.
+	// +optional
+	DeletionPolicy *DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// DNS defines the resolver configuration of the server, overrides the cluster wide one.
+	// +optional
+	DNS *DNSSpec `json:"dns,omitempty"`
+
+	// NodeProfileRef is the name of a HetznerNodeProfile in the same namespace whose settings are added to the
+	// user data of the server.
+	// +optional
+	NodeProfileRef string `json:"nodeProfileRef,omitempty"`
+
+	// Volumes are HCloud volumes that are created in the location of the server and attached to it when the
+	// server is created. Volumes cannot be changed afterwards.
+	// +optional
+	Volumes []HCloudVolumeSpec `json:"volumes,omitempty"`
+
+	// EnableBackups turns the automated backups of Hetzner on or off for the server. The setting is reconciled,
+	// so that backups that are turned on or off outside of the controller are changed back. Turning backups off
+	// deletes the existing backups of the server. If it is not set, the backups of the server are not managed.
+	// +optional
+	EnableBackups *bool `json:"enableBackups,omitempty"`
+}
+
+// HCloudVolumeFormat is the filesystem with which a volume is formatted.
+type HCloudVolumeFormat string
+
+const (
+	// HCloudVolumeFormatExt4 formats the volume with ext4.
+	HCloudVolumeFormatExt4 = HCloudVolumeFormat("ext4")
+	// HCloudVolumeFormatXFS formats the volume with xfs.
+	HCloudVolumeFormatXFS = HCloudVolumeFormat("xfs")
+)
+
+// VolumeReclaimPolicy defines what happens with a volume in HCloud when its machine is deleted.
+type VolumeReclaimPolicy string
+
+const (
+	// VolumeReclaimPolicyDelete deletes the volume together with the server.
+	VolumeReclaimPolicyDelete = VolumeReclaimPolicy("Delete")
+	// VolumeReclaimPolicyRetain keeps the volume in HCloud. A machine with the same name adopts it again.
+	VolumeReclaimPolicyRetain = VolumeReclaimPolicy("Retain")
+)
+
+// HCloudVolumeSpec defines an HCloud volume of a server.
+type HCloudVolumeSpec struct {
+	// Name of the volume, unique within the machine. The volume in HCloud is named <machine name>-<name>.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=32
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// Size of the volume in GB.
+	// +kubebuilder:validation:Minimum=10
+	// +kubebuilder:validation:Maximum=10240
+	Size int `json:"size"`
+
+	// Format is the filesystem with which the volume is formatted when it is created.
+	// +kubebuilder:validation:Enum=ext4;xfs
+	// +kubebuilder:default=ext4
+	// +optional
+	Format HCloudVolumeFormat `json:"format,omitempty"`
+
+	// MountPath is the absolute path at which the volume is mounted via cloud-init, e.g. /var/lib/etcd.
+	// If empty, the volume is attached, but not mounted.
+	// +optional
+	MountPath string `json:"mountPath,omitempty"`
+
+	// ReclaimPolicy defines whether the volume is deleted or retained in HCloud when the machine is deleted.
+	// +kubebuilder:validation:Enum=Delete;Retain
+	// +kubebuilder:default=Delete
+	// +optional
+	ReclaimPolicy VolumeReclaimPolicy `json:"reclaimPolicy,omitempty"`
+}
+
+// HCloudVolumeStatus is the status of an HCloud volume of a server.
+type HCloudVolumeStatus struct {
+	// Name of the volume in the spec of the machine.
+	Name string `json:"name"`
+	// ID of the volume in HCloud.
+	ID int `json:"id"`
+	// LinuxDevice is the path of the device of the volume on the server.
+	// +optional
+	LinuxDevice string `json:"linuxDevice,omitempty"`
+}
+
+// DeletionPolicyType defines how deletion is handled if the HCloud API is unreachable.
+type DeletionPolicyType string
+
+const (
+	// DeletionPolicyTypeWait blocks the deletion until the HCloud API is reachable again.
+	DeletionPolicyTypeWait = DeletionPolicyType("Wait")
+	// DeletionPolicyTypeOrphanAfterTimeout releases the finalizer once the HCloud API was unreachable
+	// for longer than the timeout and records the server as orphaned in the HetznerCluster.
+	DeletionPolicyTypeOrphanAfterTimeout = DeletionPolicyType("OrphanAfterTimeout")
+)
+
+// DefaultOrphanTimeout is the default time after which a server is orphaned on deletion
+// if the HCloud API is unreachable and the policy OrphanAfterTimeout is set.
+const DefaultOrphanTimeout = 30 * time.Minute
+
+// DeletionPolicy defines the behavior on deletion if the HCloud API is unreachable.
+type DeletionPolicy struct {
+	// Type of the deletion policy.
+	// +kubebuilder:validation:Enum=Wait;OrphanAfterTimeout
+	// +kubebuilder:default=Wait
+	Type DeletionPolicyType `json:"type"`
+
+	// Timeout is the time the HCloud API has to be unreachable before the server is orphaned.
+	// Only used with type OrphanAfterTimeout. Defaults to 30m.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// GetTimeout returns the configured timeout or the default.
+func (p *DeletionPolicy) GetTimeout() time.Duration {
+	if p.Timeout == nil {
+		return DefaultOrphanTimeout
+	}
+	return p.Timeout.Duration
+}
+
+// AutomaticPlacementGroupSpec defines how machines are grouped into automatic placement groups.
+type AutomaticPlacementGroupSpec struct {
+	// LabelKey is the key of the label of the Machine whose value determines the placement group.
+	// Defaults to the label of the MachineDeployment.
+	// +optional
+	// +kubebuilder:default="cluster.x-k8s.io/deployment-name"
+	LabelKey string `json:"labelKey,omitempty"`
 }
 
 // HCloudMachineStatus defines the observed state of HCloudMachine.
@@ -73,6 +273,40 @@ type HCloudMachineStatus struct {
 	// +optional
 	InstanceState *hcloud.ServerStatus `json:"instanceState,omitempty"`
 
+	// AppliedConfiguration is a snapshot of the inputs that have been used to create the server.
+	// +optional
+	AppliedConfiguration *AppliedConfiguration `json:"appliedConfiguration,omitempty"`
+
+	// Volumes are the HCloud volumes that have been attached to the server.
+	// +optional
+	Volumes []HCloudVolumeStatus `json:"volumes,omitempty"`
+
+	// AppliedLabels are the keys of the labels that the controller has set on the server. With the drift policy
+	// Adopt, labels of the server are only removed if they are listed here.
+	// +optional
+	AppliedLabels []string `json:"appliedLabels,omitempty"`
+
+	// AppliedPublicNetwork is the public network that has last been applied to the server. The public IPs of the
+	// server are only changed if the spec differs from it.
+	// +optional
+	AppliedPublicNetwork *AppliedPublicNetwork `json:"appliedPublicNetwork,omitempty"`
+
+	// KubeletServingCertificate is the last serving certificate that has been issued to the kubelet of the node.
+	// +optional
+	KubeletServingCertificate *CertificateStatus `json:"kubeletServingCertificate,omitempty"`
+
+	// Milestones are the times at which the machine reached the milestones of its provisioning.
+	// +optional
+	Milestones *ProvisioningMilestones `json:"milestones,omitempty"`
+
+	// ISODetached indicates that the server has booted from the ISO of the spec and that the ISO has been detached.
+	// +optional
+	ISODetached bool `json:"isoDetached,omitempty"`
+
+	// RescueMode indicates that the server has been booted into the rescue system because of the rescue annotation.
+	// +optional
+	RescueMode bool `json:"rescueMode,omitempty"`
+
 	// FailureReason will be set in the event that there is a terminal problem
 	// reconciling the Machine and will contain a succinct value suitable
 	// for machine interpretation.
@@ -90,6 +324,15 @@ type HCloudMachineStatus struct {
 	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
 }
 
+// AppliedPublicNetwork is the public network that has been applied to a server.
+type AppliedPublicNetwork struct {
+	// EnableIPv4 tells whether the server has been given a public IPv4.
+	EnableIPv4 bool `json:"enableIPv4"`
+
+	// EnableIPv6 tells whether the server has been given a public IPv6.
+	EnableIPv6 bool `json:"enableIPv6"`
+}
+
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:path=hcloudmachines,scope=Namespaced,categories=cluster-api,shortName=capihcm
 // +kubebuilder:storageversion
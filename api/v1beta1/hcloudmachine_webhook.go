@@ -18,11 +18,14 @@ package v1beta1
 
 import (
 	"fmt"
+	"path"
 	"reflect"
 
 	"github.com/syself/cluster-api-provider-hetzner/pkg/utils"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
@@ -68,6 +71,13 @@ func (r *HCloudMachine) ValidateCreate() error {
 	hcloudmachinelog.V(1).Info("validate create", "name", r.Name)
 	var allErrs field.ErrorList
 
+	allErrs = append(allErrs, validateImage(field.NewPath("spec"), &r.Spec)...)
+	allErrs = append(allErrs, validateDNSSpec(field.NewPath("spec", "dns"), r.Spec.DNS)...)
+	allErrs = append(allErrs, validatePublicNetworkSpec(field.NewPath("spec", "publicNetwork"), r.Spec.PublicNetwork)...)
+	allErrs = append(allErrs, validateVolumes(field.NewPath("spec", "volumes"), r.Spec.Volumes)...)
+	allErrs = append(allErrs, validatePlacementGroup(field.NewPath("spec"), &r.Spec)...)
+	allErrs = append(allErrs, validateAdditionalSSHKeys(field.NewPath("spec", "additionalSSHKeys"), r.Spec.AdditionalSSHKeys)...)
+
 	return aggregateObjErrors(r.GroupVersionKind().GroupKind(), r.Name, allErrs)
 }
 
@@ -82,10 +92,11 @@ func (r *HCloudMachine) ValidateUpdate(old runtime.Object) error {
 
 	var allErrs field.ErrorList
 
-	// Type is immutable
-	if !reflect.DeepEqual(oldM.Spec.Type, r.Spec.Type) {
+	// Type can only be changed within the architecture, as the image of the server is kept
+	if oldM.Spec.Type.Architecture() != r.Spec.Type.Architecture() {
 		allErrs = append(allErrs,
-			field.Invalid(field.NewPath("spec", "type"), r.Spec.Type, "field is immutable"),
+			field.Invalid(field.NewPath("spec", "type"), r.Spec.Type,
+				fmt.Sprintf("cannot change the architecture of the server type from %s", oldM.Spec.Type.Architecture())),
 		)
 	}
 
@@ -96,6 +107,27 @@ func (r *HCloudMachine) ValidateUpdate(old runtime.Object) error {
 		)
 	}
 
+	// ISO is immutable
+	if oldM.Spec.ISO != r.Spec.ISO {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "iso"), r.Spec.ISO, "field is immutable"),
+		)
+	}
+
+	// ImageChannel is immutable
+	if oldM.Spec.ImageChannel != r.Spec.ImageChannel {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "imageChannel"), r.Spec.ImageChannel, "field is immutable"),
+		)
+	}
+
+	// ImageSelector is immutable
+	if !reflect.DeepEqual(oldM.Spec.ImageSelector, r.Spec.ImageSelector) {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "imageSelector"), r.Spec.ImageSelector, "field is immutable"),
+		)
+	}
+
 	// SSHKeys is immutable
 	if !reflect.DeepEqual(oldM.Spec.SSHKeys, r.Spec.SSHKeys) {
 		allErrs = append(allErrs,
@@ -103,6 +135,34 @@ func (r *HCloudMachine) ValidateUpdate(old runtime.Object) error {
 		)
 	}
 
+	// AdditionalSSHKeys is immutable
+	if !reflect.DeepEqual(oldM.Spec.AdditionalSSHKeys, r.Spec.AdditionalSSHKeys) {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "additionalSSHKeys"), r.Spec.AdditionalSSHKeys, "field is immutable"),
+		)
+	}
+
+	// DNS is immutable
+	if !reflect.DeepEqual(oldM.Spec.DNS, r.Spec.DNS) {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "dns"), r.Spec.DNS, "field is immutable"),
+		)
+	}
+
+	// NodeProfileRef is immutable
+	if oldM.Spec.NodeProfileRef != r.Spec.NodeProfileRef {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "nodeProfileRef"), r.Spec.NodeProfileRef, "field is immutable"),
+		)
+	}
+
+	// Subnet is immutable, as the IP of the server in the network cannot be changed
+	if oldM.Spec.Subnet != r.Spec.Subnet {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "subnet"), r.Spec.Subnet, "field is immutable"),
+		)
+	}
+
 	// Placement group name is immutable
 	if !reflect.DeepEqual(oldM.Spec.PlacementGroupName, r.Spec.PlacementGroupName) {
 		allErrs = append(allErrs,
@@ -110,6 +170,40 @@ func (r *HCloudMachine) ValidateUpdate(old runtime.Object) error {
 		)
 	}
 
+	// Automatic placement group is immutable
+	if !reflect.DeepEqual(oldM.Spec.AutomaticPlacementGroup, r.Spec.AutomaticPlacementGroup) {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "automaticPlacementGroup"), r.Spec.AutomaticPlacementGroup, "field is immutable"),
+		)
+	}
+
+	// Placement group selector is immutable
+	if !reflect.DeepEqual(oldM.Spec.PlacementGroupSelector, r.Spec.PlacementGroupSelector) {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "placementGroupSelector"), r.Spec.PlacementGroupSelector, "field is immutable"),
+		)
+	}
+
+	// Volumes are only created and attached when the server is created
+	if !reflect.DeepEqual(oldM.Spec.Volumes, r.Spec.Volumes) {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "volumes"), r.Spec.Volumes, "field is immutable"),
+		)
+	}
+
+	// Existing primary IPs are only assigned when the server is created
+	if !reflect.DeepEqual(oldM.Spec.PublicNetwork.PrimaryIPID(PrimaryIPTypeIPv4), r.Spec.PublicNetwork.PrimaryIPID(PrimaryIPTypeIPv4)) {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "publicNetwork", "primaryIPv4ID"), r.Spec.PublicNetwork.PrimaryIPID(PrimaryIPTypeIPv4), "field is immutable"),
+		)
+	}
+	if !reflect.DeepEqual(oldM.Spec.PublicNetwork.PrimaryIPID(PrimaryIPTypeIPv6), r.Spec.PublicNetwork.PrimaryIPID(PrimaryIPTypeIPv6)) {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "publicNetwork", "primaryIPv6ID"), r.Spec.PublicNetwork.PrimaryIPID(PrimaryIPTypeIPv6), "field is immutable"),
+		)
+	}
+	allErrs = append(allErrs, validatePublicNetworkSpec(field.NewPath("spec", "publicNetwork"), r.Spec.PublicNetwork)...)
+
 	return aggregateObjErrors(r.GroupVersionKind().GroupKind(), r.Name, allErrs)
 }
 
@@ -118,3 +212,141 @@ func (r *HCloudMachine) ValidateDelete() error {
 	hcloudmachinelog.V(1).Info("validate delete", "name", r.Name)
 	return nil
 }
+
+// validatePublicNetworkSpec checks that existing primary IPs are only set for enabled IP families and are not
+// combined with a primary IP selector.
+func validatePublicNetworkSpec(fldPath *field.Path, spec *PublicNetworkSpec) field.ErrorList {
+	if spec == nil {
+		return nil
+	}
+
+	var allErrs field.ErrorList
+	for _, id := range []struct {
+		name    string
+		value   *int
+		enabled bool
+	}{
+		{name: "primaryIPv4ID", value: spec.PrimaryIPv4ID, enabled: spec.EnableIPv4},
+		{name: "primaryIPv6ID", value: spec.PrimaryIPv6ID, enabled: spec.EnableIPv6},
+	} {
+		if id.value == nil {
+			continue
+		}
+		if *id.value <= 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child(id.name), *id.value, "must be a positive ID"))
+		}
+		if !id.enabled {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child(id.name), "the IP family of the primary IP is not enabled"))
+		}
+		if spec.PrimaryIPSelector != nil {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child(id.name), "cannot be combined with primaryIPSelector"))
+		}
+	}
+	return allErrs
+}
+
+// validateImage checks that the image is set in exactly one way, by name, selector or channel, and that the
+// selector and the channel are valid.
+func validateImage(fldPath *field.Path, spec *HCloudMachineSpec) field.ErrorList {
+	var allErrs field.ErrorList
+	switch {
+	case spec.ImageName == "" && spec.ImageSelector == nil && spec.ImageChannel == "":
+		allErrs = append(allErrs, field.Required(fldPath.Child("imageName"), "either imageName, imageSelector or imageChannel has to be set"))
+	case spec.ImageName != "" && spec.ImageSelector != nil:
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("imageSelector"), "cannot be combined with imageName"))
+	case spec.ImageChannel != "" && (spec.ImageName != "" || spec.ImageSelector != nil):
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("imageChannel"), "cannot be combined with imageName or imageSelector"))
+	case spec.ImageChannel != "":
+		for _, msg := range validation.IsValidLabelValue(spec.ImageChannel) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("imageChannel"), spec.ImageChannel, msg))
+		}
+	case spec.ImageSelector != nil:
+		if _, err := utils.HCloudLabelSelector(spec.ImageSelector); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("imageSelector"), spec.ImageSelector, err.Error()))
+		} else if len(spec.ImageSelector.MatchLabels) == 0 && len(spec.ImageSelector.MatchExpressions) == 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("imageSelector"), spec.ImageSelector, "selector must not be empty"))
+		}
+	}
+	return allErrs
+}
+
+// validatePlacementGroup checks that the placement group is chosen in only one way, by name, automatically or by
+// selector, and that the label key and the selector are valid.
+func validatePlacementGroup(fldPath *field.Path, spec *HCloudMachineSpec) field.ErrorList {
+	var allErrs field.ErrorList
+	if spec.AutomaticPlacementGroup != nil {
+		if spec.PlacementGroupName != nil {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("automaticPlacementGroup"),
+				"cannot be combined with placementGroupName"))
+		}
+		if key := spec.AutomaticPlacementGroup.LabelKey; key != "" {
+			for _, msg := range validation.IsQualifiedName(key) {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("automaticPlacementGroup", "labelKey"), key, msg))
+			}
+		}
+	}
+
+	if selector := spec.PlacementGroupSelector; selector != nil {
+		selectorPath := fldPath.Child("placementGroupSelector")
+		if spec.PlacementGroupName != nil || spec.AutomaticPlacementGroup != nil {
+			allErrs = append(allErrs, field.Forbidden(selectorPath,
+				"cannot be combined with placementGroupName and automaticPlacementGroup"))
+		}
+		if len(selector.MatchLabels) == 0 && len(selector.MatchExpressions) == 0 {
+			allErrs = append(allErrs, field.Required(selectorPath, "must select placement groups by at least one label"))
+		}
+		if _, err := metav1.LabelSelectorAsSelector(selector); err != nil {
+			allErrs = append(allErrs, field.Invalid(selectorPath, selector, err.Error()))
+		}
+	}
+	return allErrs
+}
+
+// validateAdditionalSSHKeys checks that every SSH key is referenced either by name or by secret, and only once.
+func validateAdditionalSSHKeys(fldPath *field.Path, sshKeys []SSHKeyReference) field.ErrorList {
+	var allErrs field.ErrorList
+	refs := make(map[string]struct{}, len(sshKeys))
+	for i, sshKey := range sshKeys {
+		if (sshKey.Name == "") == (sshKey.SecretRef == nil) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i), sshKey, "exactly one of name and secretRef has to be set"))
+			continue
+		}
+		ref := "name/" + sshKey.Name
+		if sshKey.SecretRef != nil {
+			ref = fmt.Sprintf("secret/%s/%s", sshKey.SecretRef.Name, sshKey.SecretRef.SSHPublicKeyKey())
+		}
+		if _, found := refs[ref]; found {
+			allErrs = append(allErrs, field.Duplicate(fldPath.Index(i), sshKey))
+		}
+		refs[ref] = struct{}{}
+	}
+	return allErrs
+}
+
+// validateVolumes checks that the names and mount paths of the volumes are unique and that the mount paths are
+// absolute.
+func validateVolumes(fldPath *field.Path, volumes []HCloudVolumeSpec) field.ErrorList {
+	var allErrs field.ErrorList
+	names := make(map[string]struct{}, len(volumes))
+	mountPaths := make(map[string]struct{}, len(volumes))
+	for i, volume := range volumes {
+		if _, found := names[volume.Name]; found {
+			allErrs = append(allErrs, field.Duplicate(fldPath.Index(i).Child("name"), volume.Name))
+		}
+		names[volume.Name] = struct{}{}
+
+		if volume.MountPath == "" {
+			continue
+		}
+		mountPath := path.Clean(volume.MountPath)
+		if !path.IsAbs(mountPath) || mountPath == "/" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("mountPath"), volume.MountPath,
+				"must be an absolute path other than /"))
+		}
+		if _, found := mountPaths[mountPath]; found {
+			allErrs = append(allErrs, field.Duplicate(fldPath.Index(i).Child("mountPath"), volume.MountPath))
+		}
+		mountPaths[mountPath] = struct{}{}
+	}
+	return allErrs
+}
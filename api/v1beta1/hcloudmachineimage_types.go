@@ -0,0 +1,215 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+const (
+	// MachineImageFinalizer allows ReconcileHCloudMachineImage to clean up the temporary build server of
+	// HCloudMachineImage before removing it from the apiserver.
+	MachineImageFinalizer = "hcloudmachineimage.infrastructure.cluster.x-k8s.io"
+
+	// MachineImageNameLabel is the label of the snapshots and build servers in HCloud that stores the name of
+	// the HCloudMachineImage object.
+	MachineImageNameLabel = "caph-machine-image-name"
+
+	// MachineImageUIDLabel is the label of the snapshots and build servers in HCloud that stores the UID of
+	// the HCloudMachineImage object.
+	MachineImageUIDLabel = "caph-machine-image-uid"
+
+	// DefaultMachineImageBuildTimeout is the default time after which a build whose server did not power off fails.
+	DefaultMachineImageBuildTimeout = 30 * time.Minute
+)
+
+// MachineImagePhase is the phase of the build of an HCloudMachineImage.
+type MachineImagePhase string
+
+const (
+	// MachineImagePhaseBuilding means that the build server runs the provisioning script.
+	MachineImagePhaseBuilding = MachineImagePhase("Building")
+	// MachineImagePhaseSnapshotting means that the snapshot of the powered off build server is taken.
+	MachineImagePhaseSnapshotting = MachineImagePhase("Snapshotting")
+	// MachineImagePhaseReady means that the last build succeeded and its snapshot is available.
+	MachineImagePhaseReady = MachineImagePhase("Ready")
+	// MachineImagePhaseFailed means that the last build failed.
+	MachineImagePhaseFailed = MachineImagePhase("Failed")
+)
+
+// HCloudMachineImageSpec defines the desired state of HCloudMachineImage.
+type HCloudMachineImageSpec struct {
+	// HetznerClusterRef is the name of the HetznerCluster in the same namespace whose
+	// HCloud token is used to build the snapshots. The HCloud SSH keys of the cluster are
+	// added to the build server.
+	HetznerClusterRef string `json:"hetznerClusterRef"`
+
+	// BaseImage is the name of the image the build server is created from, e.g. ubuntu-22.04.
+	// +kubebuilder:validation:MinLength=1
+	BaseImage string `json:"baseImage"`
+
+	// ServerType of the build server. The snapshot can only be used for servers with a disk
+	// that is at least as large as the one of this type and with the same architecture.
+	// +kubebuilder:default=cpx11
+	// +optional
+	ServerType string `json:"serverType,omitempty"`
+
+	// Location of the build server. Snapshots are available in all locations.
+	// +kubebuilder:default=fsn1
+	// +optional
+	Location Region `json:"location,omitempty"`
+
+	// Script is the shell script that provisions the build server. It runs once as root on the
+	// first boot. The server is powered off after the script succeeded, and the snapshot is taken afterwards.
+	// +kubebuilder:validation:MinLength=1
+	Script string `json:"script"`
+
+	// Labels are set on the snapshots in HCloud, so that HCloudMachines can select them with an imageSelector.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Description of the snapshots. Defaults to the name of this object.
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// Timeout is the time after which a build fails whose server did not power off. Defaults to 30m.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// RebuildInterval is the interval in which a new snapshot is built, e.g. to pick up security updates.
+	// If not set, a new snapshot is only built if the spec changes.
+	// +optional
+	RebuildInterval *metav1.Duration `json:"rebuildInterval,omitempty"`
+
+	// SnapshotsToKeep is the number of snapshots of this object that are kept in HCloud. Older snapshots
+	// are deleted after a successful build. If not set, no snapshot is deleted.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	SnapshotsToKeep *int `json:"snapshotsToKeep,omitempty"`
+}
+
+// HCloudMachineImageStatus defines the observed state of HCloudMachineImage.
+type HCloudMachineImageStatus struct {
+	// Ready is true when a snapshot has been built.
+	// +optional
+	Ready bool `json:"ready"`
+
+	// Phase is the phase of the last or current build.
+	// +optional
+	Phase MachineImagePhase `json:"phase,omitempty"`
+
+	// SnapshotID is the ID of the latest snapshot that has been built successfully.
+	// +optional
+	SnapshotID int `json:"snapshotID,omitempty"`
+
+	// Build is the build that is in progress.
+	// +optional
+	Build *HCloudMachineImageBuild `json:"build,omitempty"`
+
+	// LastBuildTime is the time when the last finished build started.
+	// +optional
+	LastBuildTime *metav1.Time `json:"lastBuildTime,omitempty"`
+
+	// ObservedGeneration is the generation of the spec of the last finished build.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions defines current service state of the HCloudMachineImage.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+}
+
+// HCloudMachineImageBuild describes a build that is in progress.
+type HCloudMachineImageBuild struct {
+	// ServerID is the ID of the temporary build server.
+	ServerID int `json:"serverID"`
+
+	// SnapshotID is the ID of the snapshot that is being taken.
+	// +optional
+	SnapshotID int `json:"snapshotID,omitempty"`
+
+	// Generation is the generation of the spec that is built.
+	Generation int64 `json:"generation"`
+
+	// StartTime is the time when the build started.
+	StartTime metav1.Time `json:"startTime"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=hcloudmachineimages,scope=Namespaced,categories=cluster-api,shortName=capihcmi
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Base image",type="string",JSONPath=".spec.baseImage",description="Image the snapshots are built from"
+// +kubebuilder:printcolumn:name="Snapshot",type="integer",JSONPath=".status.snapshotID",description="ID of the latest snapshot"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="Phase of the build"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.ready",description="Machine image ready status"
+// +k8s:defaulter-gen=true
+
+// HCloudMachineImage is the Schema for the hcloudmachineimages API.
+type HCloudMachineImage struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   HCloudMachineImageSpec   `json:"spec,omitempty"`
+	Status HCloudMachineImageStatus `json:"status,omitempty"`
+}
+
+// GetConditions returns the observations of the operational state of the HCloudMachineImage resource.
+func (r *HCloudMachineImage) GetConditions() clusterv1.Conditions {
+	return r.Status.Conditions
+}
+
+// SetConditions sets the underlying service state of the HCloudMachineImage to the predescribed clusterv1.Conditions.
+func (r *HCloudMachineImage) SetConditions(conditions clusterv1.Conditions) {
+	r.Status.Conditions = conditions
+}
+
+// BuildTimeout returns the time after which a build fails.
+func (r *HCloudMachineImage) BuildTimeout() time.Duration {
+	if r.Spec.Timeout == nil {
+		return DefaultMachineImageBuildTimeout
+	}
+	return r.Spec.Timeout.Duration
+}
+
+// NextBuildTime returns the time when the next snapshot has to be built. The zero time means immediately
+// and nil means never.
+func (r *HCloudMachineImage) NextBuildTime() *time.Time {
+	if r.Status.LastBuildTime == nil || r.Status.ObservedGeneration != r.Generation {
+		return &time.Time{}
+	}
+	if r.Spec.RebuildInterval == nil {
+		return nil
+	}
+	next := r.Status.LastBuildTime.Add(r.Spec.RebuildInterval.Duration)
+	return &next
+}
+
+//+kubebuilder:object:root=true
+
+// HCloudMachineImageList contains a list of HCloudMachineImage.
+type HCloudMachineImageList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []HCloudMachineImage `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&HCloudMachineImage{}, &HCloudMachineImageList{})
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/syself/cluster-api-provider-hetzner/pkg/utils"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var hcloudmachineimagelog = utils.GetDefaultLogger("info").WithName("hcloudmachineimage-resource")
+
+// SetupWebhookWithManager initializes webhook manager for HCloudMachineImage.
+func (r *HCloudMachineImage) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-infrastructure-cluster-x-k8s-io-v1beta1-hcloudmachineimage,mutating=true,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=hcloudmachineimages,verbs=create;update,versions=v1beta1,name=mutation.hcloudmachineimage.infrastructure.cluster.x-k8s.io,admissionReviewVersions={v1,v1beta1}
+
+var _ webhook.Defaulter = &HCloudMachineImage{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type.
+func (r *HCloudMachineImage) Default() {
+	if r.Spec.ServerType == "" {
+		r.Spec.ServerType = "cpx11"
+	}
+	if r.Spec.Location == "" {
+		r.Spec.Location = Region("fsn1")
+	}
+}
+
+//+kubebuilder:webhook:path=/validate-infrastructure-cluster-x-k8s-io-v1beta1-hcloudmachineimage,mutating=false,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=hcloudmachineimages,verbs=create;update,versions=v1beta1,name=validation.hcloudmachineimage.infrastructure.cluster.x-k8s.io,admissionReviewVersions={v1,v1beta1}
+
+var _ webhook.Validator = &HCloudMachineImage{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
+func (r *HCloudMachineImage) ValidateCreate() error {
+	hcloudmachineimagelog.V(1).Info("validate create", "name", r.Name)
+	allErrs := r.validateDurations()
+	return aggregateObjErrors(r.GroupVersionKind().GroupKind(), r.Name, allErrs)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+func (r *HCloudMachineImage) ValidateUpdate(old runtime.Object) error {
+	hcloudmachineimagelog.V(1).Info("validate update", "name", r.Name)
+
+	oldM, ok := old.(*HCloudMachineImage)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected an HCloudMachineImage but got a %T", old))
+	}
+
+	allErrs := r.validateDurations()
+
+	// HetznerClusterRef is immutable
+	if !reflect.DeepEqual(oldM.Spec.HetznerClusterRef, r.Spec.HetznerClusterRef) {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "hetznerClusterRef"), r.Spec.HetznerClusterRef, "field is immutable"),
+		)
+	}
+
+	return aggregateObjErrors(r.GroupVersionKind().GroupKind(), r.Name, allErrs)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
+func (r *HCloudMachineImage) ValidateDelete() error {
+	hcloudmachineimagelog.V(1).Info("validate delete", "name", r.Name)
+	return nil
+}
+
+func (r *HCloudMachineImage) validateDurations() field.ErrorList {
+	var allErrs field.ErrorList
+	if r.Spec.Timeout != nil && r.Spec.Timeout.Duration <= 0 {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "timeout"), r.Spec.Timeout.Duration.String(), "must be positive"),
+		)
+	}
+	if r.Spec.RebuildInterval != nil && r.Spec.RebuildInterval.Duration < r.BuildTimeout() {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "rebuildInterval"), r.Spec.RebuildInterval.Duration.String(), "must not be shorter than the timeout"),
+		)
+	}
+	return allErrs
+}
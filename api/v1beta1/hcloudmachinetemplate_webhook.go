@@ -47,8 +47,15 @@ type HCloudMachineTemplateWebhook struct{}
 var _ webhook.CustomValidator = &HCloudMachineTemplateWebhook{}
 
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
-func (r *HCloudMachineTemplateWebhook) ValidateCreate(_ context.Context, _ runtime.Object) error {
-	return nil
+func (r *HCloudMachineTemplateWebhook) ValidateCreate(_ context.Context, raw runtime.Object) error {
+	hcloudMachineTemplate, ok := raw.(*HCloudMachineTemplate)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a HCloudMachineTemplate but got a %T", raw))
+	}
+
+	allErrs := validatePlacementGroup(field.NewPath("spec", "template", "spec"), &hcloudMachineTemplate.Spec.Template.Spec)
+
+	return aggregateObjErrors(hcloudMachineTemplate.GroupVersionKind().GroupKind(), hcloudMachineTemplate.Name, allErrs)
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
@@ -72,6 +79,7 @@ func (r *HCloudMachineTemplateWebhook) ValidateUpdate(ctx context.Context, oldRa
 	if !topology.ShouldSkipImmutabilityChecks(req, newHCloudMachineTemplate) && !reflect.DeepEqual(newHCloudMachineTemplate.Spec, oldHCloudMachineTemplate.Spec) {
 		allErrs = append(allErrs, field.Invalid(field.NewPath("spec"), newHCloudMachineTemplate, "HCloudMachineTemplate.Spec is immutable"))
 	}
+	allErrs = append(allErrs, validatePlacementGroup(field.NewPath("spec", "template", "spec"), &newHCloudMachineTemplate.Spec.Template.Spec)...)
 
 	return aggregateObjErrors(newHCloudMachineTemplate.GroupVersionKind().GroupKind(), newHCloudMachineTemplate.Name, allErrs)
 }
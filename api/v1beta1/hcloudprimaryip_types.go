@@ -0,0 +1,175 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+const (
+	// PrimaryIPFinalizer allows ReconcileHCloudPrimaryIP to clean up the HCloud
+	// primary IP associated with HCloudPrimaryIP before removing it from the
+	// apiserver.
+	PrimaryIPFinalizer = "hcloudprimaryip.infrastructure.cluster.x-k8s.io"
+
+	// PrimaryIPNameLabel is the label of the primary IP in HCloud that stores the name of the HCloudPrimaryIP object.
+	PrimaryIPNameLabel = "caph-primary-ip-name"
+)
+
+// PrimaryIPType defines the IP family of a primary IP.
+type PrimaryIPType string
+
+const (
+	// PrimaryIPTypeIPv4 is an IPv4 primary IP.
+	PrimaryIPTypeIPv4 = PrimaryIPType("ipv4")
+	// PrimaryIPTypeIPv6 is an IPv6 primary IP.
+	PrimaryIPTypeIPv6 = PrimaryIPType("ipv6")
+)
+
+// PrimaryIPReclaimPolicy defines what happens with the primary IP in HCloud when the HCloudPrimaryIP is deleted.
+type PrimaryIPReclaimPolicy string
+
+const (
+	// PrimaryIPReclaimPolicyRetain keeps the primary IP in HCloud.
+	PrimaryIPReclaimPolicyRetain = PrimaryIPReclaimPolicy("Retain")
+	// PrimaryIPReclaimPolicyDelete deletes the primary IP in HCloud.
+	PrimaryIPReclaimPolicyDelete = PrimaryIPReclaimPolicy("Delete")
+)
+
+// HCloudPrimaryIPSpec defines the desired state of HCloudPrimaryIP.
+type HCloudPrimaryIPSpec struct {
+	// HetznerClusterRef is the name of the HetznerCluster in the same namespace whose
+	// HCloud token is used to manage the primary IP.
+	HetznerClusterRef string `json:"hetznerClusterRef"`
+
+	// Type is the IP family of the primary IP.
+	// +kubebuilder:validation:Enum=ipv4;ipv6
+	Type PrimaryIPType `json:"type"`
+
+	// Datacenter in which the primary IP is created, e.g. fsn1-dc14. Servers using the
+	// primary IP have to be in the same datacenter. Not needed if an existing primary IP is adopted
+	// by its ID or by the name of this object. Without it, no primary IP is created.
+	// +optional
+	Datacenter string `json:"datacenter,omitempty"`
+
+	// ID of an existing primary IP in HCloud that should be adopted. If not set, a primary IP
+	// with the name of this object is adopted if it exists, otherwise a new one is created.
+	// +optional
+	ID *int `json:"id,omitempty"`
+
+	// Labels are set on the primary IP in HCloud.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// ReclaimPolicy defines what happens with the primary IP in HCloud if this object is deleted.
+	// The primary IP is never deleted together with a machine that uses it.
+	// +kubebuilder:validation:Enum=Retain;Delete
+	// +kubebuilder:default=Retain
+	// +optional
+	ReclaimPolicy PrimaryIPReclaimPolicy `json:"reclaimPolicy,omitempty"`
+
+	// ConsumerRef references the HCloudMachine that uses the primary IP. It is set and removed by the
+	// HCloudMachine controller. A replacement machine picks up the primary IP as soon as it is released.
+	// +optional
+	ConsumerRef *corev1.ObjectReference `json:"consumerRef,omitempty"`
+}
+
+// HCloudPrimaryIPStatus defines the observed state of HCloudPrimaryIP.
+type HCloudPrimaryIPStatus struct {
+	// Ready is true when the primary IP exists in HCloud.
+	// +optional
+	Ready bool `json:"ready"`
+
+	// ID of the primary IP in HCloud.
+	// +optional
+	ID int `json:"id,omitempty"`
+
+	// IP is the address of the primary IP. For IPv6 it is the first address of the network.
+	// +optional
+	IP string `json:"ip,omitempty"`
+
+	// Datacenter of the primary IP.
+	// +optional
+	Datacenter string `json:"datacenter,omitempty"`
+
+	// Location of the primary IP.
+	// +optional
+	Location Region `json:"location,omitempty"`
+
+	// AssigneeID is the ID of the server the primary IP is assigned to.
+	// +optional
+	AssigneeID int `json:"assigneeID,omitempty"`
+
+	// Conditions defines current service state of the HCloudPrimaryIP.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=hcloudprimaryips,scope=Namespaced,categories=cluster-api,shortName=capihcpip
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Type",type="string",JSONPath=".spec.type",description="IP family of the primary IP"
+// +kubebuilder:printcolumn:name="IP",type="string",JSONPath=".status.ip",description="Address of the primary IP"
+// +kubebuilder:printcolumn:name="Datacenter",type="string",JSONPath=".status.datacenter",description="Datacenter of the primary IP"
+// +kubebuilder:printcolumn:name="Consumer",type="string",JSONPath=".spec.consumerRef.name",description="HCloudMachine using the primary IP"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.ready",description="Primary IP ready status"
+// +k8s:defaulter-gen=true
+
+// HCloudPrimaryIP is the Schema for the hcloudprimaryips API.
+type HCloudPrimaryIP struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   HCloudPrimaryIPSpec   `json:"spec,omitempty"`
+	Status HCloudPrimaryIPStatus `json:"status,omitempty"`
+}
+
+// GetConditions returns the observations of the operational state of the HCloudPrimaryIP resource.
+func (r *HCloudPrimaryIP) GetConditions() clusterv1.Conditions {
+	return r.Status.Conditions
+}
+
+// SetConditions sets the underlying service state of the HCloudPrimaryIP to the predescribed clusterv1.Conditions.
+func (r *HCloudPrimaryIP) SetConditions(conditions clusterv1.Conditions) {
+	r.Status.Conditions = conditions
+}
+
+// IsAvailableFor returns whether the primary IP can be used by the given HCloudMachine.
+func (r *HCloudPrimaryIP) IsAvailableFor(hcloudMachine *HCloudMachine) bool {
+	if !r.Status.Ready || !r.DeletionTimestamp.IsZero() {
+		return false
+	}
+	if r.Spec.ConsumerRef == nil {
+		return true
+	}
+	return r.Spec.ConsumerRef.Name == hcloudMachine.Name && r.Spec.ConsumerRef.Namespace == hcloudMachine.Namespace
+}
+
+//+kubebuilder:object:root=true
+
+// HCloudPrimaryIPList contains a list of HCloudPrimaryIP.
+type HCloudPrimaryIPList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []HCloudPrimaryIP `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&HCloudPrimaryIP{}, &HCloudPrimaryIPList{})
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/syself/cluster-api-provider-hetzner/pkg/utils"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var hcloudprimaryiplog = utils.GetDefaultLogger("info").WithName("hcloudprimaryip-resource")
+
+// SetupWebhookWithManager initializes webhook manager for HCloudPrimaryIP.
+func (r *HCloudPrimaryIP) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-infrastructure-cluster-x-k8s-io-v1beta1-hcloudprimaryip,mutating=true,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=hcloudprimaryips,verbs=create;update,versions=v1beta1,name=mutation.hcloudprimaryip.infrastructure.cluster.x-k8s.io,admissionReviewVersions={v1,v1beta1}
+
+var _ webhook.Defaulter = &HCloudPrimaryIP{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type.
+func (r *HCloudPrimaryIP) Default() {
+	if r.Spec.ReclaimPolicy == "" {
+		r.Spec.ReclaimPolicy = PrimaryIPReclaimPolicyRetain
+	}
+}
+
+//+kubebuilder:webhook:path=/validate-infrastructure-cluster-x-k8s-io-v1beta1-hcloudprimaryip,mutating=false,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=hcloudprimaryips,verbs=create;update,versions=v1beta1,name=validation.hcloudprimaryip.infrastructure.cluster.x-k8s.io,admissionReviewVersions={v1,v1beta1}
+
+var _ webhook.Validator = &HCloudPrimaryIP{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
+func (r *HCloudPrimaryIP) ValidateCreate() error {
+	hcloudprimaryiplog.V(1).Info("validate create", "name", r.Name)
+	return nil
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+func (r *HCloudPrimaryIP) ValidateUpdate(old runtime.Object) error {
+	hcloudprimaryiplog.V(1).Info("validate update", "name", r.Name)
+
+	oldP, ok := old.(*HCloudPrimaryIP)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected an HCloudPrimaryIP but got a %T", old))
+	}
+
+	var allErrs field.ErrorList
+
+	// HetznerClusterRef is immutable
+	if !reflect.DeepEqual(oldP.Spec.HetznerClusterRef, r.Spec.HetznerClusterRef) {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "hetznerClusterRef"), r.Spec.HetznerClusterRef, "field is immutable"),
+		)
+	}
+
+	// Type is immutable
+	if !reflect.DeepEqual(oldP.Spec.Type, r.Spec.Type) {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "type"), r.Spec.Type, "field is immutable"),
+		)
+	}
+
+	// Datacenter is immutable
+	if !reflect.DeepEqual(oldP.Spec.Datacenter, r.Spec.Datacenter) {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "datacenter"), r.Spec.Datacenter, "field is immutable"),
+		)
+	}
+
+	// ID is immutable
+	if !reflect.DeepEqual(oldP.Spec.ID, r.Spec.ID) {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "id"), r.Spec.ID, "field is immutable"),
+		)
+	}
+
+	return aggregateObjErrors(r.GroupVersionKind().GroupKind(), r.Name, allErrs)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
+func (r *HCloudPrimaryIP) ValidateDelete() error {
+	hcloudprimaryiplog.V(1).Info("validate delete", "name", r.Name)
+	return nil
+}
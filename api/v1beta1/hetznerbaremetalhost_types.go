@@ -23,7 +23,9 @@ import (
 	"fmt"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
 
@@ -36,6 +38,28 @@ const (
 	// HostAnnotation is the key for an annotation that should go on a HetznerBareMetalMachine to
 	// reference what HetznerBareMetalHost it corresponds to.
 	HostAnnotation = "infrastructure.cluster.x-k8s.io/HetznerBareMetalHost"
+
+	// ForceDeleteAnnotation is the key for an annotation that allows to delete a HetznerBareMetalHost
+	// even though it is consumed by a HetznerBareMetalMachine.
+	ForceDeleteAnnotation = "force-delete.hetznerbaremetalhost.infrastructure.cluster.x-k8s.io"
+
+	// ForensicHoldAnnotation is the key for an annotation that prevents that the disks of a HetznerBareMetalHost
+	// are wiped or reimaged when its machine is deleted. The host is quarantined instead, until the annotation
+	// is removed. The value can describe the reason, e.g. the incident.
+	ForensicHoldAnnotation = "forensic-hold.hetznerbaremetalhost.infrastructure.cluster.x-k8s.io"
+
+	// ApproveReprovisionAnnotation is the key for an annotation that approves that a HetznerBareMetalHost with
+	// reprovisionApproval Required is reimaged with another image or partitioning than it has been provisioned with.
+	// It is removed when the reprovisioning starts, so that every reprovisioning has to be approved.
+	ApproveReprovisionAnnotation = "approve-reprovision.hetznerbaremetalhost.infrastructure.cluster.x-k8s.io"
+
+	// ApproveDeprovisionAnnotation is the key for an annotation that confirms that removing the installImage of a
+	// HetznerBareMetalHost that is being provisioned or has been provisioned deprovisions the host. It is removed
+	// when the deprovisioning starts.
+	ApproveDeprovisionAnnotation = "approve-deprovision.hetznerbaremetalhost.infrastructure.cluster.x-k8s.io"
+
+	// RackLabel is the key of the label with the rack of the host that is set on its node.
+	RackLabel = "infrastructure.cluster.x-k8s.io/rack"
 )
 
 // RootDeviceHints holds the hints for specifying the storage location
@@ -109,8 +133,103 @@ const (
 
 	// FatalError is a fatal error that triggers a failureMessage in the bm machine.
 	FatalError ErrorType = "fatal error"
+
+	// FencingError is an error condition indicating that the host is still running after it has been fenced.
+	// It does not fail the bm machine, as its node must not be replaced while the host might still run.
+	FencingError ErrorType = "fencing error"
 )
 
+// FailureClass classifies the failures of the actions of a HetznerBareMetalHost. It determines how
+// failed actions are retried and whether the machine that uses the host fails.
+type FailureClass string
+
+const (
+	// FailureClassTransient is a failure that is expected to resolve itself, e.g. a slow reboot.
+	FailureClassTransient FailureClass = "Transient"
+	// FailureClassRateLimited is a failure caused by the rate limit of the Robot or HCloud API.
+	FailureClassRateLimited FailureClass = "RateLimited"
+	// FailureClassPermanentConfiguration is a failure that persists until the configuration is changed,
+	// e.g. missing secrets or root device hints that match no storage device.
+	FailureClassPermanentConfiguration FailureClass = "PermanentConfiguration"
+	// FailureClassPermanentHardware is a failure of the server itself, e.g. failing hardware reboots.
+	FailureClassPermanentHardware FailureClass = "PermanentHardware"
+)
+
+// ActionHistoryResult is the result of an action in the action history of a HetznerBareMetalHost.
+type ActionHistoryResult string
+
+const (
+	// ActionHistoryInProgress is an action that has not completed yet.
+	ActionHistoryInProgress ActionHistoryResult = "InProgress"
+	// ActionHistoryError is an action that returned an error and is retried.
+	ActionHistoryError ActionHistoryResult = "Error"
+	// ActionHistoryCompleted is an action that has completed.
+	ActionHistoryCompleted ActionHistoryResult = "Completed"
+	// ActionHistoryFailed is an action that has failed and set an error type on the host.
+	ActionHistoryFailed ActionHistoryResult = "Failed"
+	// ActionHistoryInterrupted is an action that has not finished before the host changed to another state,
+	// e.g. because the host has been deleted.
+	ActionHistoryInterrupted ActionHistoryResult = "Interrupted"
+)
+
+// PostInstallPhase is the phase of a post-install step.
+type PostInstallPhase string
+
+const (
+	// PostInstallPhaseApplying means the script of the step is run.
+	PostInstallPhaseApplying PostInstallPhase = "Applying"
+	// PostInstallPhaseRebooting means the host is rebooted after the script.
+	PostInstallPhaseRebooting PostInstallPhase = "Rebooting"
+	// PostInstallPhaseVerifying means the verify script of the step is run.
+	PostInstallPhaseVerifying PostInstallPhase = "Verifying"
+)
+
+// PostInstallStepStatus is the progress of a post-install step.
+type PostInstallStepStatus struct {
+	// Index is the index of the step in the post-install steps of the image.
+	Index int `json:"index"`
+
+	// Name is the name of the step.
+	Name string `json:"name"`
+
+	// Phase is the phase of the step.
+	Phase PostInstallPhase `json:"phase"`
+
+	// BootID is the boot ID of the operating system before the reboot, which tells the rebooted system apart from
+	// the one that is still shutting down.
+	// +optional
+	BootID string `json:"bootID,omitempty"`
+}
+
+// ActionHistoryEntry is an action of a provisioning state of a HetznerBareMetalHost. Consecutive reconciles of the
+// action of the same state are recorded in the same entry.
+type ActionHistoryEntry struct {
+	// State is the provisioning state whose action ran.
+	State ProvisioningState `json:"state"`
+
+	// Result is the result of the action.
+	Result ActionHistoryResult `json:"result"`
+
+	// Started is the time of the first reconcile of the action.
+	Started metav1.Time `json:"started"`
+
+	// Finished is the time when the action completed, failed or was interrupted.
+	// +optional
+	Finished *metav1.Time `json:"finished,omitempty"`
+
+	// Duration is the time from the start until the end of the action.
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+
+	// Errors is the number of errors that the action returned before it finished.
+	// +optional
+	Errors int `json:"errors,omitempty"`
+
+	// Message is the last error of the action.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
 const (
 	// ErrorMessageMissingRootDeviceHints specifies the error message when no root device hints are specified.
 	ErrorMessageMissingRootDeviceHints string = "no root device hints specified"
@@ -120,8 +239,12 @@ const (
 	ErrorMessageMissingRescueSSHSecret string = "could not find RescueSSHSecret"
 	// ErrorMessageMissingOSSSHSecret specifies the error message when no OSSSH secret was found.
 	ErrorMessageMissingOSSSHSecret string = "could not find OSSSHSecret"
+	// ErrorMessageMissingImageDownloadSecret specifies the error message when the secret to download the image was not found.
+	ErrorMessageMissingImageDownloadSecret string = "could not find image download secret"
 	// ErrorMessageMissingOrInvalidSecretData specifies the error message when no data in secret is missing or invalid.
 	ErrorMessageMissingOrInvalidSecretData string = "invalid or not specified information in secret"
+	// ErrorMessageMissingPrivateIP specifies the error message when private provisioning is used, but the host has no private IP.
+	ErrorMessageMissingPrivateIP string = "no private IP specified for private provisioning"
 )
 
 // ProvisioningState defines the states the provisioner will report the host has having.
@@ -140,6 +263,9 @@ const (
 	// StateImageInstalling means we install a new image.
 	StateImageInstalling ProvisioningState = "image-installing"
 
+	// StatePostInstalling means we are running the post-install steps in the installed operating system.
+	StatePostInstalling ProvisioningState = "post-installing"
+
 	// StateProvisioning means we are sending userData to the host and boot the machine.
 	StateProvisioning ProvisioningState = "provisioning"
 
@@ -152,6 +278,10 @@ const (
 	// StateDeprovisioning means we are removing all machine-specific information from host.
 	StateDeprovisioning ProvisioningState = "deprovisioning"
 
+	// StateQuarantined means the machine of the host has been deleted, but the host is kept as it is because of
+	// the forensic hold annotation. It is not used for other machines until the annotation is removed.
+	StateQuarantined ProvisioningState = "quarantined"
+
 	// StateDeleting means we are deleting the host.
 	StateDeleting ProvisioningState = "deleting"
 )
@@ -168,6 +298,8 @@ const (
 	RebootTypeSoftware RebootType = "sw"
 	// RebootTypeManual defines the manual reboot.
 	RebootTypeManual RebootType = "man"
+	// RebootTypePowerLong defines the long press of the power button, which forces the server off.
+	RebootTypePowerLong RebootType = "power_long"
 )
 
 // RebootAnnotationArguments defines the arguments of the RebootAnnotation type.
@@ -198,30 +330,141 @@ type HetznerBareMetalHostSpec struct {
 	// +optional
 	Description string `json:"description,omitempty"`
 
+	// Reservation restricts the HetznerBareMetalMachines that are allowed to consume the host.
+	// If it is set, the host is only chosen by machines that match the reservation, even if
+	// the host selector of other machines matches the host.
+	// +optional
+	Reservation *HostReservation `json:"reservation,omitempty"`
+
+	// PrivateIP is the IP address of the server in a private network, e.g. a vSwitch or a VPN.
+	// It has to be configured by the installed operating system, e.g. via the postInstallScript.
+	// It is required if the host is consumed by a HetznerBareMetalMachine with private provisioning.
+	// +optional
+	PrivateIP string `json:"privateIP,omitempty"`
+
+	// Rack is the rack of the server. Robot does not expose it, so it has to be given with the
+	// inventory. It is set as label on the node of the host, so that workloads can be spread over racks.
+	// +optional
+	Rack string `json:"rack,omitempty"`
+
+	// ReprovisionApproval defines whether reimaging the host with another image or partitioning than it has been
+	// provisioned with has to be approved. With Required, the changes are listed in status.pendingReprovision and
+	// the host waits for the annotation approve-reprovision.hetznerbaremetalhost.infrastructure.cluster.x-k8s.io.
+	// Defaults to Automatic.
+	// +optional
+	ReprovisionApproval ReprovisionApprovalPolicy `json:"reprovisionApproval,omitempty"`
+
 	// Status contains all status information. DO NOT EDIT!!!
 	// +optional
 	Status ControllerGeneratedStatus `json:"status,omitempty"`
 }
 
-// ControllerGeneratedStatus contains all status information which is important to persist.
+// ReprovisionApprovalPolicy defines whether reimaging a host with another image or partitioning has to be approved.
+// +kubebuilder:validation:Enum=Automatic;Required
+type ReprovisionApprovalPolicy string
+
+const (
+	// ReprovisionApprovalAutomatic reimages the host without approval. The changes are reported with an event.
+	ReprovisionApprovalAutomatic ReprovisionApprovalPolicy = "Automatic"
+	// ReprovisionApprovalRequired waits for the approval annotation before the host is reimaged.
+	ReprovisionApprovalRequired ReprovisionApprovalPolicy = "Required"
+)
+
+// ReprovisionChange is a change of the image or partitioning that requires reimaging a host.
+type ReprovisionChange struct {
+	// Field is the changed field of installImage, e.g. image.url or partitions.
+	Field string `json:"field"`
+
+	// Installed is the value that is installed on the host.
+	// +optional
+	Installed string `json:"installed,omitempty"`
+
+	// Desired is the value that would be installed.
+	// +optional
+	Desired string `json:"desired,omitempty"`
+}
+
+// HostReservation defines which HetznerBareMetalMachines are allowed to consume a host.
+// A machine is allowed to consume the host if it matches either the names or the selector.
+type HostReservation struct {
+	// MachineNames is a list of names of HetznerBareMetalMachines in the namespace of the host.
+	// +optional
+	MachineNames []string `json:"machineNames,omitempty"`
+
+	// MachineSelector selects HetznerBareMetalMachines via their labels. To reserve a host for a
+	// MachineDeployment, use the label cluster.x-k8s.io/deployment-name.
+	// +optional
+	MachineSelector *metav1.LabelSelector `json:"machineSelector,omitempty"`
+}
+
+// ControllerGeneratedStatus contains all status information which is important to persist. The fields that describe
+// how the host is provisioned are set by the HetznerBareMetalMachine controller, all others by the host controller.
+// Both write only their own fields, the host controller with server-side apply.
 type ControllerGeneratedStatus struct {
 	// HetznerClusterRef is the name of the HetznerCluster object which is
 	// needed as some necessary information is stored there, e.g. the hrobot password
-	HetznerClusterRef string `json:"hetznerClusterRef"`
+	// +optional
+	HetznerClusterRef string `json:"hetznerClusterRef,omitempty"`
 
 	// UserData holds the reference to the Secret containing the user
 	// data to be passed to the host before it boots.
 	// +optional
 	UserData *corev1.SecretReference `json:"userData,omitempty"`
 
+	// UserDataHash is the hash of the user data that has been written to the host.
+	// +optional
+	UserDataHash string `json:"userDataHash,omitempty"`
+
+	// BootstrapDataChangePolicy defines what happens if the user data changes after it has been written to the host.
+	// +optional
+	BootstrapDataChangePolicy BootstrapDataChangePolicy `json:"bootstrapDataChangePolicy,omitempty"`
+
+	// AppliedConfiguration is a snapshot of the inputs that have been used to provision the host.
+	// +optional
+	AppliedConfiguration *AppliedConfiguration `json:"appliedConfiguration,omitempty"`
+
+	// DNS is the resolver configuration that is added to the user data.
+	// +optional
+	DNS *DNSSpec `json:"dns,omitempty"`
+
+	// NodeProfile is the spec of the HetznerNodeProfile of the machine that is added to the user data. It is
+	// copied when the host is claimed, so that changes of the profile do not affect the provisioning.
+	// +optional
+	NodeProfile *HetznerNodeProfileSpec `json:"nodeProfile,omitempty"`
+
 	// InstallImage is the configuration which is used for the autosetup configuration for installing an OS via InstallImage.
 	// +optional
 	InstallImage *InstallImage `json:"installImage,omitempty"`
 
+	// InstalledImage is the image and partitioning that have been installed on the host, with the templates of
+	// the image executed. It is kept when the host is released, so that the changes of the next provisioning can
+	// be reported.
+	// +optional
+	InstalledImage *InstallImage `json:"installedImage,omitempty"`
+
+	// PendingReprovision lists the changes of the image and partitioning that wait for the approval of the
+	// reprovisioning.
+	// +optional
+	PendingReprovision []ReprovisionChange `json:"pendingReprovision,omitempty"`
+
+	// KubernetesVersion is the Kubernetes version of the machine that uses the host. It can be used in the
+	// templates of InstallImage.
+	// +optional
+	KubernetesVersion string `json:"kubernetesVersion,omitempty"`
+
+	// ProvisioningChecks are the checks that have to succeed after cloud init.
+	// +optional
+	ProvisioningChecks *ProvisioningChecks `json:"provisioningChecks,omitempty"`
+
 	// StatusHardwareDetails are automatically gathered and should not be modified by the user.
 	// +optional
 	HardwareDetails *HardwareDetails `json:"hardwareDetails,omitempty"`
 
+	// HardwareInspection holds the results of the hardware probes of a registration that has not completed
+	// yet. A retry of the registration only runs the probes that have not succeeded so far.
+	// +optional
+	HardwareInspection *HardwareInspection `json:"hardwareInspection,omitempty"`
+
 	// IPv4 address of server.
 	// +optional
 	IPv4 string `json:"ipv4"`
@@ -230,6 +473,19 @@ type ControllerGeneratedStatus struct {
 	// +optional
 	IPv6 string `json:"ipv6"`
 
+	// Datacenter of the server as reported by Robot, e.g. FSN1-DC14.
+	// +optional
+	Datacenter string `json:"datacenter,omitempty"`
+
+	// RobotServer contains the product and contract data of the server in Robot. It is refreshed periodically.
+	// +optional
+	RobotServer *RobotServerStatus `json:"robotServer,omitempty"`
+
+	// Traffic is the traffic of the IPv4 address of the server in the current month as reported by Robot.
+	// It is refreshed periodically.
+	// +optional
+	Traffic *TrafficStatus `json:"traffic,omitempty"`
+
 	// RebootTypes is a list of all available reboot types for API reboots
 	// +optional
 	RebootTypes []RebootType `json:"rebootTypes,omitempty"`
@@ -246,18 +502,44 @@ type ControllerGeneratedStatus struct {
 	// +optional
 	ErrorType ErrorType `json:"errorType,omitempty"`
 
+	// FailureClass classifies the error of ErrorType.
+	// +optional
+	FailureClass FailureClass `json:"failureClass,omitempty"`
+
 	// ErrorCount records how many times the host has encoutered an error since the last successful operation.
 	// +kubebuilder:default:=0
 	ErrorCount int `json:"errorCount"`
 
+	// ActionErrorCount records how many times in a row the action of the current provisioning state
+	// returned an error. It determines the backoff until the action is retried.
+	// +optional
+	ActionErrorCount int `json:"actionErrorCount,omitempty"`
+
 	// Information tracked by the provisioner.
 	// +optional
 	ProvisioningState ProvisioningState `json:"provisioningState,omitempty"`
 
+	// ProvisioningStateChanged is the time of the last change of the provisioning state.
+	// +optional
+	ProvisioningStateChanged *metav1.Time `json:"provisioningStateChanged,omitempty"`
+
+	// ProvisioningStarted is the time when the host has last entered the state preparing.
+	// +optional
+	ProvisioningStarted *metav1.Time `json:"provisioningStarted,omitempty"`
+
 	// the last error message reported by the provisioning subsystem.
 	// +optional
 	ErrorMessage string `json:"errorMessage"`
 
+	// ActionHistory lists the last actions of the provisioning states with their results and durations, the
+	// latest last. It is bounded to the last 20 actions.
+	// +optional
+	ActionHistory []ActionHistoryEntry `json:"actionHistory,omitempty"`
+
+	// PostInstallStep is the progress of the post-install step of the image that is being run.
+	// +optional
+	PostInstallStep *PostInstallStepStatus `json:"postInstallStep,omitempty"`
+
 	// the last error message reported by the provisioning subsystem.
 	// +optional
 	LastUpdated *metav1.Time `json:"lastUpdated,omitempty"`
@@ -265,9 +547,12 @@ type ControllerGeneratedStatus struct {
 	// Rebooted shows whether the server is currently being rebooted.
 	Rebooted bool `json:"rebooted,omitempty"`
 
-	// Conditions defines current service state of the HetznerBareMetalHost.
+	// Conditions defines current service state of the HetznerBareMetalHost. Each condition is owned by the
+	// controller that sets it.
 	// +optional
-	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+	// +listType=map
+	// +listMapKey=type
+	Conditions []clusterv1.Condition `json:"conditions,omitempty"`
 }
 
 // GetConditions returns the observations of the operational state of the HetznerBareMetalHost resource.
@@ -403,6 +688,57 @@ type NIC struct {
 	SpeedMbps int `json:"speedMbps,omitempty"`
 }
 
+// RobotServerStatus contains the product and contract data of a server in Robot.
+type RobotServerStatus struct {
+	// Product is the product name of the server, e.g. AX41-NVMe.
+	// +optional
+	Product string `json:"product,omitempty"`
+
+	// Traffic is the traffic that is included in the contract of the server, e.g. unlimited.
+	// +optional
+	Traffic string `json:"traffic,omitempty"`
+
+	// Status is the status of the server in Robot, e.g. ready or in process.
+	// +optional
+	Status string `json:"status,omitempty"`
+
+	// Cancelled specifies whether the contract of the server has been cancelled.
+	// +optional
+	Cancelled bool `json:"cancelled,omitempty"`
+
+	// PaidUntil is the date until which the server has been paid, e.g. 2023-05-31.
+	// +optional
+	PaidUntil string `json:"paidUntil,omitempty"`
+
+	// LastUpdated is the time when the data has last been fetched from Robot.
+	// +optional
+	LastUpdated *metav1.Time `json:"lastUpdated,omitempty"`
+}
+
+// TrafficStatus contains the traffic of a server in a month.
+type TrafficStatus struct {
+	// Month is the month of the traffic, e.g. 2023-05.
+	// +optional
+	Month string `json:"month,omitempty"`
+
+	// Inbound is the incoming traffic of the server in the month.
+	// +optional
+	Inbound resource.Quantity `json:"inbound,omitempty"`
+
+	// Outbound is the outgoing traffic of the server in the month, which counts against the included traffic.
+	// +optional
+	Outbound resource.Quantity `json:"outbound,omitempty"`
+
+	// Included is the monthly traffic that is included in the contract of the server. It is not set if the
+	// traffic is unlimited.
+	// +optional
+	Included *resource.Quantity `json:"included,omitempty"`
+
+	// LastUpdated is the time when the traffic has last been fetched from Robot.
+	// +optional
+	LastUpdated *metav1.Time `json:"lastUpdated,omitempty"`
+}
+
 // HardwareDetails collects all of the information about hardware
 // discovered on the host.
 type HardwareDetails struct {
@@ -412,6 +748,25 @@ type HardwareDetails struct {
 	CPU     CPU       `json:"cpu,omitempty"`
 }
 
+// HardwareInspection collects the results of the hardware probes in the rescue system until all of them
+// have succeeded.
+type HardwareInspection struct {
+	// ServerID is the ID of the server that has been inspected. Results of another server are discarded.
+	ServerID int `json:"serverID"`
+
+	// +optional
+	RAMGB *int `json:"ramGB,omitempty"`
+
+	// +optional
+	NIC []NIC `json:"nics,omitempty"`
+
+	// +optional
+	Storage []Storage `json:"storage,omitempty"`
+
+	// +optional
+	CPU *CPU `json:"cpu,omitempty"`
+}
+
 // HetznerBareMetalHostStatus defines the observed state of HetznerBareMetalHost.
 type HetznerBareMetalHostStatus struct {
 }
@@ -425,6 +780,7 @@ type HetznerBareMetalHostStatus struct {
 // +kubebuilder:printcolumn:name="Threads",type="string",JSONPath=".spec.status.hardwareDetails.cpu.threads",description="CPU threads"
 // +kubebuilder:printcolumn:name="Clock speed",type="string",JSONPath=".spec.status.hardwareDetails.cpu.clockGigahertz",description="CPU clock speed"
 // +kubebuilder:printcolumn:name="RAM in GB",type="string",JSONPath=".spec.status.hardwareDetails.ramGB",description="RAM in GB"
+// +kubebuilder:printcolumn:name="Product",type="string",JSONPath=".spec.status.robotServer.product",description="Product of the server in Robot",priority=1
 // +kubebuilder:printcolumn:name="Consumer",type="string",JSONPath=".spec.consumerRef.name",description="Consumer using this host"
 // +kubebuilder:printcolumn:name="ErrorType",type="string",JSONPath=".spec.status.errorType",description="Type of the most recent error"
 // +kubebuilder:printcolumn:name="ErrorMessage",type="string",JSONPath=".spec.status.errorMessage",description="Message of the most recent error"
@@ -501,6 +857,45 @@ func (host *HetznerBareMetalHost) HasPowerReboot() bool {
 	return false
 }
 
+// HasPowerLongReboot returns a boolean indicating whether the long press of the power button exists for server.
+func (host *HetznerBareMetalHost) HasPowerLongReboot() bool {
+	for _, rt := range host.Spec.Status.RebootTypes {
+		if rt == RebootTypePowerLong {
+			return true
+		}
+	}
+	return false
+}
+
+// HasForensicHold returns whether the host has the forensic hold annotation.
+func (host *HetznerBareMetalHost) HasForensicHold() bool {
+	_, found := host.Annotations[ForensicHoldAnnotation]
+	return found
+}
+
+// ReprovisionApproved returns whether the host has the annotation that approves its reprovisioning.
+func (host *HetznerBareMetalHost) ReprovisionApproved() bool {
+	_, found := host.Annotations[ApproveReprovisionAnnotation]
+	return found
+}
+
+// DeprovisionApproved returns whether the host has the annotation that confirms its deprovisioning.
+func (host *HetznerBareMetalHost) DeprovisionApproved() bool {
+	_, found := host.Annotations[ApproveDeprovisionAnnotation]
+	return found
+}
+
+// RemovingInstallImageDeprovisions returns whether removing the installImage deprovisions the host, which is the
+// case while the host is being provisioned and after it has been provisioned.
+func (host *HetznerBareMetalHost) RemovingInstallImageDeprovisions() bool {
+	switch host.Spec.Status.ProvisioningState {
+	case StatePreparing, StateRegistering, StateImageInstalling, StatePostInstalling, StateProvisioning, StateEnsureProvisioned,
+		StateProvisioned:
+		return true
+	}
+	return false
+}
+
 // NeedsProvisioning compares the settings with the provisioning
 // status and returns true when more work is needed or false
 // otherwise.
@@ -509,6 +904,31 @@ func (host *HetznerBareMetalHost) NeedsProvisioning() bool {
 	return host.Spec.Status.InstallImage != nil
 }
 
+// IsReservedFor returns whether the host may be consumed by the given HetznerBareMetalMachine.
+// Hosts without reservation may be consumed by any machine.
+func (host *HetznerBareMetalHost) IsReservedFor(bmMachine *HetznerBareMetalMachine) (bool, error) {
+	reservation := host.Spec.Reservation
+	if reservation == nil {
+		return true, nil
+	}
+
+	for _, name := range reservation.MachineNames {
+		if name == bmMachine.Name {
+			return true, nil
+		}
+	}
+
+	if reservation.MachineSelector == nil {
+		return false, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(reservation.MachineSelector)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse machine selector of reservation: %w", err)
+	}
+	return selector.Matches(labels.Set(bmMachine.Labels)), nil
+}
+
 //+kubebuilder:object:root=true
 
 // HetznerBareMetalHostList contains a list of HetznerBareMetalHost.
@@ -17,7 +17,13 @@ limitations under the License.
 package v1beta1
 
 import (
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 )
@@ -37,21 +43,81 @@ var _ webhook.Defaulter = &HetznerBareMetalHost{}
 func (host *HetznerBareMetalHost) Default() {
 }
 
-//+kubebuilder:webhook:path=/validate-infrastructure-cluster-x-k8s-io-v1beta1-hetznerbaremetalhost,mutating=false,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=hetznerbaremetalhosts,verbs=create;update,versions=v1beta1,name=validation.hetznerbaremetalhost.infrastructure.cluster.x-k8s.io,admissionReviewVersions={v1,v1beta1}
+//+kubebuilder:webhook:path=/validate-infrastructure-cluster-x-k8s-io-v1beta1-hetznerbaremetalhost,mutating=false,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=hetznerbaremetalhosts,verbs=create;update;delete,versions=v1beta1,name=validation.hetznerbaremetalhost.infrastructure.cluster.x-k8s.io,admissionReviewVersions={v1,v1beta1}
 
 var _ webhook.Validator = &HetznerBareMetalHost{}
 
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
 func (host *HetznerBareMetalHost) ValidateCreate() error {
-	return nil
+	allErrs := append(host.validateReservation(), host.validateRack()...)
+	return aggregateObjErrors(host.GroupVersionKind().GroupKind(), host.Name, allErrs)
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
 func (host *HetznerBareMetalHost) ValidateUpdate(old runtime.Object) error {
-	return nil
+	oldHost, ok := old.(*HetznerBareMetalHost)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected an HetznerBareMetalHost but got a %T", old))
+	}
+
+	allErrs := append(host.validateReservation(), host.validateRack()...)
+	allErrs = append(allErrs, host.validateInstallImageRemoval(oldHost)...)
+	return aggregateObjErrors(host.GroupVersionKind().GroupKind(), host.Name, allErrs)
+}
+
+// validateInstallImageRemoval makes sure that the installImage of a host that is being provisioned or has been
+// provisioned is only removed with the annotation that confirms the deprovisioning, as removing it wipes the host.
+func (host *HetznerBareMetalHost) validateInstallImageRemoval(oldHost *HetznerBareMetalHost) field.ErrorList {
+	var allErrs field.ErrorList
+	if oldHost.Spec.Status.InstallImage == nil || host.Spec.Status.InstallImage != nil || !oldHost.RemovingInstallImageDeprovisions() {
+		return allErrs
+	}
+	if !host.DeletionTimestamp.IsZero() || host.DeprovisionApproved() {
+		return allErrs
+	}
+	allErrs = append(allErrs,
+		field.Forbidden(field.NewPath("spec", "status", "installImage"),
+			fmt.Sprintf("removing installImage deprovisions the host in state %s. Set the annotation %s to confirm it",
+				oldHost.Spec.Status.ProvisioningState, ApproveDeprovisionAnnotation)),
+	)
+	return allErrs
+}
+
+// validateRack makes sure that the rack can be used as label value.
+func (host *HetznerBareMetalHost) validateRack() field.ErrorList {
+	var allErrs field.ErrorList
+	for _, msg := range validation.IsValidLabelValue(host.Spec.Rack) {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "rack"), host.Spec.Rack, msg))
+	}
+	return allErrs
+}
+
+func (host *HetznerBareMetalHost) validateReservation() field.ErrorList {
+	var allErrs field.ErrorList
+	if host.Spec.Reservation == nil || host.Spec.Reservation.MachineSelector == nil {
+		return allErrs
+	}
+	if _, err := metav1.LabelSelectorAsSelector(host.Spec.Reservation.MachineSelector); err != nil {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "reservation", "machineSelector"), host.Spec.Reservation.MachineSelector, err.Error()),
+		)
+	}
+	return allErrs
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
 func (host *HetznerBareMetalHost) ValidateDelete() error {
-	return nil
+	// Deleting a consumed host would pull the server out from under a running node
+	if host.Spec.ConsumerRef == nil {
+		return nil
+	}
+	if _, ok := host.GetAnnotations()[ForceDeleteAnnotation]; ok {
+		return nil
+	}
+	return apierrors.NewForbidden(
+		GroupVersion.WithResource("hetznerbaremetalhosts").GroupResource(),
+		host.Name,
+		fmt.Errorf("host is consumed by %s %s/%s. Delete the consumer first or set the annotation %s to force the deletion",
+			host.Spec.ConsumerRef.Kind, host.Spec.ConsumerRef.Namespace, host.Spec.ConsumerRef.Name, ForceDeleteAnnotation),
+	)
 }
@@ -35,6 +35,10 @@ const (
 
 	// BareMetalHostNamePrefix is a prefix for all hostNames of bare metal servers.
 	BareMetalHostNamePrefix = "bm-"
+
+	// RescheduleAnnotation is the key for an annotation that releases the host of a HetznerBareMetalMachine that
+	// is not provisioned yet, so that another host is chosen for it. The released host is not chosen again.
+	RescheduleAnnotation = "reschedule.hetznerbaremetalmachine.infrastructure.cluster.x-k8s.io"
 )
 
 var errUnknownSuffix = errors.New("unknown suffix")
@@ -79,8 +83,63 @@ type HetznerBareMetalMachineSpec struct {
 
 	// SSHSpec gives a reference on the secret where SSH details are specified as well as ports for ssh.
 	SSHSpec SSHSpec `json:"sshSpec,omitempty"`
+
+	// BootstrapDataChangePolicy defines what happens if the bootstrap data changes after it has been
+	// written to the host. Changes before that are always used for provisioning.
+	// +kubebuilder:default=Ignore
+	// +optional
+	BootstrapDataChangePolicy BootstrapDataChangePolicy `json:"bootstrapDataChangePolicy,omitempty"`
+
+	// DNS defines the resolver configuration of the host, overrides the cluster wide one.
+	// +optional
+	DNS *DNSSpec `json:"dns,omitempty"`
+
+	// NodeProfileRef is the name of a HetznerNodeProfile in the same namespace whose settings are added to the
+	// user data of the host.
+	// +optional
+	NodeProfileRef string `json:"nodeProfileRef,omitempty"`
+
+	// ProvisioningChecks are checks of the services of the host that have to succeed after cloud init,
+	// before the host is provisioned.
+	// +optional
+	ProvisioningChecks *ProvisioningChecks `json:"provisioningChecks,omitempty"`
+}
+
+// ProvisioningChecks defines checks that are run on a host after cloud init has finished. They catch
+// images whose cloud init succeeds while critical services fail, e.g. a crash-looping kubelet.
+type ProvisioningChecks struct {
+	// SystemdUnits are the systemd units that have to be active, e.g. containerd.service.
+	// +optional
+	SystemdUnits []string `json:"systemdUnits,omitempty"`
+
+	// Kubelet checks that the health endpoint of the kubelet on port 10248 reports ok.
+	// +optional
+	Kubelet bool `json:"kubelet,omitempty"`
+
+	// Containerd checks that containerd responds on its socket.
+	// +optional
+	Containerd bool `json:"containerd,omitempty"`
+
+	// Timeout is the time in which cloud init has to finish and the checks have to succeed, counted from
+	// the start of the provisioning state ensure-provisioned. Afterwards the host gets a provisioning error.
+	// Defaults to 20m.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
 }
 
+// BootstrapDataChangePolicy defines what happens if the bootstrap data of a provisioned host changes.
+// +kubebuilder:validation:Enum=Ignore;RerunUserData;Reprovision
+type BootstrapDataChangePolicy string
+
+const (
+	// BootstrapDataChangePolicyIgnore records an event but leaves the host as it is.
+	BootstrapDataChangePolicyIgnore BootstrapDataChangePolicy = "Ignore"
+	// BootstrapDataChangePolicyRerunUserData writes the new user data to the host and runs cloud init again.
+	BootstrapDataChangePolicyRerunUserData BootstrapDataChangePolicy = "RerunUserData"
+	// BootstrapDataChangePolicyReprovision provisions the host again from the rescue system.
+	BootstrapDataChangePolicyReprovision BootstrapDataChangePolicy = "Reprovision"
+)
+
 // HostSelector specifies matching criteria for labels on BareMetalHosts.
 // This is used to limit the set of BareMetalHost objects considered for
 // claiming for a Machine.
@@ -101,19 +160,71 @@ type HostSelectorRequirement struct {
 	Values   []string           `json:"values"`
 }
 
-// SSHSpec defines specs for SSH.
+const (
+	// DefaultSSHPort is the default of the SSH ports of a host.
+	DefaultSSHPort = 22
+	// DefaultSSHUser is the default of the user that connects to a host after cloud init.
+	DefaultSSHUser = "root"
+)
+
+// SSHSpec defines specs for SSH. Fields that are not set or keep their default value are taken from the
+// sshDefaults of the HetznerCluster.
 type SSHSpec struct {
-	// SecretRef gives reference to the secret.
-	SecretRef SSHSecretRef `json:"secretRef"`
+	// SecretRef gives reference to the secret. It has to be set either here or in the sshDefaults of the
+	// HetznerCluster.
+	// +optional
+	SecretRef SSHSecretRef `json:"secretRef,omitempty"`
 
 	// PortAfterInstallImage specifies the port that has to be used to connect to the machine after install image.
 	// +kubebuilder:default=22
 	// +optional
-	PortAfterInstallImage int `json:"portAfterInstallImage"`
+	PortAfterInstallImage int `json:"portAfterInstallImage,omitempty"`
 
 	// PortAfterCloudInit specifies the port that has to be used to connect to the machine after cloud init.
+	// The default value is PortAfterInstallImage.
+	// +optional
+	PortAfterCloudInit int `json:"portAfterCloudInit,omitempty"`
+
+	// UserAfterCloudInit specifies the user that has to be used to connect to the machine after cloud init. It is
+	// used to verify the provisioning, to rerun changed user data and to reset kubeadm on deprovisioning. Images that
+	// disable the SSH login of root need a user that is created by the bootstrap data with the same SSH key.
+	// +kubebuilder:default=root
+	// +optional
+	UserAfterCloudInit string `json:"userAfterCloudInit,omitempty"`
+
+	// PrivilegeEscalation specifies the command that runs the commands of UserAfterCloudInit as root, e.g. "doas".
+	// It is not used if the user is root. The user must be allowed to run it without password. The default value
+	// is "sudo -n".
 	// +optional
-	PortAfterCloudInit int `json:"portAfterCloudInit"`
+	PrivilegeEscalation string `json:"privilegeEscalation,omitempty"`
+
+	// PrivateProvisioning specifies that the installed operating system is reached via the private IP of the host
+	// through a bastion host. This is needed if the server is not reachable via its public IP after installimage,
+	// e.g. because all traffic goes through a vSwitch or a VPN. The rescue system is still reached via the public IP.
+	// +optional
+	PrivateProvisioning *PrivateProvisioning `json:"privateProvisioning,omitempty"`
+}
+
+// PrivateProvisioning defines how to reach a host that is only accessible via a private network after installimage.
+type PrivateProvisioning struct {
+	// Bastion is the SSH jump host through which the private IP of the host is reached.
+	Bastion Bastion `json:"bastion"`
+}
+
+// Bastion defines an SSH jump host. It is accessed with the same SSH key as the installed operating system.
+type Bastion struct {
+	// Address is the IP address or DNS name of the bastion host.
+	Address string `json:"address"`
+
+	// Port is the SSH port of the bastion host.
+	// +kubebuilder:default=22
+	// +optional
+	Port int `json:"port,omitempty"`
+
+	// User is the user that is used to log in to the bastion host.
+	// +kubebuilder:default=root
+	// +optional
+	User string `json:"user,omitempty"`
 }
 
 // SSHSecretRef defines the secret containing all information of the SSH key used for Hetzner robot.
@@ -129,15 +240,40 @@ type SSHSecretKeyRef struct {
 	PrivateKey string `json:"privateKey"`
 }
 
+// PostInstallStep is a step that is run via SSH in the installed operating system.
+type PostInstallStep struct {
+	// Name identifies the step.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Script is run by bash as root. It has to be idempotent, as it is run again if the step is interrupted.
+	Script string `json:"script"`
+
+	// RebootRequired reboots the host after the script.
+	// +optional
+	RebootRequired bool `json:"rebootRequired,omitempty"`
+
+	// VerifyScript is run by bash as root after the script and the reboot, e.g. to check the running kernel. The
+	// step fails if it exits with a status other than 0.
+	// +optional
+	VerifyScript string `json:"verifyScript,omitempty"`
+}
+
 // InstallImage defines the configuration for InstallImage.
 type InstallImage struct {
 	// Image is the image to be provisioned.
 	Image Image `json:"image"`
 
 	// PostInstallScript is used for configuring commands which should be executed after installimage.
-	// It is passed along with the installimage command.
+	// It is passed along with the installimage command. It can be a template like the fields of Image.
 	PostInstallScript string `json:"postInstallScript,omitempty"`
 
+	// PostInstallSteps are run one after the other via SSH in the installed operating system, before the user data
+	// is written. Steps that require a reboot, e.g. because they install a kernel or microcode, reboot the host and
+	// are verified once the installed operating system is reachable again.
+	// +optional
+	PostInstallSteps []PostInstallStep `json:"postInstallSteps,omitempty"`
+
 	// Partitions defines the additional Partitions to be created.
 	Partitions []Partition `json:"partitions"`
 
@@ -160,9 +296,52 @@ type InstallImage struct {
 	// +kubebuilder:default=1
 	// +kubebuilder:validation:Enum=0;1;5;6;10;
 	SwraidLevel int `json:"swraidLevel,omitempty"`
+
+	// Swap defines the swap space of the host. Without it, the host has no swap unless a swap partition
+	// is defined in the partitions. The kubelet is not configured for swap, failSwapOn has to be disabled
+	// in the bootstrap configuration.
+	// +optional
+	Swap *SwapSpec `json:"swap,omitempty"`
+
+	// PreBaked indicates that the image already contains kubelet, containerd and kubeadm, so that the bootstrap
+	// data only has to join the node. Cloud-init does not update or upgrade packages, and the host is provisioned
+	// as soon as kubelet and containerd are healthy instead of when cloud-init has finished.
+	// +optional
+	PreBaked bool `json:"preBaked,omitempty"`
+}
+
+// SwapType defines how the swap space of a host is provided.
+type SwapType string
+
+const (
+	// SwapTypeFile is a swap file in the root file system that is created by cloud init.
+	SwapTypeFile SwapType = "file"
+	// SwapTypePartition is a swap partition that is created by installimage.
+	SwapTypePartition SwapType = "partition"
+)
+
+// SwapSpec defines the swap space of a host.
+type SwapSpec struct {
+	// Type is either file for a swap file at /swapfile or partition for a swap partition in front of
+	// the other partitions.
+	// +optional
+	// +kubebuilder:default=file
+	// +kubebuilder:validation:Enum=file;partition
+	Type SwapType `json:"type,omitempty"`
+
+	// Size of the swap space. Can use M/G/T for unit specification in MiB/GiB/TiB.
+	// +kubebuilder:validation:Pattern=`^[1-9][0-9]*[MGT]$`
+	Size string `json:"size"`
+
+	// Swappiness sets vm.swappiness of the kernel. The default of the image is kept if not set.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	Swappiness *int `json:"swappiness,omitempty"`
 }
 
-// Image defines the properties for the autosetup config.
+// Image defines the properties for the autosetup config. The fields can be Go templates that use the
+// variables .ClusterName, .MachineName, .KubernetesVersion and .Arch, e.g. amd64 or arm64.
 type Image struct {
 	// URL defines the remote URL for downloading a tar, tar.gz, tar.bz, tar.bz2, tar.xz, tgz, tbz, txz image.
 	URL string `json:"url,omitempty"`
@@ -172,8 +351,26 @@ type Image struct {
 
 	// Path is the local path for a preinstalled image from upstream.
 	Path string `json:"path,omitempty"`
+
+	// DownloadSecretRef references a secret in the namespace of the HetznerBareMetalMachine with the credentials
+	// to download the image from URL, e.g. from an artifact store that does not allow anonymous access.
+	// The secret can contain the keys username and password for basic authentication or token for a bearer token.
+	// The key ca.crt can contain the PEM encoded CA that signed the certificate of the artifact store.
+	// +optional
+	DownloadSecretRef *corev1.LocalObjectReference `json:"downloadSecretRef,omitempty"`
 }
 
+const (
+	// ImageDownloadSecretUsernameKey is the key of the username for basic authentication in the image download secret.
+	ImageDownloadSecretUsernameKey = "username"
+	// ImageDownloadSecretPasswordKey is the key of the password for basic authentication in the image download secret.
+	ImageDownloadSecretPasswordKey = "password"
+	// ImageDownloadSecretTokenKey is the key of the bearer token in the image download secret.
+	ImageDownloadSecretTokenKey = "token"
+	// ImageDownloadSecretCACertKey is the key of the CA certificate in the image download secret.
+	ImageDownloadSecretCACertKey = "ca.crt"
+)
+
 // Partition defines the additional Partitions to be created.
 type Partition struct {
 
@@ -247,6 +444,19 @@ type HetznerBareMetalMachineStatus struct {
 	// +optional
 	Ready bool `json:"ready"`
 
+	// KubeletServingCertificate is the last serving certificate that has been issued to the kubelet of the node.
+	// +optional
+	KubeletServingCertificate *CertificateStatus `json:"kubeletServingCertificate,omitempty"`
+
+	// Milestones are the times at which the machine reached the milestones of its provisioning.
+	// +optional
+	Milestones *ProvisioningMilestones `json:"milestones,omitempty"`
+
+	// ReleasedHosts are the names of the hosts that have been released by rescheduling the machine. They are
+	// not chosen for the machine again.
+	// +optional
+	ReleasedHosts []string `json:"releasedHosts,omitempty"`
+
 	// Conditions defines current service state of the HetznerBareMetalMachine.
 	// +optional
 	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
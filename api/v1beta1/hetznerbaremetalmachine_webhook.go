@@ -17,11 +17,16 @@ limitations under the License.
 package v1beta1
 
 import (
+	"context"
+	"fmt"
 	"reflect"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 )
 
@@ -29,6 +34,7 @@ import (
 func (r *HetznerBareMetalMachine) SetupWebhookWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(r).
+		WithValidator(&hetznerBareMetalMachineValidator{Client: mgr.GetAPIReader()}).
 		Complete()
 }
 
@@ -48,10 +54,6 @@ var _ webhook.Validator = &HetznerBareMetalMachine{}
 func (r *HetznerBareMetalMachine) ValidateCreate() error {
 	var allErrs field.ErrorList
 
-	if r.Spec.SSHSpec.PortAfterCloudInit == 0 {
-		r.Spec.SSHSpec.PortAfterCloudInit = r.Spec.SSHSpec.PortAfterInstallImage
-	}
-
 	if (r.Spec.InstallImage.Image.Name == "" || r.Spec.InstallImage.Image.URL == "") &&
 		r.Spec.InstallImage.Image.Path == "" {
 		allErrs = append(allErrs,
@@ -68,6 +70,20 @@ func (r *HetznerBareMetalMachine) ValidateCreate() error {
 			)
 		}
 	}
+
+	if r.Spec.InstallImage.Image.DownloadSecretRef != nil && r.Spec.InstallImage.Image.URL == "" {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "installImage", "image", "downloadSecretRef"), r.Spec.InstallImage.Image.DownloadSecretRef,
+				"can only be used with an image url"),
+		)
+	}
+
+	allErrs = append(allErrs, validateSSHSpec(field.NewPath("spec", "sshSpec"), &r.Spec.SSHSpec)...)
+	allErrs = append(allErrs, validateDNSSpec(field.NewPath("spec", "dns"), r.Spec.DNS)...)
+	allErrs = append(allErrs, validateSwapSpec(field.NewPath("spec", "installImage"), r.Spec.InstallImage)...)
+	allErrs = append(allErrs, validateInstallImageTemplates(field.NewPath("spec", "installImage"), r.Spec.InstallImage)...)
+	allErrs = append(allErrs, validatePostInstallSteps(field.NewPath("spec", "installImage", "postInstallSteps"), r.Spec.InstallImage.PostInstallSteps)...)
+	allErrs = append(allErrs, validateProvisioningChecks(field.NewPath("spec", "provisioningChecks"), r.Spec.ProvisioningChecks)...)
 	return aggregateObjErrors(r.GroupVersionKind().GroupKind(), r.Name, allErrs)
 }
 
@@ -82,6 +98,21 @@ func (r *HetznerBareMetalMachine) ValidateUpdate(old runtime.Object) error {
 			field.Invalid(field.NewPath("spec", "sshSpec"), r.Spec.SSHSpec, "sshSpec immutable"),
 		)
 	}
+	if !reflect.DeepEqual(r.Spec.DNS, oldHetznerBareMetalMachine.Spec.DNS) {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "dns"), r.Spec.DNS, "dns immutable"),
+		)
+	}
+	if r.Spec.NodeProfileRef != oldHetznerBareMetalMachine.Spec.NodeProfileRef {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "nodeProfileRef"), r.Spec.NodeProfileRef, "nodeProfileRef immutable"),
+		)
+	}
+	if !reflect.DeepEqual(r.Spec.ProvisioningChecks, oldHetznerBareMetalMachine.Spec.ProvisioningChecks) {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "provisioningChecks"), r.Spec.ProvisioningChecks, "provisioningChecks immutable"),
+		)
+	}
 	return aggregateObjErrors(r.GroupVersionKind().GroupKind(), r.Name, allErrs)
 }
 
@@ -89,3 +120,84 @@ func (r *HetznerBareMetalMachine) ValidateUpdate(old runtime.Object) error {
 func (r *HetznerBareMetalMachine) ValidateDelete() error {
 	return nil
 }
+
+// hetznerBareMetalMachineValidator validates HetznerBareMetalMachines together with the sshDefaults of their
+// HetznerCluster.
+// +kubebuilder:object:generate=false
+type hetznerBareMetalMachineValidator struct {
+	// Client reads the clusters. It should not be cached, as they are created together with the machines.
+	Client client.Reader
+}
+
+var _ webhook.CustomValidator = &hetznerBareMetalMachineValidator{}
+
+// ValidateCreate implements webhook.CustomValidator.
+func (v *hetznerBareMetalMachineValidator) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	bmMachine, ok := obj.(*HetznerBareMetalMachine)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a HetznerBareMetalMachine but got a %T", obj))
+	}
+	if err := bmMachine.ValidateCreate(); err != nil {
+		return err
+	}
+	return v.validateSSHSecretRef(ctx, bmMachine)
+}
+
+// ValidateUpdate implements webhook.CustomValidator.
+func (v *hetznerBareMetalMachineValidator) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) error {
+	bmMachine, ok := newObj.(*HetznerBareMetalMachine)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a HetznerBareMetalMachine but got a %T", newObj))
+	}
+	return bmMachine.ValidateUpdate(oldObj)
+}
+
+// ValidateDelete implements webhook.CustomValidator.
+func (v *hetznerBareMetalMachineValidator) ValidateDelete(_ context.Context, _ runtime.Object) error {
+	return nil
+}
+
+// validateSSHSecretRef rejects machines without SSH secret if the sshDefaults of their HetznerCluster do not
+// specify one either. Machines whose HetznerCluster cannot be found are admitted, as it might be created after
+// them. In this case, the missing secret is reported by the controller.
+func (v *hetznerBareMetalMachineValidator) validateSSHSecretRef(ctx context.Context, bmMachine *HetznerBareMetalMachine) error {
+	if bmMachine.Spec.SSHSpec.SecretRef.Name != "" {
+		return nil
+	}
+
+	hetznerCluster, err := v.hetznerCluster(ctx, bmMachine)
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+	if hetznerCluster == nil || (hetznerCluster.Spec.SSHDefaults != nil && hetznerCluster.Spec.SSHDefaults.SecretRef.Name != "") {
+		return nil
+	}
+
+	return aggregateObjErrors(bmMachine.GroupVersionKind().GroupKind(), bmMachine.Name, field.ErrorList{
+		field.Required(field.NewPath("spec", "sshSpec", "secretRef", "name"),
+			fmt.Sprintf("have to specify the SSH secret, as the sshDefaults of HetznerCluster %s do not specify one", hetznerCluster.Name)),
+	})
+}
+
+// hetznerCluster returns the HetznerCluster of the cluster the machine belongs to, or nil if it cannot be found.
+func (v *hetznerBareMetalMachineValidator) hetznerCluster(ctx context.Context, bmMachine *HetznerBareMetalMachine) (*HetznerCluster, error) {
+	clusterName := bmMachine.Labels[clusterv1.ClusterLabelName]
+	if clusterName == "" {
+		return nil, nil
+	}
+
+	var cluster clusterv1.Cluster
+	if err := v.Client.Get(ctx, client.ObjectKey{Namespace: bmMachine.Namespace, Name: clusterName}, &cluster); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	ref := cluster.Spec.InfrastructureRef
+	if ref == nil || ref.Kind != "HetznerCluster" {
+		return nil, nil
+	}
+
+	var hetznerCluster HetznerCluster
+	if err := v.Client.Get(ctx, client.ObjectKey{Namespace: bmMachine.Namespace, Name: ref.Name}, &hetznerCluster); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	return &hetznerCluster, nil
+}
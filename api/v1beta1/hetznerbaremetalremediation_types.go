@@ -18,6 +18,7 @@ package v1beta1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
 
 // RemediationType defines the type of remediation.
@@ -31,6 +32,10 @@ const (
 	// RebootAnnotation indicates that a bare metal host object should be rebooted.
 	RebootAnnotation = "reboot.hetznerbaremetalhost.infrastructure.cluster.x-k8s.io"
 
+	// FenceAnnotation indicates that a bare metal host object should be powered off and kept off, so that the
+	// volumes of its node can be attached to other nodes.
+	FenceAnnotation = "fence.hetznerbaremetalhost.infrastructure.cluster.x-k8s.io"
+
 	// RebootRemediationStrategy sets RemediationType to Reboot.
 	RebootRemediationStrategy RemediationType = "Reboot"
 )
@@ -42,6 +47,10 @@ const (
 	// PhaseWaiting represents the state during remediation when the controller has done its job but still waiting for the result of the last remediation step.
 	PhaseWaiting = "Waiting"
 
+	// PhaseFencing represents the state where the host is powered off and the controller waits until Robot confirms it
+	// before the unhealthy Machine object is deleted.
+	PhaseFencing = "Fencing"
+
 	// PhaseDeleting represents the state where host remediation has failed and the controller is deleting the unhealthy Machine object from the cluster.
 	PhaseDeleting = "Deleting machine"
 )
@@ -65,6 +74,11 @@ type RemediationStrategy struct {
 
 	// Sets the timeout between remediation retries.
 	Timeout *metav1.Duration `json:"timeout"`
+
+	// Fencing powers off the host via Robot if rebooting did not remediate it. The Machine is only deleted once
+	// Robot confirms that the host is off, so that the volumes of its node can be attached safely to other nodes.
+	// +optional
+	Fencing bool `json:"fencing,omitempty"`
 }
 
 // HetznerBareMetalRemediationStatus defines the observed state of HetznerBareMetalRemediation.
@@ -83,6 +97,11 @@ type HetznerBareMetalRemediationStatus struct {
 	// LastRemediated identifies when the host was last remediated
 	// +optional
 	LastRemediated *metav1.Time `json:"lastRemediated,omitempty"`
+
+	// Conditions of the remediation. The condition HostFenced reports whether Robot confirmed that the
+	// fenced host is powered off.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -96,6 +115,7 @@ type HetznerBareMetalRemediationStatus struct {
 // +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=".status.phase",description="Phase of the remediation"
 // +kubebuilder:printcolumn:name="Last Remediated",type=string,JSONPath=".status.lastRemediated",description="Timestamp of the last remediation attempt"
 // +kubebuilder:printcolumn:name="Retry count",type=string,JSONPath=".status.retryCount",description="How many times remediation controller has tried to remediate the node"
+// +kubebuilder:printcolumn:name="Fenced",type=string,JSONPath=".status.conditions[?(@.type=='HostFenced')].status",description="Whether the host is confirmed to be powered off"
 
 // HetznerBareMetalRemediation is the Schema for the hetznerbaremetalremediations API.
 type HetznerBareMetalRemediation struct {
@@ -108,6 +128,16 @@ type HetznerBareMetalRemediation struct {
 	Status HetznerBareMetalRemediationStatus `json:"status,omitempty"`
 }
 
+// GetConditions returns the observations of the operational state of the HetznerBareMetalRemediation resource.
+func (r *HetznerBareMetalRemediation) GetConditions() clusterv1.Conditions {
+	return r.Status.Conditions
+}
+
+// SetConditions sets the underlying service state of the HetznerBareMetalRemediation to the predescribed clusterv1.Conditions.
+func (r *HetznerBareMetalRemediation) SetConditions(conditions clusterv1.Conditions) {
+	r.Status.Conditions = conditions
+}
+
 //+kubebuilder:object:root=true
 
 // HetznerBareMetalRemediationList contains a list of HetznerBareMetalRemediation.
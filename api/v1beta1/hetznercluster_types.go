@@ -17,6 +17,9 @@ limitations under the License.
 package v1beta1
 
 import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
@@ -26,6 +29,37 @@ const (
 	// resources associated with HetznerCluster before removing it from the
 	// apiserver.
 	ClusterFinalizer = "hetznercluster.infrastructure.cluster.x-k8s.io"
+
+	// DryRunAnnotation set to "true" makes the controllers record the mutations of servers and other resources
+	// of the cluster in HCloud and Robot as events instead of executing them.
+	DryRunAnnotation = "dry-run.hetznercluster.infrastructure.cluster.x-k8s.io"
+
+	// PreDeleteHookAnnotationPrefix is the prefix of annotations on Machines, HCloudMachines and HetznerBareMetalMachines
+	// that prevent the deletion of their servers. The annotations are removed by external systems, e.g. once the
+	// deletion has been approved.
+	PreDeleteHookAnnotationPrefix = "pre-delete.hook.infrastructure.cluster.x-k8s.io"
+
+	// MaxMachineAgeAnnotation on a MachineDeployment sets the maximum age of its machines as duration, e.g. "720h".
+	// Once the oldest machine is older, the machines of the MachineDeployment are replaced by a rolling update.
+	MaxMachineAgeAnnotation = "max-machine-age.infrastructure.cluster.x-k8s.io"
+
+	// RecycledAtAnnotation is set on the machine template of a MachineDeployment to the time at which the
+	// machines have been recycled because they exceeded their maximum age. Changing it triggers the rolling update.
+	RecycledAtAnnotation = "recycled-at.infrastructure.cluster.x-k8s.io"
+
+	// ForceCleanupAnnotation set to "true" on a deleted HetznerCluster or HCloudMachine removes its finalizer even
+	// though its HCloud resources cannot be deleted, because the Hetzner secret is missing or invalid or the HCloud
+	// API is unreachable. The resources are left behind in HCloud and have to be deleted manually.
+	ForceCleanupAnnotation = "force-cleanup.infrastructure.cluster.x-k8s.io"
+
+	// KeepLoadBalancerTargetsAnnotation on a HetznerCluster lists the targets of the control plane load balancer that
+	// were added outside of the controller, e.g. a standby API server, as comma-separated HCloud server IDs and IP
+	// addresses. Other targets that do not belong to a machine of the cluster are removed.
+	KeepLoadBalancerTargetsAnnotation = "keep-load-balancer-targets.infrastructure.cluster.x-k8s.io"
+
+	// CleanupReportLabel is set on the ConfigMap with the cleanup report of a deleted HetznerCluster. Its value is
+	// the name of the HetznerCluster.
+	CleanupReportLabel = "cleanup-report.hetznercluster.infrastructure.cluster.x-k8s.io"
 )
 
 // HetznerClusterSpec defines the desired state of HetznerCluster.
@@ -48,14 +82,307 @@ type HetznerClusterSpec struct {
 	// ControlPlaneLoadBalancer is optional configuration for customizing control plane behavior. Naming convention is from upstream cluster-api project.
 	ControlPlaneLoadBalancer LoadBalancerSpec `json:"controlPlaneLoadBalancer,omitempty"`
 
+	// ControlPlaneFloatingIP allocates an HCloud floating IP as control plane endpoint, which is assigned to
+	// one of the control plane servers. It requires the control plane load balancer to be disabled.
+	// +optional
+	ControlPlaneFloatingIP *ControlPlaneFloatingIPSpec `json:"controlPlaneFloatingIP,omitempty"`
+
 	// +optional
 	HCloudPlacementGroup []HCloudPlacementGroupSpec `json:"hcloudPlacementGroups,omitempty"`
 
+	// HCloudFirewalls are created for the cluster and applied to its servers. Firewalls that are removed
+	// from the list are deleted.
+	// +optional
+	HCloudFirewalls []HCloudFirewallSpec `json:"hcloudFirewalls,omitempty"`
+
 	// HetznerSecretRef is a reference to a token to be used when reconciling this cluster.
 	// This is generated in the security section under API TOKENS. Read & write is necessary.
 	HetznerSecret HetznerSecretRef `json:"hetznerSecretRef"`
+
+	// DNS is the cluster wide resolver configuration of the nodes. It can be overridden per machine.
+	// +optional
+	DNS *DNSSpec `json:"dns,omitempty"`
+
+	// HostHealthNodeConditions are node conditions that report problems of the hardware of bare metal
+	// hosts, e.g. set by node-problem-detector. They are mirrored into the condition HostHealthy of the
+	// HetznerBareMetalHost of the node. A condition with status True means that the problem is present.
+	// +optional
+	HostHealthNodeConditions []corev1.NodeConditionType `json:"hostHealthNodeConditions,omitempty"`
+
+	// SSHDefaults are the cluster wide defaults of the sshSpec of HetznerBareMetalMachines. Every field that is
+	// not set in the sshSpec of a machine is taken from here.
+	// +optional
+	SSHDefaults *SSHSpec `json:"sshDefaults,omitempty"`
+
+	// HCloudServerTypeSuccessors maps deprecated HCloud server types to the server types that are used
+	// instead for new servers. A mapping only takes effect once HCloud has deprecated the server type.
+	// +optional
+	HCloudServerTypeSuccessors map[string]HCloudMachineType `json:"hcloudServerTypeSuccessors,omitempty"`
+
+	// PlacementConstraints restrict the HCloud locations of the servers and the Robot datacenters of the bare
+	// metal hosts of the cluster, e.g. to meet data residency requirements.
+	// +optional
+	PlacementConstraints *PlacementConstraints `json:"placementConstraints,omitempty"`
+
+	// DriftPolicies define per type of HCloud resource whether changes that were made outside of the controller,
+	// e.g. in the HCloud console, are reverted or adopted. Changes are reverted by default.
+	// +optional
+	DriftPolicies *DriftPolicies `json:"driftPolicies,omitempty"`
+
+	// ServerLabels selects the labels of Machines that are set on their HCloud servers, e.g. for cost allocation.
+	// Without it, all labels that are propagated to the HCloudMachines are set.
+	// +optional
+	ServerLabels *ServerLabels `json:"serverLabels,omitempty"`
+
+	// ProtectControlPlaneServers enables the delete and rebuild protection of the HCloud servers of the control
+	// planes, so that they cannot be deleted by accident in the Cloud Console or by other automation. The
+	// protection is only removed by the controller when it deletes a server. If false, the protection is removed
+	// from the servers of the control planes, if not set, the protection is not changed.
+	// +optional
+	ProtectControlPlaneServers *bool `json:"protectControlPlaneServers,omitempty"`
+
+	// HCloudQuota are the resource limits of the HCloud project. Servers, load balancers and networks are only
+	// created if they fit into the limits, otherwise the condition QuotaExceeded names the missing quota.
+	// +optional
+	HCloudQuota *HCloudQuota `json:"hcloudQuota,omitempty"`
+
+	// ProvisioningThrottle limits the rescue activations and imaging operations of bare metal hosts that run at
+	// the same time per Robot datacenter. Without it, the operations are not limited.
+	// +optional
+	ProvisioningThrottle *ProvisioningThrottle `json:"provisioningThrottle,omitempty"`
+
+	// ProvisioningFirewall adds rules to active Robot firewalls of bare metal hosts that allow the controller to
+	// connect via SSH while the hosts are provisioned. The rules are removed once the hosts are provisioned.
+	// +optional
+	ProvisioningFirewall *ProvisioningFirewall `json:"provisioningFirewall,omitempty"`
+
+	// TrustedCABundle references CA certificates that are added to the trust store of the operating system of
+	// HCloud servers and bare metal hosts when they are provisioned, e.g. for private registries or proxies.
+	// +optional
+	TrustedCABundle *TrustedCABundleRef `json:"trustedCABundle,omitempty"`
+
+	// ConsoleUser is a local user with a password that is created when HCloud servers and bare metal hosts are
+	// provisioned, to log in on the console, e.g. via vKVM, if SSH is broken.
+	// +optional
+	ConsoleUser *ConsoleUserSpec `json:"consoleUser,omitempty"`
+
+	// CloudInitParts are cloud-configs of the infrastructure, e.g. for mirrors, proxies or kernel parameters, that
+	// are merged with the bootstrap data of HCloud servers and bare metal hosts via a MIME multi-part. Their
+	// commands run before the commands of the bootstrap data.
+	// +optional
+	CloudInitParts []CloudInitPart `json:"cloudInitParts,omitempty"`
+
+	// HCloudMaintenance defines how HCloud machines are handled whose host is under maintenance.
+	// +optional
+	HCloudMaintenance *HCloudMaintenanceSpec `json:"hcloudMaintenance,omitempty"`
+
+	// DeletionPolicy defines the order in which the HCloud resources of the cluster are deleted and which of them
+	// are kept. Without it, all resources are deleted in the default order.
+	// +optional
+	DeletionPolicy *ClusterDeletionPolicy `json:"deletionPolicy,omitempty"`
 }
 
+// DeletionStep is a step of the deletion of a HetznerCluster that deletes one type of HCloud resources.
+// +kubebuilder:validation:Enum=OrphanedResources;LoadBalancerTargets;LoadBalancer;Subnets;Network;PlacementGroups;Firewalls;FloatingIP;SSHKeys
+type DeletionStep string
+
+const (
+	// DeletionStepOrphanedResources deletes the resources that were orphaned while the HCloud API was unreachable.
+	DeletionStepOrphanedResources DeletionStep = "OrphanedResources"
+	// DeletionStepLoadBalancerTargets removes the remaining targets from the load balancer.
+	DeletionStepLoadBalancerTargets DeletionStep = "LoadBalancerTargets"
+	// DeletionStepLoadBalancer deletes the load balancer, or detaches it from the network if it is protected.
+	DeletionStepLoadBalancer DeletionStep = "LoadBalancer"
+	// DeletionStepSubnets deletes the additional subnets of the network.
+	DeletionStepSubnets DeletionStep = "Subnets"
+	// DeletionStepNetwork deletes the network.
+	DeletionStepNetwork DeletionStep = "Network"
+	// DeletionStepPlacementGroups deletes the placement groups.
+	DeletionStepPlacementGroups DeletionStep = "PlacementGroups"
+	// DeletionStepFirewalls deletes the firewalls.
+	DeletionStepFirewalls DeletionStep = "Firewalls"
+	// DeletionStepFloatingIP deletes the floating IP of the control planes.
+	DeletionStepFloatingIP DeletionStep = "FloatingIP"
+	// DeletionStepSSHKeys deletes the SSH keys that have been created for additional SSH keys of machines.
+	DeletionStepSSHKeys DeletionStep = "SSHKeys"
+)
+
+// DefaultDeletionOrder is the order in which the steps of the deletion run. The machines are always deleted first.
+var DefaultDeletionOrder = []DeletionStep{
+	DeletionStepOrphanedResources,
+	DeletionStepLoadBalancerTargets,
+	DeletionStepLoadBalancer,
+	DeletionStepSubnets,
+	DeletionStepNetwork,
+	DeletionStepPlacementGroups,
+	DeletionStepFirewalls,
+	DeletionStepFloatingIP,
+	DeletionStepSSHKeys,
+}
+
+// ClusterDeletionPolicy defines the order of the steps of the deletion of a HetznerCluster and the steps that are skipped.
+type ClusterDeletionPolicy struct {
+	// Order of the deletion steps. Steps that are not listed run after the listed ones in their default order.
+	// +optional
+	Order []DeletionStep `json:"order,omitempty"`
+
+	// Skip are the deletion steps that do not run. Their resources are left behind in the HCloud project.
+	// +optional
+	Skip []DeletionStep `json:"skip,omitempty"`
+
+	// ContinueOnError runs the remaining steps if a step fails, instead of waiting until the step succeeds. The
+	// failed steps are retried until all steps have succeeded.
+	// +optional
+	ContinueOnError bool `json:"continueOnError,omitempty"`
+}
+
+// Steps returns the deletion steps in the order in which they run, including the skipped ones.
+func (p *ClusterDeletionPolicy) Steps() []DeletionStep {
+	if p == nil || len(p.Order) == 0 {
+		return DefaultDeletionOrder
+	}
+	steps := make([]DeletionStep, 0, len(DefaultDeletionOrder))
+	listed := make(map[DeletionStep]struct{}, len(p.Order))
+	for _, step := range p.Order {
+		steps = append(steps, step)
+		listed[step] = struct{}{}
+	}
+	for _, step := range DefaultDeletionOrder {
+		if _, found := listed[step]; !found {
+			steps = append(steps, step)
+		}
+	}
+	return steps
+}
+
+// IsSkipped returns whether the deletion step does not run.
+func (p *ClusterDeletionPolicy) IsSkipped(step DeletionStep) bool {
+	if p == nil {
+		return false
+	}
+	for _, skipped := range p.Skip {
+		if skipped == step {
+			return true
+		}
+	}
+	return false
+}
+
+// ContinuesOnError returns whether the remaining deletion steps run after a step has failed.
+func (p *ClusterDeletionPolicy) ContinuesOnError() bool {
+	return p != nil && p.ContinueOnError
+}
+
+// DeletionStepCondition returns the condition of the HetznerCluster that reports on the deletion step.
+func DeletionStepCondition(step DeletionStep) clusterv1.ConditionType {
+	return clusterv1.ConditionType(string(step) + "Deleted")
+}
+
+// HCloudMaintenanceSpec defines how HCloud machines are handled whose host is under maintenance.
+type HCloudMaintenanceSpec struct {
+	// ReplaceMachines replaces machines whose maintenance window starts within the lead time, so that their
+	// nodes are drained before the host is rebooted. Control planes are not replaced.
+	// +optional
+	ReplaceMachines bool `json:"replaceMachines,omitempty"`
+
+	// LeadTime is the time before the start of a maintenance window in which machines are replaced.
+	// +optional
+	// +kubebuilder:default="24h"
+	LeadTime *metav1.Duration `json:"leadTime,omitempty"`
+}
+
+// DefaultMaintenanceLeadTime is the lead time of HCloudMaintenance if none is given.
+const DefaultMaintenanceLeadTime = 24 * time.Hour
+
+// MaintenanceLeadTime returns the lead time in which machines are replaced before a maintenance window.
+func (m *HCloudMaintenanceSpec) MaintenanceLeadTime() time.Duration {
+	if m.LeadTime == nil {
+		return DefaultMaintenanceLeadTime
+	}
+	return m.LeadTime.Duration
+}
+
+// TrustedCABundleRef references a secret with PEM encoded CA certificates.
+type TrustedCABundleRef struct {
+	// Name is the name of the secret in the namespace of the HetznerCluster.
+	Name string `json:"name"`
+
+	// Key is the key of the CA certificates in the secret.
+	// +optional
+	// +kubebuilder:default=ca.crt
+	Key string `json:"key,omitempty"`
+}
+
+// ConsoleUserSpec defines the local console user of the machines.
+type ConsoleUserSpec struct {
+	// Name is the name of the user. Users of the operating system, e.g. root, are rejected.
+	// +optional
+	// +kubebuilder:default=console
+	// +kubebuilder:validation:MaxLength=32
+	// +kubebuilder:validation:Pattern=`^[a-z_][a-z0-9_-]*$`
+	Name string `json:"name,omitempty"`
+
+	// PasswordSecretRef references the secret with the hashed password of the user, as created by
+	// "mkpasswd --method=SHA-512".
+	PasswordSecretRef ConsoleUserPasswordSecretRef `json:"passwordSecretRef"`
+}
+
+// ConsoleUserPasswordSecretRef references a secret with the hashed password of the console user.
+type ConsoleUserPasswordSecretRef struct {
+	// Name is the name of the secret in the namespace of the HetznerCluster.
+	Name string `json:"name"`
+
+	// Key is the key of the hashed password in the secret.
+	// +optional
+	// +kubebuilder:default=passwordHash
+	Key string `json:"key,omitempty"`
+}
+
+// CloudInitPart is a cloud-config that is given inline or referenced in a secret.
+type CloudInitPart struct {
+	// Name identifies the part.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Content is the cloud-config. It has to start with "#cloud-config".
+	// +optional
+	Content string `json:"content,omitempty"`
+
+	// SecretRef references a secret with the cloud-config, e.g. if it contains credentials of a proxy.
+	// +optional
+	SecretRef *CloudInitPartSecretRef `json:"secretRef,omitempty"`
+}
+
+// CloudInitPartSecretRef references a secret with a cloud-config.
+type CloudInitPartSecretRef struct {
+	// Name is the name of the secret in the namespace of the HetznerCluster.
+	Name string `json:"name"`
+
+	// Key is the key of the cloud-config in the secret.
+	// +optional
+	// +kubebuilder:default=value
+	Key string `json:"key,omitempty"`
+}
+
+const (
+	// DefaultCloudInitPartKey is the key of the cloud-config in the secret of a CloudInitPart if no key is given.
+	DefaultCloudInitPartKey = "value"
+	// CloudConfigPrefix is the header that cloud-configs start with.
+	CloudConfigPrefix = "#cloud-config"
+)
+
+const (
+	// DefaultConsoleUserName is the name of the console user if no name is given.
+	DefaultConsoleUserName = "console"
+	// DefaultConsoleUserPasswordKey is the key of the hashed password in the secret of ConsoleUser if no key is given.
+	DefaultConsoleUserPasswordKey = "passwordHash"
+)
+
+const (
+	// DefaultTrustedCABundleKey is the key of the CA certificates in the secret of TrustedCABundle if no key is given.
+	DefaultTrustedCABundleKey = "ca.crt"
+)
+
 // HetznerClusterStatus defines the observed state of HetznerCluster.
 type HetznerClusterStatus struct {
 	// +kubebuilder:default=false
@@ -66,9 +393,66 @@ type HetznerClusterStatus struct {
 
 	ControlPlaneLoadBalancer *LoadBalancerStatus `json:"controlPlaneLoadBalancer,omitempty"`
 	// +optional
+	ControlPlaneFloatingIP *FloatingIPStatus `json:"controlPlaneFloatingIP,omitempty"`
+	// +optional
 	HCloudPlacementGroup []HCloudPlacementGroupStatus `json:"hcloudPlacementGroups,omitempty"`
-	FailureDomains       clusterv1.FailureDomains     `json:"failureDomains,omitempty"`
-	Conditions           clusterv1.Conditions         `json:"conditions,omitempty"`
+	// +optional
+	HCloudFirewalls []HCloudFirewallStatus `json:"hcloudFirewalls,omitempty"`
+	// OrphanedResources lists HCloud resources whose owning objects were deleted while the
+	// HCloud API was unreachable. They get deleted as soon as the API is reachable again.
+	// +optional
+	OrphanedResources []OrphanedResource `json:"orphanedResources,omitempty"`
+	// ExhaustedLocations lists locations in which servers of a type could not be created because
+	// Hetzner ran out of capacity. Other failure domains are preferred until the cooldown expired.
+	// +optional
+	ExhaustedLocations []ExhaustedLocation `json:"exhaustedLocations,omitempty"`
+	// EgressIPs are the public IPs that the controllers use for outbound traffic, e.g. for the SSH connections to
+	// bare metal hosts, as reported by the endpoints of the flag --egress-ip-discovery-endpoints of the manager.
+	// They can be used to maintain firewalls and allow-lists.
+	// +optional
+	EgressIPs      []string                 `json:"egressIPs,omitempty"`
+	FailureDomains clusterv1.FailureDomains `json:"failureDomains,omitempty"`
+	Conditions     clusterv1.Conditions     `json:"conditions,omitempty"`
+}
+
+// OrphanedResourceType defines the type of an orphaned HCloud resource.
+type OrphanedResourceType string
+
+const (
+	// OrphanedResourceTypeServer is an orphaned HCloud server.
+	OrphanedResourceTypeServer = OrphanedResourceType("server")
+)
+
+// OrphanedResource is an HCloud resource that could not be deleted and still has to be cleaned up.
+type OrphanedResource struct {
+	// Type of the resource.
+	Type OrphanedResourceType `json:"type"`
+
+	// ID of the resource in HCloud. It is 0 for a server that was orphaned before its providerID had
+	// been set. Such a server is found by the labels of its cluster and machine.
+	ID int `json:"id"`
+
+	// Name of the object that owned the resource.
+	Name string `json:"name"`
+
+	// OrphanedAt is the time the resource was orphaned.
+	OrphanedAt metav1.Time `json:"orphanedAt"`
+}
+
+// ExhaustedLocationCooldown is the time for which a location is avoided after servers could not be
+// created in it due to a lack of capacity.
+const ExhaustedLocationCooldown = 15 * time.Minute
+
+// ExhaustedLocation is a location in which no servers of a type are available.
+type ExhaustedLocation struct {
+	// Location in which the capacity is exhausted.
+	Location Region `json:"location"`
+
+	// ServerType of which no servers are available.
+	ServerType HCloudMachineType `json:"serverType"`
+
+	// RetryAfter is the time after which servers are created in the location again.
+	RetryAfter metav1.Time `json:"retryAfter"`
 }
 
 // +kubebuilder:object:root=true
@@ -18,11 +18,16 @@ package v1beta1
 
 import (
 	"fmt"
+	"net"
 	"reflect"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/syself/cluster-api-provider-hetzner/pkg/utils"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
@@ -106,8 +111,17 @@ func (r *HetznerCluster) ValidateCreate() error {
 		}
 	}
 
-	// Check whether controlPlaneEndpoint is specified if controlPlaneLoadBalancer is not enabled
-	if !r.Spec.ControlPlaneLoadBalancer.Enabled {
+	if r.Spec.ControlPlaneFloatingIP != nil {
+		allErrs = append(allErrs, r.validateControlPlaneFloatingIP()...)
+	}
+
+	allErrs = append(allErrs, validateNATGateway(field.NewPath("spec", "hcloudNetwork", "natGateway"), r.Spec.HCloudNetwork)...)
+	allErrs = append(allErrs, validateExistingNetwork(field.NewPath("spec", "hcloudNetwork"), r.Spec.HCloudNetwork)...)
+	allErrs = append(allErrs, validateSubnets(field.NewPath("spec", "hcloudNetwork", "subnets"), r.Spec.HCloudNetwork)...)
+	allErrs = append(allErrs, validateRoutes(field.NewPath("spec", "hcloudNetwork", "routes"), r.Spec.HCloudNetwork)...)
+
+	// Check whether controlPlaneEndpoint is specified if neither controlPlaneLoadBalancer nor controlPlaneFloatingIP is enabled
+	if !r.Spec.ControlPlaneLoadBalancer.Enabled && r.Spec.ControlPlaneFloatingIP == nil {
 		if r.Spec.ControlPlaneEndpoint == nil ||
 			r.Spec.ControlPlaneEndpoint.Host == "" ||
 			r.Spec.ControlPlaneEndpoint.Port == 0 {
@@ -115,7 +129,7 @@ func (r *HetznerCluster) ValidateCreate() error {
 				field.Invalid(
 					field.NewPath("spec", "controlPlaneEndpoint"),
 					r.Spec.ControlPlaneEndpoint,
-					"controlPlaneEndpoint has to be specified if controlPlaneLoadBalancer is not enabled",
+					"controlPlaneEndpoint has to be specified if neither controlPlaneLoadBalancer nor controlPlaneFloatingIP is enabled",
 				),
 			)
 		}
@@ -125,9 +139,173 @@ func (r *HetznerCluster) ValidateCreate() error {
 		allErrs = append(allErrs, err)
 	}
 
+	allErrs = append(allErrs, validateDNSSpec(field.NewPath("spec", "dns"), r.Spec.DNS)...)
+	allErrs = append(allErrs, validateSSHSpec(field.NewPath("spec", "sshDefaults"), r.Spec.SSHDefaults)...)
+	allErrs = append(allErrs, r.validateLoadBalancerServices()...)
+	allErrs = append(allErrs, r.validatePlacementConstraints()...)
+	allErrs = append(allErrs, r.validateServerTypeSuccessors()...)
+	allErrs = append(allErrs, validateFirewalls(field.NewPath("spec", "hcloudFirewalls"), r.Spec.HCloudFirewalls)...)
+	allErrs = append(allErrs, validateProvisioningFirewall(field.NewPath("spec", "provisioningFirewall"), r.Spec.ProvisioningFirewall)...)
+	allErrs = append(allErrs, validateConsoleUser(field.NewPath("spec", "consoleUser"), r.Spec.ConsoleUser)...)
+	allErrs = append(allErrs, validateCloudInitParts(field.NewPath("spec", "cloudInitParts"), r.Spec.CloudInitParts)...)
+	allErrs = append(allErrs, validateDeletionPolicy(field.NewPath("spec", "deletionPolicy"), r.Spec.DeletionPolicy)...)
+
 	return aggregateObjErrors(r.GroupVersionKind().GroupKind(), r.Name, allErrs)
 }
 
+// validateNATGateway checks that the NAT gateway is an IPv4 in the range of the network.
+func validateNATGateway(fldPath *field.Path, network HCloudNetworkSpec) field.ErrorList {
+	if network.NATGateway == nil {
+		return nil
+	}
+	if !network.Enabled {
+		return field.ErrorList{field.Invalid(fldPath, network.NATGateway, "NAT gateway requires an enabled network")}
+	}
+	ip := net.ParseIP(network.NATGateway.IP)
+	if ip == nil || ip.To4() == nil {
+		return field.ErrorList{field.Invalid(fldPath.Child("ip"), network.NATGateway.IP, "has to be an IPv4 address")}
+	}
+	if _, ipRange, err := net.ParseCIDR(network.CIDRBlock); err == nil && !ipRange.Contains(ip) {
+		return field.ErrorList{field.Invalid(fldPath.Child("ip"), network.NATGateway.IP,
+			fmt.Sprintf("has to be in the range %s of the network", network.CIDRBlock))}
+	}
+	return nil
+}
+
+// validateExistingNetwork checks that an existing network is referenced either by ID or by name. The routes of an
+// existing network are not managed by the controller, so it cannot be combined with a NAT gateway.
+func validateExistingNetwork(fldPath *field.Path, network HCloudNetworkSpec) field.ErrorList {
+	ref := network.ExistingNetwork
+	if ref == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	if !network.Enabled {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("existingNetwork"), ref, "existing network requires an enabled network"))
+	}
+	if (ref.ID == nil) == (ref.Name == "") {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("existingNetwork"), ref, "exactly one of id and name has to be specified"))
+	}
+	if ref.ID != nil && *ref.ID <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("existingNetwork", "id"), *ref.ID, "has to be positive"))
+	}
+	if ref.SubnetCIDRBlock != "" {
+		if _, _, err := net.ParseCIDR(ref.SubnetCIDRBlock); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("existingNetwork", "subnetCidrBlock"), ref.SubnetCIDRBlock, err.Error()))
+		}
+	}
+	if network.NATGateway != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("natGateway"),
+			"NAT gateway cannot be combined with an existing network, the routes of the network have to be managed outside of the cluster"))
+	}
+	return allErrs
+}
+
+// validateRoutes checks that the routes have unique IPv4 destinations and gateways in the range of the network. The
+// default route is managed through the NAT gateway, and the routes of an existing network are not managed by the
+// controller.
+func validateRoutes(fldPath *field.Path, network HCloudNetworkSpec) field.ErrorList {
+	if len(network.Routes) == 0 {
+		return nil
+	}
+	if !network.Enabled {
+		return field.ErrorList{field.Invalid(fldPath, network.Routes, "routes require an enabled network")}
+	}
+	if network.ExistingNetwork != nil {
+		return field.ErrorList{field.Forbidden(fldPath,
+			"routes cannot be combined with an existing network, the routes of the network have to be managed outside of the cluster")}
+	}
+
+	_, networkRange, err := net.ParseCIDR(network.CIDRBlock)
+	if err != nil {
+		networkRange = nil
+	}
+
+	var allErrs field.ErrorList
+	destinations := make(map[string]struct{}, len(network.Routes))
+	for i, route := range network.Routes {
+		_, destination, err := net.ParseCIDR(route.Destination)
+		if err != nil || destination.IP.To4() == nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("destination"), route.Destination, "has to be an IPv4 CIDR block"))
+		} else if ones, _ := destination.Mask.Size(); ones == 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("destination"), route.Destination,
+				"the default route is managed with natGateway"))
+		} else if _, found := destinations[destination.String()]; found {
+			allErrs = append(allErrs, field.Duplicate(fldPath.Index(i).Child("destination"), route.Destination))
+		} else {
+			destinations[destination.String()] = struct{}{}
+		}
+
+		gateway := net.ParseIP(route.Gateway)
+		if gateway == nil || gateway.To4() == nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("gateway"), route.Gateway, "has to be an IPv4 address"))
+		} else if networkRange != nil && !networkRange.Contains(gateway) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("gateway"), route.Gateway,
+				fmt.Sprintf("has to be in the range %s of the network", network.CIDRBlock)))
+		}
+	}
+	return allErrs
+}
+
+// validateSubnets checks that the additional subnets have unique names and are valid IPv4 ranges that do not
+// overlap with each other and with the subnet subnetCidrBlock. The subnets of networks that are created by the
+// controller have to be in the range of the network.
+func validateSubnets(fldPath *field.Path, network HCloudNetworkSpec) field.ErrorList {
+	if len(network.Subnets) == 0 {
+		return nil
+	}
+	var allErrs field.ErrorList
+	if !network.Enabled {
+		return field.ErrorList{field.Invalid(fldPath, network.Subnets, "subnets require an enabled network")}
+	}
+
+	var ipRanges []*net.IPNet
+	if network.ExistingNetwork == nil {
+		if _, subnet, err := net.ParseCIDR(network.SubnetCIDRBlock); err == nil {
+			ipRanges = append(ipRanges, subnet)
+		}
+	}
+	_, networkRange, err := net.ParseCIDR(network.CIDRBlock)
+	if err != nil || network.ExistingNetwork != nil {
+		networkRange = nil
+	}
+
+	names := make(map[string]struct{}, len(network.Subnets))
+	for i, subnet := range network.Subnets {
+		if _, found := names[subnet.Name]; found {
+			allErrs = append(allErrs, field.Duplicate(fldPath.Index(i).Child("name"), subnet.Name))
+		}
+		names[subnet.Name] = struct{}{}
+
+		_, ipRange, err := net.ParseCIDR(subnet.CIDRBlock)
+		if err != nil || ipRange.IP.To4() == nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("cidrBlock"), subnet.CIDRBlock, "has to be an IPv4 CIDR block"))
+			continue
+		}
+		if networkRange != nil && !containsNetwork(networkRange, ipRange) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("cidrBlock"), subnet.CIDRBlock,
+				fmt.Sprintf("has to be in the range %s of the network", network.CIDRBlock)))
+			continue
+		}
+		for _, other := range ipRanges {
+			if other.Contains(ipRange.IP) || ipRange.Contains(other.IP) {
+				allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("cidrBlock"), subnet.CIDRBlock,
+					fmt.Sprintf("overlaps with subnet %s", other)))
+				break
+			}
+		}
+		ipRanges = append(ipRanges, ipRange)
+	}
+	return allErrs
+}
+
+// containsNetwork returns whether the IP range subnet is part of the IP range network.
+func containsNetwork(network, subnet *net.IPNet) bool {
+	networkOnes, _ := network.Mask.Size()
+	subnetOnes, _ := subnet.Mask.Size()
+	return network.Contains(subnet.IP) && subnetOnes >= networkOnes
+}
+
 func isNetworkZoneSameForAllRegions(regions []Region, defaultNetworkZone *string) *field.Error {
 	if len(regions) == 0 {
 		return nil
@@ -155,12 +333,25 @@ func (r *HetznerCluster) ValidateUpdate(old runtime.Object) error {
 		return apierrors.NewBadRequest(fmt.Sprintf("expected an HetznerCluster but got a %T", old))
 	}
 
-	// Network settings are immutable
-	if !reflect.DeepEqual(oldC.Spec.HCloudNetwork, r.Spec.HCloudNetwork) {
+	// Network settings are immutable, except for the NAT gateway, the routes and subnets that are added
+	oldNetwork, newNetwork := oldC.Spec.HCloudNetwork, r.Spec.HCloudNetwork
+	oldNetwork.NATGateway, newNetwork.NATGateway = nil, nil
+	oldNetwork.Routes, newNetwork.Routes = nil, nil
+	oldNetwork.Subnets, newNetwork.Subnets = nil, nil
+	if !reflect.DeepEqual(oldNetwork, newNetwork) {
 		allErrs = append(allErrs,
 			field.Invalid(field.NewPath("spec", "hcloudNetwork"), r.Spec.HCloudNetwork, "field is immutable"),
 		)
 	}
+	oldSubnets, newSubnets := oldC.Spec.HCloudNetwork.Subnets, r.Spec.HCloudNetwork.Subnets
+	if len(newSubnets) < len(oldSubnets) || !reflect.DeepEqual(oldSubnets, newSubnets[:len(oldSubnets)]) {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "hcloudNetwork", "subnets"), newSubnets, "subnets can only be added"),
+		)
+	}
+	allErrs = append(allErrs, validateNATGateway(field.NewPath("spec", "hcloudNetwork", "natGateway"), r.Spec.HCloudNetwork)...)
+	allErrs = append(allErrs, validateSubnets(field.NewPath("spec", "hcloudNetwork", "subnets"), r.Spec.HCloudNetwork)...)
+	allErrs = append(allErrs, validateRoutes(field.NewPath("spec", "hcloudNetwork", "routes"), r.Spec.HCloudNetwork)...)
 
 	// Check if all regions are in the same network zone if a private network is enabled
 	if oldC.Spec.HCloudNetwork.Enabled {
@@ -182,6 +373,13 @@ func (r *HetznerCluster) ValidateUpdate(old runtime.Object) error {
 		)
 	}
 
+	// The floating IP is the control plane endpoint, so it cannot be added, removed or changed
+	if !reflect.DeepEqual(oldC.Spec.ControlPlaneFloatingIP, r.Spec.ControlPlaneFloatingIP) {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "controlPlaneFloatingIP"), r.Spec.ControlPlaneFloatingIP, "field is immutable"),
+		)
+	}
+
 	// Load balancer region and port are immutable
 	if !reflect.DeepEqual(oldC.Spec.ControlPlaneLoadBalancer.Port, r.Spec.ControlPlaneLoadBalancer.Port) {
 		allErrs = append(allErrs,
@@ -198,9 +396,137 @@ func (r *HetznerCluster) ValidateUpdate(old runtime.Object) error {
 		allErrs = append(allErrs, err)
 	}
 
+	allErrs = append(allErrs, validateDNSSpec(field.NewPath("spec", "dns"), r.Spec.DNS)...)
+	allErrs = append(allErrs, validateSSHSpec(field.NewPath("spec", "sshDefaults"), r.Spec.SSHDefaults)...)
+	allErrs = append(allErrs, r.validateLoadBalancerServices()...)
+	allErrs = append(allErrs, r.validatePlacementConstraints()...)
+	allErrs = append(allErrs, r.validateServerTypeSuccessors()...)
+	allErrs = append(allErrs, validateFirewalls(field.NewPath("spec", "hcloudFirewalls"), r.Spec.HCloudFirewalls)...)
+	allErrs = append(allErrs, validateProvisioningFirewall(field.NewPath("spec", "provisioningFirewall"), r.Spec.ProvisioningFirewall)...)
+	allErrs = append(allErrs, validateConsoleUser(field.NewPath("spec", "consoleUser"), r.Spec.ConsoleUser)...)
+	allErrs = append(allErrs, validateCloudInitParts(field.NewPath("spec", "cloudInitParts"), r.Spec.CloudInitParts)...)
+	allErrs = append(allErrs, validateDeletionPolicy(field.NewPath("spec", "deletionPolicy"), r.Spec.DeletionPolicy)...)
+
 	return aggregateObjErrors(r.GroupVersionKind().GroupKind(), r.Name, allErrs)
 }
 
+func (r *HetznerCluster) validateLoadBalancerServices() field.ErrorList {
+	var allErrs field.ErrorList
+	fldPath := field.NewPath("spec", "controlPlaneLoadBalancer", "extraServices")
+	listenPorts := make(map[int]struct{}, len(r.Spec.ControlPlaneLoadBalancer.ExtraServices))
+
+	// The API server service listens on the port of the control plane endpoint
+	apiServerPort := r.Spec.ControlPlaneLoadBalancer.Port
+	if r.Spec.ControlPlaneEndpoint != nil && r.Spec.ControlPlaneEndpoint.Port != 0 {
+		apiServerPort = int(r.Spec.ControlPlaneEndpoint.Port)
+	}
+
+	for i, service := range r.Spec.ControlPlaneLoadBalancer.ExtraServices {
+		if service.ListenPort == apiServerPort {
+			allErrs = append(allErrs, field.Invalid(
+				fldPath.Index(i).Child("listenPort"), service.ListenPort, "listen port is used by the service of the API server"),
+			)
+		}
+		if _, found := listenPorts[service.ListenPort]; found {
+			allErrs = append(allErrs, field.Duplicate(fldPath.Index(i).Child("listenPort"), service.ListenPort))
+		}
+		listenPorts[service.ListenPort] = struct{}{}
+
+		allErrs = append(allErrs, validateLoadBalancerCertificate(fldPath.Index(i), service)...)
+	}
+
+	// the service of the API server is one of the services of the load balancer
+	maxServices, found := loadBalancerMaxServices[r.Spec.ControlPlaneLoadBalancer.Type]
+	if found && len(r.Spec.ControlPlaneLoadBalancer.ExtraServices) > maxServices-1 {
+		allErrs = append(allErrs, field.TooMany(fldPath, len(r.Spec.ControlPlaneLoadBalancer.ExtraServices), maxServices-1))
+	}
+
+	allErrs = append(allErrs, validateLoadBalancerHealthCheck(
+		field.NewPath("spec", "controlPlaneLoadBalancer", "healthCheck"), r.Spec.ControlPlaneLoadBalancer.HealthCheck)...)
+	return allErrs
+}
+
+// loadBalancerMaxServices is the maximum number of services per type of load balancer.
+var loadBalancerMaxServices = map[string]int{
+	"lb11": 5,
+	"lb21": 15,
+	"lb31": 30,
+}
+
+// minLoadBalancerHealthCheckInterval is the shortest interval of health checks that HCloud accepts.
+const minLoadBalancerHealthCheckInterval = 3 * time.Second
+
+// validateLoadBalancerHealthCheck checks that the interval and timeout are whole seconds, as HCloud only supports
+// seconds, and that a health check ends before the next one starts.
+func validateLoadBalancerHealthCheck(fldPath *field.Path, healthCheck *LoadBalancerHealthCheckSpec) field.ErrorList {
+	if healthCheck == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	interval := healthCheck.IntervalOrDefault()
+	timeout := healthCheck.TimeoutOrDefault()
+	if interval%time.Second != 0 || interval < minLoadBalancerHealthCheckInterval {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("interval"), interval.String(), "has to be whole seconds and at least 3s"))
+	}
+	if timeout%time.Second != 0 || timeout < time.Second {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("timeout"), timeout.String(), "has to be whole seconds and at least 1s"))
+	}
+	if timeout > interval {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("timeout"), timeout.String(), "must not exceed the interval"))
+	}
+	return allErrs
+}
+
+func validateLoadBalancerCertificate(fldPath *field.Path, service LoadBalancerServiceSpec) field.ErrorList {
+	var allErrs field.ErrorList
+	cert := service.Certificate
+
+	if service.Protocol != "https" {
+		if cert != nil {
+			allErrs = append(allErrs,
+				field.Invalid(fldPath.Child("certificate"), cert, "certificate is only allowed for the protocol https"),
+			)
+		}
+		return allErrs
+	}
+
+	if cert == nil {
+		return append(allErrs, field.Required(fldPath.Child("certificate"), "certificate is required for the protocol https"))
+	}
+
+	switch cert.Type {
+	case LoadBalancerCertificateTypeManaged:
+		if len(cert.DomainNames) == 0 {
+			allErrs = append(allErrs,
+				field.Required(fldPath.Child("certificate", "domainNames"), "domain names are required for managed certificates"),
+			)
+		}
+		for i, domainName := range cert.DomainNames {
+			// Wildcard domains are allowed
+			for _, msg := range validation.IsDNS1123Subdomain(strings.TrimPrefix(domainName, "*.")) {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("certificate", "domainNames").Index(i), domainName, msg))
+			}
+		}
+		if cert.Name != "" {
+			allErrs = append(allErrs,
+				field.Invalid(fldPath.Child("certificate", "name"), cert.Name, "name is only allowed for uploaded certificates"),
+			)
+		}
+	case LoadBalancerCertificateTypeUploaded:
+		if cert.Name == "" {
+			allErrs = append(allErrs,
+				field.Required(fldPath.Child("certificate", "name"), "name is required for uploaded certificates"),
+			)
+		}
+		if len(cert.DomainNames) > 0 {
+			allErrs = append(allErrs,
+				field.Invalid(fldPath.Child("certificate", "domainNames"), cert.DomainNames, "domain names are only allowed for managed certificates"),
+			)
+		}
+	}
+	return allErrs
+}
+
 func (r *HetznerCluster) validateHetznerSecretKey() *field.Error {
 	// Hetzner secret key needs to contain either HCloud or Hrobot credentials
 	if r.Spec.HetznerSecret.Key.HCloudToken == "" &&
@@ -219,3 +545,286 @@ func (r *HetznerCluster) ValidateDelete() error {
 	hetznerclusterlog.V(1).Info("validate delete", "name", r.Name)
 	return nil
 }
+
+// firewallPortRegex matches a port, a port range or any.
+var firewallPortRegex = regexp.MustCompile(`^(any|[0-9]{1,5}(-[0-9]{1,5})?)$`)
+
+// validateFirewalls checks that the names of the firewalls are unique and that the rules are accepted by HCloud.
+func validateFirewalls(fldPath *field.Path, firewalls []HCloudFirewallSpec) field.ErrorList {
+	var allErrs field.ErrorList
+	names := make(map[string]struct{}, len(firewalls))
+	for i, firewall := range firewalls {
+		if _, found := names[firewall.Name]; found {
+			allErrs = append(allErrs, field.Duplicate(fldPath.Index(i).Child("name"), firewall.Name))
+		}
+		names[firewall.Name] = struct{}{}
+
+		for j, rule := range firewall.Rules {
+			allErrs = append(allErrs, validateFirewallRule(fldPath.Index(i).Child("rules").Index(j), rule)...)
+		}
+	}
+	return allErrs
+}
+
+var consoleUserNameRegex = regexp.MustCompile(`^[a-z_][a-z0-9_-]*$`)
+
+// reservedConsoleUserNames are users of the operating system and of common images, which must not be changed or
+// removed as console user.
+var reservedConsoleUserNames = map[string]struct{}{
+	"root": {}, "daemon": {}, "bin": {}, "sys": {}, "sync": {}, "games": {}, "man": {}, "lp": {}, "mail": {},
+	"news": {}, "uucp": {}, "proxy": {}, "www-data": {}, "backup": {}, "list": {}, "irc": {}, "gnats": {},
+	"nobody": {}, "nogroup": {}, "_apt": {}, "messagebus": {}, "syslog": {}, "sshd": {}, "polkitd": {},
+	"tss": {}, "uuidd": {}, "tcpdump": {}, "landscape": {}, "pollinate": {}, "usbmux": {}, "lxd": {},
+	"ubuntu": {}, "debian": {}, "admin": {}, "adm": {}, "sudo": {}, "wheel": {}, "operator": {}, "halt": {},
+	"shutdown": {}, "ftp": {}, "chrony": {}, "containerd": {}, "etcd": {}, "kubelet": {},
+}
+
+// validateConsoleUser checks that the name of the console user is a valid Linux user name and not a user of the
+// operating system, as the user is created, changed and removed on the machines.
+func validateConsoleUser(fldPath *field.Path, consoleUser *ConsoleUserSpec) field.ErrorList {
+	if consoleUser == nil || consoleUser.Name == "" {
+		return nil
+	}
+	var allErrs field.ErrorList
+	name := consoleUser.Name
+	if !consoleUserNameRegex.MatchString(name) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("name"), name,
+			fmt.Sprintf("name has to match %q", consoleUserNameRegex.String())))
+	}
+	if _, reserved := reservedConsoleUserNames[name]; reserved || strings.HasPrefix(name, "systemd-") {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("name"), fmt.Sprintf("%s is a user of the operating system", name)))
+	}
+	return allErrs
+}
+
+// validateCloudInitParts checks that the parts have unique names and either an inline cloud-config or a secret.
+func validateCloudInitParts(fldPath *field.Path, parts []CloudInitPart) field.ErrorList {
+	var allErrs field.ErrorList
+	names := make(map[string]struct{}, len(parts))
+	for i, part := range parts {
+		if _, found := names[part.Name]; found {
+			allErrs = append(allErrs, field.Duplicate(fldPath.Index(i).Child("name"), part.Name))
+		}
+		names[part.Name] = struct{}{}
+
+		switch {
+		case part.Content == "" && part.SecretRef == nil:
+			allErrs = append(allErrs, field.Required(fldPath.Index(i), "either content or secretRef has to be specified"))
+		case part.Content != "" && part.SecretRef != nil:
+			allErrs = append(allErrs, field.Forbidden(fldPath.Index(i), "content and secretRef are mutually exclusive"))
+		case part.Content != "" && !strings.HasPrefix(part.Content, CloudConfigPrefix):
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("content"), part.Name,
+				fmt.Sprintf("content has to be a cloud-config starting with %q", CloudConfigPrefix)))
+		}
+	}
+	return allErrs
+}
+
+// deletionDependencies are the deletion steps that have to run before a step, because the HCloud API refuses to
+// delete its resources otherwise. A network cannot be deleted while the load balancer is attached to it.
+var deletionDependencies = map[DeletionStep][]DeletionStep{
+	DeletionStepNetwork: {DeletionStepLoadBalancer},
+}
+
+// deletionParents are the deletion steps that delete the resources of a step together with their own. Deleting the
+// network deletes its subnets, and deleting the load balancer removes its targets.
+var deletionParents = map[DeletionStep]DeletionStep{
+	DeletionStepSubnets:             DeletionStepNetwork,
+	DeletionStepLoadBalancerTargets: DeletionStepLoadBalancer,
+}
+
+// validateDeletionPolicy checks that the deletion steps are unique, that no step can wait forever for a step
+// it depends on, because that one is skipped or runs later, and that no step is skipped whose resources are
+// deleted by its parent step anyway.
+func validateDeletionPolicy(fldPath *field.Path, policy *ClusterDeletionPolicy) field.ErrorList {
+	if policy == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	allErrs = append(allErrs, validateUniqueDeletionSteps(fldPath.Child("order"), policy.Order)...)
+	allErrs = append(allErrs, validateUniqueDeletionSteps(fldPath.Child("skip"), policy.Skip)...)
+
+	position := make(map[DeletionStep]int, len(DefaultDeletionOrder))
+	for i, step := range policy.Steps() {
+		position[step] = i
+	}
+	for _, step := range DefaultDeletionOrder {
+		if parent, found := deletionParents[step]; found && policy.IsSkipped(step) && !policy.IsSkipped(parent) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("skip"), policy.Skip,
+				fmt.Sprintf("step %s cannot be skipped unless step %s is skipped as well, which deletes its resources", step, parent)))
+		}
+		if policy.IsSkipped(step) {
+			continue
+		}
+		for _, dependency := range deletionDependencies[step] {
+			if policy.IsSkipped(dependency) {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("skip"), policy.Skip,
+					fmt.Sprintf("step %s cannot be skipped unless step %s is skipped as well", dependency, step)))
+			} else if position[dependency] > position[step] {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("order"), policy.Order,
+					fmt.Sprintf("step %s has to run before step %s", dependency, step)))
+			}
+		}
+	}
+	return allErrs
+}
+
+func validateUniqueDeletionSteps(fldPath *field.Path, steps []DeletionStep) field.ErrorList {
+	var allErrs field.ErrorList
+	seen := make(map[DeletionStep]struct{}, len(steps))
+	for i, step := range steps {
+		if _, found := seen[step]; found {
+			allErrs = append(allErrs, field.Duplicate(fldPath.Index(i), step))
+		}
+		seen[step] = struct{}{}
+	}
+	return allErrs
+}
+
+func validateFirewallRule(fldPath *field.Path, rule HCloudFirewallRule) field.ErrorList {
+	var allErrs field.ErrorList
+	switch rule.Protocol {
+	case "tcp", "udp":
+		if !firewallPortRegex.MatchString(rule.Port) {
+			allErrs = append(allErrs,
+				field.Invalid(fldPath.Child("port"), rule.Port, "port must be a port, a port range like 30000-32767 or any"),
+			)
+		}
+	default:
+		if rule.Port != "" {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("port"), fmt.Sprintf("port is not allowed for protocol %s", rule.Protocol)))
+		}
+	}
+
+	switch rule.Direction {
+	case "in":
+		if len(rule.SourceIPs) == 0 {
+			allErrs = append(allErrs, field.Required(fldPath.Child("sourceIPs"), "inbound rules need source IPs"))
+		}
+		if len(rule.DestinationIPs) > 0 {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("destinationIPs"), "inbound rules must not have destination IPs"))
+		}
+	case "out":
+		if len(rule.DestinationIPs) == 0 {
+			allErrs = append(allErrs, field.Required(fldPath.Child("destinationIPs"), "outbound rules need destination IPs"))
+		}
+		if len(rule.SourceIPs) > 0 {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("sourceIPs"), "outbound rules must not have source IPs"))
+		}
+	}
+
+	for i, cidr := range rule.SourceIPs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("sourceIPs").Index(i), cidr, "must be a CIDR"))
+		}
+	}
+	for i, cidr := range rule.DestinationIPs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("destinationIPs").Index(i), cidr, "must be a CIDR"))
+		}
+	}
+	return allErrs
+}
+
+func validateProvisioningFirewall(fldPath *field.Path, firewall *ProvisioningFirewall) field.ErrorList {
+	if firewall == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	for i, cidr := range firewall.SourceIPs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("sourceIPs").Index(i), cidr, "must be a CIDR"))
+		}
+	}
+	return allErrs
+}
+
+// validateServerTypeSuccessors checks that server types are only succeeded by server types of the same
+// architecture, as the image of a machine exists only for the architecture of its server type.
+func (r *HetznerCluster) validateServerTypeSuccessors() field.ErrorList {
+	var allErrs field.ErrorList
+	for serverType, successor := range r.Spec.HCloudServerTypeSuccessors {
+		if HCloudMachineType(serverType).Architecture() != successor.Architecture() {
+			allErrs = append(allErrs,
+				field.Invalid(field.NewPath("spec", "hcloudServerTypeSuccessors").Key(serverType), successor,
+					"successor must have the same architecture as the server type"),
+			)
+		}
+	}
+	return allErrs
+}
+
+// validatePlacementConstraints checks that no location or datacenter is both allowed and denied and that the
+// control plane regions and the region of the load balancer are allowed.
+func (r *HetznerCluster) validatePlacementConstraints() field.ErrorList {
+	pc := r.Spec.PlacementConstraints
+	if pc == nil {
+		return nil
+	}
+	path := field.NewPath("spec", "placementConstraints")
+
+	var allErrs field.ErrorList
+	for _, location := range pc.DeniedLocations {
+		if regionInList(pc.AllowedLocations, location) {
+			allErrs = append(allErrs,
+				field.Invalid(path.Child("deniedLocations"), location, "location must not be allowed and denied"),
+			)
+		}
+	}
+	for _, datacenter := range pc.DeniedDatacenters {
+		for _, allowed := range pc.AllowedDatacenters {
+			if strings.EqualFold(allowed, datacenter) {
+				allErrs = append(allErrs,
+					field.Invalid(path.Child("deniedDatacenters"), datacenter, "datacenter must not be allowed and denied"),
+				)
+			}
+		}
+	}
+
+	for _, region := range r.Spec.ControlPlaneRegions {
+		if !pc.IsLocationAllowed(region) {
+			allErrs = append(allErrs,
+				field.Invalid(field.NewPath("spec", "controlPlaneRegions"), region, "region is not allowed by the placement constraints"),
+			)
+		}
+	}
+	if r.Spec.ControlPlaneLoadBalancer.Enabled && !pc.IsLocationAllowed(r.Spec.ControlPlaneLoadBalancer.Region) {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "controlPlaneLoadBalancer", "region"), r.Spec.ControlPlaneLoadBalancer.Region,
+				"region is not allowed by the placement constraints"),
+		)
+	}
+	if fip := r.Spec.ControlPlaneFloatingIP; fip != nil && fip.HomeLocation != "" && !pc.IsLocationAllowed(fip.HomeLocation) {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "controlPlaneFloatingIP", "homeLocation"), fip.HomeLocation,
+				"home location is not allowed by the placement constraints"),
+		)
+	}
+	return allErrs
+}
+
+// validateControlPlaneFloatingIP checks that the floating IP is not combined with the control plane load balancer
+// and that its home location exists.
+func (r *HetznerCluster) validateControlPlaneFloatingIP() field.ErrorList {
+	var allErrs field.ErrorList
+	fldPath := field.NewPath("spec", "controlPlaneFloatingIP")
+
+	if r.Spec.ControlPlaneLoadBalancer.Enabled {
+		allErrs = append(allErrs,
+			field.Forbidden(fldPath, "controlPlaneFloatingIP cannot be used together with an enabled controlPlaneLoadBalancer"),
+		)
+	}
+
+	if homeLocation := r.Spec.ControlPlaneFloatingIP.HomeLocation; homeLocation != "" {
+		if _, ok := regionNetworkZoneMap[string(homeLocation)]; !ok {
+			allErrs = append(allErrs,
+				field.Invalid(fldPath.Child("homeLocation"), homeLocation, "wrong home location. Should be fsn1, nbg1, hel1, or ash"),
+			)
+		}
+	} else if len(r.Spec.ControlPlaneRegions) == 0 {
+		allErrs = append(allErrs,
+			field.Required(fldPath.Child("homeLocation"), "homeLocation is required if no control plane regions are specified"),
+		)
+	}
+	return allErrs
+}
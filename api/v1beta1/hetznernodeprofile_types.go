@@ -0,0 +1,99 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// HetznerNodeProfileSpec defines provisioning settings of nodes that are shared by the machines of several
+// HCloudMachineTemplates and HetznerBareMetalMachineTemplates. The settings are added to the user data when a
+// server is created or a bare metal host is provisioned.
+type HetznerNodeProfileSpec struct {
+	// SSHAuthorizedKeys are public keys that are authorized to log in as the default user of the image.
+	// +optional
+	SSHAuthorizedKeys []string `json:"sshAuthorizedKeys,omitempty"`
+
+	// NTPServers are the time servers of the node. If empty, the time servers of the image are used.
+	// +optional
+	NTPServers []string `json:"ntpServers,omitempty"`
+
+	// RegistryMirrors are mirrors of container registries from which containerd pulls images. Containerd of the
+	// image has to read the registry configuration from /etc/containerd/certs.d.
+	// +optional
+	RegistryMirrors []RegistryMirror `json:"registryMirrors,omitempty"`
+
+	// Sysctls are kernel parameters of the node, e.g. net.core.somaxconn.
+	// +optional
+	Sysctls map[string]string `json:"sysctls,omitempty"`
+
+	// Hardening restricts the access to the node.
+	// +optional
+	Hardening *NodeHardening `json:"hardening,omitempty"`
+}
+
+// RegistryMirror defines the mirrors of a container registry.
+type RegistryMirror struct {
+	// Registry is the host of the registry, e.g. docker.io or registry.k8s.io.
+	// +kubebuilder:validation:MinLength=1
+	Registry string `json:"registry"`
+
+	// Endpoints are the URLs of the mirrors. They are tried in order before the registry itself.
+	// +kubebuilder:validation:MinItems=1
+	Endpoints []string `json:"endpoints"`
+}
+
+// NodeHardening defines restrictions of the access to a node.
+type NodeHardening struct {
+	// DisableSSHPasswordAuthentication only allows SSH logins with keys.
+	// +optional
+	DisableSSHPasswordAuthentication bool `json:"disableSSHPasswordAuthentication,omitempty"`
+
+	// DisableSSHForwarding forbids TCP, agent and X11 forwarding through SSH.
+	// +optional
+	DisableSSHForwarding bool `json:"disableSSHForwarding,omitempty"`
+
+	// DisabledKernelModules are kernel modules that cannot be loaded, e.g. unused filesystems and protocols.
+	// +optional
+	DisabledKernelModules []string `json:"disabledKernelModules,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=hetznernodeprofiles,scope=Namespaced,categories=cluster-api,shortName=hnp
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of HetznerNodeProfile"
+// +k8s:defaulter-gen=true
+
+// HetznerNodeProfile is the Schema for the hetznernodeprofiles API.
+type HetznerNodeProfile struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec HetznerNodeProfileSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// HetznerNodeProfileList contains a list of HetznerNodeProfile.
+type HetznerNodeProfileList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []HetznerNodeProfile `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&HetznerNodeProfile{}, &HetznerNodeProfileList{})
+}
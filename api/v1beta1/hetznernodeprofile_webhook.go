@@ -0,0 +1,128 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/syself/cluster-api-provider-hetzner/pkg/utils"
+	"golang.org/x/crypto/ssh"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var hetznernodeprofilelog = utils.GetDefaultLogger("info").WithName("hetznernodeprofile-resource")
+
+var (
+	sysctlKeyRegex    = regexp.MustCompile(`^[a-z0-9_-]+([./][a-z0-9_-]+)*$`)
+	kernelModuleRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+	registryHostRegex = regexp.MustCompile(`^[a-zA-Z0-9.-]+(:[0-9]+)?$`)
+)
+
+// SetupWebhookWithManager initializes webhook manager for HetznerNodeProfile.
+func (r *HetznerNodeProfile) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-infrastructure-cluster-x-k8s-io-v1beta1-hetznernodeprofile,mutating=false,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=hetznernodeprofiles,verbs=create;update,versions=v1beta1,name=validation.hetznernodeprofile.infrastructure.cluster.x-k8s.io,admissionReviewVersions={v1,v1beta1}
+
+var _ webhook.Validator = &HetznerNodeProfile{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
+func (r *HetznerNodeProfile) ValidateCreate() error {
+	hetznernodeprofilelog.V(1).Info("validate create", "name", r.Name)
+	return aggregateObjErrors(r.GroupVersionKind().GroupKind(), r.Name, validateNodeProfileSpec(field.NewPath("spec"), &r.Spec))
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type. Profiles can be changed,
+// the changes apply to the servers that are created and the hosts that are provisioned afterwards.
+func (r *HetznerNodeProfile) ValidateUpdate(old runtime.Object) error {
+	hetznernodeprofilelog.V(1).Info("validate update", "name", r.Name)
+	return aggregateObjErrors(r.GroupVersionKind().GroupKind(), r.Name, validateNodeProfileSpec(field.NewPath("spec"), &r.Spec))
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
+func (r *HetznerNodeProfile) ValidateDelete() error {
+	hetznernodeprofilelog.V(1).Info("validate delete", "name", r.Name)
+	return nil
+}
+
+func validateNodeProfileSpec(fldPath *field.Path, spec *HetznerNodeProfileSpec) field.ErrorList {
+	var allErrs field.ErrorList
+
+	for i, key := range spec.SSHAuthorizedKeys {
+		if _, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key)); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("sshAuthorizedKeys").Index(i), key, "invalid public key"))
+		}
+	}
+
+	for i, server := range spec.NTPServers {
+		if server == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("ntpServers").Index(i), "must not be empty"))
+		}
+	}
+
+	registries := make(map[string]struct{}, len(spec.RegistryMirrors))
+	for i, mirror := range spec.RegistryMirrors {
+		mirrorPath := fldPath.Child("registryMirrors").Index(i)
+		if !registryHostRegex.MatchString(mirror.Registry) {
+			allErrs = append(allErrs, field.Invalid(mirrorPath.Child("registry"), mirror.Registry, "must be the host of a registry"))
+		}
+		if _, found := registries[mirror.Registry]; found {
+			allErrs = append(allErrs, field.Duplicate(mirrorPath.Child("registry"), mirror.Registry))
+		}
+		registries[mirror.Registry] = struct{}{}
+		for j, endpoint := range mirror.Endpoints {
+			if u, err := url.Parse(endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				allErrs = append(allErrs, field.Invalid(mirrorPath.Child("endpoints").Index(j), endpoint, "must be an http or https URL"))
+			}
+		}
+	}
+
+	sysctls := make([]string, 0, len(spec.Sysctls))
+	for key := range spec.Sysctls {
+		sysctls = append(sysctls, key)
+	}
+	sort.Strings(sysctls)
+	for _, key := range sysctls {
+		value := spec.Sysctls[key]
+		if !sysctlKeyRegex.MatchString(key) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("sysctls").Key(key), key, "invalid kernel parameter"))
+		}
+		if value == "" || strings.ContainsAny(value, "\r\n") {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("sysctls").Key(key), value, "must be a non-empty single line"))
+		}
+	}
+
+	if hardening := spec.Hardening; hardening != nil {
+		for i, module := range hardening.DisabledKernelModules {
+			if !kernelModuleRegex.MatchString(module) {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("hardening", "disabledKernelModules").Index(i), module,
+					"invalid kernel module name"))
+			}
+		}
+	}
+	return allErrs
+}
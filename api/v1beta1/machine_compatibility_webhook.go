@@ -0,0 +1,135 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/syself/cluster-api-provider-hetzner/pkg/compatibility"
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// machineCompatibilityWebhookPath is the path of the webhook that checks the Kubernetes version of Machines.
+const machineCompatibilityWebhookPath = "/validate-cluster-x-k8s-io-v1beta1-machine-compatibility"
+
+// MachineCompatibilityWebhook checks whether the Kubernetes version of a Machine is supported by the operating
+// system of the image of its HCloudMachine or HetznerBareMetalMachine, according to the compatibility matrix.
+// +kubebuilder:object:generate=false
+type MachineCompatibilityWebhook struct {
+	// Client reads the infrastructure machines. It should not be cached, as they are created just before the Machine.
+	Client client.Reader
+	Policy compatibility.Policy
+}
+
+// SetupWebhookWithManager registers the webhook.
+func (r *MachineCompatibilityWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register(machineCompatibilityWebhookPath, &webhook.Admission{Handler: r})
+	return nil
+}
+
+// +kubebuilder:webhook:path=/validate-cluster-x-k8s-io-v1beta1-machine-compatibility,mutating=false,failurePolicy=ignore,sideEffects=None,groups=cluster.x-k8s.io,resources=machines,verbs=create;update,versions=v1beta1,name=compatibility.machine.infrastructure.cluster.x-k8s.io,admissionReviewVersions=v1;v1beta1
+
+var _ admission.Handler = &MachineCompatibilityWebhook{}
+
+// Handle implements admission.Handler. Machines are admitted if their version or image cannot be determined,
+// e.g. for Machines of other providers or custom images.
+func (r *MachineCompatibilityWebhook) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if r.Policy == compatibility.PolicyIgnore {
+		return admission.Allowed("")
+	}
+
+	var machine clusterv1.Machine
+	if err := json.Unmarshal(req.Object.Raw, &machine); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if machine.Spec.Version == nil {
+		return admission.Allowed("")
+	}
+	if req.Operation == admissionv1.Update {
+		var oldMachine clusterv1.Machine
+		if err := json.Unmarshal(req.OldObject.Raw, &oldMachine); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if oldMachine.Spec.Version != nil && *oldMachine.Spec.Version == *machine.Spec.Version {
+			return admission.Allowed("")
+		}
+	}
+
+	image, err := r.machineImage(ctx, &machine)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if image == "" {
+		return admission.Allowed("")
+	}
+
+	if err := compatibility.Check(image, *machine.Spec.Version); err != nil {
+		msg := fmt.Sprintf("Machine %s: %s", machine.Name, err)
+		if r.Policy == compatibility.PolicyDeny {
+			return admission.Denied(msg)
+		}
+		return admission.Allowed("").WithWarnings(msg)
+	}
+	return admission.Allowed("")
+}
+
+// machineImage returns the image of the infrastructure machine of the Machine. It is empty for infrastructure
+// machines of other providers, infrastructure machines that do not exist yet and templated images.
+func (r *MachineCompatibilityWebhook) machineImage(ctx context.Context, machine *clusterv1.Machine) (string, error) {
+	ref := machine.Spec.InfrastructureRef
+	if !strings.HasPrefix(ref.APIVersion, GroupVersion.Group+"/") {
+		return "", nil
+	}
+	key := types.NamespacedName{Namespace: machine.Namespace, Name: ref.Name}
+
+	var image string
+	switch ref.Kind {
+	case "HCloudMachine":
+		var hcloudMachine HCloudMachine
+		if err := r.Client.Get(ctx, key, &hcloudMachine); err != nil {
+			return "", client.IgnoreNotFound(err)
+		}
+		image = hcloudMachine.Spec.ImageName
+	case "HetznerBareMetalMachine":
+		var bmMachine HetznerBareMetalMachine
+		if err := r.Client.Get(ctx, key, &bmMachine); err != nil {
+			return "", client.IgnoreNotFound(err)
+		}
+		image = bmMachine.Spec.InstallImage.Image.Name
+		if image == "" {
+			image = bmMachine.Spec.InstallImage.Image.URL
+		}
+		if image == "" {
+			image = bmMachine.Spec.InstallImage.Image.Path
+		}
+	}
+
+	if strings.Contains(image, "{{") {
+		return "", nil
+	}
+	return image, nil
+}
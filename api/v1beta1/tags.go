@@ -49,6 +49,9 @@ const (
 
 	// MachineNameTagKey tags related MachineNameTag.
 	MachineNameTagKey = "machine." + NameHetznerProviderPrefix + "name"
+
+	// PlacementGroupMachineGroupTagKey tags automatic placement groups with the group of their machines.
+	PlacementGroupMachineGroupTagKey = "placementgroup." + NameHetznerProviderPrefix + "machine-group"
 )
 
 // ClusterTagKey generates the key for resources associated with a cluster.
@@ -17,7 +17,12 @@ limitations under the License.
 package v1beta1
 
 import (
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/hetznercloud/hcloud-go/hcloud"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // LoadBalancerAlgorithmType defines the Algorithm type.
@@ -46,13 +51,25 @@ const (
 	LoadBalancerTargetTypeIP = LoadBalancerTargetType("ip")
 )
 
+// LoadBalancerCertificateType defines how the certificate of a load balancer service is provided.
+// +kubebuilder:validation:Enum=managed;uploaded
+type LoadBalancerCertificateType string
+
+const (
+	// LoadBalancerCertificateTypeManaged lets HCloud issue and renew the certificate.
+	LoadBalancerCertificateTypeManaged = LoadBalancerCertificateType("managed")
+
+	// LoadBalancerCertificateTypeUploaded refers to a certificate that has been uploaded to HCloud.
+	LoadBalancerCertificateTypeUploaded = LoadBalancerCertificateType("uploaded")
+)
+
 // HCloudAlgorithmType converts LoadBalancerAlgorithmType to hcloud type.
 func (algorithmType *LoadBalancerAlgorithmType) HCloudAlgorithmType() hcloud.LoadBalancerAlgorithmType {
 	switch *algorithmType {
 	case LoadBalancerAlgorithmTypeLeastConnections:
-		return hcloud.LoadBalancerAlgorithmTypeRoundRobin
-	case LoadBalancerAlgorithmTypeRoundRobin:
 		return hcloud.LoadBalancerAlgorithmTypeLeastConnections
+	case LoadBalancerAlgorithmTypeRoundRobin:
+		return hcloud.LoadBalancerAlgorithmTypeRoundRobin
 	}
 	return hcloud.LoadBalancerAlgorithmType("")
 }
@@ -74,9 +91,68 @@ type SSHKey struct {
 	Fingerprint string `json:"fingerprint,omitempty"`
 }
 
+// SSHKeyReference references an SSH key in HCloud by its name or a public key in a secret. Exactly one of
+// name and secretRef has to be set.
+type SSHKeyReference struct {
+	// Name is the name of the SSH key in HCloud.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// SecretRef references a public key in a secret in the namespace of the machine. The public key is added to
+	// HCloud if the project has no SSH key with the same fingerprint.
+	// +optional
+	SecretRef *SSHPublicKeySecretRef `json:"secretRef,omitempty"`
+}
+
+// SSHPublicKeySecretRef references a public key in a secret.
+type SSHPublicKeySecretRef struct {
+	// Name is the name of the secret.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Key is the key of the public key in the data of the secret.
+	// +kubebuilder:default=publicKey
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// SSHPublicKeyKey returns the key of the public key in the data of the secret.
+func (r *SSHPublicKeySecretRef) SSHPublicKeyKey() string {
+	if r.Key == "" {
+		return "publicKey"
+	}
+	return r.Key
+}
+
 // HCloudMachineType defines the HCloud Machine type.
 type HCloudMachineType string
 
+// Architecture returns the CPU architecture of the server type. Server types of the CAX series are Arm64 servers.
+func (t HCloudMachineType) Architecture() Architecture {
+	if strings.HasPrefix(string(t), "cax") {
+		return ArchitectureARM
+	}
+	return ArchitectureX86
+}
+
+// Architecture is the CPU architecture of HCloud server types and images, as named by the HCloud API.
+type Architecture string
+
+const (
+	// ArchitectureX86 is the architecture of the server types with Intel or AMD CPUs.
+	ArchitectureX86 Architecture = "x86"
+	// ArchitectureARM is the architecture of the server types with Arm64 CPUs.
+	ArchitectureARM Architecture = "arm"
+)
+
+// GoArch returns the name of the architecture as used by Go and the kubernetes.io/arch label of nodes.
+func (a Architecture) GoArch() string {
+	if a == ArchitectureARM {
+		return "arm64"
+	}
+	return "amd64"
+}
+
 // ResourceLifecycle configures the lifecycle of a resource.
 type ResourceLifecycle string
 
@@ -97,6 +173,56 @@ type HCloudPlacementGroupStatus struct {
 	Type   string `json:"type,omitempty"`
 }
 
+// HCloudFirewallSpec defines a firewall that is created for the cluster and applied to its servers.
+type HCloudFirewallSpec struct {
+	// Name of the firewall. The firewall is named <name of the HetznerCluster>-<name> in HCloud.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Rules of the firewall. Inbound traffic is blocked unless a rule allows it. Outbound traffic is allowed,
+	// unless the firewall has outbound rules. Then only the outbound traffic of the rules is allowed.
+	// +optional
+	Rules []HCloudFirewallRule `json:"rules,omitempty"`
+
+	// ApplyToLabelSelector is an HCloud label selector, e.g. machine_type==control_plane, that selects the
+	// servers of the cluster that the firewall is applied to. Defaults to all servers of the cluster.
+	// +optional
+	ApplyToLabelSelector string `json:"applyToLabelSelector,omitempty"`
+}
+
+// HCloudFirewallRule defines a rule of an HCloud firewall.
+type HCloudFirewallRule struct {
+	// Direction of the traffic.
+	// +kubebuilder:validation:Enum=in;out
+	Direction string `json:"direction"`
+
+	// Protocol of the traffic.
+	// +kubebuilder:validation:Enum=tcp;udp;icmp;esp;gre
+	Protocol string `json:"protocol"`
+
+	// Port or port range, e.g. 443 or 30000-32767, or any. Required for tcp and udp, not allowed for other protocols.
+	// +optional
+	Port string `json:"port,omitempty"`
+
+	// SourceIPs are the CIDRs that inbound traffic is allowed from. Required for inbound rules.
+	// +optional
+	SourceIPs []string `json:"sourceIPs,omitempty"`
+
+	// DestinationIPs are the CIDRs that outbound traffic is allowed to. Required for outbound rules.
+	// +optional
+	DestinationIPs []string `json:"destinationIPs,omitempty"`
+
+	// Description of the rule.
+	// +optional
+	Description string `json:"description,omitempty"`
+}
+
+// HCloudFirewallStatus returns the status of a firewall of the cluster.
+type HCloudFirewallStatus struct {
+	ID   int    `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
 // HetznerSecretRef defines all the name of the secret and the relevant keys needed to access Hetzner API.
 type HetznerSecretRef struct {
 	Name string              `json:"name"`
@@ -122,6 +248,30 @@ type PublicNetworkSpec struct {
 	// +optional
 	// +kubebuilder:default=true
 	EnableIPv6 bool `json:"enableIPv6"`
+	// PrimaryIPSelector selects HCloudPrimaryIPs in the namespace of the machine. On server creation,
+	// a free primary IP of the failure domain is assigned for each enabled IP family, so that the
+	// public addresses stay stable if the machine gets replaced.
+	// +optional
+	PrimaryIPSelector *metav1.LabelSelector `json:"primaryIPSelector,omitempty"`
+	// PrimaryIPv4ID is the ID of an existing primary IP in HCloud that is assigned to the server as public IPv4.
+	// The primary IP has to be in the location of the failure domain of the machine. It is not deleted with the
+	// server, so that a replacement of the machine gets the same address.
+	// +optional
+	PrimaryIPv4ID *int `json:"primaryIPv4ID,omitempty"`
+	// PrimaryIPv6ID is the ID of an existing primary IP in HCloud that is assigned to the server as public IPv6.
+	// +optional
+	PrimaryIPv6ID *int `json:"primaryIPv6ID,omitempty"`
+}
+
+// PrimaryIPID returns the ID of the existing primary IP of the IP family, or nil if the spec has none.
+func (spec *PublicNetworkSpec) PrimaryIPID(family PrimaryIPType) *int {
+	if spec == nil {
+		return nil
+	}
+	if family == PrimaryIPTypeIPv6 {
+		return spec.PrimaryIPv6ID
+	}
+	return spec.PrimaryIPv4ID
 }
 
 // LoadBalancerSpec defines the desired state of the Control Plane Loadbalancer.
@@ -152,12 +302,116 @@ type LoadBalancerSpec struct {
 	// +kubebuilder:default=6443
 	Port int `json:"port,omitempty"`
 
-	// Defines how traffic will be routed from the Load Balancer to your target server.
+	// ExtraServices are further services of the load balancer besides the one of the API server, e.g. for
+	// konnectivity or other traffic to the control planes. Their number is limited by the type of the load
+	// balancer: lb11 has 5, lb21 15 and lb31 30 services including the one of the API server.
 	// +optional
 	ExtraServices []LoadBalancerServiceSpec `json:"extraServices,omitempty"`
 
 	// Region contains the name of the HCloud location the load balancer is running.
 	Region Region `json:"region,omitempty"`
+
+	// EndpointIPFamily is the IP family of the address of the load balancer that is used as host of the control
+	// plane endpoint if the host is not set. Clusters without public IPv4 addresses use ipv6.
+	// +optional
+	// +kubebuilder:validation:Enum=ipv4;ipv6
+	// +kubebuilder:default=ipv4
+	EndpointIPFamily PrimaryIPType `json:"endpointIPFamily,omitempty"`
+
+	// HealthCheck of the service of the API server. Without it, the health check is not changed, and new load
+	// balancers check the port of the API server via TCP every 15 seconds with a timeout of 10 seconds and 3 retries.
+	// +optional
+	HealthCheck *LoadBalancerHealthCheckSpec `json:"healthCheck,omitempty"`
+}
+
+// LoadBalancerHealthCheckSpec defines the TCP health check with which the load balancer detects unhealthy targets.
+type LoadBalancerHealthCheckSpec struct {
+	// Interval between two health checks of a target. It has to be whole seconds and at least 3s.
+	// +optional
+	// +kubebuilder:default="15s"
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// Timeout of a health check. It has to be whole seconds and must not exceed the interval.
+	// +optional
+	// +kubebuilder:default="10s"
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// Retries are the failed health checks after which a target is considered unhealthy.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=3
+	Retries *int `json:"retries,omitempty"`
+
+	// Port that is checked on the targets. Defaults to the port of the API server.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int `json:"port,omitempty"`
+}
+
+const (
+	// DefaultLoadBalancerHealthCheckInterval is the interval of health checks if none is given.
+	DefaultLoadBalancerHealthCheckInterval = 15 * time.Second
+	// DefaultLoadBalancerHealthCheckTimeout is the timeout of health checks if none is given.
+	DefaultLoadBalancerHealthCheckTimeout = 10 * time.Second
+	// DefaultLoadBalancerHealthCheckRetries are the retries of health checks if none are given.
+	DefaultLoadBalancerHealthCheckRetries = 3
+)
+
+// IntervalOrDefault returns the interval of the health checks.
+func (hc *LoadBalancerHealthCheckSpec) IntervalOrDefault() time.Duration {
+	if hc.Interval == nil {
+		return DefaultLoadBalancerHealthCheckInterval
+	}
+	return hc.Interval.Duration
+}
+
+// TimeoutOrDefault returns the timeout of the health checks.
+func (hc *LoadBalancerHealthCheckSpec) TimeoutOrDefault() time.Duration {
+	if hc.Timeout == nil {
+		return DefaultLoadBalancerHealthCheckTimeout
+	}
+	return hc.Timeout.Duration
+}
+
+// RetriesOrDefault returns the retries of the health checks.
+func (hc *LoadBalancerHealthCheckSpec) RetriesOrDefault() int {
+	if hc.Retries == nil {
+		return DefaultLoadBalancerHealthCheckRetries
+	}
+	return *hc.Retries
+}
+
+// ControlPlaneFloatingIPSpec defines the floating IP that is used as control plane endpoint instead of a load balancer.
+type ControlPlaneFloatingIPSpec struct {
+	// Type is the IP family of the floating IP.
+	// +optional
+	// +kubebuilder:validation:Enum=ipv4;ipv6
+	// +kubebuilder:default=ipv4
+	Type PrimaryIPType `json:"type,omitempty"`
+
+	// HomeLocation is the HCloud location of the floating IP. The IP can be assigned to servers in all locations,
+	// but traffic is routed through the home location. Defaults to the first control plane region.
+	// +optional
+	HomeLocation Region `json:"homeLocation,omitempty"`
+
+	// Port of the API server on the control planes. It is used as port of the control plane endpoint if the port
+	// is not set.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +kubebuilder:default=6443
+	Port int `json:"port,omitempty"`
+}
+
+// FloatingIPStatus defines the observed state of the floating IP of the control plane.
+type FloatingIPStatus struct {
+	ID int    `json:"id,omitempty"`
+	IP string `json:"ip,omitempty"`
+	// ServerID is the ID of the control plane server the floating IP is assigned to. It is zero while the
+	// floating IP is not assigned.
+	// +optional
+	ServerID int `json:"serverID,omitempty"`
 }
 
 // LoadBalancerServiceSpec defines a Loadbalancer Target.
@@ -175,6 +429,26 @@ type LoadBalancerServiceSpec struct {
 	// +kubebuilder:validation:Minimum=1
 	// +kubebuilder:validation:Maximum=65535
 	DestinationPort int `json:"destinationPort,omitempty"`
+
+	// Certificate of the service. It is required for the protocol https, which terminates TLS on the
+	// load balancer and forwards plain HTTP to the destination port.
+	// +optional
+	Certificate *LoadBalancerCertificateSpec `json:"certificate,omitempty"`
+}
+
+// LoadBalancerCertificateSpec defines the certificate of a service that terminates TLS.
+type LoadBalancerCertificateSpec struct {
+	// Type is either managed or uploaded. Managed certificates are issued by HCloud via Let's Encrypt
+	// for the domain names. Uploaded certificates have to exist in the HCloud project.
+	Type LoadBalancerCertificateType `json:"type"`
+
+	// DomainNames of a managed certificate. The domain names have to resolve to the load balancer.
+	// +optional
+	DomainNames []string `json:"domainNames,omitempty"`
+
+	// Name of an uploaded certificate.
+	// +optional
+	Name string `json:"name,omitempty"`
 }
 
 // LoadBalancerStatus defines the obeserved state of the control plane loadbalancer.
@@ -185,6 +459,108 @@ type LoadBalancerStatus struct {
 	InternalIP string               `json:"internalIP,omitempty"`
 	Target     []LoadBalancerTarget `json:"targets,omitempty"`
 	Protected  bool                 `json:"protected,omitempty"`
+
+	// AppliedProperties are the properties of the spec that have been applied to the load balancer last.
+	// They tell changes of the spec apart from changes that were made outside of the controller.
+	// +optional
+	AppliedProperties *LoadBalancerProperties `json:"appliedProperties,omitempty"`
+}
+
+// LoadBalancerProperties are the properties of a load balancer that can be changed after its creation.
+type LoadBalancerProperties struct {
+	// +optional
+	Type string `json:"type,omitempty"`
+	// +optional
+	Algorithm LoadBalancerAlgorithmType `json:"algorithm,omitempty"`
+	// +optional
+	Name string `json:"name,omitempty"`
+}
+
+// DriftPolicy defines how changes of HCloud resources that were made outside of the controller, e.g. in the
+// HCloud console, are handled.
+// +kubebuilder:validation:Enum=Revert;Adopt
+type DriftPolicy string
+
+const (
+	// DriftPolicyRevert reverts changes that were made outside of the controller.
+	DriftPolicyRevert DriftPolicy = "Revert"
+	// DriftPolicyAdopt keeps changes that were made outside of the controller until the spec is changed.
+	DriftPolicyAdopt DriftPolicy = "Adopt"
+)
+
+// DriftPolicies define the drift policy per type of HCloud resource.
+type DriftPolicies struct {
+	// LoadBalancer is the drift policy of the type, algorithm, name and labels of the control plane load balancer.
+	// +optional
+	LoadBalancer DriftPolicy `json:"loadBalancer,omitempty"`
+
+	// Servers is the drift policy of the labels of the servers of HCloudMachines.
+	// +optional
+	Servers DriftPolicy `json:"servers,omitempty"`
+}
+
+// LoadBalancerPolicy returns the drift policy of the load balancer. It defaults to DriftPolicyRevert.
+func (dp *DriftPolicies) LoadBalancerPolicy() DriftPolicy {
+	if dp == nil || dp.LoadBalancer == "" {
+		return DriftPolicyRevert
+	}
+	return dp.LoadBalancer
+}
+
+// ServersPolicy returns the drift policy of servers. It defaults to DriftPolicyRevert.
+func (dp *DriftPolicies) ServersPolicy() DriftPolicy {
+	if dp == nil || dp.Servers == "" {
+		return DriftPolicyRevert
+	}
+	return dp.Servers
+}
+
+// ProvisioningMilestones are the times at which a machine reached the milestones of its provisioning. Machines
+// that were provisioned before the milestones were recorded have none.
+type ProvisioningMilestones struct {
+	// InfrastructureCreated is the time at which the HCloud server was created or a bare metal host was
+	// associated with the machine.
+	// +optional
+	InfrastructureCreated *metav1.Time `json:"infrastructureCreated,omitempty"`
+
+	// BootstrapDataDelivered is the time at which the bootstrap data was passed to the HCloud server or written
+	// to the bare metal host.
+	// +optional
+	BootstrapDataDelivered *metav1.Time `json:"bootstrapDataDelivered,omitempty"`
+
+	// NodeReady is the time at which the node of the machine was reported healthy for the first time.
+	// +optional
+	NodeReady *metav1.Time `json:"nodeReady,omitempty"`
+}
+
+// ServerLabels selects the propagated labels of Machines that are set on HCloud servers by their keys. A label is
+// selected if its key is one of the keys or starts with one of the prefixes.
+type ServerLabels struct {
+	// Keys are the keys of the selected labels, e.g. cost-center.
+	// +optional
+	Keys []string `json:"keys,omitempty"`
+
+	// Prefixes select the labels whose keys start with one of them, e.g. example.com/ for the labels of a domain.
+	// +optional
+	Prefixes []string `json:"prefixes,omitempty"`
+}
+
+// Selects returns whether the label with the key is set on servers. Without ServerLabels, all labels are selected.
+func (sl *ServerLabels) Selects(key string) bool {
+	if sl == nil {
+		return true
+	}
+	for _, k := range sl.Keys {
+		if k == key {
+			return true
+		}
+	}
+	for _, prefix := range sl.Prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
 }
 
 // LoadBalancerTarget defines the target of a load balancer.
@@ -192,6 +568,19 @@ type LoadBalancerTarget struct {
 	Type     LoadBalancerTargetType `json:"type"`
 	ServerID int                    `json:"serverID,omitempty"`
 	IP       string                 `json:"ip,omitempty"`
+
+	// HealthStatus of the target for each service of the load balancer.
+	// +optional
+	HealthStatus []LoadBalancerTargetHealthStatus `json:"healthStatus,omitempty"`
+}
+
+// LoadBalancerTargetHealthStatus defines the result of the health checks of a service for a target.
+type LoadBalancerTargetHealthStatus struct {
+	// ListenPort of the service.
+	ListenPort int `json:"listenPort"`
+
+	// Status is either healthy, unhealthy or unknown.
+	Status string `json:"status"`
 }
 
 // HCloudNetworkSpec defines the desired state of the HCloud Private Network.
@@ -209,11 +598,88 @@ type HCloudNetworkSpec struct {
 	// +optional
 	SubnetCIDRBlock string `json:"subnetCidrBlock,omitempty"`
 
+	// ExistingNetwork references a network that exists in HCloud, e.g. a network that is shared with other
+	// clusters. The network is used instead of creating one and is never deleted by the controller. The
+	// controller only attaches the servers and the load balancer of the cluster to the network. CIDRBlock and
+	// SubnetCIDRBlock are not used, the network has to have a subnet in the network zone of the cluster or the
+	// subnet that the reference selects.
+	// +optional
+	ExistingNetwork *HCloudNetworkRef `json:"existingNetwork,omitempty"`
+
+	// Subnets are additional subnets of the network, e.g. to put control planes, workers and infrastructure nodes
+	// in separate IP ranges. HCloudMachineTemplates select a subnet by its name, servers of other templates are
+	// attached to the subnet SubnetCIDRBlock. Subnets can be added, but not changed or removed.
+	// +optional
+	Subnets []HCloudSubnetSpec `json:"subnets,omitempty"`
+
 	// NetworkZone specifies the HCloud network zone of the private network.
 	// +kubebuilder:validation:Enum=eu-central;us-east;us-west
 	// +kubebuilder:default=eu-central
 	// +optional
 	NetworkZone HCloudNetworkZone `json:"networkZone,omitempty"`
+
+	// NATGateway is a server in the network that routes the traffic of servers without public IPs to the internet.
+	// The controller manages the route 0.0.0.0/0 of the network via the gateway. Other than the rest of the
+	// network settings, the gateway can be changed.
+	// +optional
+	NATGateway *HCloudNATGatewaySpec `json:"natGateway,omitempty"`
+
+	// Routes are routes of the network that the controller manages, e.g. routes of the pod CIDRs via the nodes for
+	// CNIs with native routing. Like the NAT gateway, the routes can be changed. Routes that are removed from the
+	// spec are deleted from the network.
+	// +optional
+	Routes []HCloudNetworkRouteSpec `json:"routes,omitempty"`
+}
+
+// HCloudNetworkRouteSpec defines a route of the HCloud network.
+type HCloudNetworkRouteSpec struct {
+	// Destination is the IPv4 CIDR block of the route, e.g. the pod CIDR of a node.
+	Destination string `json:"destination"`
+
+	// Gateway is the private IPv4 in the network to which the traffic to the destination is routed, e.g. the
+	// private IP of the node.
+	Gateway string `json:"gateway"`
+}
+
+// HCloudSubnetSpec defines an additional subnet of the HCloud network.
+type HCloudSubnetSpec struct {
+	// Name of the subnet, by which HCloudMachineTemplates select it.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// CIDRBlock of the subnet. It has to be in the range of the network and must not overlap with other subnets.
+	CIDRBlock string `json:"cidrBlock"`
+}
+
+// HCloudNetworkRef references an existing HCloud network by its ID or its name.
+type HCloudNetworkRef struct {
+	// ID of the network. Either the ID or the name has to be specified.
+	// +optional
+	ID *int `json:"id,omitempty"`
+
+	// Name of the network. Either the ID or the name has to be specified.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// SubnetCIDRBlock selects the subnet of the network to which servers are attached that do not select a
+	// subnet. By default, the first subnet of type cloud or server in the network zone is used.
+	// +optional
+	SubnetCIDRBlock string `json:"subnetCidrBlock,omitempty"`
+}
+
+// IDOrName returns the ID of the network if it is set and the name otherwise.
+func (r *HCloudNetworkRef) IDOrName() string {
+	if r.ID != nil {
+		return strconv.Itoa(*r.ID)
+	}
+	return r.Name
+}
+
+// HCloudNATGatewaySpec defines the NAT gateway of the HCloud network.
+type HCloudNATGatewaySpec struct {
+	// IP is the private IPv4 of the gateway in the network. The gateway has to forward and masquerade the traffic
+	// of the network, which is not configured by the controller.
+	IP string `json:"ip"`
 }
 
 // NetworkStatus defines the observed state of the HCloud Private Network.
@@ -221,12 +687,270 @@ type NetworkStatus struct {
 	ID              int               `json:"id,omitempty"`
 	Labels          map[string]string `json:"-"`
 	AttachedServers []int             `json:"attachedServers,omitempty"`
+
+	// IPRange is the IP range of the network.
+	// +optional
+	IPRange string `json:"ipRange,omitempty"`
+
+	// DefaultRouteGateway is the gateway of the route 0.0.0.0/0 of the network, which servers without public
+	// IPs use to reach the internet. It is empty if the network has no such route.
+	// +optional
+	DefaultRouteGateway string `json:"defaultRouteGateway,omitempty"`
+
+	// Routes are the routes of the spec that have been added to the network. They are deleted from the network
+	// once they are removed from the spec.
+	// +optional
+	Routes []HCloudNetworkRouteSpec `json:"routes,omitempty"`
+}
+
+// DNSSpec defines the resolver configuration of a node. It replaces the default
+// resolvers of Hetzner, e.g. for split-horizon DNS.
+type DNSSpec struct {
+	// Nameservers are the IP addresses of the DNS servers.
+	// +optional
+	Nameservers []string `json:"nameservers,omitempty"`
+
+	// SearchDomains are the domains that are used to complete host names.
+	// +optional
+	SearchDomains []string `json:"searchDomains,omitempty"`
+
+	// KubeletResolvConf writes /etc/kubernetes/resolv.conf with the nameservers and search domains, or with the
+	// resolvers of Hetzner if no nameservers are set. Kubelet has to use it with the flag resolv-conf. Pods with
+	// dnsPolicy Default, the cluster DNS and node-local DNS caches get their upstream resolvers from this file, so
+	// they never get the stub resolver 127.0.0.53 of systemd-resolved, which is not reachable from pods.
+	// +optional
+	KubeletResolvConf bool `json:"kubeletResolvConf,omitempty"`
+}
+
+// MaxKubeletNameservers is the number of nameservers that kubelet uses from its resolv.conf.
+const MaxKubeletNameservers = 3
+
+// IsZero returns true if no resolver configuration is set.
+func (dns *DNSSpec) IsZero() bool {
+	return dns == nil || (len(dns.Nameservers) == 0 && len(dns.SearchDomains) == 0 && !dns.KubeletResolvConf)
+}
+
+// AppliedConfiguration is a snapshot of the inputs that have been used to provision a server.
+// It shows which image and which bootstrap data a node has been built from.
+type AppliedConfiguration struct {
+	// ImageID is the ID of the image that has been resolved in the HCloud API.
+	// +optional
+	ImageID int `json:"imageID,omitempty"`
+
+	// Image is the name, URL or path of the image that has been installed.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// AutoSetupHash is the SHA256 hash of the rendered autosetup file that has been used for installimage.
+	// +optional
+	AutoSetupHash string `json:"autoSetupHash,omitempty"`
+
+	// UserDataHash is the SHA256 hash of the rendered user data that has been passed to the server. Besides the
+	// bootstrap data, it contains everything the controller adds to it, e.g. the resolver config and cloud-init parts.
+	// +optional
+	UserDataHash string `json:"userDataHash,omitempty"`
+
+	// SSHKeyFingerprints are the fingerprints of the SSH keys that have been installed.
+	// +optional
+	SSHKeyFingerprints []string `json:"sshKeyFingerprints,omitempty"`
+
+	// ConsoleUser is the console user that has been created.
+	// +optional
+	ConsoleUser *AppliedConsoleUser `json:"consoleUser,omitempty"`
+
+	// AppliedAt is the time at which the configuration has been applied.
+	// +optional
+	AppliedAt *metav1.Time `json:"appliedAt,omitempty"`
+}
+
+// AppliedConsoleUser is the console user that has been created on a machine.
+type AppliedConsoleUser struct {
+	// Name is the name of the user.
+	Name string `json:"name"`
+
+	// PasswordHash is the SHA256 hash of the hashed password of the user.
+	PasswordHash string `json:"passwordHash"`
+}
+
+// CertificateStatus shows the validity of a certificate.
+type CertificateStatus struct {
+	// NotBefore is the time from which the certificate is valid.
+	NotBefore metav1.Time `json:"notBefore"`
+
+	// NotAfter is the time at which the certificate expires.
+	NotAfter metav1.Time `json:"notAfter"`
 }
 
 // Region is a Hetzner Location
 // +kubebuilder:validation:Enum=fsn1;hel1;nbg1;ash;hil
 type Region string
 
+// PlacementConstraints are allow-lists and deny-lists of HCloud locations and Robot datacenters.
+// An empty allow-list allows everything that is not denied.
+type PlacementConstraints struct {
+	// AllowedLocations are the only HCloud locations in which servers are created.
+	// +optional
+	AllowedLocations []Region `json:"allowedLocations,omitempty"`
+
+	// DeniedLocations are HCloud locations in which no servers are created.
+	// +optional
+	DeniedLocations []Region `json:"deniedLocations,omitempty"`
+
+	// AllowedDatacenters are the only Robot datacenters whose bare metal hosts are chosen for machines.
+	// A datacenter is given by its name, e.g. FSN1-DC14, or by the name of its location, e.g. FSN1,
+	// which matches all datacenters of the location. Names are matched case-insensitively.
+	// +optional
+	AllowedDatacenters []string `json:"allowedDatacenters,omitempty"`
+
+	// DeniedDatacenters are Robot datacenters whose bare metal hosts are not chosen for machines.
+	// They are matched like AllowedDatacenters.
+	// +optional
+	DeniedDatacenters []string `json:"deniedDatacenters,omitempty"`
+}
+
+// IsLocationAllowed returns whether servers can be created in the HCloud location.
+func (pc *PlacementConstraints) IsLocationAllowed(location Region) bool {
+	if pc == nil {
+		return true
+	}
+	if regionInList(pc.DeniedLocations, location) {
+		return false
+	}
+	return len(pc.AllowedLocations) == 0 || regionInList(pc.AllowedLocations, location)
+}
+
+// IsDatacenterAllowed returns whether bare metal hosts in the Robot datacenter can be chosen. If an allow-list
+// is given, hosts whose datacenter is not known are not allowed.
+func (pc *PlacementConstraints) IsDatacenterAllowed(datacenter string) bool {
+	if pc == nil {
+		return true
+	}
+	if datacenterInList(pc.DeniedDatacenters, datacenter) {
+		return false
+	}
+	return len(pc.AllowedDatacenters) == 0 || datacenterInList(pc.AllowedDatacenters, datacenter)
+}
+
+func regionInList(list []Region, region Region) bool {
+	for _, r := range list {
+		if r == region {
+			return true
+		}
+	}
+	return false
+}
+
+// datacenterInList returns whether the datacenter or its location is in the list.
+func datacenterInList(list []string, datacenter string) bool {
+	if datacenter == "" {
+		return false
+	}
+	for _, entry := range list {
+		if strings.EqualFold(entry, datacenter) || strings.HasPrefix(strings.ToLower(datacenter), strings.ToLower(entry)+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// HCloudQuota defines the resource limits of an HCloud project. HCloud does not expose the limits in its API, so
+// they have to be taken from the Cloud Console. The resources of the whole project count against the limits,
+// including the ones of other clusters. A limit of 0 is not checked.
+type HCloudQuota struct {
+	// Servers is the maximal number of servers in the project.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Servers int `json:"servers,omitempty"`
+
+	// LoadBalancers is the maximal number of load balancers in the project.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	LoadBalancers int `json:"loadBalancers,omitempty"`
+
+	// Networks is the maximal number of networks in the project.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Networks int `json:"networks,omitempty"`
+}
+
+// ProvisioningThrottle limits the provisioning operations of bare metal hosts that run at the same time in a Robot
+// datacenter, as Robot throttles the rescue and reset requests per datacenter. A limit of 0 means no limit.
+type ProvisioningThrottle struct {
+	// MaxConcurrentRescue is the maximal number of hosts per datacenter that have activated the rescue system
+	// and are booting into it.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxConcurrentRescue int `json:"maxConcurrentRescue,omitempty"`
+
+	// MaxConcurrentImaging is the maximal number of hosts per datacenter that install their image and boot into
+	// the installed operating system.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxConcurrentImaging int `json:"maxConcurrentImaging,omitempty"`
+
+	// Datacenters override the limits for single datacenters. The first entry that matches the datacenter of a
+	// host applies.
+	// +optional
+	Datacenters []DatacenterProvisioningThrottle `json:"datacenters,omitempty"`
+}
+
+// DatacenterProvisioningThrottle overrides the limits of provisioning operations for a Robot datacenter.
+type DatacenterProvisioningThrottle struct {
+	// Datacenter is the name of the datacenter, e.g. FSN1-DC14, or the name of its location, e.g. FSN1,
+	// which matches all datacenters of the location. Names are matched case-insensitively.
+	Datacenter string `json:"datacenter"`
+
+	// MaxConcurrentRescue overrides MaxConcurrentRescue for the datacenter.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxConcurrentRescue *int `json:"maxConcurrentRescue,omitempty"`
+
+	// MaxConcurrentImaging overrides MaxConcurrentImaging for the datacenter.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxConcurrentImaging *int `json:"maxConcurrentImaging,omitempty"`
+}
+
+// RescueLimit returns the maximal number of concurrent rescue activations in the datacenter, 0 means no limit.
+func (pt *ProvisioningThrottle) RescueLimit(datacenter string) int {
+	if pt == nil {
+		return 0
+	}
+	if override := pt.override(datacenter); override != nil && override.MaxConcurrentRescue != nil {
+		return *override.MaxConcurrentRescue
+	}
+	return pt.MaxConcurrentRescue
+}
+
+// ImagingLimit returns the maximal number of concurrent imaging operations in the datacenter, 0 means no limit.
+func (pt *ProvisioningThrottle) ImagingLimit(datacenter string) int {
+	if pt == nil {
+		return 0
+	}
+	if override := pt.override(datacenter); override != nil && override.MaxConcurrentImaging != nil {
+		return *override.MaxConcurrentImaging
+	}
+	return pt.MaxConcurrentImaging
+}
+
+func (pt *ProvisioningThrottle) override(datacenter string) *DatacenterProvisioningThrottle {
+	for i := range pt.Datacenters {
+		if datacenterInList([]string{pt.Datacenters[i].Datacenter}, datacenter) {
+			return &pt.Datacenters[i]
+		}
+	}
+	return nil
+}
+
+// ProvisioningFirewall defines the rules that are added to active Robot firewalls of bare metal hosts while they
+// are provisioned, so that the controller can connect to the rescue system and the installed operating system.
+type ProvisioningFirewall struct {
+	// SourceIPs are the networks in CIDR notation from which the controller connects to the hosts, e.g. the egress
+	// IPs of the management cluster. A rule is added per network and SSH port.
+	// +kubebuilder:validation:MinItems=1
+	SourceIPs []string `json:"sourceIPs"`
+}
+
 // HCloudNetworkZone describes the Network zone.
 type HCloudNetworkZone string
 
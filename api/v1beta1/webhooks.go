@@ -17,11 +17,21 @@ limitations under the License.
 package v1beta1
 
 import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"text/template"
+
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
+// systemdUnitNameRegex matches the characters that are allowed in names of systemd units.
+var systemdUnitNameRegex = regexp.MustCompile(`^[a-zA-Z0-9:_.\\@-]+$`)
+
 func aggregateObjErrors(gk schema.GroupKind, name string, allErrs field.ErrorList) error {
 	if len(allErrs) == 0 {
 		return nil
@@ -33,3 +43,121 @@ func aggregateObjErrors(gk schema.GroupKind, name string, allErrs field.ErrorLis
 		allErrs,
 	)
 }
+
+func validateDNSSpec(fldPath *field.Path, dns *DNSSpec) field.ErrorList {
+	var allErrs field.ErrorList
+	if dns == nil {
+		return allErrs
+	}
+
+	for i, nameserver := range dns.Nameservers {
+		if net.ParseIP(nameserver) == nil {
+			allErrs = append(allErrs,
+				field.Invalid(fldPath.Child("nameservers").Index(i), nameserver, "nameserver has to be an IP address"),
+			)
+		}
+	}
+
+	if dns.KubeletResolvConf && len(dns.Nameservers) > MaxKubeletNameservers {
+		allErrs = append(allErrs,
+			field.TooMany(fldPath.Child("nameservers"), len(dns.Nameservers), MaxKubeletNameservers),
+		)
+	}
+
+	for i, searchDomain := range dns.SearchDomains {
+		for _, msg := range validation.IsDNS1123Subdomain(searchDomain) {
+			allErrs = append(allErrs,
+				field.Invalid(fldPath.Child("searchDomains").Index(i), searchDomain, msg),
+			)
+		}
+	}
+	return allErrs
+}
+
+func validateSwapSpec(fldPath *field.Path, installImage InstallImage) field.ErrorList {
+	var allErrs field.ErrorList
+	if installImage.Swap == nil || installImage.Swap.Type != SwapTypePartition {
+		return allErrs
+	}
+
+	for i, partition := range installImage.Partitions {
+		if partition.FileSystem == "swap" {
+			allErrs = append(allErrs,
+				field.Invalid(fldPath.Child("partitions").Index(i), partition,
+					"swap partition cannot be defined in partitions if swap.type is partition"),
+			)
+		}
+	}
+	return allErrs
+}
+
+func validateSSHSpec(fldPath *field.Path, sshSpec *SSHSpec) field.ErrorList {
+	var allErrs field.ErrorList
+	if sshSpec == nil {
+		return allErrs
+	}
+
+	if sshSpec.PrivateProvisioning != nil && sshSpec.PrivateProvisioning.Bastion.Address == "" {
+		allErrs = append(allErrs,
+			field.Required(fldPath.Child("privateProvisioning", "bastion", "address"),
+				"have to specify the address of the bastion host for private provisioning"),
+		)
+	}
+	return allErrs
+}
+
+func validateInstallImageTemplates(fldPath *field.Path, installImage InstallImage) field.ErrorList {
+	var allErrs field.ErrorList
+	for _, f := range []struct {
+		path  *field.Path
+		value string
+	}{
+		{fldPath.Child("image", "url"), installImage.Image.URL},
+		{fldPath.Child("image", "name"), installImage.Image.Name},
+		{fldPath.Child("image", "path"), installImage.Image.Path},
+		{fldPath.Child("postInstallScript"), installImage.PostInstallScript},
+	} {
+		if _, err := template.New(f.path.String()).Parse(f.value); err != nil {
+			allErrs = append(allErrs, field.Invalid(f.path, f.value, fmt.Sprintf("invalid template: %s", err)))
+		}
+	}
+	return allErrs
+}
+
+func validatePostInstallSteps(fldPath *field.Path, steps []PostInstallStep) field.ErrorList {
+	var allErrs field.ErrorList
+	names := make(map[string]struct{}, len(steps))
+	for i, step := range steps {
+		if _, found := names[step.Name]; found {
+			allErrs = append(allErrs, field.Duplicate(fldPath.Index(i).Child("name"), step.Name))
+		}
+		names[step.Name] = struct{}{}
+
+		if strings.TrimSpace(step.Script) == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Index(i).Child("script"), "script of the step must not be empty"))
+		}
+	}
+	return allErrs
+}
+
+func validateProvisioningChecks(fldPath *field.Path, checks *ProvisioningChecks) field.ErrorList {
+	var allErrs field.ErrorList
+	if checks == nil {
+		return allErrs
+	}
+
+	for i, unit := range checks.SystemdUnits {
+		if !systemdUnitNameRegex.MatchString(unit) {
+			allErrs = append(allErrs,
+				field.Invalid(fldPath.Child("systemdUnits").Index(i), unit, "invalid name of systemd unit"),
+			)
+		}
+	}
+
+	if checks.Timeout != nil && checks.Timeout.Duration <= 0 {
+		allErrs = append(allErrs,
+			field.Invalid(fldPath.Child("timeout"), checks.Timeout.Duration.String(), "timeout has to be positive"),
+		)
+	}
+	return allErrs
+}
@@ -23,676 +23,601 @@ package v1beta1
 
 import (
 	"github.com/hetznercloud/hcloud-go/hcloud"
-	"k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	apiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/errors"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *BTRFSDefinition) DeepCopyInto(out *BTRFSDefinition) {
+func (in *ActionHistoryEntry) DeepCopyInto(out *ActionHistoryEntry) {
 	*out = *in
+	in.Started.DeepCopyInto(&out.Started)
+	if in.Finished != nil {
+		in, out := &in.Finished, &out.Finished
+		*out = (*in).DeepCopy()
+	}
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(v1.Duration)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BTRFSDefinition.
-func (in *BTRFSDefinition) DeepCopy() *BTRFSDefinition {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ActionHistoryEntry.
+func (in *ActionHistoryEntry) DeepCopy() *ActionHistoryEntry {
 	if in == nil {
 		return nil
 	}
-	out := new(BTRFSDefinition)
+	out := new(ActionHistoryEntry)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CPU) DeepCopyInto(out *CPU) {
+func (in *AppliedConfiguration) DeepCopyInto(out *AppliedConfiguration) {
 	*out = *in
-	if in.Flags != nil {
-		in, out := &in.Flags, &out.Flags
+	if in.SSHKeyFingerprints != nil {
+		in, out := &in.SSHKeyFingerprints, &out.SSHKeyFingerprints
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ConsoleUser != nil {
+		in, out := &in.ConsoleUser, &out.ConsoleUser
+		*out = new(AppliedConsoleUser)
+		**out = **in
+	}
+	if in.AppliedAt != nil {
+		in, out := &in.AppliedAt, &out.AppliedAt
+		*out = (*in).DeepCopy()
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CPU.
-func (in *CPU) DeepCopy() *CPU {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppliedConfiguration.
+func (in *AppliedConfiguration) DeepCopy() *AppliedConfiguration {
 	if in == nil {
 		return nil
 	}
-	out := new(CPU)
+	out := new(AppliedConfiguration)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ControllerGeneratedStatus) DeepCopyInto(out *ControllerGeneratedStatus) {
+func (in *AppliedConsoleUser) DeepCopyInto(out *AppliedConsoleUser) {
 	*out = *in
-	if in.UserData != nil {
-		in, out := &in.UserData, &out.UserData
-		*out = new(v1.SecretReference)
-		**out = **in
-	}
-	if in.InstallImage != nil {
-		in, out := &in.InstallImage, &out.InstallImage
-		*out = new(InstallImage)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.HardwareDetails != nil {
-		in, out := &in.HardwareDetails, &out.HardwareDetails
-		*out = new(HardwareDetails)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.RebootTypes != nil {
-		in, out := &in.RebootTypes, &out.RebootTypes
-		*out = make([]RebootType, len(*in))
-		copy(*out, *in)
-	}
-	if in.SSHSpec != nil {
-		in, out := &in.SSHSpec, &out.SSHSpec
-		*out = new(SSHSpec)
-		**out = **in
-	}
-	in.SSHStatus.DeepCopyInto(&out.SSHStatus)
-	if in.LastUpdated != nil {
-		in, out := &in.LastUpdated, &out.LastUpdated
-		*out = (*in).DeepCopy()
-	}
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make(apiv1beta1.Conditions, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControllerGeneratedStatus.
-func (in *ControllerGeneratedStatus) DeepCopy() *ControllerGeneratedStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppliedConsoleUser.
+func (in *AppliedConsoleUser) DeepCopy() *AppliedConsoleUser {
 	if in == nil {
 		return nil
 	}
-	out := new(ControllerGeneratedStatus)
+	out := new(AppliedConsoleUser)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HCloudMachine) DeepCopyInto(out *HCloudMachine) {
+func (in *AppliedPublicNetwork) DeepCopyInto(out *AppliedPublicNetwork) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudMachine.
-func (in *HCloudMachine) DeepCopy() *HCloudMachine {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppliedPublicNetwork.
+func (in *AppliedPublicNetwork) DeepCopy() *AppliedPublicNetwork {
 	if in == nil {
 		return nil
 	}
-	out := new(HCloudMachine)
+	out := new(AppliedPublicNetwork)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *HCloudMachine) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HCloudMachineList) DeepCopyInto(out *HCloudMachineList) {
+func (in *AutomaticPlacementGroupSpec) DeepCopyInto(out *AutomaticPlacementGroupSpec) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]HCloudMachine, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudMachineList.
-func (in *HCloudMachineList) DeepCopy() *HCloudMachineList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutomaticPlacementGroupSpec.
+func (in *AutomaticPlacementGroupSpec) DeepCopy() *AutomaticPlacementGroupSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(HCloudMachineList)
+	out := new(AutomaticPlacementGroupSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *HCloudMachineList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HCloudMachineSpec) DeepCopyInto(out *HCloudMachineSpec) {
+func (in *BTRFSDefinition) DeepCopyInto(out *BTRFSDefinition) {
 	*out = *in
-	if in.ProviderID != nil {
-		in, out := &in.ProviderID, &out.ProviderID
-		*out = new(string)
-		**out = **in
-	}
-	if in.SSHKeys != nil {
-		in, out := &in.SSHKeys, &out.SSHKeys
-		*out = make([]SSHKey, len(*in))
-		copy(*out, *in)
-	}
-	if in.PlacementGroupName != nil {
-		in, out := &in.PlacementGroupName, &out.PlacementGroupName
-		*out = new(string)
-		**out = **in
-	}
-	if in.PublicNetwork != nil {
-		in, out := &in.PublicNetwork, &out.PublicNetwork
-		*out = new(PublicNetworkSpec)
-		**out = **in
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudMachineSpec.
-func (in *HCloudMachineSpec) DeepCopy() *HCloudMachineSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BTRFSDefinition.
+func (in *BTRFSDefinition) DeepCopy() *BTRFSDefinition {
 	if in == nil {
 		return nil
 	}
-	out := new(HCloudMachineSpec)
+	out := new(BTRFSDefinition)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HCloudMachineStatus) DeepCopyInto(out *HCloudMachineStatus) {
+func (in *Bastion) DeepCopyInto(out *Bastion) {
 	*out = *in
-	if in.Addresses != nil {
-		in, out := &in.Addresses, &out.Addresses
-		*out = make([]v1.NodeAddress, len(*in))
-		copy(*out, *in)
-	}
-	if in.InstanceState != nil {
-		in, out := &in.InstanceState, &out.InstanceState
-		*out = new(hcloud.ServerStatus)
-		**out = **in
-	}
-	if in.FailureReason != nil {
-		in, out := &in.FailureReason, &out.FailureReason
-		*out = new(errors.MachineStatusError)
-		**out = **in
-	}
-	if in.FailureMessage != nil {
-		in, out := &in.FailureMessage, &out.FailureMessage
-		*out = new(string)
-		**out = **in
-	}
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make(apiv1beta1.Conditions, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudMachineStatus.
-func (in *HCloudMachineStatus) DeepCopy() *HCloudMachineStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Bastion.
+func (in *Bastion) DeepCopy() *Bastion {
 	if in == nil {
 		return nil
 	}
-	out := new(HCloudMachineStatus)
+	out := new(Bastion)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HCloudMachineTemplate) DeepCopyInto(out *HCloudMachineTemplate) {
+func (in *CPU) DeepCopyInto(out *CPU) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
+	if in.Flags != nil {
+		in, out := &in.Flags, &out.Flags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudMachineTemplate.
-func (in *HCloudMachineTemplate) DeepCopy() *HCloudMachineTemplate {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CPU.
+func (in *CPU) DeepCopy() *CPU {
 	if in == nil {
 		return nil
 	}
-	out := new(HCloudMachineTemplate)
+	out := new(CPU)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *HCloudMachineTemplate) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HCloudMachineTemplateList) DeepCopyInto(out *HCloudMachineTemplateList) {
+func (in *CertificateStatus) DeepCopyInto(out *CertificateStatus) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]HCloudMachineTemplate, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
+	in.NotBefore.DeepCopyInto(&out.NotBefore)
+	in.NotAfter.DeepCopyInto(&out.NotAfter)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudMachineTemplateList.
-func (in *HCloudMachineTemplateList) DeepCopy() *HCloudMachineTemplateList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateStatus.
+func (in *CertificateStatus) DeepCopy() *CertificateStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(HCloudMachineTemplateList)
+	out := new(CertificateStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *HCloudMachineTemplateList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HCloudMachineTemplateResource) DeepCopyInto(out *HCloudMachineTemplateResource) {
+func (in *CloudInitPart) DeepCopyInto(out *CloudInitPart) {
 	*out = *in
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(CloudInitPartSecretRef)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudMachineTemplateResource.
-func (in *HCloudMachineTemplateResource) DeepCopy() *HCloudMachineTemplateResource {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudInitPart.
+func (in *CloudInitPart) DeepCopy() *CloudInitPart {
 	if in == nil {
 		return nil
 	}
-	out := new(HCloudMachineTemplateResource)
+	out := new(CloudInitPart)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HCloudMachineTemplateSpec) DeepCopyInto(out *HCloudMachineTemplateSpec) {
+func (in *CloudInitPartSecretRef) DeepCopyInto(out *CloudInitPartSecretRef) {
 	*out = *in
-	in.Template.DeepCopyInto(&out.Template)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudMachineTemplateSpec.
-func (in *HCloudMachineTemplateSpec) DeepCopy() *HCloudMachineTemplateSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudInitPartSecretRef.
+func (in *CloudInitPartSecretRef) DeepCopy() *CloudInitPartSecretRef {
 	if in == nil {
 		return nil
 	}
-	out := new(HCloudMachineTemplateSpec)
+	out := new(CloudInitPartSecretRef)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HCloudMachineTemplateStatus) DeepCopyInto(out *HCloudMachineTemplateStatus) {
+func (in *ClusterDeletionPolicy) DeepCopyInto(out *ClusterDeletionPolicy) {
 	*out = *in
-	if in.Capacity != nil {
-		in, out := &in.Capacity, &out.Capacity
-		*out = make(v1.ResourceList, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val.DeepCopy()
-		}
+	if in.Order != nil {
+		in, out := &in.Order, &out.Order
+		*out = make([]DeletionStep, len(*in))
+		copy(*out, *in)
 	}
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make(apiv1beta1.Conditions, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.Skip != nil {
+		in, out := &in.Skip, &out.Skip
+		*out = make([]DeletionStep, len(*in))
+		copy(*out, *in)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudMachineTemplateStatus.
-func (in *HCloudMachineTemplateStatus) DeepCopy() *HCloudMachineTemplateStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterDeletionPolicy.
+func (in *ClusterDeletionPolicy) DeepCopy() *ClusterDeletionPolicy {
 	if in == nil {
 		return nil
 	}
-	out := new(HCloudMachineTemplateStatus)
+	out := new(ClusterDeletionPolicy)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HCloudNetworkSpec) DeepCopyInto(out *HCloudNetworkSpec) {
+func (in *ConsoleUserPasswordSecretRef) DeepCopyInto(out *ConsoleUserPasswordSecretRef) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudNetworkSpec.
-func (in *HCloudNetworkSpec) DeepCopy() *HCloudNetworkSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleUserPasswordSecretRef.
+func (in *ConsoleUserPasswordSecretRef) DeepCopy() *ConsoleUserPasswordSecretRef {
 	if in == nil {
 		return nil
 	}
-	out := new(HCloudNetworkSpec)
+	out := new(ConsoleUserPasswordSecretRef)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HCloudPlacementGroupSpec) DeepCopyInto(out *HCloudPlacementGroupSpec) {
+func (in *ConsoleUserSpec) DeepCopyInto(out *ConsoleUserSpec) {
 	*out = *in
+	out.PasswordSecretRef = in.PasswordSecretRef
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudPlacementGroupSpec.
-func (in *HCloudPlacementGroupSpec) DeepCopy() *HCloudPlacementGroupSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleUserSpec.
+func (in *ConsoleUserSpec) DeepCopy() *ConsoleUserSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(HCloudPlacementGroupSpec)
+	out := new(ConsoleUserSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HCloudPlacementGroupStatus) DeepCopyInto(out *HCloudPlacementGroupStatus) {
+func (in *ControlPlaneFloatingIPSpec) DeepCopyInto(out *ControlPlaneFloatingIPSpec) {
 	*out = *in
-	if in.Server != nil {
-		in, out := &in.Server, &out.Server
-		*out = make([]int, len(*in))
-		copy(*out, *in)
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudPlacementGroupStatus.
-func (in *HCloudPlacementGroupStatus) DeepCopy() *HCloudPlacementGroupStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlaneFloatingIPSpec.
+func (in *ControlPlaneFloatingIPSpec) DeepCopy() *ControlPlaneFloatingIPSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(HCloudPlacementGroupStatus)
+	out := new(ControlPlaneFloatingIPSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HardwareDetails) DeepCopyInto(out *HardwareDetails) {
+func (in *ControllerGeneratedStatus) DeepCopyInto(out *ControllerGeneratedStatus) {
 	*out = *in
-	if in.NIC != nil {
-		in, out := &in.NIC, &out.NIC
-		*out = make([]NIC, len(*in))
-		copy(*out, *in)
+	if in.UserData != nil {
+		in, out := &in.UserData, &out.UserData
+		*out = new(corev1.SecretReference)
+		**out = **in
 	}
-	if in.Storage != nil {
-		in, out := &in.Storage, &out.Storage
-		*out = make([]Storage, len(*in))
+	if in.AppliedConfiguration != nil {
+		in, out := &in.AppliedConfiguration, &out.AppliedConfiguration
+		*out = new(AppliedConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DNS != nil {
+		in, out := &in.DNS, &out.DNS
+		*out = new(DNSSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NodeProfile != nil {
+		in, out := &in.NodeProfile, &out.NodeProfile
+		*out = new(HetznerNodeProfileSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.InstallImage != nil {
+		in, out := &in.InstallImage, &out.InstallImage
+		*out = new(InstallImage)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.InstalledImage != nil {
+		in, out := &in.InstalledImage, &out.InstalledImage
+		*out = new(InstallImage)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PendingReprovision != nil {
+		in, out := &in.PendingReprovision, &out.PendingReprovision
+		*out = make([]ReprovisionChange, len(*in))
 		copy(*out, *in)
 	}
-	in.CPU.DeepCopyInto(&out.CPU)
+	if in.ProvisioningChecks != nil {
+		in, out := &in.ProvisioningChecks, &out.ProvisioningChecks
+		*out = new(ProvisioningChecks)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HardwareDetails != nil {
+		in, out := &in.HardwareDetails, &out.HardwareDetails
+		*out = new(HardwareDetails)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HardwareInspection != nil {
+		in, out := &in.HardwareInspection, &out.HardwareInspection
+		*out = new(HardwareInspection)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RobotServer != nil {
+		in, out := &in.RobotServer, &out.RobotServer
+		*out = new(RobotServerStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Traffic != nil {
+		in, out := &in.Traffic, &out.Traffic
+		*out = new(TrafficStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RebootTypes != nil {
+		in, out := &in.RebootTypes, &out.RebootTypes
+		*out = make([]RebootType, len(*in))
+		copy(*out, *in)
+	}
+	if in.SSHSpec != nil {
+		in, out := &in.SSHSpec, &out.SSHSpec
+		*out = new(SSHSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	in.SSHStatus.DeepCopyInto(&out.SSHStatus)
+	if in.ProvisioningStateChanged != nil {
+		in, out := &in.ProvisioningStateChanged, &out.ProvisioningStateChanged
+		*out = (*in).DeepCopy()
+	}
+	if in.ProvisioningStarted != nil {
+		in, out := &in.ProvisioningStarted, &out.ProvisioningStarted
+		*out = (*in).DeepCopy()
+	}
+	if in.ActionHistory != nil {
+		in, out := &in.ActionHistory, &out.ActionHistory
+		*out = make([]ActionHistoryEntry, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PostInstallStep != nil {
+		in, out := &in.PostInstallStep, &out.PostInstallStep
+		*out = new(PostInstallStepStatus)
+		**out = **in
+	}
+	if in.LastUpdated != nil {
+		in, out := &in.LastUpdated, &out.LastUpdated
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]apiv1beta1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareDetails.
-func (in *HardwareDetails) DeepCopy() *HardwareDetails {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControllerGeneratedStatus.
+func (in *ControllerGeneratedStatus) DeepCopy() *ControllerGeneratedStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(HardwareDetails)
+	out := new(ControllerGeneratedStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HetznerBareMetalHost) DeepCopyInto(out *HetznerBareMetalHost) {
+func (in *DNSSpec) DeepCopyInto(out *DNSSpec) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	if in.Nameservers != nil {
+		in, out := &in.Nameservers, &out.Nameservers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SearchDomains != nil {
+		in, out := &in.SearchDomains, &out.SearchDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalHost.
-func (in *HetznerBareMetalHost) DeepCopy() *HetznerBareMetalHost {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DNSSpec.
+func (in *DNSSpec) DeepCopy() *DNSSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(HetznerBareMetalHost)
+	out := new(DNSSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *HetznerBareMetalHost) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HetznerBareMetalHostList) DeepCopyInto(out *HetznerBareMetalHostList) {
+func (in *DatacenterProvisioningThrottle) DeepCopyInto(out *DatacenterProvisioningThrottle) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]HetznerBareMetalHost, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.MaxConcurrentRescue != nil {
+		in, out := &in.MaxConcurrentRescue, &out.MaxConcurrentRescue
+		*out = new(int)
+		**out = **in
+	}
+	if in.MaxConcurrentImaging != nil {
+		in, out := &in.MaxConcurrentImaging, &out.MaxConcurrentImaging
+		*out = new(int)
+		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalHostList.
-func (in *HetznerBareMetalHostList) DeepCopy() *HetznerBareMetalHostList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatacenterProvisioningThrottle.
+func (in *DatacenterProvisioningThrottle) DeepCopy() *DatacenterProvisioningThrottle {
 	if in == nil {
 		return nil
 	}
-	out := new(HetznerBareMetalHostList)
+	out := new(DatacenterProvisioningThrottle)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *HetznerBareMetalHostList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HetznerBareMetalHostSpec) DeepCopyInto(out *HetznerBareMetalHostSpec) {
+func (in *DeletionPolicy) DeepCopyInto(out *DeletionPolicy) {
 	*out = *in
-	if in.RootDeviceHints != nil {
-		in, out := &in.RootDeviceHints, &out.RootDeviceHints
-		*out = new(RootDeviceHints)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.ConsumerRef != nil {
-		in, out := &in.ConsumerRef, &out.ConsumerRef
-		*out = new(v1.ObjectReference)
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
 		**out = **in
 	}
-	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalHostSpec.
-func (in *HetznerBareMetalHostSpec) DeepCopy() *HetznerBareMetalHostSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeletionPolicy.
+func (in *DeletionPolicy) DeepCopy() *DeletionPolicy {
 	if in == nil {
 		return nil
 	}
-	out := new(HetznerBareMetalHostSpec)
+	out := new(DeletionPolicy)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HetznerBareMetalHostStatus) DeepCopyInto(out *HetznerBareMetalHostStatus) {
+func (in *DriftPolicies) DeepCopyInto(out *DriftPolicies) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalHostStatus.
-func (in *HetznerBareMetalHostStatus) DeepCopy() *HetznerBareMetalHostStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DriftPolicies.
+func (in *DriftPolicies) DeepCopy() *DriftPolicies {
 	if in == nil {
 		return nil
 	}
-	out := new(HetznerBareMetalHostStatus)
+	out := new(DriftPolicies)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HetznerBareMetalMachine) DeepCopyInto(out *HetznerBareMetalMachine) {
+func (in *ExhaustedLocation) DeepCopyInto(out *ExhaustedLocation) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
+	in.RetryAfter.DeepCopyInto(&out.RetryAfter)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalMachine.
-func (in *HetznerBareMetalMachine) DeepCopy() *HetznerBareMetalMachine {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExhaustedLocation.
+func (in *ExhaustedLocation) DeepCopy() *ExhaustedLocation {
 	if in == nil {
 		return nil
 	}
-	out := new(HetznerBareMetalMachine)
+	out := new(ExhaustedLocation)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *HetznerBareMetalMachine) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HetznerBareMetalMachineList) DeepCopyInto(out *HetznerBareMetalMachineList) {
+func (in *FloatingIPStatus) DeepCopyInto(out *FloatingIPStatus) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]HetznerBareMetalMachine, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalMachineList.
-func (in *HetznerBareMetalMachineList) DeepCopy() *HetznerBareMetalMachineList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FloatingIPStatus.
+func (in *FloatingIPStatus) DeepCopy() *FloatingIPStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(HetznerBareMetalMachineList)
+	out := new(FloatingIPStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *HetznerBareMetalMachineList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HetznerBareMetalMachineSpec) DeepCopyInto(out *HetznerBareMetalMachineSpec) {
+func (in *HCloudFirewallRule) DeepCopyInto(out *HCloudFirewallRule) {
 	*out = *in
-	if in.ProviderID != nil {
-		in, out := &in.ProviderID, &out.ProviderID
-		*out = new(string)
-		**out = **in
+	if in.SourceIPs != nil {
+		in, out := &in.SourceIPs, &out.SourceIPs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DestinationIPs != nil {
+		in, out := &in.DestinationIPs, &out.DestinationIPs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
-	in.InstallImage.DeepCopyInto(&out.InstallImage)
-	in.HostSelector.DeepCopyInto(&out.HostSelector)
-	out.SSHSpec = in.SSHSpec
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalMachineSpec.
-func (in *HetznerBareMetalMachineSpec) DeepCopy() *HetznerBareMetalMachineSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudFirewallRule.
+func (in *HCloudFirewallRule) DeepCopy() *HCloudFirewallRule {
 	if in == nil {
 		return nil
 	}
-	out := new(HetznerBareMetalMachineSpec)
+	out := new(HCloudFirewallRule)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HetznerBareMetalMachineStatus) DeepCopyInto(out *HetznerBareMetalMachineStatus) {
+func (in *HCloudFirewallSpec) DeepCopyInto(out *HCloudFirewallSpec) {
 	*out = *in
-	if in.LastUpdated != nil {
-		in, out := &in.LastUpdated, &out.LastUpdated
-		*out = (*in).DeepCopy()
-	}
-	if in.FailureReason != nil {
-		in, out := &in.FailureReason, &out.FailureReason
-		*out = new(errors.MachineStatusError)
-		**out = **in
-	}
-	if in.FailureMessage != nil {
-		in, out := &in.FailureMessage, &out.FailureMessage
-		*out = new(string)
-		**out = **in
-	}
-	if in.Addresses != nil {
-		in, out := &in.Addresses, &out.Addresses
-		*out = make([]v1.NodeAddress, len(*in))
-		copy(*out, *in)
-	}
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make(apiv1beta1.Conditions, len(*in))
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]HCloudFirewallRule, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalMachineStatus.
-func (in *HetznerBareMetalMachineStatus) DeepCopy() *HetznerBareMetalMachineStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudFirewallSpec.
+func (in *HCloudFirewallSpec) DeepCopy() *HCloudFirewallSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(HetznerBareMetalMachineStatus)
+	out := new(HCloudFirewallSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HetznerBareMetalMachineTemplate) DeepCopyInto(out *HetznerBareMetalMachineTemplate) {
+func (in *HCloudFirewallStatus) DeepCopyInto(out *HCloudFirewallStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudFirewallStatus.
+func (in *HCloudFirewallStatus) DeepCopy() *HCloudFirewallStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HCloudFirewallStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HCloudMachine) DeepCopyInto(out *HCloudMachine) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalMachineTemplate.
-func (in *HetznerBareMetalMachineTemplate) DeepCopy() *HetznerBareMetalMachineTemplate {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudMachine.
+func (in *HCloudMachine) DeepCopy() *HCloudMachine {
 	if in == nil {
 		return nil
 	}
-	out := new(HetznerBareMetalMachineTemplate)
+	out := new(HCloudMachine)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *HetznerBareMetalMachineTemplate) DeepCopyObject() runtime.Object {
+func (in *HCloudMachine) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -700,31 +625,26 @@ func (in *HetznerBareMetalMachineTemplate) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HetznerBareMetalMachineTemplateList) DeepCopyInto(out *HetznerBareMetalMachineTemplateList) {
+func (in *HCloudMachineImage) DeepCopyInto(out *HCloudMachineImage) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]HetznerBareMetalMachineTemplate, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalMachineTemplateList.
-func (in *HetznerBareMetalMachineTemplateList) DeepCopy() *HetznerBareMetalMachineTemplateList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudMachineImage.
+func (in *HCloudMachineImage) DeepCopy() *HCloudMachineImage {
 	if in == nil {
 		return nil
 	}
-	out := new(HetznerBareMetalMachineTemplateList)
+	out := new(HCloudMachineImage)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *HetznerBareMetalMachineTemplateList) DeepCopyObject() runtime.Object {
+func (in *HCloudMachineImage) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -732,90 +652,147 @@ func (in *HetznerBareMetalMachineTemplateList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HetznerBareMetalMachineTemplateResource) DeepCopyInto(out *HetznerBareMetalMachineTemplateResource) {
+func (in *HCloudMachineImageBuild) DeepCopyInto(out *HCloudMachineImageBuild) {
 	*out = *in
-	in.Spec.DeepCopyInto(&out.Spec)
+	in.StartTime.DeepCopyInto(&out.StartTime)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalMachineTemplateResource.
-func (in *HetznerBareMetalMachineTemplateResource) DeepCopy() *HetznerBareMetalMachineTemplateResource {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudMachineImageBuild.
+func (in *HCloudMachineImageBuild) DeepCopy() *HCloudMachineImageBuild {
 	if in == nil {
 		return nil
 	}
-	out := new(HetznerBareMetalMachineTemplateResource)
+	out := new(HCloudMachineImageBuild)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HetznerBareMetalMachineTemplateSpec) DeepCopyInto(out *HetznerBareMetalMachineTemplateSpec) {
+func (in *HCloudMachineImageList) DeepCopyInto(out *HCloudMachineImageList) {
 	*out = *in
-	in.Template.DeepCopyInto(&out.Template)
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalMachineTemplateSpec.
-func (in *HetznerBareMetalMachineTemplateSpec) DeepCopy() *HetznerBareMetalMachineTemplateSpec {
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]HCloudMachineImage, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudMachineImageList.
+func (in *HCloudMachineImageList) DeepCopy() *HCloudMachineImageList {
 	if in == nil {
 		return nil
 	}
-	out := new(HetznerBareMetalMachineTemplateSpec)
+	out := new(HCloudMachineImageList)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HCloudMachineImageList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HetznerBareMetalRemediation) DeepCopyInto(out *HetznerBareMetalRemediation) {
+func (in *HCloudMachineImageSpec) DeepCopyInto(out *HCloudMachineImageSpec) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.RebuildInterval != nil {
+		in, out := &in.RebuildInterval, &out.RebuildInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.SnapshotsToKeep != nil {
+		in, out := &in.SnapshotsToKeep, &out.SnapshotsToKeep
+		*out = new(int)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalRemediation.
-func (in *HetznerBareMetalRemediation) DeepCopy() *HetznerBareMetalRemediation {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudMachineImageSpec.
+func (in *HCloudMachineImageSpec) DeepCopy() *HCloudMachineImageSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(HetznerBareMetalRemediation)
+	out := new(HCloudMachineImageSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *HetznerBareMetalRemediation) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HCloudMachineImageStatus) DeepCopyInto(out *HCloudMachineImageStatus) {
+	*out = *in
+	if in.Build != nil {
+		in, out := &in.Build, &out.Build
+		*out = new(HCloudMachineImageBuild)
+		(*in).DeepCopyInto(*out)
 	}
-	return nil
+	if in.LastBuildTime != nil {
+		in, out := &in.LastBuildTime, &out.LastBuildTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(apiv1beta1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudMachineImageStatus.
+func (in *HCloudMachineImageStatus) DeepCopy() *HCloudMachineImageStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HCloudMachineImageStatus)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HetznerBareMetalRemediationList) DeepCopyInto(out *HetznerBareMetalRemediationList) {
+func (in *HCloudMachineList) DeepCopyInto(out *HCloudMachineList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]HetznerBareMetalRemediation, len(*in))
+		*out = make([]HCloudMachine, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalRemediationList.
-func (in *HetznerBareMetalRemediationList) DeepCopy() *HetznerBareMetalRemediationList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudMachineList.
+func (in *HCloudMachineList) DeepCopy() *HCloudMachineList {
 	if in == nil {
 		return nil
 	}
-	out := new(HetznerBareMetalRemediationList)
+	out := new(HCloudMachineList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *HetznerBareMetalRemediationList) DeepCopyObject() runtime.Object {
+func (in *HCloudMachineList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -823,46 +800,156 @@ func (in *HetznerBareMetalRemediationList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HetznerBareMetalRemediationSpec) DeepCopyInto(out *HetznerBareMetalRemediationSpec) {
+func (in *HCloudMachineSpec) DeepCopyInto(out *HCloudMachineSpec) {
 	*out = *in
-	if in.Strategy != nil {
-		in, out := &in.Strategy, &out.Strategy
-		*out = new(RemediationStrategy)
+	if in.ProviderID != nil {
+		in, out := &in.ProviderID, &out.ProviderID
+		*out = new(string)
+		**out = **in
+	}
+	if in.ImageSelector != nil {
+		in, out := &in.ImageSelector, &out.ImageSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SSHKeys != nil {
+		in, out := &in.SSHKeys, &out.SSHKeys
+		*out = make([]SSHKey, len(*in))
+		copy(*out, *in)
+	}
+	if in.AdditionalSSHKeys != nil {
+		in, out := &in.AdditionalSSHKeys, &out.AdditionalSSHKeys
+		*out = make([]SSHKeyReference, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PlacementGroupName != nil {
+		in, out := &in.PlacementGroupName, &out.PlacementGroupName
+		*out = new(string)
+		**out = **in
+	}
+	if in.AutomaticPlacementGroup != nil {
+		in, out := &in.AutomaticPlacementGroup, &out.AutomaticPlacementGroup
+		*out = new(AutomaticPlacementGroupSpec)
+		**out = **in
+	}
+	if in.PlacementGroupSelector != nil {
+		in, out := &in.PlacementGroupSelector, &out.PlacementGroupSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PublicNetwork != nil {
+		in, out := &in.PublicNetwork, &out.PublicNetwork
+		*out = new(PublicNetworkSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DeletionPolicy != nil {
+		in, out := &in.DeletionPolicy, &out.DeletionPolicy
+		*out = new(DeletionPolicy)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.DNS != nil {
+		in, out := &in.DNS, &out.DNS
+		*out = new(DNSSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]HCloudVolumeSpec, len(*in))
+		copy(*out, *in)
+	}
+	if in.EnableBackups != nil {
+		in, out := &in.EnableBackups, &out.EnableBackups
+		*out = new(bool)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalRemediationSpec.
-func (in *HetznerBareMetalRemediationSpec) DeepCopy() *HetznerBareMetalRemediationSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudMachineSpec.
+func (in *HCloudMachineSpec) DeepCopy() *HCloudMachineSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(HetznerBareMetalRemediationSpec)
+	out := new(HCloudMachineSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HetznerBareMetalRemediationStatus) DeepCopyInto(out *HetznerBareMetalRemediationStatus) {
+func (in *HCloudMachineStatus) DeepCopyInto(out *HCloudMachineStatus) {
 	*out = *in
-	if in.LastRemediated != nil {
-		in, out := &in.LastRemediated, &out.LastRemediated
-		*out = (*in).DeepCopy()
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]corev1.NodeAddress, len(*in))
+		copy(*out, *in)
+	}
+	if in.InstanceState != nil {
+		in, out := &in.InstanceState, &out.InstanceState
+		*out = new(hcloud.ServerStatus)
+		**out = **in
+	}
+	if in.AppliedConfiguration != nil {
+		in, out := &in.AppliedConfiguration, &out.AppliedConfiguration
+		*out = new(AppliedConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]HCloudVolumeStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.AppliedLabels != nil {
+		in, out := &in.AppliedLabels, &out.AppliedLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AppliedPublicNetwork != nil {
+		in, out := &in.AppliedPublicNetwork, &out.AppliedPublicNetwork
+		*out = new(AppliedPublicNetwork)
+		**out = **in
+	}
+	if in.KubeletServingCertificate != nil {
+		in, out := &in.KubeletServingCertificate, &out.KubeletServingCertificate
+		*out = new(CertificateStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Milestones != nil {
+		in, out := &in.Milestones, &out.Milestones
+		*out = new(ProvisioningMilestones)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FailureReason != nil {
+		in, out := &in.FailureReason, &out.FailureReason
+		*out = new(errors.MachineStatusError)
+		**out = **in
+	}
+	if in.FailureMessage != nil {
+		in, out := &in.FailureMessage, &out.FailureMessage
+		*out = new(string)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(apiv1beta1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalRemediationStatus.
-func (in *HetznerBareMetalRemediationStatus) DeepCopy() *HetznerBareMetalRemediationStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudMachineStatus.
+func (in *HCloudMachineStatus) DeepCopy() *HCloudMachineStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(HetznerBareMetalRemediationStatus)
+	out := new(HCloudMachineStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HetznerBareMetalRemediationTemplate) DeepCopyInto(out *HetznerBareMetalRemediationTemplate) {
+func (in *HCloudMachineTemplate) DeepCopyInto(out *HCloudMachineTemplate) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
@@ -870,18 +957,18 @@ func (in *HetznerBareMetalRemediationTemplate) DeepCopyInto(out *HetznerBareMeta
 	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalRemediationTemplate.
-func (in *HetznerBareMetalRemediationTemplate) DeepCopy() *HetznerBareMetalRemediationTemplate {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudMachineTemplate.
+func (in *HCloudMachineTemplate) DeepCopy() *HCloudMachineTemplate {
 	if in == nil {
 		return nil
 	}
-	out := new(HetznerBareMetalRemediationTemplate)
+	out := new(HCloudMachineTemplate)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *HetznerBareMetalRemediationTemplate) DeepCopyObject() runtime.Object {
+func (in *HCloudMachineTemplate) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -889,31 +976,31 @@ func (in *HetznerBareMetalRemediationTemplate) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HetznerBareMetalRemediationTemplateList) DeepCopyInto(out *HetznerBareMetalRemediationTemplateList) {
+func (in *HCloudMachineTemplateList) DeepCopyInto(out *HCloudMachineTemplateList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]HetznerBareMetalRemediationTemplate, len(*in))
+		*out = make([]HCloudMachineTemplate, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalRemediationTemplateList.
-func (in *HetznerBareMetalRemediationTemplateList) DeepCopy() *HetznerBareMetalRemediationTemplateList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudMachineTemplateList.
+func (in *HCloudMachineTemplateList) DeepCopy() *HCloudMachineTemplateList {
 	if in == nil {
 		return nil
 	}
-	out := new(HetznerBareMetalRemediationTemplateList)
+	out := new(HCloudMachineTemplateList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *HetznerBareMetalRemediationTemplateList) DeepCopyObject() runtime.Object {
+func (in *HCloudMachineTemplateList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -921,212 +1008,228 @@ func (in *HetznerBareMetalRemediationTemplateList) DeepCopyObject() runtime.Obje
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HetznerBareMetalRemediationTemplateResource) DeepCopyInto(out *HetznerBareMetalRemediationTemplateResource) {
+func (in *HCloudMachineTemplateResource) DeepCopyInto(out *HCloudMachineTemplateResource) {
 	*out = *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalRemediationTemplateResource.
-func (in *HetznerBareMetalRemediationTemplateResource) DeepCopy() *HetznerBareMetalRemediationTemplateResource {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudMachineTemplateResource.
+func (in *HCloudMachineTemplateResource) DeepCopy() *HCloudMachineTemplateResource {
 	if in == nil {
 		return nil
 	}
-	out := new(HetznerBareMetalRemediationTemplateResource)
+	out := new(HCloudMachineTemplateResource)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HetznerBareMetalRemediationTemplateSpec) DeepCopyInto(out *HetznerBareMetalRemediationTemplateSpec) {
+func (in *HCloudMachineTemplateSpec) DeepCopyInto(out *HCloudMachineTemplateSpec) {
 	*out = *in
 	in.Template.DeepCopyInto(&out.Template)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalRemediationTemplateSpec.
-func (in *HetznerBareMetalRemediationTemplateSpec) DeepCopy() *HetznerBareMetalRemediationTemplateSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudMachineTemplateSpec.
+func (in *HCloudMachineTemplateSpec) DeepCopy() *HCloudMachineTemplateSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(HetznerBareMetalRemediationTemplateSpec)
+	out := new(HCloudMachineTemplateSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HetznerBareMetalRemediationTemplateStatus) DeepCopyInto(out *HetznerBareMetalRemediationTemplateStatus) {
+func (in *HCloudMachineTemplateStatus) DeepCopyInto(out *HCloudMachineTemplateStatus) {
 	*out = *in
-	in.Status.DeepCopyInto(&out.Status)
+	if in.Capacity != nil {
+		in, out := &in.Capacity, &out.Capacity
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(apiv1beta1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalRemediationTemplateStatus.
-func (in *HetznerBareMetalRemediationTemplateStatus) DeepCopy() *HetznerBareMetalRemediationTemplateStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudMachineTemplateStatus.
+func (in *HCloudMachineTemplateStatus) DeepCopy() *HCloudMachineTemplateStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(HetznerBareMetalRemediationTemplateStatus)
+	out := new(HCloudMachineTemplateStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HetznerCluster) DeepCopyInto(out *HetznerCluster) {
+func (in *HCloudMaintenanceSpec) DeepCopyInto(out *HCloudMaintenanceSpec) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
+	if in.LeadTime != nil {
+		in, out := &in.LeadTime, &out.LeadTime
+		*out = new(v1.Duration)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerCluster.
-func (in *HetznerCluster) DeepCopy() *HetznerCluster {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudMaintenanceSpec.
+func (in *HCloudMaintenanceSpec) DeepCopy() *HCloudMaintenanceSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(HetznerCluster)
+	out := new(HCloudMaintenanceSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *HetznerCluster) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HetznerClusterList) DeepCopyInto(out *HetznerClusterList) {
+func (in *HCloudNATGatewaySpec) DeepCopyInto(out *HCloudNATGatewaySpec) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]HetznerCluster, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudNATGatewaySpec.
+func (in *HCloudNATGatewaySpec) DeepCopy() *HCloudNATGatewaySpec {
+	if in == nil {
+		return nil
 	}
+	out := new(HCloudNATGatewaySpec)
+	in.DeepCopyInto(out)
+	return out
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerClusterList.
-func (in *HetznerClusterList) DeepCopy() *HetznerClusterList {
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HCloudNetworkRef) DeepCopyInto(out *HCloudNetworkRef) {
+	*out = *in
+	if in.ID != nil {
+		in, out := &in.ID, &out.ID
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudNetworkRef.
+func (in *HCloudNetworkRef) DeepCopy() *HCloudNetworkRef {
 	if in == nil {
 		return nil
 	}
-	out := new(HetznerClusterList)
+	out := new(HCloudNetworkRef)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *HetznerClusterList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HCloudNetworkRouteSpec) DeepCopyInto(out *HCloudNetworkRouteSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudNetworkRouteSpec.
+func (in *HCloudNetworkRouteSpec) DeepCopy() *HCloudNetworkRouteSpec {
+	if in == nil {
+		return nil
 	}
-	return nil
+	out := new(HCloudNetworkRouteSpec)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HetznerClusterSpec) DeepCopyInto(out *HetznerClusterSpec) {
+func (in *HCloudNetworkSpec) DeepCopyInto(out *HCloudNetworkSpec) {
 	*out = *in
-	out.HCloudNetwork = in.HCloudNetwork
-	if in.ControlPlaneRegions != nil {
-		in, out := &in.ControlPlaneRegions, &out.ControlPlaneRegions
-		*out = make([]Region, len(*in))
+	if in.ExistingNetwork != nil {
+		in, out := &in.ExistingNetwork, &out.ExistingNetwork
+		*out = new(HCloudNetworkRef)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Subnets != nil {
+		in, out := &in.Subnets, &out.Subnets
+		*out = make([]HCloudSubnetSpec, len(*in))
 		copy(*out, *in)
 	}
-	in.SSHKeys.DeepCopyInto(&out.SSHKeys)
-	if in.ControlPlaneEndpoint != nil {
-		in, out := &in.ControlPlaneEndpoint, &out.ControlPlaneEndpoint
-		*out = new(apiv1beta1.APIEndpoint)
+	if in.NATGateway != nil {
+		in, out := &in.NATGateway, &out.NATGateway
+		*out = new(HCloudNATGatewaySpec)
 		**out = **in
 	}
-	in.ControlPlaneLoadBalancer.DeepCopyInto(&out.ControlPlaneLoadBalancer)
-	if in.HCloudPlacementGroup != nil {
-		in, out := &in.HCloudPlacementGroup, &out.HCloudPlacementGroup
-		*out = make([]HCloudPlacementGroupSpec, len(*in))
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]HCloudNetworkRouteSpec, len(*in))
 		copy(*out, *in)
 	}
-	out.HetznerSecret = in.HetznerSecret
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerClusterSpec.
-func (in *HetznerClusterSpec) DeepCopy() *HetznerClusterSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudNetworkSpec.
+func (in *HCloudNetworkSpec) DeepCopy() *HCloudNetworkSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(HetznerClusterSpec)
+	out := new(HCloudNetworkSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HetznerClusterStatus) DeepCopyInto(out *HetznerClusterStatus) {
+func (in *HCloudPlacementGroupSpec) DeepCopyInto(out *HCloudPlacementGroupSpec) {
 	*out = *in
-	if in.Network != nil {
-		in, out := &in.Network, &out.Network
-		*out = new(NetworkStatus)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.ControlPlaneLoadBalancer != nil {
-		in, out := &in.ControlPlaneLoadBalancer, &out.ControlPlaneLoadBalancer
-		*out = new(LoadBalancerStatus)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.HCloudPlacementGroup != nil {
-		in, out := &in.HCloudPlacementGroup, &out.HCloudPlacementGroup
-		*out = make([]HCloudPlacementGroupStatus, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
-	if in.FailureDomains != nil {
-		in, out := &in.FailureDomains, &out.FailureDomains
-		*out = make(apiv1beta1.FailureDomains, len(*in))
-		for key, val := range *in {
-			(*out)[key] = *val.DeepCopy()
-		}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudPlacementGroupSpec.
+func (in *HCloudPlacementGroupSpec) DeepCopy() *HCloudPlacementGroupSpec {
+	if in == nil {
+		return nil
 	}
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make(apiv1beta1.Conditions, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	out := new(HCloudPlacementGroupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HCloudPlacementGroupStatus) DeepCopyInto(out *HCloudPlacementGroupStatus) {
+	*out = *in
+	if in.Server != nil {
+		in, out := &in.Server, &out.Server
+		*out = make([]int, len(*in))
+		copy(*out, *in)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerClusterStatus.
-func (in *HetznerClusterStatus) DeepCopy() *HetznerClusterStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudPlacementGroupStatus.
+func (in *HCloudPlacementGroupStatus) DeepCopy() *HCloudPlacementGroupStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(HetznerClusterStatus)
+	out := new(HCloudPlacementGroupStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HetznerClusterTemplate) DeepCopyInto(out *HetznerClusterTemplate) {
+func (in *HCloudPrimaryIP) DeepCopyInto(out *HCloudPrimaryIP) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerClusterTemplate.
-func (in *HetznerClusterTemplate) DeepCopy() *HetznerClusterTemplate {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudPrimaryIP.
+func (in *HCloudPrimaryIP) DeepCopy() *HCloudPrimaryIP {
 	if in == nil {
 		return nil
 	}
-	out := new(HetznerClusterTemplate)
+	out := new(HCloudPrimaryIP)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *HetznerClusterTemplate) DeepCopyObject() runtime.Object {
+func (in *HCloudPrimaryIP) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -1134,31 +1237,31 @@ func (in *HetznerClusterTemplate) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HetznerClusterTemplateList) DeepCopyInto(out *HetznerClusterTemplateList) {
+func (in *HCloudPrimaryIPList) DeepCopyInto(out *HCloudPrimaryIPList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]HetznerClusterTemplate, len(*in))
+		*out = make([]HCloudPrimaryIP, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerClusterTemplateList.
-func (in *HetznerClusterTemplateList) DeepCopy() *HetznerClusterTemplateList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudPrimaryIPList.
+func (in *HCloudPrimaryIPList) DeepCopy() *HCloudPrimaryIPList {
 	if in == nil {
 		return nil
 	}
-	out := new(HetznerClusterTemplateList)
+	out := new(HCloudPrimaryIPList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *HetznerClusterTemplateList) DeepCopyObject() runtime.Object {
+func (in *HCloudPrimaryIPList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -1166,328 +1269,1842 @@ func (in *HetznerClusterTemplateList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HetznerClusterTemplateResource) DeepCopyInto(out *HetznerClusterTemplateResource) {
+func (in *HCloudPrimaryIPSpec) DeepCopyInto(out *HCloudPrimaryIPSpec) {
 	*out = *in
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
+	if in.ID != nil {
+		in, out := &in.ID, &out.ID
+		*out = new(int)
+		**out = **in
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ConsumerRef != nil {
+		in, out := &in.ConsumerRef, &out.ConsumerRef
+		*out = new(corev1.ObjectReference)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerClusterTemplateResource.
-func (in *HetznerClusterTemplateResource) DeepCopy() *HetznerClusterTemplateResource {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudPrimaryIPSpec.
+func (in *HCloudPrimaryIPSpec) DeepCopy() *HCloudPrimaryIPSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(HetznerClusterTemplateResource)
+	out := new(HCloudPrimaryIPSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HetznerClusterTemplateSpec) DeepCopyInto(out *HetznerClusterTemplateSpec) {
+func (in *HCloudPrimaryIPStatus) DeepCopyInto(out *HCloudPrimaryIPStatus) {
 	*out = *in
-	in.Template.DeepCopyInto(&out.Template)
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(apiv1beta1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerClusterTemplateSpec.
-func (in *HetznerClusterTemplateSpec) DeepCopy() *HetznerClusterTemplateSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudPrimaryIPStatus.
+func (in *HCloudPrimaryIPStatus) DeepCopy() *HCloudPrimaryIPStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(HetznerClusterTemplateSpec)
+	out := new(HCloudPrimaryIPStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HetznerSSHKeys) DeepCopyInto(out *HetznerSSHKeys) {
+func (in *HCloudQuota) DeepCopyInto(out *HCloudQuota) {
 	*out = *in
-	if in.HCloud != nil {
-		in, out := &in.HCloud, &out.HCloud
-		*out = make([]SSHKey, len(*in))
-		copy(*out, *in)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudQuota.
+func (in *HCloudQuota) DeepCopy() *HCloudQuota {
+	if in == nil {
+		return nil
 	}
-	out.RobotRescueSecretRef = in.RobotRescueSecretRef
+	out := new(HCloudQuota)
+	in.DeepCopyInto(out)
+	return out
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerSSHKeys.
-func (in *HetznerSSHKeys) DeepCopy() *HetznerSSHKeys {
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HCloudSubnetSpec) DeepCopyInto(out *HCloudSubnetSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudSubnetSpec.
+func (in *HCloudSubnetSpec) DeepCopy() *HCloudSubnetSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(HetznerSSHKeys)
+	out := new(HCloudSubnetSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HetznerSecretKeyRef) DeepCopyInto(out *HetznerSecretKeyRef) {
+func (in *HCloudVolumeSpec) DeepCopyInto(out *HCloudVolumeSpec) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerSecretKeyRef.
-func (in *HetznerSecretKeyRef) DeepCopy() *HetznerSecretKeyRef {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudVolumeSpec.
+func (in *HCloudVolumeSpec) DeepCopy() *HCloudVolumeSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(HetznerSecretKeyRef)
+	out := new(HCloudVolumeSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HetznerSecretRef) DeepCopyInto(out *HetznerSecretRef) {
+func (in *HCloudVolumeStatus) DeepCopyInto(out *HCloudVolumeStatus) {
 	*out = *in
-	out.Key = in.Key
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerSecretRef.
-func (in *HetznerSecretRef) DeepCopy() *HetznerSecretRef {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HCloudVolumeStatus.
+func (in *HCloudVolumeStatus) DeepCopy() *HCloudVolumeStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(HetznerSecretRef)
+	out := new(HCloudVolumeStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HostSelector) DeepCopyInto(out *HostSelector) {
+func (in *HardwareDetails) DeepCopyInto(out *HardwareDetails) {
 	*out = *in
-	if in.MatchLabels != nil {
-		in, out := &in.MatchLabels, &out.MatchLabels
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	if in.NIC != nil {
+		in, out := &in.NIC, &out.NIC
+		*out = make([]NIC, len(*in))
+		copy(*out, *in)
 	}
-	if in.MatchExpressions != nil {
-		in, out := &in.MatchExpressions, &out.MatchExpressions
-		*out = make([]HostSelectorRequirement, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = make([]Storage, len(*in))
+		copy(*out, *in)
+	}
+	in.CPU.DeepCopyInto(&out.CPU)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareDetails.
+func (in *HardwareDetails) DeepCopy() *HardwareDetails {
+	if in == nil {
+		return nil
+	}
+	out := new(HardwareDetails)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HardwareInspection) DeepCopyInto(out *HardwareInspection) {
+	*out = *in
+	if in.RAMGB != nil {
+		in, out := &in.RAMGB, &out.RAMGB
+		*out = new(int)
+		**out = **in
+	}
+	if in.NIC != nil {
+		in, out := &in.NIC, &out.NIC
+		*out = make([]NIC, len(*in))
+		copy(*out, *in)
+	}
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = make([]Storage, len(*in))
+		copy(*out, *in)
+	}
+	if in.CPU != nil {
+		in, out := &in.CPU, &out.CPU
+		*out = new(CPU)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareInspection.
+func (in *HardwareInspection) DeepCopy() *HardwareInspection {
+	if in == nil {
+		return nil
+	}
+	out := new(HardwareInspection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerBareMetalHost) DeepCopyInto(out *HetznerBareMetalHost) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalHost.
+func (in *HetznerBareMetalHost) DeepCopy() *HetznerBareMetalHost {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerBareMetalHost)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HetznerBareMetalHost) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerBareMetalHostList) DeepCopyInto(out *HetznerBareMetalHostList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]HetznerBareMetalHost, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalHostList.
+func (in *HetznerBareMetalHostList) DeepCopy() *HetznerBareMetalHostList {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerBareMetalHostList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HetznerBareMetalHostList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerBareMetalHostSpec) DeepCopyInto(out *HetznerBareMetalHostSpec) {
+	*out = *in
+	if in.RootDeviceHints != nil {
+		in, out := &in.RootDeviceHints, &out.RootDeviceHints
+		*out = new(RootDeviceHints)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConsumerRef != nil {
+		in, out := &in.ConsumerRef, &out.ConsumerRef
+		*out = new(corev1.ObjectReference)
+		**out = **in
+	}
+	if in.Reservation != nil {
+		in, out := &in.Reservation, &out.Reservation
+		*out = new(HostReservation)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalHostSpec.
+func (in *HetznerBareMetalHostSpec) DeepCopy() *HetznerBareMetalHostSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerBareMetalHostSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerBareMetalHostStatus) DeepCopyInto(out *HetznerBareMetalHostStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalHostStatus.
+func (in *HetznerBareMetalHostStatus) DeepCopy() *HetznerBareMetalHostStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerBareMetalHostStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerBareMetalMachine) DeepCopyInto(out *HetznerBareMetalMachine) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalMachine.
+func (in *HetznerBareMetalMachine) DeepCopy() *HetznerBareMetalMachine {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerBareMetalMachine)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HetznerBareMetalMachine) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerBareMetalMachineList) DeepCopyInto(out *HetznerBareMetalMachineList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]HetznerBareMetalMachine, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalMachineList.
+func (in *HetznerBareMetalMachineList) DeepCopy() *HetznerBareMetalMachineList {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerBareMetalMachineList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HetznerBareMetalMachineList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerBareMetalMachineSpec) DeepCopyInto(out *HetznerBareMetalMachineSpec) {
+	*out = *in
+	if in.ProviderID != nil {
+		in, out := &in.ProviderID, &out.ProviderID
+		*out = new(string)
+		**out = **in
+	}
+	in.InstallImage.DeepCopyInto(&out.InstallImage)
+	in.HostSelector.DeepCopyInto(&out.HostSelector)
+	in.SSHSpec.DeepCopyInto(&out.SSHSpec)
+	if in.DNS != nil {
+		in, out := &in.DNS, &out.DNS
+		*out = new(DNSSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ProvisioningChecks != nil {
+		in, out := &in.ProvisioningChecks, &out.ProvisioningChecks
+		*out = new(ProvisioningChecks)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalMachineSpec.
+func (in *HetznerBareMetalMachineSpec) DeepCopy() *HetznerBareMetalMachineSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerBareMetalMachineSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerBareMetalMachineStatus) DeepCopyInto(out *HetznerBareMetalMachineStatus) {
+	*out = *in
+	if in.LastUpdated != nil {
+		in, out := &in.LastUpdated, &out.LastUpdated
+		*out = (*in).DeepCopy()
+	}
+	if in.FailureReason != nil {
+		in, out := &in.FailureReason, &out.FailureReason
+		*out = new(errors.MachineStatusError)
+		**out = **in
+	}
+	if in.FailureMessage != nil {
+		in, out := &in.FailureMessage, &out.FailureMessage
+		*out = new(string)
+		**out = **in
+	}
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]corev1.NodeAddress, len(*in))
+		copy(*out, *in)
+	}
+	if in.KubeletServingCertificate != nil {
+		in, out := &in.KubeletServingCertificate, &out.KubeletServingCertificate
+		*out = new(CertificateStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Milestones != nil {
+		in, out := &in.Milestones, &out.Milestones
+		*out = new(ProvisioningMilestones)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReleasedHosts != nil {
+		in, out := &in.ReleasedHosts, &out.ReleasedHosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(apiv1beta1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalMachineStatus.
+func (in *HetznerBareMetalMachineStatus) DeepCopy() *HetznerBareMetalMachineStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerBareMetalMachineStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerBareMetalMachineTemplate) DeepCopyInto(out *HetznerBareMetalMachineTemplate) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalMachineTemplate.
+func (in *HetznerBareMetalMachineTemplate) DeepCopy() *HetznerBareMetalMachineTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerBareMetalMachineTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HetznerBareMetalMachineTemplate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerBareMetalMachineTemplateList) DeepCopyInto(out *HetznerBareMetalMachineTemplateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]HetznerBareMetalMachineTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalMachineTemplateList.
+func (in *HetznerBareMetalMachineTemplateList) DeepCopy() *HetznerBareMetalMachineTemplateList {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerBareMetalMachineTemplateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HetznerBareMetalMachineTemplateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerBareMetalMachineTemplateResource) DeepCopyInto(out *HetznerBareMetalMachineTemplateResource) {
+	*out = *in
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalMachineTemplateResource.
+func (in *HetznerBareMetalMachineTemplateResource) DeepCopy() *HetznerBareMetalMachineTemplateResource {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerBareMetalMachineTemplateResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerBareMetalMachineTemplateSpec) DeepCopyInto(out *HetznerBareMetalMachineTemplateSpec) {
+	*out = *in
+	in.Template.DeepCopyInto(&out.Template)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalMachineTemplateSpec.
+func (in *HetznerBareMetalMachineTemplateSpec) DeepCopy() *HetznerBareMetalMachineTemplateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerBareMetalMachineTemplateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerBareMetalRemediation) DeepCopyInto(out *HetznerBareMetalRemediation) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalRemediation.
+func (in *HetznerBareMetalRemediation) DeepCopy() *HetznerBareMetalRemediation {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerBareMetalRemediation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HetznerBareMetalRemediation) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerBareMetalRemediationList) DeepCopyInto(out *HetznerBareMetalRemediationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]HetznerBareMetalRemediation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalRemediationList.
+func (in *HetznerBareMetalRemediationList) DeepCopy() *HetznerBareMetalRemediationList {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerBareMetalRemediationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HetznerBareMetalRemediationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerBareMetalRemediationSpec) DeepCopyInto(out *HetznerBareMetalRemediationSpec) {
+	*out = *in
+	if in.Strategy != nil {
+		in, out := &in.Strategy, &out.Strategy
+		*out = new(RemediationStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalRemediationSpec.
+func (in *HetznerBareMetalRemediationSpec) DeepCopy() *HetznerBareMetalRemediationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerBareMetalRemediationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerBareMetalRemediationStatus) DeepCopyInto(out *HetznerBareMetalRemediationStatus) {
+	*out = *in
+	if in.LastRemediated != nil {
+		in, out := &in.LastRemediated, &out.LastRemediated
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(apiv1beta1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalRemediationStatus.
+func (in *HetznerBareMetalRemediationStatus) DeepCopy() *HetznerBareMetalRemediationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerBareMetalRemediationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerBareMetalRemediationTemplate) DeepCopyInto(out *HetznerBareMetalRemediationTemplate) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalRemediationTemplate.
+func (in *HetznerBareMetalRemediationTemplate) DeepCopy() *HetznerBareMetalRemediationTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerBareMetalRemediationTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HetznerBareMetalRemediationTemplate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerBareMetalRemediationTemplateList) DeepCopyInto(out *HetznerBareMetalRemediationTemplateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]HetznerBareMetalRemediationTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalRemediationTemplateList.
+func (in *HetznerBareMetalRemediationTemplateList) DeepCopy() *HetznerBareMetalRemediationTemplateList {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerBareMetalRemediationTemplateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HetznerBareMetalRemediationTemplateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerBareMetalRemediationTemplateResource) DeepCopyInto(out *HetznerBareMetalRemediationTemplateResource) {
+	*out = *in
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalRemediationTemplateResource.
+func (in *HetznerBareMetalRemediationTemplateResource) DeepCopy() *HetznerBareMetalRemediationTemplateResource {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerBareMetalRemediationTemplateResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerBareMetalRemediationTemplateSpec) DeepCopyInto(out *HetznerBareMetalRemediationTemplateSpec) {
+	*out = *in
+	in.Template.DeepCopyInto(&out.Template)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalRemediationTemplateSpec.
+func (in *HetznerBareMetalRemediationTemplateSpec) DeepCopy() *HetznerBareMetalRemediationTemplateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerBareMetalRemediationTemplateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerBareMetalRemediationTemplateStatus) DeepCopyInto(out *HetznerBareMetalRemediationTemplateStatus) {
+	*out = *in
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerBareMetalRemediationTemplateStatus.
+func (in *HetznerBareMetalRemediationTemplateStatus) DeepCopy() *HetznerBareMetalRemediationTemplateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerBareMetalRemediationTemplateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerCluster) DeepCopyInto(out *HetznerCluster) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerCluster.
+func (in *HetznerCluster) DeepCopy() *HetznerCluster {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerCluster)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HetznerCluster) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerClusterList) DeepCopyInto(out *HetznerClusterList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]HetznerCluster, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerClusterList.
+func (in *HetznerClusterList) DeepCopy() *HetznerClusterList {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerClusterList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HetznerClusterList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerClusterSpec) DeepCopyInto(out *HetznerClusterSpec) {
+	*out = *in
+	in.HCloudNetwork.DeepCopyInto(&out.HCloudNetwork)
+	if in.ControlPlaneRegions != nil {
+		in, out := &in.ControlPlaneRegions, &out.ControlPlaneRegions
+		*out = make([]Region, len(*in))
+		copy(*out, *in)
+	}
+	in.SSHKeys.DeepCopyInto(&out.SSHKeys)
+	if in.ControlPlaneEndpoint != nil {
+		in, out := &in.ControlPlaneEndpoint, &out.ControlPlaneEndpoint
+		*out = new(apiv1beta1.APIEndpoint)
+		**out = **in
+	}
+	in.ControlPlaneLoadBalancer.DeepCopyInto(&out.ControlPlaneLoadBalancer)
+	if in.ControlPlaneFloatingIP != nil {
+		in, out := &in.ControlPlaneFloatingIP, &out.ControlPlaneFloatingIP
+		*out = new(ControlPlaneFloatingIPSpec)
+		**out = **in
+	}
+	if in.HCloudPlacementGroup != nil {
+		in, out := &in.HCloudPlacementGroup, &out.HCloudPlacementGroup
+		*out = make([]HCloudPlacementGroupSpec, len(*in))
+		copy(*out, *in)
+	}
+	if in.HCloudFirewalls != nil {
+		in, out := &in.HCloudFirewalls, &out.HCloudFirewalls
+		*out = make([]HCloudFirewallSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	out.HetznerSecret = in.HetznerSecret
+	if in.DNS != nil {
+		in, out := &in.DNS, &out.DNS
+		*out = new(DNSSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HostHealthNodeConditions != nil {
+		in, out := &in.HostHealthNodeConditions, &out.HostHealthNodeConditions
+		*out = make([]corev1.NodeConditionType, len(*in))
+		copy(*out, *in)
+	}
+	if in.SSHDefaults != nil {
+		in, out := &in.SSHDefaults, &out.SSHDefaults
+		*out = new(SSHSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HCloudServerTypeSuccessors != nil {
+		in, out := &in.HCloudServerTypeSuccessors, &out.HCloudServerTypeSuccessors
+		*out = make(map[string]HCloudMachineType, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PlacementConstraints != nil {
+		in, out := &in.PlacementConstraints, &out.PlacementConstraints
+		*out = new(PlacementConstraints)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DriftPolicies != nil {
+		in, out := &in.DriftPolicies, &out.DriftPolicies
+		*out = new(DriftPolicies)
+		**out = **in
+	}
+	if in.ServerLabels != nil {
+		in, out := &in.ServerLabels, &out.ServerLabels
+		*out = new(ServerLabels)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ProtectControlPlaneServers != nil {
+		in, out := &in.ProtectControlPlaneServers, &out.ProtectControlPlaneServers
+		*out = new(bool)
+		**out = **in
+	}
+	if in.HCloudQuota != nil {
+		in, out := &in.HCloudQuota, &out.HCloudQuota
+		*out = new(HCloudQuota)
+		**out = **in
+	}
+	if in.ProvisioningThrottle != nil {
+		in, out := &in.ProvisioningThrottle, &out.ProvisioningThrottle
+		*out = new(ProvisioningThrottle)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ProvisioningFirewall != nil {
+		in, out := &in.ProvisioningFirewall, &out.ProvisioningFirewall
+		*out = new(ProvisioningFirewall)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TrustedCABundle != nil {
+		in, out := &in.TrustedCABundle, &out.TrustedCABundle
+		*out = new(TrustedCABundleRef)
+		**out = **in
+	}
+	if in.ConsoleUser != nil {
+		in, out := &in.ConsoleUser, &out.ConsoleUser
+		*out = new(ConsoleUserSpec)
+		**out = **in
+	}
+	if in.CloudInitParts != nil {
+		in, out := &in.CloudInitParts, &out.CloudInitParts
+		*out = make([]CloudInitPart, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.HCloudMaintenance != nil {
+		in, out := &in.HCloudMaintenance, &out.HCloudMaintenance
+		*out = new(HCloudMaintenanceSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DeletionPolicy != nil {
+		in, out := &in.DeletionPolicy, &out.DeletionPolicy
+		*out = new(ClusterDeletionPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerClusterSpec.
+func (in *HetznerClusterSpec) DeepCopy() *HetznerClusterSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerClusterSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerClusterStatus) DeepCopyInto(out *HetznerClusterStatus) {
+	*out = *in
+	if in.Network != nil {
+		in, out := &in.Network, &out.Network
+		*out = new(NetworkStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ControlPlaneLoadBalancer != nil {
+		in, out := &in.ControlPlaneLoadBalancer, &out.ControlPlaneLoadBalancer
+		*out = new(LoadBalancerStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ControlPlaneFloatingIP != nil {
+		in, out := &in.ControlPlaneFloatingIP, &out.ControlPlaneFloatingIP
+		*out = new(FloatingIPStatus)
+		**out = **in
+	}
+	if in.HCloudPlacementGroup != nil {
+		in, out := &in.HCloudPlacementGroup, &out.HCloudPlacementGroup
+		*out = make([]HCloudPlacementGroupStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.HCloudFirewalls != nil {
+		in, out := &in.HCloudFirewalls, &out.HCloudFirewalls
+		*out = make([]HCloudFirewallStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.OrphanedResources != nil {
+		in, out := &in.OrphanedResources, &out.OrphanedResources
+		*out = make([]OrphanedResource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ExhaustedLocations != nil {
+		in, out := &in.ExhaustedLocations, &out.ExhaustedLocations
+		*out = make([]ExhaustedLocation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.EgressIPs != nil {
+		in, out := &in.EgressIPs, &out.EgressIPs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.FailureDomains != nil {
+		in, out := &in.FailureDomains, &out.FailureDomains
+		*out = make(apiv1beta1.FailureDomains, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(apiv1beta1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerClusterStatus.
+func (in *HetznerClusterStatus) DeepCopy() *HetznerClusterStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerClusterStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerClusterTemplate) DeepCopyInto(out *HetznerClusterTemplate) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerClusterTemplate.
+func (in *HetznerClusterTemplate) DeepCopy() *HetznerClusterTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerClusterTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HetznerClusterTemplate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerClusterTemplateList) DeepCopyInto(out *HetznerClusterTemplateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]HetznerClusterTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerClusterTemplateList.
+func (in *HetznerClusterTemplateList) DeepCopy() *HetznerClusterTemplateList {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerClusterTemplateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HetznerClusterTemplateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerClusterTemplateResource) DeepCopyInto(out *HetznerClusterTemplateResource) {
+	*out = *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerClusterTemplateResource.
+func (in *HetznerClusterTemplateResource) DeepCopy() *HetznerClusterTemplateResource {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerClusterTemplateResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerClusterTemplateSpec) DeepCopyInto(out *HetznerClusterTemplateSpec) {
+	*out = *in
+	in.Template.DeepCopyInto(&out.Template)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerClusterTemplateSpec.
+func (in *HetznerClusterTemplateSpec) DeepCopy() *HetznerClusterTemplateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerClusterTemplateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerNodeProfile) DeepCopyInto(out *HetznerNodeProfile) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerNodeProfile.
+func (in *HetznerNodeProfile) DeepCopy() *HetznerNodeProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerNodeProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HetznerNodeProfile) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerNodeProfileList) DeepCopyInto(out *HetznerNodeProfileList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]HetznerNodeProfile, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerNodeProfileList.
+func (in *HetznerNodeProfileList) DeepCopy() *HetznerNodeProfileList {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerNodeProfileList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HetznerNodeProfileList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerNodeProfileSpec) DeepCopyInto(out *HetznerNodeProfileSpec) {
+	*out = *in
+	if in.SSHAuthorizedKeys != nil {
+		in, out := &in.SSHAuthorizedKeys, &out.SSHAuthorizedKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NTPServers != nil {
+		in, out := &in.NTPServers, &out.NTPServers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RegistryMirrors != nil {
+		in, out := &in.RegistryMirrors, &out.RegistryMirrors
+		*out = make([]RegistryMirror, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Sysctls != nil {
+		in, out := &in.Sysctls, &out.Sysctls
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Hardening != nil {
+		in, out := &in.Hardening, &out.Hardening
+		*out = new(NodeHardening)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerNodeProfileSpec.
+func (in *HetznerNodeProfileSpec) DeepCopy() *HetznerNodeProfileSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerNodeProfileSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerSSHKeys) DeepCopyInto(out *HetznerSSHKeys) {
+	*out = *in
+	if in.HCloud != nil {
+		in, out := &in.HCloud, &out.HCloud
+		*out = make([]SSHKey, len(*in))
+		copy(*out, *in)
+	}
+	out.RobotRescueSecretRef = in.RobotRescueSecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerSSHKeys.
+func (in *HetznerSSHKeys) DeepCopy() *HetznerSSHKeys {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerSSHKeys)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerSecretKeyRef) DeepCopyInto(out *HetznerSecretKeyRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerSecretKeyRef.
+func (in *HetznerSecretKeyRef) DeepCopy() *HetznerSecretKeyRef {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerSecretKeyRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerSecretRef) DeepCopyInto(out *HetznerSecretRef) {
+	*out = *in
+	out.Key = in.Key
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerSecretRef.
+func (in *HetznerSecretRef) DeepCopy() *HetznerSecretRef {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerSecretRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostReservation) DeepCopyInto(out *HostReservation) {
+	*out = *in
+	if in.MachineNames != nil {
+		in, out := &in.MachineNames, &out.MachineNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MachineSelector != nil {
+		in, out := &in.MachineSelector, &out.MachineSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostReservation.
+func (in *HostReservation) DeepCopy() *HostReservation {
+	if in == nil {
+		return nil
+	}
+	out := new(HostReservation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostSelector) DeepCopyInto(out *HostSelector) {
+	*out = *in
+	if in.MatchLabels != nil {
+		in, out := &in.MatchLabels, &out.MatchLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.MatchExpressions != nil {
+		in, out := &in.MatchExpressions, &out.MatchExpressions
+		*out = make([]HostSelectorRequirement, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostSelector.
+func (in *HostSelector) DeepCopy() *HostSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(HostSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostSelectorRequirement) DeepCopyInto(out *HostSelectorRequirement) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostSelectorRequirement.
+func (in *HostSelectorRequirement) DeepCopy() *HostSelectorRequirement {
+	if in == nil {
+		return nil
+	}
+	out := new(HostSelectorRequirement)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Image) DeepCopyInto(out *Image) {
+	*out = *in
+	if in.DownloadSecretRef != nil {
+		in, out := &in.DownloadSecretRef, &out.DownloadSecretRef
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Image.
+func (in *Image) DeepCopy() *Image {
+	if in == nil {
+		return nil
+	}
+	out := new(Image)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstallImage) DeepCopyInto(out *InstallImage) {
+	*out = *in
+	in.Image.DeepCopyInto(&out.Image)
+	if in.PostInstallSteps != nil {
+		in, out := &in.PostInstallSteps, &out.PostInstallSteps
+		*out = make([]PostInstallStep, len(*in))
+		copy(*out, *in)
+	}
+	if in.Partitions != nil {
+		in, out := &in.Partitions, &out.Partitions
+		*out = make([]Partition, len(*in))
+		copy(*out, *in)
+	}
+	if in.LVMDefinitions != nil {
+		in, out := &in.LVMDefinitions, &out.LVMDefinitions
+		*out = make([]LVMDefinition, len(*in))
+		copy(*out, *in)
+	}
+	if in.BTRFSDefinitions != nil {
+		in, out := &in.BTRFSDefinitions, &out.BTRFSDefinitions
+		*out = make([]BTRFSDefinition, len(*in))
+		copy(*out, *in)
+	}
+	if in.Swap != nil {
+		in, out := &in.Swap, &out.Swap
+		*out = new(SwapSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstallImage.
+func (in *InstallImage) DeepCopy() *InstallImage {
+	if in == nil {
+		return nil
+	}
+	out := new(InstallImage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LVMDefinition) DeepCopyInto(out *LVMDefinition) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LVMDefinition.
+func (in *LVMDefinition) DeepCopy() *LVMDefinition {
+	if in == nil {
+		return nil
+	}
+	out := new(LVMDefinition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoadBalancerCertificateSpec) DeepCopyInto(out *LoadBalancerCertificateSpec) {
+	*out = *in
+	if in.DomainNames != nil {
+		in, out := &in.DomainNames, &out.DomainNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerCertificateSpec.
+func (in *LoadBalancerCertificateSpec) DeepCopy() *LoadBalancerCertificateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LoadBalancerCertificateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoadBalancerHealthCheckSpec) DeepCopyInto(out *LoadBalancerHealthCheckSpec) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Retries != nil {
+		in, out := &in.Retries, &out.Retries
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerHealthCheckSpec.
+func (in *LoadBalancerHealthCheckSpec) DeepCopy() *LoadBalancerHealthCheckSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LoadBalancerHealthCheckSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoadBalancerProperties) DeepCopyInto(out *LoadBalancerProperties) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerProperties.
+func (in *LoadBalancerProperties) DeepCopy() *LoadBalancerProperties {
+	if in == nil {
+		return nil
+	}
+	out := new(LoadBalancerProperties)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoadBalancerServiceSpec) DeepCopyInto(out *LoadBalancerServiceSpec) {
+	*out = *in
+	if in.Certificate != nil {
+		in, out := &in.Certificate, &out.Certificate
+		*out = new(LoadBalancerCertificateSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerServiceSpec.
+func (in *LoadBalancerServiceSpec) DeepCopy() *LoadBalancerServiceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LoadBalancerServiceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoadBalancerSpec) DeepCopyInto(out *LoadBalancerSpec) {
+	*out = *in
+	if in.Name != nil {
+		in, out := &in.Name, &out.Name
+		*out = new(string)
+		**out = **in
+	}
+	if in.ExtraServices != nil {
+		in, out := &in.ExtraServices, &out.ExtraServices
+		*out = make([]LoadBalancerServiceSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.HealthCheck != nil {
+		in, out := &in.HealthCheck, &out.HealthCheck
+		*out = new(LoadBalancerHealthCheckSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerSpec.
+func (in *LoadBalancerSpec) DeepCopy() *LoadBalancerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LoadBalancerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoadBalancerStatus) DeepCopyInto(out *LoadBalancerStatus) {
+	*out = *in
+	if in.Target != nil {
+		in, out := &in.Target, &out.Target
+		*out = make([]LoadBalancerTarget, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AppliedProperties != nil {
+		in, out := &in.AppliedProperties, &out.AppliedProperties
+		*out = new(LoadBalancerProperties)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerStatus.
+func (in *LoadBalancerStatus) DeepCopy() *LoadBalancerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LoadBalancerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoadBalancerTarget) DeepCopyInto(out *LoadBalancerTarget) {
+	*out = *in
+	if in.HealthStatus != nil {
+		in, out := &in.HealthStatus, &out.HealthStatus
+		*out = make([]LoadBalancerTargetHealthStatus, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerTarget.
+func (in *LoadBalancerTarget) DeepCopy() *LoadBalancerTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(LoadBalancerTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoadBalancerTargetHealthStatus) DeepCopyInto(out *LoadBalancerTargetHealthStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerTargetHealthStatus.
+func (in *LoadBalancerTargetHealthStatus) DeepCopy() *LoadBalancerTargetHealthStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LoadBalancerTargetHealthStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NIC) DeepCopyInto(out *NIC) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NIC.
+func (in *NIC) DeepCopy() *NIC {
+	if in == nil {
+		return nil
+	}
+	out := new(NIC)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkStatus) DeepCopyInto(out *NetworkStatus) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.AttachedServers != nil {
+		in, out := &in.AttachedServers, &out.AttachedServers
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]HCloudNetworkRouteSpec, len(*in))
+		copy(*out, *in)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostSelector.
-func (in *HostSelector) DeepCopy() *HostSelector {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkStatus.
+func (in *NetworkStatus) DeepCopy() *NetworkStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(HostSelector)
+	out := new(NetworkStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HostSelectorRequirement) DeepCopyInto(out *HostSelectorRequirement) {
+func (in *NodeHardening) DeepCopyInto(out *NodeHardening) {
 	*out = *in
-	if in.Values != nil {
-		in, out := &in.Values, &out.Values
+	if in.DisabledKernelModules != nil {
+		in, out := &in.DisabledKernelModules, &out.DisabledKernelModules
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostSelectorRequirement.
-func (in *HostSelectorRequirement) DeepCopy() *HostSelectorRequirement {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeHardening.
+func (in *NodeHardening) DeepCopy() *NodeHardening {
 	if in == nil {
 		return nil
 	}
-	out := new(HostSelectorRequirement)
+	out := new(NodeHardening)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Image) DeepCopyInto(out *Image) {
+func (in *OrphanedResource) DeepCopyInto(out *OrphanedResource) {
 	*out = *in
+	in.OrphanedAt.DeepCopyInto(&out.OrphanedAt)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Image.
-func (in *Image) DeepCopy() *Image {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrphanedResource.
+func (in *OrphanedResource) DeepCopy() *OrphanedResource {
 	if in == nil {
 		return nil
 	}
-	out := new(Image)
+	out := new(OrphanedResource)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *InstallImage) DeepCopyInto(out *InstallImage) {
+func (in *Partition) DeepCopyInto(out *Partition) {
 	*out = *in
-	out.Image = in.Image
-	if in.Partitions != nil {
-		in, out := &in.Partitions, &out.Partitions
-		*out = make([]Partition, len(*in))
-		copy(*out, *in)
-	}
-	if in.LVMDefinitions != nil {
-		in, out := &in.LVMDefinitions, &out.LVMDefinitions
-		*out = make([]LVMDefinition, len(*in))
-		copy(*out, *in)
-	}
-	if in.BTRFSDefinitions != nil {
-		in, out := &in.BTRFSDefinitions, &out.BTRFSDefinitions
-		*out = make([]BTRFSDefinition, len(*in))
-		copy(*out, *in)
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstallImage.
-func (in *InstallImage) DeepCopy() *InstallImage {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Partition.
+func (in *Partition) DeepCopy() *Partition {
 	if in == nil {
 		return nil
 	}
-	out := new(InstallImage)
+	out := new(Partition)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *LVMDefinition) DeepCopyInto(out *LVMDefinition) {
+func (in *PlacementConstraints) DeepCopyInto(out *PlacementConstraints) {
 	*out = *in
+	if in.AllowedLocations != nil {
+		in, out := &in.AllowedLocations, &out.AllowedLocations
+		*out = make([]Region, len(*in))
+		copy(*out, *in)
+	}
+	if in.DeniedLocations != nil {
+		in, out := &in.DeniedLocations, &out.DeniedLocations
+		*out = make([]Region, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedDatacenters != nil {
+		in, out := &in.AllowedDatacenters, &out.AllowedDatacenters
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DeniedDatacenters != nil {
+		in, out := &in.DeniedDatacenters, &out.DeniedDatacenters
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LVMDefinition.
-func (in *LVMDefinition) DeepCopy() *LVMDefinition {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementConstraints.
+func (in *PlacementConstraints) DeepCopy() *PlacementConstraints {
 	if in == nil {
 		return nil
 	}
-	out := new(LVMDefinition)
+	out := new(PlacementConstraints)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *LoadBalancerServiceSpec) DeepCopyInto(out *LoadBalancerServiceSpec) {
+func (in *PostInstallStep) DeepCopyInto(out *PostInstallStep) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerServiceSpec.
-func (in *LoadBalancerServiceSpec) DeepCopy() *LoadBalancerServiceSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostInstallStep.
+func (in *PostInstallStep) DeepCopy() *PostInstallStep {
 	if in == nil {
 		return nil
 	}
-	out := new(LoadBalancerServiceSpec)
+	out := new(PostInstallStep)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *LoadBalancerSpec) DeepCopyInto(out *LoadBalancerSpec) {
+func (in *PostInstallStepStatus) DeepCopyInto(out *PostInstallStepStatus) {
 	*out = *in
-	if in.Name != nil {
-		in, out := &in.Name, &out.Name
-		*out = new(string)
-		**out = **in
-	}
-	if in.ExtraServices != nil {
-		in, out := &in.ExtraServices, &out.ExtraServices
-		*out = make([]LoadBalancerServiceSpec, len(*in))
-		copy(*out, *in)
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerSpec.
-func (in *LoadBalancerSpec) DeepCopy() *LoadBalancerSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostInstallStepStatus.
+func (in *PostInstallStepStatus) DeepCopy() *PostInstallStepStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(LoadBalancerSpec)
+	out := new(PostInstallStepStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *LoadBalancerStatus) DeepCopyInto(out *LoadBalancerStatus) {
+func (in *PrivateProvisioning) DeepCopyInto(out *PrivateProvisioning) {
 	*out = *in
-	if in.Target != nil {
-		in, out := &in.Target, &out.Target
-		*out = make([]LoadBalancerTarget, len(*in))
-		copy(*out, *in)
-	}
+	out.Bastion = in.Bastion
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerStatus.
-func (in *LoadBalancerStatus) DeepCopy() *LoadBalancerStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrivateProvisioning.
+func (in *PrivateProvisioning) DeepCopy() *PrivateProvisioning {
 	if in == nil {
 		return nil
 	}
-	out := new(LoadBalancerStatus)
+	out := new(PrivateProvisioning)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *LoadBalancerTarget) DeepCopyInto(out *LoadBalancerTarget) {
+func (in *ProvisioningChecks) DeepCopyInto(out *ProvisioningChecks) {
 	*out = *in
+	if in.SystemdUnits != nil {
+		in, out := &in.SystemdUnits, &out.SystemdUnits
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerTarget.
-func (in *LoadBalancerTarget) DeepCopy() *LoadBalancerTarget {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProvisioningChecks.
+func (in *ProvisioningChecks) DeepCopy() *ProvisioningChecks {
 	if in == nil {
 		return nil
 	}
-	out := new(LoadBalancerTarget)
+	out := new(ProvisioningChecks)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NIC) DeepCopyInto(out *NIC) {
+func (in *ProvisioningFirewall) DeepCopyInto(out *ProvisioningFirewall) {
 	*out = *in
+	if in.SourceIPs != nil {
+		in, out := &in.SourceIPs, &out.SourceIPs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NIC.
-func (in *NIC) DeepCopy() *NIC {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProvisioningFirewall.
+func (in *ProvisioningFirewall) DeepCopy() *ProvisioningFirewall {
 	if in == nil {
 		return nil
 	}
-	out := new(NIC)
+	out := new(ProvisioningFirewall)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NetworkStatus) DeepCopyInto(out *NetworkStatus) {
+func (in *ProvisioningMilestones) DeepCopyInto(out *ProvisioningMilestones) {
 	*out = *in
-	if in.Labels != nil {
-		in, out := &in.Labels, &out.Labels
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	if in.InfrastructureCreated != nil {
+		in, out := &in.InfrastructureCreated, &out.InfrastructureCreated
+		*out = (*in).DeepCopy()
 	}
-	if in.AttachedServers != nil {
-		in, out := &in.AttachedServers, &out.AttachedServers
-		*out = make([]int, len(*in))
-		copy(*out, *in)
+	if in.BootstrapDataDelivered != nil {
+		in, out := &in.BootstrapDataDelivered, &out.BootstrapDataDelivered
+		*out = (*in).DeepCopy()
+	}
+	if in.NodeReady != nil {
+		in, out := &in.NodeReady, &out.NodeReady
+		*out = (*in).DeepCopy()
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkStatus.
-func (in *NetworkStatus) DeepCopy() *NetworkStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProvisioningMilestones.
+func (in *ProvisioningMilestones) DeepCopy() *ProvisioningMilestones {
 	if in == nil {
 		return nil
 	}
-	out := new(NetworkStatus)
+	out := new(ProvisioningMilestones)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Partition) DeepCopyInto(out *Partition) {
+func (in *ProvisioningThrottle) DeepCopyInto(out *ProvisioningThrottle) {
 	*out = *in
+	if in.Datacenters != nil {
+		in, out := &in.Datacenters, &out.Datacenters
+		*out = make([]DatacenterProvisioningThrottle, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Partition.
-func (in *Partition) DeepCopy() *Partition {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProvisioningThrottle.
+func (in *ProvisioningThrottle) DeepCopy() *ProvisioningThrottle {
 	if in == nil {
 		return nil
 	}
-	out := new(Partition)
+	out := new(ProvisioningThrottle)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -1495,6 +3112,21 @@ func (in *Partition) DeepCopy() *Partition {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PublicNetworkSpec) DeepCopyInto(out *PublicNetworkSpec) {
 	*out = *in
+	if in.PrimaryIPSelector != nil {
+		in, out := &in.PrimaryIPSelector, &out.PrimaryIPSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PrimaryIPv4ID != nil {
+		in, out := &in.PrimaryIPv4ID, &out.PrimaryIPv4ID
+		*out = new(int)
+		**out = **in
+	}
+	if in.PrimaryIPv6ID != nil {
+		in, out := &in.PrimaryIPv6ID, &out.PrimaryIPv6ID
+		*out = new(int)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PublicNetworkSpec.
@@ -1542,12 +3174,32 @@ func (in *RebootAnnotationArguments) DeepCopy() *RebootAnnotationArguments {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RegistryMirror) DeepCopyInto(out *RegistryMirror) {
+	*out = *in
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegistryMirror.
+func (in *RegistryMirror) DeepCopy() *RegistryMirror {
+	if in == nil {
+		return nil
+	}
+	out := new(RegistryMirror)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RemediationStrategy) DeepCopyInto(out *RemediationStrategy) {
 	*out = *in
 	if in.Timeout != nil {
 		in, out := &in.Timeout, &out.Timeout
-		*out = new(metav1.Duration)
+		*out = new(v1.Duration)
 		**out = **in
 	}
 }
@@ -1562,6 +3214,40 @@ func (in *RemediationStrategy) DeepCopy() *RemediationStrategy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReprovisionChange) DeepCopyInto(out *ReprovisionChange) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReprovisionChange.
+func (in *ReprovisionChange) DeepCopy() *ReprovisionChange {
+	if in == nil {
+		return nil
+	}
+	out := new(ReprovisionChange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RobotServerStatus) DeepCopyInto(out *RobotServerStatus) {
+	*out = *in
+	if in.LastUpdated != nil {
+		in, out := &in.LastUpdated, &out.LastUpdated
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RobotServerStatus.
+func (in *RobotServerStatus) DeepCopy() *RobotServerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RobotServerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RootDeviceHints) DeepCopyInto(out *RootDeviceHints) {
 	*out = *in
@@ -1593,6 +3279,41 @@ func (in *SSHKey) DeepCopy() *SSHKey {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SSHKeyReference) DeepCopyInto(out *SSHKeyReference) {
+	*out = *in
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(SSHPublicKeySecretRef)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SSHKeyReference.
+func (in *SSHKeyReference) DeepCopy() *SSHKeyReference {
+	if in == nil {
+		return nil
+	}
+	out := new(SSHKeyReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SSHPublicKeySecretRef) DeepCopyInto(out *SSHPublicKeySecretRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SSHPublicKeySecretRef.
+func (in *SSHPublicKeySecretRef) DeepCopy() *SSHPublicKeySecretRef {
+	if in == nil {
+		return nil
+	}
+	out := new(SSHPublicKeySecretRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SSHSecretKeyRef) DeepCopyInto(out *SSHSecretKeyRef) {
 	*out = *in
@@ -1628,6 +3349,11 @@ func (in *SSHSecretRef) DeepCopy() *SSHSecretRef {
 func (in *SSHSpec) DeepCopyInto(out *SSHSpec) {
 	*out = *in
 	out.SecretRef = in.SecretRef
+	if in.PrivateProvisioning != nil {
+		in, out := &in.PrivateProvisioning, &out.PrivateProvisioning
+		*out = new(PrivateProvisioning)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SSHSpec.
@@ -1680,7 +3406,7 @@ func (in *SecretStatus) DeepCopyInto(out *SecretStatus) {
 	*out = *in
 	if in.Reference != nil {
 		in, out := &in.Reference, &out.Reference
-		*out = new(v1.SecretReference)
+		*out = new(corev1.SecretReference)
 		**out = **in
 	}
 	if in.DataHash != nil {
@@ -1700,6 +3426,31 @@ func (in *SecretStatus) DeepCopy() *SecretStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServerLabels) DeepCopyInto(out *ServerLabels) {
+	*out = *in
+	if in.Keys != nil {
+		in, out := &in.Keys, &out.Keys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Prefixes != nil {
+		in, out := &in.Prefixes, &out.Prefixes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerLabels.
+func (in *ServerLabels) DeepCopy() *ServerLabels {
+	if in == nil {
+		return nil
+	}
+	out := new(ServerLabels)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Storage) DeepCopyInto(out *Storage) {
 	*out = *in
@@ -1714,3 +3465,64 @@ func (in *Storage) DeepCopy() *Storage {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SwapSpec) DeepCopyInto(out *SwapSpec) {
+	*out = *in
+	if in.Swappiness != nil {
+		in, out := &in.Swappiness, &out.Swappiness
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SwapSpec.
+func (in *SwapSpec) DeepCopy() *SwapSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SwapSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrafficStatus) DeepCopyInto(out *TrafficStatus) {
+	*out = *in
+	out.Inbound = in.Inbound.DeepCopy()
+	out.Outbound = in.Outbound.DeepCopy()
+	if in.Included != nil {
+		in, out := &in.Included, &out.Included
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.LastUpdated != nil {
+		in, out := &in.LastUpdated, &out.LastUpdated
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrafficStatus.
+func (in *TrafficStatus) DeepCopy() *TrafficStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TrafficStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrustedCABundleRef) DeepCopyInto(out *TrustedCABundleRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrustedCABundleRef.
+func (in *TrustedCABundleRef) DeepCopy() *TrustedCABundleRef {
+	if in == nil {
+		return nil
+	}
+	out := new(TrustedCABundleRef)
+	in.DeepCopyInto(out)
+	return out
+}
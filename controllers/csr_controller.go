@@ -24,6 +24,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/pkg/errors"
 	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
 	"github.com/syself/cluster-api-provider-hetzner/pkg/csr"
 	certificatesv1 "k8s.io/api/certificates/v1"
@@ -33,6 +34,9 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/cluster-api/util/predicates"
 	"sigs.k8s.io/cluster-api/util/record"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -79,12 +83,14 @@ func (r *GuestCSRReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_
 		return reconcile.Result{}, err
 	}
 
-	// skip CSR that have already been decided
+	// CSR that have already been decided might contain an issued certificate
 	if len(certificateSigningRequest.Status.Conditions) > 0 {
+		if err := r.recordKubeletServingCertificate(ctx, certificateSigningRequest); err != nil {
+			return reconcile.Result{}, errors.Wrap(err, "failed to record kubelet serving certificate")
+		}
 		return reconcile.Result{}, nil
 	}
 
-	nodePrefix := "system:node:"
 	// skip CSR from non-nodes
 	if !strings.HasPrefix(certificateSigningRequest.Spec.Username, nodePrefix) {
 		return reconcile.Result{}, nil
@@ -94,40 +100,27 @@ func (r *GuestCSRReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_
 	var machineName string
 	var machineAddresses []corev1.NodeAddress
 
-	// find matching HCloudMachine object
-	var hcloudMachine infrav1.HCloudMachine
-	hcloudMachineName := types.NamespacedName{
-		Namespace: r.mCluster.Namespace(),
-		Name:      strings.TrimPrefix(certificateSigningRequest.Spec.Username, nodePrefix),
+	machine, err := r.getMachineOfNode(ctx, certificateSigningRequest.Spec.Username)
+	if err != nil {
+		log.Error(err, "found an error while getting machine - bm machine or hcloud machine", "namespacedName", req.NamespacedName,
+			"userName", certificateSigningRequest.Spec.Username,
+			"nodePrefix", nodePrefix,
+		)
+		return reconcile.Result{RequeueAfter: 20 * time.Second}, nil
 	}
-	err = r.mCluster.Get(ctx, hcloudMachineName, &hcloudMachine)
 
-	if err == nil {
+	switch m := machine.(type) {
+	case *infrav1.HCloudMachine:
 		isHCloudMachine = true
-		machineName = hcloudMachine.GetName()
-		machineAddresses = hcloudMachine.Status.Addresses
-
-		log = log.WithValues("HCloudMachine", klog.KRef(hcloudMachineName.Namespace, hcloudMachine.Name))
-	} else {
-		// Check whether it is a bare metal machine
-		var bmMachine infrav1.HetznerBareMetalMachine
-		bmMachineName := types.NamespacedName{
-			Namespace: r.mCluster.Namespace(),
-			Name:      strings.TrimPrefix(certificateSigningRequest.Spec.Username, nodePrefix+infrav1.BareMetalHostNamePrefix),
-		}
-
-		if err := r.mCluster.Get(ctx, bmMachineName, &bmMachine); err != nil {
-			log.Error(err, "found an error while getting machine - bm machine or hcloud machine", "namespacedName", req.NamespacedName,
-				"userName", certificateSigningRequest.Spec.Username,
-				"nodePrefix", nodePrefix,
-			)
-			return reconcile.Result{RequeueAfter: 20 * time.Second}, nil
-		}
+		machineName = m.GetName()
+		machineAddresses = m.Status.Addresses
 
-		machineName = bmMachine.GetName()
-		machineAddresses = bmMachine.Status.Addresses
+		log = log.WithValues("HCloudMachine", klog.KObj(m))
+	case *infrav1.HetznerBareMetalMachine:
+		machineName = m.GetName()
+		machineAddresses = m.Status.Addresses
 
-		log = log.WithValues("HetznerBareMetalMachine", klog.KRef(bmMachineName.Namespace, bmMachine.Name))
+		log = log.WithValues("HetznerBareMetalMachine", klog.KObj(m))
 	}
 	ctx = ctrl.LoggerInto(ctx, log)
 
@@ -151,13 +144,28 @@ func (r *GuestCSRReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_
 
 	if err := csr.ValidateKubeletCSR(csrRequest, machineName, isHCloudMachine, machineAddresses); err != nil {
 		condition.Type = certificatesv1.CertificateDenied
-		condition.Reason = "CSRValidationFailed"
+		condition.Reason = infrav1.CSRValidationFailedReason
 		condition.Status = "True"
 		condition.Message = fmt.Sprintf("Validation by cluster-api-provider-hetzner failed: %s", err)
 		log.Error(err, "failed to validate kubelet csr")
+
+		if certificateSigningRequest.Spec.SignerName == certificatesv1.KubeletServingSignerName {
+			if patchErr := r.patchMachine(ctx, machine, func(m machineWithCertificate) {
+				conditions.MarkFalse(m,
+					infrav1.KubeletServingCertificateValidCondition,
+					infrav1.KubeletServingCertificateDeniedReason,
+					clusterv1.ConditionSeverityWarning,
+					"CSR %s has been denied: %s",
+					certificateSigningRequest.Name,
+					err,
+				)
+			}); patchErr != nil {
+				log.Error(patchErr, "failed to patch machine")
+			}
+		}
 	} else {
 		condition.Type = certificatesv1.CertificateApproved
-		condition.Reason = "CSRValidationSucceed"
+		condition.Reason = infrav1.CSRValidationSucceedReason
 		condition.Status = "True"
 		condition.Message = "Validation by cluster-api-provider-hetzner was successful"
 	}
@@ -175,6 +183,149 @@ func (r *GuestCSRReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_
 	return reconcile.Result{}, nil
 }
 
+// nodePrefix is the prefix of the user names of nodes.
+const nodePrefix = "system:node:"
+
+// kubeletServingCertificateRotationThreshold is the share of the lifetime of a kubelet serving certificate after
+// which it should have been rotated. The kubelet requests a new certificate after 70-90% of the lifetime.
+const kubeletServingCertificateRotationThreshold = 0.9
+
+// machineWithCertificate is an HCloudMachine or HetznerBareMetalMachine that shows the kubelet serving certificate.
+type machineWithCertificate interface {
+	client.Object
+	conditions.Setter
+}
+
+// getMachineOfNode returns the HCloudMachine or HetznerBareMetalMachine of the node with the given user name.
+func (r *GuestCSRReconciler) getMachineOfNode(ctx context.Context, userName string) (machineWithCertificate, error) {
+	hcloudMachine := &infrav1.HCloudMachine{}
+	hcloudMachineName := types.NamespacedName{
+		Namespace: r.mCluster.Namespace(),
+		Name:      strings.TrimPrefix(userName, nodePrefix),
+	}
+	err := r.mCluster.Get(ctx, hcloudMachineName, hcloudMachine)
+	if err == nil {
+		return hcloudMachine, nil
+	}
+
+	// Check whether it is a bare metal machine
+	bmMachine := &infrav1.HetznerBareMetalMachine{}
+	bmMachineName := types.NamespacedName{
+		Namespace: r.mCluster.Namespace(),
+		Name:      strings.TrimPrefix(userName, nodePrefix+infrav1.BareMetalHostNamePrefix),
+	}
+	if err := r.mCluster.Get(ctx, bmMachineName, bmMachine); err != nil {
+		return nil, err
+	}
+	return bmMachine, nil
+}
+
+// patchMachine patches the status of the machine after applying the mutation.
+func (r *GuestCSRReconciler) patchMachine(ctx context.Context, machine machineWithCertificate, mutate func(machineWithCertificate)) error {
+	helper, err := patch.NewHelper(machine, r.mCluster)
+	if err != nil {
+		return errors.Wrap(err, "failed to init patch helper")
+	}
+	mutate(machine)
+	return helper.Patch(ctx, machine)
+}
+
+// recordKubeletServingCertificate records the validity of a kubelet serving certificate that has been issued for the
+// CSR in the status of the machine of the node, so that missing rotations become visible before the certificate expires.
+func (r *GuestCSRReconciler) recordKubeletServingCertificate(ctx context.Context, certificateSigningRequest *certificatesv1.CertificateSigningRequest) error {
+	if certificateSigningRequest.Spec.SignerName != certificatesv1.KubeletServingSignerName ||
+		!strings.HasPrefix(certificateSigningRequest.Spec.Username, nodePrefix) ||
+		len(certificateSigningRequest.Status.Certificate) == 0 {
+		return nil
+	}
+
+	certBlock, _ := pem.Decode(certificateSigningRequest.Status.Certificate)
+	if certBlock == nil {
+		return errors.Errorf("failed to decode certificate of CSR %s", certificateSigningRequest.Name)
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse certificate of CSR %s", certificateSigningRequest.Name)
+	}
+
+	machine, err := r.getMachineOfNode(ctx, certificateSigningRequest.Spec.Username)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrap(err, "failed to get machine of node")
+	}
+
+	status := &infrav1.CertificateStatus{
+		NotBefore: metav1.NewTime(cert.NotBefore),
+		NotAfter:  metav1.NewTime(cert.NotAfter),
+	}
+
+	// CSR are kept for a while after the certificate has been issued. Older certificates are ignored.
+	if current := kubeletServingCertificate(machine); current != nil && !status.NotBefore.After(current.NotBefore.Time) {
+		return nil
+	}
+
+	return r.patchMachine(ctx, machine, func(m machineWithCertificate) {
+		switch m := m.(type) {
+		case *infrav1.HCloudMachine:
+			m.Status.KubeletServingCertificate = status
+		case *infrav1.HetznerBareMetalMachine:
+			m.Status.KubeletServingCertificate = status
+		}
+		// a new certificate resolves a denied CSR
+		conditions.MarkTrue(m, infrav1.KubeletServingCertificateValidCondition)
+		reconcileKubeletServingCertificate(m, status)
+	})
+}
+
+// kubeletServingCertificate returns the kubelet serving certificate in the status of the machine.
+func kubeletServingCertificate(machine machineWithCertificate) *infrav1.CertificateStatus {
+	switch m := machine.(type) {
+	case *infrav1.HCloudMachine:
+		return m.Status.KubeletServingCertificate
+	case *infrav1.HetznerBareMetalMachine:
+		return m.Status.KubeletServingCertificate
+	}
+	return nil
+}
+
+// reconcileKubeletServingCertificate sets the condition KubeletServingCertificateValid according to the validity of
+// the last kubelet serving certificate. A denied CSR is reported until a new certificate has been issued.
+func reconcileKubeletServingCertificate(setter conditions.Setter, cert *infrav1.CertificateStatus) {
+	if cert == nil {
+		return
+	}
+
+	now := time.Now()
+	notAfter := cert.NotAfter.Time
+	lifetime := notAfter.Sub(cert.NotBefore.Time)
+	rotationDue := cert.NotBefore.Add(time.Duration(float64(lifetime) * kubeletServingCertificateRotationThreshold))
+
+	switch {
+	case !now.Before(notAfter):
+		conditions.MarkFalse(setter,
+			infrav1.KubeletServingCertificateValidCondition,
+			infrav1.KubeletServingCertificateExpiredReason,
+			clusterv1.ConditionSeverityError,
+			"kubelet serving certificate expired at %s",
+			notAfter.UTC().Format(time.RFC3339),
+		)
+	case conditions.GetReason(setter, infrav1.KubeletServingCertificateValidCondition) == infrav1.KubeletServingCertificateDeniedReason:
+		// keep the denied CSR as reason until a new certificate has been issued
+	case now.After(rotationDue):
+		conditions.MarkFalse(setter,
+			infrav1.KubeletServingCertificateValidCondition,
+			infrav1.KubeletServingCertificateRotationOverdueReason,
+			clusterv1.ConditionSeverityWarning,
+			"kubelet serving certificate expires at %s and has not been rotated",
+			notAfter.UTC().Format(time.RFC3339),
+		)
+	default:
+		conditions.MarkTrue(setter, infrav1.KubeletServingCertificateValidCondition)
+	}
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *GuestCSRReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
 	return ctrl.NewControllerManagedBy(mgr).
@@ -191,8 +342,11 @@ func (r *GuestCSRReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Mana
 				return false
 			},
 			UpdateFunc: func(e event.UpdateEvent) bool {
-				// We don't want to listen to Update events, as CSRs are Updated frequently without us having to do something
-				return false
+				// We only want to know when a certificate has been issued, as CSRs are updated frequently without
+				// us having to do something
+				oldCSR, okOld := e.ObjectOld.(*certificatesv1.CertificateSigningRequest)
+				newCSR, okNew := e.ObjectNew.(*certificatesv1.CertificateSigningRequest)
+				return okOld && okNew && len(oldCSR.Status.Certificate) == 0 && len(newCSR.Status.Certificate) > 0
 			},
 		}).
 		Complete(r)
@@ -0,0 +1,143 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("reconcileKubeletServingCertificate", func() {
+	now := time.Now()
+	newCertificate := func(age, lifetime time.Duration) *infrav1.CertificateStatus {
+		return &infrav1.CertificateStatus{
+			NotBefore: metav1.NewTime(now.Add(-age)),
+			NotAfter:  metav1.NewTime(now.Add(-age).Add(lifetime)),
+		}
+	}
+
+	DescribeTable("reconcileKubeletServingCertificate",
+		func(cert *infrav1.CertificateStatus, denied bool, expectedReason string) {
+			hcloudMachine := &infrav1.HCloudMachine{}
+			if denied {
+				conditions.MarkFalse(hcloudMachine, infrav1.KubeletServingCertificateValidCondition,
+					infrav1.KubeletServingCertificateDeniedReason, clusterv1.ConditionSeverityWarning, "denied")
+			}
+			reconcileKubeletServingCertificate(hcloudMachine, cert)
+			if expectedReason == "" {
+				Expect(conditions.IsTrue(hcloudMachine, infrav1.KubeletServingCertificateValidCondition)).To(BeTrue())
+				return
+			}
+			Expect(conditions.GetReason(hcloudMachine, infrav1.KubeletServingCertificateValidCondition)).To(Equal(expectedReason))
+		},
+		Entry("valid", newCertificate(time.Hour, 100*time.Hour), false, ""),
+		Entry("rotation overdue", newCertificate(95*time.Hour, 100*time.Hour), false, infrav1.KubeletServingCertificateRotationOverdueReason),
+		Entry("expired", newCertificate(101*time.Hour, 100*time.Hour), false, infrav1.KubeletServingCertificateExpiredReason),
+		Entry("denied", newCertificate(time.Hour, 100*time.Hour), true, infrav1.KubeletServingCertificateDeniedReason),
+		Entry("denied and expired", newCertificate(101*time.Hour, 100*time.Hour), true, infrav1.KubeletServingCertificateExpiredReason),
+	)
+})
+
+var _ = Describe("GuestCSRReconciler recordKubeletServingCertificate", func() {
+	var (
+		r             *GuestCSRReconciler
+		hcloudMachine *infrav1.HCloudMachine
+	)
+
+	newCSR := func(notBefore time.Time) *certificatesv1.CertificateSigningRequest {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).To(Succeed())
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "system:node:hcloud-machine"},
+			NotBefore:    notBefore,
+			NotAfter:     notBefore.Add(365 * 24 * time.Hour),
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		Expect(err).To(Succeed())
+
+		return &certificatesv1.CertificateSigningRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "csr"},
+			Spec: certificatesv1.CertificateSigningRequestSpec{
+				SignerName: certificatesv1.KubeletServingSignerName,
+				Username:   "system:node:hcloud-machine",
+			},
+			Status: certificatesv1.CertificateSigningRequestStatus{
+				Conditions:  []certificatesv1.CertificateSigningRequestCondition{{Type: certificatesv1.CertificateApproved}},
+				Certificate: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(infrav1.AddToScheme(scheme)).To(Succeed())
+
+		hcloudMachine = &infrav1.HCloudMachine{ObjectMeta: metav1.ObjectMeta{Name: "hcloud-machine", Namespace: "default"}}
+		conditions.MarkFalse(hcloudMachine, infrav1.KubeletServingCertificateValidCondition,
+			infrav1.KubeletServingCertificateDeniedReason, clusterv1.ConditionSeverityWarning, "denied")
+
+		r = &GuestCSRReconciler{
+			mCluster: &fakeManagementCluster{
+				Client:    fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(hcloudMachine).Build(),
+				namespace: "default",
+			},
+		}
+	})
+
+	getMachine := func() *infrav1.HCloudMachine {
+		var updated infrav1.HCloudMachine
+		Expect(r.mCluster.Get(context.Background(), client.ObjectKeyFromObject(hcloudMachine), &updated)).To(Succeed())
+		return &updated
+	}
+
+	It("records the issued certificate and resolves a denied CSR", func() {
+		notBefore := time.Now().Add(-time.Hour).Truncate(time.Second)
+		Expect(r.recordKubeletServingCertificate(context.Background(), newCSR(notBefore))).To(Succeed())
+
+		updated := getMachine()
+		Expect(updated.Status.KubeletServingCertificate).ToNot(BeNil())
+		Expect(updated.Status.KubeletServingCertificate.NotBefore.Time).To(BeTemporally("==", notBefore))
+		Expect(conditions.IsTrue(updated, infrav1.KubeletServingCertificateValidCondition)).To(BeTrue())
+	})
+
+	It("ignores certificates that are older than the recorded one", func() {
+		notBefore := time.Now().Add(-time.Hour).Truncate(time.Second)
+		Expect(r.recordKubeletServingCertificate(context.Background(), newCSR(notBefore))).To(Succeed())
+		Expect(r.recordKubeletServingCertificate(context.Background(), newCSR(notBefore.Add(-time.Hour)))).To(Succeed())
+
+		Expect(getMachine().Status.KubeletServingCertificate.NotBefore.Time).To(BeTemporally("==", notBefore))
+	})
+})
@@ -18,20 +18,28 @@ package controllers
 
 import (
 	"context"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/dryrun"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/propagation"
 	"github.com/syself/cluster-api-provider-hetzner/pkg/scope"
 	secretutil "github.com/syself/cluster-api-provider-hetzner/pkg/secrets"
 	hcloudclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client"
 	"github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/server"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/sharding"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog/v2"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/predicates"
+	"sigs.k8s.io/cluster-api/util/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -48,14 +56,19 @@ type HCloudMachineReconciler struct {
 	APIReader           client.Reader
 	HCloudClientFactory hcloudclient.Factory
 	WatchFilterValue    string
+	// Shard limits the reconciliation to the namespaces of the shard.
+	Shard sharding.Shard
+	// DryRun makes the controller record the mutations in HCloud instead of executing them.
+	DryRun bool
 }
 
 //+kubebuilder:rbac:groups="",resources=events,verbs=get;list;watch;create;update;patch
 //+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;update
-//+kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines;machines/status,verbs=get;list;watch
+//+kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines;machines/status,verbs=get;list;watch;update;patch
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=hcloudmachines,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=hcloudmachines/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=hcloudmachines/finalizers,verbs=update
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=hetznernodeprofiles,verbs=get;list;watch
 
 // Reconcile manages the lifecycle of an HCloud machine object.
 func (r *HCloudMachineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
@@ -115,12 +128,15 @@ func (r *HCloudMachineReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 
 	// Create the scope.
 	secretManager := secretutil.NewSecretManager(log, r.Client, r.APIReader)
-	hcloudToken, hetznerSecret, err := getAndValidateHCloudToken(ctx, req.Namespace, hetznerCluster, secretManager)
-	if err != nil {
-		return hcloudTokenErrorResult(ctx, err, hcloudMachine, infrav1.InstanceReadyCondition, r.Client)
+	hcloudToken, hetznerSecret, tokenErr := getAndValidateHCloudToken(ctx, req.Namespace, hetznerCluster, secretManager)
+	if tokenErr != nil && !forceCleanupRequested(hcloudMachine) {
+		return hcloudTokenErrorResult(ctx, tokenErr, hcloudMachine, infrav1.InstanceReadyCondition, r.Client)
 	}
 
 	hcc := r.HCloudClientFactory.NewClient(hcloudToken)
+	if dryrun.Enabled(r.DryRun, hetznerCluster) {
+		hcc = hcloudclient.NewDryRunClient(hcc, hcloudMachine)
+	}
 
 	machineScope, err := scope.NewMachineScope(ctx, scope.MachineScopeParams{
 		ClusterScopeParams: scope.ClusterScopeParams{
@@ -152,10 +168,51 @@ func (r *HCloudMachineReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	}
 
 	if !hcloudMachine.ObjectMeta.DeletionTimestamp.IsZero() {
-		return r.reconcileDelete(ctx, machineScope)
+		if tokenErr != nil {
+			return dryrun.Result(r.reconcileForceCleanup(ctx, machineScope, tokenErr))
+		}
+		return dryrun.Result(r.reconcileDelete(ctx, machineScope))
 	}
 
-	return r.reconcileNormal(ctx, machineScope)
+	return dryrun.Result(r.reconcileNormal(ctx, machineScope))
+}
+
+// preDeleteHookRequeueInterval is the interval in which the pre-delete hooks of deleted machines are checked.
+const preDeleteHookRequeueInterval = time.Minute
+
+// reconcilePreDeleteHooks returns whether the deletion of the server of a deleted machine has to wait for
+// pre-delete hooks. These are annotations with the prefix infrav1.PreDeleteHookAnnotationPrefix on the
+// Machine or on the infrastructure machine.
+func reconcilePreDeleteHooks(infraMachine conditions.Setter, machine *clusterv1.Machine) bool {
+	objects := []metav1.Object{infraMachine}
+	if machine != nil {
+		objects = append(objects, machine)
+	}
+
+	var hooks []string
+	for _, obj := range objects {
+		for annotation := range obj.GetAnnotations() {
+			if strings.HasPrefix(annotation, infrav1.PreDeleteHookAnnotationPrefix) {
+				hooks = append(hooks, annotation)
+			}
+		}
+	}
+
+	if len(hooks) == 0 {
+		if conditions.Has(infraMachine, infrav1.PreDeleteHookSucceededCondition) {
+			conditions.MarkTrue(infraMachine, infrav1.PreDeleteHookSucceededCondition)
+		}
+		return false
+	}
+
+	sort.Strings(hooks)
+	if !conditions.IsFalse(infraMachine, infrav1.PreDeleteHookSucceededCondition) {
+		record.Eventf(infraMachine, "WaitingForPreDeleteHooks", "Server is not deleted before the pre-delete hooks are removed: %s",
+			strings.Join(hooks, ", "))
+	}
+	conditions.MarkFalse(infraMachine, infrav1.PreDeleteHookSucceededCondition, clusterv1.WaitingExternalHookReason,
+		clusterv1.ConditionSeverityInfo, "waiting for pre-delete hooks %s", strings.Join(hooks, ", "))
+	return true
 }
 
 func breakReconcile(ctrl *reconcile.Result, err error) (reconcile.Result, bool, error) {
@@ -170,6 +227,10 @@ func (r *HCloudMachineReconciler) reconcileDelete(ctx context.Context, machineSc
 	machineScope.Info("Reconciling HCloudMachine delete")
 	hcloudMachine := machineScope.HCloudMachine
 
+	if reconcilePreDeleteHooks(hcloudMachine, machineScope.Machine) {
+		return reconcile.Result{RequeueAfter: preDeleteHookRequeueInterval}, nil
+	}
+
 	// delete servers
 	if result, brk, err := breakReconcile(server.NewService(machineScope).Delete(ctx)); brk {
 		return result, errors.Wrapf(err, "failed to delete servers for HCloudMachine %s/%s", hcloudMachine.Namespace, hcloudMachine.Name)
@@ -181,6 +242,29 @@ func (r *HCloudMachineReconciler) reconcileDelete(ctx context.Context, machineSc
 	return reconcile.Result{}, nil
 }
 
+// reconcileForceCleanup removes the finalizer of a deleted HCloudMachine with the force-cleanup annotation whose
+// server cannot be deleted, because the Hetzner secret is missing or invalid.
+func (r *HCloudMachineReconciler) reconcileForceCleanup(ctx context.Context, machineScope *scope.MachineScope, cause error) (reconcile.Result, error) {
+	machineScope.Info("Reconciling HCloudMachine force cleanup", "cause", cause.Error())
+	hcloudMachine := machineScope.HCloudMachine
+
+	if reconcilePreDeleteHooks(hcloudMachine, machineScope.Machine) {
+		return reconcile.Result{RequeueAfter: preDeleteHookRequeueInterval}, nil
+	}
+
+	if dryrun.Enabled(r.DryRun, machineScope.HetznerCluster) {
+		return reconcile.Result{}, dryrun.Skip(hcloudMachine, "force cleanup of HCloudMachine %s", hcloudMachine.Name)
+	}
+
+	if err := server.NewService(machineScope).ForceCleanup(ctx, cause); err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to force cleanup of HCloudMachine %s/%s", hcloudMachine.Namespace, hcloudMachine.Name)
+	}
+
+	controllerutil.RemoveFinalizer(machineScope.HCloudMachine, infrav1.MachineFinalizer)
+
+	return reconcile.Result{}, nil
+}
+
 func (r *HCloudMachineReconciler) reconcileNormal(ctx context.Context, machineScope *scope.MachineScope) (reconcile.Result, error) {
 	machineScope.Info("Reconciling HCloudMachine")
 	hcloudMachine := machineScope.HCloudMachine
@@ -193,6 +277,12 @@ func (r *HCloudMachineReconciler) reconcileNormal(ctx context.Context, machineSc
 		return ctrl.Result{}, err
 	}
 
+	// propagate the labels and annotations of the Machine, the server labels are updated accordingly
+	propagation.MachineMetadata(machineScope.Machine, hcloudMachine)
+
+	// the certificate is recorded by the CSR controller, its expiry is checked on every reconcile
+	reconcileKubeletServingCertificate(hcloudMachine, hcloudMachine.Status.KubeletServingCertificate)
+
 	// reconcile server
 	if result, brk, err := breakReconcile(server.NewService(machineScope).Reconcile(ctx)); brk {
 		return result, errors.Wrapf(err, "failed to reconcile server for HCloudMachine %s/%s", hcloudMachine.Namespace, hcloudMachine.Name)
@@ -208,6 +298,7 @@ func (r *HCloudMachineReconciler) SetupWithManager(ctx context.Context, mgr ctrl
 		WithOptions(options).
 		For(&infrav1.HCloudMachine{}).
 		WithEventFilter(predicates.ResourceNotPausedAndHasFilterLabel(ctrl.LoggerFrom(ctx), r.WatchFilterValue)).
+		WithEventFilter(r.Shard.Predicate()).
 		Watches(
 			&source.Kind{Type: &clusterv1.Machine{}},
 			handler.EnqueueRequestsFromMapFunc(util.MachineToInfrastructureMapFunc(infrav1.GroupVersion.WithKind("HCloudMachine"))),
@@ -231,6 +322,7 @@ func (r *HCloudMachineReconciler) SetupWithManager(ctx context.Context, mgr ctrl
 		&source.Kind{Type: &clusterv1.Cluster{}},
 		handler.EnqueueRequestsFromMapFunc(clusterToObjectFunc),
 		predicates.ClusterUnpausedAndInfrastructureReady(log),
+		r.Shard.Predicate(),
 	); err != nil {
 		return errors.Wrap(err, "failed adding a watch for ready clusters")
 	}
@@ -585,3 +585,39 @@ var _ = Describe("HCloudMachine validation", func() {
 		Expect(testEnv.Create(ctx, infraMachine)).ToNot(Succeed())
 	})
 })
+
+var _ = Describe("reconcilePreDeleteHooks", func() {
+	var (
+		machine       *clusterv1.Machine
+		hcloudMachine *infrav1.HCloudMachine
+	)
+
+	BeforeEach(func() {
+		machine = &clusterv1.Machine{}
+		hcloudMachine = &infrav1.HCloudMachine{}
+	})
+
+	It("does not wait without pre-delete hooks", func() {
+		Expect(reconcilePreDeleteHooks(hcloudMachine, machine)).To(BeFalse())
+		Expect(conditions.Has(hcloudMachine, infrav1.PreDeleteHookSucceededCondition)).To(BeFalse())
+	})
+
+	It("waits for pre-delete hooks on the machine and the HCloudMachine", func() {
+		machine.Annotations = map[string]string{infrav1.PreDeleteHookAnnotationPrefix + "/change-approval": ""}
+		hcloudMachine.Annotations = map[string]string{infrav1.PreDeleteHookAnnotationPrefix + "/backup": ""}
+
+		Expect(reconcilePreDeleteHooks(hcloudMachine, machine)).To(BeTrue())
+		Expect(conditions.IsFalse(hcloudMachine, infrav1.PreDeleteHookSucceededCondition)).To(BeTrue())
+		Expect(conditions.GetMessage(hcloudMachine, infrav1.PreDeleteHookSucceededCondition)).To(Equal(fmt.Sprintf(
+			"waiting for pre-delete hooks %[1]s/backup, %[1]s/change-approval", infrav1.PreDeleteHookAnnotationPrefix)))
+	})
+
+	It("continues once the pre-delete hooks have been removed", func() {
+		machine.Annotations = map[string]string{infrav1.PreDeleteHookAnnotationPrefix + "/change-approval": ""}
+		Expect(reconcilePreDeleteHooks(hcloudMachine, machine)).To(BeTrue())
+
+		machine.Annotations = nil
+		Expect(reconcilePreDeleteHooks(hcloudMachine, nil)).To(BeFalse())
+		Expect(conditions.IsTrue(hcloudMachine, infrav1.PreDeleteHookSucceededCondition)).To(BeTrue())
+	})
+})
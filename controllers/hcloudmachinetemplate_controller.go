@@ -26,6 +26,7 @@ import (
 	secretutil "github.com/syself/cluster-api-provider-hetzner/pkg/secrets"
 	hcloudclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client"
 	"github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/machinetemplate"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/sharding"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/cluster-api/util"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -41,6 +42,8 @@ type HCloudMachineTemplateReconciler struct {
 	APIReader           client.Reader
 	HCloudClientFactory hcloudclient.Factory
 	WatchFilterValue    string
+	// Shard limits the reconciliation to the namespaces of the shard.
+	Shard sharding.Shard
 }
 
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=hcloudmachinetemplates,verbs=get;list;watch;create;update;patch;delete
@@ -99,6 +102,7 @@ func (r *HCloudMachineTemplateReconciler) Reconcile(ctx context.Context, req ctr
 		Client:                r.Client,
 		Logger:                &log,
 		HCloudMachineTemplate: machineTemplate,
+		HetznerCluster:        hetznerCluster,
 		HCloudClient:          hcc,
 	})
 	if err != nil {
@@ -144,5 +148,6 @@ func (r *HCloudMachineTemplateReconciler) SetupWithManager(ctx context.Context,
 	return ctrl.NewControllerManagedBy(mgr).
 		WithOptions(options).
 		For(&infrav1.HCloudMachineTemplate{}).
+		WithEventFilter(r.Shard.Predicate()).
 		Complete(r)
 }
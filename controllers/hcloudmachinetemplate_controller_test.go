@@ -155,3 +155,48 @@ var _ = Describe("HCloudMachineTemplateReconciler", func() {
 		})
 	})
 })
+
+var _ = Describe("HCloudMachineTemplate validation", func() {
+	var (
+		machineTemplate *infrav1.HCloudMachineTemplate
+		testNs          *corev1.Namespace
+	)
+
+	BeforeEach(func() {
+		var err error
+		testNs, err = testEnv.CreateNamespace(ctx, "hcloudmachinetemplate-validation")
+		Expect(err).NotTo(HaveOccurred())
+
+		machineTemplate = &infrav1.HCloudMachineTemplate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "hcloud-validation-machine-template",
+				Namespace: testNs.Name,
+			},
+			Spec: infrav1.HCloudMachineTemplateSpec{
+				Template: infrav1.HCloudMachineTemplateResource{
+					Spec: infrav1.HCloudMachineSpec{
+						ImageName: "fedora-control-plane",
+						Type:      "cpx31",
+						PlacementGroupSelector: &metav1.LabelSelector{
+							MatchLabels: map[string]string{"approved": "true"},
+						},
+					},
+				},
+			},
+		}
+	})
+
+	AfterEach(func() {
+		Expect(testEnv.Cleanup(ctx, testNs, machineTemplate)).To(Succeed())
+	})
+
+	It("should fail with a placementGroupSelector and a placementGroupName", func() {
+		machineTemplate.Spec.Template.Spec.PlacementGroupName = &defaultPlacementGroupName
+		Expect(testEnv.Create(ctx, machineTemplate)).ToNot(Succeed())
+	})
+
+	It("should fail with an empty placementGroupSelector", func() {
+		machineTemplate.Spec.Template.Spec.PlacementGroupSelector = &metav1.LabelSelector{}
+		Expect(testEnv.Create(ctx, machineTemplate)).ToNot(Succeed())
+	})
+})
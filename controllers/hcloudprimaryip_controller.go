@@ -0,0 +1,171 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/dryrun"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/scope"
+	secretutil "github.com/syself/cluster-api-provider-hetzner/pkg/secrets"
+	hcloudclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/primaryip"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/sharding"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/predicates"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// primaryIPRefreshInterval is the interval in which HCloudPrimaryIPs are reconciled to pick up changes in HCloud.
+const primaryIPRefreshInterval = 5 * time.Minute
+
+// HCloudPrimaryIPReconciler reconciles a HCloudPrimaryIP object.
+type HCloudPrimaryIPReconciler struct {
+	client.Client
+	APIReader           client.Reader
+	HCloudClientFactory hcloudclient.Factory
+	WatchFilterValue    string
+	// Shard limits the reconciliation to the namespaces of the shard.
+	Shard sharding.Shard
+	// DryRun makes the controller record the mutations in HCloud instead of executing them.
+	DryRun bool
+}
+
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=hcloudprimaryips,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=hcloudprimaryips/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=hcloudprimaryips/finalizers,verbs=update
+
+// Reconcile manages the lifecycle of an HCloudPrimaryIP object.
+func (r *HCloudPrimaryIPReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	primaryIP := &infrav1.HCloudPrimaryIP{}
+	if err := r.Get(ctx, req.NamespacedName, primaryIP); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	log = log.WithValues("HCloudPrimaryIP", klog.KObj(primaryIP))
+
+	hetznerCluster := &infrav1.HetznerCluster{}
+
+	hetznerClusterName := client.ObjectKey{
+		Namespace: primaryIP.Namespace,
+		Name:      primaryIP.Spec.HetznerClusterRef,
+	}
+	if err := r.Client.Get(ctx, hetznerClusterName, hetznerCluster); err != nil {
+		log.Info("HetznerCluster is not available yet")
+		return reconcile.Result{RequeueAfter: 30 * time.Second}, nil
+	}
+
+	if annotations.HasPaused(hetznerCluster) || annotations.HasPaused(primaryIP) {
+		log.Info("HCloudPrimaryIP or linked HetznerCluster is marked as paused. Won't reconcile")
+		return ctrl.Result{}, nil
+	}
+
+	log = log.WithValues("HetznerCluster", klog.KObj(hetznerCluster))
+	ctx = ctrl.LoggerInto(ctx, log)
+
+	// Create the scope.
+	secretManager := secretutil.NewSecretManager(log, r.Client, r.APIReader)
+	hcloudToken, _, err := getAndValidateHCloudToken(ctx, req.Namespace, hetznerCluster, secretManager)
+	if err != nil {
+		return hcloudTokenErrorResult(ctx, err, primaryIP, infrav1.PrimaryIPReadyCondition, r.Client)
+	}
+
+	hcc := r.HCloudClientFactory.NewClient(hcloudToken)
+	if dryrun.Enabled(r.DryRun, hetznerCluster) {
+		hcc = hcloudclient.NewDryRunClient(hcc, primaryIP)
+	}
+
+	primaryIPScope, err := scope.NewHCloudPrimaryIPScope(ctx, scope.HCloudPrimaryIPScopeParams{
+		Client:          r.Client,
+		Logger:          &log,
+		HCloudClient:    hcc,
+		HetznerCluster:  hetznerCluster,
+		HCloudPrimaryIP: primaryIP,
+	})
+	if err != nil {
+		return reconcile.Result{}, errors.Errorf("failed to create scope: %+v", err)
+	}
+
+	// Always close the scope when exiting this function so we can persist any HCloudPrimaryIP changes.
+	defer func() {
+		if err := primaryIPScope.Close(ctx); err != nil && reterr == nil {
+			reterr = err
+		}
+	}()
+
+	// check whether rate limit has been reached and if so, then wait.
+	if wait := reconcileRateLimit(primaryIP); wait {
+		return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+	}
+
+	if !primaryIP.DeletionTimestamp.IsZero() {
+		return dryrun.Result(r.reconcileDelete(ctx, primaryIPScope))
+	}
+
+	return dryrun.Result(r.reconcileNormal(ctx, primaryIPScope))
+}
+
+func (r *HCloudPrimaryIPReconciler) reconcileNormal(ctx context.Context, primaryIPScope *scope.HCloudPrimaryIPScope) (reconcile.Result, error) {
+	primaryIP := primaryIPScope.HCloudPrimaryIP
+
+	// If the HCloudPrimaryIP doesn't have our finalizer, add it.
+	controllerutil.AddFinalizer(primaryIP, infrav1.PrimaryIPFinalizer)
+
+	// Register the finalizer immediately to avoid orphaning HCloud resources on delete
+	if err := primaryIPScope.PatchObject(ctx); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if result, brk, err := breakReconcile(primaryip.NewService(primaryIPScope).Reconcile(ctx)); brk {
+		return result, errors.Wrapf(err, "failed to reconcile primary IP for HCloudPrimaryIP %s/%s", primaryIP.Namespace, primaryIP.Name)
+	}
+
+	return reconcile.Result{RequeueAfter: primaryIPRefreshInterval}, nil
+}
+
+func (r *HCloudPrimaryIPReconciler) reconcileDelete(ctx context.Context, primaryIPScope *scope.HCloudPrimaryIPScope) (reconcile.Result, error) {
+	primaryIP := primaryIPScope.HCloudPrimaryIP
+
+	if result, brk, err := breakReconcile(primaryip.NewService(primaryIPScope).Delete(ctx)); brk {
+		return result, errors.Wrapf(err, "failed to delete primary IP for HCloudPrimaryIP %s/%s", primaryIP.Namespace, primaryIP.Name)
+	}
+
+	// Primary IP is released so remove the finalizer.
+	controllerutil.RemoveFinalizer(primaryIP, infrav1.PrimaryIPFinalizer)
+
+	return reconcile.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *HCloudPrimaryIPReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		WithOptions(options).
+		For(&infrav1.HCloudPrimaryIP{}).
+		WithEventFilter(predicates.ResourceNotPausedAndHasFilterLabel(ctrl.LoggerFrom(ctx), r.WatchFilterValue)).
+		WithEventFilter(r.Shard.Predicate()).
+		Complete(r)
+}
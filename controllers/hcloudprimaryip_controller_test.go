@@ -0,0 +1,82 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	fakehcloudclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client/fake"
+	"github.com/syself/cluster-api-provider-hetzner/test/helpers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+var _ = Describe("HCloudPrimaryIPReconciler", func() {
+	var (
+		r         *HCloudPrimaryIPReconciler
+		primaryIP *infrav1.HCloudPrimaryIP
+	)
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		Expect(infrav1.AddToScheme(scheme)).To(Succeed())
+
+		hetznerCluster := &infrav1.HetznerCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "hetzner-cluster", Namespace: "default"},
+			Spec:       helpers.GetDefaultHetznerClusterSpec(),
+		}
+		hetznerSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: hetznerCluster.Spec.HetznerSecret.Name, Namespace: "default"},
+			Data:       map[string][]byte{hetznerCluster.Spec.HetznerSecret.Key.HCloudToken: []byte("token")},
+		}
+		primaryIP = &infrav1.HCloudPrimaryIP{
+			ObjectMeta: metav1.ObjectMeta{Name: "primary-ip-controller", Namespace: "default"},
+			Spec: infrav1.HCloudPrimaryIPSpec{
+				HetznerClusterRef: hetznerCluster.Name,
+				Type:              infrav1.PrimaryIPTypeIPv4,
+				Datacenter:        "fsn1-dc14",
+			},
+		}
+
+		c := fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(hetznerCluster, hetznerSecret, primaryIP).Build()
+		r = &HCloudPrimaryIPReconciler{
+			Client:              c,
+			APIReader:           c,
+			HCloudClientFactory: fakehcloudclient.NewHCloudClientFactory(),
+		}
+	})
+
+	It("creates the primary IP and requeues to pick up changes in HCloud", func() {
+		res, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(primaryIP)})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.RequeueAfter).To(Equal(primaryIPRefreshInterval))
+
+		Expect(r.Get(context.Background(), client.ObjectKeyFromObject(primaryIP), primaryIP)).To(Succeed())
+		Expect(controllerutil.ContainsFinalizer(primaryIP, infrav1.PrimaryIPFinalizer)).To(BeTrue())
+		Expect(primaryIP.Status.ID).ToNot(BeZero())
+		Expect(primaryIP.Status.Ready).To(BeTrue())
+	})
+})
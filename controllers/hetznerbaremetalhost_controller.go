@@ -24,18 +24,21 @@ import (
 
 	"github.com/pkg/errors"
 	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/dryrun"
 	"github.com/syself/cluster-api-provider-hetzner/pkg/scope"
 	secretutil "github.com/syself/cluster-api-provider-hetzner/pkg/secrets"
 	bmclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/baremetal/client"
 	robotclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/baremetal/client/robot"
 	sshclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/baremetal/client/ssh"
 	"github.com/syself/cluster-api-provider-hetzner/pkg/services/baremetal/host"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/sharding"
 	"github.com/syself/cluster-api-provider-hetzner/pkg/utils"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/cluster-api/util/predicates"
+	"sigs.k8s.io/cluster-api/util/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -51,6 +54,11 @@ type HetznerBareMetalHostReconciler struct {
 	RobotClientFactory robotclient.Factory
 	SSHClientFactory   sshclient.Factory
 	WatchFilterValue   string
+	// Shard limits the reconciliation to the namespaces of the shard.
+	Shard sharding.Shard
+	// DryRun makes the controller record the mutations in Robot and the commands changing the servers
+	// instead of executing them.
+	DryRun bool
 }
 
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=hetznerbaremetalhosts,verbs=get;list;watch;create;update;patch;delete
@@ -78,9 +86,10 @@ func (r *HetznerBareMetalHostReconciler) Reconcile(ctx context.Context, req ctrl
 	// Add a finalizer to newly created objects.
 	if bmHost.DeletionTimestamp.IsZero() && !hostHasFinalizer(bmHost) {
 		log.Info("adding finalizer", "existingFinalizers", bmHost.Finalizers, "newValue", infrav1.BareMetalHostFinalizer)
+		patch := client.MergeFrom(bmHost.DeepCopy())
 		bmHost.Finalizers = append(bmHost.Finalizers,
 			infrav1.BareMetalHostFinalizer)
-		err := r.Update(ctx, bmHost)
+		err := r.Patch(ctx, bmHost, patch, client.FieldOwner(host.HostControllerFieldOwner))
 		if err != nil {
 			return ctrl.Result{}, errors.Wrap(err, "failed to add finalizer")
 		}
@@ -120,16 +129,29 @@ func (r *HetznerBareMetalHostReconciler) Reconcile(ctx context.Context, req ctrl
 		return *res, err
 	}
 
+	imageDownloadSecret, res, err := r.getImageDownloadSecret(ctx, *secretManager, bmHost)
+	if res != nil {
+		return *res, err
+	}
+
+	robotClient := r.RobotClientFactory.NewClient(robotCreds)
+	sshClientFactory := r.SSHClientFactory
+	if dryrun.Enabled(r.DryRun, hetznerCluster) {
+		robotClient = robotclient.NewDryRunClient(robotClient, bmHost)
+		sshClientFactory = sshclient.NewDryRunFactory(sshClientFactory, bmHost)
+	}
+
 	// Create the scope.
 	hostScope, err := scope.NewBareMetalHostScope(ctx, scope.BareMetalHostScopeParams{
 		Logger:               &log,
 		Client:               r.Client,
 		HetznerCluster:       hetznerCluster,
 		HetznerBareMetalHost: bmHost,
-		RobotClient:          r.RobotClientFactory.NewClient(robotCreds),
-		SSHClientFactory:     r.SSHClientFactory,
+		RobotClient:          robotClient,
+		SSHClientFactory:     sshClientFactory,
 		OSSSHSecret:          osSSHSecret,
 		RescueSSHSecret:      rescueSSHSecret,
+		ImageDownloadSecret:  imageDownloadSecret,
 		SecretManager:        secretManager,
 	})
 	if err != nil {
@@ -145,6 +167,22 @@ func (r *HetznerBareMetalHostReconciler) Reconcile(ctx context.Context, req ctrl
 	return r.reconcile(ctx, hostScope)
 }
 
+// hetznerClusterExists checks whether the HetznerCluster that the host refers to exists.
+func (r *HetznerBareMetalHostReconciler) hetznerClusterExists(ctx context.Context, bmHost *infrav1.HetznerBareMetalHost) (bool, error) {
+	if bmHost.Spec.Status.HetznerClusterRef == "" {
+		return false, nil
+	}
+	hetznerCluster := &infrav1.HetznerCluster{}
+	key := client.ObjectKey{Namespace: bmHost.Namespace, Name: bmHost.Spec.Status.HetznerClusterRef}
+	if err := r.Client.Get(ctx, key, hetznerCluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, errors.Wrap(err, "failed to get HetznerCluster")
+	}
+	return true, nil
+}
+
 func (r *HetznerBareMetalHostReconciler) reconcile(
 	ctx context.Context,
 	hostScope *scope.BareMetalHostScope,
@@ -162,26 +200,57 @@ func (r *HetznerBareMetalHostReconciler) reconcile(
 
 func (r *HetznerBareMetalHostReconciler) reconcileSelectedStates(ctx context.Context, bmHost *infrav1.HetznerBareMetalHost) (*ctrl.Result, error) {
 	log := ctrl.LoggerFrom(ctx)
+	original := bmHost.DeepCopy()
 	switch bmHost.Spec.Status.ProvisioningState {
 	// Handle StateNone: check whether needs to be provisioned or deleted.
 	case infrav1.StateNone:
 		var needsUpdate bool
 		if !bmHost.DeletionTimestamp.IsZero() && bmHost.Spec.ConsumerRef == nil {
-			bmHost.Spec.Status.ProvisioningState = infrav1.StateDeleting
+			host.SetProvisioningState(bmHost, infrav1.StateDeleting)
 			needsUpdate = true
 		} else if bmHost.NeedsProvisioning() {
-			bmHost.Spec.Status.ProvisioningState = infrav1.StatePreparing
+			host.SetProvisioningState(bmHost, infrav1.StatePreparing)
 			needsUpdate = true
 		}
 		if needsUpdate {
-			err := r.Update(ctx, bmHost)
+			err := host.SaveHost(ctx, r.Client, bmHost)
 			if err != nil {
-				return &ctrl.Result{}, errors.Wrap(err, "failed to add finalizer")
+				return &ctrl.Result{}, errors.Wrap(err, "failed to update provisioning state")
 			}
+			host.RecordProvisioningStateChange(original, bmHost)
 		}
 
 		return &ctrl.Result{RequeueAfter: 10 * time.Second}, nil
 
+	// Handle StateQuarantined: the host has no consumer anymore and waits for the forensic hold to be released.
+	// It is deprovisioned with the credentials of its last HetznerCluster once it is released.
+	case infrav1.StateQuarantined:
+		switch {
+		case !bmHost.DeletionTimestamp.IsZero() && bmHost.Spec.ConsumerRef == nil:
+			host.SetProvisioningState(bmHost, infrav1.StateDeleting)
+		case !bmHost.HasForensicHold():
+			found, err := r.hetznerClusterExists(ctx, bmHost)
+			if err != nil {
+				return &ctrl.Result{}, err
+			}
+			if found {
+				record.Event(bmHost, "ForensicHoldReleased", "Forensic hold has been released, deprovisioning host")
+				host.SetProvisioningState(bmHost, infrav1.StateDeprovisioning)
+			} else {
+				record.Warnf(bmHost, "ForensicHoldReleased",
+					"Forensic hold has been released, host is available again without deprovisioning as HetznerCluster %q does not exist",
+					bmHost.Spec.Status.HetznerClusterRef)
+				host.SetProvisioningState(bmHost, infrav1.StateNone)
+			}
+		default:
+			return &ctrl.Result{}, nil
+		}
+		if err := host.SaveHost(ctx, r.Client, bmHost); err != nil {
+			return &ctrl.Result{}, errors.Wrap(err, "failed to update provisioning state of quarantined host")
+		}
+		host.RecordProvisioningStateChange(original, bmHost)
+		return &ctrl.Result{Requeue: true}, nil
+
 		// Handle StateDeleting
 	case infrav1.StateDeleting:
 		log.Info("Marked to be deleted", "timestamp", bmHost.DeletionTimestamp)
@@ -191,10 +260,12 @@ func (r *HetznerBareMetalHostReconciler) reconcileSelectedStates(ctx context.Con
 			return &ctrl.Result{}, nil
 		}
 
+		patch := client.MergeFrom(bmHost.DeepCopy())
 		bmHost.Finalizers = utils.FilterStringFromList(bmHost.Finalizers, infrav1.BareMetalHostFinalizer)
-		if err := r.Update(context.Background(), bmHost); err != nil {
+		if err := r.Patch(context.Background(), bmHost, patch, client.FieldOwner(host.HostControllerFieldOwner)); err != nil {
 			return &ctrl.Result{}, errors.Wrap(err, "failed to remove finalizer")
 		}
+		host.DeleteTrafficMetrics(bmHost)
 		log.Info("Cleanup complete. Removed finalizer", "remaining", bmHost.Finalizers)
 		return &ctrl.Result{}, nil
 	}
@@ -253,6 +324,37 @@ func (r *HetznerBareMetalHostReconciler) getSecrets(
 	return osSSHSecret, rescueSSHSecret, nil, nil
 }
 
+// getImageDownloadSecret returns the secret with the credentials to download the image of the host, if specified.
+func (r *HetznerBareMetalHostReconciler) getImageDownloadSecret(
+	ctx context.Context,
+	secretManager secretutil.SecretManager,
+	bmHost *infrav1.HetznerBareMetalHost,
+) (*corev1.Secret, *ctrl.Result, error) {
+	installImage := bmHost.Spec.Status.InstallImage
+	if installImage == nil || installImage.Image.DownloadSecretRef == nil {
+		return nil, nil, nil
+	}
+
+	secretNamespacedName := types.NamespacedName{Namespace: bmHost.Namespace, Name: installImage.Image.DownloadSecretRef.Name}
+	secret, err := secretManager.ObtainSecret(ctx, secretNamespacedName)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			if err := host.SetErrorCondition(
+				ctx,
+				bmHost,
+				r.Client,
+				infrav1.PreparationError,
+				infrav1.ErrorMessageMissingImageDownloadSecret,
+			); err != nil {
+				return nil, &ctrl.Result{}, err
+			}
+			return nil, &ctrl.Result{RequeueAfter: host.CalculateBackoff(bmHost.Spec.Status.ErrorCount)}, nil
+		}
+		return nil, &ctrl.Result{}, errors.Wrap(err, "failed to get secret")
+	}
+	return secret, nil, nil
+}
+
 func getAndValidateRobotCredentials(
 	ctx context.Context,
 	namespace string,
@@ -339,6 +441,7 @@ func (r *HetznerBareMetalHostReconciler) SetupWithManager(ctx context.Context, m
 		WithOptions(options).
 		For(&infrav1.HetznerBareMetalHost{}).
 		WithEventFilter(predicates.ResourceNotPausedAndHasFilterLabel(ctrl.LoggerFrom(ctx), r.WatchFilterValue)).
+		WithEventFilter(r.Shard.Predicate()).
 		WithEventFilter(
 			predicate.Funcs{
 				UpdateFunc: func(e event.UpdateEvent) bool {
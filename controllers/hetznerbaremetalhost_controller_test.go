@@ -25,11 +25,13 @@ import (
 	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
 	robotmock "github.com/syself/cluster-api-provider-hetzner/pkg/services/baremetal/client/mocks/robot"
 	sshmock "github.com/syself/cluster-api-provider-hetzner/pkg/services/baremetal/client/mocks/ssh"
+	robotclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/baremetal/client/robot"
 	sshclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/baremetal/client/ssh"
 	"github.com/syself/cluster-api-provider-hetzner/pkg/utils"
 	"github.com/syself/cluster-api-provider-hetzner/test/helpers"
 	"github.com/syself/hrobot-go/models"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/pointer"
@@ -161,6 +163,7 @@ var _ = Describe("HetznerBareMetalHostReconciler", func() {
 		robotClient.On("DeleteBootRescue", 1).Return(&models.Rescue{Active: false}, nil)
 		robotClient.On("RebootBMServer", mock.Anything, mock.Anything).Return(&models.ResetPost{}, nil)
 		robotClient.On("SetBMServerName", 1, mock.Anything).Return(nil, nil)
+		robotClient.On("GetMonthlyTraffic", mock.Anything, mock.Anything).Return(&robotclient.Traffic{}, nil)
 		configureRescueSSHClient(rescueSSHClient)
 
 		osSSHClientAfterInstallImage.On("Reboot").Return(sshclient.Output{})
@@ -246,6 +249,34 @@ var _ = Describe("HetznerBareMetalHostReconciler", func() {
 		})
 	})
 
+	Context("Deletion of consumed host", func() {
+		BeforeEach(func() {
+			host = helpers.BareMetalHost(
+				hostName,
+				testNs.Name,
+				helpers.WithRootDeviceHintWWN(),
+				helpers.WithHetznerClusterRef(hetznerClusterName),
+				helpers.WithConsumerRef(),
+			)
+			Expect(testEnv.Create(ctx, host)).To(Succeed())
+
+			key = client.ObjectKey{Namespace: testNs.Name, Name: host.Name}
+		})
+
+		It("rejects the deletion without the force delete annotation", func() {
+			err := testEnv.Delete(ctx, host)
+			Expect(apierrors.IsForbidden(err)).To(BeTrue())
+
+			Expect(testEnv.Get(ctx, key, host)).To(Succeed())
+			ph, err := patch.NewHelper(host, testEnv)
+			Expect(err).ShouldNot(HaveOccurred())
+			host.SetAnnotations(map[string]string{infrav1.ForceDeleteAnnotation: "true"})
+			Expect(ph.Patch(ctx, host)).To(Succeed())
+
+			Expect(testEnv.Delete(ctx, host)).To(Succeed())
+		})
+	})
+
 	Context("Tests with bm machine", func() {
 		BeforeEach(func() {
 			capiMachine = &clusterv1.Machine{
@@ -303,6 +334,7 @@ var _ = Describe("HetznerBareMetalHostReconciler", func() {
 					hostName,
 					testNs.Name,
 					helpers.WithHetznerClusterRef(hetznerClusterName),
+					helpers.WithForceDeleteAnnotation(),
 				)
 				Expect(testEnv.Create(ctx, host)).To(Succeed())
 
@@ -349,6 +381,7 @@ var _ = Describe("HetznerBareMetalHostReconciler", func() {
 					testNs.Name,
 					helpers.WithRootDeviceHintWWN(),
 					helpers.WithHetznerClusterRef(hetznerClusterName),
+					helpers.WithForceDeleteAnnotation(),
 				)
 				Expect(testEnv.Create(ctx, host)).To(Succeed())
 
@@ -386,6 +419,7 @@ var _ = Describe("HetznerBareMetalHostReconciler", func() {
 					testNs.Name,
 					helpers.WithRootDeviceHintRaid(),
 					helpers.WithHetznerClusterRef(hetznerClusterName),
+					helpers.WithForceDeleteAnnotation(),
 				)
 				Expect(testEnv.Create(ctx, host)).To(Succeed())
 
@@ -422,6 +456,7 @@ var _ = Describe("HetznerBareMetalHostReconciler", func() {
 					hostName,
 					testNs.Name,
 					helpers.WithHetznerClusterRef(hetznerClusterName),
+					helpers.WithForceDeleteAnnotation(),
 				)
 				Expect(testEnv.Create(ctx, host)).To(Succeed())
 
@@ -513,6 +548,7 @@ var _ = Describe("HetznerBareMetalHostReconciler", func() {
 					testNs.Name,
 					helpers.WithRootDeviceHintWWN(),
 					helpers.WithHetznerClusterRef(hetznerClusterName),
+					helpers.WithForceDeleteAnnotation(),
 				)
 				Expect(testEnv.Create(ctx, host)).To(Succeed())
 
@@ -684,6 +720,7 @@ var _ = Describe("HetznerBareMetalHostReconciler - missing secrets", func() {
 		robotClient.On("SetBootRescue", 1, mock.Anything).Return(&models.Rescue{Active: true}, nil)
 		robotClient.On("DeleteBootRescue", 1).Return(&models.Rescue{Active: true}, nil)
 		robotClient.On("RebootBMServer", mock.Anything, mock.Anything).Return(&models.ResetPost{}, nil)
+		robotClient.On("GetMonthlyTraffic", mock.Anything, mock.Anything).Return(&robotclient.Traffic{}, nil)
 
 		configureRescueSSHClient(rescueSSHClient)
 	})
@@ -854,7 +891,7 @@ name="eth0" model="Realtek Semiconductor Co., Ltd. RTL8111/8168/8411 PCI Express
 		StdOut: "12",
 		StdErr: "",
 		Err:    nil})
-	sshClient.On("DownloadImage", mock.Anything, mock.Anything).Return(sshclient.Output{})
+	sshClient.On("DownloadImage", mock.Anything, mock.Anything, mock.Anything).Return(sshclient.Output{})
 	sshClient.On("CreateAutoSetup", mock.Anything).Return(sshclient.Output{})
 	sshClient.On("CreatePostInstallScript", mock.Anything).Return(sshclient.Output{})
 	sshClient.On("ExecuteInstallImage", mock.Anything).Return(sshclient.Output{})
@@ -22,10 +22,13 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/dryrun"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/propagation"
 	"github.com/syself/cluster-api-provider-hetzner/pkg/scope"
 	secretutil "github.com/syself/cluster-api-provider-hetzner/pkg/secrets"
 	"github.com/syself/cluster-api-provider-hetzner/pkg/services/baremetal/baremetal"
 	hcloudclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/sharding"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog/v2"
@@ -49,11 +52,16 @@ type HetznerBareMetalMachineReconciler struct {
 	APIReader           client.Reader
 	HCloudClientFactory hcloudclient.Factory
 	WatchFilterValue    string
+	// Shard limits the reconciliation to the namespaces of the shard.
+	Shard sharding.Shard
+	// DryRun makes the controller record the mutations in HCloud instead of executing them.
+	DryRun bool
 }
 
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=hetznerbaremetalmachines,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=hetznerbaremetalmachines/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=hetznerbaremetalmachines/finalizers,verbs=update
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=hetznernodeprofiles,verbs=get;list;watch
 
 // Reconcile implements the reconcilement of HetznerBareMetalMachine objects.
 func (r *HetznerBareMetalMachineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
@@ -120,6 +128,9 @@ func (r *HetznerBareMetalMachineReconciler) Reconcile(ctx context.Context, req c
 	}
 
 	hcc := r.HCloudClientFactory.NewClient(hcloudToken)
+	if dryrun.Enabled(r.DryRun, hetznerCluster) {
+		hcc = hcloudclient.NewDryRunClient(hcc, hbmMachine)
+	}
 
 	// Create the scope.
 	machineScope, err := scope.NewBareMetalMachineScope(ctx, scope.BareMetalMachineScopeParams{
@@ -142,14 +153,19 @@ func (r *HetznerBareMetalMachineReconciler) Reconcile(ctx context.Context, req c
 	}()
 
 	if !hbmMachine.ObjectMeta.DeletionTimestamp.IsZero() {
-		return r.reconcileDelete(ctx, machineScope)
+		return dryrun.Result(r.reconcileDelete(ctx, machineScope))
 	}
 
-	return r.reconcileNormal(ctx, machineScope)
+	return dryrun.Result(r.reconcileNormal(ctx, machineScope))
 }
 
 func (r *HetznerBareMetalMachineReconciler) reconcileDelete(ctx context.Context, machineScope *scope.BareMetalMachineScope) (reconcile.Result, error) {
 	machineScope.Info("Reconciling HetznerBareMetalMachine delete")
+
+	if reconcilePreDeleteHooks(machineScope.BareMetalMachine, machineScope.Machine) {
+		return reconcile.Result{RequeueAfter: preDeleteHookRequeueInterval}, nil
+	}
+
 	// delete servers
 	if result, brk, err := breakReconcile(baremetal.NewService(machineScope).Delete(ctx)); brk {
 		if requeueErr, ok := errors.Cause(err).(scope.HasRequeueAfterError); ok {
@@ -180,6 +196,14 @@ func (r *HetznerBareMetalMachineReconciler) reconcileNormal(ctx context.Context,
 		return ctrl.Result{}, err
 	}
 
+	bmMachine := machineScope.BareMetalMachine
+
+	// propagate the labels and annotations of the Machine
+	propagation.MachineMetadata(machineScope.Machine, bmMachine)
+
+	// the certificate is recorded by the CSR controller, its expiry is checked on every reconcile
+	reconcileKubeletServingCertificate(bmMachine, bmMachine.Status.KubeletServingCertificate)
+
 	// reconcile server
 	if result, brk, err := breakReconcile(baremetal.NewService(machineScope).Reconcile(ctx)); brk {
 		return result, errors.Wrapf(
@@ -200,6 +224,7 @@ func (r *HetznerBareMetalMachineReconciler) SetupWithManager(ctx context.Context
 		WithOptions(options).
 		For(&infrav1.HetznerBareMetalMachine{}).
 		WithEventFilter(predicates.ResourceNotPausedAndHasFilterLabel(ctrl.LoggerFrom(ctx), r.WatchFilterValue)).
+		WithEventFilter(r.Shard.Predicate()).
 		Watches(
 			&source.Kind{Type: &clusterv1.Machine{}},
 			handler.EnqueueRequestsFromMapFunc(util.MachineToInfrastructureMapFunc(infrav1.GroupVersion.WithKind("HetznerBareMetalMachine"))),
@@ -231,6 +256,7 @@ func (r *HetznerBareMetalMachineReconciler) SetupWithManager(ctx context.Context
 		&source.Kind{Type: &clusterv1.Cluster{}},
 		handler.EnqueueRequestsFromMapFunc(clusterToObjectFunc),
 		predicates.ClusterUnpausedAndInfrastructureReady(log),
+		r.Shard.Predicate(),
 	); err != nil {
 		return errors.Wrap(err, "failed adding a watch for ready clusters")
 	}
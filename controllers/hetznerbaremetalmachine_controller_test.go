@@ -27,6 +27,7 @@ import (
 	"github.com/syself/cluster-api-provider-hetzner/pkg/services/baremetal/baremetal"
 	robotmock "github.com/syself/cluster-api-provider-hetzner/pkg/services/baremetal/client/mocks/robot"
 	sshmock "github.com/syself/cluster-api-provider-hetzner/pkg/services/baremetal/client/mocks/ssh"
+	robotclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/baremetal/client/robot"
 	sshclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/baremetal/client/ssh"
 	"github.com/syself/cluster-api-provider-hetzner/pkg/utils"
 	"github.com/syself/cluster-api-provider-hetzner/test/helpers"
@@ -118,6 +119,7 @@ var _ = Describe("HetznerBareMetalMachineReconciler", func() {
 			testNs.Name,
 			helpers.WithRootDeviceHintWWN(),
 			helpers.WithHetznerClusterRef(hetznerClusterName),
+			helpers.WithForceDeleteAnnotation(),
 		)
 		Expect(testEnv.Create(ctx, host)).To(Succeed())
 		hostKey = client.ObjectKey{Namespace: testNs.Name, Name: hostName}
@@ -156,6 +158,7 @@ var _ = Describe("HetznerBareMetalMachineReconciler", func() {
 		robotClient.On("DeleteBootRescue", 1).Return(&models.Rescue{Active: false}, nil)
 		robotClient.On("RebootBMServer", mock.Anything, mock.Anything).Return(&models.ResetPost{}, nil)
 		robotClient.On("SetBMServerName", 1, mock.Anything).Return(nil, nil)
+		robotClient.On("GetMonthlyTraffic", mock.Anything, mock.Anything).Return(&robotclient.Traffic{}, nil)
 
 		configureRescueSSHClient(rescueSSHClient)
 
@@ -423,3 +426,66 @@ var _ = Describe("HetznerBareMetalMachineReconciler", func() {
 		})
 	})
 })
+
+var _ = Describe("HetznerBareMetalMachine validation", func() {
+	var (
+		hetznerCluster *infrav1.HetznerCluster
+		capiCluster    *clusterv1.Cluster
+		bmMachine      *infrav1.HetznerBareMetalMachine
+		testNs         *corev1.Namespace
+	)
+
+	BeforeEach(func() {
+		var err error
+		testNs, err = testEnv.CreateNamespace(ctx, "baremetalmachine-validation")
+		Expect(err).NotTo(HaveOccurred())
+
+		hetznerCluster = &infrav1.HetznerCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "bm-validation-cluster",
+				Namespace: testNs.Name,
+			},
+			Spec: helpers.GetDefaultHetznerClusterSpec(),
+		}
+		capiCluster = &clusterv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "bm-validation",
+				Namespace: testNs.Name,
+			},
+			Spec: clusterv1.ClusterSpec{
+				InfrastructureRef: &corev1.ObjectReference{
+					APIVersion: infrav1.GroupVersion.String(),
+					Kind:       "HetznerCluster",
+					Name:       hetznerCluster.Name,
+					Namespace:  testNs.Name,
+				},
+			},
+		}
+		Expect(testEnv.Create(ctx, capiCluster)).To(Succeed())
+
+		bmMachine = &infrav1.HetznerBareMetalMachine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "bm-validation-machine",
+				Namespace: testNs.Name,
+				Labels:    map[string]string{clusterv1.ClusterLabelName: capiCluster.Name},
+			},
+			Spec: getDefaultHetznerBareMetalMachineSpec(),
+		}
+		bmMachine.Spec.SSHSpec.SecretRef = infrav1.SSHSecretRef{}
+	})
+
+	AfterEach(func() {
+		Expect(testEnv.Cleanup(ctx, testNs, capiCluster, hetznerCluster, bmMachine)).To(Succeed())
+	})
+
+	It("rejects a machine without SSH secret if the HetznerCluster has no sshDefaults", func() {
+		Expect(testEnv.Create(ctx, hetznerCluster)).To(Succeed())
+		Expect(testEnv.Create(ctx, bmMachine)).ToNot(Succeed())
+	})
+
+	It("admits a machine without SSH secret if the sshDefaults of the HetznerCluster specify one", func() {
+		hetznerCluster.Spec.SSHDefaults = &infrav1.SSHSpec{SecretRef: getDefaultHetznerBareMetalMachineSpec().SSHSpec.SecretRef}
+		Expect(testEnv.Create(ctx, hetznerCluster)).To(Succeed())
+		Expect(testEnv.Create(ctx, bmMachine)).To(Succeed())
+	})
+})
@@ -23,6 +23,7 @@ import (
 	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
 	"github.com/syself/cluster-api-provider-hetzner/pkg/scope"
 	"github.com/syself/cluster-api-provider-hetzner/pkg/services/baremetal/remediation"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/sharding"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/cluster-api/util"
@@ -39,6 +40,8 @@ import (
 type HetznerBareMetalRemediationReconciler struct {
 	client.Client
 	WatchFilterValue string
+	// Shard limits the reconciliation to the namespaces of the shard.
+	Shard sharding.Shard
 }
 
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=hetznerbaremetalremediations,verbs=get;list;watch;create;update;patch;delete
@@ -169,5 +172,6 @@ func (r *HetznerBareMetalRemediationReconciler) SetupWithManager(ctx context.Con
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&infrav1.HetznerBareMetalRemediation{}).
 		WithEventFilter(predicates.ResourceNotPausedAndHasFilterLabel(ctrl.LoggerFrom(ctx), r.WatchFilterValue)).
+		WithEventFilter(r.Shard.Predicate()).
 		Complete(r)
 }
@@ -20,6 +20,7 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"strconv"
 	"strings"
 	"sync"
@@ -28,18 +29,27 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/cleanupreport"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/dryrun"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/egressip"
 	"github.com/syself/cluster-api-provider-hetzner/pkg/scope"
 	secretutil "github.com/syself/cluster-api-provider-hetzner/pkg/secrets"
 	hcloudclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/firewall"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/floatingip"
 	"github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/loadbalancer"
 	"github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/network"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/orphan"
 	"github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/placementgroup"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/sshkey"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/sharding"
 	certificatesv1 "k8s.io/api/certificates/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
@@ -61,6 +71,10 @@ import (
 const (
 	secretErrorRetryDelay = time.Second * 10
 	rateLimitWaitTime     = 5 * time.Minute
+
+	// floatingIPRefreshInterval is the interval in which the assignment of the floating IP of the control plane
+	// is checked.
+	floatingIPRefreshInterval = time.Minute
 )
 
 // HetznerClusterReconciler reconciles a HetznerCluster object.
@@ -73,12 +87,19 @@ type HetznerClusterReconciler struct {
 	targetClusterManagersStopCh    map[types.NamespacedName]chan struct{}
 	targetClusterManagersLock      sync.Mutex
 	TargetClusterManagersWaitGroup *sync.WaitGroup
+	// Shard limits the reconciliation to the namespaces of the shard.
+	Shard sharding.Shard
+	// DryRun makes the controller record the mutations in HCloud instead of executing them.
+	DryRun bool
+	// EgressIPDiscoverer discovers the egress IPs that are published in the status. Nil disables the discovery.
+	EgressIPDiscoverer *egressip.Discoverer
 }
 
 //+kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status,verbs=get;list;watch
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=hetznerclusters,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=hetznerclusters/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=hetznerclusters/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;create;update
 
 // Reconcile manages the lifecycle of a HetznerCluster object.
 func (r *HetznerClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
@@ -124,12 +145,15 @@ func (r *HetznerClusterReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	log.V(1).Info("Creating cluster scope")
 	// Create the scope.
 	secretManager := secretutil.NewSecretManager(log, r.Client, r.APIReader)
-	hcloudToken, hetznerSecret, err := getAndValidateHCloudToken(ctx, req.Namespace, hetznerCluster, secretManager)
-	if err != nil {
-		return hcloudTokenErrorResult(ctx, err, hetznerCluster, infrav1.HetznerClusterReady, r.Client)
+	hcloudToken, hetznerSecret, tokenErr := getAndValidateHCloudToken(ctx, req.Namespace, hetznerCluster, secretManager)
+	if tokenErr != nil && !forceCleanupRequested(hetznerCluster) {
+		return hcloudTokenErrorResult(ctx, tokenErr, hetznerCluster, infrav1.HetznerClusterReady, r.Client)
 	}
 
 	hcloudClient := r.HCloudClientFactory.NewClient(hcloudToken)
+	if dryrun.Enabled(r.DryRun, hetznerCluster) {
+		hcloudClient = hcloudclient.NewDryRunClient(hcloudClient, hetznerCluster)
+	}
 
 	clusterScope, err := scope.NewClusterScope(ctx, scope.ClusterScopeParams{
 		Client:         r.Client,
@@ -158,11 +182,14 @@ func (r *HetznerClusterReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 
 	// Handle deleted clusters
 	if !hetznerCluster.DeletionTimestamp.IsZero() {
-		return r.reconcileDelete(ctx, clusterScope)
+		if tokenErr != nil {
+			return dryrun.Result(r.reconcileForceCleanup(ctx, clusterScope, tokenErr))
+		}
+		return dryrun.Result(r.reconcileDelete(ctx, clusterScope))
 	}
 
 	// Handle non-deleted clusters
-	return r.reconcileNormal(ctx, clusterScope)
+	return dryrun.Result(r.reconcileNormal(ctx, clusterScope))
 }
 
 func (r *HetznerClusterReconciler) reconcileNormal(ctx context.Context, clusterScope *scope.ClusterScope) (ctrl.Result, error) {
@@ -200,9 +227,28 @@ func (r *HetznerClusterReconciler) reconcileNormal(ctx context.Context, clusterS
 	}
 	conditions.MarkTrue(hetznerCluster, infrav1.PlacementGroupsSynced)
 
+	// reconcile the firewalls
+	if err := firewall.NewService(clusterScope).Reconcile(ctx); err != nil {
+		conditions.MarkFalse(hetznerCluster, infrav1.FirewallsSyncedCondition, infrav1.FirewallsSyncFailedReason, clusterv1.ConditionSeverityError, err.Error())
+		return reconcile.Result{}, errors.Wrapf(err, "failed to reconcile firewalls for HetznerCluster %s/%s", hetznerCluster.Namespace, hetznerCluster.Name)
+	}
+	conditions.MarkTrue(hetznerCluster, infrav1.FirewallsSyncedCondition)
+
+	// reconcile the floating IP of the control plane
+	if err := floatingip.NewService(clusterScope).Reconcile(ctx); err != nil {
+		conditions.MarkFalse(hetznerCluster, infrav1.ControlPlaneFloatingIPReadyCondition, infrav1.FloatingIPReconcileFailedReason, clusterv1.ConditionSeverityError, err.Error())
+		return reconcile.Result{}, errors.Wrapf(err, "failed to reconcile floating IP for HetznerCluster %s/%s", hetznerCluster.Namespace, hetznerCluster.Name)
+	}
+
+	r.reconcileEgressIPs(ctx, hetznerCluster)
+
+	// delete resources that were orphaned while the HCloud API was unreachable
+	if err := orphan.NewService(clusterScope).Reconcile(ctx); err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to reconcile orphaned resources for HetznerCluster %s/%s", hetznerCluster.Namespace, hetznerCluster.Name)
+	}
+
 	if hetznerCluster.Spec.ControlPlaneLoadBalancer.Enabled {
-		if hetznerCluster.Status.ControlPlaneLoadBalancer.IPv4 != "<nil>" {
-			var defaultHost = hetznerCluster.Status.ControlPlaneLoadBalancer.IPv4
+		if defaultHost := loadBalancerEndpointHost(hetznerCluster); defaultHost != "" {
 			var defaultPort = int32(hetznerCluster.Spec.ControlPlaneLoadBalancer.Port)
 
 			if hetznerCluster.Spec.ControlPlaneEndpoint == nil {
@@ -219,6 +265,20 @@ func (r *HetznerClusterReconciler) reconcileNormal(ctx context.Context, clusterS
 				}
 			}
 
+			hetznerCluster.Status.Ready = true
+		}
+	} else if hetznerCluster.Spec.ControlPlaneFloatingIP != nil {
+		if status := hetznerCluster.Status.ControlPlaneFloatingIP; status != nil {
+			defaultPort := int32(hetznerCluster.Spec.ControlPlaneFloatingIP.Port)
+			if hetznerCluster.Spec.ControlPlaneEndpoint == nil {
+				hetznerCluster.Spec.ControlPlaneEndpoint = &clusterv1.APIEndpoint{}
+			}
+			if hetznerCluster.Spec.ControlPlaneEndpoint.Host == "" {
+				hetznerCluster.Spec.ControlPlaneEndpoint.Host = status.IP
+			}
+			if hetznerCluster.Spec.ControlPlaneEndpoint.Port == 0 {
+				hetznerCluster.Spec.ControlPlaneEndpoint.Port = defaultPort
+			}
 			hetznerCluster.Status.Ready = true
 		}
 	} else if hetznerCluster.Spec.ControlPlaneEndpoint != nil {
@@ -234,6 +294,11 @@ func (r *HetznerClusterReconciler) reconcileNormal(ctx context.Context, clusterS
 	}
 
 	log.V(1).Info("Reconciling finished")
+
+	// the floating IP moves between the control planes when they are replaced, which has to be reflected in the status
+	if hetznerCluster.Spec.ControlPlaneFloatingIP != nil {
+		return reconcile.Result{RequeueAfter: floatingIPRefreshInterval}, nil
+	}
 	return reconcile.Result{}, nil
 }
 
@@ -245,23 +310,10 @@ func (r *HetznerClusterReconciler) reconcileDelete(ctx context.Context, clusterS
 	hetznerCluster := clusterScope.HetznerCluster
 
 	// wait for all hcloudMachines to be deleted
-	machines, _, err := clusterScope.ListMachines(ctx)
-	if err != nil {
-		return reconcile.Result{}, errors.Wrapf(err, "failed to list machines for HetznerCluster %s/%s", hetznerCluster.Namespace, hetznerCluster.Name)
-	}
-	if len(machines) > 0 {
-		names := make([]string, len(machines))
-		for i, m := range machines {
-			names[i] = fmt.Sprintf("machine/%s", m.Name)
-		}
-		record.Eventf(
-			hetznerCluster,
-			"WaitingForMachineDeletion",
-			"Machines %s still running, waiting with deletion of HetznerCluster",
-			strings.Join(names, ", "),
-		)
-		return reconcile.Result{RequeueAfter: 10 * time.Second}, nil
+	if wait, err := waitForMachineDeletion(ctx, clusterScope); err != nil || wait {
+		return reconcile.Result{RequeueAfter: 10 * time.Second}, err
 	}
+	conditions.MarkTrue(hetznerCluster, infrav1.MachinesDeletedCondition)
 
 	secretManager := secretutil.NewSecretManager(log, r.Client, r.APIReader)
 	// Remove finalizer of secret
@@ -269,56 +321,272 @@ func (r *HetznerClusterReconciler) reconcileDelete(ctx context.Context, clusterS
 		return reconcile.Result{}, errors.Wrap(err, "failed to release Hetzner secret")
 	}
 
-	// Check if rescue ssh secret exists and release it if yes
-	if hetznerCluster.Spec.SSHKeys.RobotRescueSecretRef.Name != "" {
-		rescueSSHSecretObjectKey := client.ObjectKey{Name: hetznerCluster.Spec.SSHKeys.RobotRescueSecretRef.Name, Namespace: hetznerCluster.Namespace}
-		rescueSSHSecret, err := secretManager.ObtainSecret(ctx, rescueSSHSecretObjectKey)
-		if err != nil {
-			if !apierrors.IsNotFound(err) {
-				return reconcile.Result{}, errors.Wrap(err, "failed to get Rescue SSH secret")
-			}
+	if err := releaseRescueSSHSecret(ctx, secretManager, hetznerCluster); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	// record the resources before they are deleted and dropped from the status
+	reporter := cleanupreport.NewReporter(r.Client, r.APIReader)
+	if err := reporter.Record(ctx, hetznerCluster); err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to record cleanup report")
+	}
+
+	if err := deleteHCloudResources(ctx, clusterScope); err != nil {
+		// a revoked token does not allow any cleanup
+		if forceCleanupRequested(hetznerCluster) && hcloudclient.IsUnauthorized(err) {
+			return r.reconcileForceCleanup(ctx, clusterScope, err)
 		}
-		if rescueSSHSecret != nil {
-			if err := secretManager.ReleaseSecret(ctx, rescueSSHSecret); err != nil {
-				if !apierrors.IsNotFound(err) {
-					return reconcile.Result{}, errors.Wrap(err, "failed to release Rescue SSH secret")
-				}
+		return reconcile.Result{}, err
+	}
+
+	if err := reporter.Complete(ctx, hetznerCluster, cleanupreport.ResultDeleted); err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to complete cleanup report")
+	}
+
+	r.stopTargetClusterManager(hetznerCluster)
+
+	// Cluster is deleted so remove the finalizer.
+	controllerutil.RemoveFinalizer(clusterScope.HetznerCluster, infrav1.ClusterFinalizer)
+
+	return reconcile.Result{}, nil
+}
+
+// reconcileForceCleanup removes the finalizer of a deleted HetznerCluster with the force-cleanup annotation whose
+// HCloud resources cannot be deleted, because the Hetzner secret is missing or invalid. The resources that are
+// left behind are recorded in a warning event.
+func (r *HetznerClusterReconciler) reconcileForceCleanup(ctx context.Context, clusterScope *scope.ClusterScope, cause error) (reconcile.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	log.Info("Reconciling HetznerCluster force cleanup", "cause", cause.Error())
+
+	hetznerCluster := clusterScope.HetznerCluster
+
+	// machines are cleaned up by their own controllers, e.g. with the force-cleanup annotation as well
+	if wait, err := waitForMachineDeletion(ctx, clusterScope); err != nil || wait {
+		return reconcile.Result{RequeueAfter: 10 * time.Second}, err
+	}
+
+	if dryrun.Enabled(r.DryRun, hetznerCluster) {
+		return reconcile.Result{}, dryrun.Skip(hetznerCluster, "force cleanup of HetznerCluster %s", hetznerCluster.Name)
+	}
+
+	secretManager := secretutil.NewSecretManager(log, r.Client, r.APIReader)
+
+	// the secret might exist with an invalid token
+	hetznerSecret, err := secretManager.ObtainSecret(ctx, client.ObjectKey{Name: hetznerCluster.Spec.HetznerSecret.Name, Namespace: hetznerCluster.Namespace})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return reconcile.Result{}, errors.Wrap(err, "failed to get Hetzner secret")
+	}
+	if hetznerSecret != nil {
+		if err := secretManager.ReleaseSecret(ctx, hetznerSecret); err != nil {
+			return reconcile.Result{}, errors.Wrap(err, "failed to release Hetzner secret")
+		}
+	}
+
+	if err := releaseRescueSSHSecret(ctx, secretManager, hetznerCluster); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if err := cleanupreport.NewReporter(r.Client, r.APIReader).Complete(ctx, hetznerCluster, cleanupreport.ResultOrphaned); err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to complete cleanup report")
+	}
+
+	resources := leftBehindResources(hetznerCluster)
+	if len(resources) == 0 {
+		resources = []string{"none"}
+	}
+	record.Warnf(
+		hetznerCluster,
+		"HetznerClusterForceCleanup",
+		"Skipped deletion of HCloud resources because of annotation %s: %s. Resources left behind: %s",
+		infrav1.ForceCleanupAnnotation,
+		cause.Error(),
+		strings.Join(resources, ", "),
+	)
+
+	r.stopTargetClusterManager(hetznerCluster)
+
+	controllerutil.RemoveFinalizer(hetznerCluster, infrav1.ClusterFinalizer)
+
+	return reconcile.Result{}, nil
+}
+
+// deleteHCloudResources deletes the HCloud resources of the HetznerCluster in the steps of its deletion policy.
+func deleteHCloudResources(ctx context.Context, clusterScope *scope.ClusterScope) error {
+	return runDeletionSteps(ctx, clusterScope.HetznerCluster, deletionSteps(clusterScope))
+}
+
+// deletionStepFunc deletes the HCloud resources of a deletion step.
+type deletionStepFunc func(ctx context.Context) error
+
+// deletionSteps returns the functions that delete the HCloud resources of each deletion step.
+func deletionSteps(clusterScope *scope.ClusterScope) map[infrav1.DeletionStep]deletionStepFunc {
+	return map[infrav1.DeletionStep]deletionStepFunc{
+		// resources that were orphaned while the HCloud API was unreachable
+		infrav1.DeletionStepOrphanedResources:   orphan.NewService(clusterScope).Reconcile,
+		infrav1.DeletionStepLoadBalancerTargets: loadbalancer.NewService(clusterScope).DeleteTargets,
+		infrav1.DeletionStepLoadBalancer:        loadbalancer.NewService(clusterScope).Delete,
+		infrav1.DeletionStepSubnets:             network.NewService(clusterScope).DeleteSubnets,
+		infrav1.DeletionStepNetwork:             network.NewService(clusterScope).Delete,
+		infrav1.DeletionStepPlacementGroups:     placementgroup.NewService(clusterScope).Delete,
+		infrav1.DeletionStepFirewalls:           firewall.NewService(clusterScope).Delete,
+		infrav1.DeletionStepFloatingIP:          floatingip.NewService(clusterScope).Delete,
+		// ssh keys that have been created for additional ssh keys of machines
+		infrav1.DeletionStepSSHKeys: sshkey.NewService(clusterScope).Delete,
+	}
+}
+
+// runDeletionSteps runs the deletion steps in the order of the deletion policy and reports the result of each step
+// in its condition. After a failed step, the remaining steps wait for the next reconciliation, unless the policy
+// continues on errors. The errors of the failed steps are returned.
+func runDeletionSteps(ctx context.Context, hetznerCluster *infrav1.HetznerCluster, steps map[infrav1.DeletionStep]deletionStepFunc) error {
+	policy := hetznerCluster.Spec.DeletionPolicy
+
+	var errs []error
+	var failedStep infrav1.DeletionStep
+	for _, step := range policy.Steps() {
+		condition := infrav1.DeletionStepCondition(step)
+		if policy.IsSkipped(step) {
+			conditions.MarkFalse(hetznerCluster, condition, infrav1.DeletionStepSkippedReason, clusterv1.ConditionSeverityInfo,
+				"skipped by the deletion policy, the resources are left behind")
+			continue
+		}
+		if len(errs) > 0 && !policy.ContinuesOnError() {
+			conditions.MarkFalse(hetznerCluster, condition, infrav1.DeletionStepPendingReason, clusterv1.ConditionSeverityInfo,
+				"waiting for step %s", failedStep)
+			continue
+		}
+
+		if err := steps[step](ctx); err != nil {
+			conditions.MarkFalse(hetznerCluster, condition, infrav1.DeletionStepFailedReason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
+			errs = append(errs, errors.Wrapf(err, "failed to run deletion step %s for HetznerCluster %s/%s",
+				step, hetznerCluster.Namespace, hetznerCluster.Name))
+			if failedStep == "" {
+				failedStep = step
 			}
+			continue
 		}
+		conditions.MarkTrue(hetznerCluster, condition)
 	}
+	return kerrors.NewAggregate(errs)
+}
 
-	// delete load balancers
-	if err := loadbalancer.NewService(clusterScope).Delete(ctx); err != nil {
-		return reconcile.Result{}, errors.Wrapf(err, "failed to delete load balancers for HetznerCluster %s/%s", hetznerCluster.Namespace, hetznerCluster.Name)
+// reconcileEgressIPs publishes the egress IPs of the controllers in the status. If the discovery fails, the IPs
+// that have been discovered before are kept, as they are most likely still valid.
+func (r *HetznerClusterReconciler) reconcileEgressIPs(ctx context.Context, hetznerCluster *infrav1.HetznerCluster) {
+	if r.EgressIPDiscoverer == nil {
+		hetznerCluster.Status.EgressIPs = nil
+		conditions.Delete(hetznerCluster, infrav1.EgressIPsDiscoveredCondition)
+		return
 	}
 
-	// delete the network
-	if err := network.NewService(clusterScope).Delete(ctx); err != nil {
-		return reconcile.Result{}, errors.Wrapf(err, "failed to delete network for HetznerCluster %s/%s", hetznerCluster.Namespace, hetznerCluster.Name)
+	ips, err := r.EgressIPDiscoverer.IPs(ctx)
+	if err != nil {
+		conditions.MarkFalse(hetznerCluster, infrav1.EgressIPsDiscoveredCondition, infrav1.EgressIPDiscoveryFailedReason,
+			clusterv1.ConditionSeverityWarning, err.Error())
+		return
 	}
+	if !reflect.DeepEqual(hetznerCluster.Status.EgressIPs, ips) {
+		record.Eventf(hetznerCluster, "EgressIPsChanged", "Egress IPs of the controllers are %s", strings.Join(ips, ", "))
+	}
+	hetznerCluster.Status.EgressIPs = ips
+	conditions.MarkTrue(hetznerCluster, infrav1.EgressIPsDiscoveredCondition)
+}
+
+// forceCleanupRequested returns whether a deleted object has the force-cleanup annotation.
+func forceCleanupRequested(obj metav1.Object) bool {
+	return !obj.GetDeletionTimestamp().IsZero() && obj.GetAnnotations()[infrav1.ForceCleanupAnnotation] == "true"
+}
 
-	// delete the placement groups
-	if err := placementgroup.NewService(clusterScope).Delete(ctx); err != nil {
-		return reconcile.Result{}, errors.Wrapf(err, "failed to delete placement groups for HetznerCluster %s/%s", hetznerCluster.Namespace, hetznerCluster.Name)
+// leftBehindResources lists the HCloud resources of the HetznerCluster that have not been deleted.
+func leftBehindResources(hetznerCluster *infrav1.HetznerCluster) []string {
+	var resources []string
+	status := hetznerCluster.Status
+	if status.Network != nil && status.Network.ID != 0 {
+		resources = append(resources, fmt.Sprintf("network %d", status.Network.ID))
 	}
+	if status.ControlPlaneLoadBalancer != nil && status.ControlPlaneLoadBalancer.ID != 0 {
+		resources = append(resources, fmt.Sprintf("load balancer %d", status.ControlPlaneLoadBalancer.ID))
+	}
+	if status.ControlPlaneFloatingIP != nil && status.ControlPlaneFloatingIP.ID != 0 {
+		resources = append(resources, fmt.Sprintf("floating IP %d", status.ControlPlaneFloatingIP.ID))
+	}
+	for _, pg := range status.HCloudPlacementGroup {
+		resources = append(resources, fmt.Sprintf("placement group %d", pg.ID))
+	}
+	for _, fw := range status.HCloudFirewalls {
+		resources = append(resources, fmt.Sprintf("firewall %d", fw.ID))
+	}
+	for _, res := range status.OrphanedResources {
+		resources = append(resources, fmt.Sprintf("%s %d of %s", res.Type, res.ID, res.Name))
+	}
+	return resources
+}
+
+// waitForMachineDeletion returns whether machines of the cluster still exist, as the HetznerCluster
+// has to wait for their deletion.
+func waitForMachineDeletion(ctx context.Context, clusterScope *scope.ClusterScope) (bool, error) {
+	hetznerCluster := clusterScope.HetznerCluster
 
-	// Stop CSR manager
+	machines, _, err := clusterScope.ListMachines(ctx)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to list machines for HetznerCluster %s/%s", hetznerCluster.Namespace, hetznerCluster.Name)
+	}
+	if len(machines) == 0 {
+		return false, nil
+	}
+
+	names := make([]string, len(machines))
+	for i, m := range machines {
+		names[i] = fmt.Sprintf("machine/%s", m.Name)
+	}
+	conditions.MarkFalse(hetznerCluster, infrav1.MachinesDeletedCondition, infrav1.MachinesRunningReason, clusterv1.ConditionSeverityInfo,
+		"%d machines are still running", len(machines))
+	record.Eventf(
+		hetznerCluster,
+		"WaitingForMachineDeletion",
+		"Machines %s still running, waiting with deletion of HetznerCluster",
+		strings.Join(names, ", "),
+	)
+	return true, nil
+}
+
+// releaseRescueSSHSecret removes the finalizer of the rescue SSH secret if it exists.
+func releaseRescueSSHSecret(ctx context.Context, secretManager *secretutil.SecretManager, hetznerCluster *infrav1.HetznerCluster) error {
+	if hetznerCluster.Spec.SSHKeys.RobotRescueSecretRef.Name == "" {
+		return nil
+	}
+
+	rescueSSHSecretObjectKey := client.ObjectKey{Name: hetznerCluster.Spec.SSHKeys.RobotRescueSecretRef.Name, Namespace: hetznerCluster.Namespace}
+	rescueSSHSecret, err := secretManager.ObtainSecret(ctx, rescueSSHSecretObjectKey)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return errors.Wrap(err, "failed to get Rescue SSH secret")
+		}
+	}
+	if rescueSSHSecret != nil {
+		if err := secretManager.ReleaseSecret(ctx, rescueSSHSecret); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return errors.Wrap(err, "failed to release Rescue SSH secret")
+			}
+		}
+	}
+	return nil
+}
+
+// stopTargetClusterManager stops the CSR manager of the workload cluster.
+func (r *HetznerClusterReconciler) stopTargetClusterManager(hetznerCluster *infrav1.HetznerCluster) {
 	r.targetClusterManagersLock.Lock()
 	defer r.targetClusterManagersLock.Unlock()
 
 	key := types.NamespacedName{
-		Namespace: clusterScope.HetznerCluster.Namespace,
-		Name:      clusterScope.HetznerCluster.Name,
+		Namespace: hetznerCluster.Namespace,
+		Name:      hetznerCluster.Name,
 	}
 	if stopCh, ok := r.targetClusterManagersStopCh[key]; ok {
 		close(stopCh)
 		delete(r.targetClusterManagersStopCh, key)
 	}
-
-	// Cluster is deleted so remove the finalizer.
-	controllerutil.RemoveFinalizer(clusterScope.HetznerCluster, infrav1.ClusterFinalizer)
-
-	return reconcile.Result{}, nil
 }
 
 // reconcileRateLimit checks whether a rate limit has been reached and returns whether
@@ -412,6 +680,23 @@ func hcloudTokenErrorResult(
 	return res, err
 }
 
+// loadBalancerEndpointHost returns the address of the load balancer of the IP family of the spec, which is the
+// default host of the control plane endpoint. It returns an empty string if the load balancer has no such address.
+func loadBalancerEndpointHost(hetznerCluster *infrav1.HetznerCluster) string {
+	lbStatus := hetznerCluster.Status.ControlPlaneLoadBalancer
+	if lbStatus == nil {
+		return ""
+	}
+	host := lbStatus.IPv4
+	if hetznerCluster.Spec.ControlPlaneLoadBalancer.EndpointIPFamily == infrav1.PrimaryIPTypeIPv6 {
+		host = lbStatus.IPv6
+	}
+	if host == "<nil>" {
+		return ""
+	}
+	return host
+}
+
 func reconcileTargetSecret(ctx context.Context, clusterScope *scope.ClusterScope) error {
 	log := ctrl.LoggerFrom(ctx)
 
@@ -584,6 +869,7 @@ func (r *HetznerClusterReconciler) newTargetClusterManager(ctx context.Context,
 
 	scheme := runtime.NewScheme()
 	_ = certificatesv1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
 	_ = infrav1.AddToScheme(scheme)
 
 	clusterMgr, err := ctrl.NewManager(
@@ -612,6 +898,19 @@ func (r *HetznerClusterReconciler) newTargetClusterManager(ctx context.Context,
 		return nil, errors.Wrapf(err, "failed to setup CSR controller")
 	}
 
+	nr := &GuestNodeReconciler{
+		Client: clusterMgr.GetClient(),
+		mCluster: &managementCluster{
+			Client:         r.Client,
+			hetznerCluster: hetznerCluster,
+		},
+		hetznerClusterName: hetznerCluster.Name,
+	}
+
+	if err := nr.SetupWithManager(ctx, clusterMgr, controller.Options{}); err != nil {
+		return nil, errors.Wrapf(err, "failed to setup node controller")
+	}
+
 	return clusterMgr, nil
 }
 
@@ -627,6 +926,7 @@ func (r *HetznerClusterReconciler) SetupWithManager(ctx context.Context, mgr ctr
 		WithOptions(options).
 		For(&infrav1.HetznerCluster{}).
 		WithEventFilter(predicates.ResourceNotPausedAndHasFilterLabel(log, r.WatchFilterValue)).
+		WithEventFilter(r.Shard.Predicate()).
 		WithEventFilter(predicates.ResourceIsNotExternallyManaged(log)).
 		Owns(&corev1.Secret{}).
 		Build(r)
@@ -681,5 +981,6 @@ func (r *HetznerClusterReconciler) SetupWithManager(ctx context.Context, mgr ctr
 				},
 			}
 		}),
+		r.Shard.Predicate(),
 	)
 }
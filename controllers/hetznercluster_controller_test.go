@@ -19,6 +19,8 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"time"
 
 	"github.com/hetznercloud/hcloud-go/hcloud"
@@ -26,6 +28,7 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/pkg/errors"
 	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/egressip"
 	"github.com/syself/cluster-api-provider-hetzner/pkg/utils"
 	"github.com/syself/cluster-api-provider-hetzner/test/helpers"
 	corev1 "k8s.io/api/core/v1"
@@ -975,6 +978,14 @@ var _ = Describe("HetznerCluster validation", func() {
 			})
 			Expect(testEnv.Create(ctx, hetznerCluster)).ToNot(Succeed())
 		})
+
+		It("should fail with a console user of the operating system", func() {
+			hetznerCluster.Spec.ConsoleUser = &infrav1.ConsoleUserSpec{
+				Name:              "root",
+				PasswordSecretRef: infrav1.ConsoleUserPasswordSecretRef{Name: "console-password"},
+			}
+			Expect(testEnv.Create(ctx, hetznerCluster)).ToNot(Succeed())
+		})
 	})
 })
 
@@ -1012,3 +1023,138 @@ var _ = Describe("reconcileRateLimit", func() {
 	})
 
 })
+
+var _ = Describe("force cleanup", func() {
+	It("is only requested on deleted objects with the annotation", func() {
+		hetznerCluster := &infrav1.HetznerCluster{ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{infrav1.ForceCleanupAnnotation: "true"},
+		}}
+		Expect(forceCleanupRequested(hetznerCluster)).To(BeFalse())
+
+		hetznerCluster.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+		Expect(forceCleanupRequested(hetznerCluster)).To(BeTrue())
+
+		hetznerCluster.Annotations[infrav1.ForceCleanupAnnotation] = "false"
+		Expect(forceCleanupRequested(hetznerCluster)).To(BeFalse())
+	})
+
+	It("lists the HCloud resources that are left behind", func() {
+		hetznerCluster := &infrav1.HetznerCluster{Status: infrav1.HetznerClusterStatus{
+			Network:                  &infrav1.NetworkStatus{ID: 1},
+			ControlPlaneLoadBalancer: &infrav1.LoadBalancerStatus{ID: 2},
+			HCloudPlacementGroup:     []infrav1.HCloudPlacementGroupStatus{{ID: 3}},
+			OrphanedResources:        []infrav1.OrphanedResource{{Type: infrav1.OrphanedResourceTypeServer, ID: 4, Name: "machine"}},
+		}}
+		Expect(leftBehindResources(hetznerCluster)).To(Equal([]string{
+			"network 1", "load balancer 2", "placement group 3", "server 4 of machine",
+		}))
+		Expect(leftBehindResources(&infrav1.HetznerCluster{})).To(BeEmpty())
+	})
+})
+
+var _ = Describe("runDeletionSteps", func() {
+	var (
+		hetznerCluster *infrav1.HetznerCluster
+		ran            []infrav1.DeletionStep
+		steps          map[infrav1.DeletionStep]deletionStepFunc
+	)
+
+	BeforeEach(func() {
+		hetznerCluster = &infrav1.HetznerCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default"}}
+		ran = nil
+		steps = make(map[infrav1.DeletionStep]deletionStepFunc)
+		for _, step := range infrav1.DefaultDeletionOrder {
+			step := step
+			steps[step] = func(context.Context) error {
+				ran = append(ran, step)
+				return nil
+			}
+		}
+	})
+
+	It("runs the steps in the order of the policy and skips steps", func() {
+		hetznerCluster.Spec.DeletionPolicy = &infrav1.ClusterDeletionPolicy{
+			Order: []infrav1.DeletionStep{infrav1.DeletionStepSSHKeys, infrav1.DeletionStepLoadBalancer},
+			Skip:  []infrav1.DeletionStep{infrav1.DeletionStepFirewalls},
+		}
+		Expect(runDeletionSteps(ctx, hetznerCluster, steps)).To(Succeed())
+
+		Expect(ran).To(Equal([]infrav1.DeletionStep{
+			infrav1.DeletionStepSSHKeys,
+			infrav1.DeletionStepLoadBalancer,
+			infrav1.DeletionStepOrphanedResources,
+			infrav1.DeletionStepLoadBalancerTargets,
+			infrav1.DeletionStepSubnets,
+			infrav1.DeletionStepNetwork,
+			infrav1.DeletionStepPlacementGroups,
+			infrav1.DeletionStepFloatingIP,
+		}))
+		Expect(conditions.IsTrue(hetznerCluster, infrav1.DeletionStepCondition(infrav1.DeletionStepNetwork))).To(BeTrue())
+		Expect(conditions.GetReason(hetznerCluster, infrav1.DeletionStepCondition(infrav1.DeletionStepFirewalls))).
+			To(Equal(infrav1.DeletionStepSkippedReason))
+	})
+
+	It("waits with the remaining steps after a failed step", func() {
+		steps[infrav1.DeletionStepLoadBalancer] = func(context.Context) error {
+			return errors.New("load balancer is locked")
+		}
+		err := runDeletionSteps(ctx, hetznerCluster, steps)
+		Expect(err).To(MatchError(ContainSubstring("load balancer is locked")))
+
+		Expect(ran).To(Equal([]infrav1.DeletionStep{infrav1.DeletionStepOrphanedResources, infrav1.DeletionStepLoadBalancerTargets}))
+		Expect(conditions.GetReason(hetznerCluster, infrav1.DeletionStepCondition(infrav1.DeletionStepLoadBalancer))).
+			To(Equal(infrav1.DeletionStepFailedReason))
+		Expect(conditions.GetMessage(hetznerCluster, infrav1.DeletionStepCondition(infrav1.DeletionStepNetwork))).
+			To(Equal("waiting for step LoadBalancer"))
+	})
+
+	It("shows the error of a failed step verbatim in its condition", func() {
+		steps[infrav1.DeletionStepNetwork] = func(context.Context) error {
+			return errors.New("network 100%d is in use")
+		}
+		Expect(runDeletionSteps(ctx, hetznerCluster, steps)).ToNot(Succeed())
+		Expect(conditions.GetMessage(hetznerCluster, infrav1.DeletionStepCondition(infrav1.DeletionStepNetwork))).
+			To(Equal("network 100%d is in use"))
+	})
+
+	It("runs the remaining steps after a failed step if the policy continues on errors", func() {
+		hetznerCluster.Spec.DeletionPolicy = &infrav1.ClusterDeletionPolicy{ContinueOnError: true}
+		steps[infrav1.DeletionStepLoadBalancer] = func(context.Context) error {
+			return errors.New("load balancer is locked")
+		}
+		Expect(runDeletionSteps(ctx, hetznerCluster, steps)).ToNot(Succeed())
+
+		Expect(ran).To(HaveLen(len(infrav1.DefaultDeletionOrder) - 1))
+		Expect(conditions.IsTrue(hetznerCluster, infrav1.DeletionStepCondition(infrav1.DeletionStepSSHKeys))).To(BeTrue())
+	})
+})
+
+var _ = Describe("reconcileEgressIPs", func() {
+	It("publishes the egress IPs and keeps them if the discovery fails", func() {
+		healthy := true
+		endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !healthy {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			fmt.Fprintln(w, "203.0.113.10")
+		}))
+		defer endpoint.Close()
+
+		hetznerCluster := &infrav1.HetznerCluster{}
+		r := &HetznerClusterReconciler{EgressIPDiscoverer: egressip.NewDiscoverer([]string{endpoint.URL})}
+		r.reconcileEgressIPs(context.Background(), hetznerCluster)
+		Expect(hetznerCluster.Status.EgressIPs).To(Equal([]string{"203.0.113.10"}))
+		Expect(conditions.IsTrue(hetznerCluster, infrav1.EgressIPsDiscoveredCondition)).To(BeTrue())
+
+		healthy = false
+		r.EgressIPDiscoverer = egressip.NewDiscoverer([]string{endpoint.URL})
+		r.reconcileEgressIPs(context.Background(), hetznerCluster)
+		Expect(hetznerCluster.Status.EgressIPs).To(Equal([]string{"203.0.113.10"}))
+		Expect(conditions.IsFalse(hetznerCluster, infrav1.EgressIPsDiscoveredCondition)).To(BeTrue())
+
+		(&HetznerClusterReconciler{}).reconcileEgressIPs(context.Background(), hetznerCluster)
+		Expect(hetznerCluster.Status.EgressIPs).To(BeEmpty())
+		Expect(conditions.Get(hetznerCluster, infrav1.EgressIPsDiscoveredCondition)).To(BeNil())
+	})
+})
@@ -0,0 +1,178 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/sharding"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/predicates"
+	"sigs.k8s.io/cluster-api/util/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+)
+
+// rolloutCheckInterval is the interval in which a MachineDeployment that is rolling out is checked again.
+const rolloutCheckInterval = time.Minute
+
+// MachineRecyclingReconciler replaces the machines of MachineDeployments that exceed the maximum age
+// set by the annotation MaxMachineAgeAnnotation.
+type MachineRecyclingReconciler struct {
+	client.Client
+	WatchFilterValue string
+	// Shard limits the reconciliation to the namespaces of the shard.
+	Shard sharding.Shard
+}
+
+//+kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machinedeployments,verbs=get;list;watch;patch
+//+kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines,verbs=get;list;watch
+
+// Reconcile triggers a rolling update of a MachineDeployment once its oldest machine exceeds the maximum age.
+// The rolling update is done by Cluster API and respects maxSurge and maxUnavailable of the MachineDeployment.
+func (r *MachineRecyclingReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	machineDeployment := &clusterv1.MachineDeployment{}
+	if err := r.Get(ctx, req.NamespacedName, machineDeployment); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	log = log.WithValues("MachineDeployment", klog.KObj(machineDeployment))
+
+	value, found := machineDeployment.Annotations[infrav1.MaxMachineAgeAnnotation]
+	if !found || !machineDeployment.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	maxAge, err := time.ParseDuration(value)
+	if err != nil || maxAge <= 0 {
+		record.Warnf(machineDeployment, "InvalidMaxMachineAge", "Invalid value %q of annotation %s: has to be a positive duration",
+			value, infrav1.MaxMachineAgeAnnotation)
+		return ctrl.Result{}, nil
+	}
+
+	// Only machines whose infrastructure is managed by this provider are recycled.
+	if machineDeployment.Spec.Template.Spec.InfrastructureRef.GroupVersionKind().Group != infrav1.GroupVersion.Group {
+		return ctrl.Result{}, nil
+	}
+
+	// The template of MachineDeployments of a managed topology is owned by the topology controller.
+	if _, found := machineDeployment.Labels[clusterv1.ClusterTopologyOwnedLabel]; found {
+		record.Warnf(machineDeployment, "UnsupportedMaxMachineAge", "Annotation %s is not supported on MachineDeployments of a managed topology",
+			infrav1.MaxMachineAgeAnnotation)
+		return ctrl.Result{}, nil
+	}
+
+	cluster, err := util.GetClusterByName(ctx, r.Client, machineDeployment.Namespace, machineDeployment.Spec.ClusterName)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to get cluster")
+	}
+	if annotations.IsPaused(cluster, machineDeployment) {
+		log.Info("MachineDeployment or linked Cluster is marked as paused. Won't reconcile")
+		return ctrl.Result{}, nil
+	}
+
+	if isRollingOut(machineDeployment) {
+		return ctrl.Result{RequeueAfter: rolloutCheckInterval}, nil
+	}
+
+	var machines clusterv1.MachineList
+	if err := r.List(ctx, &machines,
+		client.InNamespace(machineDeployment.Namespace),
+		client.MatchingLabels{clusterv1.MachineDeploymentLabelName: machineDeployment.Name},
+	); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to list machines")
+	}
+
+	oldest := oldestMachine(machines.Items)
+	if oldest == nil {
+		return ctrl.Result{RequeueAfter: maxAge}, nil
+	}
+
+	age := time.Since(oldest.CreationTimestamp.Time)
+	if age < maxAge {
+		return ctrl.Result{RequeueAfter: maxAge - age}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(machineDeployment, r.Client)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to init patch helper")
+	}
+
+	if machineDeployment.Spec.Template.Annotations == nil {
+		machineDeployment.Spec.Template.Annotations = make(map[string]string)
+	}
+	machineDeployment.Spec.Template.Annotations[infrav1.RecycledAtAnnotation] = time.Now().UTC().Format(time.RFC3339)
+
+	if err := patchHelper.Patch(ctx, machineDeployment); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to patch MachineDeployment")
+	}
+
+	record.Eventf(machineDeployment, "RecycleMachines", "Machine %s is older than %s - replacing the machines of the MachineDeployment",
+		oldest.Name, maxAge)
+	return ctrl.Result{RequeueAfter: rolloutCheckInterval}, nil
+}
+
+// isRollingOut checks whether the MachineDeployment has not finished its last rolling update yet.
+func isRollingOut(machineDeployment *clusterv1.MachineDeployment) bool {
+	status := machineDeployment.Status
+	if status.ObservedGeneration < machineDeployment.Generation {
+		return true
+	}
+	if machineDeployment.Spec.Replicas != nil && status.UpdatedReplicas < *machineDeployment.Spec.Replicas {
+		return true
+	}
+	return status.Replicas > status.UpdatedReplicas || status.UnavailableReplicas > 0
+}
+
+// oldestMachine returns the oldest machine that is not being deleted.
+func oldestMachine(machines []clusterv1.Machine) *clusterv1.Machine {
+	var oldest *clusterv1.Machine
+	for i := range machines {
+		machine := &machines[i]
+		if !machine.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if oldest == nil || machine.CreationTimestamp.Before(&oldest.CreationTimestamp) {
+			oldest = machine
+		}
+	}
+	return oldest
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *MachineRecyclingReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		WithOptions(options).
+		For(&clusterv1.MachineDeployment{}).
+		WithEventFilter(predicates.ResourceNotPausedAndHasFilterLabel(ctrl.LoggerFrom(ctx), r.WatchFilterValue)).
+		WithEventFilter(r.Shard.Predicate()).
+		Complete(r)
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("oldestMachine", func() {
+	now := time.Now()
+	newMachine := func(name string, age time.Duration, deleting bool) clusterv1.Machine {
+		machine := clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(now.Add(-age)),
+		}}
+		if deleting {
+			machine.DeletionTimestamp = &metav1.Time{Time: now}
+		}
+		return machine
+	}
+
+	It("returns nil without machines", func() {
+		Expect(oldestMachine(nil)).To(BeNil())
+	})
+
+	It("returns the oldest machine that is not being deleted", func() {
+		machines := []clusterv1.Machine{
+			newMachine("young", time.Hour, false),
+			newMachine("deleting", 3*time.Hour, true),
+			newMachine("old", 2*time.Hour, false),
+		}
+		Expect(oldestMachine(machines).Name).To(Equal("old"))
+	})
+})
+
+var _ = Describe("isRollingOut", func() {
+	DescribeTable("isRollingOut",
+		func(generation int64, status clusterv1.MachineDeploymentStatus, expected bool) {
+			machineDeployment := &clusterv1.MachineDeployment{
+				ObjectMeta: metav1.ObjectMeta{Generation: generation},
+				Spec:       clusterv1.MachineDeploymentSpec{Replicas: pointer.Int32(2)},
+				Status:     status,
+			}
+			Expect(isRollingOut(machineDeployment)).To(Equal(expected))
+		},
+		Entry("rolled out", int64(1), clusterv1.MachineDeploymentStatus{ObservedGeneration: 1, Replicas: 2, UpdatedReplicas: 2}, false),
+		Entry("generation not observed", int64(2), clusterv1.MachineDeploymentStatus{ObservedGeneration: 1, Replicas: 2, UpdatedReplicas: 2}, true),
+		Entry("not all replicas updated", int64(1), clusterv1.MachineDeploymentStatus{ObservedGeneration: 1, Replicas: 2, UpdatedReplicas: 1}, true),
+		Entry("old replicas left", int64(1), clusterv1.MachineDeploymentStatus{ObservedGeneration: 1, Replicas: 3, UpdatedReplicas: 2}, true),
+		Entry("unavailable replicas", int64(1), clusterv1.MachineDeploymentStatus{ObservedGeneration: 1, Replicas: 2, UpdatedReplicas: 2, UnavailableReplicas: 1}, true),
+	)
+})
+
+var _ = Describe("MachineRecyclingReconciler", func() {
+	var (
+		r                 *MachineRecyclingReconciler
+		machineDeployment *clusterv1.MachineDeployment
+	)
+
+	newReconciler := func(maxAge string, machineAge time.Duration) {
+		scheme := runtime.NewScheme()
+		Expect(clusterv1.AddToScheme(scheme)).To(Succeed())
+
+		cluster := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default"}}
+		machineDeployment = &clusterv1.MachineDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "md",
+				Namespace:   "default",
+				Annotations: map[string]string{infrav1.MaxMachineAgeAnnotation: maxAge},
+			},
+			Spec: clusterv1.MachineDeploymentSpec{
+				ClusterName: cluster.Name,
+				Replicas:    pointer.Int32(1),
+				Template: clusterv1.MachineTemplateSpec{
+					Spec: clusterv1.MachineSpec{
+						ClusterName: cluster.Name,
+						InfrastructureRef: corev1.ObjectReference{
+							APIVersion: infrav1.GroupVersion.String(),
+							Kind:       "HCloudMachineTemplate",
+							Name:       "md",
+						},
+					},
+				},
+			},
+			Status: clusterv1.MachineDeploymentStatus{Replicas: 1, UpdatedReplicas: 1},
+		}
+		machine := &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "machine",
+				Namespace:         "default",
+				Labels:            map[string]string{clusterv1.MachineDeploymentLabelName: machineDeployment.Name},
+				CreationTimestamp: metav1.NewTime(time.Now().Add(-machineAge)),
+			},
+		}
+
+		r = &MachineRecyclingReconciler{
+			Client: fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(cluster, machineDeployment, machine).Build(),
+		}
+	}
+
+	reconcile := func() ctrl.Result {
+		res, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(machineDeployment)})
+		Expect(err).To(Succeed())
+		return res
+	}
+
+	getTemplateAnnotations := func() map[string]string {
+		var updated clusterv1.MachineDeployment
+		Expect(r.Get(context.Background(), client.ObjectKeyFromObject(machineDeployment), &updated)).To(Succeed())
+		return updated.Spec.Template.Annotations
+	}
+
+	It("requeues until the oldest machine exceeds the maximum age", func() {
+		newReconciler("10h", time.Hour)
+		res := reconcile()
+		Expect(res.RequeueAfter).To(BeNumerically("~", 9*time.Hour, time.Minute))
+		Expect(getTemplateAnnotations()).ToNot(HaveKey(infrav1.RecycledAtAnnotation))
+	})
+
+	It("triggers a rolling update once the oldest machine exceeds the maximum age", func() {
+		newReconciler("10h", 11*time.Hour)
+		res := reconcile()
+		Expect(res.RequeueAfter).To(Equal(rolloutCheckInterval))
+		Expect(getTemplateAnnotations()).To(HaveKey(infrav1.RecycledAtAnnotation))
+	})
+
+	It("does not trigger another rolling update while the last one is in progress", func() {
+		newReconciler("10h", 11*time.Hour)
+		Expect(reconcile().RequeueAfter).To(Equal(rolloutCheckInterval))
+		recycledAt := getTemplateAnnotations()[infrav1.RecycledAtAnnotation]
+		Expect(recycledAt).ToNot(BeEmpty())
+
+		// Cluster API has not replaced the expired machine yet
+		var updated clusterv1.MachineDeployment
+		Expect(r.Get(context.Background(), client.ObjectKeyFromObject(machineDeployment), &updated)).To(Succeed())
+		updated.Spec.Template.Annotations[infrav1.RecycledAtAnnotation] = "2006-01-02T15:04:05Z"
+		updated.Status.UpdatedReplicas = 0
+		Expect(r.Update(context.Background(), &updated)).To(Succeed())
+
+		Expect(reconcile().RequeueAfter).To(Equal(rolloutCheckInterval))
+		Expect(getTemplateAnnotations()).To(HaveKeyWithValue(infrav1.RecycledAtAnnotation, "2006-01-02T15:04:05Z"))
+	})
+
+	It("ignores an invalid maximum age", func() {
+		newReconciler("forever", 11*time.Hour)
+		Expect(reconcile()).To(Equal(ctrl.Result{}))
+		Expect(getTemplateAnnotations()).ToNot(HaveKey(infrav1.RecycledAtAnnotation))
+	})
+})
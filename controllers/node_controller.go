@@ -0,0 +1,249 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	hostservice "github.com/syself/cluster-api-provider-hetzner/pkg/services/baremetal/host"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/topology"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// staleNodeCheckInterval is the interval in which NotReady nodes of bare metal hosts are checked for their machine.
+const staleNodeCheckInterval = time.Minute
+
+// GuestNodeReconciler sets the topology labels on nodes of the workload cluster that run on bare metal hosts
+// and deletes their node objects once the HetznerBareMetalMachine is gone.
+type GuestNodeReconciler struct {
+	client.Client
+	mCluster           ManagementCluster
+	hetznerClusterName string
+}
+
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=hetznerbaremetalhosts,verbs=get;list;watch;update
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=hetznerbaremetalmachines,verbs=get;list;watch
+
+// Reconcile sets the labels derived from the HetznerBareMetalHost on the node and mirrors the health
+// conditions of the node into the HetznerBareMetalHost.
+func (r *GuestNodeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	node := &corev1.Node{}
+	if err := r.Get(ctx, req.NamespacedName, node); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, errors.Wrap(err, "failed to get node")
+	}
+
+	if !isNodeReady(node) {
+		return r.reconcileStaleNode(ctx, node)
+	}
+
+	serverID, ok := topology.BareMetalServerID(node)
+	if !ok {
+		return reconcile.Result{}, nil
+	}
+
+	hosts := &infrav1.HetznerBareMetalHostList{}
+	if err := r.mCluster.List(ctx, hosts, client.InNamespace(r.mCluster.Namespace())); err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to list HetznerBareMetalHosts")
+	}
+
+	var host *infrav1.HetznerBareMetalHost
+	for i := range hosts.Items {
+		if hosts.Items[i].Spec.ServerID == serverID {
+			host = &hosts.Items[i]
+			break
+		}
+	}
+	if host == nil {
+		log.V(1).Info("no HetznerBareMetalHost found for node", "node", node.Name, "serverID", serverID)
+		return reconcile.Result{}, nil
+	}
+	log = log.WithValues("HetznerBareMetalHost", klog.KObj(host))
+
+	if err := r.reconcileHostHealth(ctx, node, host); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	patch := client.MergeFrom(node.DeepCopy())
+	if !topology.SetLabels(node, topology.BareMetalNodeLabels(host)) {
+		return reconcile.Result{}, nil
+	}
+	if err := r.Patch(ctx, node, patch); err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to patch labels of node")
+	}
+	log.Info("Set topology labels of node", "node", node.Name)
+
+	return reconcile.Result{}, nil
+}
+
+// reconcileHostHealth sets the condition HostHealthy of the host from the node conditions that are
+// configured in HostHealthNodeConditions of the HetznerCluster. These are usually set by node-problem-detector
+// and report e.g. disk errors, ECC events or flapping NICs, which SSH reachability alone does not reveal.
+func (r *GuestNodeReconciler) reconcileHostHealth(ctx context.Context, node *corev1.Node, host *infrav1.HetznerBareMetalHost) error {
+	var hetznerCluster infrav1.HetznerCluster
+	key := types.NamespacedName{Namespace: r.mCluster.Namespace(), Name: r.hetznerClusterName}
+	if err := r.mCluster.Get(ctx, key, &hetznerCluster); err != nil {
+		return errors.Wrap(err, "failed to get HetznerCluster")
+	}
+
+	before := conditions.Get(host, infrav1.HostHealthyCondition)
+	if len(hetznerCluster.Spec.HostHealthNodeConditions) == 0 {
+		if before == nil {
+			return nil
+		}
+		conditions.Delete(host, infrav1.HostHealthyCondition)
+	} else {
+		problems := hostProblems(node, hetznerCluster.Spec.HostHealthNodeConditions)
+		if len(problems) == 0 {
+			conditions.MarkTrue(host, infrav1.HostHealthyCondition)
+		} else {
+			conditions.MarkFalse(host, infrav1.HostHealthyCondition, infrav1.HostProblemDetectedReason,
+				clusterv1.ConditionSeverityWarning, strings.Join(problems, "; "))
+		}
+		after := conditions.Get(host, infrav1.HostHealthyCondition)
+		if before != nil && before.Status == after.Status && before.Message == after.Message {
+			return nil
+		}
+		if after.Status == corev1.ConditionFalse {
+			record.Warnf(host, "HostProblemDetected", "Node %s reports problems of the host: %s", node.Name, after.Message)
+		}
+	}
+
+	if err := hostservice.ApplyConditions(ctx, r.mCluster, host, hostservice.NodeControllerFieldOwner, infrav1.HostHealthyCondition); err != nil {
+		return errors.Wrap(err, "failed to update condition HostHealthy of HetznerBareMetalHost")
+	}
+	return nil
+}
+
+// hostProblems returns the node conditions that report a problem in the form "<type>: <message>".
+func hostProblems(node *corev1.Node, conditionTypes []corev1.NodeConditionType) []string {
+	var problems []string
+	for _, conditionType := range conditionTypes {
+		for _, condition := range node.Status.Conditions {
+			if condition.Type != conditionType || condition.Status != corev1.ConditionTrue {
+				continue
+			}
+			problem := string(condition.Type)
+			if condition.Message != "" {
+				problem += ": " + condition.Message
+			}
+			problems = append(problems, problem)
+		}
+	}
+	return problems
+}
+
+// reconcileStaleNode deletes the node if its HetznerBareMetalMachine does not exist anymore. The node is
+// deleted by Cluster API if the machine has a node reference. Without a cloud controller manager that
+// knows Robot servers, the node has no provider ID, is never referenced and would stay NotReady forever.
+func (r *GuestNodeReconciler) reconcileStaleNode(ctx context.Context, node *corev1.Node) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	if !node.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+
+	// The node could belong to an HCloudMachine whose name starts with the prefix
+	var hcloudMachine infrav1.HCloudMachine
+	err := r.mCluster.Get(ctx, types.NamespacedName{Namespace: r.mCluster.Namespace(), Name: node.Name}, &hcloudMachine)
+	if err == nil {
+		return reconcile.Result{}, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return reconcile.Result{}, errors.Wrap(err, "failed to get HCloudMachine")
+	}
+
+	var bmMachine infrav1.HetznerBareMetalMachine
+	bmMachineName := types.NamespacedName{
+		Namespace: r.mCluster.Namespace(),
+		Name:      strings.TrimPrefix(node.Name, infrav1.BareMetalHostNamePrefix),
+	}
+	err = r.mCluster.Get(ctx, bmMachineName, &bmMachine)
+	if err == nil {
+		// Check again later, as the machine might get deleted while the node is NotReady
+		return reconcile.Result{RequeueAfter: staleNodeCheckInterval}, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return reconcile.Result{}, errors.Wrap(err, "failed to get HetznerBareMetalMachine")
+	}
+
+	if err := r.Delete(ctx, node); err != nil && !apierrors.IsNotFound(err) {
+		return reconcile.Result{}, errors.Wrap(err, "failed to delete node")
+	}
+	log.Info("Deleted node of deleted HetznerBareMetalMachine", "node", node.Name, "HetznerBareMetalMachine", bmMachineName)
+
+	return reconcile.Result{}, nil
+}
+
+// isBareMetalNode returns whether the node runs on a bare metal host. Nodes of bare metal hosts have no
+// provider ID if the cloud controller manager does not know Robot servers, so the name is checked as well.
+func isBareMetalNode(node *corev1.Node) bool {
+	if _, ok := topology.BareMetalServerID(node); ok {
+		return true
+	}
+	return node.Spec.ProviderID == "" && strings.HasPrefix(node.Name, infrav1.BareMetalHostNamePrefix)
+}
+
+func isNodeReady(node *corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *GuestNodeReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		WithOptions(options).
+		For(&corev1.Node{}).
+		WithEventFilter(predicate.NewPredicateFuncs(func(o client.Object) bool {
+			node, ok := o.(*corev1.Node)
+			if !ok {
+				return false
+			}
+			return isBareMetalNode(node)
+		})).
+		WithEventFilter(predicate.Funcs{
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				// Deleted nodes do not need labels
+				return false
+			},
+		}).
+		Complete(r)
+}
@@ -0,0 +1,258 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+type fakeManagementCluster struct {
+	client.Client
+	namespace string
+}
+
+func (c *fakeManagementCluster) Namespace() string {
+	return c.namespace
+}
+
+// Patch sends server-side apply patches as merge patches, as the fake client does not support server-side apply.
+func (c *fakeManagementCluster) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if patch.Type() != types.ApplyPatchType {
+		return c.Client.Patch(ctx, obj, patch, opts...)
+	}
+	data, err := patch.Data(obj)
+	if err != nil {
+		return err
+	}
+	return c.Client.Patch(ctx, obj, client.RawPatch(types.MergePatchType, data))
+}
+
+var _ = Describe("hostProblems", func() {
+	node := &corev1.Node{
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+				{Type: "DiskErrors", Status: corev1.ConditionTrue, Message: "I/O error on /dev/nvme0n1"},
+				{Type: "ECCErrors", Status: corev1.ConditionFalse},
+				{Type: "NICFlapping", Status: corev1.ConditionTrue},
+			},
+		},
+	}
+
+	DescribeTable("hostProblems",
+		func(conditionTypes []corev1.NodeConditionType, expectedProblems []string) {
+			Expect(hostProblems(node, conditionTypes)).To(Equal(expectedProblems))
+		},
+		Entry("no condition types", nil, nil),
+		Entry("healthy", []corev1.NodeConditionType{"ECCErrors", "KernelDeadlock"}, nil),
+		Entry("problems", []corev1.NodeConditionType{"NICFlapping", "ECCErrors", "DiskErrors"},
+			[]string{"NICFlapping", "DiskErrors: I/O error on /dev/nvme0n1"}),
+	)
+})
+
+var _ = Describe("GuestNodeReconciler reconcileHostHealth", func() {
+	var (
+		r    *GuestNodeReconciler
+		host *infrav1.HetznerBareMetalHost
+		node *corev1.Node
+	)
+
+	newReconciler := func(conditionTypes []corev1.NodeConditionType) {
+		scheme := runtime.NewScheme()
+		Expect(infrav1.AddToScheme(scheme)).To(Succeed())
+
+		hetznerCluster := &infrav1.HetznerCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "hetzner-cluster", Namespace: "default"},
+			Spec:       infrav1.HetznerClusterSpec{HostHealthNodeConditions: conditionTypes},
+		}
+		host = &infrav1.HetznerBareMetalHost{
+			ObjectMeta: metav1.ObjectMeta{Name: "host", Namespace: "default"},
+			Spec:       infrav1.HetznerBareMetalHostSpec{ServerID: 1},
+		}
+
+		r = &GuestNodeReconciler{
+			mCluster: &fakeManagementCluster{
+				Client:    fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(hetznerCluster, host).Build(),
+				namespace: "default",
+			},
+			hetznerClusterName: hetznerCluster.Name,
+		}
+		Expect(r.mCluster.Get(context.Background(), client.ObjectKeyFromObject(host), host)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		node = &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "bm-machine"},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{
+					{Type: "DiskErrors", Status: corev1.ConditionTrue, Message: "I/O error"},
+				},
+			},
+		}
+	})
+
+	getHost := func() *infrav1.HetznerBareMetalHost {
+		var updated infrav1.HetznerBareMetalHost
+		Expect(r.mCluster.Get(context.Background(), client.ObjectKeyFromObject(host), &updated)).To(Succeed())
+		return &updated
+	}
+
+	It("does not set the condition if no node conditions are configured", func() {
+		newReconciler(nil)
+		Expect(r.reconcileHostHealth(context.Background(), node, host)).To(Succeed())
+		Expect(conditions.Has(getHost(), infrav1.HostHealthyCondition)).To(BeFalse())
+	})
+
+	It("marks the host as unhealthy if a configured node condition reports a problem", func() {
+		newReconciler([]corev1.NodeConditionType{"DiskErrors"})
+		Expect(r.reconcileHostHealth(context.Background(), node, host)).To(Succeed())
+
+		updated := getHost()
+		Expect(conditions.IsFalse(updated, infrav1.HostHealthyCondition)).To(BeTrue())
+		Expect(conditions.GetReason(updated, infrav1.HostHealthyCondition)).To(Equal(infrav1.HostProblemDetectedReason))
+		Expect(conditions.GetMessage(updated, infrav1.HostHealthyCondition)).To(Equal("DiskErrors: I/O error"))
+	})
+
+	It("marks the host as healthy once the problem is gone", func() {
+		newReconciler([]corev1.NodeConditionType{"DiskErrors"})
+		Expect(r.reconcileHostHealth(context.Background(), node, host)).To(Succeed())
+
+		node.Status.Conditions[0].Status = corev1.ConditionFalse
+		host = getHost()
+		Expect(r.reconcileHostHealth(context.Background(), node, host)).To(Succeed())
+		Expect(conditions.IsTrue(getHost(), infrav1.HostHealthyCondition)).To(BeTrue())
+	})
+})
+
+var _ = DescribeTable("isBareMetalNode",
+	func(name, providerID string, expected bool) {
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       corev1.NodeSpec{ProviderID: providerID},
+		}
+		Expect(isBareMetalNode(node)).To(Equal(expected))
+	},
+	Entry("bare metal node with provider ID", "bm-machine", "hcloud://bm-42", true),
+	Entry("bare metal node without provider ID", "bm-machine", "", true),
+	Entry("cloud node", "hcloud-machine", "hcloud://42", false),
+	Entry("cloud node whose name has the prefix", "bm-machine", "hcloud://42", false),
+	Entry("other node without provider ID", "machine", "", false),
+)
+
+var _ = Describe("GuestNodeReconciler reconcileStaleNode", func() {
+	var (
+		r              *GuestNodeReconciler
+		workloadClient client.Client
+		node           *corev1.Node
+	)
+
+	newReconciler := func(objects ...client.Object) {
+		scheme := runtime.NewScheme()
+		Expect(infrav1.AddToScheme(scheme)).To(Succeed())
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+
+		workloadClient = fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(node).Build()
+		r = &GuestNodeReconciler{
+			Client: workloadClient,
+			mCluster: &fakeManagementCluster{
+				Client:    fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+				namespace: "default",
+			},
+		}
+	}
+
+	nodeExists := func() bool {
+		err := workloadClient.Get(context.Background(), client.ObjectKeyFromObject(node), &corev1.Node{})
+		if apierrors.IsNotFound(err) {
+			return false
+		}
+		Expect(err).To(Succeed())
+		return true
+	}
+
+	BeforeEach(func() {
+		node = &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "bm-machine"},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionUnknown}},
+			},
+		}
+	})
+
+	It("deletes the node of a deleted HetznerBareMetalMachine", func() {
+		node.Spec.ProviderID = "hcloud://bm-42"
+		newReconciler()
+
+		res, err := r.reconcileStaleNode(context.Background(), node)
+		Expect(err).To(Succeed())
+		Expect(res).To(Equal(reconcile.Result{}))
+		Expect(nodeExists()).To(BeFalse())
+	})
+
+	It("deletes the node without provider ID of a deleted HetznerBareMetalMachine", func() {
+		newReconciler()
+
+		_, err := r.reconcileStaleNode(context.Background(), node)
+		Expect(err).To(Succeed())
+		Expect(nodeExists()).To(BeFalse())
+	})
+
+	It("keeps the node while its HetznerBareMetalMachine exists", func() {
+		newReconciler(&infrav1.HetznerBareMetalMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: "machine", Namespace: "default"},
+		})
+
+		res, err := r.reconcileStaleNode(context.Background(), node)
+		Expect(err).To(Succeed())
+		Expect(res).To(Equal(reconcile.Result{RequeueAfter: staleNodeCheckInterval}))
+		Expect(nodeExists()).To(BeTrue())
+	})
+
+	It("keeps the node of an HCloudMachine whose name has the prefix", func() {
+		newReconciler(&infrav1.HCloudMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: "bm-machine", Namespace: "default"},
+		})
+
+		_, err := r.reconcileStaleNode(context.Background(), node)
+		Expect(err).To(Succeed())
+		Expect(nodeExists()).To(BeTrue())
+	})
+
+	It("keeps a node that is being deleted", func() {
+		now := metav1.Now()
+		node.DeletionTimestamp = &now
+		node.Finalizers = []string{"test"}
+		newReconciler()
+
+		_, err := r.reconcileStaleNode(context.Background(), node)
+		Expect(err).To(Succeed())
+		Expect(nodeExists()).To(BeTrue())
+	})
+})
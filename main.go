@@ -21,15 +21,20 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 	"sync"
 
 	// +kubebuilder:scaffold:imports
 	infrastructurev1beta1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
 	"github.com/syself/cluster-api-provider-hetzner/controllers"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/compatibility"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/egressip"
+	caphmetrics "github.com/syself/cluster-api-provider-hetzner/pkg/metrics"
 	secretutil "github.com/syself/cluster-api-provider-hetzner/pkg/secrets"
 	robotclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/baremetal/client/robot"
 	sshclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/baremetal/client/ssh"
 	hcloudclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/sharding"
 	"github.com/syself/cluster-api-provider-hetzner/pkg/utils"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -41,6 +46,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 )
 
 var (
@@ -57,13 +63,19 @@ func init() {
 }
 
 var (
-	metricsAddr          string
-	enableLeaderElection bool
-	verbose              bool
-	probeAddr            string
-	watchFilterValue     string
-	watchNamespace       string
-	logLevel             string
+	metricsAddr              string
+	enableLeaderElection     bool
+	verbose                  bool
+	probeAddr                string
+	watchFilterValue         string
+	watchNamespace           string
+	logLevel                 string
+	hcloudMachineConcurrency int
+	dryRun                   bool
+	compatibilityPolicy      string
+	leaderElectionID         string
+	shard                    sharding.Shard
+	egressIPEndpoints        string
 )
 
 func main() {
@@ -74,18 +86,36 @@ func main() {
 	flag.StringVar(&watchFilterValue, "watch-filter", "", fmt.Sprintf("Label value that the controller watches to reconcile cluster-api objects. Label key is always %s. If unspecified, the controller watches for all cluster-api objects.", clusterv1.WatchLabel))
 	flag.StringVar(&watchNamespace, "namespace", "", "Namespace that the controller watches to reconcile cluster-api objects. If unspecified, the controller watches for cluster-api objects across all namespaces.")
 	flag.StringVar(&logLevel, "log-level", "debug", "Specifies log level. Options are 'debug', 'info' and 'error'")
+	flag.IntVar(&hcloudMachineConcurrency, "hcloudmachine-concurrency", 1, "Number of HCloudMachines that are reconciled in parallel. Higher values speed up large scale-ups.")
+	flag.BoolVar(&dryRun, "dry-run", false, fmt.Sprintf("Record the mutations of servers and other resources in HCloud and Robot as events instead of executing them. Single clusters can be put into dry-run mode with the annotation %s.", infrastructurev1beta1.DryRunAnnotation))
+	flag.StringVar(&compatibilityPolicy, "kubernetes-compatibility-policy", string(compatibility.PolicyWarn), "What happens if the Kubernetes version of a Machine is not supported by the operating system of its image according to the tested compatibility matrix. Options are 'Ignore', 'Warn' and 'Deny'.")
+	flag.StringVar(&leaderElectionID, "leader-election-id", "hetzner.cluster.x-k8s.io", "Name of the lease of the leader election. Managers that reconcile different resources, e.g. with different values of --watch-filter, need different IDs.")
+	flag.IntVar(&shard.Count, "shard-count", 1, "Number of managers that the namespaces are distributed to by the hash of their name. Each manager reconciles the namespaces of its shard and holds its own leader election.")
+	flag.IntVar(&shard.Index, "shard-index", 0, "Index of the shard of the manager, from 0 to --shard-count minus 1.")
+	flag.StringVar(&egressIPEndpoints, "egress-ip-discovery-endpoints", "", "Comma-separated URLs of endpoints that answer with the public IP of the client, e.g. https://ipv4.icanhazip.com. The discovered egress IPs of the controllers are published in the status of the HetznerClusters. If unspecified, the egress IPs are not discovered.")
 
 	flag.Parse()
 
 	ctrl.SetLogger(utils.GetDefaultLogger(logLevel))
 
+	policy, err := compatibility.ParsePolicy(compatibilityPolicy)
+	if err != nil {
+		setupLog.Error(err, "invalid flag kubernetes-compatibility-policy")
+		os.Exit(1)
+	}
+
+	if err = shard.Validate(); err != nil {
+		setupLog.Error(err, "invalid flags shard-count and shard-index")
+		os.Exit(1)
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                     scheme,
 		MetricsBindAddress:         metricsAddr,
 		Port:                       9443,
 		HealthProbeBindAddress:     probeAddr,
 		LeaderElection:             enableLeaderElection,
-		LeaderElectionID:           "hetzner.cluster.x-k8s.io",
+		LeaderElectionID:           shard.LeaderElectionID(leaderElectionID),
 		LeaderElectionResourceLock: "leases",
 		Namespace:                  watchNamespace,
 		NewCache: cache.BuilderWithOptions(cache.Options{
@@ -100,6 +130,10 @@ func main() {
 	// Initialize event recorder.
 	record.InitFromRecorder(mgr.GetEventRecorderFor("hetzner-controller"))
 
+	// Report the machines and hosts per phase and the certificates of the nodes from the cache of the manager.
+	ctrlmetrics.Registry.MustRegister(caphmetrics.NewPhaseCollector(mgr.GetClient()))
+	ctrlmetrics.Registry.MustRegister(caphmetrics.NewCertificateCollector(mgr.GetClient()))
+
 	// Setup the context that's going to be used in controllers and for the manager.
 	ctx := ctrl.SetupSignalHandler()
 
@@ -113,7 +147,10 @@ func main() {
 		APIReader:                      mgr.GetAPIReader(),
 		HCloudClientFactory:            hcloudClientFactory,
 		WatchFilterValue:               watchFilterValue,
+		Shard:                          shard,
 		TargetClusterManagersWaitGroup: &wg,
+		DryRun:                         dryRun,
+		EgressIPDiscoverer:             egressip.NewDiscoverer(splitList(egressIPEndpoints)),
 	}).SetupWithManager(ctx, mgr, controller.Options{}); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "HetznerCluster")
 		os.Exit(1)
@@ -124,7 +161,9 @@ func main() {
 		APIReader:           mgr.GetAPIReader(),
 		HCloudClientFactory: hcloudClientFactory,
 		WatchFilterValue:    watchFilterValue,
-	}).SetupWithManager(ctx, mgr, controller.Options{}); err != nil {
+		Shard:               shard,
+		DryRun:              dryRun,
+	}).SetupWithManager(ctx, mgr, controller.Options{MaxConcurrentReconciles: hcloudMachineConcurrency}); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "HCloudMachine")
 		os.Exit(1)
 	}
@@ -134,17 +173,44 @@ func main() {
 		APIReader:           mgr.GetAPIReader(),
 		HCloudClientFactory: hcloudClientFactory,
 		WatchFilterValue:    watchFilterValue,
+		Shard:               shard,
 	}).SetupWithManager(ctx, mgr, controller.Options{}); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "HCloudMachineTemplate")
 		os.Exit(1)
 	}
 
+	if err = (&controllers.HCloudPrimaryIPReconciler{
+		Client:              mgr.GetClient(),
+		APIReader:           mgr.GetAPIReader(),
+		HCloudClientFactory: hcloudClientFactory,
+		WatchFilterValue:    watchFilterValue,
+		Shard:               shard,
+		DryRun:              dryRun,
+	}).SetupWithManager(ctx, mgr, controller.Options{}); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "HCloudPrimaryIP")
+		os.Exit(1)
+	}
+
+	if err = (&controllers.HCloudMachineImageReconciler{
+		Client:              mgr.GetClient(),
+		APIReader:           mgr.GetAPIReader(),
+		HCloudClientFactory: hcloudClientFactory,
+		WatchFilterValue:    watchFilterValue,
+		Shard:               shard,
+		DryRun:              dryRun,
+	}).SetupWithManager(ctx, mgr, controller.Options{}); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "HCloudMachineImage")
+		os.Exit(1)
+	}
+
 	if err = (&controllers.HetznerBareMetalHostReconciler{
 		Client:             mgr.GetClient(),
 		RobotClientFactory: robotclient.NewFactory(),
 		SSHClientFactory:   sshclient.NewFactory(),
 		APIReader:          mgr.GetAPIReader(),
 		WatchFilterValue:   watchFilterValue,
+		Shard:              shard,
+		DryRun:             dryRun,
 	}).SetupWithManager(ctx, mgr, controller.Options{}); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "HetznerBareMetalHost")
 		os.Exit(1)
@@ -155,6 +221,8 @@ func main() {
 		APIReader:           mgr.GetAPIReader(),
 		HCloudClientFactory: hcloudClientFactory,
 		WatchFilterValue:    watchFilterValue,
+		Shard:               shard,
+		DryRun:              dryRun,
 	}).SetupWithManager(ctx, mgr, controller.Options{}); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "HetznerBareMetalMachine")
 		os.Exit(1)
@@ -163,12 +231,22 @@ func main() {
 	if err = (&controllers.HetznerBareMetalRemediationReconciler{
 		Client:           mgr.GetClient(),
 		WatchFilterValue: watchFilterValue,
+		Shard:            shard,
 	}).SetupWithManager(ctx, mgr, controller.Options{}); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "HetznerBareMetalRemediation")
 		os.Exit(1)
 	}
 
-	setUpWebhookWithManager(mgr)
+	if err = (&controllers.MachineRecyclingReconciler{
+		Client:           mgr.GetClient(),
+		WatchFilterValue: watchFilterValue,
+		Shard:            shard,
+	}).SetupWithManager(ctx, mgr, controller.Options{}); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "MachineRecycling")
+		os.Exit(1)
+	}
+
+	setUpWebhookWithManager(mgr, policy)
 
 	//+kubebuilder:scaffold:builder
 
@@ -193,7 +271,7 @@ func main() {
 	wg.Wait()
 }
 
-func setUpWebhookWithManager(mgr ctrl.Manager) {
+func setUpWebhookWithManager(mgr ctrl.Manager, compatibilityPolicy compatibility.Policy) {
 	if err := (&infrastructurev1beta1.HetznerCluster{}).SetupWebhookWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "HetznerCluster")
 		os.Exit(1)
@@ -210,6 +288,18 @@ func setUpWebhookWithManager(mgr ctrl.Manager) {
 		setupLog.Error(err, "unable to create webhook", "webhook", "HCloudMachineTemplate")
 		os.Exit(1)
 	}
+	if err := (&infrastructurev1beta1.HCloudPrimaryIP{}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "HCloudPrimaryIP")
+		os.Exit(1)
+	}
+	if err := (&infrastructurev1beta1.HetznerNodeProfile{}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "HetznerNodeProfile")
+		os.Exit(1)
+	}
+	if err := (&infrastructurev1beta1.HCloudMachineImage{}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "HCloudMachineImage")
+		os.Exit(1)
+	}
 	if err := (&infrastructurev1beta1.HetznerBareMetalHost{}).SetupWebhookWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "HetznerBareMetalHost")
 		os.Exit(1)
@@ -230,4 +320,22 @@ func setUpWebhookWithManager(mgr ctrl.Manager) {
 		setupLog.Error(err, "unable to create webhook", "webhook", "HetznerBareMetalRemediationTemplate")
 		os.Exit(1)
 	}
+	if err := (&infrastructurev1beta1.MachineCompatibilityWebhook{
+		Client: mgr.GetAPIReader(),
+		Policy: compatibilityPolicy,
+	}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "MachineCompatibility")
+		os.Exit(1)
+	}
+}
+
+// splitList splits a comma-separated flag value and drops empty entries.
+func splitList(value string) []string {
+	var list []string
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+	return list
 }
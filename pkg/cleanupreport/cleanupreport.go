@@ -0,0 +1,261 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cleanupreport records the HCloud resources of a deleted HetznerCluster and the result of their
+// cleanup in a ConfigMap, so that audits can confirm that nothing billable was left behind.
+package cleanupreport
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/cluster-api/util/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// reportKey is the key of the report in the data of the ConfigMap.
+const reportKey = "report.yaml"
+
+// Result is the result of the cleanup of a resource.
+type Result string
+
+const (
+	// ResultPending means that the resource has not been cleaned up yet.
+	ResultPending = Result("pending")
+	// ResultDeleted means that the resource has been deleted.
+	ResultDeleted = Result("deleted")
+	// ResultRetained means that the resource has been kept on purpose, e.g. a protected load balancer.
+	ResultRetained = Result("retained")
+	// ResultOrphaned means that the resource has been left behind, e.g. by a force cleanup.
+	ResultOrphaned = Result("orphaned")
+)
+
+// Resource is an HCloud resource of the cluster.
+type Resource struct {
+	// Type of the resource, e.g. network or load balancer.
+	Type string `json:"type"`
+	// ID of the resource in HCloud.
+	ID int `json:"id"`
+	// Name of the resource, or of the object that owned it.
+	Name string `json:"name,omitempty"`
+	// Result of the cleanup.
+	Result Result `json:"result"`
+}
+
+// Report lists the HCloud resources of a deleted HetznerCluster.
+type Report struct {
+	Cluster     string       `json:"cluster"`
+	StartedAt   metav1.Time  `json:"startedAt"`
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+	Resources   []Resource   `json:"resources"`
+}
+
+// ConfigMapName returns the name of the ConfigMap with the cleanup report of a HetznerCluster.
+func ConfigMapName(hetznerCluster *infrav1.HetznerCluster) string {
+	return hetznerCluster.Name + "-cleanup-report"
+}
+
+// Resources returns the HCloud resources in the status of the HetznerCluster. A protected load balancer is
+// retained, all other resources are pending.
+func Resources(hetznerCluster *infrav1.HetznerCluster) []Resource {
+	var resources []Resource
+	status := hetznerCluster.Status
+	if status.Network != nil && status.Network.ID != 0 {
+		resources = append(resources, Resource{Type: "network", ID: status.Network.ID, Result: ResultPending})
+	}
+	if lb := status.ControlPlaneLoadBalancer; lb != nil && lb.ID != 0 {
+		result := ResultPending
+		if lb.Protected {
+			result = ResultRetained
+		}
+		resources = append(resources, Resource{Type: "load balancer", ID: lb.ID, Result: result})
+	}
+	if status.ControlPlaneFloatingIP != nil && status.ControlPlaneFloatingIP.ID != 0 {
+		resources = append(resources, Resource{
+			Type: "floating IP", ID: status.ControlPlaneFloatingIP.ID, Name: status.ControlPlaneFloatingIP.IP, Result: ResultPending,
+		})
+	}
+	for _, pg := range status.HCloudPlacementGroup {
+		resources = append(resources, Resource{Type: "placement group", ID: pg.ID, Name: pg.Name, Result: ResultPending})
+	}
+	for _, fw := range status.HCloudFirewalls {
+		resources = append(resources, Resource{Type: "firewall", ID: fw.ID, Name: fw.Name, Result: ResultPending})
+	}
+	for _, res := range status.OrphanedResources {
+		resources = append(resources, Resource{Type: string(res.Type), ID: res.ID, Name: res.Name, Result: ResultPending})
+	}
+	return resources
+}
+
+// Merge adds the resources that are not in the report yet. Resources that are part of the report are kept with
+// their result, as the status of the HetznerCluster drops resources once they are deleted.
+func (r *Report) Merge(resources []Resource) {
+	for _, resource := range resources {
+		var found bool
+		for _, existing := range r.Resources {
+			if existing.Type == resource.Type && existing.ID == resource.ID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			r.Resources = append(r.Resources, resource)
+		}
+	}
+}
+
+// Complete sets the result of the pending resources that are still in the status of the HetznerCluster and the
+// completion time. Pending resources that have been dropped from the status have been deleted.
+func (r *Report) Complete(result Result, remaining []Resource, now metav1.Time) {
+	for i := range r.Resources {
+		resource := &r.Resources[i]
+		if resource.Result != ResultPending {
+			continue
+		}
+		resource.Result = ResultDeleted
+		for _, other := range remaining {
+			if other.Type == resource.Type && other.ID == resource.ID {
+				resource.Result = result
+				break
+			}
+		}
+	}
+	r.CompletedAt = &now
+}
+
+// Summary returns the resources of the report grouped by their result, e.g.
+// "deleted: network 1, firewall 2 (workers); retained: load balancer 3".
+func (r *Report) Summary() string {
+	var parts []string
+	for _, result := range []Result{ResultDeleted, ResultRetained, ResultOrphaned, ResultPending} {
+		var resources []string
+		for _, resource := range r.Resources {
+			if resource.Result != result {
+				continue
+			}
+			entry := fmt.Sprintf("%s %d", resource.Type, resource.ID)
+			if resource.Name != "" {
+				entry += fmt.Sprintf(" (%s)", resource.Name)
+			}
+			resources = append(resources, entry)
+		}
+		if len(resources) > 0 {
+			parts = append(parts, fmt.Sprintf("%s: %s", result, strings.Join(resources, ", ")))
+		}
+	}
+	if len(parts) == 0 {
+		return "no HCloud resources"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Reporter writes the cleanup reports of HetznerClusters. The ConfigMaps are read with the API reader, so that
+// the manager does not have to cache all ConfigMaps.
+type Reporter struct {
+	client    client.Client
+	apiReader client.Reader
+}
+
+// NewReporter returns a new Reporter.
+func NewReporter(c client.Client, apiReader client.Reader) *Reporter {
+	return &Reporter{client: c, apiReader: apiReader}
+}
+
+// Record adds the resources of the HetznerCluster to its report, which is created at the start of the deletion.
+// It has to be called before the resources are deleted.
+func (r *Reporter) Record(ctx context.Context, hetznerCluster *infrav1.HetznerCluster) error {
+	return r.update(ctx, hetznerCluster, func(report *Report) {
+		report.Merge(Resources(hetznerCluster))
+	})
+}
+
+// Complete sets the result of the pending resources of the report and records the report in an event.
+func (r *Reporter) Complete(ctx context.Context, hetznerCluster *infrav1.HetznerCluster, result Result) error {
+	var summary string
+	if err := r.update(ctx, hetznerCluster, func(report *Report) {
+		remaining := Resources(hetznerCluster)
+		report.Merge(remaining)
+		report.Complete(result, remaining, metav1.Now())
+		summary = report.Summary()
+	}); err != nil {
+		return err
+	}
+
+	record.Eventf(hetznerCluster, "HetznerClusterCleanupReport",
+		"Cleanup of HCloud resources completed, see ConfigMap %s: %s", ConfigMapName(hetznerCluster), summary)
+	return nil
+}
+
+func (r *Reporter) update(ctx context.Context, hetznerCluster *infrav1.HetznerCluster, mutate func(*Report)) error {
+	key := client.ObjectKey{Namespace: hetznerCluster.Namespace, Name: ConfigMapName(hetznerCluster)}
+	configMap := &corev1.ConfigMap{}
+	err := r.apiReader.Get(ctx, key, configMap)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to get cleanup report %s", key)
+	}
+	exists := err == nil
+
+	report := &Report{Cluster: hetznerCluster.Name, StartedAt: metav1.Now()}
+	if exists {
+		if err := yaml.Unmarshal([]byte(configMap.Data[reportKey]), report); err != nil {
+			return errors.Wrapf(err, "failed to unmarshal cleanup report %s", key)
+		}
+		// the report of a previous cluster with the same name is replaced
+		if report.CompletedAt != nil && report.CompletedAt.Before(hetznerCluster.GetDeletionTimestamp()) {
+			report = &Report{Cluster: hetznerCluster.Name, StartedAt: metav1.Now()}
+		}
+	}
+	mutate(report)
+
+	data, err := yaml.Marshal(report)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal cleanup report")
+	}
+
+	if !exists {
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      key.Name,
+				Namespace: key.Namespace,
+				Labels:    map[string]string{infrav1.CleanupReportLabel: hetznerCluster.Name},
+			},
+			Data: map[string]string{reportKey: string(data)},
+		}
+		if err := r.client.Create(ctx, configMap); err != nil {
+			return errors.Wrapf(err, "failed to create cleanup report %s", key)
+		}
+		return nil
+	}
+
+	if configMap.Data[reportKey] == string(data) {
+		return nil
+	}
+	if configMap.Data == nil {
+		configMap.Data = make(map[string]string)
+	}
+	configMap.Data[reportKey] = string(data)
+	if err := r.client.Update(ctx, configMap); err != nil {
+		return errors.Wrapf(err, "failed to update cleanup report %s", key)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cleanupreport_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/cleanupreport"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+)
+
+var _ = Describe("Report", func() {
+	It("keeps the result of resources that are recorded already", func() {
+		report := &cleanupreport.Report{Resources: []cleanupreport.Resource{
+			{Type: "network", ID: 1, Result: cleanupreport.ResultDeleted},
+		}}
+		report.Merge([]cleanupreport.Resource{
+			{Type: "network", ID: 1, Result: cleanupreport.ResultPending},
+			{Type: "firewall", ID: 2, Name: "workers", Result: cleanupreport.ResultPending},
+		})
+		Expect(report.Resources).To(Equal([]cleanupreport.Resource{
+			{Type: "network", ID: 1, Result: cleanupreport.ResultDeleted},
+			{Type: "firewall", ID: 2, Name: "workers", Result: cleanupreport.ResultPending},
+		}))
+	})
+
+	It("marks pending resources that are no longer in the status as deleted", func() {
+		report := &cleanupreport.Report{Resources: []cleanupreport.Resource{
+			{Type: "load balancer", ID: 1, Result: cleanupreport.ResultPending},
+			{Type: "network", ID: 2, Result: cleanupreport.ResultPending},
+			{Type: "load balancer", ID: 3, Result: cleanupreport.ResultRetained},
+		}}
+		report.Complete(cleanupreport.ResultOrphaned, []cleanupreport.Resource{{Type: "network", ID: 2}}, metav1.Now())
+
+		Expect(report.CompletedAt).ToNot(BeNil())
+		Expect(report.Summary()).To(Equal("deleted: load balancer 1; retained: load balancer 3; orphaned: network 2"))
+	})
+
+	It("retains protected load balancers", func() {
+		hetznerCluster := &infrav1.HetznerCluster{Status: infrav1.HetznerClusterStatus{
+			ControlPlaneLoadBalancer: &infrav1.LoadBalancerStatus{ID: 1, Protected: true},
+			HCloudFirewalls:          []infrav1.HCloudFirewallStatus{{ID: 2, Name: "workers"}},
+		}}
+		Expect(cleanupreport.Resources(hetznerCluster)).To(Equal([]cleanupreport.Resource{
+			{Type: "load balancer", ID: 1, Result: cleanupreport.ResultRetained},
+			{Type: "firewall", ID: 2, Name: "workers", Result: cleanupreport.ResultPending},
+		}))
+	})
+})
+
+var _ = Describe("Reporter", func() {
+	var c client.Client
+	var hetznerCluster *infrav1.HetznerCluster
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		utilruntime.Must(corev1.AddToScheme(scheme))
+		c = fakeclient.NewClientBuilder().WithScheme(scheme).Build()
+		hetznerCluster = &infrav1.HetznerCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "cluster",
+				Namespace:         "default",
+				DeletionTimestamp: &metav1.Time{Time: time.Now()},
+			},
+			Status: infrav1.HetznerClusterStatus{
+				Network:                  &infrav1.NetworkStatus{ID: 1},
+				ControlPlaneLoadBalancer: &infrav1.LoadBalancerStatus{ID: 2},
+			},
+		}
+	})
+
+	report := func() *cleanupreport.Report {
+		configMap := &corev1.ConfigMap{}
+		Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "cluster-cleanup-report"}, configMap)).To(Succeed())
+		Expect(configMap.Labels).To(HaveKeyWithValue(infrav1.CleanupReportLabel, "cluster"))
+		var r cleanupreport.Report
+		Expect(yaml.Unmarshal([]byte(configMap.Data["report.yaml"]), &r)).To(Succeed())
+		return &r
+	}
+
+	It("reports the resources that have been dropped from the status during the deletion", func() {
+		reporter := cleanupreport.NewReporter(c, c)
+		Expect(reporter.Record(context.Background(), hetznerCluster)).To(Succeed())
+		Expect(report().CompletedAt).To(BeNil())
+
+		// the load balancer is deleted before the deletion of the network fails
+		hetznerCluster.Status.ControlPlaneLoadBalancer = nil
+		Expect(reporter.Record(context.Background(), hetznerCluster)).To(Succeed())
+		Expect(report().Resources).To(HaveLen(2))
+
+		Expect(reporter.Complete(context.Background(), hetznerCluster, cleanupreport.ResultDeleted)).To(Succeed())
+		r := report()
+		Expect(r.CompletedAt).ToNot(BeNil())
+		Expect(r.Summary()).To(Equal("deleted: network 1, load balancer 2"))
+	})
+})
@@ -0,0 +1,119 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package compatibility contains the matrix of the Kubernetes versions that have been tested with the
+// operating systems of the images that the provider knows, e.g. the images of HCloud and of installimage.
+package compatibility
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"github.com/pkg/errors"
+)
+
+// Policy defines what happens at admission if the Kubernetes version of a Machine is not supported by its image.
+type Policy string
+
+const (
+	// PolicyIgnore does not check the Kubernetes version.
+	PolicyIgnore Policy = "Ignore"
+	// PolicyWarn admits the Machine with a warning.
+	PolicyWarn Policy = "Warn"
+	// PolicyDeny rejects the Machine.
+	PolicyDeny Policy = "Deny"
+)
+
+// ParsePolicy returns the policy with the given name, ignoring the case.
+func ParsePolicy(name string) (Policy, error) {
+	for _, policy := range []Policy{PolicyIgnore, PolicyWarn, PolicyDeny} {
+		if strings.EqualFold(name, string(policy)) {
+			return policy, nil
+		}
+	}
+	return "", errors.Errorf("unknown compatibility policy %q, expected Ignore, Warn or Deny", name)
+}
+
+// Profile is an operating system with the range of Kubernetes minor versions that have been tested with it.
+type Profile struct {
+	// Name is the name of the operating system.
+	Name string
+	// image matches the names of the images of the operating system.
+	image *regexp.Regexp
+	// MinVersion and MaxVersion are the oldest and newest supported minor versions.
+	MinVersion semver.Version
+	MaxVersion semver.Version
+}
+
+// Matrix is the tested compatibility matrix. Images of HCloud are named like ubuntu-22.04, images of installimage
+// like Ubuntu-2204-jammy-amd64-base.
+var Matrix = []Profile{
+	newProfile("ubuntu-20.04", `^ubuntu-?20\.?04`, "1.23", "1.26"),
+	newProfile("ubuntu-22.04", `^ubuntu-?22\.?04`, "1.24", "1.26"),
+	newProfile("debian-11", `^debian-?11`, "1.23", "1.26"),
+	newProfile("fedora-35", `^fedora-?35`, "1.23", "1.25"),
+}
+
+func newProfile(name, image, minVersion, maxVersion string) Profile {
+	return Profile{
+		Name:       name,
+		image:      regexp.MustCompile(`(?i)` + image),
+		MinVersion: semver.MustParse(minVersion + ".0"),
+		MaxVersion: semver.MustParse(maxVersion + ".0"),
+	}
+}
+
+// ProfileForImage returns the profile of the image, or nil if the image is unknown, e.g. a custom snapshot.
+// For URLs and paths, the file name is used.
+func ProfileForImage(image string) *Profile {
+	name := path.Base(image)
+	for i := range Matrix {
+		if Matrix[i].image.MatchString(name) {
+			return &Matrix[i]
+		}
+	}
+	return nil
+}
+
+// Supports returns whether the profile supports the minor version of the Kubernetes version, e.g. v1.25.2.
+func (p *Profile) Supports(version string) (bool, error) {
+	v, err := semver.ParseTolerant(version)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to parse Kubernetes version %q", version)
+	}
+	minor := semver.Version{Major: v.Major, Minor: v.Minor}
+	return minor.GTE(p.MinVersion) && minor.LTE(p.MaxVersion), nil
+}
+
+// Check returns an error if the Kubernetes version is not supported by the image. Unknown images are not checked.
+func Check(image, version string) error {
+	profile := ProfileForImage(image)
+	if profile == nil {
+		return nil
+	}
+	supported, err := profile.Supports(version)
+	if err != nil {
+		return err
+	}
+	if !supported {
+		return errors.Errorf("kubernetes version %s is not supported by image %s (%s), supported versions are v%d.%d to v%d.%d",
+			version, image, profile.Name,
+			profile.MinVersion.Major, profile.MinVersion.Minor, profile.MaxVersion.Major, profile.MaxVersion.Minor)
+	}
+	return nil
+}
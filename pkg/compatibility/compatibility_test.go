@@ -0,0 +1,52 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compatibility_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/compatibility"
+)
+
+var _ = DescribeTable("Check",
+	func(image, version string, supported bool) {
+		err := compatibility.Check(image, version)
+		if supported {
+			Expect(err).To(Succeed())
+		} else {
+			Expect(err).To(HaveOccurred())
+		}
+	},
+	Entry("HCloud image", "ubuntu-22.04", "v1.25.2", true),
+	Entry("too old version", "ubuntu-22.04", "v1.23.4", false),
+	Entry("too new version", "fedora-35", "v1.26.0", false),
+	Entry("installimage path", "/root/.oldroot/nfs/install/../images/Ubuntu-2004-focal-64-minimal-hwe.tar.gz", "v1.26.1", true),
+	Entry("installimage name", "Ubuntu-2204-jammy-amd64-base", "v1.27.0", false),
+	Entry("unknown image", "my-snapshot", "v1.20.0", true),
+	Entry("invalid version", "debian-11", "latest", false),
+)
+
+var _ = Describe("ParsePolicy", func() {
+	It("ignores the case", func() {
+		Expect(compatibility.ParsePolicy("deny")).To(Equal(compatibility.PolicyDeny))
+	})
+
+	It("fails for unknown policies", func() {
+		_, err := compatibility.ParsePolicy("Enforce")
+		Expect(err).To(HaveOccurred())
+	})
+})
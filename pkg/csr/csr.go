@@ -22,6 +22,7 @@ import (
 	"crypto/x509/pkix"
 	"encoding/asn1"
 	"fmt"
+	"net"
 	"reflect"
 	"strings"
 
@@ -84,7 +85,10 @@ func ValidateKubeletCSR(csr *x509.CertificateRequest, machineName string, isHClo
 	for _, address := range addresses {
 		switch address.Type {
 		case corev1.NodeInternalIP, corev1.NodeExternalIP:
-			allowedIPAddresses[strings.Split(address.Address, "/")[0]] = struct{}{}
+			// Compare the canonical form, as IPv6 addresses can be written in different ways
+			if ip := net.ParseIP(strings.Split(address.Address, "/")[0]); ip != nil {
+				allowedIPAddresses[ip.String()] = struct{}{}
+			}
 		}
 	}
 	for _, ip := range csr.IPAddresses {
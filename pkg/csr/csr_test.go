@@ -17,9 +17,14 @@ limitations under the License.
 package csr_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/base64"
 	"encoding/pem"
+	"net"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -51,3 +56,34 @@ var _ = Describe("Validate Kubelet CSR", func() {
 		Expect(csr.ValidateKubeletCSR(cr, name, true, addresses)).To(Succeed())
 	})
 })
+
+var _ = Describe("Validate Kubelet CSR with IPv6 addresses", func() {
+	var cr *x509.CertificateRequest
+	name := "hcloud-testing-control-plane-vgnlc"
+
+	BeforeEach(func() {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).To(BeNil())
+		der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+			Subject: pkix.Name{
+				CommonName:   csr.NodesPrefix + name,
+				Organization: []string{csr.NodesGroup},
+			},
+			DNSNames:    []string{name},
+			IPAddresses: []net.IP{net.ParseIP("2a01:4f8:c012:1b1::1")},
+		}, key)
+		Expect(err).To(BeNil())
+		cr, err = x509.ParseCertificateRequest(der)
+		Expect(err).To(BeNil())
+	})
+
+	It("should accept an address that is written differently in the status", func() {
+		addresses := []corev1.NodeAddress{{Type: corev1.NodeExternalIP, Address: "2a01:04f8:c012:01b1:0000:0000:0000:0001"}}
+		Expect(csr.ValidateKubeletCSR(cr, name, true, addresses)).To(Succeed())
+	})
+
+	It("should reject an address of another machine", func() {
+		addresses := []corev1.NodeAddress{{Type: corev1.NodeExternalIP, Address: "2a01:4f8:c012:1b2::1"}}
+		Expect(csr.ValidateKubeletCSR(cr, name, true, addresses)).ToNot(Succeed())
+	})
+})
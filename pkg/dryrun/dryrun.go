@@ -0,0 +1,64 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package dryrun contains the dry-run mode of the controllers, in which mutations of servers and other
+// resources in HCloud and Robot are recorded instead of executed.
+package dryrun
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/cluster-api/util/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ErrDryRun is returned instead of executing a mutation in dry-run mode.
+var ErrDryRun = errors.New("skipped in dry-run mode")
+
+// RequeueAfter is the time after which an object whose reconciliation stopped at a skipped mutation is
+// reconciled again.
+const RequeueAfter = 5 * time.Minute
+
+// Enabled returns whether the mutations of the cluster are only recorded, either because of the global
+// dry-run mode or because of the annotation of the HetznerCluster.
+func Enabled(global bool, hetznerCluster *infrav1.HetznerCluster) bool {
+	if global {
+		return true
+	}
+	return hetznerCluster != nil && hetznerCluster.Annotations[infrav1.DryRunAnnotation] == "true"
+}
+
+// Skip logs and records the mutation on the object instead of executing it and returns ErrDryRun.
+func Skip(obj client.Object, format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	ctrl.Log.WithName("dry-run").Info("Skipped mutation", "object", klog.KObj(obj), "mutation", msg)
+	record.Eventf(obj, "DryRun", "Dry run: skipped %s", msg)
+	return ErrDryRun
+}
+
+// Result requeues the object after RequeueAfter instead of returning an error if the
+// reconciliation stopped at a skipped mutation.
+func Result(res ctrl.Result, err error) (ctrl.Result, error) {
+	if errors.Is(err, ErrDryRun) {
+		return ctrl.Result{RequeueAfter: RequeueAfter}, nil
+	}
+	return res, err
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dryrun_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/dryrun"
+	hcloudclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+var _ = Describe("Enabled", func() {
+	It("is enabled globally", func() {
+		Expect(dryrun.Enabled(true, nil)).To(BeTrue())
+		Expect(dryrun.Enabled(true, &infrav1.HetznerCluster{})).To(BeTrue())
+	})
+
+	It("is enabled by the annotation of the cluster", func() {
+		hetznerCluster := &infrav1.HetznerCluster{}
+		Expect(dryrun.Enabled(false, nil)).To(BeFalse())
+		Expect(dryrun.Enabled(false, hetznerCluster)).To(BeFalse())
+
+		hetznerCluster.Annotations = map[string]string{infrav1.DryRunAnnotation: "false"}
+		Expect(dryrun.Enabled(false, hetznerCluster)).To(BeFalse())
+
+		hetznerCluster.Annotations[infrav1.DryRunAnnotation] = "true"
+		Expect(dryrun.Enabled(false, hetznerCluster)).To(BeTrue())
+	})
+})
+
+var _ = Describe("Result", func() {
+	It("requeues skipped mutations without error", func() {
+		err := errors.Wrap(dryrun.ErrDryRun, "failed to create server")
+		Expect(dryrun.Result(ctrl.Result{}, err)).To(Equal(ctrl.Result{RequeueAfter: dryrun.RequeueAfter}))
+	})
+
+	It("keeps other results", func() {
+		res, err := dryrun.Result(ctrl.Result{RequeueAfter: time.Second}, errors.New("boom"))
+		Expect(err).To(MatchError("boom"))
+		Expect(res).To(Equal(ctrl.Result{RequeueAfter: time.Second}))
+	})
+})
+
+var _ = Describe("HCloud dry-run client", func() {
+	var client hcloudclient.Client
+
+	BeforeEach(func() {
+		hetznerCluster := &infrav1.HetznerCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default"}}
+		client = hcloudclient.NewDryRunClient(fake.NewHCloudClientFactory().NewClient(""), hetznerCluster)
+	})
+
+	AfterEach(func() {
+		client.Close()
+	})
+
+	It("skips mutations", func() {
+		_, err := client.CreateServer(context.Background(), hcloud.ServerCreateOpts{Name: "server"})
+		Expect(errors.Is(err, dryrun.ErrDryRun)).To(BeTrue())
+
+		servers, err := client.ListServers(context.Background(), hcloud.ServerListOpts{})
+		Expect(err).To(Succeed())
+		Expect(servers).To(BeEmpty())
+	})
+})
@@ -0,0 +1,127 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package egressip discovers the public IPs that the controllers use for outbound traffic, e.g. for the SSH
+// connections to bare metal hosts and the requests to the HCloud and Robot APIs.
+package egressip
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+const (
+	// defaultTTL is the time for which the discovered IPs are reused.
+	defaultTTL = 10 * time.Minute
+
+	// maxResponseSize limits the response of an endpoint, which is only an IP address.
+	maxResponseSize = 256
+)
+
+// Discoverer asks endpoints that answer with the public IP of the client, e.g. https://ipv4.icanhazip.com, for
+// the egress IPs. The result is cached, so that the endpoints are not queried for every reconciliation.
+type Discoverer struct {
+	endpoints []string
+	ttl       time.Duration
+	client    *http.Client
+
+	mu      sync.Mutex
+	ips     []string
+	expires time.Time
+}
+
+// NewDiscoverer creates a Discoverer that queries the endpoints. It returns nil without endpoints, which
+// disables the discovery.
+func NewDiscoverer(endpoints []string) *Discoverer {
+	if len(endpoints) == 0 {
+		return nil
+	}
+	return &Discoverer{
+		endpoints: endpoints,
+		ttl:       defaultTTL,
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// IPs returns the sorted egress IPs that the endpoints report. It fails only if no endpoint answers, so that
+// an endpoint of a single IP family that is not reachable does not hide the IPs of the other family.
+func (d *Discoverer) IPs(ctx context.Context) ([]string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.ips != nil && time.Now().Before(d.expires) {
+		return d.ips, nil
+	}
+
+	found := make(map[string]struct{})
+	var errs []error
+	for _, endpoint := range d.endpoints {
+		ip, err := d.query(ctx, endpoint)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to query %s", endpoint))
+			continue
+		}
+		found[ip] = struct{}{}
+	}
+	if len(found) == 0 {
+		return nil, kerrors.NewAggregate(errs)
+	}
+
+	ips := make([]string, 0, len(found))
+	for ip := range found {
+		ips = append(ips, ip)
+	}
+	sort.Strings(ips)
+
+	d.ips = ips
+	d.expires = time.Now().Add(d.ttl)
+	return ips, nil
+}
+
+func (d *Discoverer) query(ctx context.Context, endpoint string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, http.NoBody)
+	if err != nil {
+		return "", err
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read response")
+	}
+	ip := net.ParseIP(strings.TrimSpace(string(body)))
+	if ip == nil {
+		return "", fmt.Errorf("response %q is not an IP address", strings.TrimSpace(string(body)))
+	}
+	return ip.String(), nil
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package egressip
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Discoverer", func() {
+	newEndpoint := func(body string, requests *int32) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(requests, 1)
+			fmt.Fprintln(w, body)
+		}))
+		DeferCleanup(server.Close)
+		return server
+	}
+
+	It("returns the IPs of all endpoints and caches them", func() {
+		var requests int32
+		ipv4 := newEndpoint("203.0.113.10", &requests)
+		ipv6 := newEndpoint("2001:db8::1", &requests)
+		discoverer := NewDiscoverer([]string{ipv6.URL, ipv4.URL})
+
+		ips, err := discoverer.IPs(context.Background())
+		Expect(err).To(Succeed())
+		Expect(ips).To(Equal([]string{"2001:db8::1", "203.0.113.10"}))
+
+		_, err = discoverer.IPs(context.Background())
+		Expect(err).To(Succeed())
+		Expect(atomic.LoadInt32(&requests)).To(Equal(int32(2)))
+	})
+
+	It("ignores endpoints that do not answer with an IP as long as one endpoint does", func() {
+		var requests int32
+		discoverer := NewDiscoverer([]string{newEndpoint("<html>", &requests).URL, newEndpoint("203.0.113.10", &requests).URL})
+
+		Expect(discoverer.IPs(context.Background())).To(Equal([]string{"203.0.113.10"}))
+	})
+
+	It("fails if no endpoint answers with an IP", func() {
+		var requests int32
+		discoverer := NewDiscoverer([]string{newEndpoint("<html>", &requests).URL})
+
+		_, err := discoverer.IPs(context.Background())
+		Expect(err).ToNot(Succeed())
+	})
+
+	It("is disabled without endpoints", func() {
+		Expect(NewDiscoverer(nil)).To(BeNil())
+	})
+})
@@ -0,0 +1,85 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var kubeletServingCertificateExpiryDesc = prometheus.NewDesc(
+	"caph_kubelet_serving_certificate_expiration_timestamp_seconds",
+	"Expiration time of the last kubelet serving certificate that has been issued to the node of a machine.",
+	[]string{"namespace", "cluster", "kind", "machine"}, nil,
+)
+
+// CertificateCollector reports the expiration time of the kubelet serving certificates of the machines, as
+// recorded by the CSR controller. Machines whose node has not received a certificate yet are not reported.
+type CertificateCollector struct {
+	client client.Reader
+}
+
+// NewCertificateCollector returns a new collector that lists the machines with the given reader.
+func NewCertificateCollector(reader client.Reader) *CertificateCollector {
+	return &CertificateCollector{client: reader}
+}
+
+// Describe implements prometheus.Collector.
+func (c *CertificateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- kubeletServingCertificateExpiryDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c *CertificateCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), listTimeout)
+	defer cancel()
+	log := ctrl.Log.WithName("metrics")
+
+	report := func(obj client.Object, kind string, cert *infrav1.CertificateStatus) {
+		if cert == nil {
+			return
+		}
+		ch <- prometheus.MustNewConstMetric(kubeletServingCertificateExpiryDesc, prometheus.GaugeValue,
+			float64(cert.NotAfter.Unix()),
+			obj.GetNamespace(), obj.GetLabels()[clusterv1.ClusterLabelName], kind, obj.GetName())
+	}
+
+	var hcloudMachines infrav1.HCloudMachineList
+	if err := c.client.List(ctx, &hcloudMachines); err != nil {
+		log.Error(err, "failed to list HCloudMachines")
+		ch <- prometheus.NewInvalidMetric(kubeletServingCertificateExpiryDesc, err)
+	}
+	for i := range hcloudMachines.Items {
+		machine := &hcloudMachines.Items[i]
+		report(machine, "HCloudMachine", machine.Status.KubeletServingCertificate)
+	}
+
+	var bmMachines infrav1.HetznerBareMetalMachineList
+	if err := c.client.List(ctx, &bmMachines); err != nil {
+		log.Error(err, "failed to list HetznerBareMetalMachines")
+		ch <- prometheus.NewInvalidMetric(kubeletServingCertificateExpiryDesc, err)
+	}
+	for i := range bmMachines.Items {
+		machine := &bmMachines.Items[i]
+		report(machine, "HetznerBareMetalMachine", machine.Status.KubeletServingCertificate)
+	}
+}
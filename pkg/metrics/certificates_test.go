@@ -0,0 +1,68 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics_test
+
+import (
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/metrics"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("CertificateCollector", func() {
+	It("reports the expiration time of the kubelet serving certificates", func() {
+		scheme := runtime.NewScheme()
+		Expect(infrav1.AddToScheme(scheme)).To(Succeed())
+
+		clusterLabels := map[string]string{clusterv1.ClusterLabelName: "my-cluster"}
+		cert := &infrav1.CertificateStatus{
+			NotBefore: metav1.NewTime(time.Unix(1600000000, 0)),
+			NotAfter:  metav1.NewTime(time.Unix(1700000000, 0)),
+		}
+		objects := []client.Object{
+			&infrav1.HCloudMachine{
+				ObjectMeta: metav1.ObjectMeta{Name: "cp-1", Namespace: "default", Labels: clusterLabels},
+				Status:     infrav1.HCloudMachineStatus{KubeletServingCertificate: cert},
+			},
+			&infrav1.HCloudMachine{
+				ObjectMeta: metav1.ObjectMeta{Name: "cp-2", Namespace: "default", Labels: clusterLabels},
+			},
+			&infrav1.HetznerBareMetalMachine{
+				ObjectMeta: metav1.ObjectMeta{Name: "worker-1", Namespace: "default", Labels: clusterLabels},
+				Status:     infrav1.HetznerBareMetalMachineStatus{KubeletServingCertificate: cert},
+			},
+		}
+		collector := metrics.NewCertificateCollector(fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build())
+
+		expected := `
+# HELP caph_kubelet_serving_certificate_expiration_timestamp_seconds Expiration time of the last kubelet serving certificate that has been issued to the node of a machine.
+# TYPE caph_kubelet_serving_certificate_expiration_timestamp_seconds gauge
+caph_kubelet_serving_certificate_expiration_timestamp_seconds{cluster="my-cluster",kind="HCloudMachine",machine="cp-1",namespace="default"} 1.7e+09
+caph_kubelet_serving_certificate_expiration_timestamp_seconds{cluster="my-cluster",kind="HetznerBareMetalMachine",machine="worker-1",namespace="default"} 1.7e+09
+`
+		Expect(testutil.CollectAndCompare(collector, strings.NewReader(expected))).To(Succeed())
+	})
+})
@@ -0,0 +1,58 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// readyLatencyBuckets range from 30 seconds for HCloud servers to 4 hours for bare metal hosts.
+var readyLatencyBuckets = []float64{30, 60, 90, 120, 180, 240, 300, 450, 600, 900, 1200, 1800, 2700, 3600, 7200, 14400}
+
+var readyLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "caph_machine_ready_latency_seconds",
+	Help:    "Time from the creation of HCloudMachines and HetznerBareMetalMachines until their nodes are healthy.",
+	Buckets: readyLatencyBuckets,
+}, []string{"cluster", "kind", "machine_type"})
+
+func init() {
+	metrics.Registry.MustRegister(readyLatency)
+}
+
+// ObserveNodeReady sets the milestone NodeReady of the infrastructure machine once the node of the Machine is healthy,
+// and observes the time since the creation of the infrastructure machine as ready latency. The machine type is the
+// HCloud server type or the Robot product of the bare metal host. Machines without milestones are not observed.
+func ObserveNodeReady(machine *clusterv1.Machine, infraMachine client.Object, kind, machineType string, milestones *infrav1.ProvisioningMilestones) {
+	if milestones == nil || milestones.NodeReady != nil || !conditions.IsTrue(machine, clusterv1.MachineNodeHealthyCondition) {
+		return
+	}
+	now := metav1.Now()
+	milestones.NodeReady = &now
+
+	latency := now.Sub(infraMachine.GetCreationTimestamp().Time)
+	readyLatency.WithLabelValues(infraMachine.GetLabels()[clusterv1.ClusterLabelName], kind, machineType).Observe(latency.Seconds())
+	record.Eventf(infraMachine, "NodeReady", "Node is healthy %s after the creation of the machine", latency.Round(time.Second))
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/metrics"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var _ = Describe("ObserveNodeReady", func() {
+	newMachines := func(cluster string, healthy bool) (*clusterv1.Machine, *infrav1.HCloudMachine) {
+		machine := &clusterv1.Machine{}
+		if healthy {
+			conditions.MarkTrue(machine, clusterv1.MachineNodeHealthyCondition)
+		}
+		hcloudMachine := &infrav1.HCloudMachine{ObjectMeta: metav1.ObjectMeta{
+			Name:              "hcloud-machine",
+			Labels:            map[string]string{clusterv1.ClusterLabelName: cluster},
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-2 * time.Minute)),
+		}}
+		return machine, hcloudMachine
+	}
+
+	readyLatencySeries := func() int {
+		count, err := testutil.GatherAndCount(ctrlmetrics.Registry, "caph_machine_ready_latency_seconds")
+		Expect(err).To(Succeed())
+		return count
+	}
+
+	It("sets the milestone and observes the latency once the node is healthy", func() {
+		machine, hcloudMachine := newMachines("ready-cluster", true)
+		milestones := &infrav1.ProvisioningMilestones{InfrastructureCreated: &hcloudMachine.CreationTimestamp}
+		before := readyLatencySeries()
+
+		metrics.ObserveNodeReady(machine, hcloudMachine, "HCloudMachine", "cpx31", milestones)
+
+		Expect(milestones.NodeReady).ToNot(BeNil())
+		Expect(readyLatencySeries()).To(Equal(before + 1))
+	})
+
+	It("does not change the milestone of a node that has been healthy before", func() {
+		machine, hcloudMachine := newMachines("known-cluster", true)
+		readyTime := metav1.NewTime(time.Now().Add(-time.Minute))
+		milestones := &infrav1.ProvisioningMilestones{NodeReady: &readyTime}
+		before := readyLatencySeries()
+
+		metrics.ObserveNodeReady(machine, hcloudMachine, "HCloudMachine", "cpx31", milestones)
+
+		Expect(milestones.NodeReady).To(Equal(&readyTime))
+		Expect(readyLatencySeries()).To(Equal(before))
+	})
+
+	It("waits for the node to be healthy", func() {
+		machine, hcloudMachine := newMachines("pending-cluster", false)
+		milestones := &infrav1.ProvisioningMilestones{}
+
+		metrics.ObserveNodeReady(machine, hcloudMachine, "HCloudMachine", "cpx31", milestones)
+
+		Expect(milestones.NodeReady).To(BeNil())
+	})
+
+	It("ignores machines without milestones", func() {
+		machine, hcloudMachine := newMachines("old-cluster", true)
+		before := readyLatencySeries()
+
+		metrics.ObserveNodeReady(machine, hcloudMachine, "HCloudMachine", "cpx31", nil)
+
+		Expect(readyLatencySeries()).To(Equal(before))
+	})
+})
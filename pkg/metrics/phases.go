@@ -0,0 +1,188 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics contains metrics of the objects of the provider. Most of them are computed on every scrape.
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Phases of HCloudMachines and HetznerBareMetalMachines.
+const (
+	PhasePending      = "pending"
+	PhaseProvisioning = "provisioning"
+	PhaseProvisioned  = "provisioned"
+	PhaseDeleting     = "deleting"
+	PhaseFailed       = "failed"
+)
+
+// Phases lists all phases. Every phase is reported, so that a phase without machines has the value 0.
+var Phases = []string{PhasePending, PhaseProvisioning, PhaseProvisioned, PhaseDeleting, PhaseFailed}
+
+// listTimeout is the maximum time that listing the objects may take during a scrape.
+const listTimeout = 10 * time.Second
+
+var (
+	machinesDesc = prometheus.NewDesc(
+		"caph_machines",
+		"Number of HCloudMachines and HetznerBareMetalMachines per cluster and phase.",
+		[]string{"namespace", "cluster", "kind", "phase"}, nil,
+	)
+	hostsDesc = prometheus.NewDesc(
+		"caph_baremetal_hosts",
+		"Number of HetznerBareMetalHosts per cluster and provisioning state. Hosts that are not consumed by a machine have an empty cluster.",
+		[]string{"namespace", "cluster", "state"}, nil,
+	)
+)
+
+type machineKey struct {
+	namespace string
+	cluster   string
+	kind      string
+}
+
+type hostKey struct {
+	namespace string
+	cluster   string
+	state     string
+}
+
+// PhaseCollector reports the number of machines per phase and of bare metal hosts per provisioning
+// state. The objects are listed from the cache of the manager on every scrape, so that the numbers
+// are always up to date and no object has to be tracked when it is deleted.
+type PhaseCollector struct {
+	client client.Reader
+}
+
+// NewPhaseCollector returns a new collector that lists the objects with the given reader.
+func NewPhaseCollector(reader client.Reader) *PhaseCollector {
+	return &PhaseCollector{client: reader}
+}
+
+// Describe implements prometheus.Collector.
+func (c *PhaseCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- machinesDesc
+	ch <- hostsDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c *PhaseCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), listTimeout)
+	defer cancel()
+	log := ctrl.Log.WithName("metrics")
+
+	machines := make(map[machineKey]map[string]int)
+	addMachine := func(obj client.Object, kind, phase string) {
+		key := machineKey{namespace: obj.GetNamespace(), cluster: obj.GetLabels()[clusterv1.ClusterLabelName], kind: kind}
+		if _, found := machines[key]; !found {
+			machines[key] = make(map[string]int)
+		}
+		machines[key][phase]++
+	}
+
+	var hcloudMachines infrav1.HCloudMachineList
+	if err := c.client.List(ctx, &hcloudMachines); err != nil {
+		log.Error(err, "failed to list HCloudMachines")
+		ch <- prometheus.NewInvalidMetric(machinesDesc, err)
+	}
+	for i := range hcloudMachines.Items {
+		addMachine(&hcloudMachines.Items[i], "HCloudMachine", HCloudMachinePhase(&hcloudMachines.Items[i]))
+	}
+
+	var bmMachines infrav1.HetznerBareMetalMachineList
+	if err := c.client.List(ctx, &bmMachines); err != nil {
+		log.Error(err, "failed to list HetznerBareMetalMachines")
+		ch <- prometheus.NewInvalidMetric(machinesDesc, err)
+	}
+	clusterOfBMMachines := make(map[client.ObjectKey]string, len(bmMachines.Items))
+	for i := range bmMachines.Items {
+		machine := &bmMachines.Items[i]
+		clusterOfBMMachines[client.ObjectKeyFromObject(machine)] = machine.Labels[clusterv1.ClusterLabelName]
+		addMachine(machine, "HetznerBareMetalMachine", BareMetalMachinePhase(machine))
+	}
+
+	for key, phases := range machines {
+		for _, phase := range Phases {
+			ch <- prometheus.MustNewConstMetric(machinesDesc, prometheus.GaugeValue, float64(phases[phase]),
+				key.namespace, key.cluster, key.kind, phase)
+		}
+	}
+
+	var hosts infrav1.HetznerBareMetalHostList
+	if err := c.client.List(ctx, &hosts); err != nil {
+		log.Error(err, "failed to list HetznerBareMetalHosts")
+		ch <- prometheus.NewInvalidMetric(hostsDesc, err)
+		return
+	}
+	hostCounts := make(map[hostKey]int)
+	for _, host := range hosts.Items {
+		var cluster string
+		if host.Spec.ConsumerRef != nil {
+			cluster = clusterOfBMMachines[client.ObjectKey{Namespace: host.Namespace, Name: host.Spec.ConsumerRef.Name}]
+		}
+		state := string(host.Spec.Status.ProvisioningState)
+		if host.Spec.Status.ProvisioningState == infrav1.StateNone {
+			state = "none"
+		}
+		hostCounts[hostKey{namespace: host.Namespace, cluster: cluster, state: state}]++
+	}
+	for key, count := range hostCounts {
+		ch <- prometheus.MustNewConstMetric(hostsDesc, prometheus.GaugeValue, float64(count),
+			key.namespace, key.cluster, key.state)
+	}
+}
+
+// HCloudMachinePhase returns the phase of the HCloudMachine.
+func HCloudMachinePhase(machine *infrav1.HCloudMachine) string {
+	switch {
+	case !machine.DeletionTimestamp.IsZero():
+		return PhaseDeleting
+	case machine.Status.FailureReason != nil || machine.Status.FailureMessage != nil:
+		return PhaseFailed
+	case machine.Status.Ready:
+		return PhaseProvisioned
+	case machine.Spec.ProviderID != nil:
+		return PhaseProvisioning
+	default:
+		return PhasePending
+	}
+}
+
+// BareMetalMachinePhase returns the phase of the HetznerBareMetalMachine. The machine is provisioning
+// as soon as a host has been associated with it.
+func BareMetalMachinePhase(machine *infrav1.HetznerBareMetalMachine) string {
+	_, hasHost := machine.Annotations[infrav1.HostAnnotation]
+	switch {
+	case !machine.DeletionTimestamp.IsZero():
+		return PhaseDeleting
+	case machine.Status.FailureReason != nil || machine.Status.FailureMessage != nil:
+		return PhaseFailed
+	case machine.Status.Ready:
+		return PhaseProvisioned
+	case hasHost:
+		return PhaseProvisioning
+	default:
+		return PhasePending
+	}
+}
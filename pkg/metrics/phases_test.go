@@ -0,0 +1,135 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("HCloudMachinePhase", func() {
+	now := metav1.Now()
+
+	DescribeTable("HCloudMachinePhase",
+		func(machine infrav1.HCloudMachine, expectedPhase string) {
+			Expect(metrics.HCloudMachinePhase(&machine)).To(Equal(expectedPhase))
+		},
+		Entry("pending", infrav1.HCloudMachine{}, metrics.PhasePending),
+		Entry("provisioning", infrav1.HCloudMachine{
+			Spec: infrav1.HCloudMachineSpec{ProviderID: pointer.String("hcloud://1")},
+		}, metrics.PhaseProvisioning),
+		Entry("provisioned", infrav1.HCloudMachine{
+			Spec:   infrav1.HCloudMachineSpec{ProviderID: pointer.String("hcloud://1")},
+			Status: infrav1.HCloudMachineStatus{Ready: true},
+		}, metrics.PhaseProvisioned),
+		Entry("failed", infrav1.HCloudMachine{
+			Status: infrav1.HCloudMachineStatus{Ready: true, FailureMessage: pointer.String("server not found")},
+		}, metrics.PhaseFailed),
+		Entry("deleting", infrav1.HCloudMachine{
+			ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &now},
+			Status:     infrav1.HCloudMachineStatus{FailureMessage: pointer.String("server not found")},
+		}, metrics.PhaseDeleting),
+	)
+})
+
+var _ = Describe("BareMetalMachinePhase", func() {
+	DescribeTable("BareMetalMachinePhase",
+		func(machine infrav1.HetznerBareMetalMachine, expectedPhase string) {
+			Expect(metrics.BareMetalMachinePhase(&machine)).To(Equal(expectedPhase))
+		},
+		Entry("pending", infrav1.HetznerBareMetalMachine{}, metrics.PhasePending),
+		Entry("provisioning", infrav1.HetznerBareMetalMachine{
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{infrav1.HostAnnotation: "default/host"}},
+		}, metrics.PhaseProvisioning),
+		Entry("provisioned", infrav1.HetznerBareMetalMachine{
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{infrav1.HostAnnotation: "default/host"}},
+			Status:     infrav1.HetznerBareMetalMachineStatus{Ready: true},
+		}, metrics.PhaseProvisioned),
+	)
+})
+
+var _ = Describe("PhaseCollector", func() {
+	It("reports the machines per phase and the hosts per state", func() {
+		scheme := runtime.NewScheme()
+		Expect(infrav1.AddToScheme(scheme)).To(Succeed())
+
+		clusterLabels := map[string]string{clusterv1.ClusterLabelName: "my-cluster"}
+		objects := []client.Object{
+			&infrav1.HCloudMachine{
+				ObjectMeta: metav1.ObjectMeta{Name: "cp-1", Namespace: "default", Labels: clusterLabels},
+				Spec:       infrav1.HCloudMachineSpec{ProviderID: pointer.String("hcloud://1")},
+				Status:     infrav1.HCloudMachineStatus{Ready: true},
+			},
+			&infrav1.HCloudMachine{
+				ObjectMeta: metav1.ObjectMeta{Name: "cp-2", Namespace: "default", Labels: clusterLabels},
+			},
+			&infrav1.HetznerBareMetalMachine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "worker-1",
+					Namespace:   "default",
+					Labels:      clusterLabels,
+					Annotations: map[string]string{infrav1.HostAnnotation: "default/host-1"},
+				},
+			},
+			&infrav1.HetznerBareMetalHost{
+				ObjectMeta: metav1.ObjectMeta{Name: "host-1", Namespace: "default"},
+				Spec: infrav1.HetznerBareMetalHostSpec{
+					ServerID:    1,
+					ConsumerRef: &corev1.ObjectReference{Name: "worker-1", Namespace: "default"},
+					Status:      infrav1.ControllerGeneratedStatus{ProvisioningState: infrav1.StateImageInstalling},
+				},
+			},
+			&infrav1.HetznerBareMetalHost{
+				ObjectMeta: metav1.ObjectMeta{Name: "host-2", Namespace: "default"},
+				Spec:       infrav1.HetznerBareMetalHostSpec{ServerID: 2},
+			},
+		}
+		collector := metrics.NewPhaseCollector(fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build())
+
+		expected := `
+# HELP caph_baremetal_hosts Number of HetznerBareMetalHosts per cluster and provisioning state. Hosts that are not consumed by a machine have an empty cluster.
+# TYPE caph_baremetal_hosts gauge
+caph_baremetal_hosts{cluster="",namespace="default",state="none"} 1
+caph_baremetal_hosts{cluster="my-cluster",namespace="default",state="image-installing"} 1
+# HELP caph_machines Number of HCloudMachines and HetznerBareMetalMachines per cluster and phase.
+# TYPE caph_machines gauge
+caph_machines{cluster="my-cluster",kind="HCloudMachine",namespace="default",phase="deleting"} 0
+caph_machines{cluster="my-cluster",kind="HCloudMachine",namespace="default",phase="failed"} 0
+caph_machines{cluster="my-cluster",kind="HCloudMachine",namespace="default",phase="pending"} 1
+caph_machines{cluster="my-cluster",kind="HCloudMachine",namespace="default",phase="provisioned"} 1
+caph_machines{cluster="my-cluster",kind="HCloudMachine",namespace="default",phase="provisioning"} 0
+caph_machines{cluster="my-cluster",kind="HetznerBareMetalMachine",namespace="default",phase="deleting"} 0
+caph_machines{cluster="my-cluster",kind="HetznerBareMetalMachine",namespace="default",phase="failed"} 0
+caph_machines{cluster="my-cluster",kind="HetznerBareMetalMachine",namespace="default",phase="pending"} 0
+caph_machines{cluster="my-cluster",kind="HetznerBareMetalMachine",namespace="default",phase="provisioned"} 0
+caph_machines{cluster="my-cluster",kind="HetznerBareMetalMachine",namespace="default",phase="provisioning"} 1
+`
+		Expect(testutil.CollectAndCompare(collector, strings.NewReader(expected))).To(Succeed())
+	})
+})
@@ -0,0 +1,129 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package propagation contains functions to propagate the labels and annotations of Machines to
+// their infrastructure machines.
+package propagation
+
+import (
+	"sort"
+	"strings"
+
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// clusterAPIDomain is the domain of the labels and annotations that are owned by Cluster API.
+const clusterAPIDomain = "cluster.x-k8s.io"
+
+// MachineMetadata propagates the labels and annotations of the Machine to its infrastructure machine and
+// returns whether the infrastructure machine has been changed. The propagated keys are recorded, so that
+// labels and annotations that are removed from the Machine are removed from the infrastructure machine as well.
+func MachineMetadata(machine, infraMachine metav1.Object) bool {
+	labels := copyMap(infraMachine.GetLabels())
+	annotations := copyMap(infraMachine.GetAnnotations())
+
+	labelsChanged := propagate(machine.GetLabels(), labels, annotations, infrav1.PropagatedLabelsAnnotation)
+	annotationsChanged := propagate(machine.GetAnnotations(), annotations, annotations, infrav1.PropagatedAnnotationsAnnotation)
+	if !labelsChanged && !annotationsChanged {
+		return false
+	}
+
+	infraMachine.SetLabels(labels)
+	infraMachine.SetAnnotations(annotations)
+	return true
+}
+
+// PropagatedLabels returns the labels of the infrastructure machine that have been propagated from its Machine.
+func PropagatedLabels(infraMachine metav1.Object) map[string]string {
+	labels := make(map[string]string)
+	for _, key := range propagatedKeys(infraMachine.GetAnnotations(), infrav1.PropagatedLabelsAnnotation) {
+		if value, found := infraMachine.GetLabels()[key]; found {
+			labels[key] = value
+		}
+	}
+	return labels
+}
+
+// propagate sets the propagated entries of source in target and removes the entries that have been
+// propagated before and are no longer in source. The propagated keys are recorded in annotations.
+func propagate(source, target, annotations map[string]string, recordKey string) bool {
+	var changed bool
+	for _, key := range propagatedKeys(annotations, recordKey) {
+		if _, found := source[key]; found && isPropagated(key) {
+			continue
+		}
+		if _, found := target[key]; found {
+			delete(target, key)
+			changed = true
+		}
+	}
+
+	var keys []string
+	for key, value := range source {
+		if !isPropagated(key) {
+			continue
+		}
+		keys = append(keys, key)
+		if current, found := target[key]; !found || current != value {
+			target[key] = value
+			changed = true
+		}
+	}
+	sort.Strings(keys)
+
+	record := strings.Join(keys, ",")
+	if annotations[recordKey] != record {
+		changed = true
+	}
+	if record == "" {
+		delete(annotations, recordKey)
+	} else {
+		annotations[recordKey] = record
+	}
+	return changed
+}
+
+// propagatedKeys returns the keys that are recorded in the annotation recordKey.
+func propagatedKeys(annotations map[string]string, recordKey string) []string {
+	record := annotations[recordKey]
+	if record == "" {
+		return nil
+	}
+	return strings.Split(record, ",")
+}
+
+// isPropagated returns whether a label or annotation of a Machine is propagated. Keys of the domain
+// cluster.x-k8s.io and its subdomains are owned by Cluster API and the providers and are not propagated.
+func isPropagated(key string) bool {
+	if key == corev1.LastAppliedConfigAnnotation {
+		return false
+	}
+	prefix, _, found := strings.Cut(key, "/")
+	if !found {
+		prefix = key
+	}
+	return prefix != clusterAPIDomain && !strings.HasSuffix(prefix, "."+clusterAPIDomain)
+}
+
+func copyMap(m map[string]string) map[string]string {
+	result := make(map[string]string, len(m))
+	for key, value := range m {
+		result[key] = value
+	}
+	return result
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package propagation_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/propagation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+var _ = Describe("MachineMetadata", func() {
+	var (
+		machine       *clusterv1.Machine
+		hcloudMachine *infrav1.HCloudMachine
+	)
+
+	BeforeEach(func() {
+		machine = &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				clusterv1.ClusterLabelName:             "cluster",
+				clusterv1.MachineDeploymentLabelName:   "md-0",
+				"cost-center":                          "team-a",
+				"node-role.kubernetes.io/worker":       "",
+				"topology.cluster.x-k8s.io/owned":      "",
+				"example.com/environment":              "production",
+				"infrastructure.cluster.x-k8s.io/kind": "hcloud",
+			},
+			Annotations: map[string]string{
+				"example.com/owner":                        "team-a",
+				clusterv1.TemplateClonedFromNameAnnotation: "template",
+			},
+		}}
+		hcloudMachine = &infrav1.HCloudMachine{ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{clusterv1.ClusterLabelName: "cluster", "own": "label"},
+		}}
+	})
+
+	It("propagates the labels and annotations that are not owned by Cluster API", func() {
+		Expect(propagation.MachineMetadata(machine, hcloudMachine)).To(BeTrue())
+
+		Expect(hcloudMachine.Labels).To(Equal(map[string]string{
+			clusterv1.ClusterLabelName:       "cluster",
+			"own":                            "label",
+			"cost-center":                    "team-a",
+			"node-role.kubernetes.io/worker": "",
+			"example.com/environment":        "production",
+		}))
+		Expect(hcloudMachine.Annotations).To(Equal(map[string]string{
+			"example.com/owner":                     "team-a",
+			infrav1.PropagatedLabelsAnnotation:      "cost-center,example.com/environment,node-role.kubernetes.io/worker",
+			infrav1.PropagatedAnnotationsAnnotation: "example.com/owner",
+		}))
+		Expect(propagation.PropagatedLabels(hcloudMachine)).To(Equal(map[string]string{
+			"cost-center":                    "team-a",
+			"node-role.kubernetes.io/worker": "",
+			"example.com/environment":        "production",
+		}))
+	})
+
+	It("does not change the infrastructure machine if nothing changed", func() {
+		Expect(propagation.MachineMetadata(machine, hcloudMachine)).To(BeTrue())
+		Expect(propagation.MachineMetadata(machine, hcloudMachine)).To(BeFalse())
+	})
+
+	It("updates changed values and removes entries that have been removed from the Machine", func() {
+		Expect(propagation.MachineMetadata(machine, hcloudMachine)).To(BeTrue())
+
+		machine.Labels["cost-center"] = "team-b"
+		delete(machine.Labels, "example.com/environment")
+		delete(machine.Annotations, "example.com/owner")
+		Expect(propagation.MachineMetadata(machine, hcloudMachine)).To(BeTrue())
+
+		Expect(hcloudMachine.Labels).To(HaveKeyWithValue("cost-center", "team-b"))
+		Expect(hcloudMachine.Labels).ToNot(HaveKey("example.com/environment"))
+		Expect(hcloudMachine.Labels).To(HaveKeyWithValue("own", "label"))
+		Expect(hcloudMachine.Annotations).ToNot(HaveKey("example.com/owner"))
+		Expect(hcloudMachine.Annotations).ToNot(HaveKey(infrav1.PropagatedAnnotationsAnnotation))
+		Expect(hcloudMachine.Annotations).To(HaveKeyWithValue(infrav1.PropagatedLabelsAnnotation, "cost-center,node-role.kubernetes.io/worker"))
+	})
+})
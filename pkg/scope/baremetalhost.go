@@ -42,6 +42,7 @@ type BareMetalHostScopeParams struct {
 	SSHClientFactory     sshclient.Factory
 	OSSSHSecret          *corev1.Secret
 	RescueSSHSecret      *corev1.Secret
+	ImageDownloadSecret  *corev1.Secret
 	SecretManager        *secretutil.SecretManager
 }
 
@@ -81,6 +82,7 @@ func NewBareMetalHostScope(ctx context.Context, params BareMetalHostScopeParams)
 		HetznerBareMetalHost: params.HetznerBareMetalHost,
 		OSSSHSecret:          params.OSSSHSecret,
 		RescueSSHSecret:      params.RescueSSHSecret,
+		ImageDownloadSecret:  params.ImageDownloadSecret,
 		SecretManager:        params.SecretManager,
 	}, nil
 }
@@ -96,6 +98,7 @@ type BareMetalHostScope struct {
 	HetznerCluster       *infrav1.HetznerCluster
 	OSSSHSecret          *corev1.Secret
 	RescueSSHSecret      *corev1.Secret
+	ImageDownloadSecret  *corev1.Secret
 }
 
 // Name returns the HetznerCluster name.
@@ -134,6 +134,54 @@ func (m *BareMetalMachineScope) IsControlPlane() bool {
 	return util.IsControlPlaneMachine(m.Machine)
 }
 
+// DNS returns the resolver configuration of the machine, falling back to the cluster wide one.
+func (m *BareMetalMachineScope) DNS() *infrav1.DNSSpec {
+	if m.BareMetalMachine.Spec.DNS != nil {
+		return m.BareMetalMachine.Spec.DNS
+	}
+	return m.HetznerCluster.Spec.DNS
+}
+
+// SSHSpec returns the SSH configuration of the machine. Fields that are not set are taken from the cluster wide
+// defaults. As the API server defaults the port after install image and the user, they are also taken from the
+// cluster wide defaults if they have their default value. Ports and user that are set nowhere get their default
+// values.
+func (m *BareMetalMachineScope) SSHSpec() *infrav1.SSHSpec {
+	sshSpec := m.BareMetalMachine.Spec.SSHSpec.DeepCopy()
+
+	if defaults := m.HetznerCluster.Spec.SSHDefaults; defaults != nil {
+		if sshSpec.SecretRef.Name == "" {
+			sshSpec.SecretRef = defaults.SecretRef
+		}
+		if sshSpec.PortAfterInstallImage == 0 || sshSpec.PortAfterInstallImage == infrav1.DefaultSSHPort {
+			sshSpec.PortAfterInstallImage = defaults.PortAfterInstallImage
+		}
+		if sshSpec.PortAfterCloudInit == 0 {
+			sshSpec.PortAfterCloudInit = defaults.PortAfterCloudInit
+		}
+		if sshSpec.UserAfterCloudInit == "" || sshSpec.UserAfterCloudInit == infrav1.DefaultSSHUser {
+			sshSpec.UserAfterCloudInit = defaults.UserAfterCloudInit
+		}
+		if sshSpec.PrivilegeEscalation == "" {
+			sshSpec.PrivilegeEscalation = defaults.PrivilegeEscalation
+		}
+		if sshSpec.PrivateProvisioning == nil && defaults.PrivateProvisioning != nil {
+			sshSpec.PrivateProvisioning = defaults.PrivateProvisioning.DeepCopy()
+		}
+	}
+
+	if sshSpec.PortAfterInstallImage == 0 {
+		sshSpec.PortAfterInstallImage = infrav1.DefaultSSHPort
+	}
+	if sshSpec.PortAfterCloudInit == 0 {
+		sshSpec.PortAfterCloudInit = sshSpec.PortAfterInstallImage
+	}
+	if sshSpec.UserAfterCloudInit == "" {
+		sshSpec.UserAfterCloudInit = infrav1.DefaultSSHUser
+	}
+	return sshSpec
+}
+
 // IsBootstrapReady checks the readiness of a capi machine's bootstrap data.
 func (m *BareMetalMachineScope) IsBootstrapReady(ctx context.Context) bool {
 	return m.Machine.Spec.Bootstrap.DataSecretName != nil
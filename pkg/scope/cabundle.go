@@ -0,0 +1,62 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"context"
+	"crypto/x509"
+
+	"github.com/pkg/errors"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	secretutil "github.com/syself/cluster-api-provider-hetzner/pkg/secrets"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// TrustedCABundle returns the CA certificates of the trust bundle of the cluster, or nil if none is referenced.
+func (s *ClusterScope) TrustedCABundle(ctx context.Context) ([]byte, error) {
+	return trustedCABundle(ctx, secretutil.NewSecretManager(*s.Logger, s.Client, s.APIReader), s.HetznerCluster)
+}
+
+// TrustedCABundle returns the CA certificates of the trust bundle of the cluster, or nil if none is referenced.
+func (s *BareMetalHostScope) TrustedCABundle(ctx context.Context) ([]byte, error) {
+	return trustedCABundle(ctx, s.SecretManager, s.HetznerCluster)
+}
+
+func trustedCABundle(ctx context.Context, secretManager *secretutil.SecretManager, hetznerCluster *infrav1.HetznerCluster) ([]byte, error) {
+	ref := hetznerCluster.Spec.TrustedCABundle
+	if ref == nil {
+		return nil, nil
+	}
+
+	secret, err := secretManager.ObtainSecret(ctx, types.NamespacedName{Namespace: hetznerCluster.Namespace, Name: ref.Name})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get secret %s of trusted CA bundle", ref.Name)
+	}
+
+	key := ref.Key
+	if key == "" {
+		key = infrav1.DefaultTrustedCABundleKey
+	}
+	bundle, found := secret.Data[key]
+	if !found {
+		return nil, errors.Errorf("key %q of trusted CA bundle is missing in secret %s", key, ref.Name)
+	}
+	if !x509.NewCertPool().AppendCertsFromPEM(bundle) {
+		return nil, errors.Errorf("trusted CA bundle in secret %s contains no PEM encoded certificate", ref.Name)
+	}
+	return bundle, nil
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/pkg/errors"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	secretutil "github.com/syself/cluster-api-provider-hetzner/pkg/secrets"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// CloudInitParts returns the cloud-configs of the cloud-init parts of the cluster in the order of the spec.
+func (s *ClusterScope) CloudInitParts(ctx context.Context) ([][]byte, error) {
+	return cloudInitParts(ctx, secretutil.NewSecretManager(*s.Logger, s.Client, s.APIReader), s.HetznerCluster)
+}
+
+// CloudInitParts returns the cloud-configs of the cloud-init parts of the cluster in the order of the spec.
+func (s *BareMetalHostScope) CloudInitParts(ctx context.Context) ([][]byte, error) {
+	return cloudInitParts(ctx, s.SecretManager, s.HetznerCluster)
+}
+
+func cloudInitParts(ctx context.Context, secretManager *secretutil.SecretManager, hetznerCluster *infrav1.HetznerCluster) ([][]byte, error) {
+	parts := make([][]byte, 0, len(hetznerCluster.Spec.CloudInitParts))
+	for _, part := range hetznerCluster.Spec.CloudInitParts {
+		config := []byte(part.Content)
+		if ref := part.SecretRef; ref != nil {
+			secret, err := secretManager.ObtainSecret(ctx, types.NamespacedName{Namespace: hetznerCluster.Namespace, Name: ref.Name})
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to get secret %s of cloud-init part %s", ref.Name, part.Name)
+			}
+
+			key := ref.Key
+			if key == "" {
+				key = infrav1.DefaultCloudInitPartKey
+			}
+			var found bool
+			config, found = secret.Data[key]
+			if !found {
+				return nil, errors.Errorf("key %q of cloud-init part %s is missing in secret %s", key, part.Name, ref.Name)
+			}
+		}
+		if !bytes.HasPrefix(config, []byte(infrav1.CloudConfigPrefix)) {
+			return nil, errors.Errorf("cloud-init part %s is no cloud-config starting with %q", part.Name, infrav1.CloudConfigPrefix)
+		}
+		parts = append(parts, config)
+	}
+	return parts, nil
+}
@@ -20,6 +20,8 @@ package scope
 import (
 	"context"
 	"fmt"
+	"net"
+	"strconv"
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
@@ -129,10 +131,14 @@ func (s *ClusterScope) GetSpecRegion() []infrav1.Region {
 	return s.HetznerCluster.Spec.ControlPlaneRegions
 }
 
-// SetStatusFailureDomain sets the region for the status.
+// SetStatusFailureDomain sets the region for the status. Regions that are not allowed by the placement
+// constraints are left out.
 func (s *ClusterScope) SetStatusFailureDomain(regions []infrav1.Region) {
 	s.HetznerCluster.Status.FailureDomains = make(clusterv1.FailureDomains)
 	for _, region := range regions {
+		if !s.HetznerCluster.Spec.PlacementConstraints.IsLocationAllowed(region) {
+			continue
+		}
 		s.HetznerCluster.Status.FailureDomains[string(region)] = clusterv1.FailureDomainSpec{
 			ControlPlane: true,
 		}
@@ -176,7 +182,7 @@ func (s *ClusterScope) ClientConfigWithAPIEndpoint(ctx context.Context, endpoint
 	}
 	// update cluster endpint in config
 	for key := range raw.Clusters {
-		raw.Clusters[key].Server = fmt.Sprintf("https://%s:%d", endpoint.Host, endpoint.Port)
+		raw.Clusters[key].Server = "https://" + net.JoinHostPort(endpoint.Host, strconv.Itoa(int(endpoint.Port)))
 	}
 
 	return clientcmd.NewDefaultClientConfig(raw, &clientcmd.ConfigOverrides{}), nil
@@ -0,0 +1,107 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	secretutil "github.com/syself/cluster-api-provider-hetzner/pkg/secrets"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/utils"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ConsoleUser is the console user of the machines of a cluster.
+type ConsoleUser struct {
+	Name         string
+	PasswordHash string
+}
+
+// Applied returns the record of the user in the applied configuration of a machine. Only a hash of the
+// password hash is recorded. It is nil for a nil user.
+func (u *ConsoleUser) Applied() *infrav1.AppliedConsoleUser {
+	if u == nil {
+		return nil
+	}
+	return &infrav1.AppliedConsoleUser{Name: u.Name, PasswordHash: utils.SHA256Hash([]byte(u.PasswordHash))}
+}
+
+// ConsoleUser returns the console user of the cluster, or nil if none is configured.
+func (s *ClusterScope) ConsoleUser(ctx context.Context) (*ConsoleUser, error) {
+	if s.HetznerCluster.Spec.ConsoleUser == nil {
+		return nil, nil
+	}
+	return consoleUser(ctx, secretutil.NewSecretManager(*s.Logger, s.Client, s.APIReader), s.HetznerCluster)
+}
+
+// ConsoleUser returns the console user of the cluster, or nil if none is configured.
+func (s *BareMetalHostScope) ConsoleUser(ctx context.Context) (*ConsoleUser, error) {
+	return consoleUser(ctx, s.SecretManager, s.HetznerCluster)
+}
+
+func consoleUser(ctx context.Context, secretManager *secretutil.SecretManager, hetznerCluster *infrav1.HetznerCluster) (*ConsoleUser, error) {
+	spec := hetznerCluster.Spec.ConsoleUser
+	if spec == nil {
+		return nil, nil
+	}
+	ref := spec.PasswordSecretRef
+
+	secret, err := secretManager.ObtainSecret(ctx, types.NamespacedName{Namespace: hetznerCluster.Namespace, Name: ref.Name})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get secret %s of console user", ref.Name)
+	}
+
+	key := ref.Key
+	if key == "" {
+		key = infrav1.DefaultConsoleUserPasswordKey
+	}
+	passwordHash := strings.TrimSpace(string(secret.Data[key]))
+	if passwordHash == "" {
+		return nil, errors.Errorf("key %q of console user is missing in secret %s", key, ref.Name)
+	}
+	// The hash is written to /etc/shadow and passed to a shell, plain passwords are rejected
+	if !strings.HasPrefix(passwordHash, "$") || strings.ContainsAny(passwordHash, ": \t\n'\"\\") {
+		return nil, errors.Errorf("password of console user in secret %s is not hashed like crypt(3) does", ref.Name)
+	}
+
+	name := spec.Name
+	if name == "" {
+		name = infrav1.DefaultConsoleUserName
+	}
+	return &ConsoleUser{Name: name, PasswordHash: passwordHash}, nil
+}
+
+// ConsoleUserChange describes how the desired console user differs from the applied one. It is empty if
+// they are the same.
+func ConsoleUserChange(applied, desired *infrav1.AppliedConsoleUser) string {
+	switch {
+	case applied == nil && desired == nil:
+		return ""
+	case applied == nil:
+		return fmt.Sprintf("console user %q has been added", desired.Name)
+	case desired == nil:
+		return fmt.Sprintf("console user %q has been removed", applied.Name)
+	case applied.Name != desired.Name:
+		return fmt.Sprintf("console user %q has been replaced by %q", applied.Name, desired.Name)
+	case applied.PasswordHash != desired.PasswordHash:
+		return fmt.Sprintf("password of console user %q has been changed", desired.Name)
+	}
+	return ""
+}
@@ -35,6 +35,7 @@ type HCloudMachineTemplateScopeParams struct {
 	Logger                *logr.Logger
 	HCloudClient          hcloudclient.Client
 	HCloudMachineTemplate *infrav1.HCloudMachineTemplate
+	HetznerCluster        *infrav1.HetznerCluster
 }
 
 // NewHCloudMachineTemplateScope creates a new Scope from the supplied parameters.
@@ -43,6 +44,9 @@ func NewHCloudMachineTemplateScope(ctx context.Context, params HCloudMachineTemp
 	if params.HCloudClient == nil {
 		return nil, errors.New("failed to generate new scope from nil HCloudClient")
 	}
+	if params.HetznerCluster == nil {
+		return nil, errors.New("failed to generate new scope from nil HetznerCluster")
+	}
 
 	if params.Logger == nil {
 		logger := klogr.New()
@@ -59,6 +63,7 @@ func NewHCloudMachineTemplateScope(ctx context.Context, params HCloudMachineTemp
 		Client:                params.Client,
 		HCloudMachineTemplate: params.HCloudMachineTemplate,
 		HCloudClient:          params.HCloudClient,
+		HetznerCluster:        params.HetznerCluster,
 		patchHelper:           helper,
 	}, nil
 }
@@ -71,6 +76,7 @@ type HCloudMachineTemplateScope struct {
 	HCloudClient hcloudclient.Client
 
 	HCloudMachineTemplate *infrav1.HCloudMachineTemplate
+	HetznerCluster        *infrav1.HetznerCluster
 }
 
 // Name returns the HCloudMachineTemplate name.
@@ -0,0 +1,100 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package scope defines cluster and machine scope as well as a repository for the Hetzner API.
+package scope
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	hcloudclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client"
+	"k8s.io/klog/v2/klogr"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// HCloudPrimaryIPScopeParams defines the input parameters used to create a new scope.
+type HCloudPrimaryIPScopeParams struct {
+	Client          client.Client
+	Logger          *logr.Logger
+	HCloudClient    hcloudclient.Client
+	HetznerCluster  *infrav1.HetznerCluster
+	HCloudPrimaryIP *infrav1.HCloudPrimaryIP
+}
+
+// NewHCloudPrimaryIPScope creates a new Scope from the supplied parameters.
+// This is meant to be called for each reconcile iteration.
+func NewHCloudPrimaryIPScope(ctx context.Context, params HCloudPrimaryIPScopeParams) (*HCloudPrimaryIPScope, error) {
+	if params.HCloudClient == nil {
+		return nil, errors.New("failed to generate new scope from nil HCloudClient")
+	}
+	if params.HetznerCluster == nil {
+		return nil, errors.New("failed to generate new scope from nil HetznerCluster")
+	}
+
+	if params.Logger == nil {
+		logger := klogr.New()
+		params.Logger = &logger
+	}
+
+	helper, err := patch.NewHelper(params.HCloudPrimaryIP, params.Client)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to init patch helper")
+	}
+
+	return &HCloudPrimaryIPScope{
+		Logger:          params.Logger,
+		Client:          params.Client,
+		HetznerCluster:  params.HetznerCluster,
+		HCloudPrimaryIP: params.HCloudPrimaryIP,
+		HCloudClient:    params.HCloudClient,
+		patchHelper:     helper,
+	}, nil
+}
+
+// HCloudPrimaryIPScope defines the basic context for an actuator to operate upon.
+type HCloudPrimaryIPScope struct {
+	*logr.Logger
+	Client       client.Client
+	patchHelper  *patch.Helper
+	HCloudClient hcloudclient.Client
+
+	HetznerCluster  *infrav1.HetznerCluster
+	HCloudPrimaryIP *infrav1.HCloudPrimaryIP
+}
+
+// Name returns the HCloudPrimaryIP name.
+func (s *HCloudPrimaryIPScope) Name() string {
+	return s.HCloudPrimaryIP.Name
+}
+
+// Namespace returns the namespace name.
+func (s *HCloudPrimaryIPScope) Namespace() string {
+	return s.HCloudPrimaryIP.Namespace
+}
+
+// Close closes the current scope persisting the primary IP configuration and status.
+func (s *HCloudPrimaryIPScope) Close(ctx context.Context) error {
+	return s.patchHelper.Patch(ctx, s.HCloudPrimaryIP)
+}
+
+// PatchObject persists the primary IP spec and status.
+func (s *HCloudPrimaryIPScope) PatchObject(ctx context.Context) error {
+	return s.patchHelper.Patch(ctx, s.HCloudPrimaryIP)
+}
@@ -111,6 +111,14 @@ func (m *MachineScope) SetError(message string, reason capierrors.MachineStatusE
 	m.HCloudMachine.Status.FailureReason = &reason
 }
 
+// DNS returns the resolver configuration of the machine, falling back to the cluster wide one.
+func (m *MachineScope) DNS() *infrav1.DNSSpec {
+	if m.HCloudMachine.Spec.DNS != nil {
+		return m.HCloudMachine.Spec.DNS
+	}
+	return m.HetznerCluster.Spec.DNS
+}
+
 // IsBootstrapDataReady checks the readiness of a capi machine's bootstrap data.
 func (m *MachineScope) IsBootstrapDataReady(ctx context.Context) bool {
 	return m.Machine.Spec.Bootstrap.DataSecretName != nil
@@ -122,16 +130,7 @@ func (m *MachineScope) GetFailureDomain() (string, error) {
 		return *m.Machine.Spec.FailureDomain, nil
 	}
 
-	failureDomainNames := make([]string, 0, len(m.Cluster.Status.FailureDomains))
-	for fdName, fd := range m.Cluster.Status.FailureDomains {
-		// filter out zones if we are a control plane and the cluster object
-		// wants to avoid contorl planes in that zone
-		if m.IsControlPlane() && !fd.ControlPlane {
-			continue
-		}
-		failureDomainNames = append(failureDomainNames, fdName)
-	}
-
+	failureDomainNames := m.FailureDomains()
 	if len(failureDomainNames) == 0 {
 		return "", ErrFailureDomainNotFound
 	}
@@ -139,14 +138,28 @@ func (m *MachineScope) GetFailureDomain() (string, error) {
 		return failureDomainNames[0], nil
 	}
 
-	sort.Strings(failureDomainNames)
-
 	// assign the node a zone based on a hash
 	pos := int(crc32.ChecksumIEEE([]byte(m.HCloudMachine.Name))) % len(failureDomainNames)
 
 	return failureDomainNames[pos], nil
 }
 
+// FailureDomains returns the sorted names of all failure domains the machine can be placed in.
+func (m *MachineScope) FailureDomains() []string {
+	failureDomainNames := make([]string, 0, len(m.Cluster.Status.FailureDomains))
+	for fdName, fd := range m.Cluster.Status.FailureDomains {
+		// filter out zones if we are a control plane and the cluster object
+		// wants to avoid contorl planes in that zone
+		if m.IsControlPlane() && !fd.ControlPlane {
+			continue
+		}
+		failureDomainNames = append(failureDomainNames, fdName)
+	}
+
+	sort.Strings(failureDomainNames)
+	return failureDomainNames
+}
+
 // GetRawBootstrapData returns the bootstrap data from the secret in the Machine's bootstrap.dataSecretName.
 func (m *MachineScope) GetRawBootstrapData(ctx context.Context) ([]byte, error) {
 	if m.Machine.Spec.Bootstrap.DataSecretName == nil {
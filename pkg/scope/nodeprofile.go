@@ -0,0 +1,48 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// NodeProfile returns the spec of the HetznerNodeProfile of the machine, or nil if none is referenced.
+func (m *MachineScope) NodeProfile(ctx context.Context) (*infrav1.HetznerNodeProfileSpec, error) {
+	return nodeProfile(ctx, m.Client, m.Namespace(), m.HCloudMachine.Spec.NodeProfileRef)
+}
+
+// NodeProfile returns the spec of the HetznerNodeProfile of the machine, or nil if none is referenced.
+func (m *BareMetalMachineScope) NodeProfile(ctx context.Context) (*infrav1.HetznerNodeProfileSpec, error) {
+	return nodeProfile(ctx, m.Client, m.Namespace(), m.BareMetalMachine.Spec.NodeProfileRef)
+}
+
+func nodeProfile(ctx context.Context, c client.Client, namespace, name string) (*infrav1.HetznerNodeProfileSpec, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	var profile infrav1.HetznerNodeProfile
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &profile); err != nil {
+		return nil, errors.Wrapf(err, "failed to get HetznerNodeProfile %s", name)
+	}
+	return &profile.Spec, nil
+}
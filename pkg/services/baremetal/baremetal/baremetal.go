@@ -30,7 +30,10 @@ import (
 	"github.com/hetznercloud/hcloud-go/hcloud"
 	"github.com/pkg/errors"
 	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/metrics"
 	"github.com/syself/cluster-api-provider-hetzner/pkg/scope"
+	hostservice "github.com/syself/cluster-api-provider-hetzner/pkg/services/baremetal/host"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/utils"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -38,13 +41,11 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/selection"
-	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/utils/pointer"
 	capi "sigs.k8s.io/cluster-api/api/v1beta1"
 	capierrors "sigs.k8s.io/cluster-api/errors"
 	"sigs.k8s.io/cluster-api/util/conditions"
-	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/cluster-api/util/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -81,6 +82,10 @@ func (s *Service) Reconcile(ctx context.Context) (_ *ctrl.Result, err error) {
 
 	log.Info("Reconciling baremetal machine", "name", s.scope.BareMetalMachine.Name)
 
+	if _, found := s.scope.BareMetalMachine.Annotations[infrav1.RescheduleAnnotation]; found {
+		return s.reschedule(ctx)
+	}
+
 	// if the machine is already provisioned, update and return
 	if s.scope.IsProvisioned() {
 		errType := capierrors.UpdateMachineError
@@ -171,39 +176,7 @@ func (s *Service) Delete(ctx context.Context) (_ *ctrl.Result, err error) {
 			return nil, err
 		}
 
-		if removeMachineSpecsFromHost(host) {
-			// Update the BMH object, if the errors are NotFound, do not return the
-			// errors.
-			if err := patchIfFound(ctx, helper, host); err != nil {
-				return nil, err
-			}
-
-			s.scope.Info("Patched BaremetalHost while deprovisioning, requeuing")
-			return nil, &scope.RequeueAfterError{}
-		}
-
-		if host.Spec.Status.ProvisioningState != infrav1.StateNone {
-			s.scope.Info("Deprovisioning BaremetalHost, requeuing", "host.Spec.Status.ProvisioningState", host.Spec.Status.ProvisioningState)
-			return nil, &scope.RequeueAfterError{RequeueAfter: requeueAfter}
-		}
-
-		host.Spec.ConsumerRef = nil
-		host.Spec.Status.HetznerClusterRef = ""
-		host.SetDeletionTimestamp(nil)
-
-		// Remove the ownerreference to this machine.
-		host.OwnerReferences, err = s.DeleteOwnerRef(host.OwnerReferences)
-		if err != nil {
-			return nil, err
-		}
-
-		if host.Labels != nil && host.Labels[capi.ClusterLabelName] == s.scope.Machine.Spec.ClusterName {
-			delete(host.Labels, capi.ClusterLabelName)
-		}
-
-		// Update the BMH object, if the errors are NotFound, do not return the
-		// errors.
-		if err := patchIfFound(ctx, helper, host); err != nil {
+		if err := s.releaseHost(ctx, host, helper); err != nil {
 			return nil, err
 		}
 	}
@@ -219,8 +192,109 @@ func (s *Service) Delete(ctx context.Context) (_ *ctrl.Result, err error) {
 	return nil, nil
 }
 
+// releaseHost deprovisions the host and removes its consumer ref. It returns a RequeueAfterError until the host
+// has been deprovisioned.
+func (s *Service) releaseHost(ctx context.Context, host *infrav1.HetznerBareMetalHost, helper *hostPatchHelper) (err error) {
+	if removeMachineSpecsFromHost(host) {
+		// Update the BMH object, if the errors are NotFound, do not return the
+		// errors.
+		if err := patchIfFound(ctx, helper, host); err != nil {
+			return err
+		}
+
+		s.scope.Info("Patched BaremetalHost while deprovisioning, requeuing")
+		return &scope.RequeueAfterError{}
+	}
+
+	// Quarantined hosts are kept as they are, they are released by removing the forensic hold
+	if host.Spec.Status.ProvisioningState != infrav1.StateNone && host.Spec.Status.ProvisioningState != infrav1.StateQuarantined {
+		s.scope.Info("Deprovisioning BaremetalHost, requeuing", "host.Spec.Status.ProvisioningState", host.Spec.Status.ProvisioningState)
+		return &scope.RequeueAfterError{RequeueAfter: requeueAfter}
+	}
+
+	host.Spec.ConsumerRef = nil
+	// Quarantined hosts keep their HetznerCluster, as it is needed to deprovision them once they are released
+	if host.Spec.Status.ProvisioningState != infrav1.StateQuarantined {
+		host.Spec.Status.HetznerClusterRef = ""
+	}
+	host.SetDeletionTimestamp(nil)
+
+	// Remove the ownerreference to this machine.
+	host.OwnerReferences, err = s.DeleteOwnerRef(host.OwnerReferences)
+	if err != nil {
+		return err
+	}
+
+	if host.Labels != nil && host.Labels[capi.ClusterLabelName] == s.scope.Machine.Spec.ClusterName {
+		delete(host.Labels, capi.ClusterLabelName)
+	}
+
+	// Update the BMH object, if the errors are NotFound, do not return the
+	// errors.
+	return patchIfFound(ctx, helper, host)
+}
+
+// reschedule releases the host of a machine that is not provisioned yet, e.g. because the host turned out to be
+// unhealthy, so that the same machine is provisioned on another host. Provisioned machines are not rescheduled,
+// as their provider ID cannot change.
+func (s *Service) reschedule(ctx context.Context) (*ctrl.Result, error) {
+	bmMachine := s.scope.BareMetalMachine
+	if bmMachine.Spec.ProviderID != nil {
+		delete(bmMachine.Annotations, infrav1.RescheduleAnnotation)
+		record.Warnf(bmMachine, "RescheduleRejected", "Machine is provisioned and cannot be rescheduled, delete the Machine instead")
+		return &ctrl.Result{}, nil
+	}
+
+	host, helper, err := s.getHost(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get host")
+	}
+
+	if host != nil && host.Spec.ConsumerRef != nil && consumerRefMatches(host.Spec.ConsumerRef, bmMachine) {
+		if s.scope.IsControlPlane() && s.scope.HetznerCluster.Spec.ControlPlaneLoadBalancer.Enabled {
+			if err := s.deleteServerOfLoadBalancer(ctx, host); err != nil {
+				return nil, errors.Wrap(err, "failed to delete attached server of load balancer")
+			}
+		}
+
+		if err := s.releaseHost(ctx, host, helper); err != nil {
+			if requeueErr, ok := errors.Cause(err).(scope.HasRequeueAfterError); ok {
+				return &ctrl.Result{Requeue: true, RequeueAfter: requeueErr.GetRequeueAfter()}, nil
+			}
+			return nil, errors.Wrap(err, "failed to release host")
+		}
+
+		if !utils.StringInList(bmMachine.Status.ReleasedHosts, host.Name) {
+			bmMachine.Status.ReleasedHosts = append(bmMachine.Status.ReleasedHosts, host.Name)
+		}
+		record.Eventf(bmMachine, "Rescheduled", "Released host %s, another host is chosen for the machine", host.Name)
+	}
+
+	delete(bmMachine.Annotations, infrav1.HostAnnotation)
+	delete(bmMachine.Annotations, infrav1.RescheduleAnnotation)
+	bmMachine.Status.Addresses = nil
+	bmMachine.Status.Milestones = nil
+	bmMachine.Status.FailureReason = nil
+	bmMachine.Status.FailureMessage = nil
+	conditions.Delete(bmMachine, infrav1.AssociateBMHCondition)
+	return &ctrl.Result{Requeue: true}, nil
+}
+
+// removeMachineSpecsFromHost removes the fields of the machine from the host, which starts its deprovisioning. The
+// host controller clears the status of the provisioning itself once the host has been deprovisioned.
 func removeMachineSpecsFromHost(host *infrav1.HetznerBareMetalHost) (updatedHost bool) {
+	if _, fenced := host.Annotations[infrav1.FenceAnnotation]; fenced {
+		delete(host.Annotations, infrav1.FenceAnnotation)
+		updatedHost = true
+	}
 	if host.Spec.Status.InstallImage != nil {
+		// confirms the deprovisioning of the host, which removing the image triggers
+		if host.RemovingInstallImageDeprovisions() {
+			if host.Annotations == nil {
+				host.Annotations = make(map[string]string)
+			}
+			host.Annotations[infrav1.ApproveDeprovisionAnnotation] = ""
+		}
 		host.Spec.Status.InstallImage = nil
 		updatedHost = true
 	}
@@ -228,13 +302,28 @@ func removeMachineSpecsFromHost(host *infrav1.HetznerBareMetalHost) (updatedHost
 		host.Spec.Status.UserData = nil
 		updatedHost = true
 	}
-	if host.Spec.Status.SSHSpec != nil {
-		host.Spec.Status.SSHSpec = nil
+	if host.Spec.Status.BootstrapDataChangePolicy != "" {
+		host.Spec.Status.BootstrapDataChangePolicy = ""
 		updatedHost = true
 	}
-	var emptySSHStatus = infrav1.SSHStatus{}
-	if host.Spec.Status.SSHStatus != emptySSHStatus {
-		host.Spec.Status.SSHStatus = emptySSHStatus
+	if host.Spec.Status.DNS != nil {
+		host.Spec.Status.DNS = nil
+		updatedHost = true
+	}
+	if host.Spec.Status.NodeProfile != nil {
+		host.Spec.Status.NodeProfile = nil
+		updatedHost = true
+	}
+	if host.Spec.Status.KubernetesVersion != "" {
+		host.Spec.Status.KubernetesVersion = ""
+		updatedHost = true
+	}
+	if host.Spec.Status.ProvisioningChecks != nil {
+		host.Spec.Status.ProvisioningChecks = nil
+		updatedHost = true
+	}
+	if host.Spec.Status.SSHSpec != nil {
+		host.Spec.Status.SSHSpec = nil
 		updatedHost = true
 	}
 	return updatedHost
@@ -262,7 +351,10 @@ func (s *Service) update(ctx context.Context, log logr.Logger) error {
 		return nil
 	}
 
-	if host.Spec.Status.ErrorType == infrav1.FatalError && s.scope.BareMetalMachine.Status.FailureReason == nil {
+	// Fatal errors and permanent failures of the hardware cannot be resolved on this host. The machine fails,
+	// so that it can be remediated with another host.
+	if (host.Spec.Status.ErrorType == infrav1.FatalError || host.Spec.Status.FailureClass == infrav1.FailureClassPermanentHardware) &&
+		s.scope.BareMetalMachine.Status.FailureReason == nil {
 		s.scope.BareMetalMachine.SetFailure(capierrors.UpdateMachineError, host.Spec.Status.ErrorMessage)
 		record.Eventf(
 			s.scope.BareMetalMachine,
@@ -289,7 +381,9 @@ func (s *Service) update(ctx context.Context, log logr.Logger) error {
 	}
 
 	// ensure that the host's specs are correctly set
-	s.setHostSpec(host)
+	if err := s.setHostSpec(ctx, host); err != nil {
+		return errors.Wrap(err, "failed to set host spec")
+	}
 
 	err = helper.Patch(ctx, host)
 	if err != nil {
@@ -430,6 +524,12 @@ func (s *Service) deleteServerOfLoadBalancer(ctx context.Context, host *infrav1.
 func (s *Service) associate(ctx context.Context, log logr.Logger) error {
 	log.Info("Associating machine", "machine", s.scope.Machine.Name)
 
+	if s.scope.SSHSpec().SecretRef.Name == "" {
+		// The cluster wide defaults might still be set, so we wait instead of failing the machine.
+		record.Warn(s.scope.BareMetalMachine, "NoSSHSecret", "No SSH secret specified in sshSpec of machine or sshDefaults of HetznerCluster")
+		return &scope.RequeueAfterError{RequeueAfter: requeueAfter}
+	}
+
 	// look for associated BMH
 	host, helper, err := s.getHost(ctx)
 	if err != nil {
@@ -473,16 +573,14 @@ func (s *Service) associate(ctx context.Context, log logr.Logger) error {
 	}
 
 	// ensure that the host's specs are correctly set
-	s.setHostSpec(host)
+	if err := s.setHostSpec(ctx, host); err != nil {
+		return errors.Wrap(err, "failed to set host spec")
+	}
 
 	err = helper.Patch(ctx, host)
 	if err != nil {
-		if aggr, ok := err.(kerrors.Aggregate); ok {
-			for _, kerr := range aggr.Errors() {
-				if apierrors.IsConflict(kerr) {
-					return &scope.RequeueAfterError{}
-				}
-			}
+		if apierrors.IsConflict(err) {
+			return &scope.RequeueAfterError{}
 		}
 		return err
 	}
@@ -504,7 +602,7 @@ func (s *Service) associate(ctx context.Context, log logr.Logger) error {
 // getHost gets the associated host by looking for an annotation on the machine
 // that contains a reference to the host. Returns nil if not found. Assumes the
 // host is in the same namespace as the machine.
-func (s *Service) getHost(ctx context.Context) (*infrav1.HetznerBareMetalHost, *patch.Helper, error) {
+func (s *Service) getHost(ctx context.Context) (*infrav1.HetznerBareMetalHost, *hostPatchHelper, error) {
 	annotations := s.scope.BareMetalMachine.ObjectMeta.GetAnnotations()
 	if annotations == nil {
 		return nil, nil, nil
@@ -531,11 +629,10 @@ func (s *Service) getHost(ctx context.Context) (*infrav1.HetznerBareMetalHost, *
 	} else if err != nil {
 		return nil, nil, err
 	}
-	helper, err := patch.NewHelper(&host, s.scope.Client)
-	return &host, helper, err
+	return &host, newHostPatchHelper(s.scope.Client, &host), nil
 }
 
-func (s *Service) chooseHost(ctx context.Context) (*infrav1.HetznerBareMetalHost, *patch.Helper, error) {
+func (s *Service) chooseHost(ctx context.Context) (*infrav1.HetznerBareMetalHost, *hostPatchHelper, error) {
 	// get list of BMH
 	hosts := infrav1.HetznerBareMetalHostList{}
 	// without this ListOption, all namespaces would be including in the listing
@@ -557,13 +654,16 @@ func (s *Service) chooseHost(ctx context.Context) (*infrav1.HetznerBareMetalHost
 	for i, host := range hosts.Items {
 		if host.Spec.ConsumerRef != nil && consumerRefMatches(host.Spec.ConsumerRef, s.scope.BareMetalMachine) {
 			s.scope.Info("Found host with existing ConsumerRef", "host", host.Name)
-			helper, err := patch.NewHelper(&hosts.Items[i], s.scope.Client)
-			return &hosts.Items[i], helper, err
+			return &hosts.Items[i], newHostPatchHelper(s.scope.Client, &hosts.Items[i]), nil
 		}
 		if host.Spec.ConsumerRef != nil {
 			continue
 		}
-		if host.Spec.MaintenanceMode {
+		if host.Spec.MaintenanceMode || host.HasForensicHold() {
+			continue
+		}
+		if utils.StringInList(s.scope.BareMetalMachine.Status.ReleasedHosts, host.Name) {
+			s.scope.Info(fmt.Sprintf("Host %v has been released by rescheduling the HetznerBareMetalMachine", host.Name))
 			continue
 		}
 		if host.GetDeletionTimestamp() != nil {
@@ -572,6 +672,23 @@ func (s *Service) chooseHost(ctx context.Context) (*infrav1.HetznerBareMetalHost
 		if host.Spec.Status.ErrorMessage != "" {
 			continue
 		}
+		isReserved, err := host.IsReservedFor(s.scope.BareMetalMachine)
+		if err != nil {
+			s.scope.Error(err, "Failed to check reservation of host", "host", host.Name)
+			continue
+		}
+		if !isReserved {
+			s.scope.Info(fmt.Sprintf("Host %v is reserved for other HetznerBareMetalMachines", host.Name))
+			continue
+		}
+		if !s.scope.HetznerCluster.Spec.PlacementConstraints.IsDatacenterAllowed(host.Spec.Status.Datacenter) {
+			s.scope.Info(fmt.Sprintf("Host %v is in datacenter %q, which is not allowed by the placement constraints", host.Name, host.Spec.Status.Datacenter))
+			continue
+		}
+		if s.scope.SSHSpec().PrivateProvisioning != nil && host.Spec.PrivateIP == "" {
+			s.scope.Info(fmt.Sprintf("Host %v has no private IP, which is required for private provisioning", host.Name))
+			continue
+		}
 
 		if labelSelector.Matches(labels.Set(host.ObjectMeta.Labels)) {
 			if host.Spec.Status.ProvisioningState == infrav1.StateNone {
@@ -593,8 +710,7 @@ func (s *Service) chooseHost(ctx context.Context) (*infrav1.HetznerBareMetalHost
 	s.scope.Info(fmt.Sprintf("%d host(s) available, choosing a random host", len(availableHosts)))
 	chosenHost := availableHosts[rand.Intn(len(availableHosts))] // #nosec
 
-	helper, err := patch.NewHelper(chosenHost, s.scope.Client)
-	return chosenHost, helper, err
+	return chosenHost, newHostPatchHelper(s.scope.Client, chosenHost), nil
 }
 
 func (s *Service) getLabelSelector() (labels.Selector, error) {
@@ -657,7 +773,7 @@ func (s *Service) GetBaremetalHostID(ctx context.Context) (*string, error) {
 
 // setHostSpec will ensure the host's Spec is set according to the machine's
 // details. It will then update the host via the kube API.
-func (s *Service) setHostSpec(host *infrav1.HetznerBareMetalHost) {
+func (s *Service) setHostSpec(ctx context.Context, host *infrav1.HetznerBareMetalHost) error {
 	// We only want to update the image setting if the host does not
 	// already have an image.
 	//
@@ -667,37 +783,78 @@ func (s *Service) setHostSpec(host *infrav1.HetznerBareMetalHost) {
 	// Not provisioning while we do not have the UserData.
 
 	if host.Spec.Status.InstallImage == nil && s.scope.Machine.Spec.Bootstrap.DataSecretName != nil {
+		nodeProfile, err := s.scope.NodeProfile(ctx)
+		if err != nil {
+			record.Warnf(s.scope.BareMetalMachine, "FailedGetNodeProfile", err.Error())
+			return err
+		}
+		host.Spec.Status.NodeProfile = nodeProfile
 		host.Spec.Status.InstallImage = &s.scope.BareMetalMachine.Spec.InstallImage
 		host.Spec.Status.UserData = &corev1.SecretReference{Namespace: s.scope.Namespace(), Name: *s.scope.Machine.Spec.Bootstrap.DataSecretName}
-		host.Spec.Status.SSHSpec = &s.scope.BareMetalMachine.Spec.SSHSpec
+		host.Spec.Status.SSHSpec = s.scope.SSHSpec()
 		host.Spec.Status.HetznerClusterRef = s.scope.HetznerCluster.Name
+		host.Spec.Status.BootstrapDataChangePolicy = s.scope.BareMetalMachine.Spec.BootstrapDataChangePolicy
+		host.Spec.Status.DNS = s.scope.DNS()
+		host.Spec.Status.ProvisioningChecks = s.scope.BareMetalMachine.Spec.ProvisioningChecks
+		if s.scope.Machine.Spec.Version != nil {
+			host.Spec.Status.KubernetesVersion = *s.scope.Machine.Spec.Version
+		}
+	}
+
+	// The bootstrap provider might reference a new secret, e.g. after the bootstrap config has been regenerated.
+	// The host compares the user data with the one it has been provisioned with and acts according to the policy.
+	if host.Spec.Status.UserData != nil && s.scope.Machine.Spec.Bootstrap.DataSecretName != nil &&
+		host.Spec.Status.UserData.Name != *s.scope.Machine.Spec.Bootstrap.DataSecretName {
+		host.Spec.Status.UserData.Name = *s.scope.Machine.Spec.Bootstrap.DataSecretName
 	}
+	return nil
 }
 
-func patchIfFound(ctx context.Context, helper *patch.Helper, host client.Object) error {
+func patchIfFound(ctx context.Context, helper *hostPatchHelper, host *infrav1.HetznerBareMetalHost) error {
 	err := helper.Patch(ctx, host)
-	if err != nil {
-		notFound := true
-		var aggr kerrors.Aggregate
-		if ok := errors.As(err, &aggr); ok {
-			for _, kerr := range aggr.Errors() {
-				if !apierrors.IsNotFound(kerr) {
-					notFound = false
-				}
-				if apierrors.IsConflict(kerr) {
-					return &scope.RequeueAfterError{}
-				}
-			}
-		} else {
-			notFound = false
-		}
-		if notFound {
-			return nil
-		}
+	switch {
+	case apierrors.IsNotFound(err):
+		return nil
+	case apierrors.IsConflict(err):
+		return &scope.RequeueAfterError{}
 	}
 	return err
 }
 
+// hostPatchHelper patches the fields of a host that are owned by the HetznerBareMetalMachine controller, see
+// hostservice.MachineStatusFields. Only the changes are sent, so that they neither conflict with nor overwrite the
+// status that the host controller applies concurrently. Claiming a free host is the exception: it is bound to the
+// resource version, so that a host is never claimed by two machines.
+type hostPatchHelper struct {
+	client client.Client
+	before *infrav1.HetznerBareMetalHost
+}
+
+func newHostPatchHelper(c client.Client, host *infrav1.HetznerBareMetalHost) *hostPatchHelper {
+	return &hostPatchHelper{client: c, before: host.DeepCopy()}
+}
+
+// Patch sends the changes of the host since the helper has been created or since the last patch.
+func (h *hostPatchHelper) Patch(ctx context.Context, host *infrav1.HetznerBareMetalHost) error {
+	patch := client.MergeFrom(h.before)
+	if h.before.Spec.ConsumerRef == nil && host.Spec.ConsumerRef != nil {
+		patch = client.MergeFromWithOptions(h.before, client.MergeFromWithOptimisticLock{})
+	}
+	data, err := patch.Data(host)
+	if err != nil {
+		return errors.Wrap(err, "failed to compute patch of host")
+	}
+	if string(data) == "{}" {
+		return nil
+	}
+
+	if err := h.client.Patch(ctx, host, patch, client.FieldOwner(hostservice.MachineControllerFieldOwner)); err != nil {
+		return err
+	}
+	h.before = host.DeepCopy()
+	return nil
+}
+
 // setHostConsumerRef will ensure the host's Spec is set to link to this Hetzner bare metalMachine.
 func (s *Service) setHostConsumerRef(host *infrav1.HetznerBareMetalHost) error {
 	if host.Spec.ConsumerRef == nil || host.Spec.ConsumerRef.Name != s.scope.BareMetalMachine.Name {
@@ -726,6 +883,7 @@ func (s *Service) updateMachineStatus(host *infrav1.HetznerBareMetalHost) {
 
 	s.scope.BareMetalMachine.Status.Addresses = addrs
 	conditions.MarkTrue(s.scope.BareMetalMachine, infrav1.AssociateBMHCondition)
+	s.reconcileMilestones(host)
 
 	// Update lastUpdated when status changed
 	if !equality.Semantic.DeepEqual(s.scope.BareMetalMachine.Status, bareMetalMachineOld.Status) {
@@ -734,6 +892,35 @@ func (s *Service) updateMachineStatus(host *infrav1.HetznerBareMetalHost) {
 	}
 }
 
+// reconcileMilestones records the provisioning milestones of the machine. The infrastructure is created when the
+// host is associated, and the bootstrap data is delivered when the host has been provisioned with it and reboots
+// into the installed operating system. Machines that were already provisioned when the milestones were introduced
+// get none.
+func (s *Service) reconcileMilestones(host *infrav1.HetznerBareMetalHost) {
+	machine := s.scope.BareMetalMachine
+	now := metav1.Now()
+	if machine.Status.Milestones == nil {
+		if machine.Status.Ready {
+			return
+		}
+		machine.Status.Milestones = &infrav1.ProvisioningMilestones{InfrastructureCreated: &now}
+	}
+
+	milestones := machine.Status.Milestones
+	if milestones.BootstrapDataDelivered == nil {
+		switch host.Spec.Status.ProvisioningState {
+		case infrav1.StateEnsureProvisioned, infrav1.StateProvisioned:
+			milestones.BootstrapDataDelivered = &now
+		}
+	}
+
+	var product string
+	if host.Spec.Status.RobotServer != nil {
+		product = host.Spec.Status.RobotServer.Product
+	}
+	metrics.ObserveNodeReady(s.scope.Machine, machine, "HetznerBareMetalMachine", product, milestones)
+}
+
 // NodeAddresses returns a slice of corev1.NodeAddress objects for a
 // given HetznerBareMetal machine.
 func nodeAddresses(host *infrav1.HetznerBareMetalHost, bareMetalMachineName string) []corev1.NodeAddress {
@@ -752,6 +939,14 @@ func nodeAddresses(host *infrav1.HetznerBareMetalHost, bareMetalMachineName stri
 		addrs = append(addrs, address)
 	}
 
+	// The private IP is configured in the installed operating system and therefore not part of the hardware details.
+	if host.Spec.PrivateIP != "" {
+		addrs = append(addrs, corev1.NodeAddress{
+			Type:    corev1.NodeInternalIP,
+			Address: host.Spec.PrivateIP,
+		})
+	}
+
 	// Add hostname == bareMetalMachineName as well
 	addrs = append(addrs, corev1.NodeAddress{
 		Type:    corev1.NodeHostName,
@@ -45,6 +45,7 @@ func newTestService(
 			Logger:           &log,
 			Client:           client,
 			BareMetalMachine: bmMachine,
+			HetznerCluster:   &infrav1.HetznerCluster{},
 		},
 	}
 }
@@ -90,6 +90,19 @@ var _ = Describe("chooseHost", func() {
 		},
 	}
 
+	hostWithForensicHold := infrav1.HetznerBareMetalHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "hostWithForensicHold",
+			Namespace:   defaultNamespace,
+			Annotations: map[string]string{infrav1.ForensicHoldAnnotation: "incident 42"},
+		},
+		Spec: infrav1.HetznerBareMetalHostSpec{
+			Status: infrav1.ControllerGeneratedStatus{
+				ProvisioningState: infrav1.StateNone,
+			},
+		},
+	}
+
 	now := metav1.Now()
 	hostWithDeletionTimeStamp := infrav1.HetznerBareMetalHost{
 		ObjectMeta: metav1.ObjectMeta{
@@ -194,10 +207,96 @@ var _ = Describe("chooseHost", func() {
 		},
 	}
 
+	hostWithPrivateIP := infrav1.HetznerBareMetalHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hostWithPrivateIP",
+			Namespace: defaultNamespace,
+		},
+		Spec: infrav1.HetznerBareMetalHostSpec{
+			PrivateIP: "10.0.0.2",
+			Status: infrav1.ControllerGeneratedStatus{
+				ProvisioningState: infrav1.StateNone,
+			},
+		},
+	}
+
+	hostReservedByName := infrav1.HetznerBareMetalHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hostReservedByName",
+			Namespace: defaultNamespace,
+		},
+		Spec: infrav1.HetznerBareMetalHostSpec{
+			Reservation: &infrav1.HostReservation{MachineNames: []string{"bm-machine"}},
+			Status: infrav1.ControllerGeneratedStatus{
+				ProvisioningState: infrav1.StateNone,
+			},
+		},
+	}
+
+	hostReservedByLabel := infrav1.HetznerBareMetalHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hostReservedByLabel",
+			Namespace: defaultNamespace,
+		},
+		Spec: infrav1.HetznerBareMetalHostSpec{
+			Reservation: &infrav1.HostReservation{
+				MachineSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{clusterv1.MachineDeploymentLabelName: "gpu"},
+				},
+			},
+			Status: infrav1.ControllerGeneratedStatus{
+				ProvisioningState: infrav1.StateNone,
+			},
+		},
+	}
+
+	hostReservedForOthers := infrav1.HetznerBareMetalHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hostReservedForOthers",
+			Namespace: defaultNamespace,
+		},
+		Spec: infrav1.HetznerBareMetalHostSpec{
+			Reservation: &infrav1.HostReservation{MachineNames: []string{"bm-machine-other"}},
+			Status: infrav1.ControllerGeneratedStatus{
+				ProvisioningState: infrav1.StateNone,
+			},
+		},
+	}
+
+	hostInFSN1 := infrav1.HetznerBareMetalHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hostInFSN1",
+			Namespace: defaultNamespace,
+		},
+		Spec: infrav1.HetznerBareMetalHostSpec{
+			Status: infrav1.ControllerGeneratedStatus{
+				ProvisioningState: infrav1.StateNone,
+				Datacenter:        "FSN1-DC14",
+			},
+		},
+	}
+
+	hostInHEL1 := infrav1.HetznerBareMetalHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hostInHEL1",
+			Namespace: defaultNamespace,
+		},
+		Spec: infrav1.HetznerBareMetalHostSpec{
+			Status: infrav1.ControllerGeneratedStatus{
+				ProvisioningState: infrav1.StateNone,
+				Datacenter:        "HEL1-DC2",
+			},
+		},
+	}
+
 	type testCaseChooseHost struct {
-		Hosts            []client.Object
-		HostSelector     infrav1.HostSelector
-		ExpectedHostName string
+		Hosts                []client.Object
+		HostSelector         infrav1.HostSelector
+		MachineLabels        map[string]string
+		PrivateProvisioning  *infrav1.PrivateProvisioning
+		PlacementConstraints *infrav1.PlacementConstraints
+		ReleasedHosts        []string
+		ExpectedHostName     string
 	}
 	DescribeTable("chooseHost",
 		func(tc testCaseChooseHost) {
@@ -205,7 +304,11 @@ var _ = Describe("chooseHost", func() {
 			utilruntime.Must(infrav1.AddToScheme(scheme))
 			c := fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(tc.Hosts...).Build()
 			bmMachine.Spec.HostSelector = tc.HostSelector
+			bmMachine.Spec.SSHSpec.PrivateProvisioning = tc.PrivateProvisioning
+			bmMachine.Labels = tc.MachineLabels
+			bmMachine.Status.ReleasedHosts = tc.ReleasedHosts
 			service := newTestService(bmMachine, c)
+			service.scope.HetznerCluster.Spec.PlacementConstraints = tc.PlacementConstraints
 
 			host, _, err := service.chooseHost(context.TODO())
 			Expect(err).To(Succeed())
@@ -221,6 +324,17 @@ var _ = Describe("chooseHost", func() {
 				Hosts:            []client.Object{&hostInMaintenanceMode, &host},
 				ExpectedHostName: "host",
 			}),
+		Entry("No host with forensic hold",
+			testCaseChooseHost{
+				Hosts:            []client.Object{&hostWithForensicHold, &host},
+				ExpectedHostName: "host",
+			}),
+		Entry("No host released by rescheduling",
+			testCaseChooseHost{
+				Hosts:            []client.Object{&host, &hostInHEL1},
+				ReleasedHosts:    []string{"host"},
+				ExpectedHostName: "hostInHEL1",
+			}),
 		Entry("No host with deletion timestamp",
 			testCaseChooseHost{
 				Hosts:            []client.Object{&hostWithDeletionTimeStamp, &host},
@@ -265,6 +379,46 @@ var _ = Describe("chooseHost", func() {
 				}},
 				ExpectedHostName: "hostWithLabel",
 			}),
+		Entry("No host reserved for other machines",
+			testCaseChooseHost{
+				Hosts:            []client.Object{&hostReservedForOthers, &hostReservedByLabel, &host},
+				ExpectedHostName: "host",
+			}),
+		Entry("Choosing host reserved by name",
+			testCaseChooseHost{
+				Hosts:            []client.Object{&hostReservedByName, &hostReservedForOthers},
+				ExpectedHostName: "hostReservedByName",
+			}),
+		Entry("Choosing host reserved by label",
+			testCaseChooseHost{
+				Hosts:            []client.Object{&hostReservedByLabel, &hostReservedForOthers},
+				MachineLabels:    map[string]string{clusterv1.MachineDeploymentLabelName: "gpu"},
+				ExpectedHostName: "hostReservedByLabel",
+			}),
+		Entry("Choosing host with private IP for private provisioning",
+			testCaseChooseHost{
+				Hosts:               []client.Object{&host, &hostWithPrivateIP},
+				PrivateProvisioning: &infrav1.PrivateProvisioning{Bastion: infrav1.Bastion{Address: "bastion"}},
+				ExpectedHostName:    "hostWithPrivateIP",
+			}),
+		Entry("No host without private IP for private provisioning",
+			testCaseChooseHost{
+				Hosts:               []client.Object{&host},
+				PrivateProvisioning: &infrav1.PrivateProvisioning{Bastion: infrav1.Bastion{Address: "bastion"}},
+				ExpectedHostName:    "",
+			}),
+		Entry("Choosing host in allowed location",
+			testCaseChooseHost{
+				Hosts:                []client.Object{&host, &hostInHEL1, &hostInFSN1},
+				PlacementConstraints: &infrav1.PlacementConstraints{AllowedDatacenters: []string{"fsn1", "NBG1"}},
+				ExpectedHostName:     "hostInFSN1",
+			}),
+		Entry("No host in denied datacenter",
+			testCaseChooseHost{
+				Hosts:                []client.Object{&hostInFSN1, &hostInHEL1},
+				PlacementConstraints: &infrav1.PlacementConstraints{DeniedDatacenters: []string{"FSN1-DC14", "HEL1"}},
+				ExpectedHostName:     "",
+			}),
 	)
 })
 
@@ -335,6 +489,19 @@ var _ = Describe("Test NodeAddresses", func() {
 			},
 			ExpectedNodeAddresses: []corev1.NodeAddress{addr1, addr2, addr3, addr4},
 		}),
+		Entry("One NIC and private IP", testCaseNodeAddress{
+			Host: &infrav1.HetznerBareMetalHost{
+				Spec: infrav1.HetznerBareMetalHostSpec{
+					PrivateIP: "10.0.0.2",
+					Status: infrav1.ControllerGeneratedStatus{
+						HardwareDetails: &infrav1.HardwareDetails{
+							NIC: []infrav1.NIC{nic1},
+						},
+					},
+				},
+			},
+			ExpectedNodeAddresses: []corev1.NodeAddress{addr1, {Type: corev1.NodeInternalIP, Address: "10.0.0.2"}, addr3, addr4},
+		}),
 		Entry("No host", testCaseNodeAddress{
 			Host:                  nil,
 			ExpectedNodeAddresses: nil,
@@ -713,3 +880,212 @@ var _ = Describe("Test ensureAnnotation", func() {
 		}),
 	)
 })
+
+var _ = Describe("Test SSHSpec", func() {
+	secretRef := infrav1.SSHSecretRef{
+		Name: "ssh-secret",
+		Key:  infrav1.SSHSecretKeyRef{Name: "sshkey-name", PublicKey: "public-key", PrivateKey: "private-key"},
+	}
+	clusterSecretRef := infrav1.SSHSecretRef{
+		Name: "cluster-ssh-secret",
+		Key:  infrav1.SSHSecretKeyRef{Name: "sshkey-name", PublicKey: "public-key", PrivateKey: "private-key"},
+	}
+
+	type testCaseSSHSpec struct {
+		SSHSpec         infrav1.SSHSpec
+		SSHDefaults     *infrav1.SSHSpec
+		ExpectedSSHSpec infrav1.SSHSpec
+	}
+
+	DescribeTable("Test SSHSpec",
+		func(tc testCaseSSHSpec) {
+			bmMachine := &infrav1.HetznerBareMetalMachine{Spec: infrav1.HetznerBareMetalMachineSpec{SSHSpec: tc.SSHSpec}}
+			service := newTestService(bmMachine, nil)
+			service.scope.HetznerCluster.Spec.SSHDefaults = tc.SSHDefaults
+
+			Expect(*service.scope.SSHSpec()).To(Equal(tc.ExpectedSSHSpec))
+			Expect(bmMachine.Spec.SSHSpec).To(Equal(tc.SSHSpec))
+		},
+		Entry("no defaults", testCaseSSHSpec{
+			SSHSpec: infrav1.SSHSpec{SecretRef: secretRef},
+			ExpectedSSHSpec: infrav1.SSHSpec{
+				SecretRef:             secretRef,
+				PortAfterInstallImage: 22,
+				PortAfterCloudInit:    22,
+				UserAfterCloudInit:    "root",
+			},
+		}),
+		Entry("port after cloud init defaults to port after install image", testCaseSSHSpec{
+			SSHSpec: infrav1.SSHSpec{SecretRef: secretRef, PortAfterInstallImage: 2222},
+			ExpectedSSHSpec: infrav1.SSHSpec{
+				SecretRef:             secretRef,
+				PortAfterInstallImage: 2222,
+				PortAfterCloudInit:    2222,
+				UserAfterCloudInit:    "root",
+			},
+		}),
+		Entry("all fields from cluster defaults", testCaseSSHSpec{
+			SSHDefaults: &infrav1.SSHSpec{
+				SecretRef:             clusterSecretRef,
+				PortAfterInstallImage: 2222,
+				PortAfterCloudInit:    2223,
+				UserAfterCloudInit:    "admin",
+				PrivilegeEscalation:   "doas",
+				PrivateProvisioning:   &infrav1.PrivateProvisioning{Bastion: infrav1.Bastion{Address: "bastion"}},
+			},
+			ExpectedSSHSpec: infrav1.SSHSpec{
+				SecretRef:             clusterSecretRef,
+				PortAfterInstallImage: 2222,
+				PortAfterCloudInit:    2223,
+				UserAfterCloudInit:    "admin",
+				PrivilegeEscalation:   "doas",
+				PrivateProvisioning:   &infrav1.PrivateProvisioning{Bastion: infrav1.Bastion{Address: "bastion"}},
+			},
+		}),
+		Entry("machine overrides cluster defaults", testCaseSSHSpec{
+			SSHSpec: infrav1.SSHSpec{SecretRef: secretRef, PortAfterCloudInit: 24, UserAfterCloudInit: "capi"},
+			SSHDefaults: &infrav1.SSHSpec{
+				SecretRef:             clusterSecretRef,
+				PortAfterInstallImage: 23,
+				PortAfterCloudInit:    2223,
+				UserAfterCloudInit:    "admin",
+			},
+			ExpectedSSHSpec: infrav1.SSHSpec{
+				SecretRef:             secretRef,
+				PortAfterInstallImage: 23,
+				PortAfterCloudInit:    24,
+				UserAfterCloudInit:    "capi",
+			},
+		}),
+		Entry("defaulted port and user of the machine are taken from cluster defaults", testCaseSSHSpec{
+			SSHSpec: infrav1.SSHSpec{SecretRef: secretRef, PortAfterInstallImage: 22, UserAfterCloudInit: "root"},
+			SSHDefaults: &infrav1.SSHSpec{
+				PortAfterInstallImage: 2222,
+				UserAfterCloudInit:    "admin",
+			},
+			ExpectedSSHSpec: infrav1.SSHSpec{
+				SecretRef:             secretRef,
+				PortAfterInstallImage: 2222,
+				PortAfterCloudInit:    2222,
+				UserAfterCloudInit:    "admin",
+			},
+		}),
+	)
+})
+
+var _ = Describe("reconcileMilestones", func() {
+	newService := func(bmMachine *infrav1.HetznerBareMetalMachine) *Service {
+		service := newTestService(bmMachine, nil)
+		service.scope.Machine = &clusterv1.Machine{}
+		return service
+	}
+
+	It("records the creation of the infrastructure and the delivery of the bootstrap data", func() {
+		bmMachine := &infrav1.HetznerBareMetalMachine{}
+		service := newService(bmMachine)
+		host := &infrav1.HetznerBareMetalHost{}
+		host.Spec.Status.ProvisioningState = infrav1.StateImageInstalling
+
+		service.reconcileMilestones(host)
+		Expect(bmMachine.Status.Milestones).ToNot(BeNil())
+		Expect(bmMachine.Status.Milestones.InfrastructureCreated).ToNot(BeNil())
+		Expect(bmMachine.Status.Milestones.BootstrapDataDelivered).To(BeNil())
+
+		host.Spec.Status.ProvisioningState = infrav1.StateEnsureProvisioned
+		service.reconcileMilestones(host)
+		Expect(bmMachine.Status.Milestones.BootstrapDataDelivered).ToNot(BeNil())
+		Expect(bmMachine.Status.Milestones.NodeReady).To(BeNil())
+	})
+
+	It("does not record milestones of machines that are already provisioned", func() {
+		bmMachine := &infrav1.HetznerBareMetalMachine{Status: infrav1.HetznerBareMetalMachineStatus{Ready: true}}
+		service := newService(bmMachine)
+		host := &infrav1.HetznerBareMetalHost{}
+		host.Spec.Status.ProvisioningState = infrav1.StateProvisioned
+
+		service.reconcileMilestones(host)
+		Expect(bmMachine.Status.Milestones).To(BeNil())
+	})
+})
+
+var _ = Describe("reschedule", func() {
+	newService := func(bmMachine *infrav1.HetznerBareMetalMachine, objects ...client.Object) (*Service, client.Client) {
+		scheme := runtime.NewScheme()
+		utilruntime.Must(infrav1.AddToScheme(scheme))
+		c := fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+		service := newTestService(bmMachine, c)
+		service.scope.Machine = &clusterv1.Machine{}
+		return service, c
+	}
+
+	newMachine := func() *infrav1.HetznerBareMetalMachine {
+		return &infrav1.HetznerBareMetalMachine{
+			TypeMeta: metav1.TypeMeta{Kind: "HetznerBareMetalMachine", APIVersion: infrav1.GroupVersion.String()},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "bm-machine",
+				Namespace: "default",
+				Annotations: map[string]string{
+					infrav1.HostAnnotation:       "default/unhealthy-host",
+					infrav1.RescheduleAnnotation: "",
+				},
+			},
+		}
+	}
+
+	It("releases the host and chooses another one for the same machine", func() {
+		bmMachine := newMachine()
+		host := &infrav1.HetznerBareMetalHost{
+			ObjectMeta: metav1.ObjectMeta{Name: "unhealthy-host", Namespace: "default"},
+			Spec: infrav1.HetznerBareMetalHostSpec{
+				ConsumerRef: &corev1.ObjectReference{
+					Name:       "bm-machine",
+					Namespace:  "default",
+					Kind:       "HetznerBareMetalMachine",
+					APIVersion: infrav1.GroupVersion.String(),
+				},
+				Status: infrav1.ControllerGeneratedStatus{
+					ProvisioningState: infrav1.StateImageInstalling,
+					InstallImage:      &infrav1.InstallImage{},
+				},
+			},
+		}
+		service, c := newService(bmMachine, host)
+
+		// the host is deprovisioned first
+		res, err := service.reschedule(context.Background())
+		Expect(err).To(Succeed())
+		Expect(res.Requeue).To(BeTrue())
+		Expect(bmMachine.Annotations).To(HaveKey(infrav1.RescheduleAnnotation))
+
+		var updatedHost infrav1.HetznerBareMetalHost
+		Expect(c.Get(context.Background(), client.ObjectKeyFromObject(host), &updatedHost)).To(Succeed())
+		Expect(updatedHost.Spec.Status.InstallImage).To(BeNil())
+		Expect(updatedHost.Spec.ConsumerRef).ToNot(BeNil())
+
+		// the host controller has deprovisioned the host
+		updatedHost.Spec.Status.ProvisioningState = infrav1.StateNone
+		Expect(c.Update(context.Background(), &updatedHost)).To(Succeed())
+
+		res, err = service.reschedule(context.Background())
+		Expect(err).To(Succeed())
+		Expect(res.Requeue).To(BeTrue())
+		Expect(bmMachine.Annotations).ToNot(HaveKey(infrav1.RescheduleAnnotation))
+		Expect(bmMachine.Annotations).ToNot(HaveKey(infrav1.HostAnnotation))
+		Expect(bmMachine.Status.ReleasedHosts).To(Equal([]string{"unhealthy-host"}))
+
+		Expect(c.Get(context.Background(), client.ObjectKeyFromObject(host), &updatedHost)).To(Succeed())
+		Expect(updatedHost.Spec.ConsumerRef).To(BeNil())
+	})
+
+	It("does not reschedule provisioned machines", func() {
+		bmMachine := newMachine()
+		bmMachine.Spec.ProviderID = pointer.String("hcloud://bm-1")
+		service, _ := newService(bmMachine)
+
+		_, err := service.reschedule(context.Background())
+		Expect(err).To(Succeed())
+		Expect(bmMachine.Annotations).ToNot(HaveKey(infrav1.RescheduleAnnotation))
+		Expect(bmMachine.Annotations).To(HaveKey(infrav1.HostAnnotation))
+		Expect(bmMachine.Status.ReleasedHosts).To(BeEmpty())
+	})
+})
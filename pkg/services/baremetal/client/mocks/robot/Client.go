@@ -6,6 +6,10 @@ import (
 	mock "github.com/stretchr/testify/mock"
 	models "github.com/syself/hrobot-go/models"
 
+	robotclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/baremetal/client/robot"
+
+	time "time"
+
 	v1beta1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
 )
 
@@ -83,6 +87,52 @@ func (_m *Client) GetBootRescue(id int) (*models.Rescue, error) {
 	return r0, r1
 }
 
+// GetFirewall provides a mock function with given fields: _a0
+func (_m *Client) GetFirewall(_a0 int) (*robotclient.Firewall, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *robotclient.Firewall
+	if rf, ok := ret.Get(0).(func(int) *robotclient.Firewall); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*robotclient.Firewall)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMonthlyTraffic provides a mock function with given fields: ip, month
+func (_m *Client) GetMonthlyTraffic(ip string, month time.Time) (*robotclient.Traffic, error) {
+	ret := _m.Called(ip, month)
+
+	var r0 *robotclient.Traffic
+	if rf, ok := ret.Get(0).(func(string, time.Time) *robotclient.Traffic); ok {
+		r0 = rf(ip, month)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*robotclient.Traffic)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, time.Time) error); ok {
+		r1 = rf(ip, month)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetReboot provides a mock function with given fields: _a0
 func (_m *Client) GetReboot(_a0 int) (*models.Reset, error) {
 	ret := _m.Called(_a0)
@@ -221,6 +271,29 @@ func (_m *Client) SetBootRescue(id int, fingerprint string) (*models.Rescue, err
 	return r0, r1
 }
 
+// SetFirewall provides a mock function with given fields: _a0, _a1
+func (_m *Client) SetFirewall(_a0 int, _a1 *robotclient.Firewall) (*robotclient.Firewall, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *robotclient.Firewall
+	if rf, ok := ret.Get(0).(func(int, *robotclient.Firewall) *robotclient.Firewall); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*robotclient.Firewall)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int, *robotclient.Firewall) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // SetSSHKey provides a mock function with given fields: name, publickey
 func (_m *Client) SetSSHKey(name string, publickey string) (*models.Key, error) {
 	ret := _m.Called(name, publickey)
@@ -26,6 +26,48 @@ func (_m *Client) CheckCloudInitLogsForSigTerm() sshclient.Output {
 	return r0
 }
 
+// CheckContainerd provides a mock function with given fields:
+func (_m *Client) CheckContainerd() sshclient.Output {
+	ret := _m.Called()
+
+	var r0 sshclient.Output
+	if rf, ok := ret.Get(0).(func() sshclient.Output); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(sshclient.Output)
+	}
+
+	return r0
+}
+
+// CheckKubeletHealth provides a mock function with given fields:
+func (_m *Client) CheckKubeletHealth() sshclient.Output {
+	ret := _m.Called()
+
+	var r0 sshclient.Output
+	if rf, ok := ret.Get(0).(func() sshclient.Output); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(sshclient.Output)
+	}
+
+	return r0
+}
+
+// CheckSystemdUnit provides a mock function with given fields: unit
+func (_m *Client) CheckSystemdUnit(unit string) sshclient.Output {
+	ret := _m.Called(unit)
+
+	var r0 sshclient.Output
+	if rf, ok := ret.Get(0).(func(string) sshclient.Output); ok {
+		r0 = rf(unit)
+	} else {
+		r0 = ret.Get(0).(sshclient.Output)
+	}
+
+	return r0
+}
+
 // CleanCloudInitInstances provides a mock function with given fields:
 func (_m *Client) CleanCloudInitInstances() sshclient.Output {
 	ret := _m.Called()
@@ -68,6 +110,20 @@ func (_m *Client) CloudInitStatus() sshclient.Output {
 	return r0
 }
 
+// ConfigureConsoleUser provides a mock function with given fields: name, passwordHash
+func (_m *Client) ConfigureConsoleUser(name string, passwordHash string) sshclient.Output {
+	ret := _m.Called(name, passwordHash)
+
+	var r0 sshclient.Output
+	if rf, ok := ret.Get(0).(func(string, string) sshclient.Output); ok {
+		r0 = rf(name, passwordHash)
+	} else {
+		r0 = ret.Get(0).(sshclient.Output)
+	}
+
+	return r0
+}
+
 // CreateAutoSetup provides a mock function with given fields: data
 func (_m *Client) CreateAutoSetup(data string) sshclient.Output {
 	ret := _m.Called(data)
@@ -138,13 +194,13 @@ func (_m *Client) CreateUserData(userData string) sshclient.Output {
 	return r0
 }
 
-// DownloadImage provides a mock function with given fields: path, url
-func (_m *Client) DownloadImage(path string, url string) sshclient.Output {
-	ret := _m.Called(path, url)
+// DownloadImage provides a mock function with given fields: path, url, creds
+func (_m *Client) DownloadImage(path string, url string, creds sshclient.DownloadCredentials) sshclient.Output {
+	ret := _m.Called(path, url, creds)
 
 	var r0 sshclient.Output
-	if rf, ok := ret.Get(0).(func(string, string) sshclient.Output); ok {
-		r0 = rf(path, url)
+	if rf, ok := ret.Get(0).(func(string, string, sshclient.DownloadCredentials) sshclient.Output); ok {
+		r0 = rf(path, url, creds)
 	} else {
 		r0 = ret.Get(0).(sshclient.Output)
 	}
@@ -180,6 +236,34 @@ func (_m *Client) ExecuteInstallImage(hasPostInstallScript bool) sshclient.Outpu
 	return r0
 }
 
+// GetDisksWithoutBootloader provides a mock function with given fields: wwns
+func (_m *Client) GetDisksWithoutBootloader(wwns []string) sshclient.Output {
+	ret := _m.Called(wwns)
+
+	var r0 sshclient.Output
+	if rf, ok := ret.Get(0).(func([]string) sshclient.Output); ok {
+		r0 = rf(wwns)
+	} else {
+		r0 = ret.Get(0).(sshclient.Output)
+	}
+
+	return r0
+}
+
+// GetBootID provides a mock function with given fields:
+func (_m *Client) GetBootID() sshclient.Output {
+	ret := _m.Called()
+
+	var r0 sshclient.Output
+	if rf, ok := ret.Get(0).(func() sshclient.Output); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(sshclient.Output)
+	}
+
+	return r0
+}
+
 // GetHardwareDetailsCPUArch provides a mock function with given fields:
 func (_m *Client) GetHardwareDetailsCPUArch() sshclient.Output {
 	ret := _m.Called()
@@ -320,6 +404,20 @@ func (_m *Client) GetHostName() sshclient.Output {
 	return r0
 }
 
+// InstallBootloader provides a mock function with given fields: wwn
+func (_m *Client) InstallBootloader(wwn string) sshclient.Output {
+	ret := _m.Called(wwn)
+
+	var r0 sshclient.Output
+	if rf, ok := ret.Get(0).(func(string) sshclient.Output); ok {
+		r0 = rf(wwn)
+	} else {
+		r0 = ret.Get(0).(sshclient.Output)
+	}
+
+	return r0
+}
+
 // Reboot provides a mock function with given fields:
 func (_m *Client) Reboot() sshclient.Output {
 	ret := _m.Called()
@@ -334,6 +432,20 @@ func (_m *Client) Reboot() sshclient.Output {
 	return r0
 }
 
+// RemoveConsoleUser provides a mock function with given fields: name
+func (_m *Client) RemoveConsoleUser(name string) sshclient.Output {
+	ret := _m.Called(name)
+
+	var r0 sshclient.Output
+	if rf, ok := ret.Get(0).(func(string) sshclient.Output); ok {
+		r0 = rf(name)
+	} else {
+		r0 = ret.Get(0).(sshclient.Output)
+	}
+
+	return r0
+}
+
 // ResetKubeadm provides a mock function with given fields:
 func (_m *Client) ResetKubeadm() sshclient.Output {
 	ret := _m.Called()
@@ -348,6 +460,20 @@ func (_m *Client) ResetKubeadm() sshclient.Output {
 	return r0
 }
 
+// RunScript provides a mock function with given fields: script
+func (_m *Client) RunScript(script string) sshclient.Output {
+	ret := _m.Called(script)
+
+	var r0 sshclient.Output
+	if rf, ok := ret.Get(0).(func(string) sshclient.Output); ok {
+		r0 = rf(script)
+	} else {
+		r0 = ret.Get(0).(sshclient.Output)
+	}
+
+	return r0
+}
+
 type mockConstructorTestingTNewClient interface {
 	mock.TestingT
 	Cleanup(func())
@@ -0,0 +1,60 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package robotclient
+
+import (
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/dryrun"
+	"github.com/syself/hrobot-go/models"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// dryRunClient passes reads to the wrapped client and records mutations on the object instead of
+// executing them.
+type dryRunClient struct {
+	Client
+	obj client.Object
+}
+
+// NewDryRunClient returns a client that records the mutations on the object instead of executing them.
+func NewDryRunClient(c Client, obj client.Object) Client {
+	return &dryRunClient{Client: c, obj: obj}
+}
+
+func (c *dryRunClient) RebootBMServer(id int, rebootType infrav1.RebootType) (*models.ResetPost, error) {
+	return nil, dryrun.Skip(c.obj, "reboot of type %s of server %d", rebootType, id)
+}
+
+func (c *dryRunClient) SetBMServerName(id int, name string) (*models.Server, error) {
+	return nil, dryrun.Skip(c.obj, "setting name of server %d to %s", id, name)
+}
+
+func (c *dryRunClient) SetSSHKey(name, _ string) (*models.Key, error) {
+	return nil, dryrun.Skip(c.obj, "uploading SSH key %s", name)
+}
+
+func (c *dryRunClient) SetBootRescue(id int, _ string) (*models.Rescue, error) {
+	return nil, dryrun.Skip(c.obj, "activating rescue system of server %d", id)
+}
+
+func (c *dryRunClient) DeleteBootRescue(id int) (*models.Rescue, error) {
+	return nil, dryrun.Skip(c.obj, "deactivating rescue system of server %d", id)
+}
+
+func (c *dryRunClient) SetFirewall(id int, _ *Firewall) (*Firewall, error) {
+	return nil, dryrun.Skip(c.obj, "updating firewall of server %d", id)
+}
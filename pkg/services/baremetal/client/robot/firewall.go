@@ -0,0 +1,166 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package robotclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/syself/hrobot-go/models"
+)
+
+// defaultBaseURL is the base URL of the Robot webservice. The firewall and traffic APIs are not covered by
+// hrobot-go, so they are requested with robotHTTPClient at the base URL of the client.
+const defaultBaseURL = "https://robot-ws.your-server.de"
+
+// robotHTTPClient is shared by all Robot clients, so that connections are reused.
+var robotHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// FirewallStatus is the status of the Robot firewall of a server.
+type FirewallStatus string
+
+const (
+	// FirewallStatusActive means that the firewall filters the traffic of the server.
+	FirewallStatusActive FirewallStatus = "active"
+	// FirewallStatusDisabled means that the firewall does not filter the traffic of the server.
+	FirewallStatusDisabled FirewallStatus = "disabled"
+	// FirewallStatusInProcess means that a change of the firewall is being applied.
+	FirewallStatusInProcess FirewallStatus = "in process"
+)
+
+// Firewall is the configuration of the Robot firewall of a server.
+type Firewall struct {
+	Status       FirewallStatus `json:"status"`
+	FilterIPv6   bool           `json:"filter_ipv6"`
+	WhitelistHOS bool           `json:"whitelist_hos"`
+	Port         string         `json:"port"`
+	Rules        FirewallRules  `json:"rules"`
+}
+
+// FirewallRules are the rules of a Robot firewall. The first matching rule applies.
+type FirewallRules struct {
+	Input  []FirewallRule `json:"input"`
+	Output []FirewallRule `json:"output"`
+}
+
+// FirewallRule is a rule of a Robot firewall. Empty fields match everything.
+type FirewallRule struct {
+	Name      string `json:"name"`
+	IPVersion string `json:"ip_version"`
+	DstIP     string `json:"dst_ip"`
+	SrcIP     string `json:"src_ip"`
+	DstPort   string `json:"dst_port"`
+	SrcPort   string `json:"src_port"`
+	Protocol  string `json:"protocol"`
+	TCPFlags  string `json:"tcp_flags"`
+	Action    string `json:"action"`
+}
+
+type firewallResponse struct {
+	Firewall Firewall `json:"firewall"`
+}
+
+func (c *realHetznerRobotClient) GetFirewall(id int) (*Firewall, error) {
+	return c.doFirewallRequest(http.MethodGet, id, nil)
+}
+
+func (c *realHetznerRobotClient) SetFirewall(id int, firewall *Firewall) (*Firewall, error) {
+	form := url.Values{}
+	form.Set("status", string(firewall.Status))
+	form.Set("filter_ipv6", strconv.FormatBool(firewall.FilterIPv6))
+	form.Set("whitelist_hos", strconv.FormatBool(firewall.WhitelistHOS))
+	addFirewallRules(form, "input", firewall.Rules.Input)
+	addFirewallRules(form, "output", firewall.Rules.Output)
+	return c.doFirewallRequest(http.MethodPost, id, form)
+}
+
+// addFirewallRules adds the rules to the form in the notation of the Robot API, e.g. rules[input][0][name].
+func addFirewallRules(form url.Values, direction string, rules []FirewallRule) {
+	for i, rule := range rules {
+		prefix := fmt.Sprintf("rules[%s][%d]", direction, i)
+		for key, value := range map[string]string{
+			"name":       rule.Name,
+			"ip_version": rule.IPVersion,
+			"dst_ip":     rule.DstIP,
+			"src_ip":     rule.SrcIP,
+			"dst_port":   rule.DstPort,
+			"src_port":   rule.SrcPort,
+			"protocol":   rule.Protocol,
+			"tcp_flags":  rule.TCPFlags,
+			"action":     rule.Action,
+		} {
+			if value != "" {
+				form.Set(fmt.Sprintf("%s[%s]", prefix, key), value)
+			}
+		}
+	}
+}
+
+func (c *realHetznerRobotClient) doFirewallRequest(method string, id int, form url.Values) (*Firewall, error) {
+	var firewall firewallResponse
+	if err := c.doRequest(method, fmt.Sprintf("/firewall/%d", id), form, &firewall); err != nil {
+		return nil, err
+	}
+	return &firewall.Firewall, nil
+}
+
+// doRequest sends a request to the Robot webservice and unmarshals the response into result. The form is sent
+// URL encoded as body of the request.
+func (c *realHetznerRobotClient) doRequest(method, path string, form url.Values, result interface{}) error {
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+	req, err := http.NewRequest(method, c.baseURL+path, body)
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	req.SetBasicAuth(c.userName, c.password)
+
+	resp, err := robotHTTPClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to send request")
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read response")
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		var errorResponse models.ErrorResponse
+		if err := json.Unmarshal(data, &errorResponse); err != nil || errorResponse.Error.Code == "" {
+			return fmt.Errorf("server responded with status code %v", resp.StatusCode)
+		}
+		return errorResponse.Error
+	}
+
+	if err := json.Unmarshal(data, result); err != nil {
+		return errors.Wrap(err, "failed to unmarshal response")
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package robotclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GetFirewall", func() {
+	It("requests the firewall at the base URL of the client", func() {
+		var requestedPath, userName string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestedPath = r.URL.Path
+			userName, _, _ = r.BasicAuth()
+			_, _ = w.Write([]byte(`{"firewall":{"status":"active","rules":{"input":[{"name":"allow ssh","dst_port":"22","action":"accept"}]}}}`))
+		}))
+		defer server.Close()
+
+		client := NewFactory().NewClient(Credentials{Username: "robot-user", Password: "secret"}).(*realHetznerRobotClient)
+		client.SetBaseURL(server.URL)
+
+		firewall, err := client.GetFirewall(42)
+		Expect(err).To(Succeed())
+		Expect(requestedPath).To(Equal("/firewall/42"))
+		Expect(userName).To(Equal("robot-user"))
+		Expect(firewall.Status).To(Equal(FirewallStatusActive))
+		Expect(firewall.Rules.Input).To(Equal([]FirewallRule{{Name: "allow ssh", DstPort: "22", Action: "accept"}}))
+	})
+})
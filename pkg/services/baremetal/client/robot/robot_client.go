@@ -18,6 +18,8 @@ limitations under the License.
 package robotclient
 
 import (
+	"time"
+
 	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
 	hrobot "github.com/syself/hrobot-go"
 	"github.com/syself/hrobot-go/models"
@@ -37,6 +39,9 @@ type Client interface {
 	GetBootRescue(id int) (*models.Rescue, error)
 	DeleteBootRescue(id int) (*models.Rescue, error)
 	GetReboot(int) (*models.Reset, error)
+	GetFirewall(int) (*Firewall, error)
+	SetFirewall(int, *Firewall) (*Firewall, error)
+	GetMonthlyTraffic(ip string, month time.Time) (*Traffic, error)
 }
 
 // Factory is the interface for creating new Client objects.
@@ -47,7 +52,10 @@ type Factory interface {
 // NewClient creates new HCloud clients.
 func (f *factory) NewClient(creds Credentials) Client {
 	return &realHetznerRobotClient{
-		client: hrobot.NewBasicAuthClient(creds.Username, creds.Password),
+		client:   hrobot.NewBasicAuthClient(creds.Username, creds.Password),
+		userName: creds.Username,
+		password: creds.Password,
+		baseURL:  defaultBaseURL,
 	}
 }
 
@@ -66,6 +74,13 @@ type realHetznerRobotClient struct {
 	client   hrobot.RobotClient
 	userName string
 	password string
+	baseURL  string
+}
+
+// SetBaseURL sets the base URL of the Robot webservice for all requests of the client.
+func (c *realHetznerRobotClient) SetBaseURL(baseURL string) {
+	c.client.SetBaseURL(baseURL)
+	c.baseURL = baseURL
 }
 
 func (c *realHetznerRobotClient) UserName() string {
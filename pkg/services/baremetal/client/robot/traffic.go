@@ -0,0 +1,57 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package robotclient
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Traffic is the traffic of an IP address in a period in GB.
+type Traffic struct {
+	In  float64 `json:"in"`
+	Out float64 `json:"out"`
+	Sum float64 `json:"sum"`
+}
+
+type trafficResponse struct {
+	Traffic struct {
+		Data map[string]Traffic `json:"data"`
+	} `json:"traffic"`
+}
+
+// GetMonthlyTraffic returns the traffic of the IP address in the month of the given time up to that day.
+func (c *realHetznerRobotClient) GetMonthlyTraffic(ip string, month time.Time) (*Traffic, error) {
+	form := url.Values{}
+	form.Set("ip[]", ip)
+	form.Set("type", "month")
+	form.Set("from", time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC).Format("2006-01-02"))
+	form.Set("to", month.UTC().Format("2006-01-02"))
+
+	var traffic trafficResponse
+	if err := c.doRequest(http.MethodPost, "/traffic", form, &traffic); err != nil {
+		return nil, err
+	}
+	data, found := traffic.Traffic.Data[ip]
+	if !found {
+		return nil, errors.Errorf("no traffic of IP %s in response", ip)
+	}
+	return &data, nil
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package sshclient
 
 import (
+	"strings"
+
 	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
 	"github.com/syself/cluster-api-provider-hetzner/pkg/services/baremetal/client"
 	corev1 "k8s.io/api/core/v1"
@@ -52,3 +54,41 @@ func CredentialsFromSecret(secret *corev1.Secret, secretRef infrav1.SSHSecretRef
 		PrivateKey: string(secret.Data[secretRef.Key.PrivateKey]),
 	}
 }
+
+// DownloadCredentials defines the credentials for downloading an image specified in a secret.
+// The zero value downloads the image anonymously.
+type DownloadCredentials struct {
+	Username string
+	Password string
+	Token    string
+	CACert   string
+}
+
+// Validate returns an error if the download credentials are invalid.
+func (creds DownloadCredentials) Validate() error {
+	if creds.Token != "" && (creds.Username != "" || creds.Password != "") {
+		return &client.CredentialsValidationError{Message: "Can only use either basic authentication or a bearer token to download the image"}
+	}
+	if creds.Username == "" && creds.Password != "" {
+		return &client.CredentialsValidationError{Message: "Missing username for basic authentication to download the image"}
+	}
+	if creds.Username != "" && creds.Password == "" {
+		return &client.CredentialsValidationError{Message: "Missing password for basic authentication to download the image"}
+	}
+	if creds.Username == "" && creds.Token == "" && creds.CACert == "" {
+		return &client.CredentialsValidationError{Message: "Missing credentials or CA certificate to download the image"}
+	}
+
+	return nil
+}
+
+// DownloadCredentialsFromSecret generates the download credentials object from a secret.
+// Surrounding whitespace, e.g. a trailing newline of a file the secret was created from, is removed.
+func DownloadCredentialsFromSecret(secret *corev1.Secret) DownloadCredentials {
+	return DownloadCredentials{
+		Username: strings.TrimSpace(string(secret.Data[infrav1.ImageDownloadSecretUsernameKey])),
+		Password: strings.TrimSpace(string(secret.Data[infrav1.ImageDownloadSecretPasswordKey])),
+		Token:    strings.TrimSpace(string(secret.Data[infrav1.ImageDownloadSecretTokenKey])),
+		CACert:   string(secret.Data[infrav1.ImageDownloadSecretCACertKey]),
+	}
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sshclient
+
+import (
+	"github.com/syself/cluster-api-provider-hetzner/pkg/dryrun"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type dryRunFactory struct {
+	Factory
+	obj client.Object
+}
+
+// NewDryRunFactory returns a factory of clients that run commands reading the state of the server
+// and record the commands changing it on the object instead of running them.
+func NewDryRunFactory(f Factory, obj client.Object) Factory {
+	return &dryRunFactory{Factory: f, obj: obj}
+}
+
+// NewClient implements the NewClient method of the factory interface.
+func (f *dryRunFactory) NewClient(in Input) Client {
+	return &dryRunClient{Client: f.Factory.NewClient(in), obj: f.obj, ip: in.IP}
+}
+
+type dryRunClient struct {
+	Client
+	obj client.Object
+	ip  string
+}
+
+func (c *dryRunClient) skip(command string) Output {
+	return Output{Err: dryrun.Skip(c.obj, "%s on %s", command, c.ip)}
+}
+
+func (c *dryRunClient) CreateAutoSetup(string) Output {
+	return c.skip("writing autosetup")
+}
+
+func (c *dryRunClient) DownloadImage(_, url string, _ DownloadCredentials) Output {
+	return c.skip("downloading image " + url)
+}
+
+func (c *dryRunClient) CreatePostInstallScript(string) Output {
+	return c.skip("writing post install script")
+}
+
+func (c *dryRunClient) ExecuteInstallImage(bool) Output {
+	return c.skip("running installimage")
+}
+
+func (c *dryRunClient) Reboot() Output {
+	return c.skip("reboot")
+}
+
+func (c *dryRunClient) CreateNoCloudDirectory() Output {
+	return c.skip("creating nocloud directory")
+}
+
+func (c *dryRunClient) CreateMetaData(string) Output {
+	return c.skip("writing cloud init meta data")
+}
+
+func (c *dryRunClient) CreateUserData(string) Output {
+	return c.skip("writing cloud init user data")
+}
+
+func (c *dryRunClient) CleanCloudInitLogs() Output {
+	return c.skip("removing cloud init logs")
+}
+
+func (c *dryRunClient) CleanCloudInitInstances() Output {
+	return c.skip("removing cloud init instances")
+}
+
+func (c *dryRunClient) ResetKubeadm() Output {
+	return c.skip("kubeadm reset")
+}
+
+func (c *dryRunClient) InstallBootloader(wwn string) Output {
+	return c.skip("installing bootloader on disk " + wwn)
+}
+
+func (c *dryRunClient) ConfigureConsoleUser(name, _ string) Output {
+	return c.skip("configuring console user " + name)
+}
+
+func (c *dryRunClient) RemoveConsoleUser(name string) Output {
+	return c.skip("removing console user " + name)
+}
+
+func (c *dryRunClient) RunScript(string) Output {
+	return c.skip("running script")
+}
@@ -20,11 +20,13 @@ package sshclient
 import (
 	"bytes"
 	"fmt"
+	"net"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/userdata"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -55,6 +57,20 @@ type Input struct {
 	IP         string
 	PrivateKey string
 	Port       int
+	// User is optional. The default value is root.
+	User string
+	// PrivilegeEscalation is optional. If set, all commands are run with it as prefix, e.g. "sudo -n".
+	PrivilegeEscalation string
+	// Bastion is optional. If set, the connection to IP is established through the bastion host.
+	Bastion *Bastion
+}
+
+// Bastion defines an SSH jump host.
+type Bastion struct {
+	IP         string
+	PrivateKey string
+	Port       int
+	User       string
 }
 
 // Output defines the SSH output.
@@ -77,7 +93,7 @@ type Client interface {
 	GetHardwareDetailsCPUThreads() Output
 	GetHardwareDetailsCPUCores() Output
 	CreateAutoSetup(data string) Output
-	DownloadImage(path, url string) Output
+	DownloadImage(path, url string, creds DownloadCredentials) Output
 	CreatePostInstallScript(data string) Output
 	ExecuteInstallImage(hasPostInstallScript bool) Output
 	Reboot() Output
@@ -90,6 +106,15 @@ type Client interface {
 	CleanCloudInitLogs() Output
 	CleanCloudInitInstances() Output
 	ResetKubeadm() Output
+	CheckSystemdUnit(unit string) Output
+	CheckKubeletHealth() Output
+	CheckContainerd() Output
+	GetDisksWithoutBootloader(wwns []string) Output
+	InstallBootloader(wwn string) Output
+	ConfigureConsoleUser(name, passwordHash string) Output
+	RemoveConsoleUser(name string) Output
+	GetBootID() Output
+	RunScript(script string) Output
 }
 
 // Factory is the interface for creating new Client objects.
@@ -108,17 +133,27 @@ var _ = Factory(&sshFactory{})
 
 // NewClient implements the NewClient method of the factory interface.
 func (f *sshFactory) NewClient(in Input) Client {
+	user := in.User
+	if user == "" {
+		user = "root"
+	}
 	return &sshClient{
-		privateSSHKey: in.PrivateKey,
-		ip:            in.IP,
-		port:          in.Port,
+		privateSSHKey:       in.PrivateKey,
+		ip:                  in.IP,
+		port:                in.Port,
+		user:                user,
+		privilegeEscalation: in.PrivilegeEscalation,
+		bastion:             in.Bastion,
 	}
 }
 
 type sshClient struct {
-	ip            string
-	privateSSHKey string
-	port          int
+	ip                  string
+	privateSSHKey       string
+	port                int
+	user                string
+	privilegeEscalation string
+	bastion             *Bastion
 }
 
 var _ = Client(&sshClient{})
@@ -206,8 +241,47 @@ EOF`, data))
 }
 
 // DownloadImage implements the DownloadImage method of the SSHClient interface.
-func (c *sshClient) DownloadImage(path, url string) Output {
-	return c.runSSH(fmt.Sprintf(`curl -sLo "%q" "%q"`, path, url))
+// Credentials are passed to curl in a config file so that they do not show up in the process list.
+func (c *sshClient) DownloadImage(path, url string, creds DownloadCredentials) Output {
+	if creds == (DownloadCredentials{}) {
+		return c.runSSH(fmt.Sprintf(`curl -fsSLo %q %q`, path, url))
+	}
+	return c.runSSH(downloadImageCommand(path, url, creds))
+}
+
+const (
+	downloadConfigPath = "/root/image-download.conf"
+	downloadCACertPath = "/root/image-download-ca.crt"
+)
+
+func downloadImageCommand(path, url string, creds DownloadCredentials) string {
+	var config strings.Builder
+	switch {
+	case creds.Token != "":
+		fmt.Fprintf(&config, "header = %s\n", curlConfigValue("Authorization: Bearer "+creds.Token))
+	case creds.Username != "":
+		fmt.Fprintf(&config, "user = %s\n", curlConfigValue(creds.Username+":"+creds.Password))
+	}
+	if creds.CACert != "" {
+		fmt.Fprintf(&config, "cacert = %s\n", curlConfigValue(downloadCACertPath))
+	}
+
+	var cmd strings.Builder
+	cmd.WriteString("umask 077\n")
+	fmt.Fprintf(&cmd, "cat << 'CAPH_EOF' > %s\n%sCAPH_EOF\n", downloadConfigPath, config.String())
+	if creds.CACert != "" {
+		fmt.Fprintf(&cmd, "cat << 'CAPH_EOF' > %s\n%s\nCAPH_EOF\n", downloadCACertPath, strings.TrimSpace(creds.CACert))
+	}
+	fmt.Fprintf(&cmd, "curl -fsSL -K %s -o %q %q\n", downloadConfigPath, path, url)
+	cmd.WriteString("rc=$?\n")
+	fmt.Fprintf(&cmd, "rm -f %s %s\n", downloadConfigPath, downloadCACertPath)
+	cmd.WriteString("exit $rc")
+	return cmd.String()
+}
+
+// curlConfigValue quotes a value for a curl config file.
+func curlConfigValue(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
 }
 
 // CreatePostInstallScript implements the CreatePostInstallScript method of the SSHClient interface.
@@ -317,6 +391,123 @@ func (c *sshClient) ResetKubeadm() Output {
 	return c.runSSH(`kubeadm reset -f`)
 }
 
+// CheckSystemdUnit implements the CheckSystemdUnit method of the SSHClient interface.
+func (c *sshClient) CheckSystemdUnit(unit string) Output {
+	return c.runSSH(fmt.Sprintf(`systemctl is-active '%s'`, unit))
+}
+
+// CheckKubeletHealth implements the CheckKubeletHealth method of the SSHClient interface.
+func (c *sshClient) CheckKubeletHealth() Output {
+	return c.runSSH(`curl -sSf http://127.0.0.1:10248/healthz`)
+}
+
+// CheckContainerd implements the CheckContainerd method of the SSHClient interface.
+func (c *sshClient) CheckContainerd() Output {
+	return c.runSSH(`ctr --address /run/containerd/containerd.sock version`)
+}
+
+// GetDisksWithoutBootloader implements the GetDisksWithoutBootloader method of the SSHClient interface.
+// It prints the WWNs of the disks whose boot sector does not contain GRUB. On UEFI systems, it prints
+// "efi" if the EFI system partition is not mirrored with software RAID.
+func (c *sshClient) GetDisksWithoutBootloader(wwns []string) Output {
+	return c.runSSH(fmt.Sprintf(`if [ -d /sys/firmware/efi ]; then
+  case "$(findmnt -no SOURCE /boot/efi)" in
+    /dev/md*) ;;
+    *) echo %s ;;
+  esac
+  exit 0
+fi
+for wwn in %s; do
+  %s
+  if [ -z "$dev" ] || ! dd if="$dev" bs=512 count=1 2>/dev/null | grep -aq GRUB; then
+    echo "$wwn"
+  fi
+done`, EFISystemPartition, quoteAll(wwns), resolveDiskByWWN))
+}
+
+// InstallBootloader implements the InstallBootloader method of the SSHClient interface.
+func (c *sshClient) InstallBootloader(wwn string) Output {
+	return c.runSSH(fmt.Sprintf(`wwn='%s'
+%s
+if [ -z "$dev" ]; then
+  echo "no disk with wwn $wwn" >&2
+  exit 1
+fi
+grub-install "$dev" 2>&1`, wwn, resolveDiskByWWN))
+}
+
+// ConfigureConsoleUser implements the ConfigureConsoleUser method of the SSHClient interface.
+// It creates the user if it does not exist and sets its password hash, like the user data does.
+// System users with a UID below 1000 are not changed.
+func (c *sshClient) ConfigureConsoleUser(name, passwordHash string) Output {
+	return c.runSSH(fmt.Sprintf(`user=%[1]s
+%[5]s
+id -u "$user" >/dev/null 2>&1 || useradd --create-home --shell /bin/bash "$user"
+usermod --password %[2]s "$user"
+printf '%%s' %[3]s > %[4]s
+chmod 0440 %[4]s`, shellQuote(name), shellQuote(passwordHash), shellQuote(userdata.ConsoleUserSudoers(name)),
+		userdata.ConsoleUserSudoersPath, refuseSystemUser))
+}
+
+// RemoveConsoleUser implements the RemoveConsoleUser method of the SSHClient interface.
+// System users with a UID below 1000 are not removed.
+func (c *sshClient) RemoveConsoleUser(name string) Output {
+	return c.runSSH(fmt.Sprintf(`user=%[1]s
+%[3]s
+rm -f %[2]s
+if id -u "$user" >/dev/null 2>&1; then
+  pkill -KILL -u "$user"
+  userdel --remove "$user" 2>&1
+fi`, shellQuote(name), userdata.ConsoleUserSudoersPath, refuseSystemUser))
+}
+
+// GetBootID implements the GetBootID method of the SSHClient interface.
+func (c *sshClient) GetBootID() Output {
+	return c.runSSH("cat /proc/sys/kernel/random/boot_id")
+}
+
+// RunScript implements the RunScript method of the SSHClient interface.
+// The script is run by bash and its output is returned in StdOut, the error tells its exit status.
+func (c *sshClient) RunScript(script string) Output {
+	return c.runSSH(fmt.Sprintf(`cat << 'EOF_CAPH_SCRIPT' > /root/caph-script.sh
+%s
+EOF_CAPH_SCRIPT
+bash /root/caph-script.sh 2>&1
+status=$?
+rm -f /root/caph-script.sh
+exit $status`, script))
+}
+
+// EFISystemPartition is printed by GetDisksWithoutBootloader if the EFI system partition is not mirrored.
+const EFISystemPartition = "efi"
+
+// resolveDiskByWWN sets dev to the device of the disk with the WWN in wwn. Disks are linked by their WWN,
+// NVMe disks by their EUI or NGUID, which lsblk reports as WWN.
+const resolveDiskByWWN = `dev=""
+  for link in "/dev/disk/by-id/wwn-$wwn" "/dev/disk/by-id/nvme-$wwn"; do
+    if [ -e "$link" ]; then dev=$(readlink -f "$link"); break; fi
+  done`
+
+// refuseSystemUser exits if the user in user exists and is a system user, i.e. its UID is below 1000, so that
+// the console user cannot be used to change or remove users of the operating system.
+const refuseSystemUser = `if uid=$(id -u "$user" 2>/dev/null) && [ "$uid" -lt 1000 ]; then
+  echo "refusing to change system user $user with uid $uid" >&2
+  exit 1
+fi`
+
+// shellQuote quotes the value for a POSIX shell, so that it is passed as a single word.
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+func quoteAll(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		quoted = append(quoted, shellQuote(v))
+	}
+	return strings.Join(quoted, " ")
+}
+
 // IsConnectionRefusedError checks whether the ssh error is a connection refused error.
 func IsConnectionRefusedError(err error) bool {
 	return strings.Contains(err.Error(), ErrConnectionRefused.Error())
@@ -345,7 +536,7 @@ func (c *sshClient) runSSH(command string) Output {
 	}
 
 	config := &ssh.ClientConfig{
-		User: "root",
+		User: c.user,
 		Auth: []ssh.AuthMethod{
 			// Use the PublicKeys method for remote authentication.
 			ssh.PublicKeys(signer),
@@ -356,7 +547,7 @@ func (c *sshClient) runSSH(command string) Output {
 
 	// Connect to the remote server and perform the SSH handshake.
 
-	client, err := ssh.Dial("tcp", c.ip+":"+strconv.Itoa(c.port), config)
+	client, err := c.dial(config)
 	if err != nil {
 		return Output{Err: fmt.Errorf("failed to dial ssh. Error message: %s. DialErr: %w", err.Error(), errSSHDialFailed)}
 	}
@@ -374,10 +565,81 @@ func (c *sshClient) runSSH(command string) Output {
 	sess.Stdout = &stdoutBuffer
 	sess.Stderr = &stderrBuffer
 
-	err = sess.Run(command)
+	err = sess.Run(withPrivilegeEscalation(c.privilegeEscalation, command))
 	return Output{
 		StdOut: stdoutBuffer.String(),
 		StdErr: stderrBuffer.String(),
 		Err:    err,
 	}
 }
+
+// withPrivilegeEscalation runs the command in a shell started by the privilege escalation command.
+// The command is quoted as a whole, so that pipes, redirects and heredocs run with privileges too.
+func withPrivilegeEscalation(privilegeEscalation, command string) string {
+	if privilegeEscalation == "" {
+		return command
+	}
+	return fmt.Sprintf("%s sh -c '%s'", privilegeEscalation, strings.ReplaceAll(command, "'", `'\''`))
+}
+
+func (c *sshClient) dial(config *ssh.ClientConfig) (*ssh.Client, error) {
+	addr := net.JoinHostPort(c.ip, strconv.Itoa(c.port))
+	if c.bastion == nil {
+		return ssh.Dial("tcp", addr, config)
+	}
+
+	signer, err := ssh.ParsePrivateKey([]byte(c.bastion.PrivateKey))
+	if err != nil {
+		return nil, errors.Errorf("unable to parse private key of bastion: %v", err)
+	}
+
+	bastionConfig := &ssh.ClientConfig{
+		User:            c.bastion.User,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(), //#nosec
+		Timeout:         sshTimeOut,
+	}
+
+	bastionClient, err := ssh.Dial("tcp", net.JoinHostPort(c.bastion.IP, strconv.Itoa(c.bastion.Port)), bastionConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to dial bastion")
+	}
+
+	conn, err := bastionClient.Dial("tcp", addr)
+	if err != nil {
+		bastionClient.Close()
+		return nil, bastionDialError(addr, err)
+	}
+
+	ncc, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		conn.Close()
+		bastionClient.Close()
+		return nil, err
+	}
+
+	client := ssh.NewClient(ncc, chans, reqs)
+	go func() {
+		// Close the connection to the bastion once the connection to the target is closed.
+		_ = client.Wait()
+		bastionClient.Close()
+	}()
+	return client, nil
+}
+
+// bastionDialError translates errors of the bastion host while connecting to the target, so that
+// they can be checked in the same way as errors of a direct connection.
+func bastionDialError(addr string, err error) error {
+	var openChannelErr *ssh.OpenChannelError
+	if !errors.As(err, &openChannelErr) {
+		return err
+	}
+	msg := strings.ToLower(openChannelErr.Message)
+	switch {
+	case strings.Contains(msg, "connection refused"):
+		return fmt.Errorf("dial tcp %s via bastion: %w", addr, ErrConnectionRefused)
+	case strings.Contains(msg, "timed out"):
+		return fmt.Errorf("dial tcp %s via bastion: %w", addr, ErrTimeout)
+	}
+	return err
+}
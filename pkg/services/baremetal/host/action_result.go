@@ -17,11 +17,14 @@ limitations under the License.
 package host
 
 import (
+	"errors"
 	"math"
 	"math/rand"
 	"time"
 
+	"github.com/hetznercloud/hcloud-go/hcloud"
 	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/hrobot-go/models"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -29,6 +32,15 @@ import (
 // timeout will not exceed (roughly) 8 hours.
 const maxBackOffCount = 9
 
+const (
+	// actionErrorBaseBackoff is the backoff after the first error of an action.
+	actionErrorBaseBackoff = 10 * time.Second
+	// maxActionErrorBackoff is the upper limit of the backoff after errors of an action.
+	maxActionErrorBackoff = 10 * time.Minute
+	// rateLimitBackoff is the minimal backoff after an action hit the rate limit of an API.
+	rateLimitBackoff = 5 * time.Minute
+)
+
 func init() {
 	rand.Seed(time.Now().UTC().UnixNano())
 }
@@ -76,8 +88,11 @@ func (r deleteComplete) Result() (result reconcile.Result, err error) {
 
 // actionError is a result indicating that an error occurred while attempting
 // to advance the current action, and that reconciliation should be retried.
+// Without a class, errors of rate limits are classified as rate-limited and
+// all others as transient.
 type actionError struct {
-	err error
+	err   error
+	class infrav1.FailureClass
 }
 
 func (r actionError) Result() (result reconcile.Result, err error) {
@@ -85,6 +100,14 @@ func (r actionError) Result() (result reconcile.Result, err error) {
 	return
 }
 
+// FailureClass returns the classification of the error.
+func (r actionError) FailureClass() infrav1.FailureClass {
+	if r.class != "" {
+		return r.class
+	}
+	return classifyError(r.err)
+}
+
 // actionFailed is a result indicating that the current action has failed,
 // and that the resource should be marked as in error.
 type actionFailed struct {
@@ -92,6 +115,54 @@ type actionFailed struct {
 	errorCount int
 }
 
+// FailureClass returns the classification of the error type of the failure.
+func (r actionFailed) FailureClass() infrav1.FailureClass {
+	return failureClassOfErrorType(r.ErrorType)
+}
+
+// classifyError returns whether an error has been caused by the rate limit of the Robot or HCloud API.
+func classifyError(err error) infrav1.FailureClass {
+	var robotErr models.Error
+	if errors.As(err, &robotErr) && robotErr.Code == models.ErrorCodeRateLimitExceeded {
+		return infrav1.FailureClassRateLimited
+	}
+	var hcloudErr hcloud.Error
+	if errors.As(err, &hcloudErr) && hcloudErr.Code == hcloud.ErrorCodeRateLimitExceeded {
+		return infrav1.FailureClassRateLimited
+	}
+	return infrav1.FailureClassTransient
+}
+
+// failureClassOfErrorType returns the classification of the error types of a host. Errors that are
+// set by actions that cannot succeed with the current configuration are permanent configuration
+// errors, while the escalation of reboots is transient until even hardware reboots fail.
+func failureClassOfErrorType(errorType infrav1.ErrorType) infrav1.FailureClass {
+	switch errorType {
+	case "":
+		return ""
+	case infrav1.RegistrationError, infrav1.PreparationError, infrav1.ProvisioningError, infrav1.FatalError:
+		return infrav1.FailureClassPermanentConfiguration
+	case infrav1.ErrorTypeHardwareRebootFailed:
+		return infrav1.FailureClassPermanentHardware
+	default:
+		return infrav1.FailureClassTransient
+	}
+}
+
+// actionFailureReason returns the reason of the condition ActionSucceeded for a failure class.
+func actionFailureReason(class infrav1.FailureClass) string {
+	switch class {
+	case infrav1.FailureClassRateLimited:
+		return infrav1.ActionRateLimitedReason
+	case infrav1.FailureClassPermanentConfiguration:
+		return infrav1.ActionConfigurationErrorReason
+	case infrav1.FailureClassPermanentHardware:
+		return infrav1.ActionHardwareErrorReason
+	default:
+		return infrav1.ActionErrorBackoffReason
+	}
+}
+
 // CalculateBackoff calculates the reconciliation backoff.
 // Distribution sample for errorCount values:
 // 1  [1m, 2m]
@@ -118,3 +189,36 @@ func (r actionFailed) Result() (result reconcile.Result, err error) {
 	result.RequeueAfter = CalculateBackoff(r.errorCount)
 	return
 }
+
+// CalculateActionErrorBackoff calculates the backoff until an action that returned an error is retried.
+// The backoff doubles with every error and is capped at 10 minutes. It is reduced by a random
+// jitter of up to 50%, so that hosts that failed at the same time are not retried at the same time.
+// Distribution sample for actionErrorCount values:
+// 1  [5s, 10s]
+// 2  [10s, 20s]
+// 3  [20s, 40s]
+// 4  [40s, 1m20s]
+// 5  [1m20s, 2m40s]
+// 6  [2m40s, 5m20s]
+// >6 [5m, 10m].
+func CalculateActionErrorBackoff(actionErrorCount int) time.Duration {
+	backOff := maxActionErrorBackoff
+	if actionErrorCount < 1 {
+		actionErrorCount = 1
+	}
+	if exp := actionErrorCount - 1; exp < 6 {
+		backOff = actionErrorBaseBackoff << exp
+	}
+	return backOff - time.Duration(rand.Float64()*float64(backOff)*0.5) // #nosec
+}
+
+// calculateActionErrorBackoffOfClass calculates the backoff until an action that returned an error of
+// the failure class is retried. Rate limits are waited for at least five minutes, as retrying earlier
+// only extends them.
+func calculateActionErrorBackoffOfClass(actionErrorCount int, class infrav1.FailureClass) time.Duration {
+	backOff := CalculateActionErrorBackoff(actionErrorCount)
+	if class == infrav1.FailureClassRateLimited && backOff < rateLimitBackoff {
+		backOff = rateLimitBackoff
+	}
+	return backOff
+}
@@ -0,0 +1,238 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package host
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// HostControllerFieldOwner is the field manager of the host controller. It owns spec.status of the host except
+	// for the fields of the HetznerBareMetalMachine controller and the conditions of other controllers.
+	HostControllerFieldOwner = "caph-host-controller"
+
+	// MachineControllerFieldOwner is the field manager of the HetznerBareMetalMachine controller. It owns the
+	// consumer of the host and the fields of spec.status that describe how the host is provisioned.
+	MachineControllerFieldOwner = "caph-bare-metal-machine-controller"
+
+	// NodeControllerFieldOwner is the field manager of the node controller. It owns the condition HostHealthy.
+	NodeControllerFieldOwner = "caph-node-controller"
+)
+
+// MachineStatusFields are the fields of spec.status that are set by the HetznerBareMetalMachine controller.
+var MachineStatusFields = []string{
+	"hetznerClusterRef",
+	"userData",
+	"bootstrapDataChangePolicy",
+	"dns",
+	"nodeProfile",
+	"installImage",
+	"kubernetesVersion",
+	"provisioningChecks",
+	"sshSpec",
+}
+
+// foreignConditions are the conditions of the host that are set by other controllers than the host controller.
+var foreignConditions = []clusterv1.ConditionType{infrav1.HostHealthyCondition}
+
+// SaveHost is the write phase of the host controller. It applies the fields of spec.status that are owned by the
+// host controller with server-side apply. The apply is not bound to the resource version of the host, so changes
+// of the other controllers in the meantime neither cause conflicts nor are they overwritten.
+func SaveHost(ctx context.Context, c client.Client, host *infrav1.HetznerBareMetalHost) error {
+	t := metav1.Now()
+	host.Spec.Status.LastUpdated = &t
+
+	if err := releaseLegacyOwnership(ctx, c, host); err != nil {
+		return err
+	}
+
+	status := *host.Spec.Status.DeepCopy()
+	status.Conditions = nil
+	for _, condition := range host.Spec.Status.Conditions {
+		if !isForeignCondition(condition.Type) {
+			status.Conditions = append(status.Conditions, condition)
+		}
+	}
+
+	fields, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&status)
+	if err != nil {
+		return errors.Wrap(err, "failed to convert status of host")
+	}
+	for _, field := range MachineStatusFields {
+		delete(fields, field)
+	}
+
+	if err := applyStatus(ctx, c, host, HostControllerFieldOwner, fields); err != nil {
+		return errors.Wrap(err, "failed to update status")
+	}
+	return nil
+}
+
+// ApplyConditions applies the conditions of the given types of the host as field owner. Conditions of these types
+// that are not set on the host anymore are removed.
+func ApplyConditions(
+	ctx context.Context,
+	c client.Client,
+	host *infrav1.HetznerBareMetalHost,
+	owner string,
+	conditionTypes ...clusterv1.ConditionType,
+) error {
+	conditions := make([]interface{}, 0, len(conditionTypes))
+	for _, condition := range host.Spec.Status.Conditions {
+		for _, conditionType := range conditionTypes {
+			if condition.Type != conditionType {
+				continue
+			}
+			fields, err := runtime.DefaultUnstructuredConverter.ToUnstructured(condition.DeepCopy())
+			if err != nil {
+				return errors.Wrapf(err, "failed to convert condition %s", condition.Type)
+			}
+			conditions = append(conditions, fields)
+		}
+	}
+
+	if err := applyStatus(ctx, c, host, owner, map[string]interface{}{"conditions": conditions}); err != nil {
+		return errors.Wrap(err, "failed to apply conditions")
+	}
+	return nil
+}
+
+// patchHostMetadata patches the annotations and finalizers of the host that the host controller changed. These are
+// shared with users and other controllers, so only the changes are sent.
+func patchHostMetadata(ctx context.Context, c client.Client, original, host *infrav1.HetznerBareMetalHost) error {
+	modified := original.DeepCopy()
+	modified.Annotations = host.Annotations
+	modified.Finalizers = host.Finalizers
+
+	patch := client.MergeFrom(original)
+	data, err := patch.Data(modified)
+	if err != nil {
+		return errors.Wrap(err, "failed to compute patch of metadata")
+	}
+	if string(data) == "{}" {
+		return nil
+	}
+
+	if err := c.Patch(ctx, modified, patch, client.FieldOwner(HostControllerFieldOwner)); err != nil {
+		return errors.Wrap(err, "failed to patch metadata")
+	}
+	host.ResourceVersion = modified.ResourceVersion
+	return nil
+}
+
+// applyStatus applies the fields of spec.status as field owner and updates the host with the response.
+func applyStatus(ctx context.Context, c client.Client, host *infrav1.HetznerBareMetalHost, owner string, status map[string]interface{}) error {
+	apply := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"status": status},
+	}}
+	apply.SetGroupVersionKind(infrav1.GroupVersion.WithKind("HetznerBareMetalHost"))
+	apply.SetName(host.Name)
+	apply.SetNamespace(host.Namespace)
+
+	if err := c.Patch(ctx, apply, client.Apply, client.FieldOwner(owner), client.ForceOwnership); err != nil {
+		return err
+	}
+
+	var applied infrav1.HetznerBareMetalHost
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(apply.Object, &applied); err != nil {
+		return errors.Wrap(err, "failed to convert applied host")
+	}
+	*host = applied
+	return nil
+}
+
+// releaseLegacyOwnership removes spec.status from the managed fields of updates by other managers than the
+// controllers of CAPH. Before the controllers owned their fields, the host was written with updates, which made the
+// default field manager co-own all fields of spec.status. Fields that are removed by an apply of the host controller
+// would be kept as long as another manager owns them.
+func releaseLegacyOwnership(ctx context.Context, c client.Client, host *infrav1.HetznerBareMetalHost) error {
+	var changed bool
+	managedFields := make([]metav1.ManagedFieldsEntry, 0, len(host.ManagedFields))
+	for _, entry := range host.ManagedFields {
+		if entry.Operation != metav1.ManagedFieldsOperationUpdate || isFieldOwner(entry.Manager) || entry.FieldsV1 == nil {
+			managedFields = append(managedFields, entry)
+			continue
+		}
+
+		var fields map[string]interface{}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			return errors.Wrapf(err, "failed to parse managed fields of %s", entry.Manager)
+		}
+		spec, ok := fields["f:spec"].(map[string]interface{})
+		if _, found := spec["f:status"]; !ok || !found {
+			managedFields = append(managedFields, entry)
+			continue
+		}
+
+		changed = true
+		delete(spec, "f:status")
+		if len(spec) == 0 {
+			delete(fields, "f:spec")
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		raw, err := json.Marshal(fields)
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal managed fields of %s", entry.Manager)
+		}
+		entry.FieldsV1 = &metav1.FieldsV1{Raw: raw}
+		managedFields = append(managedFields, entry)
+	}
+	if !changed {
+		return nil
+	}
+
+	// An empty list does not change the managed fields, a list with an empty entry resets them.
+	if len(managedFields) == 0 {
+		managedFields = []metav1.ManagedFieldsEntry{{}}
+	}
+
+	modified := host.DeepCopy()
+	modified.ManagedFields = managedFields
+	if err := c.Patch(ctx, modified, client.MergeFrom(host), client.FieldOwner(HostControllerFieldOwner)); err != nil {
+		return errors.Wrap(err, "failed to release legacy ownership of status")
+	}
+	host.ManagedFields = modified.ManagedFields
+	return nil
+}
+
+func isFieldOwner(manager string) bool {
+	switch manager {
+	case HostControllerFieldOwner, MachineControllerFieldOwner, NodeControllerFieldOwner:
+		return true
+	}
+	return false
+}
+
+func isForeignCondition(conditionType clusterv1.ConditionType) bool {
+	for _, foreign := range foreignConditions {
+		if conditionType == foreign {
+			return true
+		}
+	}
+	return false
+}
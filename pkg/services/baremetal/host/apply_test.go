@@ -0,0 +1,153 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package host
+
+import (
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/test/helpers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// applyRecordingClient records server-side apply patches and sends them as merge patches, as the fake client does
+// not support server-side apply.
+type applyRecordingClient struct {
+	client.Client
+	owners  []string
+	applied []map[string]interface{}
+}
+
+func (c *applyRecordingClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if patch.Type() != types.ApplyPatchType {
+		return c.Client.Patch(ctx, obj, patch, opts...)
+	}
+	data, err := patch.Data(obj)
+	if err != nil {
+		return err
+	}
+	var applied map[string]interface{}
+	if err := json.Unmarshal(data, &applied); err != nil {
+		return err
+	}
+	patchOpts := &client.PatchOptions{}
+	patchOpts.ApplyOptions(opts)
+	c.owners = append(c.owners, patchOpts.FieldManager)
+	c.applied = append(c.applied, applied)
+	return c.Client.Patch(ctx, obj, client.RawPatch(types.MergePatchType, data))
+}
+
+var _ = Describe("SaveHost", func() {
+	var (
+		c    *applyRecordingClient
+		host *infrav1.HetznerBareMetalHost
+	)
+
+	BeforeEach(func() {
+		host = helpers.BareMetalHost("host", "default", helpers.WithIPv4(), helpers.WithConsumerRef())
+		host.Spec.Status.InstallImage = &infrav1.InstallImage{Image: infrav1.Image{Name: "ubuntu", URL: "https://example.com/ubuntu.tar.gz"}}
+		host.Spec.Status.HetznerClusterRef = "hetzner-cluster"
+		host.Spec.Status.ProvisioningState = infrav1.StateProvisioned
+		conditions.MarkTrue(host, infrav1.HostHealthyCondition)
+		conditions.MarkFalse(host, infrav1.ActionSucceededCondition, "Failed", clusterv1.ConditionSeverityWarning, "")
+
+		scheme := runtime.NewScheme()
+		utilruntime.Must(infrav1.AddToScheme(scheme))
+		c = &applyRecordingClient{Client: fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(host).Build()}
+		Expect(c.Get(context.Background(), client.ObjectKeyFromObject(host), host)).To(Succeed())
+	})
+
+	It("applies only the fields of the host controller", func() {
+		host.Spec.Status.ProvisioningState = infrav1.StateDeprovisioning
+		Expect(SaveHost(context.Background(), c, host)).To(Succeed())
+
+		Expect(c.owners).To(Equal([]string{HostControllerFieldOwner}))
+		Expect(c.applied).To(HaveLen(1))
+		Expect(c.applied[0]).To(HaveKeyWithValue("kind", "HetznerBareMetalHost"))
+		Expect(c.applied[0]["spec"]).To(HaveLen(1))
+
+		status := c.applied[0]["spec"].(map[string]interface{})["status"].(map[string]interface{})
+		Expect(status).To(HaveKeyWithValue("provisioningState", string(infrav1.StateDeprovisioning)))
+		Expect(status).To(HaveKey("lastUpdated"))
+		for _, field := range MachineStatusFields {
+			Expect(status).ToNot(HaveKey(field))
+		}
+		Expect(status["conditions"]).To(HaveLen(1))
+		Expect(status["conditions"].([]interface{})[0]).To(HaveKeyWithValue("type", string(infrav1.ActionSucceededCondition)))
+
+		Expect(host.Spec.Status.ProvisioningState).To(Equal(infrav1.StateDeprovisioning))
+		Expect(host.Spec.Status.InstallImage).ToNot(BeNil())
+		Expect(host.Spec.Status.HetznerClusterRef).To(Equal("hetzner-cluster"))
+	})
+
+	It("releases the legacy ownership of the status", func() {
+		host.ManagedFields = []metav1.ManagedFieldsEntry{
+			{
+				Manager:   "manager",
+				Operation: metav1.ManagedFieldsOperationUpdate,
+				FieldsV1:  &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:serverID":{},"f:status":{"f:errorMessage":{}}}}`)},
+			},
+			{
+				Manager:   "kubectl-edit",
+				Operation: metav1.ManagedFieldsOperationUpdate,
+				FieldsV1:  &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:status":{"f:errorType":{}}}}`)},
+			},
+			{
+				Manager:   MachineControllerFieldOwner,
+				Operation: metav1.ManagedFieldsOperationUpdate,
+				FieldsV1:  &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:status":{"f:installImage":{}}}}`)},
+			},
+		}
+		Expect(releaseLegacyOwnership(context.Background(), c, host)).To(Succeed())
+
+		Expect(host.ManagedFields).To(HaveLen(2))
+		Expect(host.ManagedFields[0].Manager).To(Equal("manager"))
+		Expect(string(host.ManagedFields[0].FieldsV1.Raw)).To(Equal(`{"f:spec":{"f:serverID":{}}}`))
+		Expect(host.ManagedFields[1].Manager).To(Equal(MachineControllerFieldOwner))
+		Expect(string(host.ManagedFields[1].FieldsV1.Raw)).To(Equal(`{"f:spec":{"f:status":{"f:installImage":{}}}}`))
+	})
+})
+
+var _ = Describe("ApplyConditions", func() {
+	It("applies only the conditions of the given types", func() {
+		host := helpers.BareMetalHost("host", "default")
+		conditions.MarkTrue(host, infrav1.HostHealthyCondition)
+		conditions.MarkTrue(host, infrav1.ActionSucceededCondition)
+
+		scheme := runtime.NewScheme()
+		utilruntime.Must(infrav1.AddToScheme(scheme))
+		c := &applyRecordingClient{Client: fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(host).Build()}
+
+		Expect(ApplyConditions(context.Background(), c, host, NodeControllerFieldOwner, infrav1.HostHealthyCondition)).To(Succeed())
+
+		Expect(c.owners).To(Equal([]string{NodeControllerFieldOwner}))
+		status := c.applied[0]["spec"].(map[string]interface{})["status"].(map[string]interface{})
+		Expect(status).To(HaveLen(1))
+		Expect(status["conditions"]).To(HaveLen(1))
+		Expect(status["conditions"].([]interface{})[0]).To(HaveKeyWithValue("type", string(infrav1.HostHealthyCondition)))
+	})
+})
@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package host
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/scope"
+	sshclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/baremetal/client/ssh"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/record"
+)
+
+// reconcileConsoleUser updates the console user of a provisioned host if the console user of the HetznerCluster
+// has been changed or removed after the host was provisioned. Hosts without applied configuration are skipped.
+func (s *Service) reconcileConsoleUser(sshClient sshclient.Client) actionResult {
+	host := s.scope.HetznerBareMetalHost
+	applied := host.Spec.Status.AppliedConfiguration
+	if applied == nil {
+		return actionComplete{}
+	}
+
+	consoleUser, err := s.scope.ConsoleUser(context.Background())
+	if err != nil {
+		return actionError{err: errors.Wrap(err, "failed to get console user")}
+	}
+
+	change := scope.ConsoleUserChange(applied.ConsoleUser, consoleUser.Applied())
+	if change == "" {
+		if consoleUser == nil {
+			conditions.Delete(host, infrav1.ConsoleUserInSyncCondition)
+		} else {
+			conditions.MarkTrue(host, infrav1.ConsoleUserInSyncCondition)
+		}
+		return actionComplete{}
+	}
+
+	if applied.ConsoleUser != nil && (consoleUser == nil || applied.ConsoleUser.Name != consoleUser.Name) {
+		if err := handleSSHError(sshClient.RemoveConsoleUser(applied.ConsoleUser.Name)); err != nil {
+			return s.consoleUserNotInSync(change, errors.Wrapf(err, "failed to remove console user %s", applied.ConsoleUser.Name))
+		}
+		// The user is gone, even if the new one cannot be configured
+		applied.ConsoleUser = nil
+	}
+	if consoleUser != nil {
+		if err := handleSSHError(sshClient.ConfigureConsoleUser(consoleUser.Name, consoleUser.PasswordHash)); err != nil {
+			return s.consoleUserNotInSync(change, errors.Wrapf(err, "failed to configure console user %s", consoleUser.Name))
+		}
+	}
+
+	s.setAppliedConsoleUser(consoleUser.Applied())
+	if consoleUser == nil {
+		conditions.Delete(host, infrav1.ConsoleUserInSyncCondition)
+	} else {
+		conditions.MarkTrue(host, infrav1.ConsoleUserInSyncCondition)
+	}
+	record.Eventf(host, "ConsoleUserUpdated", "Updated host, as the %s", change)
+	return actionComplete{}
+}
+
+func (s *Service) consoleUserNotInSync(change string, err error) actionResult {
+	conditions.MarkFalse(
+		s.scope.HetznerBareMetalHost,
+		infrav1.ConsoleUserInSyncCondition,
+		infrav1.ConsoleUserChangedReason,
+		clusterv1.ConditionSeverityWarning,
+		"%s, but the host could not be updated: %s",
+		change, err.Error(),
+	)
+	return actionError{err: err}
+}
+
+// setAppliedConsoleUser records the console user in the applied configuration of the host.
+func (s *Service) setAppliedConsoleUser(consoleUser *infrav1.AppliedConsoleUser) {
+	if s.scope.HetznerBareMetalHost.Spec.Status.AppliedConfiguration == nil {
+		s.scope.HetznerBareMetalHost.Spec.Status.AppliedConfiguration = &infrav1.AppliedConfiguration{}
+	}
+	s.scope.HetznerBareMetalHost.Spec.Status.AppliedConfiguration.ConsoleUser = consoleUser
+}
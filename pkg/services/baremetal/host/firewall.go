@@ -0,0 +1,226 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package host
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	robotclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/baremetal/client/robot"
+	"github.com/syself/hrobot-go/models"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/record"
+)
+
+const (
+	// provisioningFirewallRulePrefix is the prefix of the names of the rules of the provisioning firewall.
+	provisioningFirewallRulePrefix = "caph-provisioning"
+
+	// provisioningFirewallDelay is the interval in which the firewall is checked while Robot applies a change.
+	provisioningFirewallDelay = 20 * time.Second
+
+	// maxFirewallInputRules is the maximal number of input rules of a Robot firewall.
+	maxFirewallInputRules = 10
+)
+
+// ensureProvisioningFirewallRules adds the rules of the provisioning firewall to an active Robot firewall of the
+// host. It returns actionContinue until Robot has applied them, and nil once the controller can connect.
+func (s *Service) ensureProvisioningFirewallRules() actionResult {
+	host := s.scope.HetznerBareMetalHost
+	spec := s.scope.HetznerCluster.Spec.ProvisioningFirewall
+	if spec == nil || conditions.IsTrue(host, infrav1.ProvisioningFirewallRulesCondition) {
+		return nil
+	}
+
+	firewall, actResult := s.getFirewall()
+	if actResult != nil {
+		return actResult
+	}
+	switch firewall.Status {
+	case robotclient.FirewallStatusDisabled:
+		return nil
+	case robotclient.FirewallStatusInProcess:
+		return s.waitForFirewall()
+	}
+
+	rules := provisioningFirewallRules(spec, host.Spec.Status.SSHSpec)
+	if hasFirewallRules(firewall.Rules.Input, rules) {
+		conditions.MarkTrue(host, infrav1.ProvisioningFirewallRulesCondition)
+		return nil
+	}
+
+	// Robot applies the first matching rule, so the rules of the provisioning firewall come first.
+	input := append(append([]robotclient.FirewallRule{}, rules...), withoutProvisioningFirewallRules(firewall.Rules.Input)...)
+	if len(input) > maxFirewallInputRules {
+		msg := fmt.Sprintf("firewall has %d input rules with the %d rules of the provisioning firewall, Robot allows %d",
+			len(input), len(rules), maxFirewallInputRules)
+		conditions.MarkFalse(host, infrav1.ProvisioningFirewallRulesCondition, infrav1.FirewallRuleLimitReachedReason,
+			clusterv1.ConditionSeverityError, msg)
+		return actionError{err: errors.New(msg)}
+	}
+	firewall.Rules.Input = input
+
+	if actResult := s.setFirewall(firewall); actResult != nil {
+		return actResult
+	}
+	record.Eventf(host, "ProvisioningFirewallRulesAdded", "Added %d rules of the provisioning firewall to the Robot firewall", len(rules))
+	return s.waitForFirewall()
+}
+
+// removeProvisioningFirewallRules removes the rules of the provisioning firewall that have been added to the Robot
+// firewall of the host.
+func (s *Service) removeProvisioningFirewallRules() actionResult {
+	host := s.scope.HetznerBareMetalHost
+	if conditions.Get(host, infrav1.ProvisioningFirewallRulesCondition) == nil {
+		return nil
+	}
+
+	firewall, actResult := s.getFirewall()
+	if actResult != nil {
+		return actResult
+	}
+	if firewall.Status == robotclient.FirewallStatusInProcess {
+		return actionContinue{delay: provisioningFirewallDelay}
+	}
+
+	input := withoutProvisioningFirewallRules(firewall.Rules.Input)
+	if len(input) != len(firewall.Rules.Input) {
+		firewall.Rules.Input = input
+		if actResult := s.setFirewall(firewall); actResult != nil {
+			return actResult
+		}
+		record.Event(host, "ProvisioningFirewallRulesRemoved", "Removed the rules of the provisioning firewall from the Robot firewall")
+	}
+	conditions.Delete(host, infrav1.ProvisioningFirewallRulesCondition)
+	return nil
+}
+
+func (s *Service) waitForFirewall() actionResult {
+	conditions.MarkFalse(s.scope.HetznerBareMetalHost, infrav1.ProvisioningFirewallRulesCondition,
+		infrav1.FirewallInProcessReason, clusterv1.ConditionSeverityInfo, "waiting for Robot to apply the firewall")
+	return actionContinue{delay: provisioningFirewallDelay}
+}
+
+func (s *Service) getFirewall() (*robotclient.Firewall, actionResult) {
+	firewall, err := s.scope.RobotClient.GetFirewall(s.scope.HetznerBareMetalHost.Spec.ServerID)
+	if err != nil {
+		if models.IsError(err, models.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HetznerBareMetalHost, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HetznerBareMetalHost,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling robot function GetFirewall",
+			)
+			return nil, actionError{err: errors.Wrap(err, "failed to get firewall"), class: infrav1.FailureClassRateLimited}
+		}
+		return nil, actionError{err: errors.Wrap(err, "failed to get firewall")}
+	}
+	return firewall, nil
+}
+
+func (s *Service) setFirewall(firewall *robotclient.Firewall) actionResult {
+	if _, err := s.scope.RobotClient.SetFirewall(s.scope.HetznerBareMetalHost.Spec.ServerID, firewall); err != nil {
+		if models.IsError(err, models.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HetznerBareMetalHost, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HetznerBareMetalHost,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling robot function SetFirewall",
+			)
+			return actionError{err: errors.Wrap(err, "failed to set firewall"), class: infrav1.FailureClassRateLimited}
+		}
+		return actionError{err: errors.Wrap(err, "failed to set firewall")}
+	}
+	return nil
+}
+
+// provisioningFirewallRules returns a rule per source network and SSH port that is used during provisioning.
+func provisioningFirewallRules(spec *infrav1.ProvisioningFirewall, sshSpec *infrav1.SSHSpec) []robotclient.FirewallRule {
+	ports := []int{rescuePort}
+	if sshSpec != nil {
+		for _, port := range []int{sshSpec.PortAfterInstallImage, sshSpec.PortAfterCloudInit} {
+			if port != 0 && !intInList(port, ports) {
+				ports = append(ports, port)
+			}
+		}
+	}
+
+	var rules []robotclient.FirewallRule
+	for _, cidr := range spec.SourceIPs {
+		ipVersion := "ipv4"
+		if ip, _, err := net.ParseCIDR(cidr); err == nil && ip.To4() == nil {
+			ipVersion = "ipv6"
+		}
+		for _, port := range ports {
+			rules = append(rules, robotclient.FirewallRule{
+				Name:      fmt.Sprintf("%s-%d", provisioningFirewallRulePrefix, len(rules)),
+				IPVersion: ipVersion,
+				SrcIP:     cidr,
+				DstPort:   strconv.Itoa(port),
+				Protocol:  "tcp",
+				Action:    "accept",
+			})
+		}
+	}
+	return rules
+}
+
+// hasFirewallRules returns whether the rules are the first rules of the firewall.
+func hasFirewallRules(current, rules []robotclient.FirewallRule) bool {
+	if len(current) < len(rules) {
+		return false
+	}
+	for i, rule := range rules {
+		if current[i].Name != rule.Name || current[i].SrcIP != rule.SrcIP || current[i].DstPort != rule.DstPort {
+			return false
+		}
+	}
+	return true
+}
+
+// provisioningFirewallRuleName matches the names that provisioningFirewallRules gives its rules.
+var provisioningFirewallRuleName = regexp.MustCompile("^" + provisioningFirewallRulePrefix + "-[0-9]+$")
+
+// isProvisioningFirewallRule returns whether the rule has been added by provisioningFirewallRules. Rules of the
+// user are kept, even if their name starts with the prefix of the provisioning firewall.
+func isProvisioningFirewallRule(rule robotclient.FirewallRule) bool {
+	return provisioningFirewallRuleName.MatchString(rule.Name) && rule.SrcIP != "" && rule.DstPort != "" &&
+		rule.DstIP == "" && rule.SrcPort == "" && rule.TCPFlags == "" && rule.Protocol == "tcp" && rule.Action == "accept"
+}
+
+func withoutProvisioningFirewallRules(rules []robotclient.FirewallRule) []robotclient.FirewallRule {
+	result := make([]robotclient.FirewallRule, 0, len(rules))
+	for _, rule := range rules {
+		if !isProvisioningFirewallRule(rule) {
+			result = append(result, rule)
+		}
+	}
+	return result
+}
+
+func intInList(i int, list []int) bool {
+	for _, v := range list {
+		if v == i {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package host
+
+import (
+	"fmt"
+	"time"
+
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// maxActionHistory is the number of actions that are kept in the action history of a host.
+	maxActionHistory = 20
+	// maxActionHistoryMessageLength limits the length of the error messages in the action history.
+	maxActionHistoryMessageLength = 256
+)
+
+// recordActionHistory records the result of the action of a provisioning state in the action history of the host.
+// Consecutive reconciles of the action of a state update the same entry, so that actions that are polled, e.g.
+// while waiting for a reboot, do not evict the history. An entry that has not finished before the host changed
+// its state is marked as interrupted. A completed action is not recorded again as long as the host stays in its
+// state, so that steady states like provisioned keep one entry.
+func recordActionHistory(host *infrav1.HetznerBareMetalHost, state infrav1.ProvisioningState, actResult actionResult, err error, now time.Time) {
+	if isActionCompleted(actResult) && len(host.Spec.Status.ActionHistory) > 0 {
+		last := host.Spec.Status.ActionHistory[len(host.Spec.Status.ActionHistory)-1]
+		if last.State == state && last.Result == infrav1.ActionHistoryCompleted {
+			return
+		}
+	}
+
+	// Copy the history, the entries are changed in place and the old host is compared to detect changes
+	history := append([]infrav1.ActionHistoryEntry(nil), host.Spec.Status.ActionHistory...)
+	var entry *infrav1.ActionHistoryEntry
+	if len(history) > 0 {
+		last := &history[len(history)-1]
+		switch {
+		case last.Finished != nil:
+		case last.State == state:
+			entry = last
+		default:
+			finishActionHistoryEntry(last, infrav1.ActionHistoryInterrupted, now)
+		}
+	}
+
+	if entry == nil {
+		history = append(history, infrav1.ActionHistoryEntry{
+			State:   state,
+			Result:  infrav1.ActionHistoryInProgress,
+			Started: metav1.NewTime(now),
+		})
+		if len(history) > maxActionHistory {
+			history = history[len(history)-maxActionHistory:]
+		}
+		entry = &history[len(history)-1]
+	}
+
+	switch failed := actResult.(type) {
+	case actionComplete, deleteComplete:
+		finishActionHistoryEntry(entry, infrav1.ActionHistoryCompleted, now)
+	case actionFailed:
+		entry.Message = truncateMessage(fmt.Sprintf("%s: %s", failed.ErrorType, host.Spec.Status.ErrorMessage))
+		finishActionHistoryEntry(entry, infrav1.ActionHistoryFailed, now)
+	default:
+		if err != nil {
+			entry.Result = infrav1.ActionHistoryError
+			entry.Errors++
+			entry.Message = truncateMessage(err.Error())
+		}
+	}
+	host.Spec.Status.ActionHistory = history
+}
+
+func isActionCompleted(actResult actionResult) bool {
+	switch actResult.(type) {
+	case actionComplete, deleteComplete:
+		return true
+	default:
+		return false
+	}
+}
+
+func finishActionHistoryEntry(entry *infrav1.ActionHistoryEntry, result infrav1.ActionHistoryResult, now time.Time) {
+	finished := metav1.NewTime(now)
+	entry.Result = result
+	entry.Finished = &finished
+	entry.Duration = &metav1.Duration{Duration: now.Sub(entry.Started.Time).Round(time.Second)}
+}
+
+func truncateMessage(msg string) string {
+	if len(msg) <= maxActionHistoryMessageLength {
+		return msg
+	}
+	return msg[:maxActionHistoryMessageLength-3] + "..."
+}
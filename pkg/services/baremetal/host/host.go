@@ -21,20 +21,26 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"os"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/dryrun"
 	"github.com/syself/cluster-api-provider-hetzner/pkg/scope"
 	sshclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/baremetal/client/ssh"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/userdata"
 	"github.com/syself/cluster-api-provider-hetzner/pkg/utils"
 	"github.com/syself/hrobot-go/models"
+	"golang.org/x/crypto/ssh"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/record"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -51,6 +57,22 @@ const (
 	hardwareResetTimeout time.Duration = 60 * time.Minute
 	rescue               string        = "rescue"
 	rescuePort           int           = 22
+
+	defaultPrivilegeEscalation = "sudo -n"
+
+	// robotServerRefreshInterval is the interval in which the Robot data of the server is refreshed.
+	robotServerRefreshInterval = 6 * time.Hour
+
+	// trafficRefreshInterval is the interval in which the traffic of the server is refreshed.
+	trafficRefreshInterval = time.Hour
+
+	// fencingTimeout is the time in which Robot has to report a fenced server as stopped after its power
+	// button has been pressed.
+	fencingTimeout = 5 * time.Minute
+
+	// defaultProvisioningChecksTimeout is the time in which cloud init has to finish and the provisioning
+	// checks have to succeed.
+	defaultProvisioningChecksTimeout = 20 * time.Minute
 )
 
 // Service defines struct with machine scope to reconcile HetznerBareMetalHosts.
@@ -73,24 +95,100 @@ func (s *Service) Reconcile(ctx context.Context) (_ *ctrl.Result, err error) {
 
 	initialState := s.scope.HetznerBareMetalHost.Spec.Status.ProvisioningState
 
-	oldHost := *s.scope.HetznerBareMetalHost
+	// The reconcile only reads the host. Its changes are written at the end, see writeHost.
+	original := s.scope.HetznerBareMetalHost.DeepCopy()
+	s.reconcileRobotServer(ctx)
+	s.reconcileTraffic(ctx)
+	beforeAction := s.scope.HetznerBareMetalHost.DeepCopy()
+
 	hostStateMachine := newHostStateMachine(s.scope.HetznerBareMetalHost, s, &log)
 	actResult := hostStateMachine.ReconcileState(ctx)
 	result, err := actResult.Result()
+	if errors.Is(err, dryrun.ErrDryRun) {
+		// Nothing has been changed on the server, so the action is tried again without a backoff
+		return &ctrl.Result{RequeueAfter: dryrun.RequeueAfter}, nil
+	}
 	if err != nil {
 		err = errors.Wrap(err, fmt.Sprintf("action %q failed", initialState))
-		return &ctrl.Result{Requeue: true}, err
+		resetFailedAction(s.scope.HetznerBareMetalHost, beforeAction)
+		// Retry with a backoff instead of the rate limiter of the controller, so that
+		// the backoff survives restarts and is visible in the host
+		class := infrav1.FailureClassTransient
+		if classified, ok := actResult.(actionError); ok {
+			class = classified.FailureClass()
+		}
+		backoff := s.recordActionError(err, class)
+		recordActionHistory(s.scope.HetznerBareMetalHost, initialState, actResult, err, time.Now())
+		log.Error(err, "action returned an error, retrying after backoff", "backoff", backoff,
+			"actionErrorCount", s.scope.HetznerBareMetalHost.Spec.Status.ActionErrorCount, "failureClass", class)
+		if err := s.writeHost(ctx, original); err != nil {
+			return &ctrl.Result{RequeueAfter: 2 * time.Second}, errors.Wrap(err, fmt.Sprintf("failed to save host status after %q", initialState))
+		}
+		return &ctrl.Result{RequeueAfter: backoff}, nil
+	}
+	clearActionError(s.scope.HetznerBareMetalHost)
+	if failed, ok := actResult.(actionFailed); ok {
+		recordActionFailed(s.scope.HetznerBareMetalHost, failed)
 	}
+	recordActionHistory(s.scope.HetznerBareMetalHost, initialState, actResult, nil, time.Now())
 
-	if !reflect.DeepEqual(oldHost, s.scope.HetznerBareMetalHost) {
-		if err := saveHost(ctx, s.scope.Client, s.scope.HetznerBareMetalHost); err != nil {
+	// The host is gone once its finalizer has been removed
+	if _, deleted := actResult.(deleteComplete); deleted {
+		return &result, nil
+	}
+
+	if !reflect.DeepEqual(original, s.scope.HetznerBareMetalHost) {
+		if err := s.writeHost(ctx, original); err != nil {
 			return &ctrl.Result{RequeueAfter: 2 * time.Second}, errors.Wrap(err, fmt.Sprintf("failed to save host status after %q", initialState))
 		}
+		RecordProvisioningStateChange(original, s.scope.HetznerBareMetalHost)
 	}
 
+	// Refresh the Robot data of the server even if nothing else triggers a reconcile
+	if result.RequeueAfter == 0 && !result.Requeue {
+		result.RequeueAfter = robotServerRefreshInterval
+	}
 	return &result, nil
 }
 
+// reconcileRobotServer refreshes the product and contract data of the server from Robot if it is outdated.
+// Failures do not block the provisioning, the data is fetched again in the next reconcile.
+func (s *Service) reconcileRobotServer(ctx context.Context) {
+	host := s.scope.HetznerBareMetalHost
+	if robotServer := host.Spec.Status.RobotServer; robotServer != nil && robotServer.LastUpdated != nil &&
+		time.Since(robotServer.LastUpdated.Time) < robotServerRefreshInterval {
+		return
+	}
+
+	server, err := s.scope.RobotClient.GetBMServer(host.Spec.ServerID)
+	if err != nil {
+		if models.IsError(err, models.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(host, infrav1.RateLimitExceeded)
+			record.Event(host,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling robot function GetBMServer",
+			)
+		}
+		ctrl.LoggerFrom(ctx).Error(err, "failed to refresh Robot data of server")
+		return
+	}
+	setRobotServerStatus(host, server)
+}
+
+// setRobotServerStatus sets the data of the server in Robot in the status of the host.
+func setRobotServerStatus(host *infrav1.HetznerBareMetalHost, server *models.Server) {
+	now := metav1.Now()
+	host.Spec.Status.Datacenter = server.Dc
+	host.Spec.Status.RobotServer = &infrav1.RobotServerStatus{
+		Product:     server.Product,
+		Traffic:     server.Traffic,
+		Status:      server.Status,
+		Cancelled:   server.Cancelled,
+		PaidUntil:   server.PaidUntil,
+		LastUpdated: &now,
+	}
+}
+
 // Delete implements delete method of bare metal hosts.
 func (s *Service) Delete(ctx context.Context) (_ *ctrl.Result, err error) {
 	return nil, nil
@@ -106,6 +204,7 @@ func SetErrorMessage(host *infrav1.HetznerBareMetalHost, errType infrav1.ErrorTy
 	}
 	host.Spec.Status.ErrorType = errType
 	host.Spec.Status.ErrorMessage = message
+	host.Spec.Status.FailureClass = failureClassOfErrorType(errType)
 }
 
 func (s *Service) recordActionFailure(errorType infrav1.ErrorType, errorMessage string) actionFailed {
@@ -114,24 +213,72 @@ func (s *Service) recordActionFailure(errorType infrav1.ErrorType, errorMessage
 	return actionFailed{ErrorType: errorType, errorCount: s.scope.HetznerBareMetalHost.Spec.Status.ErrorCount}
 }
 
+// recordActionError increases the count of consecutive action errors and returns the backoff until the next try.
+// The backoff and the reason of the condition ActionSucceeded depend on the failure class of the error.
+func (s *Service) recordActionError(err error, class infrav1.FailureClass) time.Duration {
+	host := s.scope.HetznerBareMetalHost
+	host.Spec.Status.ActionErrorCount++
+	backoff := calculateActionErrorBackoffOfClass(host.Spec.Status.ActionErrorCount, class)
+	conditions.MarkFalse(
+		host,
+		infrav1.ActionSucceededCondition,
+		actionFailureReason(class),
+		clusterv1.ConditionSeverityWarning,
+		"action failed %d time(s) in a row, retrying in %s: %s",
+		host.Spec.Status.ActionErrorCount, backoff.Round(time.Second), err,
+	)
+	return backoff
+}
+
+// resetFailedAction resets the changes of an action that returned an error, e.g. a provisioning state it has
+// advanced to. Only the conditions of the host are kept. The action is tried again from the state it started in.
+func resetFailedAction(host, beforeAction *infrav1.HetznerBareMetalHost) {
+	conds := host.Spec.Status.Conditions
+	beforeAction.DeepCopyInto(host)
+	host.Spec.Status.Conditions = conds
+}
+
+// recordActionFailed shows a failed action with its failure class in the condition ActionSucceeded.
+// Permanent failures are errors, as they are not resolved without an intervention.
+func recordActionFailed(host *infrav1.HetznerBareMetalHost, failed actionFailed) {
+	class := failed.FailureClass()
+	severity := clusterv1.ConditionSeverityWarning
+	if class == infrav1.FailureClassPermanentConfiguration || class == infrav1.FailureClassPermanentHardware {
+		severity = clusterv1.ConditionSeverityError
+	}
+	conditions.MarkFalse(
+		host,
+		infrav1.ActionSucceededCondition,
+		actionFailureReason(class),
+		severity,
+		"%s: %s", failed.ErrorType, host.Spec.Status.ErrorMessage,
+	)
+}
+
+// clearActionError resets the count of consecutive action errors after an action has succeeded.
+func clearActionError(host *infrav1.HetznerBareMetalHost) {
+	host.Spec.Status.ActionErrorCount = 0
+	if conditions.IsFalse(host, infrav1.ActionSucceededCondition) {
+		conditions.MarkTrue(host, infrav1.ActionSucceededCondition)
+	}
+}
+
 // SetErrorCondition sets the error in host status and updates the host object.
 func SetErrorCondition(ctx context.Context, host *infrav1.HetznerBareMetalHost, client client.Client, errType infrav1.ErrorType, message string) error {
 	SetErrorMessage(host, errType, message)
 
-	if err := saveHost(ctx, client, host); err != nil {
+	if err := SaveHost(ctx, client, host); err != nil {
 		return errors.Wrap(err, "failed to update error message")
 	}
 	return nil
 }
 
-func saveHost(ctx context.Context, client client.Client, host *infrav1.HetznerBareMetalHost) error {
-	t := metav1.Now()
-	host.Spec.Status.LastUpdated = &t
-
-	if err := client.Update(ctx, host); err != nil {
-		return errors.Wrap(err, "failed to update status")
+// writeHost is the write phase of a reconcile. The changed metadata of the host is patched and its status applied.
+func (s *Service) writeHost(ctx context.Context, original *infrav1.HetznerBareMetalHost) error {
+	if err := patchHostMetadata(ctx, s.scope.Client, original, s.scope.HetznerBareMetalHost); err != nil {
+		return err
 	}
-	return nil
+	return SaveHost(ctx, s.scope.Client, s.scope.HetznerBareMetalHost)
 }
 
 // clearError removes any existing error message.
@@ -143,6 +290,9 @@ func clearError(host *infrav1.HetznerBareMetalHost) {
 	if host.Spec.Status.ErrorMessage != "" {
 		host.Spec.Status.ErrorMessage = ""
 	}
+	if host.Spec.Status.FailureClass != "" {
+		host.Spec.Status.FailureClass = ""
+	}
 }
 
 // hasRebootAnnotation checks for existence of reboot annotations and returns true if at least one exist.
@@ -210,6 +360,10 @@ func (s *Service) validateSSHKey(sshSecret *corev1.Secret, secretType string) ac
 }
 
 func (s *Service) actionPreparing() actionResult {
+	if actResult := s.waitForReprovisionApproval(); actResult != nil {
+		return actResult
+	}
+
 	server, err := s.scope.RobotClient.GetBMServer(s.scope.HetznerBareMetalHost.Spec.ServerID)
 	if err != nil {
 		if models.IsError(err, models.ErrorCodeServerNotFound) {
@@ -224,15 +378,20 @@ func (s *Service) actionPreparing() actionResult {
 				"RateLimitExceeded",
 				"exceeded rate limit with calling robot function GetBMServer",
 			)
-			return actionContinue{}
+			return actionError{err: errors.Wrap(err, "failed to get bare metal server"), class: infrav1.FailureClassRateLimited}
 		}
 		return actionError{err: errors.Wrap(err, "failed to get bare metal server")}
 	}
 
 	s.scope.HetznerBareMetalHost.Spec.Status.IPv4 = server.ServerIP
-	s.scope.HetznerBareMetalHost.Spec.Status.IPv6 = server.ServerIPv6Net + "1"
+	s.scope.HetznerBareMetalHost.Spec.Status.IPv6 = serverIPv6(server.ServerIPv6Net)
+	setRobotServerStatus(s.scope.HetznerBareMetalHost, server)
 
-	sshKey, actResult := s.ensureSSHKey(s.scope.HetznerCluster.Spec.SSHKeys.RobotRescueSecretRef, s.scope.RescueSSHSecret)
+	if actResult := s.ensureProvisioningFirewallRules(); actResult != nil {
+		return actResult
+	}
+
+	sshKey, actResult := s.ensureSSHKey(s.scope.HetznerCluster.Spec.SSHKeys.RobotRescueSecretRef, s.scope.RescueSSHSecret, infrav1.PreparationError)
 	if _, complete := actResult.(actionComplete); !complete {
 		return actResult
 	}
@@ -249,7 +408,7 @@ func (s *Service) actionPreparing() actionResult {
 					"RateLimitExceeded",
 					"exceeded rate limit with calling robot function GetReboot",
 				)
-				return actionContinue{}
+				return actionError{err: errors.Wrap(err, "failed to get reboot"), class: infrav1.FailureClassRateLimited}
 			}
 			return actionError{err: errors.Wrap(err, "failed to get reboot")}
 		}
@@ -269,6 +428,11 @@ func (s *Service) actionPreparing() actionResult {
 		return s.recordActionFailure(infrav1.RegistrationError, "rescue system not available for server")
 	}
 
+	// Robot throttles rescue activations and resets per datacenter
+	if actResult := s.waitForProvisioningSlot(rescueOperation); actResult != nil {
+		return actResult
+	}
+
 	// Delete old rescue activations if exist, as the ssh key might have changed in between
 	if _, err := s.scope.RobotClient.DeleteBootRescue(s.scope.HetznerBareMetalHost.Spec.ServerID); err != nil {
 		if models.IsError(err, models.ErrorCodeRateLimitExceeded) {
@@ -277,15 +441,16 @@ func (s *Service) actionPreparing() actionResult {
 				"RateLimitExceeded",
 				"exceeded rate limit with calling robot function DeleteBootRescue",
 			)
-			return actionContinue{}
+			return actionError{err: errors.Wrap(err, "failed to delete boot rescue"), class: infrav1.FailureClassRateLimited}
 		}
 		return actionError{err: errors.Wrap(err, "failed to delete boot rescue")}
 	}
 
-	if _, err := s.scope.RobotClient.SetBootRescue(
+	rescue, err := s.scope.RobotClient.SetBootRescue(
 		s.scope.HetznerBareMetalHost.Spec.ServerID,
 		s.scope.HetznerBareMetalHost.Spec.Status.SSHStatus.RescueKey.Fingerprint,
-	); err != nil {
+	)
+	if err != nil {
 		if models.IsError(err, models.ErrorCodeRateLimitExceeded) {
 			conditions.MarkTrue(s.scope.HetznerBareMetalHost, infrav1.RateLimitExceeded)
 			record.Event(s.scope.HetznerBareMetalHost,
@@ -295,9 +460,15 @@ func (s *Service) actionPreparing() actionResult {
 		}
 		return actionError{err: errors.Wrap(err, "failed to set boot rescue")}
 	}
+	if actResult := s.verifyRescueKey(rescue); actResult != nil {
+		return actResult
+	}
 
 	var rebootType infrav1.RebootType
 	switch {
+	case conditions.IsTrue(s.scope.HetznerBareMetalHost, infrav1.HostFencedCondition):
+		// The host is still powered off after it has been fenced
+		rebootType = infrav1.RebootTypePower
 	case s.scope.HetznerBareMetalHost.HasSoftwareReboot():
 		rebootType = infrav1.RebootTypeSoftware
 	case s.scope.HetznerBareMetalHost.HasHardwareReboot():
@@ -313,16 +484,28 @@ func (s *Service) actionPreparing() actionResult {
 				"RateLimitExceeded",
 				"exceeded rate limit with calling robot function RebootBMServer",
 			)
-			return actionContinue{}
+			return actionError{err: errors.Wrap(err, "failed to reboot bare metal server"), class: infrav1.FailureClassRateLimited}
 		}
 		return actionError{err: errors.Wrap(err, "failed to reboot bare metal server")}
 	}
+	conditions.Delete(s.scope.HetznerBareMetalHost, infrav1.HostFencedCondition)
 
 	s.scope.SetErrorCount(0)
 	clearError(s.scope.HetznerBareMetalHost)
 	return actionComplete{}
 }
 
+// serverIPv6 returns the first address of the IPv6 subnet of a server, which installimage configures, e.g.
+// 2a01:4f8:111:4221::1 for 2a01:4f8:111:4221::. Servers that are ordered without IPv4 are reached by it.
+func serverIPv6(subnet string) string {
+	ip := net.ParseIP(subnet)
+	if ip == nil || ip.To4() != nil {
+		return ""
+	}
+	ip[15]++
+	return ip.String()
+}
+
 func getIPAddress(status infrav1.ControllerGeneratedStatus) string {
 	if status.IPv4 == "" {
 		return status.IPv6
@@ -330,7 +513,56 @@ func getIPAddress(status infrav1.ControllerGeneratedStatus) string {
 	return status.IPv4
 }
 
-func (s *Service) ensureSSHKey(sshSecretRef infrav1.SSHSecretRef, sshSecret *corev1.Secret) (infrav1.SSHKey, actionResult) {
+// osSSHClient returns an SSH client for the installed operating system. With private provisioning,
+// the host is reached via its private IP through the bastion host.
+func (s *Service) osSSHClient(port int) sshclient.Client {
+	return s.scope.SSHClientFactory.NewClient(s.osSSHInput(port))
+}
+
+// osSSHClientAfterCloudInit returns an SSH client for the installed operating system after cloud init.
+// It logs in as the user after cloud init, whose commands are run with privilege escalation.
+func (s *Service) osSSHClientAfterCloudInit() sshclient.Client {
+	sshSpec := s.scope.HetznerBareMetalHost.Spec.Status.SSHSpec
+	in := s.osSSHInput(sshSpec.PortAfterCloudInit)
+	if hasUserAfterCloudInit(sshSpec) {
+		in.User = sshSpec.UserAfterCloudInit
+		in.PrivilegeEscalation = sshSpec.PrivilegeEscalation
+		if in.PrivilegeEscalation == "" {
+			in.PrivilegeEscalation = defaultPrivilegeEscalation
+		}
+	}
+	return s.scope.SSHClientFactory.NewClient(in)
+}
+
+// hasUserAfterCloudInit checks whether another user than root logs in after cloud init.
+func hasUserAfterCloudInit(sshSpec *infrav1.SSHSpec) bool {
+	return sshSpec.UserAfterCloudInit != "" && sshSpec.UserAfterCloudInit != "root"
+}
+
+func (s *Service) osSSHInput(port int) sshclient.Input {
+	privateKey := sshclient.CredentialsFromSecret(s.scope.OSSSHSecret, s.scope.HetznerBareMetalHost.Spec.Status.SSHSpec.SecretRef).PrivateKey
+	in := sshclient.Input{
+		PrivateKey: privateKey,
+		Port:       port,
+		IP:         getIPAddress(s.scope.HetznerBareMetalHost.Spec.Status),
+	}
+
+	if privateProvisioning := s.scope.HetznerBareMetalHost.Spec.Status.SSHSpec.PrivateProvisioning; privateProvisioning != nil {
+		in.IP = s.scope.HetznerBareMetalHost.Spec.PrivateIP
+		in.Bastion = &sshclient.Bastion{
+			IP:         privateProvisioning.Bastion.Address,
+			PrivateKey: privateKey,
+			Port:       privateProvisioning.Bastion.Port,
+			User:       privateProvisioning.Bastion.User,
+		}
+	}
+	return in
+}
+
+// ensureSSHKey returns the SSH key of the secret in Robot and uploads it if it does not exist yet. A key with the
+// name of the secret but another public key, e.g. a stale key of an earlier secret, fails the action with errorType,
+// as the host could not be reached with the private key of the secret.
+func (s *Service) ensureSSHKey(sshSecretRef infrav1.SSHSecretRef, sshSecret *corev1.Secret, errorType infrav1.ErrorType) (infrav1.SSHKey, actionResult) {
 	hetznerSSHKeys, err := s.scope.RobotClient.ListSSHKeys()
 	if err != nil {
 		if models.IsError(err, models.ErrorCodeRateLimitExceeded) {
@@ -339,42 +571,85 @@ func (s *Service) ensureSSHKey(sshSecretRef infrav1.SSHSecretRef, sshSecret *cor
 				"RateLimitExceeded",
 				"exceeded rate limit with calling robot function ListSSHKeys",
 			)
-			return infrav1.SSHKey{}, actionContinue{}
+			return infrav1.SSHKey{}, actionError{err: errors.Wrap(err, "failed to list ssh heys"), class: infrav1.FailureClassRateLimited}
 		}
 		if !models.IsError(err, models.ErrorCodeNotFound) {
 			return infrav1.SSHKey{}, actionError{err: errors.Wrap(err, "failed to list ssh heys")}
 		}
 	}
 
-	foundSSHKey := false
-	var sshKey infrav1.SSHKey
+	name := strings.TrimSuffix(string(sshSecret.Data[sshSecretRef.Key.Name]), "\n")
+	publicKey := string(sshSecret.Data[sshSecretRef.Key.PublicKey])
+	expectedFingerprint := sshKeyFingerprint(publicKey)
+
 	for _, hetznerSSHKey := range hetznerSSHKeys {
-		if strings.TrimSuffix(string(sshSecret.Data[sshSecretRef.Key.Name]), "\n") == hetznerSSHKey.Name {
-			foundSSHKey = true
-			sshKey.Name = hetznerSSHKey.Name
-			sshKey.Fingerprint = hetznerSSHKey.Fingerprint
+		if hetznerSSHKey.Name != name {
+			continue
+		}
+		if expectedFingerprint != "" && hetznerSSHKey.Fingerprint != expectedFingerprint {
+			return infrav1.SSHKey{}, s.recordActionFailure(errorType, fmt.Sprintf(
+				"SSH key %s in Robot has fingerprint %s, but the public key of secret %s has fingerprint %s. "+
+					"Delete the SSH key in Robot or change the name in the secret",
+				name, hetznerSSHKey.Fingerprint, sshSecretRef.Name, expectedFingerprint,
+			))
 		}
+		return infrav1.SSHKey{Name: hetznerSSHKey.Name, Fingerprint: hetznerSSHKey.Fingerprint}, actionComplete{}
 	}
 
-	// Upload SSH key if not found
-	if !foundSSHKey {
-		publicKey := string(sshSecret.Data[sshSecretRef.Key.PublicKey])
-		hetznerSSHKey, err := s.scope.RobotClient.SetSSHKey(string(sshSecret.Data[sshSecretRef.Key.Name]), publicKey)
-		if err != nil {
-			if models.IsError(err, models.ErrorCodeRateLimitExceeded) {
-				conditions.MarkTrue(s.scope.HetznerBareMetalHost, infrav1.RateLimitExceeded)
-				record.Event(s.scope.HetznerBareMetalHost,
-					"RateLimitExceeded",
-					"exceeded rate limit with calling robot function SetSSHKey",
-				)
-				return infrav1.SSHKey{}, actionContinue{}
+	// Robot does not accept a public key twice, so the key is used if it has been uploaded with another name
+	if expectedFingerprint != "" {
+		for _, hetznerSSHKey := range hetznerSSHKeys {
+			if hetznerSSHKey.Fingerprint == expectedFingerprint {
+				return infrav1.SSHKey{Name: hetznerSSHKey.Name, Fingerprint: hetznerSSHKey.Fingerprint}, actionComplete{}
 			}
-			return infrav1.SSHKey{}, actionError{err: errors.Wrap(err, "failed to set ssh key")}
 		}
-		sshKey.Name = hetznerSSHKey.Name
-		sshKey.Fingerprint = hetznerSSHKey.Fingerprint
 	}
-	return sshKey, actionComplete{}
+
+	// Upload SSH key if not found
+	hetznerSSHKey, err := s.scope.RobotClient.SetSSHKey(string(sshSecret.Data[sshSecretRef.Key.Name]), publicKey)
+	if err != nil {
+		if models.IsError(err, models.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HetznerBareMetalHost, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HetznerBareMetalHost,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling robot function SetSSHKey",
+			)
+			return infrav1.SSHKey{}, actionError{err: errors.Wrap(err, "failed to set ssh key"), class: infrav1.FailureClassRateLimited}
+		}
+		return infrav1.SSHKey{}, actionError{err: errors.Wrap(err, "failed to set ssh key")}
+	}
+	return infrav1.SSHKey{Name: hetznerSSHKey.Name, Fingerprint: hetznerSSHKey.Fingerprint}, actionComplete{}
+}
+
+// sshKeyFingerprint returns the MD5 fingerprint of a public key in the format of Robot, or the empty string if the
+// public key cannot be parsed. Keys that cannot be parsed are not verified.
+func sshKeyFingerprint(publicKey string) string {
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(publicKey))
+	if err != nil {
+		return ""
+	}
+	return ssh.FingerprintLegacyMD5(key)
+}
+
+// verifyRescueKey checks that the rescue system has been activated with the rescue SSH key. Robot reports the
+// authorized keys of the activated rescue system. If none of them is the rescue key, SSH would fail with a
+// generic authentication error after the reboot.
+func (s *Service) verifyRescueKey(rescue *models.Rescue) actionResult {
+	expected := s.scope.HetznerBareMetalHost.Spec.Status.SSHStatus.RescueKey
+	if rescue == nil || len(rescue.AuthorizedKey) == 0 || expected == nil {
+		return nil
+	}
+	fingerprints := make([]string, 0, len(rescue.AuthorizedKey))
+	for _, authorizedKey := range rescue.AuthorizedKey {
+		if authorizedKey.Key.Fingerprint == expected.Fingerprint {
+			return nil
+		}
+		fingerprints = append(fingerprints, authorizedKey.Key.Fingerprint)
+	}
+	return s.recordActionFailure(infrav1.PreparationError, fmt.Sprintf(
+		"rescue system has been activated with SSH keys %s instead of the rescue SSH key %s with fingerprint %s",
+		strings.Join(fingerprints, ", "), expected.Name, expected.Fingerprint,
+	))
 }
 
 func (s *Service) handleIncompleteBootError(isRebootIntoRescue bool, isTimeout bool, isConnectionRefused bool) error {
@@ -695,34 +970,20 @@ func (s *Service) actionRegistering() actionResult {
 		return actionContinue{delay: 10 * time.Second}
 	}
 
-	if s.scope.HetznerBareMetalHost.Spec.Status.HardwareDetails == nil {
-		var hardwareDetails infrav1.HardwareDetails
-
-		mebiBytes, err := s.obtainHardwareDetailsRAM(sshClient)
-		if err != nil {
+	var hardwareReplaced bool
+	if s.scope.HetznerBareMetalHost.Spec.Status.HardwareDetails != nil {
+		var err error
+		if hardwareReplaced, err = s.detectHardwareReplacement(sshClient); err != nil {
 			return actionError{err: err}
 		}
-		hardwareDetails.RAMGB = mebiBytes / 1000
-
-		nics, err := s.obtainHardwareDetailsNics(sshClient)
-		if err != nil {
-			return actionError{err: err}
-		}
-		hardwareDetails.NIC = nics
-
-		storage, err := s.obtainHardwareDetailsStorage(sshClient)
-		if err != nil {
-			return actionError{err: err}
-		}
-		hardwareDetails.Storage = storage
+	}
 
-		cpu, err := s.obtainHardwareDetailsCPU(sshClient)
+	if s.scope.HetznerBareMetalHost.Spec.Status.HardwareDetails == nil {
+		hardwareDetails, err := s.inspectHardware(sshClient)
 		if err != nil {
 			return actionError{err: err}
 		}
-		hardwareDetails.CPU = cpu
-
-		s.scope.HetznerBareMetalHost.Spec.Status.HardwareDetails = &hardwareDetails
+		s.scope.HetznerBareMetalHost.Spec.Status.HardwareDetails = hardwareDetails
 	}
 	if s.scope.HetznerBareMetalHost.Spec.RootDeviceHints == nil ||
 		!s.scope.HetznerBareMetalHost.Spec.RootDeviceHints.IsValid() {
@@ -738,7 +999,11 @@ func (s *Service) actionRegistering() actionResult {
 			}
 		}
 		if !foundWWN {
-			return s.recordActionFailure(infrav1.RegistrationError, fmt.Sprintf("no storage device found with root device hint %s", wwn))
+			msg := fmt.Sprintf("no storage device found with root device hint %s", wwn)
+			if hardwareReplaced {
+				msg += ": the hardware of the server has been replaced, the root device hints have to be updated"
+			}
+			return s.recordActionFailure(infrav1.RegistrationError, msg)
 		}
 	}
 	s.scope.SetErrorCount(0)
@@ -746,6 +1011,123 @@ func (s *Service) actionRegistering() actionResult {
 	return actionComplete{}
 }
 
+// detectHardwareReplacement compares the NICs and storage devices of the host with its hardware details. Hetzner
+// replaces faulty hardware under the same server number, so the MACs or WWNs differ afterwards. In this case the
+// hardware details are discarded, so that the hardware is inspected again. The results of the NIC and storage
+// probes are kept for the inspection.
+func (s *Service) detectHardwareReplacement(sshClient sshclient.Client) (bool, error) {
+	host := s.scope.HetznerBareMetalHost
+
+	nics, err := s.obtainHardwareDetailsNics(sshClient)
+	if err != nil {
+		return false, err
+	}
+	storage, err := s.obtainHardwareDetailsStorage(sshClient)
+	if err != nil {
+		return false, err
+	}
+
+	var changes []string
+	oldMACs, newMACs := nicMACs(host.Spec.Status.HardwareDetails.NIC), nicMACs(nics)
+	if !reflect.DeepEqual(oldMACs, newMACs) {
+		changes = append(changes, fmt.Sprintf("MACs changed from %v to %v", oldMACs, newMACs))
+	}
+	oldWWNs, newWWNs := storageWWNs(host.Spec.Status.HardwareDetails.Storage), storageWWNs(storage)
+	if !reflect.DeepEqual(oldWWNs, newWWNs) {
+		changes = append(changes, fmt.Sprintf("WWNs changed from %v to %v", oldWWNs, newWWNs))
+	}
+	if len(changes) == 0 {
+		return false, nil
+	}
+
+	record.Warnf(host, "HardwareReplaced", "Hardware of server %d has been replaced, inspecting it again: %s",
+		host.Spec.ServerID, strings.Join(changes, ", "))
+	host.Spec.Status.HardwareDetails = nil
+	host.Spec.Status.HardwareInspection = &infrav1.HardwareInspection{
+		ServerID: host.Spec.ServerID,
+		NIC:      nics,
+		Storage:  storage,
+	}
+	return true, nil
+}
+
+// nicMACs returns the sorted MACs of the NICs. A NIC with IPv4 and IPv6 is listed twice, its MAC only once.
+func nicMACs(nics []infrav1.NIC) []string {
+	var macs []string
+	for _, nic := range nics {
+		if nic.MAC != "" && !utils.StringInList(macs, nic.MAC) {
+			macs = append(macs, nic.MAC)
+		}
+	}
+	sort.Strings(macs)
+	return macs
+}
+
+// storageWWNs returns the sorted WWNs of the storage devices.
+func storageWWNs(storage []infrav1.Storage) []string {
+	var wwns []string
+	for _, device := range storage {
+		if device.WWN != "" {
+			wwns = append(wwns, device.WWN)
+		}
+	}
+	sort.Strings(wwns)
+	return wwns
+}
+
+// inspectHardware runs the hardware probes in the rescue system. The result of each probe is recorded in the
+// status of the host, as the status is saved even if the action fails. This way, a retry or a restart of the
+// controller resumes the inspection instead of running all probes again.
+func (s *Service) inspectHardware(sshClient sshclient.Client) (*infrav1.HardwareDetails, error) {
+	host := s.scope.HetznerBareMetalHost
+	inspection := host.Spec.Status.HardwareInspection
+	if inspection == nil || inspection.ServerID != host.Spec.ServerID {
+		inspection = &infrav1.HardwareInspection{ServerID: host.Spec.ServerID}
+		host.Spec.Status.HardwareInspection = inspection
+	}
+
+	if inspection.RAMGB == nil {
+		mebiBytes, err := s.obtainHardwareDetailsRAM(sshClient)
+		if err != nil {
+			return nil, err
+		}
+		ramGB := mebiBytes / 1000
+		inspection.RAMGB = &ramGB
+	}
+
+	if inspection.NIC == nil {
+		nics, err := s.obtainHardwareDetailsNics(sshClient)
+		if err != nil {
+			return nil, err
+		}
+		inspection.NIC = nics
+	}
+
+	if inspection.Storage == nil {
+		storage, err := s.obtainHardwareDetailsStorage(sshClient)
+		if err != nil {
+			return nil, err
+		}
+		inspection.Storage = storage
+	}
+
+	if inspection.CPU == nil {
+		cpu, err := s.obtainHardwareDetailsCPU(sshClient)
+		if err != nil {
+			return nil, err
+		}
+		inspection.CPU = &cpu
+	}
+
+	host.Spec.Status.HardwareInspection = nil
+	return &infrav1.HardwareDetails{
+		RAMGB:   *inspection.RAMGB,
+		NIC:     inspection.NIC,
+		Storage: inspection.Storage,
+		CPU:     *inspection.CPU,
+	}, nil
+}
+
 func (s *Service) handleIncompleteBootRegistering(out sshclient.Output) (isTimeout bool, isConnectionRefused bool, reterr error) {
 	if out.Err != nil {
 		switch {
@@ -1020,6 +1402,10 @@ func handleSSHError(out sshclient.Output) error {
 }
 
 func (s *Service) actionImageInstalling() actionResult {
+	if actResult := s.waitForProvisioningSlot(imagingOperation); actResult != nil {
+		return actResult
+	}
+
 	creds := sshclient.CredentialsFromSecret(s.scope.RescueSSHSecret, s.scope.HetznerCluster.Spec.SSHKeys.RobotRescueSecretRef)
 	in := sshclient.Input{
 		PrivateKey: creds.PrivateKey,
@@ -1032,20 +1418,40 @@ func (s *Service) actionImageInstalling() actionResult {
 	if s.scope.OSSSHSecret == nil {
 		return s.recordActionFailure(infrav1.PreparationError, infrav1.ErrorMessageMissingOSSSHSecret)
 	}
-	sshKey, actResult := s.ensureSSHKey(s.scope.HetznerBareMetalHost.Spec.Status.SSHSpec.SecretRef, s.scope.OSSSHSecret)
+	// With private provisioning, the host is not reachable after installimage without its private IP
+	if s.scope.HetznerBareMetalHost.Spec.Status.SSHSpec.PrivateProvisioning != nil && s.scope.HetznerBareMetalHost.Spec.PrivateIP == "" {
+		return s.recordActionFailure(infrav1.ProvisioningError, infrav1.ErrorMessageMissingPrivateIP)
+	}
+
+	sshKey, actResult := s.ensureSSHKey(s.scope.HetznerBareMetalHost.Spec.Status.SSHSpec.SecretRef, s.scope.OSSSHSecret, infrav1.ProvisioningError)
 	if _, complete := actResult.(actionComplete); !complete {
 		return actResult
 	}
 
 	s.scope.HetznerBareMetalHost.Spec.Status.SSHStatus.OSKey = &sshKey
 
-	image := s.scope.HetznerBareMetalHost.Spec.Status.InstallImage.Image
+	installImage, err := renderInstallImage(*s.scope.HetznerBareMetalHost.Spec.Status.InstallImage, s.installImageVariables())
+	if err != nil {
+		return s.recordActionFailure(infrav1.ProvisioningError, err.Error())
+	}
+
+	image := installImage.Image
 	imagePath, needsDownload, errorMessage := getImageDetails(image)
 	if errorMessage != "" {
 		return s.recordActionFailure(infrav1.ProvisioningError, errorMessage)
 	}
 	if needsDownload {
-		out := sshClient.DownloadImage(imagePath, image.URL)
+		var downloadCreds sshclient.DownloadCredentials
+		if image.DownloadSecretRef != nil {
+			if s.scope.ImageDownloadSecret == nil {
+				return s.recordActionFailure(infrav1.PreparationError, infrav1.ErrorMessageMissingImageDownloadSecret)
+			}
+			downloadCreds = sshclient.DownloadCredentialsFromSecret(s.scope.ImageDownloadSecret)
+			if err := downloadCreds.Validate(); err != nil {
+				return s.recordActionFailure(infrav1.PreparationError, infrav1.ErrorMessageMissingOrInvalidSecretData+": "+err.Error())
+			}
+		}
+		out := sshClient.DownloadImage(imagePath, image.URL, downloadCreds)
 		if err := handleSSHError(out); err != nil {
 			return actionError{err: errors.Wrap(err, "failed to download image")}
 		}
@@ -1072,15 +1478,26 @@ func (s *Service) actionImageInstalling() actionResult {
 		image:     imagePath,
 	}
 
-	autoSetup := buildAutoSetup(*s.scope.HetznerBareMetalHost.Spec.Status.InstallImage, autoSetupInput)
+	autoSetup := buildAutoSetup(installImage, autoSetupInput)
 
 	out := sshClient.CreateAutoSetup(autoSetup)
 	if err := handleSSHError(out); err != nil {
 		return actionError{err: errors.Wrapf(err, "failed to create autosetup %s", autoSetup)}
 	}
 
+	appliedImage := image.URL
+	if appliedImage == "" {
+		appliedImage = image.Path
+	}
+	s.scope.HetznerBareMetalHost.Spec.Status.AppliedConfiguration = &infrav1.AppliedConfiguration{
+		Image:              appliedImage,
+		AutoSetupHash:      utils.SHA256Hash([]byte(autoSetup)),
+		SSHKeyFingerprints: []string{sshKey.Fingerprint},
+	}
+	setInstalledImage(s.scope.HetznerBareMetalHost, &installImage)
+
 	// Create post install script
-	postInstallScript := s.scope.HetznerBareMetalHost.Spec.Status.InstallImage.PostInstallScript
+	postInstallScript := installImage.PostInstallScript
 
 	if postInstallScript != "" {
 		out := sshClient.CreatePostInstallScript(postInstallScript)
@@ -1106,7 +1523,7 @@ func (s *Service) actionImageInstalling() actionResult {
 				"RateLimitExceeded",
 				"exceeded rate limit with calling robot function SetBMServerName",
 			)
-			return actionContinue{}
+			return actionError{err: fmt.Errorf("failed to update name of host in robot API: %w", err), class: infrav1.FailureClassRateLimited}
 		}
 		return actionError{err: fmt.Errorf("failed to update name of host in robot API: %w", err)}
 	}
@@ -1121,6 +1538,21 @@ func (s *Service) actionImageInstalling() actionResult {
 	return actionComplete{}
 }
 
+func (s *Service) installImageVariables() installImageVariables {
+	host := s.scope.HetznerBareMetalHost
+	vars := installImageVariables{
+		ClusterName:       host.Labels[clusterv1.ClusterLabelName],
+		KubernetesVersion: host.Spec.Status.KubernetesVersion,
+	}
+	if host.Spec.ConsumerRef != nil {
+		vars.MachineName = host.Spec.ConsumerRef.Name
+	}
+	if host.Spec.Status.HardwareDetails != nil {
+		vars.Arch = goArch(host.Spec.Status.HardwareDetails.CPU.Arch)
+	}
+	return vars
+}
+
 func getDeviceNames(wwn []string, storageDevices []infrav1.Storage) []string {
 	deviceNames := make([]string, 0, len(storageDevices))
 	for _, device := range storageDevices {
@@ -1160,36 +1592,14 @@ func getImageDetails(image infrav1.Image) (imagePath string, needsDownload bool,
 	return imagePath, needsDownload, errorMessage
 }
 
-func (s *Service) actionProvisioning() actionResult {
-	port := s.scope.HetznerBareMetalHost.Spec.Status.SSHSpec.PortAfterInstallImage
-	sshClient := s.scope.SSHClientFactory.NewClient(sshclient.Input{
-		PrivateKey: sshclient.CredentialsFromSecret(s.scope.OSSSHSecret, s.scope.HetznerBareMetalHost.Spec.Status.SSHSpec.SecretRef).PrivateKey,
-		Port:       s.scope.HetznerBareMetalHost.Spec.Status.SSHSpec.PortAfterInstallImage,
-		IP:         getIPAddress(s.scope.HetznerBareMetalHost.Spec.Status),
-	})
+func (s *Service) actionProvisioning(ctx context.Context) actionResult {
+	sshClient := s.osSSHClient(s.scope.HetznerBareMetalHost.Spec.Status.SSHSpec.PortAfterInstallImage)
 
-	// Check hostname with sshClient
-	out := sshClient.GetHostName()
-	if trimLineBreak(out.StdOut) != infrav1.BareMetalHostNamePrefix+s.scope.HetznerBareMetalHost.Spec.ConsumerRef.Name {
-		creds := sshclient.CredentialsFromSecret(s.scope.RescueSSHSecret, s.scope.HetznerCluster.Spec.SSHKeys.RobotRescueSecretRef)
-		in := sshclient.Input{
-			PrivateKey: creds.PrivateKey,
-			Port:       rescuePort,
-			IP:         getIPAddress(s.scope.HetznerBareMetalHost.Spec.Status),
-		}
-		rescueSSHClient := s.scope.SSHClientFactory.NewClient(in)
-
-		isTimeout, isConnectionFailed, err := handleIncompleteBootInstallImage(out, rescueSSHClient, port)
-		if err != nil {
-			return actionError{err: errors.Wrap(err, "failed to handle incomplete boot - installImage")}
-		}
-		if err := s.handleIncompleteBootError(false, isTimeout, isConnectionFailed); err != nil {
-			return actionError{err: errors.Wrap(err, "failed to handle incomplete boot")}
-		}
-		return actionContinue{delay: 10 * time.Second}
+	if actResult := s.ensureInstalledOSBooted(sshClient); actResult != nil {
+		return actResult
 	}
 
-	out = sshClient.EnsureCloudInit()
+	out := sshClient.EnsureCloudInit()
 	if err := handleSSHError(out); err != nil {
 		return actionError{err: errors.Wrap(err, "failed to ensure cloud init")}
 	}
@@ -1208,15 +1618,16 @@ func (s *Service) actionProvisioning() actionResult {
 		return actionError{err: errors.Wrap(err, "failed to create meta data")}
 	}
 
-	userData, err := s.scope.GetRawBootstrapData(context.TODO())
+	userData, err := s.scope.GetRawBootstrapData(ctx)
 	if err != nil {
 		return actionError{err: errors.Wrap(err, "failed to get user data")}
 	}
 
-	out = sshClient.CreateUserData(string(userData))
-	if err := handleSSHError(out); err != nil {
+	renderedUserData, err := s.createUserData(ctx, sshClient, userData)
+	if err != nil {
 		return actionError{err: errors.Wrap(err, "failed to create user data")}
 	}
+	s.setAppliedUserData(userData, renderedUserData)
 
 	out = sshClient.Reboot()
 	if err := handleSSHError(out); err != nil {
@@ -1228,6 +1639,35 @@ func (s *Service) actionProvisioning() actionResult {
 	return actionComplete{}
 }
 
+// ensureInstalledOSBooted returns nil if the installed operating system is reachable. Otherwise, it handles the
+// incomplete boot and returns the action result.
+func (s *Service) ensureInstalledOSBooted(sshClient sshclient.Client) actionResult {
+	port := s.scope.HetznerBareMetalHost.Spec.Status.SSHSpec.PortAfterInstallImage
+
+	// Check hostname with sshClient
+	out := sshClient.GetHostName()
+	if trimLineBreak(out.StdOut) == infrav1.BareMetalHostNamePrefix+s.scope.HetznerBareMetalHost.Spec.ConsumerRef.Name {
+		return nil
+	}
+
+	creds := sshclient.CredentialsFromSecret(s.scope.RescueSSHSecret, s.scope.HetznerCluster.Spec.SSHKeys.RobotRescueSecretRef)
+	in := sshclient.Input{
+		PrivateKey: creds.PrivateKey,
+		Port:       rescuePort,
+		IP:         getIPAddress(s.scope.HetznerBareMetalHost.Spec.Status),
+	}
+	rescueSSHClient := s.scope.SSHClientFactory.NewClient(in)
+
+	isTimeout, isConnectionFailed, err := handleIncompleteBootInstallImage(out, rescueSSHClient, port)
+	if err != nil {
+		return actionError{err: errors.Wrap(err, "failed to handle incomplete boot - installImage")}
+	}
+	if err := s.handleIncompleteBootError(false, isTimeout, isConnectionFailed); err != nil {
+		return actionError{err: errors.Wrap(err, "failed to handle incomplete boot")}
+	}
+	return actionContinue{delay: 10 * time.Second}
+}
+
 func handleIncompleteBootInstallImage(out sshclient.Output, sshClient sshclient.Client, port int) (isTimeout bool, isConnectionRefused bool, reterr error) {
 	// check err
 	if out.Err != nil {
@@ -1284,43 +1724,26 @@ func handleIncompleteBootInstallImage(out sshclient.Output, sshClient sshclient.
 }
 
 func (s *Service) actionEnsureProvisioned() actionResult {
-	sshClient := s.scope.SSHClientFactory.NewClient(sshclient.Input{
-		PrivateKey: sshclient.CredentialsFromSecret(s.scope.OSSSHSecret, s.scope.HetznerBareMetalHost.Spec.Status.SSHSpec.SecretRef).PrivateKey,
-		Port:       s.scope.HetznerBareMetalHost.Spec.Status.SSHSpec.PortAfterCloudInit,
-		IP:         getIPAddress(s.scope.HetznerBareMetalHost.Spec.Status),
-	})
+	sshSpec := s.scope.HetznerBareMetalHost.Spec.Status.SSHSpec
+	sshClient := s.osSSHClientAfterCloudInit()
 
 	// Check hostname with sshClient
 	out := sshClient.GetHostName()
 	if trimLineBreak(out.StdOut) != infrav1.BareMetalHostNamePrefix+s.scope.HetznerBareMetalHost.Spec.ConsumerRef.Name {
+		// A failed authentication could mean that cloud init is still running if it creates the user after cloud init
+		if out.Err != nil && sshclient.IsAuthenticationFailedError(out.Err) && hasUserAfterCloudInit(sshSpec) {
+			if actResult, ok := s.checkCloudInitAfterInstallImage(); ok {
+				return actResult
+			}
+		}
+
 		isTimeout, isConnectionFailed, err := handleIncompleteBootProvisioned(out)
 		if err != nil {
 			return actionError{err: errors.Wrap(err, "failed to handle incomplete boot - provisioning")}
 		}
 		// A connection failed error could mean that cloud init is still running (if cloudInit introduces a new port)
-		if isConnectionFailed &&
-			s.scope.HetznerBareMetalHost.Spec.Status.SSHSpec.PortAfterInstallImage != s.scope.HetznerBareMetalHost.Spec.Status.SSHSpec.PortAfterCloudInit {
-			oldSSHClient := s.scope.SSHClientFactory.NewClient(sshclient.Input{
-				PrivateKey: sshclient.CredentialsFromSecret(s.scope.OSSSHSecret, s.scope.HetznerBareMetalHost.Spec.Status.SSHSpec.SecretRef).PrivateKey,
-				Port:       s.scope.HetznerBareMetalHost.Spec.Status.SSHSpec.PortAfterInstallImage,
-				IP:         getIPAddress(s.scope.HetznerBareMetalHost.Spec.Status),
-			})
-			actResult, err := s.checkCloudInitStatus(oldSSHClient)
-			// If this ssh client also gives an error, then we go back to analyzing the error of the first ssh call
-			// This happens in the statement below this one.
-			if err == nil {
-				// If cloud-init status == "done" and cloud init was successful,
-				// then we will soon reboot and be able to access the server via the new port
-				if _, complete := actResult.(actionComplete); complete {
-					// Check whether cloud init did not run successfully even though it shows "done"
-					actResult := s.handleCloudInitNotStarted()
-					if _, complete := actResult.(actionComplete); complete {
-						return actionContinue{delay: 10 * time.Second}
-					}
-					return actResult
-				}
-			}
-			if _, actionerr := actResult.(actionError); !actionerr {
+		if isConnectionFailed && sshSpec.PortAfterInstallImage != sshSpec.PortAfterCloudInit {
+			if actResult, ok := s.checkCloudInitAfterInstallImage(); ok {
 				return actResult
 			}
 		}
@@ -1331,20 +1754,37 @@ func (s *Service) actionEnsureProvisioned() actionResult {
 		return actionContinue{delay: 10 * time.Second}
 	}
 
-	// Check the status of cloud init
-	actResult, _ := s.checkCloudInitStatus(sshClient)
+	// Hosts with a pre-baked image do not wait for cloud init to finish once the node services are healthy
+	if !s.preBakedNodeReady(sshClient) {
+		// Check the status of cloud init
+		actResult, _ := s.checkCloudInitStatus(sshClient)
+		if _, complete := actResult.(actionComplete); !complete {
+			return actResult
+		}
+
+		// Check whether cloud init did not run successfully even though it shows "done"
+		// Check this only when the port did not change. Because if it did, then we can already confirm at this point
+		// that the change worked and the new port is usable. This is a strong enough indication for us to assume cloud init worked.
+		if sshSpec.PortAfterInstallImage == sshSpec.PortAfterCloudInit {
+			actResult = s.handleCloudInitNotStarted(sshClient)
+			if _, complete := actResult.(actionComplete); !complete {
+				return actResult
+			}
+		}
+	}
+
+	actResult := s.runProvisioningChecks(sshClient)
 	if _, complete := actResult.(actionComplete); !complete {
 		return actResult
 	}
 
-	// Check whether cloud init did not run successfully even though it shows "done"
-	// Check this only when the port did not change. Because if it did, then we can already confirm at this point
-	// that the change worked and the new port is usable. This is a strong enough indication for us to assume cloud init worked.
-	if s.scope.HetznerBareMetalHost.Spec.Status.SSHSpec.PortAfterInstallImage == s.scope.HetznerBareMetalHost.Spec.Status.SSHSpec.PortAfterCloudInit {
-		actResult = s.handleCloudInitNotStarted()
-		if _, complete := actResult.(actionComplete); !complete {
-			return actResult
-		}
+	actResult = s.ensureRedundantBootloader(sshClient)
+	if _, complete := actResult.(actionComplete); !complete {
+		return actResult
+	}
+
+	if actResult := s.removeProvisioningFirewallRules(); actResult != nil {
+		return actResult
 	}
 
 	s.scope.SetErrorCount(0)
@@ -1352,6 +1792,180 @@ func (s *Service) actionEnsureProvisioned() actionResult {
 	return actionComplete{}
 }
 
+// preBakedNodeReady returns whether the host has a pre-baked image, cloud init is still running and kubelet and
+// containerd are already healthy. The image contains everything the node needs, so the node has joined at this
+// point and the remaining modules of cloud init do not have to be awaited. In any other case, the status of cloud
+// init decides as usual.
+func (s *Service) preBakedNodeReady(sshClient sshclient.Client) bool {
+	host := s.scope.HetznerBareMetalHost
+	if host.Spec.Status.InstallImage == nil || !host.Spec.Status.InstallImage.PreBaked {
+		return false
+	}
+
+	out := sshClient.CloudInitStatus()
+	if out.Err != nil || !strings.Contains(trimLineBreak(out.StdOut), "status: running") {
+		return false
+	}
+	if failed := failedProvisioningChecks(sshClient, &infrav1.ProvisioningChecks{Kubelet: true, Containerd: true}); len(failed) > 0 {
+		return false
+	}
+
+	record.Event(host, "PreBakedNodeReady", "Kubelet and containerd are healthy, not waiting for cloud init to finish")
+	return true
+}
+
+// runProvisioningChecks verifies the services of the host after cloud init has finished. Failing checks are
+// retried until the timeout, counted from the start of the state ensure-provisioned, has passed.
+func (s *Service) runProvisioningChecks(sshClient sshclient.Client) actionResult {
+	host := s.scope.HetznerBareMetalHost
+	checks := host.Spec.Status.ProvisioningChecks
+	if checks == nil {
+		return actionComplete{}
+	}
+
+	failed := failedProvisioningChecks(sshClient, checks)
+	if len(failed) == 0 {
+		conditions.MarkTrue(host, infrav1.ProvisioningChecksSucceededCondition)
+		return actionComplete{}
+	}
+
+	msg := strings.Join(failed, "; ")
+	conditions.MarkFalse(
+		host,
+		infrav1.ProvisioningChecksSucceededCondition,
+		infrav1.ProvisioningChecksFailedReason,
+		clusterv1.ConditionSeverityWarning,
+		msg,
+	)
+
+	timeout := defaultProvisioningChecksTimeout
+	if checks.Timeout != nil {
+		timeout = checks.Timeout.Duration
+	}
+	if since := host.Spec.Status.ProvisioningStateChanged; since != nil && hasTimedOut(since, timeout) {
+		record.Warnf(host, "ProvisioningChecksFailed", "Provisioning checks failed after %s: %s", timeout, msg)
+		return s.recordActionFailure(infrav1.ProvisioningError, "provisioning checks failed: "+msg)
+	}
+	return actionContinue{delay: 10 * time.Second}
+}
+
+// failedProvisioningChecks runs the checks on the host and describes the ones that failed.
+func failedProvisioningChecks(sshClient sshclient.Client, checks *infrav1.ProvisioningChecks) []string {
+	var failed []string
+	for _, unit := range checks.SystemdUnits {
+		if out := sshClient.CheckSystemdUnit(unit); out.Err != nil {
+			state := trimLineBreak(out.StdOut)
+			if state == "" {
+				state = "not active"
+			}
+			failed = append(failed, fmt.Sprintf("systemd unit %s is %s", unit, state))
+		}
+	}
+	if checks.Kubelet {
+		if out := sshClient.CheckKubeletHealth(); out.Err != nil || trimLineBreak(out.StdOut) != "ok" {
+			failed = append(failed, "kubelet is not healthy")
+		}
+	}
+	if checks.Containerd {
+		if out := sshClient.CheckContainerd(); out.Err != nil {
+			failed = append(failed, "containerd does not respond")
+		}
+	}
+	return failed
+}
+
+// ensureRedundantBootloader verifies that the bootloader is installed on every disk of a software RAID1 of the
+// operating system, so that the host still boots if one of the disks fails. Missing bootloaders are installed.
+func (s *Service) ensureRedundantBootloader(sshClient sshclient.Client) actionResult {
+	host := s.scope.HetznerBareMetalHost
+	wwns := mirroredOSDisks(host)
+	if len(wwns) < 2 {
+		return actionComplete{}
+	}
+
+	missing, err := disksWithoutBootloader(sshClient, wwns)
+	if err != nil {
+		return actionError{err: err}
+	}
+
+	for _, wwn := range missing {
+		if wwn == sshclient.EFISystemPartition {
+			return s.recordBootloaderMissing("EFI system partition is not mirrored with software RAID")
+		}
+		out := sshClient.InstallBootloader(wwn)
+		if err := handleSSHError(out); err != nil {
+			return s.recordBootloaderMissing(fmt.Sprintf("failed to install bootloader on disk %s: %s", wwn,
+				strings.TrimSpace(out.StdOut+" "+err.Error())))
+		}
+		record.Eventf(host, "BootloaderInstalled", "Installed bootloader on disk %s", wwn)
+	}
+
+	if len(missing) > 0 {
+		missing, err = disksWithoutBootloader(sshClient, wwns)
+		if err != nil {
+			return actionError{err: err}
+		}
+		if len(missing) > 0 {
+			return s.recordBootloaderMissing("bootloader is missing on disks " + strings.Join(missing, ", "))
+		}
+	}
+
+	conditions.MarkTrue(host, infrav1.RedundantBootloaderCondition)
+	return actionComplete{}
+}
+
+func (s *Service) recordBootloaderMissing(msg string) actionResult {
+	host := s.scope.HetznerBareMetalHost
+	conditions.MarkFalse(
+		host,
+		infrav1.RedundantBootloaderCondition,
+		infrav1.BootloaderMissingReason,
+		clusterv1.ConditionSeverityError,
+		msg,
+	)
+	record.Warnf(host, "BootloaderMissing", "Bootloader is not redundant: %s", msg)
+	return s.recordActionFailure(infrav1.ProvisioningError, "bootloader is not redundant: "+msg)
+}
+
+// mirroredOSDisks returns the WWNs of the disks of the operating system if they are mirrored with RAID1.
+func mirroredOSDisks(host *infrav1.HetznerBareMetalHost) []string {
+	installImage := host.Spec.Status.InstallImage
+	if installImage == nil || installImage.Swraid != 1 || installImage.SwraidLevel != 1 || host.Spec.RootDeviceHints == nil {
+		return nil
+	}
+	return host.Spec.RootDeviceHints.ListOfWWN()
+}
+
+func disksWithoutBootloader(sshClient sshclient.Client, wwns []string) ([]string, error) {
+	out := sshClient.GetDisksWithoutBootloader(wwns)
+	if err := handleSSHError(out); err != nil {
+		return nil, errors.Wrap(err, "failed to check bootloader on disks")
+	}
+	return strings.Fields(out.StdOut), nil
+}
+
+// checkCloudInitAfterInstallImage checks the status of cloud init with the SSH settings after install image,
+// while the host cannot be reached with the settings after cloud init yet. The result is only valid if ok is true.
+func (s *Service) checkCloudInitAfterInstallImage() (actResult actionResult, ok bool) {
+	oldSSHClient := s.osSSHClient(s.scope.HetznerBareMetalHost.Spec.Status.SSHSpec.PortAfterInstallImage)
+	actResult, err := s.checkCloudInitStatus(oldSSHClient)
+	// If this ssh client also gives an error, then we go back to analyzing the error of the first ssh call
+	if err == nil {
+		// If cloud-init status == "done" and cloud init was successful,
+		// then we will soon reboot and be able to access the server via the new port
+		if _, complete := actResult.(actionComplete); complete {
+			// Check whether cloud init did not run successfully even though it shows "done"
+			actResult := s.handleCloudInitNotStarted(oldSSHClient)
+			if _, complete := actResult.(actionComplete); complete {
+				return actionContinue{delay: 10 * time.Second}, true
+			}
+			return actResult, true
+		}
+	}
+	_, actionerr := actResult.(actionError)
+	return actResult, !actionerr
+}
+
 func (s *Service) checkCloudInitStatus(sshClient sshclient.Client) (actionResult, error) {
 	out := sshClient.CloudInitStatus()
 	// This error is interesting for further logic and might happen because of the fact that the sshClient has the wrong port
@@ -1391,29 +2005,24 @@ func (s *Service) checkCloudInitStatus(sshClient sshclient.Client) (actionResult
 	return actionComplete{}, nil
 }
 
-func (s *Service) handleCloudInitNotStarted() actionResult {
+func (s *Service) handleCloudInitNotStarted(sshClient sshclient.Client) actionResult {
 	// Check whether cloud init really was successfully. Sigterm causes problems there.
-	oldSSHClient := s.scope.SSHClientFactory.NewClient(sshclient.Input{
-		PrivateKey: sshclient.CredentialsFromSecret(s.scope.OSSSHSecret, s.scope.HetznerBareMetalHost.Spec.Status.SSHSpec.SecretRef).PrivateKey,
-		Port:       s.scope.HetznerBareMetalHost.Spec.Status.SSHSpec.PortAfterInstallImage,
-		IP:         getIPAddress(s.scope.HetznerBareMetalHost.Spec.Status),
-	})
-	out := oldSSHClient.CheckCloudInitLogsForSigTerm()
+	out := sshClient.CheckCloudInitLogsForSigTerm()
 	if err := handleSSHError(out); err != nil {
 		return actionError{err: errors.Wrap(err, "failed to CheckCloudInitLogsForSigTerm")}
 	}
 
 	if trimLineBreak(out.StdOut) != "" {
 		// it was not succesfull. Prepare and reboot again
-		out = oldSSHClient.CleanCloudInitLogs()
+		out = sshClient.CleanCloudInitLogs()
 		if err := handleSSHError(out); err != nil {
 			return actionError{err: errors.Wrap(err, "failed to CleanCloudInitLogs")}
 		}
-		out = oldSSHClient.CleanCloudInitInstances()
+		out = sshClient.CleanCloudInitInstances()
 		if err := handleSSHError(out); err != nil {
 			return actionError{err: errors.Wrap(err, "failed to CleanCloudInitInstances")}
 		}
-		out = oldSSHClient.Reboot()
+		out = sshClient.Reboot()
 		if err := handleSSHError(out); err != nil {
 			return actionError{err: errors.Wrap(err, "failed to reboot")}
 		}
@@ -1463,13 +2072,7 @@ func handleIncompleteBootProvisioned(out sshclient.Output) (isTimeout bool, isCo
 func (s *Service) actionProvisioned() actionResult {
 	rebootDesired := hasRebootAnnotation(*s.scope.HetznerBareMetalHost)
 	isRebooted := s.scope.HetznerBareMetalHost.Spec.Status.Rebooted
-	creds := sshclient.CredentialsFromSecret(s.scope.OSSSHSecret, s.scope.HetznerBareMetalHost.Spec.Status.SSHSpec.SecretRef)
-	in := sshclient.Input{
-		PrivateKey: creds.PrivateKey,
-		Port:       s.scope.HetznerBareMetalHost.Spec.Status.SSHSpec.PortAfterCloudInit,
-		IP:         getIPAddress(s.scope.HetznerBareMetalHost.Spec.Status),
-	}
-	sshClient := s.scope.SSHClientFactory.NewClient(in)
+	sshClient := s.osSSHClientAfterCloudInit()
 
 	if rebootDesired {
 		if isRebooted {
@@ -1503,33 +2106,237 @@ func (s *Service) actionProvisioned() actionResult {
 		return actionContinue{delay: 10 * time.Second}
 	}
 
+	return s.reconcileConsoleUser(sshClient)
+}
+
+// Operating states of servers as reported by Robot.
+const (
+	robotOperatingStatusRunning = "running"
+	robotOperatingStatusStopped = "stopped"
+)
+
+// fence powers off the host once and keeps it off as long as it has the fence annotation. The condition
+// HostFenced is only true once Robot confirms that the server is off. If the server still runs after the
+// fencingTimeout, the host gets an error and has to be powered off manually.
+func (s *Service) fence() actionResult {
+	host := s.scope.HetznerBareMetalHost
+	if conditions.IsTrue(host, infrav1.HostFencedCondition) {
+		return actionComplete{}
+	}
+
+	reset, err := s.scope.RobotClient.GetReboot(host.Spec.ServerID)
+	if err != nil {
+		if models.IsError(err, models.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(host, infrav1.RateLimitExceeded)
+			record.Event(host,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling robot function GetReboot",
+			)
+			return actionError{err: errors.Wrap(err, "failed to get reboot"), class: infrav1.FailureClassRateLimited}
+		}
+		return actionError{err: errors.Wrap(err, "failed to get reboot")}
+	}
+
+	switch reset.OperatingStatus {
+	case robotOperatingStatusStopped:
+		conditions.MarkTrue(host, infrav1.HostFencedCondition)
+		if host.Spec.Status.ErrorType == infrav1.FencingError {
+			clearError(host)
+		}
+		record.Eventf(host, "HostFenced", "Robot confirmed that server %d is powered off", host.Spec.ServerID)
+		return actionComplete{}
+	case robotOperatingStatusRunning:
+		// The power button toggles the power, so it is pressed only once and only while the server is running
+		switch conditions.GetReason(host, infrav1.HostFencedCondition) {
+		case infrav1.FencingInProgressReason:
+			if requested := conditions.GetLastTransitionTime(host, infrav1.HostFencedCondition); requested != nil &&
+				time.Since(requested.Time) > fencingTimeout {
+				return s.fencingFailed()
+			}
+			return actionContinue{delay: 10 * time.Second}
+		case infrav1.FencingFailedReason:
+			return actionContinue{delay: time.Minute}
+		}
+		return s.pressPowerButton()
+	default:
+		conditions.MarkFalse(
+			host,
+			infrav1.HostFencedCondition,
+			infrav1.FencingNotSupportedReason,
+			clusterv1.ConditionSeverityError,
+			"Robot does not report the power state of the server (%q), the power button is not pressed as the power-off cannot be confirmed",
+			reset.OperatingStatus,
+		)
+		return actionContinue{delay: time.Minute}
+	}
+}
+
+// pressPowerButton powers off the running server of the host, with a long press if the server supports it.
+func (s *Service) pressPowerButton() actionResult {
+	host := s.scope.HetznerBareMetalHost
+	rebootType := infrav1.RebootTypePower
+	if host.HasPowerLongReboot() {
+		rebootType = infrav1.RebootTypePowerLong
+	}
+	if _, err := s.scope.RobotClient.RebootBMServer(host.Spec.ServerID, rebootType); err != nil {
+		if models.IsError(err, models.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(host, infrav1.RateLimitExceeded)
+			record.Event(host,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling robot function RebootBMServer",
+			)
+			return actionError{err: errors.Wrap(err, "failed to power off bare metal server"), class: infrav1.FailureClassRateLimited}
+		}
+		return actionError{err: errors.Wrap(err, "failed to power off bare metal server")}
+	}
+	// The condition is set anew, so that its transition time is the time of the request
+	conditions.Delete(host, infrav1.HostFencedCondition)
+	conditions.MarkFalse(
+		host,
+		infrav1.HostFencedCondition,
+		infrav1.FencingInProgressReason,
+		clusterv1.ConditionSeverityWarning,
+		"requested power-off with reset type %s",
+		rebootType,
+	)
+	record.Eventf(host, "FencingStarted", "Requested power-off of server %d with reset type %s", host.Spec.ServerID, rebootType)
+	return actionContinue{delay: 10 * time.Second}
+}
+
+// fencingFailed gives the host an error, as its server is still running after the power-off has been requested.
+// The power button is not pressed again, as it might power on the server if it is stopped in the meantime. The
+// host stays fenced until Robot reports that the server is off, e.g. after it has been powered off manually.
+func (s *Service) fencingFailed() actionResult {
+	host := s.scope.HetznerBareMetalHost
+	msg := fmt.Sprintf("server %d is still running %s after its power-off has been requested, it has to be powered off manually",
+		host.Spec.ServerID, fencingTimeout)
+	conditions.MarkFalse(
+		host,
+		infrav1.HostFencedCondition,
+		infrav1.FencingFailedReason,
+		clusterv1.ConditionSeverityError,
+		msg,
+	)
+	record.Warnf(host, "FencingFailed", "Failed to fence host: %s", msg)
+	SetErrorMessage(host, infrav1.FencingError, msg)
+	return actionContinue{delay: time.Minute}
+}
+
+// createUserData writes the user data together with the resolver and swap configuration and the node profile of
+// the host and the trusted CA bundle, console user and cloud-init parts of the cluster. It returns the rendered
+// user data.
+func (s *Service) createUserData(ctx context.Context, sshClient sshclient.Client, userData []byte) ([]byte, error) {
+	userData, err := userdata.AddResolverConfig(userData, s.scope.HetznerBareMetalHost.Spec.Status.DNS)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to add resolver config to user data")
+	}
+	caBundle, err := s.scope.TrustedCABundle(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get trusted CA bundle")
+	}
+	userData, err = userdata.AddCABundle(userData, caBundle)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to add trusted CA bundle to user data")
+	}
+	consoleUser, err := s.scope.ConsoleUser(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get console user")
+	}
+	if consoleUser != nil {
+		userData, err = userdata.AddConsoleUser(userData, consoleUser.Name, consoleUser.PasswordHash)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to add console user to user data")
+		}
+	}
+	userData, err = userdata.AddNodeProfile(userData, s.scope.HetznerBareMetalHost.Spec.Status.NodeProfile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to add node profile to user data")
+	}
+	cloudInitParts, err := s.scope.CloudInitParts(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get cloud-init parts")
+	}
+	userData, err = userdata.AddCloudInitParts(userData, cloudInitParts)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to add cloud-init parts to user data")
+	}
+	if installImage := s.scope.HetznerBareMetalHost.Spec.Status.InstallImage; installImage != nil {
+		userData, err = userdata.AddSwapConfig(userData, installImage.Swap)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to add swap config to user data")
+		}
+		userData, err = userdata.AddPreBakedImageConfig(userData, installImage.PreBaked)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to add pre-baked image config to user data")
+		}
+	}
+	if err := handleSSHError(sshClient.CreateUserData(string(userData))); err != nil {
+		return nil, err
+	}
+	s.setAppliedConsoleUser(consoleUser.Applied())
+	return userData, nil
+}
+
+// setAppliedUserData stores the hash of the bootstrap data to detect changes of it and the hash of the user data
+// that has been rendered from it and written to the host.
+func (s *Service) setAppliedUserData(userData, renderedUserData []byte) {
+	s.scope.HetznerBareMetalHost.Spec.Status.UserDataHash = utils.SHA256Hash(userData)
+
+	if s.scope.HetznerBareMetalHost.Spec.Status.AppliedConfiguration == nil {
+		s.scope.HetznerBareMetalHost.Spec.Status.AppliedConfiguration = &infrav1.AppliedConfiguration{}
+	}
+	now := metav1.Now()
+	s.scope.HetznerBareMetalHost.Spec.Status.AppliedConfiguration.UserDataHash = utils.SHA256Hash(renderedUserData)
+	s.scope.HetznerBareMetalHost.Spec.Status.AppliedConfiguration.AppliedAt = &now
+}
+
+// actionRerunUserData writes the given user data to the installed operating system
+// and triggers cloud init to run again by removing its state and rebooting.
+func (s *Service) actionRerunUserData(ctx context.Context, userData []byte) actionResult {
+	sshClient := s.osSSHClientAfterCloudInit()
+
+	renderedUserData, err := s.createUserData(ctx, sshClient, userData)
+	if err != nil {
+		return actionError{err: errors.Wrap(err, "failed to create user data")}
+	}
+	out := sshClient.CleanCloudInitLogs()
+	if err := handleSSHError(out); err != nil {
+		return actionError{err: errors.Wrap(err, "failed to CleanCloudInitLogs")}
+	}
+	out = sshClient.CleanCloudInitInstances()
+	if err := handleSSHError(out); err != nil {
+		return actionError{err: errors.Wrap(err, "failed to CleanCloudInitInstances")}
+	}
+	out = sshClient.Reboot()
+	if err := handleSSHError(out); err != nil {
+		return actionError{err: errors.Wrap(err, "failed to reboot")}
+	}
+
+	s.setAppliedUserData(userData, renderedUserData)
 	return actionComplete{}
 }
 
 func (s *Service) actionDeprovisioning() actionResult {
-	// Update name in robot API
-	if _, err := s.scope.RobotClient.SetBMServerName(
-		s.scope.HetznerBareMetalHost.Spec.ServerID,
-		s.scope.HetznerBareMetalHost.Spec.ConsumerRef.Name,
-	); err != nil {
+	// Update name in robot API. A host that is deprovisioned after its quarantine has no consumer anymore.
+	name := s.scope.HetznerBareMetalHost.Name
+	if consumerRef := s.scope.HetznerBareMetalHost.Spec.ConsumerRef; consumerRef != nil {
+		name = consumerRef.Name
+	}
+	if _, err := s.scope.RobotClient.SetBMServerName(s.scope.HetznerBareMetalHost.Spec.ServerID, name); err != nil {
 		if models.IsError(err, models.ErrorCodeRateLimitExceeded) {
 			conditions.MarkTrue(s.scope.HetznerBareMetalHost, infrav1.RateLimitExceeded)
 			record.Event(s.scope.HetznerBareMetalHost,
 				"RateLimitExceeded",
 				"exceeded rate limit with calling robot function SetBMServerName",
 			)
-			return actionContinue{}
+			return actionError{err: fmt.Errorf("failed to update name of host in robot API: %w", err), class: infrav1.FailureClassRateLimited}
 		}
 		return actionError{err: fmt.Errorf("failed to update name of host in robot API: %w", err)}
 	}
 
 	// If has been provisioned completely, stop all running pods
 	if s.scope.OSSSHSecret != nil {
-		sshClient := s.scope.SSHClientFactory.NewClient(sshclient.Input{
-			PrivateKey: sshclient.CredentialsFromSecret(s.scope.OSSSHSecret, s.scope.HetznerBareMetalHost.Spec.Status.SSHSpec.SecretRef).PrivateKey,
-			Port:       s.scope.HetznerBareMetalHost.Spec.Status.SSHSpec.PortAfterCloudInit,
-			IP:         getIPAddress(s.scope.HetznerBareMetalHost.Spec.Status),
-		})
+		sshClient := s.osSSHClientAfterCloudInit()
 		out := sshClient.ResetKubeadm()
 		if err := handleSSHError(out); err != nil {
 			s.scope.Info("Error while reseting kubeadm", "err", err)
@@ -1537,12 +2344,41 @@ func (s *Service) actionDeprovisioning() actionResult {
 	} else {
 		s.scope.Info("OS SSH Secret is empty - cannot reset kubeadm")
 	}
+
+	if actResult := s.removeProvisioningFirewallRules(); actResult != nil {
+		return actResult
+	}
+
+	conditions.Delete(s.scope.HetznerBareMetalHost, infrav1.ProvisioningSlotAvailableCondition)
+	clearPendingReprovision(s.scope.HetznerBareMetalHost)
+	clearProvisioningStatus(s.scope.HetznerBareMetalHost)
 	s.scope.SetErrorCount(0)
 	clearError(s.scope.HetznerBareMetalHost)
 
 	return actionComplete{}
 }
 
+// clearProvisioningStatus removes the status of the provisioning when the host is released by its machine.
+func clearProvisioningStatus(host *infrav1.HetznerBareMetalHost) {
+	host.Spec.Status.PostInstallStep = nil
+	host.Spec.Status.UserDataHash = ""
+	host.Spec.Status.AppliedConfiguration = nil
+	host.Spec.Status.SSHStatus = infrav1.SSHStatus{}
+}
+
+// actionQuarantine leaves the host as it is, so that its disks can be investigated. Nothing is executed on the
+// host, not even kubeadm reset, and its name in Robot is kept.
+func (s *Service) actionQuarantine() actionResult {
+	host := s.scope.HetznerBareMetalHost
+	conditions.Delete(host, infrav1.ProvisioningSlotAvailableCondition)
+	clearProvisioningStatus(host)
+	s.scope.SetErrorCount(0)
+	clearError(host)
+	record.Warnf(host, "HostQuarantined", "Host is quarantined instead of deprovisioned because of the forensic hold: %s",
+		host.Annotations[infrav1.ForensicHoldAnnotation])
+	return actionComplete{}
+}
+
 func (s *Service) actionDeleting() actionResult {
 	s.scope.Info("Marked to be deleted", "timestamp", s.scope.HetznerBareMetalHost.DeletionTimestamp)
 
@@ -1551,9 +2387,10 @@ func (s *Service) actionDeleting() actionResult {
 		return deleteComplete{}
 	}
 
+	patch := client.MergeFrom(s.scope.HetznerBareMetalHost.DeepCopy())
 	s.scope.HetznerBareMetalHost.Finalizers = utils.FilterStringFromList(s.scope.HetznerBareMetalHost.Finalizers, infrav1.BareMetalHostFinalizer)
-	if err := s.scope.Client.Update(context.Background(), s.scope.HetznerBareMetalHost); err != nil {
-		return actionError{errors.Wrap(err, "failed to remove finalizer")}
+	if err := s.scope.Client.Patch(context.Background(), s.scope.HetznerBareMetalHost, patch, client.FieldOwner(HostControllerFieldOwner)); err != nil {
+		return actionError{err: errors.Wrap(err, "failed to remove finalizer")}
 	}
 
 	s.scope.Info("Cleanup complete. Removed finalizer", "remaining", s.scope.HetznerBareMetalHost.Finalizers)
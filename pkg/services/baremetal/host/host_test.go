@@ -17,21 +17,37 @@ limitations under the License.
 package host
 
 import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
 	"time"
 
+	"github.com/hetznercloud/hcloud-go/hcloud"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/mock"
 	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	secretutil "github.com/syself/cluster-api-provider-hetzner/pkg/secrets"
 	bmmock "github.com/syself/cluster-api-provider-hetzner/pkg/services/baremetal/client/mocks"
 	robotmock "github.com/syself/cluster-api-provider-hetzner/pkg/services/baremetal/client/mocks/robot"
 	sshmock "github.com/syself/cluster-api-provider-hetzner/pkg/services/baremetal/client/mocks/ssh"
+	robotclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/baremetal/client/robot"
 	sshclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/baremetal/client/ssh"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/utils"
 	"github.com/syself/cluster-api-provider-hetzner/test/helpers"
 	"github.com/syself/hrobot-go/models"
+	"golang.org/x/crypto/ssh"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 var _ = Describe("SetErrorMessage", func() {
@@ -559,7 +575,7 @@ var _ = Describe("ensureSSHKey", func() {
 			sshKey, actResult := service.ensureSSHKey(infrav1.SSHSecretRef{
 				Name: "secret-name",
 				Key:  sshSecretKeyRef,
-			}, secret)
+			}, secret, infrav1.PreparationError)
 
 			Expect(sshKey.Fingerprint).To(Equal(expectedFingerprint))
 			Expect(actResult).Should(BeAssignableToTypeOf(expectedActionResult))
@@ -621,6 +637,106 @@ var _ = Describe("ensureSSHKey", func() {
 	)
 })
 
+var _ = Describe("ensureSSHKey - fingerprint verification", func() {
+	var (
+		secret      *corev1.Secret
+		fingerprint string
+		secretRef   infrav1.SSHSecretRef
+	)
+
+	BeforeEach(func() {
+		publicKey, _, err := ed25519.GenerateKey(rand.Reader)
+		Expect(err).To(BeNil())
+		sshPublicKey, err := ssh.NewPublicKey(publicKey)
+		Expect(err).To(BeNil())
+		fingerprint = ssh.FingerprintLegacyMD5(sshPublicKey)
+
+		secret = helpers.GetDefaultSSHSecret("ssh-secret", "default")
+		secret.Data["public-key"] = ssh.MarshalAuthorizedKey(sshPublicKey)
+		secretRef = infrav1.SSHSecretRef{
+			Name: "ssh-secret",
+			Key: infrav1.SSHSecretKeyRef{
+				Name:       "sshkey-name",
+				PublicKey:  "public-key",
+				PrivateKey: "private-key",
+			},
+		}
+	})
+
+	It("fails if the key with the name of the secret has another fingerprint", func() {
+		robotMock := robotmock.Client{}
+		robotMock.On("ListSSHKeys").Return([]models.Key{{Name: "my-name", Fingerprint: "stale-fingerprint"}}, nil)
+
+		host := helpers.BareMetalHost("test-host", "default")
+		service := newTestService(host, &robotMock, nil, nil, nil)
+
+		_, actResult := service.ensureSSHKey(secretRef, secret, infrav1.PreparationError)
+		Expect(actResult).Should(BeAssignableToTypeOf(actionFailed{}))
+		Expect(host.Spec.Status.ErrorType).To(Equal(infrav1.PreparationError))
+		Expect(host.Spec.Status.ErrorMessage).To(ContainSubstring("stale-fingerprint"))
+		Expect(host.Spec.Status.ErrorMessage).To(ContainSubstring(fingerprint))
+		robotMock.AssertNotCalled(GinkgoT(), "SetSSHKey", mock.Anything, mock.Anything)
+	})
+
+	It("uses the key with the name of the secret if the fingerprint matches", func() {
+		robotMock := robotmock.Client{}
+		robotMock.On("ListSSHKeys").Return([]models.Key{{Name: "my-name", Fingerprint: fingerprint}}, nil)
+
+		host := helpers.BareMetalHost("test-host", "default")
+		service := newTestService(host, &robotMock, nil, nil, nil)
+
+		sshKey, actResult := service.ensureSSHKey(secretRef, secret, infrav1.PreparationError)
+		Expect(actResult).Should(BeAssignableToTypeOf(actionComplete{}))
+		Expect(sshKey).To(Equal(infrav1.SSHKey{Name: "my-name", Fingerprint: fingerprint}))
+	})
+
+	It("uses a key with another name if it has the fingerprint of the secret", func() {
+		robotMock := robotmock.Client{}
+		robotMock.On("ListSSHKeys").Return([]models.Key{{Name: "other-name", Fingerprint: fingerprint}}, nil)
+
+		host := helpers.BareMetalHost("test-host", "default")
+		service := newTestService(host, &robotMock, nil, nil, nil)
+
+		sshKey, actResult := service.ensureSSHKey(secretRef, secret, infrav1.PreparationError)
+		Expect(actResult).Should(BeAssignableToTypeOf(actionComplete{}))
+		Expect(sshKey).To(Equal(infrav1.SSHKey{Name: "other-name", Fingerprint: fingerprint}))
+		robotMock.AssertNotCalled(GinkgoT(), "SetSSHKey", mock.Anything, mock.Anything)
+	})
+})
+
+var _ = Describe("verifyRescueKey", func() {
+	DescribeTable("verifyRescueKey",
+		func(rescue *models.Rescue, expectedActionResult actionResult) {
+			host := helpers.BareMetalHost("test-host", "default")
+			host.Spec.Status.SSHStatus.RescueKey = &infrav1.SSHKey{Name: "my-name", Fingerprint: "my-fingerprint"}
+			service := newTestService(host, nil, nil, nil, nil)
+
+			actResult := service.verifyRescueKey(rescue)
+			if expectedActionResult == nil {
+				Expect(actResult).To(BeNil())
+			} else {
+				Expect(actResult).Should(BeAssignableToTypeOf(expectedActionResult))
+				Expect(host.Spec.Status.ErrorType).To(Equal(infrav1.PreparationError))
+			}
+		},
+		Entry("no rescue", nil, nil),
+		Entry("no authorized keys", &models.Rescue{Active: true}, nil),
+		Entry("rescue key authorized",
+			&models.Rescue{Active: true, AuthorizedKey: []models.AuthorizedKey{
+				{Key: models.Key{Name: "other-name", Fingerprint: "other-fingerprint"}},
+				{Key: models.Key{Name: "my-name", Fingerprint: "my-fingerprint"}},
+			}},
+			nil,
+		),
+		Entry("rescue key not authorized",
+			&models.Rescue{Active: true, AuthorizedKey: []models.AuthorizedKey{
+				{Key: models.Key{Name: "other-name", Fingerprint: "other-fingerprint"}},
+			}},
+			actionFailed{},
+		),
+	)
+})
+
 var _ = Describe("handleIncompleteBootInstallImage", func() {
 	DescribeTable("handleIncompleteBootInstallImage - out.Err",
 		func(
@@ -1173,6 +1289,121 @@ var _ = Describe("actionRegistering", func() {
 			infrav1.ErrorTypeConnectionError,                      // expectedErrorType string
 		),
 	)
+
+	It("resumes the hardware inspection after a failed probe", func() {
+		host := helpers.BareMetalHost(
+			"test-host",
+			"default",
+			helpers.WithRootDeviceHintWWN(),
+			helpers.WithIPv4(),
+			helpers.WithConsumerRef(),
+		)
+
+		sshMock := &sshmock.Client{}
+		sshMock.On("GetHostName").Return(sshclient.Output{StdOut: "rescue"})
+		sshMock.On("GetHardwareDetailsRAM").Return(sshclient.Output{StdOut: "65536000"}).Once()
+		sshMock.On("GetHardwareDetailsNics").Return(sshclient.Output{
+			StdOut: `name="eth0" model="Realtek Semiconductor Co., Ltd. RTL8111/8168/8411 PCI Express Gigabit Ethernet Controller (rev 15)" mac="a8:a1:59:94:19:42" ipv4="23.88.6.239/26" speedMbps="1000"`,
+		}).Once()
+		sshMock.On("GetHardwareDetailsStorage").Return(sshclient.Output{StdErr: "lsblk failed"}).Once()
+		sshMock.On("GetHardwareDetailsStorage").Return(sshclient.Output{
+			StdOut: `NAME="nvme2n1" LABEL="" FSTYPE="" TYPE="disk" HCTL="" MODEL="SAMSUNG MZVL22T0HBLB-00B00" VENDOR="" SERIAL="S677NF0R402742" SIZE="2048408248320" WWN="eui.002538b411b2cee8" ROTA="0"`,
+		}).Once()
+		sshMock.On("GetHardwareDetailsCPUArch").Return(sshclient.Output{StdOut: "myarch"})
+		sshMock.On("GetHardwareDetailsCPUModel").Return(sshclient.Output{StdOut: "mymodel"})
+		sshMock.On("GetHardwareDetailsCPUClockGigahertz").Return(sshclient.Output{StdOut: "42654"})
+		sshMock.On("GetHardwareDetailsCPUFlags").Return(sshclient.Output{StdOut: "flag1 flag2 flag3"})
+		sshMock.On("GetHardwareDetailsCPUThreads").Return(sshclient.Output{StdOut: "123"})
+		sshMock.On("GetHardwareDetailsCPUCores").Return(sshclient.Output{StdOut: "12"})
+
+		service := newTestService(host, nil, bmmock.NewSSHFactory(sshMock, sshMock, sshMock), nil, helpers.GetDefaultSSHSecret(rescueSSHKeyName, "default"))
+
+		Expect(service.actionRegistering()).Should(BeAssignableToTypeOf(actionError{}))
+		Expect(host.Spec.Status.HardwareDetails).To(BeNil())
+		Expect(host.Spec.Status.HardwareInspection).ToNot(BeNil())
+		Expect(host.Spec.Status.HardwareInspection.RAMGB).To(Equal(pointer.Int(64)))
+		Expect(host.Spec.Status.HardwareInspection.NIC).To(HaveLen(1))
+		Expect(host.Spec.Status.HardwareInspection.Storage).To(BeNil())
+
+		Expect(service.actionRegistering()).Should(BeAssignableToTypeOf(actionComplete{}))
+		Expect(host.Spec.Status.HardwareInspection).To(BeNil())
+		Expect(host.Spec.Status.HardwareDetails).ToNot(BeNil())
+		Expect(host.Spec.Status.HardwareDetails.RAMGB).To(Equal(64))
+		Expect(host.Spec.Status.HardwareDetails.Storage).To(HaveLen(1))
+		sshMock.AssertNumberOfCalls(GinkgoT(), "GetHardwareDetailsRAM", 1)
+		sshMock.AssertNumberOfCalls(GinkgoT(), "GetHardwareDetailsNics", 1)
+	})
+
+	It("inspects the hardware again after it has been replaced", func() {
+		host := helpers.BareMetalHost(
+			"test-host",
+			"default",
+			helpers.WithRootDeviceHintWWN(),
+			helpers.WithIPv4(),
+			helpers.WithConsumerRef(),
+		)
+		host.Spec.Status.HardwareDetails = &infrav1.HardwareDetails{
+			RAMGB:   32,
+			NIC:     []infrav1.NIC{{Name: "eth0", MAC: "a8:a1:59:94:19:01"}},
+			Storage: []infrav1.Storage{{Name: "nvme2n1", WWN: "eui.002538b411b2cee8"}},
+		}
+
+		sshMock := &sshmock.Client{}
+		sshMock.On("GetHostName").Return(sshclient.Output{StdOut: "rescue"})
+		sshMock.On("GetHardwareDetailsRAM").Return(sshclient.Output{StdOut: "65536000"})
+		sshMock.On("GetHardwareDetailsNics").Return(sshclient.Output{
+			StdOut: `name="eth0" model="Realtek Semiconductor Co., Ltd. RTL8111/8168/8411 PCI Express Gigabit Ethernet Controller (rev 15)" mac="a8:a1:59:94:19:42" ipv4="23.88.6.239/26" speedMbps="1000"`,
+		})
+		sshMock.On("GetHardwareDetailsStorage").Return(sshclient.Output{
+			StdOut: `NAME="nvme2n1" LABEL="" FSTYPE="" TYPE="disk" HCTL="" MODEL="SAMSUNG MZVL22T0HBLB-00B00" VENDOR="" SERIAL="S677NF0R402742" SIZE="2048408248320" WWN="eui.002538b411b2cee8" ROTA="0"`,
+		})
+		sshMock.On("GetHardwareDetailsCPUArch").Return(sshclient.Output{StdOut: "myarch"})
+		sshMock.On("GetHardwareDetailsCPUModel").Return(sshclient.Output{StdOut: "mymodel"})
+		sshMock.On("GetHardwareDetailsCPUClockGigahertz").Return(sshclient.Output{StdOut: "42654"})
+		sshMock.On("GetHardwareDetailsCPUFlags").Return(sshclient.Output{StdOut: "flag1 flag2 flag3"})
+		sshMock.On("GetHardwareDetailsCPUThreads").Return(sshclient.Output{StdOut: "123"})
+		sshMock.On("GetHardwareDetailsCPUCores").Return(sshclient.Output{StdOut: "12"})
+
+		service := newTestService(host, nil, bmmock.NewSSHFactory(sshMock, sshMock, sshMock), nil, helpers.GetDefaultSSHSecret(rescueSSHKeyName, "default"))
+
+		Expect(service.actionRegistering()).Should(BeAssignableToTypeOf(actionComplete{}))
+		Expect(host.Spec.Status.HardwareDetails.RAMGB).To(Equal(64))
+		Expect(host.Spec.Status.HardwareDetails.NIC).To(HaveLen(1))
+		Expect(host.Spec.Status.HardwareDetails.NIC[0].MAC).To(Equal("a8:a1:59:94:19:42"))
+		Expect(host.Spec.Status.HardwareInspection).To(BeNil())
+		sshMock.AssertNumberOfCalls(GinkgoT(), "GetHardwareDetailsNics", 1)
+		sshMock.AssertNumberOfCalls(GinkgoT(), "GetHardwareDetailsStorage", 1)
+	})
+
+	It("keeps the hardware details if the hardware has not changed", func() {
+		host := helpers.BareMetalHost(
+			"test-host",
+			"default",
+			helpers.WithRootDeviceHintWWN(),
+			helpers.WithIPv4(),
+			helpers.WithConsumerRef(),
+		)
+		host.Spec.Status.HardwareDetails = &infrav1.HardwareDetails{
+			RAMGB:   32,
+			NIC:     []infrav1.NIC{{Name: "eth0", MAC: "a8:a1:59:94:19:42"}},
+			Storage: []infrav1.Storage{{Name: "nvme2n1", WWN: "eui.002538b411b2cee8"}},
+		}
+
+		sshMock := &sshmock.Client{}
+		sshMock.On("GetHostName").Return(sshclient.Output{StdOut: "rescue"})
+		sshMock.On("GetHardwareDetailsNics").Return(sshclient.Output{
+			StdOut: `name="eth0" model="Realtek Semiconductor Co., Ltd. RTL8111/8168/8411 PCI Express Gigabit Ethernet Controller (rev 15)" mac="a8:a1:59:94:19:42" ipv4="23.88.6.239/26" speedMbps="1000"`,
+		})
+		sshMock.On("GetHardwareDetailsStorage").Return(sshclient.Output{
+			StdOut: `NAME="nvme2n1" LABEL="" FSTYPE="" TYPE="disk" HCTL="" MODEL="SAMSUNG MZVL22T0HBLB-00B00" VENDOR="" SERIAL="S677NF0R402742" SIZE="2048408248320" WWN="eui.002538b411b2cee8" ROTA="0"`,
+		})
+
+		service := newTestService(host, nil, bmmock.NewSSHFactory(sshMock, sshMock, sshMock), nil, helpers.GetDefaultSSHSecret(rescueSSHKeyName, "default"))
+
+		Expect(service.actionRegistering()).Should(BeAssignableToTypeOf(actionComplete{}))
+		Expect(host.Spec.Status.HardwareDetails.RAMGB).To(Equal(32))
+		sshMock.AssertNotCalled(GinkgoT(), "GetHardwareDetailsRAM")
+	})
 })
 
 var _ = Describe("getImageDetails", func() {
@@ -1245,6 +1476,77 @@ var _ = Describe("getImageDetails", func() {
 	)
 })
 
+var _ = Describe("actionPostInstalling", func() {
+	var host *infrav1.HetznerBareMetalHost
+	var service *Service
+
+	newSSHMock := func(bootID string, scriptOut sshclient.Output) *sshmock.Client {
+		sshMock := &sshmock.Client{}
+		sshMock.On("GetHostName").Return(sshclient.Output{StdOut: infrav1.BareMetalHostNamePrefix + "bm-machine"})
+		sshMock.On("GetBootID").Return(sshclient.Output{StdOut: bootID + "\n"})
+		sshMock.On("RunScript", mock.Anything).Return(scriptOut)
+		sshMock.On("Reboot").Return(sshclient.Output{})
+		service.scope.SSHClientFactory = bmmock.NewSSHFactory(sshMock, sshMock, sshMock)
+		return sshMock
+	}
+
+	BeforeEach(func() {
+		host = helpers.BareMetalHost(
+			"test-host",
+			"default",
+			helpers.WithSSHSpecInclPorts(23, 24),
+			helpers.WithIPv4(),
+			helpers.WithConsumerRef(),
+		)
+		host.Spec.Status.InstallImage = &infrav1.InstallImage{PostInstallSteps: []infrav1.PostInstallStep{
+			{Name: "kernel", Script: "apt-get install -y linux-image-6.1", RebootRequired: true, VerifyScript: "uname -r | grep ^6.1"},
+			{Name: "sysctl", Script: "sysctl --system"},
+		}}
+		service = newTestService(host, nil, nil, helpers.GetDefaultSSHSecret(osSSHKeyName, "default"), helpers.GetDefaultSSHSecret(rescueSSHKeyName, "default"))
+	})
+
+	It("applies, reboots and verifies the steps in order", func() {
+		sshMock := newSSHMock("boot-1", sshclient.Output{})
+		Expect(service.actionPostInstalling()).To(BeAssignableToTypeOf(actionContinue{}))
+		Expect(host.Spec.Status.PostInstallStep).To(Equal(&infrav1.PostInstallStepStatus{
+			Name: "kernel", Phase: infrav1.PostInstallPhaseRebooting, BootID: "boot-1",
+		}))
+		sshMock.AssertCalled(GinkgoT(), "RunScript", "apt-get install -y linux-image-6.1")
+		sshMock.AssertCalled(GinkgoT(), "Reboot")
+
+		// the host has not been rebooted yet
+		Expect(service.actionPostInstalling()).To(BeAssignableToTypeOf(actionContinue{}))
+		Expect(host.Spec.Status.PostInstallStep.Phase).To(Equal(infrav1.PostInstallPhaseRebooting))
+		Expect(host.Spec.Status.ErrorType).To(Equal(infrav1.ErrorTypeSSHRebootTooSlow))
+
+		sshMock = newSSHMock("boot-2", sshclient.Output{})
+		Expect(service.actionPostInstalling()).To(BeAssignableToTypeOf(actionContinue{}))
+		Expect(host.Spec.Status.PostInstallStep.Phase).To(Equal(infrav1.PostInstallPhaseVerifying))
+		Expect(host.Spec.Status.ErrorType).To(BeEmpty())
+
+		Expect(service.actionPostInstalling()).To(BeAssignableToTypeOf(actionContinue{}))
+		sshMock.AssertCalled(GinkgoT(), "RunScript", "uname -r | grep ^6.1")
+		Expect(host.Spec.Status.PostInstallStep).To(Equal(&infrav1.PostInstallStepStatus{
+			Index: 1, Name: "sysctl", Phase: infrav1.PostInstallPhaseApplying,
+		}))
+
+		Expect(service.actionPostInstalling()).To(BeAssignableToTypeOf(actionContinue{}))
+		sshMock.AssertCalled(GinkgoT(), "RunScript", "sysctl --system")
+		Expect(service.actionPostInstalling()).To(BeAssignableToTypeOf(actionComplete{}))
+		Expect(host.Spec.Status.PostInstallStep).To(BeNil())
+		sshMock.AssertNumberOfCalls(GinkgoT(), "Reboot", 0)
+	})
+
+	It("fails if the script of a step fails", func() {
+		newSSHMock("boot-1", sshclient.Output{StdOut: "E: Unable to locate package\n", Err: errors.New("Process exited with status 100")})
+		Expect(service.actionPostInstalling()).To(BeAssignableToTypeOf(actionFailed{}))
+		Expect(host.Spec.Status.ErrorType).To(Equal(infrav1.ProvisioningError))
+		Expect(host.Spec.Status.ErrorMessage).To(Equal(
+			"post-install step kernel failed: Process exited with status 100: E: Unable to locate package",
+		))
+	})
+})
+
 var _ = Describe("actionEnsureProvisioned", func() {
 	type ensureProvisionedInputs struct {
 		outSSHClientGetHostName                sshclient.Output
@@ -1709,3 +2011,1175 @@ var _ = Describe("actionProvisioned", func() {
 		),
 	)
 })
+
+type recordingSSHFactory struct {
+	inputs []sshclient.Input
+}
+
+func (f *recordingSSHFactory) NewClient(in sshclient.Input) sshclient.Client {
+	f.inputs = append(f.inputs, in)
+	return &sshmock.Client{}
+}
+
+var _ = Describe("osSSHClient", func() {
+	It("connects to the public IP of the host", func() {
+		host := helpers.BareMetalHost(
+			"test-host",
+			"default",
+			helpers.WithSSHSpecInclPorts(23, 24),
+			helpers.WithIPv4(),
+		)
+		factory := &recordingSSHFactory{}
+		service := newTestService(host, nil, factory, helpers.GetDefaultSSHSecret(osSSHKeyName, "default"), nil)
+
+		service.osSSHClient(24)
+		Expect(factory.inputs).To(HaveLen(1))
+		Expect(factory.inputs[0].IP).To(Equal("1.2.3.4"))
+		Expect(factory.inputs[0].Port).To(Equal(24))
+		Expect(factory.inputs[0].Bastion).To(BeNil())
+	})
+
+	It("connects to the private IP of the host through the bastion", func() {
+		host := helpers.BareMetalHost(
+			"test-host",
+			"default",
+			helpers.WithSSHSpecInclPorts(23, 24),
+			helpers.WithIPv4(),
+			helpers.WithPrivateProvisioning(),
+		)
+		factory := &recordingSSHFactory{}
+		service := newTestService(host, nil, factory, helpers.GetDefaultSSHSecret(osSSHKeyName, "default"), nil)
+
+		service.osSSHClient(23)
+		Expect(factory.inputs).To(HaveLen(1))
+		Expect(factory.inputs[0].IP).To(Equal("10.0.0.2"))
+		Expect(factory.inputs[0].Port).To(Equal(23))
+		Expect(factory.inputs[0].Bastion).To(Equal(&sshclient.Bastion{
+			IP:         "5.6.7.8",
+			PrivateKey: "os-sshkey-private-key",
+			Port:       2222,
+			User:       "jump",
+		}))
+	})
+})
+
+var _ = Describe("osSSHClientAfterCloudInit", func() {
+	It("logs in as root without privilege escalation by default", func() {
+		host := helpers.BareMetalHost(
+			"test-host",
+			"default",
+			helpers.WithSSHSpecInclPorts(23, 24),
+			helpers.WithIPv4(),
+		)
+		factory := &recordingSSHFactory{}
+		service := newTestService(host, nil, factory, helpers.GetDefaultSSHSecret(osSSHKeyName, "default"), nil)
+
+		service.osSSHClientAfterCloudInit()
+		Expect(factory.inputs).To(HaveLen(1))
+		Expect(factory.inputs[0].Port).To(Equal(24))
+		Expect(factory.inputs[0].User).To(BeEmpty())
+		Expect(factory.inputs[0].PrivilegeEscalation).To(BeEmpty())
+	})
+
+	It("logs in as the user after cloud init with sudo", func() {
+		host := helpers.BareMetalHost(
+			"test-host",
+			"default",
+			helpers.WithSSHSpecInclPorts(23, 24),
+			helpers.WithIPv4(),
+		)
+		host.Spec.Status.SSHSpec.UserAfterCloudInit = "capi"
+		factory := &recordingSSHFactory{}
+		service := newTestService(host, nil, factory, helpers.GetDefaultSSHSecret(osSSHKeyName, "default"), nil)
+
+		service.osSSHClientAfterCloudInit()
+		Expect(factory.inputs[0].User).To(Equal("capi"))
+		Expect(factory.inputs[0].PrivilegeEscalation).To(Equal("sudo -n"))
+
+		host.Spec.Status.SSHSpec.PrivilegeEscalation = "doas"
+		service.osSSHClientAfterCloudInit()
+		Expect(factory.inputs[1].PrivilegeEscalation).To(Equal("doas"))
+
+		// The settings after install image are not affected
+		service.osSSHClient(23)
+		Expect(factory.inputs[2].User).To(BeEmpty())
+		Expect(factory.inputs[2].PrivilegeEscalation).To(BeEmpty())
+	})
+})
+
+var _ = Describe("actionEnsureProvisioned with a user after cloud init", func() {
+	It("checks cloud init as root while the user cannot log in yet", func() {
+		host := helpers.BareMetalHost(
+			"test-host",
+			"default",
+			helpers.WithSSHSpecInclPorts(23, 24),
+			helpers.WithIPv4(),
+			helpers.WithConsumerRef(),
+		)
+		host.Spec.Status.SSHSpec.UserAfterCloudInit = "capi"
+
+		sshMock := &sshmock.Client{}
+		sshMock.On("GetHostName").Return(sshclient.Output{Err: sshclient.ErrAuthenticationFailed})
+
+		oldSSHMock := &sshmock.Client{}
+		oldSSHMock.On("CloudInitStatus").Return(sshclient.Output{StdOut: "status: running"})
+
+		service := newTestService(host, nil, bmmock.NewSSHFactory(sshMock, oldSSHMock, sshMock), helpers.GetDefaultSSHSecret(osSSHKeyName, "default"), nil)
+
+		actResult := service.actionEnsureProvisioned()
+		Expect(actResult).Should(BeAssignableToTypeOf(actionContinue{}))
+		Expect(oldSSHMock.AssertCalled(GinkgoT(), "CloudInitStatus")).To(BeTrue())
+	})
+
+	It("fails if neither the user nor root can log in", func() {
+		host := helpers.BareMetalHost(
+			"test-host",
+			"default",
+			helpers.WithSSHSpecInclPorts(23, 24),
+			helpers.WithIPv4(),
+			helpers.WithConsumerRef(),
+		)
+		host.Spec.Status.SSHSpec.UserAfterCloudInit = "capi"
+
+		sshMock := &sshmock.Client{}
+		sshMock.On("GetHostName").Return(sshclient.Output{Err: sshclient.ErrAuthenticationFailed})
+
+		oldSSHMock := &sshmock.Client{}
+		oldSSHMock.On("CloudInitStatus").Return(sshclient.Output{Err: sshclient.ErrAuthenticationFailed})
+
+		service := newTestService(host, nil, bmmock.NewSSHFactory(sshMock, oldSSHMock, sshMock), helpers.GetDefaultSSHSecret(osSSHKeyName, "default"), nil)
+
+		actResult := service.actionEnsureProvisioned()
+		Expect(actResult).Should(BeAssignableToTypeOf(actionError{}))
+	})
+})
+
+var _ = Describe("actionEnsureProvisioned with a pre-baked image", func() {
+	newPreBakedService := func(sshMock *sshmock.Client, preBaked bool) *Service {
+		host := helpers.BareMetalHost(
+			"test-host",
+			"default",
+			helpers.WithSSHSpecInclPorts(24, 24),
+			helpers.WithIPv4(),
+			helpers.WithConsumerRef(),
+		)
+		host.Spec.Status.InstallImage = &infrav1.InstallImage{PreBaked: preBaked}
+		sshMock.On("GetHostName").Return(sshclient.Output{StdOut: infrav1.BareMetalHostNamePrefix + host.Spec.ConsumerRef.Name})
+		sshMock.On("CloudInitStatus").Return(sshclient.Output{StdOut: "status: running"})
+		return newTestService(host, nil, bmmock.NewSSHFactory(sshMock, sshMock, sshMock), helpers.GetDefaultSSHSecret(osSSHKeyName, "default"), nil)
+	}
+
+	It("completes while cloud init is running once kubelet and containerd are healthy", func() {
+		sshMock := &sshmock.Client{}
+		sshMock.On("CheckKubeletHealth").Return(sshclient.Output{StdOut: "ok"})
+		sshMock.On("CheckContainerd").Return(sshclient.Output{})
+		service := newPreBakedService(sshMock, true)
+
+		Expect(service.actionEnsureProvisioned()).Should(BeAssignableToTypeOf(actionComplete{}))
+		Expect(sshMock.AssertNotCalled(GinkgoT(), "CheckCloudInitLogsForSigTerm")).To(BeTrue())
+	})
+
+	It("waits for cloud init while kubelet is not healthy", func() {
+		sshMock := &sshmock.Client{}
+		sshMock.On("CheckKubeletHealth").Return(sshclient.Output{Err: errors.New("connection refused")})
+		sshMock.On("CheckContainerd").Return(sshclient.Output{})
+		service := newPreBakedService(sshMock, true)
+
+		Expect(service.actionEnsureProvisioned()).Should(BeAssignableToTypeOf(actionContinue{}))
+	})
+
+	It("waits for cloud init if the image is not pre-baked", func() {
+		sshMock := &sshmock.Client{}
+		service := newPreBakedService(sshMock, false)
+
+		Expect(service.actionEnsureProvisioned()).Should(BeAssignableToTypeOf(actionContinue{}))
+		Expect(sshMock.AssertNotCalled(GinkgoT(), "CheckKubeletHealth")).To(BeTrue())
+	})
+})
+
+var _ = Describe("actionImageInstalling with private provisioning", func() {
+	It("fails if the host has no private IP", func() {
+		host := helpers.BareMetalHost(
+			"test-host",
+			"default",
+			helpers.WithSSHSpec(),
+			helpers.WithIPv4(),
+			helpers.WithPrivateProvisioning(),
+		)
+		host.Spec.PrivateIP = ""
+
+		service := newTestService(host, nil, &recordingSSHFactory{}, helpers.GetDefaultSSHSecret(osSSHKeyName, "default"), helpers.GetDefaultSSHSecret(rescueSSHKeyName, "default"))
+
+		actResult := service.actionImageInstalling()
+		Expect(actResult).Should(BeAssignableToTypeOf(actionFailed{}))
+		Expect(host.Spec.Status.ErrorMessage).To(Equal(infrav1.ErrorMessageMissingPrivateIP))
+	})
+})
+
+var _ = Describe("actionImageInstalling with a download secret", func() {
+	var (
+		host     *infrav1.HetznerBareMetalHost
+		sshMock  *sshmock.Client
+		service  *Service
+		imageURL = "https://artifacts.example.com/images/ubuntu.tar.gz"
+	)
+
+	BeforeEach(func() {
+		host = helpers.BareMetalHost(
+			"test-host",
+			"default",
+			helpers.WithSSHSpec(),
+			helpers.WithIPv4(),
+		)
+		host.Spec.Status.InstallImage = &infrav1.InstallImage{
+			Image: infrav1.Image{
+				URL:               imageURL,
+				Name:              "ubuntu",
+				DownloadSecretRef: &corev1.LocalObjectReference{Name: "image-download"},
+			},
+		}
+
+		robotMock := &robotmock.Client{}
+		robotMock.On("ListSSHKeys").Return(nil, nil)
+		robotMock.On("SetSSHKey", mock.Anything, mock.Anything).Return(&models.Key{Name: "os-key", Fingerprint: "my-fingerprint"}, nil)
+
+		sshMock = &sshmock.Client{}
+		sshMock.On("DownloadImage", mock.Anything, mock.Anything, mock.Anything).Return(sshclient.Output{Err: errors.New("download failed")})
+
+		service = newTestService(host, robotMock, bmmock.NewSSHFactory(sshMock, sshMock, sshMock),
+			helpers.GetDefaultSSHSecret(osSSHKeyName, "default"), helpers.GetDefaultSSHSecret(rescueSSHKeyName, "default"))
+	})
+
+	newDownloadSecret := func(data map[string][]byte) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "image-download", Namespace: "default"},
+			Data:       data,
+		}
+	}
+
+	It("fails if the download secret is missing", func() {
+		actResult := service.actionImageInstalling()
+		Expect(actResult).Should(BeAssignableToTypeOf(actionFailed{}))
+		Expect(host.Spec.Status.ErrorMessage).To(Equal(infrav1.ErrorMessageMissingImageDownloadSecret))
+		sshMock.AssertNotCalled(GinkgoT(), "DownloadImage", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	It("fails if the download secret contains incomplete credentials", func() {
+		service.scope.ImageDownloadSecret = newDownloadSecret(map[string][]byte{
+			infrav1.ImageDownloadSecretUsernameKey: []byte("user"),
+		})
+
+		actResult := service.actionImageInstalling()
+		Expect(actResult).Should(BeAssignableToTypeOf(actionFailed{}))
+		Expect(host.Spec.Status.ErrorMessage).To(HavePrefix(infrav1.ErrorMessageMissingOrInvalidSecretData))
+		sshMock.AssertNotCalled(GinkgoT(), "DownloadImage", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	It("downloads the image with the credentials of the secret", func() {
+		service.scope.ImageDownloadSecret = newDownloadSecret(map[string][]byte{
+			infrav1.ImageDownloadSecretTokenKey:  []byte("my-token\n"),
+			infrav1.ImageDownloadSecretCACertKey: []byte("my-ca"),
+		})
+
+		actResult := service.actionImageInstalling()
+		Expect(actResult).Should(BeAssignableToTypeOf(actionError{}))
+		sshMock.AssertCalled(GinkgoT(), "DownloadImage", "/root/ubuntu.tar.gz", imageURL,
+			sshclient.DownloadCredentials{Token: "my-token", CACert: "my-ca"})
+	})
+})
+
+var _ = Describe("setAppliedUserData", func() {
+	It("records the hash of the rendered user data and keeps the recorded image and autosetup", func() {
+		host := helpers.BareMetalHost("test-host", "default")
+		host.Spec.Status.AppliedConfiguration = &infrav1.AppliedConfiguration{
+			Image:         "https://example.com/image.tar.gz",
+			AutoSetupHash: "autosetup-hash",
+		}
+		service := newTestService(host, nil, nil, nil, nil)
+
+		service.setAppliedUserData([]byte("user data"), []byte("rendered user data"))
+		Expect(host.Spec.Status.UserDataHash).To(Equal("52408ce928fcece4a50261fcbb1c3a1556b12bd3ad2c32ee0fd5a8d429b46193"))
+		Expect(host.Spec.Status.AppliedConfiguration.UserDataHash).To(Equal(utils.SHA256Hash([]byte("rendered user data"))))
+		Expect(host.Spec.Status.AppliedConfiguration.Image).To(Equal("https://example.com/image.tar.gz"))
+		Expect(host.Spec.Status.AppliedConfiguration.AutoSetupHash).To(Equal("autosetup-hash"))
+		Expect(host.Spec.Status.AppliedConfiguration.AppliedAt).ToNot(BeNil())
+	})
+})
+
+var _ = DescribeTable("CalculateActionErrorBackoff",
+	func(actionErrorCount int, min, max time.Duration) {
+		for i := 0; i < 10; i++ {
+			backoff := CalculateActionErrorBackoff(actionErrorCount)
+			Expect(backoff).To(BeNumerically(">=", min))
+			Expect(backoff).To(BeNumerically("<=", max))
+		}
+	},
+	Entry("first error", 1, 5*time.Second, 10*time.Second),
+	Entry("third error", 3, 20*time.Second, 40*time.Second),
+	Entry("capped", 20, 5*time.Minute, 10*time.Minute),
+)
+
+var _ = Describe("recordActionError", func() {
+	It("counts errors in a row and resets them after success", func() {
+		host := helpers.BareMetalHost("test-host", "default")
+		service := newTestService(host, nil, nil, nil, nil)
+
+		service.recordActionError(errors.New("robot unavailable"), infrav1.FailureClassTransient)
+		backoff := service.recordActionError(errors.New("robot unavailable"), infrav1.FailureClassTransient)
+		Expect(host.Spec.Status.ActionErrorCount).To(Equal(2))
+		Expect(backoff).To(BeNumerically(">=", 10*time.Second))
+		Expect(conditions.IsFalse(host, infrav1.ActionSucceededCondition)).To(BeTrue())
+		Expect(conditions.GetReason(host, infrav1.ActionSucceededCondition)).To(Equal(infrav1.ActionErrorBackoffReason))
+		Expect(conditions.GetMessage(host, infrav1.ActionSucceededCondition)).To(ContainSubstring("robot unavailable"))
+
+		clearActionError(host)
+		Expect(host.Spec.Status.ActionErrorCount).To(BeZero())
+		Expect(conditions.IsTrue(host, infrav1.ActionSucceededCondition)).To(BeTrue())
+	})
+
+	It("waits for rate limits", func() {
+		host := helpers.BareMetalHost("test-host", "default")
+		service := newTestService(host, nil, nil, nil, nil)
+
+		backoff := service.recordActionError(errors.New("rate limit exceeded"), infrav1.FailureClassRateLimited)
+		Expect(backoff).To(Equal(rateLimitBackoff))
+		Expect(conditions.GetReason(host, infrav1.ActionSucceededCondition)).To(Equal(infrav1.ActionRateLimitedReason))
+	})
+
+	It("does not persist the changes of a failed action but its error", func() {
+		host := helpers.BareMetalHost("test-host", "default")
+		host.Spec.Status.ProvisioningState = infrav1.StateImageInstalling
+		beforeAction := host.DeepCopy()
+		service := newTestService(host, nil, nil, nil, nil)
+
+		// The action advanced the host before it returned an error
+		SetProvisioningState(host, infrav1.StatePostInstalling)
+		host.Spec.Status.HardwareDetails = &infrav1.HardwareDetails{}
+		conditions.MarkTrue(host, infrav1.RateLimitExceeded)
+
+		resetFailedAction(host, beforeAction)
+		service.recordActionError(errors.New("robot unavailable"), infrav1.FailureClassTransient)
+		Expect(host.Spec.Status.ProvisioningState).To(Equal(infrav1.StateImageInstalling))
+		Expect(host.Spec.Status.HardwareDetails).To(BeNil())
+		Expect(host.Spec.Status.ActionErrorCount).To(Equal(1))
+		Expect(conditions.IsTrue(host, infrav1.RateLimitExceeded)).To(BeTrue())
+		Expect(conditions.IsFalse(host, infrav1.ActionSucceededCondition)).To(BeTrue())
+	})
+})
+
+var _ = Describe("FailureClass of action results", func() {
+	DescribeTable("actionError",
+		func(result actionError, expected infrav1.FailureClass) {
+			Expect(result.FailureClass()).To(Equal(expected))
+		},
+		Entry("transient", actionError{err: errors.New("robot unavailable")}, infrav1.FailureClassTransient),
+		Entry("robot rate limit", actionError{err: errors.Wrap(models.Error{Code: models.ErrorCodeRateLimitExceeded}, "failed to get server")},
+			infrav1.FailureClassRateLimited),
+		Entry("hcloud rate limit", actionError{err: errors.Wrap(hcloud.Error{Code: hcloud.ErrorCodeRateLimitExceeded}, "failed to get server")},
+			infrav1.FailureClassRateLimited),
+		Entry("explicit class", actionError{err: errors.New("invalid template"), class: infrav1.FailureClassPermanentConfiguration},
+			infrav1.FailureClassPermanentConfiguration),
+	)
+
+	DescribeTable("actionFailed",
+		func(errorType infrav1.ErrorType, expected infrav1.FailureClass) {
+			Expect(actionFailed{ErrorType: errorType}.FailureClass()).To(Equal(expected))
+		},
+		Entry("registration error", infrav1.RegistrationError, infrav1.FailureClassPermanentConfiguration),
+		Entry("provisioning error", infrav1.ProvisioningError, infrav1.FailureClassPermanentConfiguration),
+		Entry("hardware reboot failed", infrav1.ErrorTypeHardwareRebootFailed, infrav1.FailureClassPermanentHardware),
+		Entry("ssh reboot too slow", infrav1.ErrorTypeSSHRebootTooSlow, infrav1.FailureClassTransient),
+	)
+
+	It("shows failed actions in the condition ActionSucceeded", func() {
+		host := helpers.BareMetalHost("test-host", "default")
+		service := newTestService(host, nil, nil, nil, nil)
+
+		failed := service.recordActionFailure(infrav1.RegistrationError, infrav1.ErrorMessageMissingRootDeviceHints)
+		Expect(host.Spec.Status.FailureClass).To(Equal(infrav1.FailureClassPermanentConfiguration))
+
+		recordActionFailed(host, failed)
+		Expect(conditions.IsFalse(host, infrav1.ActionSucceededCondition)).To(BeTrue())
+		Expect(conditions.GetReason(host, infrav1.ActionSucceededCondition)).To(Equal(infrav1.ActionConfigurationErrorReason))
+		Expect(conditions.GetSeverity(host, infrav1.ActionSucceededCondition)).To(HaveValue(Equal(clusterv1.ConditionSeverityError)))
+
+		clearError(host)
+		Expect(host.Spec.Status.FailureClass).To(BeEmpty())
+	})
+})
+
+var _ = Describe("SetProvisioningState", func() {
+	It("records the time of the transition and the start of the provisioning", func() {
+		host := helpers.BareMetalHost("test-host", "default")
+		host.Spec.Status.ProvisioningState = infrav1.StateNone
+
+		SetProvisioningState(host, infrav1.StatePreparing)
+		Expect(host.Spec.Status.ProvisioningState).To(Equal(infrav1.StatePreparing))
+		Expect(host.Spec.Status.ProvisioningStateChanged).ToNot(BeNil())
+		Expect(host.Spec.Status.ProvisioningStarted).To(Equal(host.Spec.Status.ProvisioningStateChanged))
+
+		started := host.Spec.Status.ProvisioningStarted
+		SetProvisioningState(host, infrav1.StateRegistering)
+		Expect(host.Spec.Status.ProvisioningStarted).To(Equal(started))
+	})
+
+	It("does nothing if the state does not change", func() {
+		host := helpers.BareMetalHost("test-host", "default")
+		host.Spec.Status.ProvisioningState = infrav1.StateProvisioned
+
+		SetProvisioningState(host, infrav1.StateProvisioned)
+		Expect(host.Spec.Status.ProvisioningStateChanged).To(BeNil())
+	})
+})
+
+var _ = Describe("RecordProvisioningStateChange", func() {
+	It("observes the duration of the previous state once the host has been saved", func() {
+		host := helpers.BareMetalHost("test-host", "default")
+		host.Spec.Status.ProvisioningState = infrav1.StateImageInstalling
+		changed := metav1.NewTime(time.Now().Add(-10 * time.Minute))
+		host.Spec.Status.ProvisioningStateChanged = &changed
+		original := host.DeepCopy()
+		series := testutil.CollectAndCount(stateDuration)
+
+		SetProvisioningState(host, infrav1.StatePostInstalling)
+		Expect(testutil.CollectAndCount(stateDuration)).To(Equal(series))
+
+		RecordProvisioningStateChange(original, host)
+		Expect(testutil.CollectAndCount(stateDuration)).To(Equal(series + 1))
+	})
+
+	It("does nothing if the state has not changed", func() {
+		host := helpers.BareMetalHost("test-host", "default")
+		host.Spec.Status.ProvisioningState = infrav1.StateProvisioned
+		series := testutil.CollectAndCount(stateDuration)
+
+		RecordProvisioningStateChange(host.DeepCopy(), host)
+		Expect(testutil.CollectAndCount(stateDuration)).To(Equal(series))
+	})
+})
+
+var _ = Describe("reconcileRobotServer", func() {
+	var robotMock *robotmock.Client
+
+	BeforeEach(func() {
+		robotMock = &robotmock.Client{}
+		robotMock.On("GetBMServer", mock.Anything).Return(&models.Server{
+			Product:   "AX41-NVMe",
+			Dc:        "FSN1-DC14",
+			Traffic:   "unlimited",
+			Status:    "ready",
+			Cancelled: true,
+			PaidUntil: "2023-05-31",
+		}, nil)
+	})
+
+	It("sets the Robot data of the server", func() {
+		host := helpers.BareMetalHost("test-host", "default")
+		service := newTestService(host, robotMock, nil, nil, nil)
+
+		service.reconcileRobotServer(context.Background())
+		Expect(host.Spec.Status.Datacenter).To(Equal("FSN1-DC14"))
+		Expect(host.Spec.Status.RobotServer).ToNot(BeNil())
+		Expect(host.Spec.Status.RobotServer.Product).To(Equal("AX41-NVMe"))
+		Expect(host.Spec.Status.RobotServer.Traffic).To(Equal("unlimited"))
+		Expect(host.Spec.Status.RobotServer.Status).To(Equal("ready"))
+		Expect(host.Spec.Status.RobotServer.Cancelled).To(BeTrue())
+		Expect(host.Spec.Status.RobotServer.PaidUntil).To(Equal("2023-05-31"))
+		Expect(host.Spec.Status.RobotServer.LastUpdated).ToNot(BeNil())
+	})
+
+	It("does not refresh recent data", func() {
+		host := helpers.BareMetalHost("test-host", "default")
+		lastUpdated := metav1.NewTime(time.Now().Add(-time.Hour))
+		host.Spec.Status.RobotServer = &infrav1.RobotServerStatus{Product: "EX44", LastUpdated: &lastUpdated}
+		service := newTestService(host, robotMock, nil, nil, nil)
+
+		service.reconcileRobotServer(context.Background())
+		Expect(host.Spec.Status.RobotServer.Product).To(Equal("EX44"))
+		robotMock.AssertNotCalled(GinkgoT(), "GetBMServer", mock.Anything)
+	})
+
+	It("refreshes outdated data", func() {
+		host := helpers.BareMetalHost("test-host", "default")
+		lastUpdated := metav1.NewTime(time.Now().Add(-robotServerRefreshInterval))
+		host.Spec.Status.RobotServer = &infrav1.RobotServerStatus{Product: "EX44", LastUpdated: &lastUpdated}
+		service := newTestService(host, robotMock, nil, nil, nil)
+
+		service.reconcileRobotServer(context.Background())
+		Expect(host.Spec.Status.RobotServer.Product).To(Equal("AX41-NVMe"))
+	})
+})
+
+var _ = Describe("reconcileTraffic", func() {
+	var (
+		host      *infrav1.HetznerBareMetalHost
+		robotMock *robotmock.Client
+	)
+
+	BeforeEach(func() {
+		host = helpers.BareMetalHost("traffic-host", "default", helpers.WithIPv4())
+		host.Spec.Status.RobotServer = &infrav1.RobotServerStatus{Traffic: "20 TB"}
+		robotMock = &robotmock.Client{}
+		robotMock.On("GetMonthlyTraffic", host.Spec.Status.IPv4, mock.Anything).Return(&robotclient.Traffic{In: 120.5, Out: 18500, Sum: 18620.5}, nil)
+	})
+
+	It("sets the traffic of the server in the current month", func() {
+		service := newTestService(host, robotMock, nil, nil, nil)
+
+		service.reconcileTraffic(context.Background())
+		Expect(host.Spec.Status.Traffic).ToNot(BeNil())
+		Expect(host.Spec.Status.Traffic.Month).To(Equal(time.Now().UTC().Format("2006-01")))
+		Expect(host.Spec.Status.Traffic.Inbound.Value()).To(Equal(int64(120.5e9)))
+		Expect(host.Spec.Status.Traffic.Outbound.Value()).To(Equal(int64(18500e9)))
+		Expect(host.Spec.Status.Traffic.Included.Value()).To(Equal(int64(20e12)))
+		Expect(testutil.ToFloat64(trafficBytes.WithLabelValues("default", "traffic-host", "out"))).To(Equal(18500e9))
+	})
+
+	It("does not refresh the traffic of the current month within the refresh interval", func() {
+		lastUpdated := metav1.NewTime(time.Now().Add(-time.Minute))
+		host.Spec.Status.Traffic = &infrav1.TrafficStatus{Month: time.Now().UTC().Format("2006-01"), LastUpdated: &lastUpdated}
+		service := newTestService(host, robotMock, nil, nil, nil)
+
+		service.reconcileTraffic(context.Background())
+		robotMock.AssertNotCalled(GinkgoT(), "GetMonthlyTraffic", mock.Anything, mock.Anything)
+	})
+
+	It("refreshes the traffic of the previous month", func() {
+		lastUpdated := metav1.NewTime(time.Now().Add(-time.Minute))
+		host.Spec.Status.Traffic = &infrav1.TrafficStatus{Month: "2023-01", LastUpdated: &lastUpdated}
+		service := newTestService(host, robotMock, nil, nil, nil)
+
+		service.reconcileTraffic(context.Background())
+		Expect(host.Spec.Status.Traffic.Month).ToNot(Equal("2023-01"))
+	})
+})
+
+var _ = DescribeTable("includedTraffic",
+	func(traffic string, expected *resource.Quantity) {
+		Expect(includedTraffic(traffic)).To(Equal(expected))
+	},
+	Entry("terabytes", "20 TB", resource.NewQuantity(20e12, resource.DecimalSI)),
+	Entry("gigabytes", "500 GB", resource.NewQuantity(500e9, resource.DecimalSI)),
+	Entry("unlimited", "unlimited", nil),
+	Entry("empty", "", nil),
+)
+
+var _ = Describe("runProvisioningChecks", func() {
+	var (
+		host    *infrav1.HetznerBareMetalHost
+		sshMock *sshmock.Client
+	)
+
+	BeforeEach(func() {
+		host = helpers.BareMetalHost("test-host", "default")
+		stateChanged := metav1.NewTime(time.Now().Add(-time.Minute))
+		host.Spec.Status.ProvisioningStateChanged = &stateChanged
+		host.Spec.Status.ProvisioningChecks = &infrav1.ProvisioningChecks{
+			SystemdUnits: []string{"containerd.service"},
+			Kubelet:      true,
+			Containerd:   true,
+		}
+
+		sshMock = &sshmock.Client{}
+		sshMock.On("CheckContainerd").Return(sshclient.Output{})
+	})
+
+	It("completes without checks", func() {
+		host.Spec.Status.ProvisioningChecks = nil
+		service := newTestService(host, nil, nil, nil, nil)
+
+		Expect(service.runProvisioningChecks(sshMock)).To(BeAssignableToTypeOf(actionComplete{}))
+		Expect(conditions.Has(host, infrav1.ProvisioningChecksSucceededCondition)).To(BeFalse())
+	})
+
+	It("completes if all checks succeed", func() {
+		sshMock.On("CheckSystemdUnit", "containerd.service").Return(sshclient.Output{StdOut: "active\n"})
+		sshMock.On("CheckKubeletHealth").Return(sshclient.Output{StdOut: "ok"})
+		service := newTestService(host, nil, nil, nil, nil)
+
+		Expect(service.runProvisioningChecks(sshMock)).To(BeAssignableToTypeOf(actionComplete{}))
+		Expect(conditions.IsTrue(host, infrav1.ProvisioningChecksSucceededCondition)).To(BeTrue())
+	})
+
+	It("waits for failing checks", func() {
+		sshMock.On("CheckSystemdUnit", "containerd.service").Return(sshclient.Output{StdOut: "activating\n", Err: errors.New("exited with status 3")})
+		sshMock.On("CheckKubeletHealth").Return(sshclient.Output{Err: errors.New("connection refused")})
+		service := newTestService(host, nil, nil, nil, nil)
+
+		Expect(service.runProvisioningChecks(sshMock)).To(BeAssignableToTypeOf(actionContinue{}))
+		Expect(conditions.IsFalse(host, infrav1.ProvisioningChecksSucceededCondition)).To(BeTrue())
+		Expect(conditions.GetMessage(host, infrav1.ProvisioningChecksSucceededCondition)).To(
+			Equal("systemd unit containerd.service is activating; kubelet is not healthy"))
+	})
+
+	It("fails if the checks do not succeed within the timeout", func() {
+		host.Spec.Status.ProvisioningChecks.Timeout = &metav1.Duration{Duration: 30 * time.Second}
+		sshMock.On("CheckSystemdUnit", "containerd.service").Return(sshclient.Output{StdOut: "active\n"})
+		sshMock.On("CheckKubeletHealth").Return(sshclient.Output{Err: errors.New("connection refused")})
+		service := newTestService(host, nil, nil, nil, nil)
+
+		Expect(service.runProvisioningChecks(sshMock)).To(BeAssignableToTypeOf(actionFailed{}))
+		Expect(host.Spec.Status.ErrorType).To(Equal(infrav1.ProvisioningError))
+	})
+})
+
+var _ = Describe("ensureRedundantBootloader", func() {
+	var (
+		host    *infrav1.HetznerBareMetalHost
+		sshMock *sshmock.Client
+		wwns    = []string{helpers.DefaultWWN, helpers.DefaultWWN2}
+	)
+
+	BeforeEach(func() {
+		host = helpers.BareMetalHost("test-host", "default", helpers.WithRootDeviceHintRaid())
+		host.Spec.Status.InstallImage = &infrav1.InstallImage{Swraid: 1, SwraidLevel: 1}
+		sshMock = &sshmock.Client{}
+	})
+
+	It("completes without RAID1", func() {
+		host.Spec.Status.InstallImage.Swraid = 0
+		service := newTestService(host, nil, nil, nil, nil)
+
+		Expect(service.ensureRedundantBootloader(sshMock)).To(BeAssignableToTypeOf(actionComplete{}))
+		Expect(conditions.Has(host, infrav1.RedundantBootloaderCondition)).To(BeFalse())
+		sshMock.AssertNotCalled(GinkgoT(), "GetDisksWithoutBootloader", mock.Anything)
+	})
+
+	It("completes if the bootloader is installed on all disks", func() {
+		sshMock.On("GetDisksWithoutBootloader", wwns).Return(sshclient.Output{})
+		service := newTestService(host, nil, nil, nil, nil)
+
+		Expect(service.ensureRedundantBootloader(sshMock)).To(BeAssignableToTypeOf(actionComplete{}))
+		Expect(conditions.IsTrue(host, infrav1.RedundantBootloaderCondition)).To(BeTrue())
+		sshMock.AssertNotCalled(GinkgoT(), "InstallBootloader", mock.Anything)
+	})
+
+	It("installs a missing bootloader", func() {
+		sshMock.On("GetDisksWithoutBootloader", wwns).Return(sshclient.Output{StdOut: helpers.DefaultWWN2 + "\n"}).Once()
+		sshMock.On("GetDisksWithoutBootloader", wwns).Return(sshclient.Output{}).Once()
+		sshMock.On("InstallBootloader", helpers.DefaultWWN2).Return(sshclient.Output{StdOut: "Installation finished. No error reported.\n"})
+		service := newTestService(host, nil, nil, nil, nil)
+
+		Expect(service.ensureRedundantBootloader(sshMock)).To(BeAssignableToTypeOf(actionComplete{}))
+		Expect(conditions.IsTrue(host, infrav1.RedundantBootloaderCondition)).To(BeTrue())
+		sshMock.AssertCalled(GinkgoT(), "InstallBootloader", helpers.DefaultWWN2)
+	})
+
+	It("fails if the bootloader cannot be installed", func() {
+		sshMock.On("GetDisksWithoutBootloader", wwns).Return(sshclient.Output{StdOut: helpers.DefaultWWN2 + "\n"})
+		sshMock.On("InstallBootloader", helpers.DefaultWWN2).Return(sshclient.Output{Err: errors.New("exited with status 1")})
+		service := newTestService(host, nil, nil, nil, nil)
+
+		Expect(service.ensureRedundantBootloader(sshMock)).To(BeAssignableToTypeOf(actionFailed{}))
+		Expect(host.Spec.Status.ErrorType).To(Equal(infrav1.ProvisioningError))
+		Expect(conditions.GetReason(host, infrav1.RedundantBootloaderCondition)).To(Equal(infrav1.BootloaderMissingReason))
+	})
+
+	It("fails if the EFI system partition is not mirrored", func() {
+		sshMock.On("GetDisksWithoutBootloader", wwns).Return(sshclient.Output{StdOut: sshclient.EFISystemPartition + "\n"})
+		service := newTestService(host, nil, nil, nil, nil)
+
+		Expect(service.ensureRedundantBootloader(sshMock)).To(BeAssignableToTypeOf(actionFailed{}))
+		Expect(conditions.IsFalse(host, infrav1.RedundantBootloaderCondition)).To(BeTrue())
+		sshMock.AssertNotCalled(GinkgoT(), "InstallBootloader", mock.Anything)
+	})
+})
+
+var _ = Describe("fence", func() {
+	var (
+		host      *infrav1.HetznerBareMetalHost
+		robotMock *robotmock.Client
+	)
+
+	BeforeEach(func() {
+		host = helpers.BareMetalHost("test-host", "default")
+		host.Annotations = map[string]string{infrav1.FenceAnnotation: ""}
+		host.Spec.Status.RebootTypes = []infrav1.RebootType{infrav1.RebootTypeSoftware, infrav1.RebootTypePowerLong}
+		robotMock = &robotmock.Client{}
+	})
+
+	It("powers off the running host once", func() {
+		robotMock.On("GetReboot", bareMetalHostID).Return(&models.Reset{OperatingStatus: "running"}, nil)
+		robotMock.On("RebootBMServer", bareMetalHostID, infrav1.RebootTypePowerLong).Return(nil, nil)
+		service := newTestService(host, robotMock, nil, nil, nil)
+
+		Expect(service.fence()).To(BeAssignableToTypeOf(actionContinue{}))
+		Expect(conditions.GetReason(host, infrav1.HostFencedCondition)).To(Equal(infrav1.FencingInProgressReason))
+
+		Expect(service.fence()).To(BeAssignableToTypeOf(actionContinue{}))
+		robotMock.AssertNumberOfCalls(GinkgoT(), "RebootBMServer", 1)
+	})
+
+	It("does not press the power button of a host that is already off", func() {
+		robotMock.On("GetReboot", bareMetalHostID).Return(&models.Reset{OperatingStatus: "stopped"}, nil)
+		service := newTestService(host, robotMock, nil, nil, nil)
+
+		Expect(service.fence()).To(BeAssignableToTypeOf(actionComplete{}))
+		Expect(conditions.IsTrue(host, infrav1.HostFencedCondition)).To(BeTrue())
+		robotMock.AssertNotCalled(GinkgoT(), "RebootBMServer", mock.Anything, mock.Anything)
+	})
+
+	It("is fenced once Robot confirms that the host is off", func() {
+		conditions.MarkFalse(host, infrav1.HostFencedCondition, infrav1.FencingInProgressReason, clusterv1.ConditionSeverityWarning, "")
+		host.Spec.Status.ProvisioningState = infrav1.StateProvisioned
+		robotMock.On("GetReboot", bareMetalHostID).Return(&models.Reset{OperatingStatus: "stopped"}, nil)
+		service := newTestService(host, robotMock, nil, nil, nil)
+
+		Expect(newTestHostStateMachine(host, service).ReconcileState(context.Background())).To(Equal(actionContinue{delay: time.Minute}))
+		Expect(conditions.IsTrue(host, infrav1.HostFencedCondition)).To(BeTrue())
+		robotMock.AssertNotCalled(GinkgoT(), "RebootBMServer", mock.Anything, mock.Anything)
+	})
+
+	It("fences a host that is not provisioned yet", func() {
+		host.Spec.Status.ProvisioningState = infrav1.StateImageInstalling
+		robotMock.On("GetReboot", bareMetalHostID).Return(&models.Reset{OperatingStatus: "running"}, nil)
+		robotMock.On("RebootBMServer", bareMetalHostID, infrav1.RebootTypePowerLong).Return(nil, nil)
+		service := newTestService(host, robotMock, nil, nil, nil)
+
+		Expect(newTestHostStateMachine(host, service).ReconcileState(context.Background())).To(BeAssignableToTypeOf(actionContinue{}))
+		Expect(host.Spec.Status.ProvisioningState).To(Equal(infrav1.StateImageInstalling))
+		Expect(conditions.GetReason(host, infrav1.HostFencedCondition)).To(Equal(infrav1.FencingInProgressReason))
+		robotMock.AssertNumberOfCalls(GinkgoT(), "RebootBMServer", 1)
+	})
+
+	It("gives the host an error if it still runs after the fencing timeout", func() {
+		conditions.MarkFalse(host, infrav1.HostFencedCondition, infrav1.FencingInProgressReason, clusterv1.ConditionSeverityWarning, "")
+		host.Spec.Status.Conditions[0].LastTransitionTime = metav1.NewTime(time.Now().Add(-fencingTimeout - time.Minute))
+		robotMock.On("GetReboot", bareMetalHostID).Return(&models.Reset{OperatingStatus: "running"}, nil)
+		service := newTestService(host, robotMock, nil, nil, nil)
+
+		Expect(service.fence()).To(Equal(actionContinue{delay: time.Minute}))
+		Expect(conditions.GetReason(host, infrav1.HostFencedCondition)).To(Equal(infrav1.FencingFailedReason))
+		Expect(host.Spec.Status.ErrorType).To(Equal(infrav1.FencingError))
+		Expect(host.Spec.Status.FailureClass).To(Equal(infrav1.FailureClassTransient))
+
+		Expect(service.fence()).To(Equal(actionContinue{delay: time.Minute}))
+		robotMock.AssertNotCalled(GinkgoT(), "RebootBMServer", mock.Anything, mock.Anything)
+	})
+
+	It("is fenced and clears the error once a failed host is powered off", func() {
+		conditions.MarkFalse(host, infrav1.HostFencedCondition, infrav1.FencingFailedReason, clusterv1.ConditionSeverityError, "")
+		SetErrorMessage(host, infrav1.FencingError, "still running")
+		robotMock.On("GetReboot", bareMetalHostID).Return(&models.Reset{OperatingStatus: "stopped"}, nil)
+		service := newTestService(host, robotMock, nil, nil, nil)
+
+		Expect(service.fence()).To(BeAssignableToTypeOf(actionComplete{}))
+		Expect(conditions.IsTrue(host, infrav1.HostFencedCondition)).To(BeTrue())
+		Expect(host.Spec.Status.ErrorType).To(BeEmpty())
+	})
+
+	It("does not confirm the power-off if Robot does not report the power state", func() {
+		conditions.MarkFalse(host, infrav1.HostFencedCondition, infrav1.FencingInProgressReason, clusterv1.ConditionSeverityWarning, "")
+		robotMock.On("GetReboot", bareMetalHostID).Return(&models.Reset{OperatingStatus: "not supported"}, nil)
+		service := newTestService(host, robotMock, nil, nil, nil)
+
+		Expect(service.fence()).To(BeAssignableToTypeOf(actionContinue{}))
+		Expect(conditions.GetReason(host, infrav1.HostFencedCondition)).To(Equal(infrav1.FencingNotSupportedReason))
+		Expect(service.fence()).To(BeAssignableToTypeOf(actionContinue{}))
+		robotMock.AssertNotCalled(GinkgoT(), "RebootBMServer", mock.Anything, mock.Anything)
+	})
+})
+
+var _ = Describe("waitForProvisioningSlot", func() {
+	var service *Service
+
+	newHost := func(name, datacenter string, state infrav1.ProvisioningState) *infrav1.HetznerBareMetalHost {
+		host := helpers.BareMetalHost(name, "default")
+		host.Spec.Status.Datacenter = datacenter
+		host.Spec.Status.ProvisioningState = state
+		return host
+	}
+
+	BeforeEach(func() {
+		service = newTestService(newHost("host", "FSN1-DC14", infrav1.StatePreparing), nil, nil, nil, nil)
+		service.scope.HetznerCluster.Spec.ProvisioningThrottle = &infrav1.ProvisioningThrottle{
+			MaxConcurrentRescue:  1,
+			MaxConcurrentImaging: 2,
+			Datacenters: []infrav1.DatacenterProvisioningThrottle{
+				{Datacenter: "HEL1", MaxConcurrentRescue: pointer.Int(0)},
+			},
+		}
+		for _, host := range []*infrav1.HetznerBareMetalHost{
+			newHost("registering-fsn1", "FSN1-DC14", infrav1.StateRegistering),
+			newHost("registering-hel1", "HEL1-DC2", infrav1.StateRegistering),
+			newHost("provisioning-fsn1", "FSN1-DC14", infrav1.StateProvisioning),
+		} {
+			Expect(service.scope.Client.Create(context.Background(), host)).To(Succeed())
+		}
+	})
+
+	It("waits if the limit of the datacenter is reached", func() {
+		Expect(service.waitForProvisioningSlot(rescueOperation)).To(Equal(actionContinue{delay: provisioningSlotDelay}))
+
+		condition := conditions.Get(service.scope.HetznerBareMetalHost, infrav1.ProvisioningSlotAvailableCondition)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(infrav1.RescueLimitReachedReason))
+		Expect(condition.Message).To(Equal("1 of 1 rescue activations in datacenter FSN1-DC14 are in progress"))
+	})
+
+	It("does not count hosts that wait for a slot themselves", func() {
+		waiting := newHost("waiting-fsn1", "FSN1-DC14", infrav1.StateImageInstalling)
+		conditions.MarkFalse(waiting, infrav1.ProvisioningSlotAvailableCondition, infrav1.ImagingLimitReachedReason, clusterv1.ConditionSeverityInfo, "")
+		Expect(service.scope.Client.Create(context.Background(), waiting)).To(Succeed())
+
+		Expect(service.waitForProvisioningSlot(imagingOperation)).To(BeNil())
+		Expect(conditions.Get(service.scope.HetznerBareMetalHost, infrav1.ProvisioningSlotAvailableCondition)).To(BeNil())
+	})
+
+	It("applies the limits of the datacenter", func() {
+		service.scope.HetznerBareMetalHost.Spec.Status.Datacenter = "HEL1-DC2"
+		Expect(service.waitForProvisioningSlot(rescueOperation)).To(BeNil())
+	})
+})
+
+var _ = Describe("waitForReprovisionApproval", func() {
+	var (
+		service *Service
+		host    *infrav1.HetznerBareMetalHost
+	)
+
+	BeforeEach(func() {
+		host = helpers.BareMetalHost("host", "default")
+		host.Spec.Status.InstalledImage = &infrav1.InstallImage{
+			Image:      infrav1.Image{URL: "https://example.com/ubuntu-22.04.tar.gz"},
+			Partitions: []infrav1.Partition{{Mount: "/", FileSystem: "ext4", Size: "all"}},
+		}
+		host.Spec.Status.InstallImage = &infrav1.InstallImage{
+			Image:             infrav1.Image{URL: "https://example.com/ubuntu-24.04.tar.gz"},
+			Partitions:        []infrav1.Partition{{Mount: "/", FileSystem: "ext4", Size: "all"}},
+			PostInstallScript: "echo changed",
+		}
+		host.Spec.ReprovisionApproval = infrav1.ReprovisionApprovalRequired
+		service = newTestService(host, nil, nil, nil, nil)
+	})
+
+	It("waits for the approval and reports the changes", func() {
+		Expect(service.waitForReprovisionApproval()).To(Equal(actionContinue{delay: reprovisionApprovalDelay}))
+		Expect(host.Spec.Status.PendingReprovision).To(Equal([]infrav1.ReprovisionChange{{
+			Field:     "image.url",
+			Installed: "https://example.com/ubuntu-22.04.tar.gz",
+			Desired:   "https://example.com/ubuntu-24.04.tar.gz",
+		}}))
+
+		condition := conditions.Get(host, infrav1.ReprovisionApprovedCondition)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(infrav1.ReprovisionApprovalRequiredReason))
+	})
+
+	It("consumes the approval annotation", func() {
+		Expect(service.waitForReprovisionApproval()).ToNot(BeNil())
+		host.Annotations = map[string]string{infrav1.ApproveReprovisionAnnotation: ""}
+
+		Expect(service.waitForReprovisionApproval()).To(BeNil())
+		Expect(host.ReprovisionApproved()).To(BeFalse())
+		Expect(host.Spec.Status.PendingReprovision).To(BeEmpty())
+		Expect(conditions.Get(host, infrav1.ReprovisionApprovedCondition)).To(BeNil())
+	})
+
+	It("does not wait with approval Automatic", func() {
+		host.Spec.ReprovisionApproval = infrav1.ReprovisionApprovalAutomatic
+		Expect(service.waitForReprovisionApproval()).To(BeNil())
+		Expect(host.Spec.Status.PendingReprovision).To(BeEmpty())
+	})
+
+	It("does not wait if only the post install script changes", func() {
+		host.Spec.Status.InstallImage.Image = host.Spec.Status.InstalledImage.Image
+		Expect(service.waitForReprovisionApproval()).To(BeNil())
+	})
+
+	It("does not wait if nothing has been installed yet", func() {
+		host.Spec.Status.InstalledImage = nil
+		Expect(service.waitForReprovisionApproval()).To(BeNil())
+	})
+
+	It("compares the installed image with the rendered desired image", func() {
+		host.Spec.Status.KubernetesVersion = "v1.25.5"
+		host.Spec.Status.InstalledImage.Image.URL = "https://example.com/ubuntu-v1.25.5.tar.gz"
+		host.Spec.Status.InstallImage.Image.URL = "https://example.com/ubuntu-{{ .KubernetesVersion }}.tar.gz"
+		Expect(service.waitForReprovisionApproval()).To(BeNil())
+
+		host.Spec.Status.KubernetesVersion = "v1.26.0"
+		Expect(service.waitForReprovisionApproval()).ToNot(BeNil())
+		Expect(host.Spec.Status.PendingReprovision).To(Equal([]infrav1.ReprovisionChange{{
+			Field:     "image.url",
+			Installed: "https://example.com/ubuntu-v1.25.5.tar.gz",
+			Desired:   "https://example.com/ubuntu-v1.26.0.tar.gz",
+		}}))
+	})
+
+	It("checks a provisioned host without consuming the approval", func() {
+		Expect(service.checkReprovisionApproval()).To(Equal(actionContinue{delay: reprovisionApprovalDelay}))
+		Expect(host.Spec.Status.PendingReprovision).To(HaveLen(1))
+
+		host.Annotations = map[string]string{infrav1.ApproveReprovisionAnnotation: ""}
+		Expect(service.checkReprovisionApproval()).To(BeNil())
+		Expect(host.ReprovisionApproved()).To(BeTrue())
+	})
+})
+
+var _ = Describe("reprovisionChanges", func() {
+	It("does not differ between nil and empty lists", func() {
+		installed := &infrav1.InstallImage{Image: infrav1.Image{Path: "/root/.oldroot/nfs/images/Ubuntu-2204.tar.gz"}}
+		desired := installed.DeepCopy()
+		desired.LVMDefinitions = []infrav1.LVMDefinition{}
+		Expect(reprovisionChanges(installed, desired)).To(BeEmpty())
+	})
+
+	It("reports changed partitions", func() {
+		installed := &infrav1.InstallImage{Partitions: []infrav1.Partition{{Mount: "/", FileSystem: "ext4", Size: "all"}}}
+		desired := &infrav1.InstallImage{Partitions: []infrav1.Partition{
+			{Mount: "/boot", FileSystem: "ext4", Size: "1024M"},
+			{Mount: "/", FileSystem: "ext4", Size: "all"},
+		}}
+		changes := reprovisionChanges(installed, desired)
+		Expect(changes).To(HaveLen(1))
+		Expect(changes[0].Field).To(Equal("partitions"))
+		Expect(changes[0].Desired).To(ContainSubstring("/boot"))
+	})
+})
+
+var _ = Describe("provisioning firewall", func() {
+	var (
+		service   *Service
+		robotMock *robotmock.Client
+		userRule  robotclient.FirewallRule
+	)
+
+	// mockFirewall lets the mock behave like the Robot firewall of the host, which is changed by SetFirewall.
+	mockFirewall := func(firewall *robotclient.Firewall) {
+		robotMock.On("GetFirewall", bareMetalHostID).Return(func(int) *robotclient.Firewall {
+			current := *firewall
+			current.Rules.Input = append([]robotclient.FirewallRule{}, firewall.Rules.Input...)
+			return &current
+		}, nil)
+		robotMock.On("SetFirewall", bareMetalHostID, mock.Anything).Run(func(args mock.Arguments) {
+			*firewall = *args.Get(1).(*robotclient.Firewall)
+		}).Return(nil, nil)
+	}
+
+	BeforeEach(func() {
+		host := helpers.BareMetalHost("host", "default")
+		host.Spec.Status.SSHSpec = &infrav1.SSHSpec{PortAfterInstallImage: 22, PortAfterCloudInit: 2222}
+		robotMock = &robotmock.Client{}
+		service = newTestService(host, robotMock, nil, nil, nil)
+		service.scope.HetznerCluster.Spec.ProvisioningFirewall = &infrav1.ProvisioningFirewall{SourceIPs: []string{"1.2.3.4/32"}}
+		userRule = robotclient.FirewallRule{Name: "allow https", DstPort: "443", Action: "accept"}
+	})
+
+	It("adds the rules in front of the rules of an active firewall and waits for Robot", func() {
+		firewall := &robotclient.Firewall{
+			Status: robotclient.FirewallStatusActive,
+			Rules:  robotclient.FirewallRules{Input: []robotclient.FirewallRule{userRule}},
+		}
+		mockFirewall(firewall)
+
+		Expect(service.ensureProvisioningFirewallRules()).To(Equal(actionContinue{delay: provisioningFirewallDelay}))
+
+		Expect(firewall.Rules.Input).To(HaveLen(3))
+		Expect(firewall.Rules.Input[0].SrcIP).To(Equal("1.2.3.4/32"))
+		Expect(firewall.Rules.Input[0].DstPort).To(Equal("22"))
+		Expect(firewall.Rules.Input[1].DstPort).To(Equal("2222"))
+		Expect(firewall.Rules.Input[2]).To(Equal(userRule))
+		Expect(conditions.IsFalse(service.scope.HetznerBareMetalHost, infrav1.ProvisioningFirewallRulesCondition)).To(BeTrue())
+	})
+
+	It("continues once the rules are applied and removes them afterwards", func() {
+		rules := provisioningFirewallRules(service.scope.HetznerCluster.Spec.ProvisioningFirewall, service.scope.HetznerBareMetalHost.Spec.Status.SSHSpec)
+		firewall := &robotclient.Firewall{
+			Status: robotclient.FirewallStatusActive,
+			Rules:  robotclient.FirewallRules{Input: append(rules, userRule)},
+		}
+		mockFirewall(firewall)
+
+		Expect(service.ensureProvisioningFirewallRules()).To(BeNil())
+		Expect(conditions.IsTrue(service.scope.HetznerBareMetalHost, infrav1.ProvisioningFirewallRulesCondition)).To(BeTrue())
+
+		Expect(service.removeProvisioningFirewallRules()).To(BeNil())
+		Expect(firewall.Rules.Input).To(Equal([]robotclient.FirewallRule{userRule}))
+		Expect(conditions.Get(service.scope.HetznerBareMetalHost, infrav1.ProvisioningFirewallRulesCondition)).To(BeNil())
+	})
+
+	It("keeps rules of the user with the prefix of the provisioning firewall", func() {
+		userProvisioningRule := robotclient.FirewallRule{
+			Name:     provisioningFirewallRulePrefix + "-office",
+			SrcIP:    "5.6.7.8/32",
+			DstPort:  "22",
+			Protocol: "tcp",
+			Action:   "accept",
+		}
+		firewall := &robotclient.Firewall{
+			Status: robotclient.FirewallStatusActive,
+			Rules:  robotclient.FirewallRules{Input: []robotclient.FirewallRule{userProvisioningRule, userRule}},
+		}
+		mockFirewall(firewall)
+
+		Expect(service.ensureProvisioningFirewallRules()).To(Equal(actionContinue{delay: provisioningFirewallDelay}))
+		Expect(firewall.Rules.Input).To(HaveLen(4))
+
+		Expect(service.removeProvisioningFirewallRules()).To(BeNil())
+		Expect(firewall.Rules.Input).To(Equal([]robotclient.FirewallRule{userProvisioningRule, userRule}))
+	})
+
+	It("does not change a disabled firewall", func() {
+		robotMock.On("GetFirewall", bareMetalHostID).Return(&robotclient.Firewall{Status: robotclient.FirewallStatusDisabled}, nil)
+
+		Expect(service.ensureProvisioningFirewallRules()).To(BeNil())
+		Expect(service.removeProvisioningFirewallRules()).To(BeNil())
+		robotMock.AssertNotCalled(GinkgoT(), "SetFirewall", mock.Anything, mock.Anything)
+	})
+
+	It("fails if the firewall cannot take the rules", func() {
+		input := make([]robotclient.FirewallRule, maxFirewallInputRules-1)
+		robotMock.On("GetFirewall", bareMetalHostID).Return(&robotclient.Firewall{
+			Status: robotclient.FirewallStatusActive,
+			Rules:  robotclient.FirewallRules{Input: input},
+		}, nil)
+
+		Expect(service.ensureProvisioningFirewallRules()).To(BeAssignableToTypeOf(actionError{}))
+		condition := conditions.Get(service.scope.HetznerBareMetalHost, infrav1.ProvisioningFirewallRulesCondition)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Reason).To(Equal(infrav1.FirewallRuleLimitReachedReason))
+	})
+})
+
+var _ = DescribeTable("serverIPv6",
+	func(subnet, expectedIP string) {
+		Expect(serverIPv6(subnet)).To(Equal(expectedIP))
+	},
+	Entry("subnet", "2a01:4f8:111:4221::", "2a01:4f8:111:4221::1"),
+	Entry("no subnet", "", ""),
+	Entry("IPv4", "1.2.3.4", ""),
+)
+
+var _ = Describe("reconcileConsoleUser", func() {
+	const passwordHash = "$6$salt$hash"
+	var (
+		service *Service
+		sshMock *sshmock.Client
+	)
+
+	BeforeEach(func() {
+		host := helpers.BareMetalHost("test-host", "default")
+		host.Spec.Status.AppliedConfiguration = &infrav1.AppliedConfiguration{
+			ConsoleUser: &infrav1.AppliedConsoleUser{Name: "console", PasswordHash: utils.SHA256Hash([]byte(passwordHash))},
+		}
+		sshMock = &sshmock.Client{}
+		service = newTestService(host, nil, nil, nil, nil)
+
+		scheme := runtime.NewScheme()
+		utilruntime.Must(infrav1.AddToScheme(scheme))
+		utilruntime.Must(corev1.AddToScheme(scheme))
+		c := fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(host, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "console-user", Namespace: "default"},
+			Data:       map[string][]byte{infrav1.DefaultConsoleUserPasswordKey: []byte("$6$salt$changed\n")},
+		}).Build()
+		service.scope.Client = c
+		service.scope.SecretManager = secretutil.NewSecretManager(log, c, c)
+		service.scope.HetznerCluster.Namespace = "default"
+	})
+
+	It("removes the console user if it has been removed from the cluster", func() {
+		sshMock.On("RemoveConsoleUser", "console").Return(sshclient.Output{})
+
+		Expect(service.reconcileConsoleUser(sshMock)).To(Equal(actionComplete{}))
+		Expect(sshMock.AssertCalled(GinkgoT(), "RemoveConsoleUser", "console")).To(BeTrue())
+		Expect(service.scope.HetznerBareMetalHost.Spec.Status.AppliedConfiguration.ConsoleUser).To(BeNil())
+	})
+
+	It("updates a changed password", func() {
+		service.scope.HetznerCluster.Spec.ConsoleUser = &infrav1.ConsoleUserSpec{
+			PasswordSecretRef: infrav1.ConsoleUserPasswordSecretRef{Name: "console-user"},
+		}
+		sshMock.On("ConfigureConsoleUser", "console", "$6$salt$changed").Return(sshclient.Output{})
+
+		Expect(service.reconcileConsoleUser(sshMock)).To(Equal(actionComplete{}))
+		Expect(sshMock.AssertNotCalled(GinkgoT(), "RemoveConsoleUser", "console")).To(BeTrue())
+		Expect(service.scope.HetznerBareMetalHost.Spec.Status.AppliedConfiguration.ConsoleUser.PasswordHash).
+			To(Equal(utils.SHA256Hash([]byte("$6$salt$changed"))))
+		Expect(conditions.IsTrue(service.scope.HetznerBareMetalHost, infrav1.ConsoleUserInSyncCondition)).To(BeTrue())
+	})
+
+	It("keeps the condition false if the host cannot be updated", func() {
+		sshMock.On("RemoveConsoleUser", "console").Return(sshclient.Output{StdErr: "userdel: user console is currently used by process 1"})
+
+		_, isError := service.reconcileConsoleUser(sshMock).(actionError)
+		Expect(isError).To(BeTrue())
+		Expect(service.scope.HetznerBareMetalHost.Spec.Status.AppliedConfiguration.ConsoleUser).ToNot(BeNil())
+		Expect(conditions.GetReason(service.scope.HetznerBareMetalHost, infrav1.ConsoleUserInSyncCondition)).
+			To(Equal(infrav1.ConsoleUserChangedReason))
+	})
+})
+
+var _ = Describe("recordActionHistory", func() {
+	start := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	It("records polled actions in one entry", func() {
+		host := helpers.BareMetalHost("test-host", "default")
+
+		recordActionHistory(host, infrav1.StateRegistering, actionContinue{delay: 10 * time.Second}, nil, start)
+		recordActionHistory(host, infrav1.StateRegistering, actionError{err: errors.New("ssh timeout")}, errors.New("ssh timeout"), start.Add(time.Minute))
+		recordActionHistory(host, infrav1.StateRegistering, actionComplete{}, nil, start.Add(2*time.Minute))
+
+		Expect(host.Spec.Status.ActionHistory).To(HaveLen(1))
+		entry := host.Spec.Status.ActionHistory[0]
+		Expect(entry.State).To(Equal(infrav1.StateRegistering))
+		Expect(entry.Result).To(Equal(infrav1.ActionHistoryCompleted))
+		Expect(entry.Started.Time).To(Equal(start))
+		Expect(entry.Duration.Duration).To(Equal(2 * time.Minute))
+		Expect(entry.Errors).To(Equal(1))
+		Expect(entry.Message).To(Equal("ssh timeout"))
+	})
+
+	It("marks unfinished actions as interrupted", func() {
+		host := helpers.BareMetalHost("test-host", "default")
+
+		recordActionHistory(host, infrav1.StateProvisioning, actionContinue{}, nil, start)
+		recordActionHistory(host, infrav1.StateDeleting, deleteComplete{}, nil, start.Add(time.Minute))
+
+		Expect(host.Spec.Status.ActionHistory).To(HaveLen(2))
+		Expect(host.Spec.Status.ActionHistory[0].Result).To(Equal(infrav1.ActionHistoryInterrupted))
+		Expect(host.Spec.Status.ActionHistory[0].Finished).ToNot(BeNil())
+		Expect(host.Spec.Status.ActionHistory[1].State).To(Equal(infrav1.StateDeleting))
+		Expect(host.Spec.Status.ActionHistory[1].Result).To(Equal(infrav1.ActionHistoryCompleted))
+	})
+
+	It("records failed actions with their error type", func() {
+		host := helpers.BareMetalHost("test-host", "default")
+		host.Spec.Status.ErrorMessage = "no storage device found"
+
+		recordActionHistory(host, infrav1.StateRegistering, actionFailed{ErrorType: infrav1.RegistrationError}, nil, start)
+
+		entry := host.Spec.Status.ActionHistory[0]
+		Expect(entry.Result).To(Equal(infrav1.ActionHistoryFailed))
+		Expect(entry.Message).To(Equal("registration error: no storage device found"))
+	})
+
+	It("does not record completed actions again while the state stays the same", func() {
+		host := helpers.BareMetalHost("test-host", "default")
+
+		recordActionHistory(host, infrav1.StateProvisioned, actionComplete{}, nil, start)
+		recordActionHistory(host, infrav1.StateProvisioned, actionComplete{}, nil, start.Add(time.Minute))
+		Expect(host.Spec.Status.ActionHistory).To(HaveLen(1))
+		Expect(host.Spec.Status.ActionHistory[0].Finished.Time).To(Equal(start))
+
+		recordActionHistory(host, infrav1.StateProvisioned, actionContinue{}, nil, start.Add(2*time.Minute))
+		recordActionHistory(host, infrav1.StateProvisioned, actionComplete{}, nil, start.Add(3*time.Minute))
+		Expect(host.Spec.Status.ActionHistory).To(HaveLen(2))
+	})
+
+	It("keeps the last actions", func() {
+		host := helpers.BareMetalHost("test-host", "default")
+		oldHost := *host
+
+		states := []infrav1.ProvisioningState{infrav1.StateRegistering, infrav1.StateProvisioning}
+		for i := 0; i < maxActionHistory+5; i++ {
+			recordActionHistory(host, states[i%2], actionComplete{}, nil, start.Add(time.Duration(i)*time.Minute))
+		}
+
+		Expect(host.Spec.Status.ActionHistory).To(HaveLen(maxActionHistory))
+		Expect(host.Spec.Status.ActionHistory[0].Started.Time).To(Equal(start.Add(5 * time.Minute)))
+		Expect(oldHost.Spec.Status.ActionHistory).To(BeEmpty())
+	})
+})
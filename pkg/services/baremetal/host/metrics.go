@@ -0,0 +1,128 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package host
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/cluster-api/util/record"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// stateDurationBuckets range from 10 seconds to 4 hours, as installing an image can take a while.
+var stateDurationBuckets = []float64{10, 30, 60, 120, 300, 600, 900, 1200, 1500, 1800, 2700, 3600, 7200, 14400}
+
+var (
+	stateDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "caph_baremetal_host_provisioning_state_duration_seconds",
+		Help:    "Time that HetznerBareMetalHosts spent in a provisioning state before changing to the next one.",
+		Buckets: stateDurationBuckets,
+	}, []string{"state", "next_state"})
+
+	provisioningDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "caph_baremetal_host_provisioning_duration_seconds",
+		Help:    "Time from the start of the provisioning of HetznerBareMetalHosts until they are provisioned.",
+		Buckets: stateDurationBuckets,
+	})
+
+	trafficBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "caph_baremetal_host_traffic_bytes",
+		Help: "Traffic of HetznerBareMetalHosts in the current month as reported by Robot.",
+	}, []string{"namespace", "host", "direction"})
+
+	includedTrafficBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "caph_baremetal_host_included_traffic_bytes",
+		Help: "Monthly traffic that is included in the contract of HetznerBareMetalHosts with limited traffic.",
+	}, []string{"namespace", "host"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(stateDuration, provisioningDuration, trafficBytes, includedTrafficBytes)
+}
+
+// setTrafficMetrics exports the traffic in the status of the host.
+func setTrafficMetrics(host *infrav1.HetznerBareMetalHost) {
+	traffic := host.Spec.Status.Traffic
+	if traffic == nil {
+		return
+	}
+	trafficBytes.WithLabelValues(host.Namespace, host.Name, "in").Set(traffic.Inbound.AsApproximateFloat64())
+	trafficBytes.WithLabelValues(host.Namespace, host.Name, "out").Set(traffic.Outbound.AsApproximateFloat64())
+	if traffic.Included != nil {
+		includedTrafficBytes.WithLabelValues(host.Namespace, host.Name).Set(traffic.Included.AsApproximateFloat64())
+	} else {
+		includedTrafficBytes.DeleteLabelValues(host.Namespace, host.Name)
+	}
+}
+
+// DeleteTrafficMetrics removes the traffic metrics of a host that is deleted.
+func DeleteTrafficMetrics(host *infrav1.HetznerBareMetalHost) {
+	trafficBytes.DeletePartialMatch(prometheus.Labels{"namespace": host.Namespace, "host": host.Name})
+	includedTrafficBytes.DeleteLabelValues(host.Namespace, host.Name)
+}
+
+// SetProvisioningState changes the provisioning state of the host and records the time of the change.
+// The change is recorded as event and metric by RecordProvisioningStateChange once the host has been saved.
+func SetProvisioningState(host *infrav1.HetznerBareMetalHost, state infrav1.ProvisioningState) {
+	if host.Spec.Status.ProvisioningState == state {
+		return
+	}
+	now := metav1.Now()
+	if state == infrav1.StatePreparing {
+		host.Spec.Status.ProvisioningStarted = &now
+	}
+	host.Spec.Status.ProvisioningState = state
+	host.Spec.Status.ProvisioningStateChanged = &now
+}
+
+// RecordProvisioningStateChange records a changed provisioning state of a saved host as event and its
+// duration in the previous state as metric. The original host is the one that was read before the change.
+func RecordProvisioningStateChange(original, host *infrav1.HetznerBareMetalHost) {
+	oldState, state := original.Spec.Status.ProvisioningState, host.Spec.Status.ProvisioningState
+	if oldState == state {
+		return
+	}
+	changed := time.Now()
+	if host.Spec.Status.ProvisioningStateChanged != nil {
+		changed = host.Spec.Status.ProvisioningStateChanged.Time
+	}
+
+	if since := original.Spec.Status.ProvisioningStateChanged; since != nil {
+		duration := changed.Sub(since.Time)
+		stateDuration.WithLabelValues(stateLabel(oldState), stateLabel(state)).Observe(duration.Seconds())
+		record.Eventf(host, "ProvisioningStateChanged", "Provisioning state changed from %s to %s after %s",
+			stateLabel(oldState), stateLabel(state), duration.Round(time.Second))
+	} else {
+		record.Eventf(host, "ProvisioningStateChanged", "Provisioning state changed from %s to %s",
+			stateLabel(oldState), stateLabel(state))
+	}
+
+	if started := host.Spec.Status.ProvisioningStarted; state == infrav1.StateProvisioned && started != nil {
+		provisioningDuration.Observe(changed.Sub(started.Time).Seconds())
+	}
+}
+
+// stateLabel returns the name of the state that is used in events and metrics.
+func stateLabel(state infrav1.ProvisioningState) string {
+	if state == infrav1.StateNone {
+		return "none"
+	}
+	return string(state)
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package host
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	sshclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/baremetal/client/ssh"
+	"sigs.k8s.io/cluster-api/util/record"
+)
+
+// hasPostInstallSteps returns whether the image of the host has post-install steps.
+func hasPostInstallSteps(host *infrav1.HetznerBareMetalHost) bool {
+	return host.Spec.Status.InstallImage != nil && len(host.Spec.Status.InstallImage.PostInstallSteps) > 0
+}
+
+// actionPostInstalling runs the post-install steps of the image one after the other in the installed operating
+// system. A step is applied, the host is rebooted if the step requires it, and the step is verified. The progress
+// is recorded in the status, so that an interrupted step is continued in its phase.
+func (s *Service) actionPostInstalling() actionResult {
+	host := s.scope.HetznerBareMetalHost
+	var steps []infrav1.PostInstallStep
+	if host.Spec.Status.InstallImage != nil {
+		steps = host.Spec.Status.InstallImage.PostInstallSteps
+	}
+
+	progress := host.Spec.Status.PostInstallStep
+	if progress == nil {
+		progress = &infrav1.PostInstallStepStatus{Phase: infrav1.PostInstallPhaseApplying}
+		host.Spec.Status.PostInstallStep = progress
+	}
+	if progress.Index >= len(steps) {
+		host.Spec.Status.PostInstallStep = nil
+		return actionComplete{}
+	}
+	step := steps[progress.Index]
+	progress.Name = step.Name
+
+	sshClient := s.osSSHClient(host.Spec.Status.SSHSpec.PortAfterInstallImage)
+	if actResult := s.ensureInstalledOSBooted(sshClient); actResult != nil {
+		return actResult
+	}
+
+	switch progress.Phase {
+	case infrav1.PostInstallPhaseApplying:
+		return s.applyPostInstallStep(sshClient, step, progress)
+	case infrav1.PostInstallPhaseRebooting:
+		return s.awaitPostInstallReboot(sshClient, progress)
+	default:
+		return s.verifyPostInstallStep(sshClient, steps, progress)
+	}
+}
+
+// applyPostInstallStep runs the script of the step and reboots the host if the step requires it. The boot ID is
+// recorded before, as the host is still reachable for a moment after the reboot has been triggered.
+func (s *Service) applyPostInstallStep(sshClient sshclient.Client, step infrav1.PostInstallStep, progress *infrav1.PostInstallStepStatus) actionResult {
+	var bootID string
+	if step.RebootRequired {
+		out := sshClient.GetBootID()
+		if err := handleSSHError(out); err != nil {
+			return actionError{err: errors.Wrap(err, "failed to get boot ID")}
+		}
+		bootID = trimLineBreak(out.StdOut)
+	}
+
+	if out := sshClient.RunScript(step.Script); out.Err != nil {
+		return s.recordPostInstallStepFailure(step, "failed", out)
+	}
+
+	if !step.RebootRequired {
+		progress.Phase = infrav1.PostInstallPhaseVerifying
+		return actionContinue{}
+	}
+
+	if err := handleSSHError(sshClient.Reboot()); err != nil {
+		return actionError{err: errors.Wrap(err, "failed to reboot")}
+	}
+	progress.Phase = infrav1.PostInstallPhaseRebooting
+	progress.BootID = bootID
+	record.Eventf(s.scope.HetznerBareMetalHost, "PostInstallStepRebooting", "Rebooting after post-install step %s", step.Name)
+	return actionContinue{delay: 10 * time.Second}
+}
+
+// awaitPostInstallReboot waits until the installed operating system has been booted again. A reboot that does
+// not start is handled like one that is too slow, escalating to a reboot via the Robot API.
+func (s *Service) awaitPostInstallReboot(sshClient sshclient.Client, progress *infrav1.PostInstallStepStatus) actionResult {
+	out := sshClient.GetBootID()
+	if err := handleSSHError(out); err != nil {
+		return actionError{err: errors.Wrap(err, "failed to get boot ID")}
+	}
+
+	if trimLineBreak(out.StdOut) == progress.BootID {
+		if err := s.handleIncompleteBootError(false, true, false); err != nil {
+			return actionError{err: errors.Wrap(err, "failed to handle incomplete boot")}
+		}
+		return actionContinue{delay: 10 * time.Second}
+	}
+
+	s.scope.SetErrorCount(0)
+	clearError(s.scope.HetznerBareMetalHost)
+	progress.Phase = infrav1.PostInstallPhaseVerifying
+	progress.BootID = ""
+	return actionContinue{}
+}
+
+// verifyPostInstallStep runs the verify script of the step and continues with the next step.
+func (s *Service) verifyPostInstallStep(sshClient sshclient.Client, steps []infrav1.PostInstallStep, progress *infrav1.PostInstallStepStatus) actionResult {
+	step := steps[progress.Index]
+	if step.VerifyScript != "" {
+		if out := sshClient.RunScript(step.VerifyScript); out.Err != nil {
+			return s.recordPostInstallStepFailure(step, "could not be verified", out)
+		}
+	}
+
+	record.Eventf(s.scope.HetznerBareMetalHost, "PostInstallStepCompleted", "Completed post-install step %s", step.Name)
+	next := progress.Index + 1
+	if next >= len(steps) {
+		s.scope.HetznerBareMetalHost.Spec.Status.PostInstallStep = nil
+		return actionComplete{}
+	}
+	*progress = infrav1.PostInstallStepStatus{Index: next, Name: steps[next].Name, Phase: infrav1.PostInstallPhaseApplying}
+	return actionContinue{}
+}
+
+func (s *Service) recordPostInstallStepFailure(step infrav1.PostInstallStep, what string, out sshclient.Output) actionResult {
+	msg := fmt.Sprintf("post-install step %s %s: %s", step.Name, what, out.Err.Error())
+	if output := trimLineBreak(out.StdOut); output != "" {
+		msg += ": " + output
+	}
+	record.Warn(s.scope.HetznerBareMetalHost, "PostInstallStepFailed", msg)
+	return s.recordActionFailure(infrav1.ProvisioningError, msg)
+}
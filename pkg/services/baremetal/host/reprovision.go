@@ -0,0 +1,177 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package host
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/record"
+)
+
+// reprovisionApprovalDelay is the interval in which a host that waits for the approval of its reprovisioning
+// checks again.
+const reprovisionApprovalDelay = 30 * time.Second
+
+// waitForReprovisionApproval returns actionContinue if the image or partitioning of the host would change and the
+// reprovisioning has not been approved yet, and nil if the host can be provisioned. An approval is consumed.
+func (s *Service) waitForReprovisionApproval() actionResult {
+	host := s.scope.HetznerBareMetalHost
+	changes, err := s.reprovisionChanges()
+	if err != nil {
+		return s.recordActionFailure(infrav1.PreparationError, err.Error())
+	}
+	if len(changes) == 0 {
+		clearPendingReprovision(host)
+		return nil
+	}
+
+	if actResult := requireReprovisionApproval(host, changes); actResult != nil {
+		return actResult
+	}
+
+	summary := summarizeReprovisionChanges(changes)
+	if host.ReprovisionApproved() {
+		delete(host.Annotations, infrav1.ApproveReprovisionAnnotation)
+		record.Eventf(host, "ReprovisionApproved", "Reimaging the host has been approved and changes %s", summary)
+	} else {
+		record.Eventf(host, "Reprovisioning", "Reimaging the host changes %s", summary)
+	}
+	clearPendingReprovision(host)
+	return nil
+}
+
+// checkReprovisionApproval returns actionContinue if a provisioned host would change its image or partitioning
+// when it is provisioned again and the reprovisioning has not been approved yet. It is checked before the host is
+// deprovisioned, so that it stays part of the cluster while it waits. An approval is not consumed, as
+// waitForReprovisionApproval still has to see it.
+func (s *Service) checkReprovisionApproval() actionResult {
+	host := s.scope.HetznerBareMetalHost
+	changes, err := s.reprovisionChanges()
+	if err != nil {
+		return actionError{err: err}
+	}
+	if len(changes) == 0 {
+		clearPendingReprovision(host)
+		return nil
+	}
+	return requireReprovisionApproval(host, changes)
+}
+
+// requireReprovisionApproval returns actionContinue and reports the changes if they have to be approved and the
+// approval is missing.
+func requireReprovisionApproval(host *infrav1.HetznerBareMetalHost, changes []infrav1.ReprovisionChange) actionResult {
+	if host.Spec.ReprovisionApproval != infrav1.ReprovisionApprovalRequired || host.ReprovisionApproved() {
+		return nil
+	}
+
+	summary := summarizeReprovisionChanges(changes)
+	if !reflect.DeepEqual(host.Spec.Status.PendingReprovision, changes) {
+		record.Warnf(host, "ReprovisionApprovalRequired",
+			"Reimaging the host changes %s. Approve it with the annotation %s", summary, infrav1.ApproveReprovisionAnnotation)
+	}
+	host.Spec.Status.PendingReprovision = changes
+	conditions.MarkFalse(
+		host,
+		infrav1.ReprovisionApprovedCondition,
+		infrav1.ReprovisionApprovalRequiredReason,
+		clusterv1.ConditionSeverityWarning,
+		"reimaging the host changes %s and has to be approved with the annotation %s",
+		summary, infrav1.ApproveReprovisionAnnotation,
+	)
+	return actionContinue{delay: reprovisionApprovalDelay}
+}
+
+// reprovisionChanges returns the changes between the installed image and the desired one. The desired image is
+// rendered with the current variables, as the installed image has been recorded after rendering.
+func (s *Service) reprovisionChanges() ([]infrav1.ReprovisionChange, error) {
+	host := s.scope.HetznerBareMetalHost
+	if host.Spec.Status.InstalledImage == nil || host.Spec.Status.InstallImage == nil {
+		return nil, nil
+	}
+	desired, err := renderInstallImage(*host.Spec.Status.InstallImage, s.installImageVariables())
+	if err != nil {
+		return nil, err
+	}
+	return reprovisionChanges(host.Spec.Status.InstalledImage, &desired), nil
+}
+
+func clearPendingReprovision(host *infrav1.HetznerBareMetalHost) {
+	host.Spec.Status.PendingReprovision = nil
+	conditions.Delete(host, infrav1.ReprovisionApprovedCondition)
+}
+
+// setInstalledImage records the rendered image and partitioning that are installed on the host. The post install
+// script is not recorded, as changing it does not change the disks.
+func setInstalledImage(host *infrav1.HetznerBareMetalHost, installImage *infrav1.InstallImage) {
+	installed := installImage.DeepCopy()
+	installed.PostInstallScript = ""
+	host.Spec.Status.InstalledImage = installed
+}
+
+// reprovisionChanges returns the changes between the installed image and partitioning and the desired ones. There
+// are no changes if nothing has been installed by the controller yet.
+func reprovisionChanges(installed, desired *infrav1.InstallImage) []infrav1.ReprovisionChange {
+	if installed == nil || desired == nil {
+		return nil
+	}
+
+	var changes []infrav1.ReprovisionChange
+	add := func(field, installedValue, desiredValue string) {
+		if installedValue != desiredValue {
+			changes = append(changes, infrav1.ReprovisionChange{Field: field, Installed: installedValue, Desired: desiredValue})
+		}
+	}
+	add("image.url", installed.Image.URL, desired.Image.URL)
+	add("image.name", installed.Image.Name, desired.Image.Name)
+	add("image.path", installed.Image.Path, desired.Image.Path)
+	add("partitions", toJSON(installed.Partitions), toJSON(desired.Partitions))
+	add("logicalVolumeDefinitions", toJSON(installed.LVMDefinitions), toJSON(desired.LVMDefinitions))
+	add("btrfsDefinitions", toJSON(installed.BTRFSDefinitions), toJSON(desired.BTRFSDefinitions))
+	add("swraid", strconv.Itoa(installed.Swraid), strconv.Itoa(desired.Swraid))
+	add("swraidLevel", strconv.Itoa(installed.SwraidLevel), strconv.Itoa(desired.SwraidLevel))
+	add("swap", toJSON(installed.Swap), toJSON(desired.Swap))
+	return changes
+}
+
+func summarizeReprovisionChanges(changes []infrav1.ReprovisionChange) string {
+	summary := make([]string, 0, len(changes))
+	for _, change := range changes {
+		summary = append(summary, fmt.Sprintf("%s from %q to %q", change.Field, change.Installed, change.Desired))
+	}
+	return strings.Join(summary, ", ")
+}
+
+// toJSON returns the JSON of empty values as the empty string, so that nil and empty lists do not differ.
+func toJSON(v interface{}) string {
+	value := reflect.ValueOf(v)
+	if value.IsZero() || (value.Kind() == reflect.Slice && value.Len() == 0) {
+		return ""
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -19,13 +19,21 @@ package host
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/utils"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/record"
 )
 
+// deprovisionApprovalDelay is the interval in which a host whose installImage has been removed checks again whether
+// its deprovisioning has been confirmed.
+const deprovisionApprovalDelay = 30 * time.Second
+
 // hostStateMachine is a finite state machine that manages transitions between
 // the states of a BareMetalHost.
 type hostStateMachine struct {
@@ -48,12 +56,13 @@ func newHostStateMachine(host *infrav1.HetznerBareMetalHost, reconciler *Service
 
 type stateHandler func() actionResult
 
-func (hsm *hostStateMachine) handlers() map[infrav1.ProvisioningState]stateHandler {
+func (hsm *hostStateMachine) handlers(ctx context.Context) map[infrav1.ProvisioningState]stateHandler {
 	return map[infrav1.ProvisioningState]stateHandler{
 		infrav1.StatePreparing:         hsm.handlePreparing,
 		infrav1.StateRegistering:       hsm.handleRegistering,
 		infrav1.StateImageInstalling:   hsm.handleImageInstalling,
-		infrav1.StateProvisioning:      hsm.handleProvisioning,
+		infrav1.StatePostInstalling:    hsm.handlePostInstalling,
+		infrav1.StateProvisioning:      func() actionResult { return hsm.handleProvisioning(ctx) },
 		infrav1.StateEnsureProvisioned: hsm.handleEnsureProvisioned,
 		infrav1.StateProvisioned:       hsm.handleProvisioned,
 		infrav1.StateDeprovisioning:    hsm.handleDeprovisioning,
@@ -66,7 +75,7 @@ func (hsm *hostStateMachine) ReconcileState(ctx context.Context) (actionRes acti
 	defer func() {
 		if hsm.nextState != initialState {
 			hsm.log.Info("changing provisioning state", "old", initialState, "new", hsm.nextState)
-			hsm.host.Spec.Status.ProvisioningState = hsm.nextState
+			SetProvisioningState(hsm.host, hsm.nextState)
 		}
 	}()
 
@@ -75,17 +84,46 @@ func (hsm *hostStateMachine) ReconcileState(ctx context.Context) (actionRes acti
 		return actionComplete{}
 	}
 
+	if actResult := hsm.fence(); actResult != nil {
+		return actResult
+	}
+
 	actResult := hsm.updateSSHKey()
 	if _, complete := actResult.(actionComplete); !complete {
 		return actResult
 	}
 
-	if stateHandler, found := hsm.handlers()[initialState]; found {
+	actResult = hsm.updateUserData(ctx)
+	if _, complete := actResult.(actionComplete); !complete {
+		return actResult
+	}
+
+	if stateHandler, found := hsm.handlers(ctx)[initialState]; found {
 		return stateHandler()
 	}
 
 	hsm.log.Info("No handler found for state", "state", initialState)
-	return actionError{fmt.Errorf("no handler found for state \"%s\"", initialState)}
+	return actionError{err: fmt.Errorf("no handler found for state \"%s\"", initialState)}
+}
+
+// fence keeps a host with the fence annotation powered off in every state, so that no state handler or update of
+// the user data powers it on again. Hosts are only fenced until they are deprovisioned. It returns nil if the host
+// is not fenced.
+func (hsm *hostStateMachine) fence() actionResult {
+	if _, fence := hsm.host.Annotations[infrav1.FenceAnnotation]; !fence {
+		return nil
+	}
+	switch hsm.host.Spec.Status.ProvisioningState {
+	case infrav1.StateDeprovisioning, infrav1.StateDeleting:
+		return nil
+	}
+
+	actResult := hsm.reconciler.fence()
+	if _, complete := actResult.(actionComplete); complete {
+		// Nothing to do until the host is released
+		return actionContinue{delay: time.Minute}
+	}
+	return actResult
 }
 
 func (hsm *hostStateMachine) checkInitiateDelete() bool {
@@ -97,7 +135,7 @@ func (hsm *hostStateMachine) checkInitiateDelete() bool {
 	switch hsm.nextState {
 	default:
 		hsm.nextState = infrav1.StateDeleting
-	case infrav1.StateRegistering, infrav1.StateImageInstalling, infrav1.StateProvisioning,
+	case infrav1.StateRegistering, infrav1.StateImageInstalling, infrav1.StatePostInstalling, infrav1.StateProvisioning,
 		infrav1.StateEnsureProvisioned, infrav1.StateProvisioned:
 		hsm.nextState = infrav1.StateDeprovisioning
 	case infrav1.StateDeprovisioning:
@@ -124,7 +162,7 @@ func (hsm *hostStateMachine) updateSSHKey() actionResult {
 		if !hsm.host.Spec.Status.SSHStatus.CurrentOS.Match(*osSSHSecret) {
 			// Take action depending on state
 			switch hsm.nextState {
-			case infrav1.StateProvisioning, infrav1.StateEnsureProvisioned:
+			case infrav1.StatePostInstalling, infrav1.StateProvisioning, infrav1.StateEnsureProvisioned:
 				// Go back to StateImageInstalling as we need to provision again
 				hsm.nextState = infrav1.StateImageInstalling
 			case infrav1.StateProvisioned:
@@ -163,11 +201,59 @@ func (hsm *hostStateMachine) updateSSHKey() actionResult {
 	return actionComplete{}
 }
 
-func (hsm *hostStateMachine) handlePreparing() actionResult {
-	if hsm.provisioningCancelled() {
+func (hsm *hostStateMachine) updateUserData(ctx context.Context) actionResult {
+	// Before the user data has been written to the host, the latest one is used anyway
+	if hsm.host.Spec.Status.UserDataHash == "" {
+		return actionComplete{}
+	}
+	if hsm.nextState != infrav1.StateEnsureProvisioned && hsm.nextState != infrav1.StateProvisioned {
+		return actionComplete{}
+	}
+
+	userData, err := hsm.reconciler.scope.GetRawBootstrapData(ctx)
+	if err != nil {
+		return actionError{err: errors.Wrap(err, "failed to get user data")}
+	}
+	if utils.SHA256Hash(userData) == hsm.host.Spec.Status.UserDataHash {
+		return actionComplete{}
+	}
+
+	// Take action depending on policy and state
+	switch hsm.host.Spec.Status.BootstrapDataChangePolicy {
+	case infrav1.BootstrapDataChangePolicyReprovision:
+		// The host is deprovisioned first, as it is still part of the cluster. As it keeps its consumer and
+		// installImage, it is prepared again afterwards. A reimaging that has to be approved is waited for
+		// before, so that the host stays provisioned in the meantime.
+		if actResult := hsm.reconciler.checkReprovisionApproval(); actResult != nil {
+			return actResult
+		}
+		record.Event(hsm.host, "BootstrapDataChanged", "bootstrap data has changed - deprovisioning host to provision it again")
+		hsm.host.Spec.Status.UserDataHash = ""
 		hsm.nextState = infrav1.StateDeprovisioning
+		return actionContinue{}
+	case infrav1.BootstrapDataChangePolicyRerunUserData:
+		// Let cloud init finish with the current user data first
+		if hsm.nextState == infrav1.StateEnsureProvisioned {
+			return actionComplete{}
+		}
+		actResult := hsm.reconciler.actionRerunUserData(ctx, userData)
+		if _, complete := actResult.(actionComplete); !complete {
+			return actResult
+		}
+		record.Event(hsm.host, "BootstrapDataChanged", "bootstrap data has changed - running cloud init again")
+		hsm.nextState = infrav1.StateEnsureProvisioned
+		return actionContinue{delay: 10 * time.Second}
+	default:
+		record.Warn(hsm.host, "BootstrapDataChanged", "bootstrap data has changed but is ignored as the host has been provisioned already")
+		hsm.host.Spec.Status.UserDataHash = utils.SHA256Hash(userData)
 		return actionComplete{}
 	}
+}
+
+func (hsm *hostStateMachine) handlePreparing() actionResult {
+	if actResult := hsm.cancelProvisioning(); actResult != nil {
+		return actResult
+	}
 	actResult := hsm.reconciler.actionPreparing()
 	if _, ok := actResult.(actionComplete); ok {
 		hsm.nextState = infrav1.StateRegistering
@@ -176,9 +262,8 @@ func (hsm *hostStateMachine) handlePreparing() actionResult {
 }
 
 func (hsm *hostStateMachine) handleRegistering() actionResult {
-	if hsm.provisioningCancelled() {
-		hsm.nextState = infrav1.StateDeprovisioning
-		return actionComplete{}
+	if actResult := hsm.cancelProvisioning(); actResult != nil {
+		return actResult
 	}
 
 	actResult := hsm.reconciler.actionRegistering()
@@ -189,25 +274,39 @@ func (hsm *hostStateMachine) handleRegistering() actionResult {
 }
 
 func (hsm *hostStateMachine) handleImageInstalling() actionResult {
-	if hsm.provisioningCancelled() {
-		hsm.nextState = infrav1.StateDeprovisioning
-		return actionComplete{}
+	if actResult := hsm.cancelProvisioning(); actResult != nil {
+		return actResult
 	}
 
 	actResult := hsm.reconciler.actionImageInstalling()
 	if _, ok := actResult.(actionComplete); ok {
+		hsm.host.Spec.Status.PostInstallStep = nil
 		hsm.nextState = infrav1.StateProvisioning
+		if hasPostInstallSteps(hsm.host) {
+			hsm.nextState = infrav1.StatePostInstalling
+		}
 	}
 	return actResult
 }
 
-func (hsm *hostStateMachine) handleProvisioning() actionResult {
-	if hsm.provisioningCancelled() {
-		hsm.nextState = infrav1.StateDeprovisioning
-		return actionComplete{}
+func (hsm *hostStateMachine) handlePostInstalling() actionResult {
+	if actResult := hsm.cancelProvisioning(); actResult != nil {
+		return actResult
 	}
 
-	actResult := hsm.reconciler.actionProvisioning()
+	actResult := hsm.reconciler.actionPostInstalling()
+	if _, ok := actResult.(actionComplete); ok {
+		hsm.nextState = infrav1.StateProvisioning
+	}
+	return actResult
+}
+
+func (hsm *hostStateMachine) handleProvisioning(ctx context.Context) actionResult {
+	if actResult := hsm.cancelProvisioning(); actResult != nil {
+		return actResult
+	}
+
+	actResult := hsm.reconciler.actionProvisioning(ctx)
 	if _, ok := actResult.(actionComplete); ok {
 		hsm.nextState = infrav1.StateEnsureProvisioned
 	}
@@ -215,9 +314,8 @@ func (hsm *hostStateMachine) handleProvisioning() actionResult {
 }
 
 func (hsm *hostStateMachine) handleEnsureProvisioned() actionResult {
-	if hsm.provisioningCancelled() {
-		hsm.nextState = infrav1.StateDeprovisioning
-		return actionComplete{}
+	if actResult := hsm.cancelProvisioning(); actResult != nil {
+		return actResult
 	}
 
 	actResult := hsm.reconciler.actionEnsureProvisioned()
@@ -228,14 +326,17 @@ func (hsm *hostStateMachine) handleEnsureProvisioned() actionResult {
 }
 
 func (hsm *hostStateMachine) handleProvisioned() actionResult {
-	if hsm.provisioningCancelled() {
-		hsm.nextState = infrav1.StateDeprovisioning
-		return actionComplete{}
+	if actResult := hsm.cancelProvisioning(); actResult != nil {
+		return actResult
 	}
 	return hsm.reconciler.actionProvisioned()
 }
 
 func (hsm *hostStateMachine) handleDeprovisioning() actionResult {
+	if hsm.host.HasForensicHold() {
+		hsm.nextState = infrav1.StateQuarantined
+		return hsm.reconciler.actionQuarantine()
+	}
 	actResult := hsm.reconciler.actionDeprovisioning()
 	if _, ok := actResult.(actionComplete); ok {
 		hsm.nextState = infrav1.StateNone
@@ -248,6 +349,35 @@ func (hsm *hostStateMachine) handleDeleting() actionResult {
 	return hsm.reconciler.actionDeleting()
 }
 
-func (hsm *hostStateMachine) provisioningCancelled() bool {
-	return hsm.host.Spec.Status.InstallImage == nil
+// cancelProvisioning moves the host to the state deprovisioning if its installImage has been removed, which has
+// to be confirmed with an annotation. It returns nil if the host keeps its image.
+func (hsm *hostStateMachine) cancelProvisioning() actionResult {
+	host := hsm.host
+	if host.Spec.Status.InstallImage != nil {
+		conditions.Delete(host, infrav1.DeprovisionApprovedCondition)
+		return nil
+	}
+
+	if !host.DeprovisionApproved() {
+		if !conditions.IsFalse(host, infrav1.DeprovisionApprovedCondition) {
+			record.Warnf(host, "DeprovisionApprovalRequired",
+				"installImage has been removed. Restore it or confirm the deprovisioning with the annotation %s",
+				infrav1.ApproveDeprovisionAnnotation)
+		}
+		conditions.MarkFalse(
+			host,
+			infrav1.DeprovisionApprovedCondition,
+			infrav1.DeprovisionApprovalRequiredReason,
+			clusterv1.ConditionSeverityWarning,
+			"installImage has been removed in state %s. Deprovisioning the host has to be confirmed with the annotation %s",
+			hsm.nextState, infrav1.ApproveDeprovisionAnnotation,
+		)
+		return actionContinue{delay: deprovisionApprovalDelay}
+	}
+
+	delete(host.Annotations, infrav1.ApproveDeprovisionAnnotation)
+	conditions.Delete(host, infrav1.DeprovisionApprovedCondition)
+	record.Event(host, "DeprovisionApproved", "installImage has been removed - deprovisioning host")
+	hsm.nextState = infrav1.StateDeprovisioning
+	return actionComplete{}
 }
@@ -17,13 +17,25 @@ limitations under the License.
 package host
 
 import (
+	"context"
 	"fmt"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	secretutil "github.com/syself/cluster-api-provider-hetzner/pkg/secrets"
+	bmmock "github.com/syself/cluster-api-provider-hetzner/pkg/services/baremetal/client/mocks"
+	robotmock "github.com/syself/cluster-api-provider-hetzner/pkg/services/baremetal/client/mocks/robot"
+	sshmock "github.com/syself/cluster-api-provider-hetzner/pkg/services/baremetal/client/mocks/ssh"
+	sshclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/baremetal/client/ssh"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/utils"
 	"github.com/syself/cluster-api-provider-hetzner/test/helpers"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 var _ = Describe("updateSSHKey", func() {
@@ -258,3 +270,191 @@ var _ = Describe("updateSSHKey", func() {
 		),
 	)
 })
+
+var _ = Describe("updateUserData", func() {
+	const (
+		oldUserData = "old-user-data"
+		newUserData = "new-user-data"
+	)
+
+	DescribeTable("updateUserData",
+		func(
+			policy infrav1.BootstrapDataChangePolicy,
+			currentState infrav1.ProvisioningState,
+			userData string,
+			expectedActionResult actionResult,
+			expectedNextState infrav1.ProvisioningState,
+			expectedUserDataHash string,
+			expectsRerun bool,
+		) {
+			host := helpers.BareMetalHost(
+				"test-host",
+				"default",
+				helpers.WithSSHSpecInclPorts(23, 24),
+				helpers.WithIPv4(),
+			)
+			host.Spec.Status.ProvisioningState = currentState
+			host.Spec.Status.BootstrapDataChangePolicy = policy
+			host.Spec.Status.UserData = &corev1.SecretReference{Name: "bootstrap-data", Namespace: "default"}
+			host.Spec.Status.UserDataHash = utils.SHA256Hash([]byte(oldUserData))
+
+			sshMock := &sshmock.Client{}
+			sshMock.On("CreateUserData", userData).Return(sshclient.Output{})
+			sshMock.On("CleanCloudInitLogs").Return(sshclient.Output{})
+			sshMock.On("CleanCloudInitInstances").Return(sshclient.Output{})
+			sshMock.On("Reboot").Return(sshclient.Output{})
+
+			service := newTestService(host, nil, bmmock.NewSSHFactory(sshMock, sshMock, sshMock), helpers.GetDefaultSSHSecret(osSSHKeyName, "default"), nil)
+			scheme := runtime.NewScheme()
+			utilruntime.Must(infrav1.AddToScheme(scheme))
+			utilruntime.Must(corev1.AddToScheme(scheme))
+			c := fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(host, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "bootstrap-data", Namespace: "default"},
+				Data:       map[string][]byte{"value": []byte(userData)},
+			}).Build()
+			service.scope.Client = c
+			service.scope.SecretManager = secretutil.NewSecretManager(log, c, c)
+			hsm := newTestHostStateMachine(host, service)
+
+			actResult := hsm.updateUserData(context.Background())
+
+			Expect(actResult).Should(BeAssignableToTypeOf(expectedActionResult))
+			Expect(hsm.nextState).Should(Equal(expectedNextState))
+			Expect(host.Spec.Status.UserDataHash).Should(Equal(expectedUserDataHash))
+			if expectsRerun {
+				Expect(sshMock.AssertCalled(GinkgoT(), "CreateUserData", userData)).To(BeTrue())
+				Expect(sshMock.AssertCalled(GinkgoT(), "Reboot")).To(BeTrue())
+			} else {
+				Expect(sshMock.AssertNotCalled(GinkgoT(), "CreateUserData", userData)).To(BeTrue())
+			}
+		},
+		Entry("user data did not change",
+			infrav1.BootstrapDataChangePolicyReprovision, infrav1.StateProvisioned, oldUserData,
+			actionComplete{}, infrav1.StateProvisioned, utils.SHA256Hash([]byte(oldUserData)), false),
+		Entry("ignore changed user data",
+			infrav1.BootstrapDataChangePolicyIgnore, infrav1.StateProvisioned, newUserData,
+			actionComplete{}, infrav1.StateProvisioned, utils.SHA256Hash([]byte(newUserData)), false),
+		Entry("reprovision with changed user data",
+			infrav1.BootstrapDataChangePolicyReprovision, infrav1.StateProvisioned, newUserData,
+			actionContinue{}, infrav1.StateDeprovisioning, "", false),
+		Entry("reprovision with changed user data while cloud init is running",
+			infrav1.BootstrapDataChangePolicyReprovision, infrav1.StateEnsureProvisioned, newUserData,
+			actionContinue{}, infrav1.StateDeprovisioning, "", false),
+		Entry("rerun user data waits for cloud init to finish",
+			infrav1.BootstrapDataChangePolicyRerunUserData, infrav1.StateEnsureProvisioned, newUserData,
+			actionComplete{}, infrav1.StateEnsureProvisioned, utils.SHA256Hash([]byte(oldUserData)), false),
+		Entry("rerun user data on provisioned host",
+			infrav1.BootstrapDataChangePolicyRerunUserData, infrav1.StateProvisioned, newUserData,
+			actionContinue{}, infrav1.StateEnsureProvisioned, utils.SHA256Hash([]byte(newUserData)), true),
+		Entry("nothing to do before user data has been written",
+			infrav1.BootstrapDataChangePolicyReprovision, infrav1.StateImageInstalling, newUserData,
+			actionComplete{}, infrav1.StateImageInstalling, utils.SHA256Hash([]byte(oldUserData)), false),
+	)
+})
+
+var _ = Describe("updateUserData with a reimaging that has to be approved", func() {
+	It("keeps the host provisioned until the reprovisioning has been approved", func() {
+		host := helpers.BareMetalHost("test-host", "default")
+		host.Spec.Status.ProvisioningState = infrav1.StateProvisioned
+		host.Spec.Status.BootstrapDataChangePolicy = infrav1.BootstrapDataChangePolicyReprovision
+		host.Spec.Status.UserData = &corev1.SecretReference{Name: "bootstrap-data", Namespace: "default"}
+		host.Spec.Status.UserDataHash = utils.SHA256Hash([]byte("old-user-data"))
+		host.Spec.Status.InstalledImage = &infrav1.InstallImage{Image: infrav1.Image{URL: "https://example.com/ubuntu-22.04.tar.gz"}}
+		host.Spec.Status.InstallImage = &infrav1.InstallImage{Image: infrav1.Image{URL: "https://example.com/ubuntu-24.04.tar.gz"}}
+		host.Spec.ReprovisionApproval = infrav1.ReprovisionApprovalRequired
+
+		service := newTestService(host, nil, nil, nil, nil)
+		scheme := runtime.NewScheme()
+		utilruntime.Must(infrav1.AddToScheme(scheme))
+		utilruntime.Must(corev1.AddToScheme(scheme))
+		c := fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(host, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "bootstrap-data", Namespace: "default"},
+			Data:       map[string][]byte{"value": []byte("new-user-data")},
+		}).Build()
+		service.scope.Client = c
+		service.scope.SecretManager = secretutil.NewSecretManager(log, c, c)
+		hsm := newTestHostStateMachine(host, service)
+
+		Expect(hsm.updateUserData(context.Background())).To(Equal(actionContinue{delay: reprovisionApprovalDelay}))
+		Expect(hsm.nextState).To(Equal(infrav1.StateProvisioned))
+		Expect(host.Spec.Status.PendingReprovision).To(HaveLen(1))
+
+		host.Annotations = map[string]string{infrav1.ApproveReprovisionAnnotation: ""}
+		Expect(hsm.updateUserData(context.Background())).To(BeAssignableToTypeOf(actionContinue{}))
+		Expect(hsm.nextState).To(Equal(infrav1.StateDeprovisioning))
+		Expect(host.ReprovisionApproved()).To(BeTrue())
+	})
+})
+
+var _ = Describe("handleDeprovisioning", func() {
+	It("quarantines a host with forensic hold without connecting to it", func() {
+		host := helpers.BareMetalHost(
+			"test-host",
+			"default",
+			helpers.WithSSHSpecInclPorts(23, 24),
+			helpers.WithIPv4(),
+		)
+		host.Annotations = map[string]string{infrav1.ForensicHoldAnnotation: "incident 42"}
+		host.Spec.Status.ProvisioningState = infrav1.StateDeprovisioning
+		host.Spec.Status.ErrorCount = 2
+
+		sshMock := &sshmock.Client{}
+		service := newTestService(host, nil, bmmock.NewSSHFactory(sshMock, sshMock, sshMock), helpers.GetDefaultSSHSecret(osSSHKeyName, "default"), nil)
+		hsm := newTestHostStateMachine(host, service)
+
+		actResult := hsm.handleDeprovisioning()
+
+		Expect(actResult).Should(BeAssignableToTypeOf(actionComplete{}))
+		Expect(hsm.nextState).Should(Equal(infrav1.StateQuarantined))
+		Expect(host.Spec.Status.ErrorCount).Should(Equal(0))
+		Expect(sshMock.Calls).Should(BeEmpty())
+	})
+
+	It("deprovisions a host that has been released from its quarantine", func() {
+		host := helpers.BareMetalHost("test-host", "default")
+		host.Spec.Status.ProvisioningState = infrav1.StateDeprovisioning
+		host.Spec.ConsumerRef = nil
+
+		robotMock := &robotmock.Client{}
+		robotMock.On("SetBMServerName", bareMetalHostID, "test-host").Return(nil, nil)
+		service := newTestService(host, robotMock, nil, nil, nil)
+		hsm := newTestHostStateMachine(host, service)
+
+		Expect(hsm.handleDeprovisioning()).Should(BeAssignableToTypeOf(actionComplete{}))
+		Expect(hsm.nextState).Should(Equal(infrav1.StateNone))
+		robotMock.AssertCalled(GinkgoT(), "SetBMServerName", bareMetalHostID, "test-host")
+	})
+})
+
+var _ = Describe("cancelProvisioning", func() {
+	var host *infrav1.HetznerBareMetalHost
+	var hsm *hostStateMachine
+
+	BeforeEach(func() {
+		host = helpers.BareMetalHost("test-host", "default")
+		host.Spec.Status.ProvisioningState = infrav1.StateProvisioned
+		hsm = newTestHostStateMachine(host, newTestService(host, nil, nil, nil, nil))
+	})
+
+	It("does nothing while the host has an image", func() {
+		host.Spec.Status.InstallImage = &infrav1.InstallImage{}
+		Expect(hsm.cancelProvisioning()).To(BeNil())
+		Expect(hsm.nextState).To(Equal(infrav1.StateProvisioned))
+	})
+
+	It("waits for the confirmation if the image has been removed", func() {
+		Expect(hsm.cancelProvisioning()).To(BeAssignableToTypeOf(actionContinue{}))
+		Expect(hsm.nextState).To(Equal(infrav1.StateProvisioned))
+		Expect(conditions.GetReason(host, infrav1.DeprovisionApprovedCondition)).To(Equal(infrav1.DeprovisionApprovalRequiredReason))
+	})
+
+	It("deprovisions the host once the removal has been confirmed", func() {
+		Expect(hsm.cancelProvisioning()).To(BeAssignableToTypeOf(actionContinue{}))
+		host.Annotations = map[string]string{infrav1.ApproveDeprovisionAnnotation: ""}
+
+		Expect(hsm.cancelProvisioning()).To(BeAssignableToTypeOf(actionComplete{}))
+		Expect(hsm.nextState).To(Equal(infrav1.StateDeprovisioning))
+		Expect(host.Annotations).ToNot(HaveKey(infrav1.ApproveDeprovisionAnnotation))
+		Expect(conditions.Has(host, infrav1.DeprovisionApprovedCondition)).To(BeFalse())
+	})
+})
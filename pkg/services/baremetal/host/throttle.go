@@ -0,0 +1,120 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package host
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// provisioningSlotDelay is the interval in which a host that waits for a provisioning slot checks again.
+const provisioningSlotDelay = 30 * time.Second
+
+// provisioningOperation is a provisioning operation whose concurrency is limited per datacenter.
+type provisioningOperation struct {
+	name   string
+	reason string
+	limit  func(throttle *infrav1.ProvisioningThrottle, datacenter string) int
+	// inProgress returns whether the operation of the host is running.
+	inProgress func(host *infrav1.HetznerBareMetalHost) bool
+}
+
+// rescueOperation activates the rescue system and reboots into it. It runs until the host is registered.
+var rescueOperation = provisioningOperation{
+	name:   "rescue activations",
+	reason: infrav1.RescueLimitReachedReason,
+	limit:  (*infrav1.ProvisioningThrottle).RescueLimit,
+	inProgress: func(host *infrav1.HetznerBareMetalHost) bool {
+		return host.Spec.Status.ProvisioningState == infrav1.StateRegistering
+	},
+}
+
+// imagingOperation installs the image, runs its post-install steps and reboots into the installed operating
+// system. Hosts in state image-installing that wait for a slot are not counted.
+var imagingOperation = provisioningOperation{
+	name:   "imaging operations",
+	reason: infrav1.ImagingLimitReachedReason,
+	limit:  (*infrav1.ProvisioningThrottle).ImagingLimit,
+	inProgress: func(host *infrav1.HetznerBareMetalHost) bool {
+		switch host.Spec.Status.ProvisioningState {
+		case infrav1.StateImageInstalling:
+			return !conditions.IsFalse(host, infrav1.ProvisioningSlotAvailableCondition)
+		case infrav1.StatePostInstalling, infrav1.StateProvisioning:
+			return true
+		}
+		return false
+	},
+}
+
+// waitForProvisioningSlot returns actionContinue if the limit of the operation in the datacenter of the host is
+// reached, and nil if the operation can be started.
+func (s *Service) waitForProvisioningSlot(op provisioningOperation) actionResult {
+	host := s.scope.HetznerBareMetalHost
+	datacenter := host.Spec.Status.Datacenter
+	limit := op.limit(s.scope.HetznerCluster.Spec.ProvisioningThrottle, datacenter)
+	if limit == 0 || datacenter == "" {
+		conditions.Delete(host, infrav1.ProvisioningSlotAvailableCondition)
+		return nil
+	}
+
+	running, err := s.countOperationsInDatacenter(op, datacenter)
+	if err != nil {
+		return actionError{err: err}
+	}
+	if running >= limit {
+		conditions.MarkFalse(
+			host,
+			infrav1.ProvisioningSlotAvailableCondition,
+			op.reason,
+			clusterv1.ConditionSeverityInfo,
+			"%d of %d %s in datacenter %s are in progress",
+			running, limit, op.name, datacenter,
+		)
+		return actionContinue{delay: provisioningSlotDelay}
+	}
+
+	conditions.Delete(host, infrav1.ProvisioningSlotAvailableCondition)
+	return nil
+}
+
+// countOperationsInDatacenter counts the other hosts of the namespace in the datacenter whose operation is running.
+func (s *Service) countOperationsInDatacenter(op provisioningOperation, datacenter string) (int, error) {
+	var hosts infrav1.HetznerBareMetalHostList
+	if err := s.scope.Client.List(context.Background(), &hosts, client.InNamespace(s.scope.Namespace())); err != nil {
+		return 0, errors.Wrap(err, fmt.Sprintf("failed to list hosts to count %s", op.name))
+	}
+
+	var running int
+	for i := range hosts.Items {
+		host := &hosts.Items[i]
+		if host.Name == s.scope.Name() || !strings.EqualFold(host.Spec.Status.Datacenter, datacenter) {
+			continue
+		}
+		if op.inProgress(host) {
+			running++
+		}
+	}
+	return running, nil
+}
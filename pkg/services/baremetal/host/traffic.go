@@ -0,0 +1,120 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package host
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/hrobot-go/models"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// trafficWarningRatio is the share of the included traffic from which on a warning event is emitted.
+const trafficWarningRatio = 0.9
+
+// reconcileTraffic refreshes the traffic of the server in the current month from Robot if it is outdated.
+// Like the Robot data of the server, failures do not block the provisioning.
+func (s *Service) reconcileTraffic(ctx context.Context) {
+	host := s.scope.HetznerBareMetalHost
+	if host.Spec.Status.IPv4 == "" {
+		return
+	}
+
+	now := time.Now()
+	month := now.UTC().Format("2006-01")
+	if traffic := host.Spec.Status.Traffic; traffic != nil && traffic.Month == month && traffic.LastUpdated != nil &&
+		time.Since(traffic.LastUpdated.Time) < trafficRefreshInterval {
+		setTrafficMetrics(host)
+		return
+	}
+
+	traffic, err := s.scope.RobotClient.GetMonthlyTraffic(host.Spec.Status.IPv4, now)
+	if err != nil {
+		if models.IsError(err, models.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(host, infrav1.RateLimitExceeded)
+			record.Event(host,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling robot function GetMonthlyTraffic",
+			)
+		}
+		ctrl.LoggerFrom(ctx).Error(err, "failed to refresh traffic of server")
+		return
+	}
+
+	previous := host.Spec.Status.Traffic
+	lastUpdated := metav1.NewTime(now)
+	status := &infrav1.TrafficStatus{
+		Month:       month,
+		Inbound:     gigabytes(traffic.In),
+		Outbound:    gigabytes(traffic.Out),
+		LastUpdated: &lastUpdated,
+	}
+	if host.Spec.Status.RobotServer != nil {
+		status.Included = includedTraffic(host.Spec.Status.RobotServer.Traffic)
+	}
+	host.Spec.Status.Traffic = status
+
+	if approachesTrafficLimit(status) && (previous == nil || previous.Month != month || !approachesTrafficLimit(previous)) {
+		record.Warnf(host, "TrafficLimitApproaching", "Server has used %s of its included traffic of %s in %s",
+			status.Outbound.String(), status.Included.String(), month)
+	}
+	setTrafficMetrics(host)
+}
+
+// approachesTrafficLimit returns whether the outgoing traffic reached trafficWarningRatio of the included traffic.
+func approachesTrafficLimit(traffic *infrav1.TrafficStatus) bool {
+	if traffic.Included == nil || traffic.Included.IsZero() {
+		return false
+	}
+	return traffic.Outbound.AsApproximateFloat64() >= trafficWarningRatio*traffic.Included.AsApproximateFloat64()
+}
+
+// gigabytes converts the traffic in GB, as reported by Robot, to a quantity.
+func gigabytes(gb float64) resource.Quantity {
+	return *resource.NewQuantity(int64(gb*1e9), resource.DecimalSI)
+}
+
+// includedTraffic parses the included traffic of a Robot server, e.g. 20 TB. Unlimited and unknown values
+// return nil.
+func includedTraffic(traffic string) *resource.Quantity {
+	value, unit, found := strings.Cut(strings.TrimSpace(traffic), " ")
+	if !found {
+		return nil
+	}
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil
+	}
+	var factor float64
+	switch unit {
+	case "TB":
+		factor = 1e12
+	case "GB":
+		factor = 1e9
+	default:
+		return nil
+	}
+	return resource.NewQuantity(int64(amount*factor), resource.DecimalSI)
+}
@@ -17,12 +17,66 @@ limitations under the License.
 package host
 
 import (
+	"bytes"
 	"fmt"
 	"strings"
+	"text/template"
 
 	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
 )
 
+// installImageVariables are the variables that can be used in the templates of the image and the post install
+// script of the install image.
+type installImageVariables struct {
+	ClusterName       string
+	MachineName       string
+	KubernetesVersion string
+	Arch              string
+}
+
+// renderInstallImage returns a copy of the install image in which the templates of the image and the post
+// install script have been executed with the variables.
+func renderInstallImage(installImage infrav1.InstallImage, vars installImageVariables) (infrav1.InstallImage, error) {
+	rendered := *installImage.DeepCopy()
+	for _, field := range []struct {
+		name string
+		text *string
+	}{
+		{"image.url", &rendered.Image.URL},
+		{"image.name", &rendered.Image.Name},
+		{"image.path", &rendered.Image.Path},
+		{"postInstallScript", &rendered.PostInstallScript},
+	} {
+		name, text := field.name, field.text
+		if !strings.Contains(*text, "{{") {
+			continue
+		}
+		tmpl, err := template.New(name).Option("missingkey=error").Parse(*text)
+		if err != nil {
+			return infrav1.InstallImage{}, fmt.Errorf("failed to parse template of %s: %w", name, err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, vars); err != nil {
+			return infrav1.InstallImage{}, fmt.Errorf("failed to execute template of %s: %w", name, err)
+		}
+		*text = buf.String()
+	}
+	return rendered, nil
+}
+
+// goArch converts the machine hardware name of uname to the architecture name that is used by Go and in the
+// names of most images, e.g. x86_64 to amd64.
+func goArch(arch string) string {
+	switch arch {
+	case "x86_64":
+		return "amd64"
+	case "aarch64":
+		return "arm64"
+	default:
+		return arch
+	}
+}
+
 type autoSetupInput struct {
 	osDevices []string
 	hostName  string
@@ -49,6 +103,10 @@ SWRAIDLEVEL %v`, hostName, installImageSpec.SwraidLevel)
 	}
 
 	var partitions string
+	if installImageSpec.Swap != nil && installImageSpec.Swap.Type == infrav1.SwapTypePartition {
+		partitions = fmt.Sprintf(`
+PART swap swap %s`, installImageSpec.Swap.Size)
+	}
 	for _, partition := range installImageSpec.Partitions {
 		partitions = fmt.Sprintf(`%s
 PART %s %s %s`, partitions, partition.Mount, partition.FileSystem, partition.Size)
@@ -216,6 +216,37 @@ PART /boot ext2 512M
 
 
 
+IMAGE my-image`),
+		Entry(
+			"swap partition",
+			infrav1.InstallImage{
+				Partitions: []infrav1.Partition{
+					{
+						Mount:      "/",
+						FileSystem: "ext4",
+						Size:       "all",
+					},
+				},
+				Swap: &infrav1.SwapSpec{
+					Type: infrav1.SwapTypePartition,
+					Size: "16G",
+				},
+			},
+			autoSetupInput{
+				image:     "my-image",
+				osDevices: []string{"device"},
+				hostName:  "my-host",
+			},
+			`DRIVE1 /dev/device
+
+HOSTNAME my-host
+SWRAID 0
+
+PART swap swap 16G
+PART / ext4 all
+
+
+
 IMAGE my-image`),
 	)
 })
@@ -243,3 +274,54 @@ var _ = Describe("validJSONFromSSHOutput", func() {
 		),
 	)
 })
+
+var _ = Describe("renderInstallImage", func() {
+	vars := installImageVariables{
+		ClusterName:       "my-cluster",
+		MachineName:       "bm-machine",
+		KubernetesVersion: "v1.25.5",
+		Arch:              "arm64",
+	}
+
+	It("executes the templates of the image and the post install script", func() {
+		installImage := infrav1.InstallImage{
+			Image: infrav1.Image{
+				URL:  "https://images.example.com/{{ .KubernetesVersion }}/ubuntu-{{ .Arch }}.tar.gz",
+				Name: "ubuntu-{{ .KubernetesVersion }}",
+			},
+			PostInstallScript: "echo {{ .ClusterName }}/{{ .MachineName }} > /etc/machine-info",
+		}
+
+		rendered, err := renderInstallImage(installImage, vars)
+		Expect(err).To(Succeed())
+		Expect(rendered.Image.URL).To(Equal("https://images.example.com/v1.25.5/ubuntu-arm64.tar.gz"))
+		Expect(rendered.Image.Name).To(Equal("ubuntu-v1.25.5"))
+		Expect(rendered.PostInstallScript).To(Equal("echo my-cluster/bm-machine > /etc/machine-info"))
+		Expect(installImage.Image.Name).To(Equal("ubuntu-{{ .KubernetesVersion }}"))
+	})
+
+	It("keeps values without templates", func() {
+		installImage := infrav1.InstallImage{
+			Image:             infrav1.Image{Path: "/root/.oldroot/nfs/images/Ubuntu-2204-jammy-amd64-base.tar.gz"},
+			PostInstallScript: "#!/bin/bash\necho ${HOSTNAME}",
+		}
+
+		rendered, err := renderInstallImage(installImage, vars)
+		Expect(err).To(Succeed())
+		Expect(rendered).To(Equal(installImage))
+	})
+
+	It("fails on unknown variables", func() {
+		_, err := renderInstallImage(infrav1.InstallImage{Image: infrav1.Image{Path: "{{ .Version }}"}}, vars)
+		Expect(err).ToNot(Succeed())
+	})
+})
+
+var _ = DescribeTable("goArch",
+	func(arch, expectedArch string) {
+		Expect(goArch(arch)).To(Equal(expectedArch))
+	},
+	Entry("x86_64", "x86_64", "amd64"),
+	Entry("aarch64", "aarch64", "arm64"),
+	Entry("unknown", "riscv64", "riscv64"),
+)
@@ -88,7 +88,9 @@ func (s *Service) Reconcile(ctx context.Context) (_ *ctrl.Result, err error) {
 		case infrav1.PhaseRunning:
 			return s.handlePhaseRunning(ctx, host, helper)
 		case infrav1.PhaseWaiting:
-			return s.handlePhaseWaiting(ctx)
+			return s.handlePhaseWaiting(ctx, host, helper)
+		case infrav1.PhaseFencing:
+			return s.handlePhaseFencing(ctx, host, helper)
 		default:
 		}
 	}
@@ -134,9 +136,14 @@ func (s *Service) handlePhaseRunning(ctx context.Context, host *infrav1.HetznerB
 	return nil, nil
 }
 
-func (s *Service) handlePhaseWaiting(ctx context.Context) (*ctrl.Result, error) {
+func (s *Service) handlePhaseWaiting(ctx context.Context, host *infrav1.HetznerBareMetalHost, helper *patch.Helper) (*ctrl.Result, error) {
 	okToStop, nextCheck := s.timeToRemediate(s.scope.BareMetalRemediation.Spec.Strategy.Timeout.Duration)
 
+	if okToStop && s.scope.BareMetalRemediation.Spec.Strategy.Fencing {
+		s.scope.BareMetalRemediation.Status.Phase = infrav1.PhaseFencing
+		return s.handlePhaseFencing(ctx, host, helper)
+	}
+
 	if okToStop {
 		s.scope.BareMetalRemediation.Status.Phase = infrav1.PhaseDeleting
 		// When machine is still unhealthy after remediation, setting of OwnerRemediatedCondition
@@ -157,6 +164,47 @@ func (s *Service) handlePhaseWaiting(ctx context.Context) (*ctrl.Result, error)
 	return nil, nil
 }
 
+// handlePhaseFencing powers off the host and moves control to the CAPI machine controller only once Robot
+// confirmed that the host is off. Until then, the machine is not deleted, so that the volumes of its node
+// are not attached to other nodes while the host might still write to them.
+func (s *Service) handlePhaseFencing(ctx context.Context, host *infrav1.HetznerBareMetalHost, helper *patch.Helper) (*ctrl.Result, error) {
+	if _, ok := host.Annotations[infrav1.FenceAnnotation]; !ok {
+		s.scope.Info("Adding Fence annotation to host", "host", host.Name)
+		if host.Annotations == nil {
+			host.Annotations = make(map[string]string)
+		}
+		host.Annotations[infrav1.FenceAnnotation] = ""
+		if err := helper.Patch(ctx, host); err != nil {
+			s.scope.Error(err, "error setting fence annotation")
+			return &ctrl.Result{}, errors.Wrap(err, "error setting fence annotation")
+		}
+	}
+
+	if fenced := conditions.Get(host, infrav1.HostFencedCondition); fenced != nil {
+		conditions.Set(s.scope.BareMetalRemediation, fenced)
+	} else {
+		conditions.MarkFalse(
+			s.scope.BareMetalRemediation,
+			infrav1.HostFencedCondition,
+			infrav1.FencingInProgressReason,
+			capi.ConditionSeverityInfo,
+			"waiting for host %s to be powered off",
+			host.Name,
+		)
+	}
+
+	if !conditions.IsTrue(host, infrav1.HostFencedCondition) {
+		return &ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
+	s.scope.BareMetalRemediation.Status.Phase = infrav1.PhaseDeleting
+	if err := s.setOwnerRemediatedConditionNew(ctx); err != nil {
+		s.scope.Error(err, "error setting cluster api conditions")
+		return &ctrl.Result{}, errors.Wrapf(err, "error setting cluster api conditions")
+	}
+	return nil, nil
+}
+
 func (s *Service) getUnhealthyHost(ctx context.Context) (*infrav1.HetznerBareMetalHost, *patch.Helper, error) {
 	host, err := s.getHost(ctx)
 	if err != nil || host == nil {
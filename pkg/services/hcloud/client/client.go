@@ -19,9 +19,17 @@ package hcloudclient
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/hetznercloud/hcloud-go/hcloud"
+	"github.com/hetznercloud/hcloud-go/hcloud/schema"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
 )
 
 // Client collects all methods used by the controller in the hcloud cloud API.
@@ -32,6 +40,7 @@ type Client interface {
 	DeleteLoadBalancer(context.Context, int) error
 	ListLoadBalancers(context.Context, hcloud.LoadBalancerListOpts) ([]*hcloud.LoadBalancer, error)
 	AttachLoadBalancerToNetwork(context.Context, *hcloud.LoadBalancer, hcloud.LoadBalancerAttachToNetworkOpts) (*hcloud.Action, error)
+	DetachLoadBalancerFromNetwork(context.Context, *hcloud.LoadBalancer, hcloud.LoadBalancerDetachFromNetworkOpts) (*hcloud.Action, error)
 	ChangeLoadBalancerType(context.Context, *hcloud.LoadBalancer, hcloud.LoadBalancerChangeTypeOpts) (*hcloud.Action, error)
 	ChangeLoadBalancerAlgorithm(context.Context, *hcloud.LoadBalancer, hcloud.LoadBalancerChangeAlgorithmOpts) (*hcloud.Action, error)
 	UpdateLoadBalancer(context.Context, *hcloud.LoadBalancer, hcloud.LoadBalancerUpdateOpts) (*hcloud.LoadBalancer, error)
@@ -41,22 +50,78 @@ type Client interface {
 	DeleteIPTargetOfLoadBalancer(context.Context, *hcloud.LoadBalancer, net.IP) (*hcloud.Action, error)
 	AddServiceToLoadBalancer(context.Context, *hcloud.LoadBalancer, hcloud.LoadBalancerAddServiceOpts) (*hcloud.Action, error)
 	DeleteServiceFromLoadBalancer(context.Context, *hcloud.LoadBalancer, int) (*hcloud.Action, error)
+	UpdateServiceOfLoadBalancer(context.Context, *hcloud.LoadBalancer, int, hcloud.LoadBalancerUpdateServiceOpts) (*hcloud.Action, error)
+	CreateCertificate(context.Context, hcloud.CertificateCreateOpts) (hcloud.CertificateCreateResult, error)
+	ListCertificates(context.Context, hcloud.CertificateListOpts) ([]*hcloud.Certificate, error)
+	DeleteCertificate(context.Context, *hcloud.Certificate) error
 	ListImages(context.Context, hcloud.ImageListOpts) ([]*hcloud.Image, error)
+	ListImagesForArchitecture(context.Context, hcloud.ImageListOpts, string) ([]*hcloud.Image, error)
+	CreateImage(context.Context, *hcloud.Server, hcloud.ServerCreateImageOpts) (hcloud.ServerCreateImageResult, error)
+	DeleteImage(context.Context, *hcloud.Image) error
 	CreateServer(context.Context, hcloud.ServerCreateOpts) (hcloud.ServerCreateResult, error)
 	AttachServerToNetwork(context.Context, *hcloud.Server, hcloud.ServerAttachToNetworkOpts) (*hcloud.Action, error)
 	ListServers(context.Context, hcloud.ServerListOpts) ([]*hcloud.Server, error)
 	DeleteServer(context.Context, *hcloud.Server) error
+	UpdateServer(context.Context, *hcloud.Server, hcloud.ServerUpdateOpts) (*hcloud.Server, error)
 	ListServerTypes(context.Context) ([]*hcloud.ServerType, error)
+	ListServerTypeDeprecations(context.Context) (map[string]ServerTypeDeprecation, error)
 	PowerOnServer(context.Context, *hcloud.Server) (*hcloud.Action, error)
+	ChangeServerType(context.Context, *hcloud.Server, hcloud.ServerChangeTypeOpts) (*hcloud.Action, error)
+	ChangeServerProtection(context.Context, *hcloud.Server, hcloud.ServerChangeProtectionOpts) (*hcloud.Action, error)
+	EnableServerBackup(context.Context, *hcloud.Server) (*hcloud.Action, error)
+	DisableServerBackup(context.Context, *hcloud.Server) (*hcloud.Action, error)
+	GetISO(context.Context, string) (*hcloud.ISO, error)
+	AttachISO(context.Context, *hcloud.Server, *hcloud.ISO) (*hcloud.Action, error)
+	DetachISO(context.Context, *hcloud.Server) (*hcloud.Action, error)
+	EnableServerRescue(context.Context, *hcloud.Server, hcloud.ServerEnableRescueOpts) (hcloud.ServerEnableRescueResult, error)
+	DisableServerRescue(context.Context, *hcloud.Server) (*hcloud.Action, error)
+	ResetServer(context.Context, *hcloud.Server) (*hcloud.Action, error)
 	ShutdownServer(context.Context, *hcloud.Server) (*hcloud.Action, error)
+	PowerOffServer(context.Context, *hcloud.Server) (*hcloud.Action, error)
 	CreateNetwork(context.Context, hcloud.NetworkCreateOpts) (*hcloud.Network, error)
 	ListNetworks(context.Context, hcloud.NetworkListOpts) ([]*hcloud.Network, error)
+	GetNetwork(context.Context, string) (*hcloud.Network, error)
 	DeleteNetwork(context.Context, *hcloud.Network) error
+	AddSubnetToNetwork(context.Context, *hcloud.Network, hcloud.NetworkAddSubnetOpts) (*hcloud.Action, error)
+	DeleteSubnetFromNetwork(context.Context, *hcloud.Network, hcloud.NetworkDeleteSubnetOpts) (*hcloud.Action, error)
+	AddRouteToNetwork(context.Context, *hcloud.Network, hcloud.NetworkAddRouteOpts) (*hcloud.Action, error)
+	DeleteRouteFromNetwork(context.Context, *hcloud.Network, hcloud.NetworkDeleteRouteOpts) (*hcloud.Action, error)
 	ListSSHKeys(ctx context.Context, opts hcloud.SSHKeyListOpts) ([]*hcloud.SSHKey, error)
+	CreateSSHKey(context.Context, hcloud.SSHKeyCreateOpts) (*hcloud.SSHKey, error)
+	DeleteSSHKey(context.Context, *hcloud.SSHKey) error
 	CreatePlacementGroup(context.Context, hcloud.PlacementGroupCreateOpts) (hcloud.PlacementGroupCreateResult, error)
 	DeletePlacementGroup(context.Context, int) error
 	ListPlacementGroups(context.Context, hcloud.PlacementGroupListOpts) ([]*hcloud.PlacementGroup, error)
 	AddServerToPlacementGroup(context.Context, *hcloud.Server, *hcloud.PlacementGroup) (*hcloud.Action, error)
+	CreateFirewall(context.Context, hcloud.FirewallCreateOpts) (hcloud.FirewallCreateResult, error)
+	ListFirewalls(context.Context, hcloud.FirewallListOpts) ([]*hcloud.Firewall, error)
+	DeleteFirewall(context.Context, *hcloud.Firewall) error
+	SetFirewallRules(context.Context, *hcloud.Firewall, hcloud.FirewallSetRulesOpts) ([]*hcloud.Action, error)
+	ApplyFirewallResources(context.Context, *hcloud.Firewall, []hcloud.FirewallResource) ([]*hcloud.Action, error)
+	RemoveFirewallResources(context.Context, *hcloud.Firewall, []hcloud.FirewallResource) ([]*hcloud.Action, error)
+	CreatePrimaryIP(context.Context, hcloud.PrimaryIPCreateOpts) (*hcloud.PrimaryIPCreateResult, error)
+	GetPrimaryIP(context.Context, int) (*hcloud.PrimaryIP, error)
+	ListPrimaryIPs(context.Context, hcloud.PrimaryIPListOpts) ([]*hcloud.PrimaryIP, error)
+	UpdatePrimaryIP(context.Context, *hcloud.PrimaryIP, hcloud.PrimaryIPUpdateOpts) (*hcloud.PrimaryIP, error)
+	DeletePrimaryIP(context.Context, *hcloud.PrimaryIP) error
+	AssignPrimaryIP(context.Context, hcloud.PrimaryIPAssignOpts) (*hcloud.Action, error)
+	UnassignPrimaryIP(context.Context, int) (*hcloud.Action, error)
+	CreateFloatingIP(context.Context, hcloud.FloatingIPCreateOpts) (hcloud.FloatingIPCreateResult, error)
+	ListFloatingIPs(context.Context, hcloud.FloatingIPListOpts) ([]*hcloud.FloatingIP, error)
+	DeleteFloatingIP(context.Context, *hcloud.FloatingIP) error
+	AssignFloatingIP(context.Context, *hcloud.FloatingIP, *hcloud.Server) (*hcloud.Action, error)
+	UnassignFloatingIP(context.Context, *hcloud.FloatingIP) (*hcloud.Action, error)
+	CreateVolume(context.Context, hcloud.VolumeCreateOpts) (hcloud.VolumeCreateResult, error)
+	ListVolumes(context.Context, hcloud.VolumeListOpts) ([]*hcloud.Volume, error)
+	DeleteVolume(context.Context, *hcloud.Volume) error
+}
+
+// ServerTypeDeprecation describes the retirement of a server type.
+type ServerTypeDeprecation struct {
+	// Announced is the time when the deprecation has been announced.
+	Announced time.Time
+	// UnavailableAfter is the time after which no servers of the type can be created anymore.
+	UnavailableAfter time.Time
 }
 
 // Factory is the interface for creating new Client objects.
@@ -108,6 +173,11 @@ func (c *realClient) AttachLoadBalancerToNetwork(ctx context.Context, lb *hcloud
 	return res, err
 }
 
+func (c *realClient) DetachLoadBalancerFromNetwork(ctx context.Context, lb *hcloud.LoadBalancer, opts hcloud.LoadBalancerDetachFromNetworkOpts) (*hcloud.Action, error) {
+	res, _, err := c.client.LoadBalancer.DetachFromNetwork(ctx, lb, opts)
+	return res, err
+}
+
 func (c *realClient) ChangeLoadBalancerType(ctx context.Context, lb *hcloud.LoadBalancer, opts hcloud.LoadBalancerChangeTypeOpts) (*hcloud.Action, error) {
 	res, _, err := c.client.LoadBalancer.ChangeType(ctx, lb, opts)
 	return res, err
@@ -153,10 +223,75 @@ func (c *realClient) DeleteServiceFromLoadBalancer(ctx context.Context, lb *hclo
 	return res, err
 }
 
+func (c *realClient) UpdateServiceOfLoadBalancer(ctx context.Context, lb *hcloud.LoadBalancer, listenPort int, opts hcloud.LoadBalancerUpdateServiceOpts) (*hcloud.Action, error) {
+	res, _, err := c.client.LoadBalancer.UpdateService(ctx, lb, listenPort, opts)
+	return res, err
+}
+
+func (c *realClient) CreateCertificate(ctx context.Context, opts hcloud.CertificateCreateOpts) (hcloud.CertificateCreateResult, error) {
+	res, _, err := c.client.Certificate.CreateCertificate(ctx, opts)
+	return res, err
+}
+
+func (c *realClient) ListCertificates(ctx context.Context, opts hcloud.CertificateListOpts) ([]*hcloud.Certificate, error) {
+	return c.client.Certificate.AllWithOpts(ctx, opts)
+}
+
+func (c *realClient) DeleteCertificate(ctx context.Context, certificate *hcloud.Certificate) error {
+	_, err := c.client.Certificate.Delete(ctx, certificate)
+	return err
+}
+
 func (c *realClient) ListImages(ctx context.Context, opts hcloud.ImageListOpts) ([]*hcloud.Image, error) {
 	return c.client.Image.AllWithOpts(ctx, opts)
 }
 
+// ListImagesForArchitecture lists the images of the architecture, i.e. x86 or arm. The name and the label
+// selector of the options are used. hcloud-go does not support the architecture yet, so the API is queried directly.
+func (c *realClient) ListImagesForArchitecture(ctx context.Context, opts hcloud.ImageListOpts, architecture string) ([]*hcloud.Image, error) {
+	query := url.Values{}
+	query.Set("architecture", architecture)
+	query.Set("per_page", "50")
+	if opts.Name != "" {
+		query.Set("name", opts.Name)
+	}
+	if opts.LabelSelector != "" {
+		query.Set("label_selector", opts.LabelSelector)
+	}
+
+	var images []*hcloud.Image
+	for page := 1; page > 0; {
+		query.Set("page", strconv.Itoa(page))
+		req, err := c.client.NewRequest(ctx, http.MethodGet, "/images?"+query.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		var body schema.ImageListResponse
+		resp, err := c.client.Do(req, &body)
+		if err != nil {
+			return nil, err
+		}
+		for _, image := range body.Images {
+			images = append(images, hcloud.ImageFromSchema(image))
+		}
+		page = 0
+		if resp.Meta.Pagination != nil {
+			page = resp.Meta.Pagination.NextPage
+		}
+	}
+	return images, nil
+}
+
+func (c *realClient) CreateImage(ctx context.Context, server *hcloud.Server, opts hcloud.ServerCreateImageOpts) (hcloud.ServerCreateImageResult, error) {
+	res, _, err := c.client.Server.CreateImage(ctx, server, &opts)
+	return res, err
+}
+
+func (c *realClient) DeleteImage(ctx context.Context, image *hcloud.Image) error {
+	_, err := c.client.Image.Delete(ctx, image)
+	return err
+}
+
 func (c *realClient) CreateServer(ctx context.Context, opts hcloud.ServerCreateOpts) (hcloud.ServerCreateResult, error) {
 	res, _, err := c.client.Server.Create(ctx, opts)
 	return res, err
@@ -175,21 +310,122 @@ func (c *realClient) ListServerTypes(ctx context.Context) ([]*hcloud.ServerType,
 	return c.client.ServerType.All(ctx)
 }
 
+type serverTypeDeprecationsResponse struct {
+	ServerTypes []struct {
+		Name        string `json:"name"`
+		Deprecation *struct {
+			Announced        time.Time `json:"announced"`
+			UnavailableAfter time.Time `json:"unavailable_after"`
+		} `json:"deprecation"`
+	} `json:"server_types"`
+}
+
+// ListServerTypeDeprecations returns the deprecations of server types by their names. hcloud-go does not
+// expose them yet, so the API is queried directly.
+func (c *realClient) ListServerTypeDeprecations(ctx context.Context) (map[string]ServerTypeDeprecation, error) {
+	deprecations := make(map[string]ServerTypeDeprecation)
+	for page := 1; page > 0; {
+		req, err := c.client.NewRequest(ctx, http.MethodGet, fmt.Sprintf("/server_types?page=%d&per_page=50", page), nil)
+		if err != nil {
+			return nil, err
+		}
+		var body serverTypeDeprecationsResponse
+		resp, err := c.client.Do(req, &body)
+		if err != nil {
+			return nil, err
+		}
+		for _, serverType := range body.ServerTypes {
+			if serverType.Deprecation != nil {
+				deprecations[serverType.Name] = ServerTypeDeprecation{
+					Announced:        serverType.Deprecation.Announced,
+					UnavailableAfter: serverType.Deprecation.UnavailableAfter,
+				}
+			}
+		}
+		page = 0
+		if resp.Meta.Pagination != nil {
+			page = resp.Meta.Pagination.NextPage
+		}
+	}
+	return deprecations, nil
+}
+
 func (c *realClient) ShutdownServer(ctx context.Context, server *hcloud.Server) (*hcloud.Action, error) {
 	res, _, err := c.client.Server.Shutdown(ctx, server)
 	return res, err
 }
 
+func (c *realClient) PowerOffServer(ctx context.Context, server *hcloud.Server) (*hcloud.Action, error) {
+	res, _, err := c.client.Server.Poweroff(ctx, server)
+	return res, err
+}
+
 func (c *realClient) PowerOnServer(ctx context.Context, server *hcloud.Server) (*hcloud.Action, error) {
 	res, _, err := c.client.Server.Poweron(ctx, server)
 	return res, err
 }
 
+func (c *realClient) ChangeServerType(ctx context.Context, server *hcloud.Server, opts hcloud.ServerChangeTypeOpts) (*hcloud.Action, error) {
+	res, _, err := c.client.Server.ChangeType(ctx, server, opts)
+	return res, err
+}
+
+func (c *realClient) ChangeServerProtection(ctx context.Context, server *hcloud.Server, opts hcloud.ServerChangeProtectionOpts) (*hcloud.Action, error) {
+	res, _, err := c.client.Server.ChangeProtection(ctx, server, opts)
+	return res, err
+}
+
+func (c *realClient) EnableServerBackup(ctx context.Context, server *hcloud.Server) (*hcloud.Action, error) {
+	// the backup window is chosen by HCloud
+	res, _, err := c.client.Server.EnableBackup(ctx, server, "")
+	return res, err
+}
+
+func (c *realClient) DisableServerBackup(ctx context.Context, server *hcloud.Server) (*hcloud.Action, error) {
+	res, _, err := c.client.Server.DisableBackup(ctx, server)
+	return res, err
+}
+
+func (c *realClient) EnableServerRescue(ctx context.Context, server *hcloud.Server, opts hcloud.ServerEnableRescueOpts) (hcloud.ServerEnableRescueResult, error) {
+	res, _, err := c.client.Server.EnableRescue(ctx, server, opts)
+	return res, err
+}
+
+func (c *realClient) DisableServerRescue(ctx context.Context, server *hcloud.Server) (*hcloud.Action, error) {
+	res, _, err := c.client.Server.DisableRescue(ctx, server)
+	return res, err
+}
+
+func (c *realClient) ResetServer(ctx context.Context, server *hcloud.Server) (*hcloud.Action, error) {
+	res, _, err := c.client.Server.Reset(ctx, server)
+	return res, err
+}
+
+func (c *realClient) GetISO(ctx context.Context, idOrName string) (*hcloud.ISO, error) {
+	res, _, err := c.client.ISO.Get(ctx, idOrName)
+	return res, err
+}
+
+func (c *realClient) AttachISO(ctx context.Context, server *hcloud.Server, iso *hcloud.ISO) (*hcloud.Action, error) {
+	res, _, err := c.client.Server.AttachISO(ctx, server, iso)
+	return res, err
+}
+
+func (c *realClient) DetachISO(ctx context.Context, server *hcloud.Server) (*hcloud.Action, error) {
+	res, _, err := c.client.Server.DetachISO(ctx, server)
+	return res, err
+}
+
 func (c *realClient) DeleteServer(ctx context.Context, server *hcloud.Server) error {
 	_, err := c.client.Server.Delete(ctx, server)
 	return err
 }
 
+func (c *realClient) UpdateServer(ctx context.Context, server *hcloud.Server, opts hcloud.ServerUpdateOpts) (*hcloud.Server, error) {
+	res, _, err := c.client.Server.Update(ctx, server, opts)
+	return res, err
+}
+
 func (c *realClient) CreateNetwork(ctx context.Context, opts hcloud.NetworkCreateOpts) (*hcloud.Network, error) {
 	res, _, err := c.client.Network.Create(ctx, opts)
 	return res, err
@@ -199,16 +435,51 @@ func (c *realClient) ListNetworks(ctx context.Context, opts hcloud.NetworkListOp
 	return c.client.Network.AllWithOpts(ctx, opts)
 }
 
+func (c *realClient) GetNetwork(ctx context.Context, idOrName string) (*hcloud.Network, error) {
+	res, _, err := c.client.Network.Get(ctx, idOrName)
+	return res, err
+}
+
 func (c *realClient) DeleteNetwork(ctx context.Context, network *hcloud.Network) error {
 	_, err := c.client.Network.Delete(ctx, network)
 	return err
 }
 
+func (c *realClient) AddSubnetToNetwork(ctx context.Context, network *hcloud.Network, opts hcloud.NetworkAddSubnetOpts) (*hcloud.Action, error) {
+	res, _, err := c.client.Network.AddSubnet(ctx, network, opts)
+	return res, err
+}
+
+func (c *realClient) DeleteSubnetFromNetwork(ctx context.Context, network *hcloud.Network, opts hcloud.NetworkDeleteSubnetOpts) (*hcloud.Action, error) {
+	res, _, err := c.client.Network.DeleteSubnet(ctx, network, opts)
+	return res, err
+}
+
+func (c *realClient) AddRouteToNetwork(ctx context.Context, network *hcloud.Network, opts hcloud.NetworkAddRouteOpts) (*hcloud.Action, error) {
+	res, _, err := c.client.Network.AddRoute(ctx, network, opts)
+	return res, err
+}
+
+func (c *realClient) DeleteRouteFromNetwork(ctx context.Context, network *hcloud.Network, opts hcloud.NetworkDeleteRouteOpts) (*hcloud.Action, error) {
+	res, _, err := c.client.Network.DeleteRoute(ctx, network, opts)
+	return res, err
+}
+
 func (c *realClient) ListSSHKeys(ctx context.Context, opts hcloud.SSHKeyListOpts) ([]*hcloud.SSHKey, error) {
 	res, _, err := c.client.SSHKey.List(ctx, opts)
 	return res, err
 }
 
+func (c *realClient) CreateSSHKey(ctx context.Context, opts hcloud.SSHKeyCreateOpts) (*hcloud.SSHKey, error) {
+	res, _, err := c.client.SSHKey.Create(ctx, opts)
+	return res, err
+}
+
+func (c *realClient) DeleteSSHKey(ctx context.Context, sshKey *hcloud.SSHKey) error {
+	_, err := c.client.SSHKey.Delete(ctx, sshKey)
+	return err
+}
+
 func (c *realClient) CreatePlacementGroup(ctx context.Context, opts hcloud.PlacementGroupCreateOpts) (hcloud.PlacementGroupCreateResult, error) {
 	res, _, err := c.client.PlacementGroup.Create(ctx, opts)
 	return res, err
@@ -227,3 +498,127 @@ func (c *realClient) AddServerToPlacementGroup(ctx context.Context, server *hclo
 	res, _, err := c.client.Server.AddToPlacementGroup(ctx, server, pg)
 	return res, err
 }
+
+func (c *realClient) CreateFirewall(ctx context.Context, opts hcloud.FirewallCreateOpts) (hcloud.FirewallCreateResult, error) {
+	res, _, err := c.client.Firewall.Create(ctx, opts)
+	return res, err
+}
+
+func (c *realClient) ListFirewalls(ctx context.Context, opts hcloud.FirewallListOpts) ([]*hcloud.Firewall, error) {
+	return c.client.Firewall.AllWithOpts(ctx, opts)
+}
+
+func (c *realClient) DeleteFirewall(ctx context.Context, firewall *hcloud.Firewall) error {
+	_, err := c.client.Firewall.Delete(ctx, firewall)
+	return err
+}
+
+func (c *realClient) SetFirewallRules(ctx context.Context, firewall *hcloud.Firewall, opts hcloud.FirewallSetRulesOpts) ([]*hcloud.Action, error) {
+	res, _, err := c.client.Firewall.SetRules(ctx, firewall, opts)
+	return res, err
+}
+
+func (c *realClient) ApplyFirewallResources(ctx context.Context, firewall *hcloud.Firewall, resources []hcloud.FirewallResource) ([]*hcloud.Action, error) {
+	res, _, err := c.client.Firewall.ApplyResources(ctx, firewall, resources)
+	return res, err
+}
+
+func (c *realClient) RemoveFirewallResources(ctx context.Context, firewall *hcloud.Firewall, resources []hcloud.FirewallResource) ([]*hcloud.Action, error) {
+	res, _, err := c.client.Firewall.RemoveResources(ctx, firewall, resources)
+	return res, err
+}
+
+func (c *realClient) CreatePrimaryIP(ctx context.Context, opts hcloud.PrimaryIPCreateOpts) (*hcloud.PrimaryIPCreateResult, error) {
+	res, _, err := c.client.PrimaryIP.Create(ctx, opts)
+	return res, err
+}
+
+func (c *realClient) GetPrimaryIP(ctx context.Context, id int) (*hcloud.PrimaryIP, error) {
+	res, _, err := c.client.PrimaryIP.GetByID(ctx, id)
+	return res, err
+}
+
+func (c *realClient) ListPrimaryIPs(ctx context.Context, opts hcloud.PrimaryIPListOpts) ([]*hcloud.PrimaryIP, error) {
+	return c.client.PrimaryIP.AllWithOpts(ctx, opts)
+}
+
+func (c *realClient) UpdatePrimaryIP(ctx context.Context, primaryIP *hcloud.PrimaryIP, opts hcloud.PrimaryIPUpdateOpts) (*hcloud.PrimaryIP, error) {
+	res, _, err := c.client.PrimaryIP.Update(ctx, primaryIP, opts)
+	return res, err
+}
+
+func (c *realClient) DeletePrimaryIP(ctx context.Context, primaryIP *hcloud.PrimaryIP) error {
+	_, err := c.client.PrimaryIP.Delete(ctx, primaryIP)
+	return err
+}
+
+func (c *realClient) AssignPrimaryIP(ctx context.Context, opts hcloud.PrimaryIPAssignOpts) (*hcloud.Action, error) {
+	res, _, err := c.client.PrimaryIP.Assign(ctx, opts)
+	return res, err
+}
+
+func (c *realClient) UnassignPrimaryIP(ctx context.Context, id int) (*hcloud.Action, error) {
+	res, _, err := c.client.PrimaryIP.Unassign(ctx, id)
+	return res, err
+}
+
+func (c *realClient) CreateFloatingIP(ctx context.Context, opts hcloud.FloatingIPCreateOpts) (hcloud.FloatingIPCreateResult, error) {
+	res, _, err := c.client.FloatingIP.Create(ctx, opts)
+	return res, err
+}
+
+func (c *realClient) ListFloatingIPs(ctx context.Context, opts hcloud.FloatingIPListOpts) ([]*hcloud.FloatingIP, error) {
+	return c.client.FloatingIP.AllWithOpts(ctx, opts)
+}
+
+func (c *realClient) DeleteFloatingIP(ctx context.Context, floatingIP *hcloud.FloatingIP) error {
+	_, err := c.client.FloatingIP.Delete(ctx, floatingIP)
+	return err
+}
+
+func (c *realClient) AssignFloatingIP(ctx context.Context, floatingIP *hcloud.FloatingIP, server *hcloud.Server) (*hcloud.Action, error) {
+	res, _, err := c.client.FloatingIP.Assign(ctx, floatingIP, server)
+	return res, err
+}
+
+func (c *realClient) UnassignFloatingIP(ctx context.Context, floatingIP *hcloud.FloatingIP) (*hcloud.Action, error) {
+	res, _, err := c.client.FloatingIP.Unassign(ctx, floatingIP)
+	return res, err
+}
+
+func (c *realClient) CreateVolume(ctx context.Context, opts hcloud.VolumeCreateOpts) (hcloud.VolumeCreateResult, error) {
+	res, _, err := c.client.Volume.Create(ctx, opts)
+	return res, err
+}
+
+func (c *realClient) ListVolumes(ctx context.Context, opts hcloud.VolumeListOpts) ([]*hcloud.Volume, error) {
+	return c.client.Volume.AllWithOpts(ctx, opts)
+}
+
+func (c *realClient) DeleteVolume(ctx context.Context, volume *hcloud.Volume) error {
+	_, err := c.client.Volume.Delete(ctx, volume)
+	return err
+}
+
+// errorCodeUnauthorized is returned by the HCloud API for invalid or unknown tokens. hcloud-go has no constant for it.
+const errorCodeUnauthorized = hcloud.ErrorCode("unauthorized")
+
+// ErrorCodeLoadBalancerTargetNotFound is returned by the HCloud API for the removal of a target that the load
+// balancer does not have. hcloud-go has no constant for it.
+const ErrorCodeLoadBalancerTargetNotFound = hcloud.ErrorCode("load_balancer_target_not_found")
+
+// IsUnauthorized checks whether an error, possibly wrapped, means that the HCloud token has been rejected.
+func IsUnauthorized(err error) bool {
+	var aggregate kerrors.Aggregate
+	if errors.As(err, &aggregate) {
+		for _, e := range aggregate.Errors() {
+			if IsUnauthorized(e) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var hcloudErr hcloud.Error
+	return errors.As(err, &hcloudErr) && hcloudErr.Code == errorCodeUnauthorized
+}
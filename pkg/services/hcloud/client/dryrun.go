@@ -0,0 +1,290 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hcloudclient
+
+import (
+	"context"
+	"net"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/dryrun"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// dryRunClient passes reads to the wrapped client and records mutations on the object instead of
+// executing them.
+type dryRunClient struct {
+	Client
+	obj client.Object
+}
+
+// NewDryRunClient returns a client that records the mutations on the object instead of executing them.
+func NewDryRunClient(c Client, obj client.Object) Client {
+	return &dryRunClient{Client: c, obj: obj}
+}
+
+func (c *dryRunClient) CreateLoadBalancer(_ context.Context, opts hcloud.LoadBalancerCreateOpts) (hcloud.LoadBalancerCreateResult, error) {
+	return hcloud.LoadBalancerCreateResult{}, dryrun.Skip(c.obj, "creating load balancer %s", opts.Name)
+}
+
+func (c *dryRunClient) DeleteLoadBalancer(_ context.Context, id int) error {
+	return dryrun.Skip(c.obj, "deleting load balancer %d", id)
+}
+
+func (c *dryRunClient) AttachLoadBalancerToNetwork(_ context.Context, lb *hcloud.LoadBalancer, opts hcloud.LoadBalancerAttachToNetworkOpts) (*hcloud.Action, error) {
+	return nil, dryrun.Skip(c.obj, "attaching load balancer %s to network %d", lb.Name, opts.Network.ID)
+}
+
+func (c *dryRunClient) DetachLoadBalancerFromNetwork(_ context.Context, lb *hcloud.LoadBalancer, opts hcloud.LoadBalancerDetachFromNetworkOpts) (*hcloud.Action, error) {
+	return nil, dryrun.Skip(c.obj, "detaching load balancer %s from network %d", lb.Name, opts.Network.ID)
+}
+
+func (c *dryRunClient) ChangeLoadBalancerType(_ context.Context, lb *hcloud.LoadBalancer, opts hcloud.LoadBalancerChangeTypeOpts) (*hcloud.Action, error) {
+	return nil, dryrun.Skip(c.obj, "changing type of load balancer %s to %s", lb.Name, opts.LoadBalancerType.Name)
+}
+
+func (c *dryRunClient) ChangeLoadBalancerAlgorithm(_ context.Context, lb *hcloud.LoadBalancer, opts hcloud.LoadBalancerChangeAlgorithmOpts) (*hcloud.Action, error) {
+	return nil, dryrun.Skip(c.obj, "changing algorithm of load balancer %s to %s", lb.Name, opts.Type)
+}
+
+func (c *dryRunClient) UpdateLoadBalancer(_ context.Context, lb *hcloud.LoadBalancer, _ hcloud.LoadBalancerUpdateOpts) (*hcloud.LoadBalancer, error) {
+	return nil, dryrun.Skip(c.obj, "updating load balancer %s", lb.Name)
+}
+
+func (c *dryRunClient) AddTargetServerToLoadBalancer(_ context.Context, opts hcloud.LoadBalancerAddServerTargetOpts, lb *hcloud.LoadBalancer) (*hcloud.Action, error) {
+	return nil, dryrun.Skip(c.obj, "adding server %d as target of load balancer %s", opts.Server.ID, lb.Name)
+}
+
+func (c *dryRunClient) DeleteTargetServerOfLoadBalancer(_ context.Context, lb *hcloud.LoadBalancer, server *hcloud.Server) (*hcloud.Action, error) {
+	return nil, dryrun.Skip(c.obj, "deleting server %d as target of load balancer %s", server.ID, lb.Name)
+}
+
+func (c *dryRunClient) AddIPTargetToLoadBalancer(_ context.Context, opts hcloud.LoadBalancerAddIPTargetOpts, lb *hcloud.LoadBalancer) (*hcloud.Action, error) {
+	return nil, dryrun.Skip(c.obj, "adding IP %s as target of load balancer %s", opts.IP, lb.Name)
+}
+
+func (c *dryRunClient) DeleteIPTargetOfLoadBalancer(_ context.Context, lb *hcloud.LoadBalancer, ip net.IP) (*hcloud.Action, error) {
+	return nil, dryrun.Skip(c.obj, "deleting IP %s as target of load balancer %s", ip, lb.Name)
+}
+
+func (c *dryRunClient) AddServiceToLoadBalancer(_ context.Context, lb *hcloud.LoadBalancer, opts hcloud.LoadBalancerAddServiceOpts) (*hcloud.Action, error) {
+	var port int
+	if opts.ListenPort != nil {
+		port = *opts.ListenPort
+	}
+	return nil, dryrun.Skip(c.obj, "adding service on port %d to load balancer %s", port, lb.Name)
+}
+
+func (c *dryRunClient) DeleteServiceFromLoadBalancer(_ context.Context, lb *hcloud.LoadBalancer, listenPort int) (*hcloud.Action, error) {
+	return nil, dryrun.Skip(c.obj, "deleting service on port %d from load balancer %s", listenPort, lb.Name)
+}
+
+func (c *dryRunClient) UpdateServiceOfLoadBalancer(_ context.Context, lb *hcloud.LoadBalancer, listenPort int, _ hcloud.LoadBalancerUpdateServiceOpts) (*hcloud.Action, error) {
+	return nil, dryrun.Skip(c.obj, "updating service on port %d of load balancer %s", listenPort, lb.Name)
+}
+
+func (c *dryRunClient) CreateCertificate(_ context.Context, opts hcloud.CertificateCreateOpts) (hcloud.CertificateCreateResult, error) {
+	return hcloud.CertificateCreateResult{}, dryrun.Skip(c.obj, "creating certificate %s", opts.Name)
+}
+
+func (c *dryRunClient) DeleteCertificate(_ context.Context, certificate *hcloud.Certificate) error {
+	return dryrun.Skip(c.obj, "deleting certificate %s", certificate.Name)
+}
+
+func (c *dryRunClient) CreateImage(_ context.Context, server *hcloud.Server, _ hcloud.ServerCreateImageOpts) (hcloud.ServerCreateImageResult, error) {
+	return hcloud.ServerCreateImageResult{}, dryrun.Skip(c.obj, "creating image of server %s", server.Name)
+}
+
+func (c *dryRunClient) DeleteImage(_ context.Context, image *hcloud.Image) error {
+	return dryrun.Skip(c.obj, "deleting image %d", image.ID)
+}
+
+func (c *dryRunClient) CreateServer(_ context.Context, opts hcloud.ServerCreateOpts) (hcloud.ServerCreateResult, error) {
+	return hcloud.ServerCreateResult{}, dryrun.Skip(c.obj, "creating server %s", opts.Name)
+}
+
+func (c *dryRunClient) AttachServerToNetwork(_ context.Context, server *hcloud.Server, opts hcloud.ServerAttachToNetworkOpts) (*hcloud.Action, error) {
+	return nil, dryrun.Skip(c.obj, "attaching server %s to network %d", server.Name, opts.Network.ID)
+}
+
+func (c *dryRunClient) DeleteServer(_ context.Context, server *hcloud.Server) error {
+	return dryrun.Skip(c.obj, "deleting server %s", server.Name)
+}
+
+func (c *dryRunClient) UpdateServer(_ context.Context, server *hcloud.Server, _ hcloud.ServerUpdateOpts) (*hcloud.Server, error) {
+	return nil, dryrun.Skip(c.obj, "updating server %s", server.Name)
+}
+
+func (c *dryRunClient) EnableServerBackup(_ context.Context, server *hcloud.Server) (*hcloud.Action, error) {
+	return nil, dryrun.Skip(c.obj, "enabling backups of server %s", server.Name)
+}
+
+func (c *dryRunClient) DisableServerBackup(_ context.Context, server *hcloud.Server) (*hcloud.Action, error) {
+	return nil, dryrun.Skip(c.obj, "disabling backups of server %s", server.Name)
+}
+
+func (c *dryRunClient) AttachISO(_ context.Context, server *hcloud.Server, iso *hcloud.ISO) (*hcloud.Action, error) {
+	return nil, dryrun.Skip(c.obj, "attaching ISO %s to server %s", iso.Name, server.Name)
+}
+
+func (c *dryRunClient) DetachISO(_ context.Context, server *hcloud.Server) (*hcloud.Action, error) {
+	return nil, dryrun.Skip(c.obj, "detaching ISO from server %s", server.Name)
+}
+
+func (c *dryRunClient) EnableServerRescue(_ context.Context, server *hcloud.Server, _ hcloud.ServerEnableRescueOpts) (hcloud.ServerEnableRescueResult, error) {
+	return hcloud.ServerEnableRescueResult{}, dryrun.Skip(c.obj, "enabling rescue mode of server %s", server.Name)
+}
+
+func (c *dryRunClient) DisableServerRescue(_ context.Context, server *hcloud.Server) (*hcloud.Action, error) {
+	return nil, dryrun.Skip(c.obj, "disabling rescue mode of server %s", server.Name)
+}
+
+func (c *dryRunClient) ResetServer(_ context.Context, server *hcloud.Server) (*hcloud.Action, error) {
+	return nil, dryrun.Skip(c.obj, "resetting server %s", server.Name)
+}
+
+func (c *dryRunClient) PowerOnServer(_ context.Context, server *hcloud.Server) (*hcloud.Action, error) {
+	return nil, dryrun.Skip(c.obj, "powering on server %s", server.Name)
+}
+
+func (c *dryRunClient) ChangeServerType(_ context.Context, server *hcloud.Server, opts hcloud.ServerChangeTypeOpts) (*hcloud.Action, error) {
+	return nil, dryrun.Skip(c.obj, "changing type of server %s to %s", server.Name, opts.ServerType.Name)
+}
+
+func (c *dryRunClient) ChangeServerProtection(_ context.Context, server *hcloud.Server, _ hcloud.ServerChangeProtectionOpts) (*hcloud.Action, error) {
+	return nil, dryrun.Skip(c.obj, "changing protection of server %s", server.Name)
+}
+
+func (c *dryRunClient) ShutdownServer(_ context.Context, server *hcloud.Server) (*hcloud.Action, error) {
+	return nil, dryrun.Skip(c.obj, "shutting down server %s", server.Name)
+}
+
+func (c *dryRunClient) PowerOffServer(_ context.Context, server *hcloud.Server) (*hcloud.Action, error) {
+	return nil, dryrun.Skip(c.obj, "powering off server %s", server.Name)
+}
+
+func (c *dryRunClient) CreateNetwork(_ context.Context, opts hcloud.NetworkCreateOpts) (*hcloud.Network, error) {
+	return nil, dryrun.Skip(c.obj, "creating network %s", opts.Name)
+}
+
+func (c *dryRunClient) DeleteNetwork(_ context.Context, network *hcloud.Network) error {
+	return dryrun.Skip(c.obj, "deleting network %s", network.Name)
+}
+
+func (c *dryRunClient) AddSubnetToNetwork(_ context.Context, network *hcloud.Network, opts hcloud.NetworkAddSubnetOpts) (*hcloud.Action, error) {
+	return nil, dryrun.Skip(c.obj, "adding subnet %s to network %d", opts.Subnet.IPRange, network.ID)
+}
+
+func (c *dryRunClient) DeleteSubnetFromNetwork(_ context.Context, network *hcloud.Network, opts hcloud.NetworkDeleteSubnetOpts) (*hcloud.Action, error) {
+	return nil, dryrun.Skip(c.obj, "deleting subnet %s from network %d", opts.Subnet.IPRange, network.ID)
+}
+
+func (c *dryRunClient) AddRouteToNetwork(_ context.Context, network *hcloud.Network, opts hcloud.NetworkAddRouteOpts) (*hcloud.Action, error) {
+	return nil, dryrun.Skip(c.obj, "adding route %s via %s to network %d", opts.Route.Destination, opts.Route.Gateway, network.ID)
+}
+
+func (c *dryRunClient) DeleteRouteFromNetwork(_ context.Context, network *hcloud.Network, opts hcloud.NetworkDeleteRouteOpts) (*hcloud.Action, error) {
+	return nil, dryrun.Skip(c.obj, "deleting route %s via %s from network %d", opts.Route.Destination, opts.Route.Gateway, network.ID)
+}
+
+func (c *dryRunClient) CreatePlacementGroup(_ context.Context, opts hcloud.PlacementGroupCreateOpts) (hcloud.PlacementGroupCreateResult, error) {
+	return hcloud.PlacementGroupCreateResult{}, dryrun.Skip(c.obj, "creating placement group %s", opts.Name)
+}
+
+func (c *dryRunClient) DeletePlacementGroup(_ context.Context, id int) error {
+	return dryrun.Skip(c.obj, "deleting placement group %d", id)
+}
+
+func (c *dryRunClient) AddServerToPlacementGroup(_ context.Context, server *hcloud.Server, pg *hcloud.PlacementGroup) (*hcloud.Action, error) {
+	return nil, dryrun.Skip(c.obj, "adding server %s to placement group %s", server.Name, pg.Name)
+}
+
+func (c *dryRunClient) CreateSSHKey(_ context.Context, opts hcloud.SSHKeyCreateOpts) (*hcloud.SSHKey, error) {
+	return nil, dryrun.Skip(c.obj, "creating ssh key %s", opts.Name)
+}
+
+func (c *dryRunClient) DeleteSSHKey(_ context.Context, sshKey *hcloud.SSHKey) error {
+	return dryrun.Skip(c.obj, "deleting ssh key %s", sshKey.Name)
+}
+
+func (c *dryRunClient) CreateFirewall(_ context.Context, opts hcloud.FirewallCreateOpts) (hcloud.FirewallCreateResult, error) {
+	return hcloud.FirewallCreateResult{}, dryrun.Skip(c.obj, "creating firewall %s", opts.Name)
+}
+
+func (c *dryRunClient) DeleteFirewall(_ context.Context, firewall *hcloud.Firewall) error {
+	return dryrun.Skip(c.obj, "deleting firewall %s", firewall.Name)
+}
+
+func (c *dryRunClient) SetFirewallRules(_ context.Context, firewall *hcloud.Firewall, opts hcloud.FirewallSetRulesOpts) ([]*hcloud.Action, error) {
+	return nil, dryrun.Skip(c.obj, "setting %d rules of firewall %s", len(opts.Rules), firewall.Name)
+}
+
+func (c *dryRunClient) ApplyFirewallResources(_ context.Context, firewall *hcloud.Firewall, resources []hcloud.FirewallResource) ([]*hcloud.Action, error) {
+	return nil, dryrun.Skip(c.obj, "applying firewall %s to %d resources", firewall.Name, len(resources))
+}
+
+func (c *dryRunClient) RemoveFirewallResources(_ context.Context, firewall *hcloud.Firewall, resources []hcloud.FirewallResource) ([]*hcloud.Action, error) {
+	return nil, dryrun.Skip(c.obj, "removing %d resources from firewall %s", len(resources), firewall.Name)
+}
+
+func (c *dryRunClient) CreatePrimaryIP(_ context.Context, opts hcloud.PrimaryIPCreateOpts) (*hcloud.PrimaryIPCreateResult, error) {
+	return nil, dryrun.Skip(c.obj, "creating primary IP %s", opts.Name)
+}
+
+func (c *dryRunClient) UpdatePrimaryIP(_ context.Context, primaryIP *hcloud.PrimaryIP, _ hcloud.PrimaryIPUpdateOpts) (*hcloud.PrimaryIP, error) {
+	return nil, dryrun.Skip(c.obj, "updating primary IP %s", primaryIP.Name)
+}
+
+func (c *dryRunClient) DeletePrimaryIP(_ context.Context, primaryIP *hcloud.PrimaryIP) error {
+	return dryrun.Skip(c.obj, "deleting primary IP %s", primaryIP.Name)
+}
+
+func (c *dryRunClient) AssignPrimaryIP(_ context.Context, opts hcloud.PrimaryIPAssignOpts) (*hcloud.Action, error) {
+	return nil, dryrun.Skip(c.obj, "assigning primary IP %d to server %d", opts.ID, opts.AssigneeID)
+}
+
+func (c *dryRunClient) UnassignPrimaryIP(_ context.Context, id int) (*hcloud.Action, error) {
+	return nil, dryrun.Skip(c.obj, "unassigning primary IP %d", id)
+}
+
+func (c *dryRunClient) CreateFloatingIP(_ context.Context, opts hcloud.FloatingIPCreateOpts) (hcloud.FloatingIPCreateResult, error) {
+	var name string
+	if opts.Name != nil {
+		name = *opts.Name
+	}
+	return hcloud.FloatingIPCreateResult{}, dryrun.Skip(c.obj, "creating floating IP %s", name)
+}
+
+func (c *dryRunClient) DeleteFloatingIP(_ context.Context, floatingIP *hcloud.FloatingIP) error {
+	return dryrun.Skip(c.obj, "deleting floating IP %s", floatingIP.Name)
+}
+
+func (c *dryRunClient) AssignFloatingIP(_ context.Context, floatingIP *hcloud.FloatingIP, server *hcloud.Server) (*hcloud.Action, error) {
+	return nil, dryrun.Skip(c.obj, "assigning floating IP %s to server %s", floatingIP.Name, server.Name)
+}
+
+func (c *dryRunClient) UnassignFloatingIP(_ context.Context, floatingIP *hcloud.FloatingIP) (*hcloud.Action, error) {
+	return nil, dryrun.Skip(c.obj, "unassigning floating IP %s", floatingIP.Name)
+}
+
+func (c *dryRunClient) CreateVolume(_ context.Context, opts hcloud.VolumeCreateOpts) (hcloud.VolumeCreateResult, error) {
+	return hcloud.VolumeCreateResult{}, dryrun.Skip(c.obj, "creating volume %s", opts.Name)
+}
+
+func (c *dryRunClient) DeleteVolume(_ context.Context, volume *hcloud.Volume) error {
+	return dryrun.Skip(c.obj, "deleting volume %s", volume.Name)
+}
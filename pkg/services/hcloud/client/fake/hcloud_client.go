@@ -21,11 +21,15 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"reflect"
+	"strconv"
+	"strings"
 
 	"github.com/hetznercloud/hcloud-go/hcloud"
 	"github.com/pkg/errors"
 	hcloudclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client"
 	"github.com/syself/cluster-api-provider-hetzner/pkg/utils"
+	"golang.org/x/crypto/ssh"
 )
 
 // DefaultCPUCores defines the default cpu cores for HCloud machines' capacities.
@@ -39,6 +43,12 @@ type cacheHCloudClient struct {
 	placementGroupCache placementGroupCache
 	loadBalancerCache   loadBalancerCache
 	networkCache        networkCache
+	primaryIPCache      primaryIPCache
+	certificateCache    certificateCache
+	firewallCache       firewallCache
+	floatingIPCache     floatingIPCache
+	volumeCache         volumeCache
+	sshKeyCache         sshKeyCache
 }
 
 // NewClient gives reference to the fake client using cache for HCloud API.
@@ -69,6 +79,30 @@ func (c *cacheHCloudClient) Close() {
 		idMap:   make(map[int]*hcloud.Network),
 		nameMap: make(map[string]struct{}),
 	}
+	cacheHCloudClientInstance.primaryIPCache = primaryIPCache{
+		idMap:   make(map[int]*hcloud.PrimaryIP),
+		nameMap: make(map[string]struct{}),
+	}
+	cacheHCloudClientInstance.certificateCache = certificateCache{
+		idMap:   make(map[int]*hcloud.Certificate),
+		nameMap: make(map[string]struct{}),
+	}
+	cacheHCloudClientInstance.firewallCache = firewallCache{
+		idMap:   make(map[int]*hcloud.Firewall),
+		nameMap: make(map[string]struct{}),
+	}
+	cacheHCloudClientInstance.floatingIPCache = floatingIPCache{
+		idMap:   make(map[int]*hcloud.FloatingIP),
+		nameMap: make(map[string]struct{}),
+	}
+	cacheHCloudClientInstance.volumeCache = volumeCache{
+		idMap:   make(map[int]*hcloud.Volume),
+		nameMap: make(map[string]struct{}),
+	}
+	cacheHCloudClientInstance.sshKeyCache = sshKeyCache{
+		idMap:   make(map[int]*hcloud.SSHKey),
+		nameMap: make(map[string]struct{}),
+	}
 }
 
 type cacheHCloudClientFactory struct{}
@@ -90,6 +124,30 @@ var cacheHCloudClientInstance = &cacheHCloudClient{
 		idMap:   make(map[int]*hcloud.Network),
 		nameMap: make(map[string]struct{}),
 	},
+	primaryIPCache: primaryIPCache{
+		idMap:   make(map[int]*hcloud.PrimaryIP),
+		nameMap: make(map[string]struct{}),
+	},
+	certificateCache: certificateCache{
+		idMap:   make(map[int]*hcloud.Certificate),
+		nameMap: make(map[string]struct{}),
+	},
+	firewallCache: firewallCache{
+		idMap:   make(map[int]*hcloud.Firewall),
+		nameMap: make(map[string]struct{}),
+	},
+	floatingIPCache: floatingIPCache{
+		idMap:   make(map[int]*hcloud.FloatingIP),
+		nameMap: make(map[string]struct{}),
+	},
+	volumeCache: volumeCache{
+		idMap:   make(map[int]*hcloud.Volume),
+		nameMap: make(map[string]struct{}),
+	},
+	sshKeyCache: sshKeyCache{
+		idMap:   make(map[int]*hcloud.SSHKey),
+		nameMap: make(map[string]struct{}),
+	},
 }
 
 // NewHCloudClientFactory creates new fake HCloud client factories using cache.
@@ -119,6 +177,36 @@ type placementGroupCache struct {
 	nameMap map[string]struct{}
 }
 
+type primaryIPCache struct {
+	idMap   map[int]*hcloud.PrimaryIP
+	nameMap map[string]struct{}
+}
+
+type certificateCache struct {
+	idMap   map[int]*hcloud.Certificate
+	nameMap map[string]struct{}
+}
+
+type firewallCache struct {
+	idMap   map[int]*hcloud.Firewall
+	nameMap map[string]struct{}
+}
+
+type floatingIPCache struct {
+	idMap   map[int]*hcloud.FloatingIP
+	nameMap map[string]struct{}
+}
+
+type volumeCache struct {
+	idMap   map[int]*hcloud.Volume
+	nameMap map[string]struct{}
+}
+
+type sshKeyCache struct {
+	idMap   map[int]*hcloud.SSHKey
+	nameMap map[string]struct{}
+}
+
 var defaultSSHKey = hcloud.SSHKey{
 	ID:          1,
 	Name:        "testsshkey",
@@ -154,7 +242,8 @@ func (c *cacheHCloudClient) CreateLoadBalancer(ctx context.Context, opts hcloud.
 	}
 	if opts.Network != nil {
 		lb.PrivateNet = append(lb.PrivateNet, hcloud.LoadBalancerPrivateNet{
-			IP: net.IP("10.0.0.2"),
+			Network: &hcloud.Network{ID: opts.Network.ID},
+			IP:      net.IP("10.0.0.2"),
 		})
 	}
 
@@ -222,11 +311,33 @@ func (c *cacheHCloudClient) AttachLoadBalancerToNetwork(ctx context.Context, lb
 	// Add it
 	c.loadBalancerCache.idMap[lb.ID].PrivateNet = append(
 		c.loadBalancerCache.idMap[lb.ID].PrivateNet,
-		hcloud.LoadBalancerPrivateNet{IP: network.IPRange.IP},
+		hcloud.LoadBalancerPrivateNet{Network: &hcloud.Network{ID: network.ID}, IP: network.IPRange.IP},
 	)
 	return &hcloud.Action{}, nil
 }
 
+func (c *cacheHCloudClient) DetachLoadBalancerFromNetwork(ctx context.Context, lb *hcloud.LoadBalancer, opts hcloud.LoadBalancerDetachFromNetworkOpts) (*hcloud.Action, error) {
+	// Check if loadBalancer exists
+	if _, found := c.loadBalancerCache.idMap[lb.ID]; !found {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+	}
+
+	// Check if network exists
+	if _, found := c.networkCache.idMap[opts.Network.ID]; !found {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+	}
+
+	// delete it if it exists
+	for i, s := range c.loadBalancerCache.idMap[lb.ID].PrivateNet {
+		if s.Network != nil && s.Network.ID == opts.Network.ID {
+			privateNet := c.loadBalancerCache.idMap[lb.ID].PrivateNet
+			c.loadBalancerCache.idMap[lb.ID].PrivateNet = append(privateNet[:i], privateNet[i+1:]...)
+			return &hcloud.Action{}, nil
+		}
+	}
+	return nil, hcloud.Error{Code: hcloud.ErrorCodeLoadBalancerNotAttachedToNetwork, Message: "not attached"}
+}
+
 func (c *cacheHCloudClient) ChangeLoadBalancerType(ctx context.Context, lb *hcloud.LoadBalancer, opts hcloud.LoadBalancerChangeTypeOpts) (*hcloud.Action, error) {
 	// Check if loadBalancer exists
 	if _, found := c.loadBalancerCache.idMap[lb.ID]; !found {
@@ -256,7 +367,12 @@ func (c *cacheHCloudClient) UpdateLoadBalancer(ctx context.Context, lb *hcloud.L
 	}
 
 	// Update it
-	c.loadBalancerCache.idMap[lb.ID].Name = opts.Name
+	if opts.Name != "" {
+		c.loadBalancerCache.idMap[lb.ID].Name = opts.Name
+	}
+	if opts.Labels != nil {
+		c.loadBalancerCache.idMap[lb.ID].Labels = opts.Labels
+	}
 	return c.loadBalancerCache.idMap[lb.ID], nil
 }
 
@@ -360,11 +476,55 @@ func (c *cacheHCloudClient) AddServiceToLoadBalancer(ctx context.Context, lb *hc
 	}
 
 	// Add it
-	c.loadBalancerCache.idMap[lb.ID].Services = append(
-		c.loadBalancerCache.idMap[lb.ID].Services, hcloud.LoadBalancerService{ListenPort: *opts.ListenPort, DestinationPort: *opts.DestinationPort})
+	service := hcloud.LoadBalancerService{Protocol: opts.Protocol, ListenPort: *opts.ListenPort, DestinationPort: *opts.DestinationPort}
+	if opts.HTTP != nil {
+		service.HTTP.Certificates = opts.HTTP.Certificates
+	}
+	c.loadBalancerCache.idMap[lb.ID].Services = append(c.loadBalancerCache.idMap[lb.ID].Services, service)
 	return &hcloud.Action{}, nil
 }
 
+func (c *cacheHCloudClient) UpdateServiceOfLoadBalancer(ctx context.Context, lb *hcloud.LoadBalancer, listenPort int, opts hcloud.LoadBalancerUpdateServiceOpts) (*hcloud.Action, error) {
+	// Check if loadBalancer exists
+	if _, found := c.loadBalancerCache.idMap[lb.ID]; !found {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+	}
+
+	for i, s := range c.loadBalancerCache.idMap[lb.ID].Services {
+		if s.ListenPort != listenPort {
+			continue
+		}
+		if opts.Protocol != "" {
+			s.Protocol = opts.Protocol
+		}
+		if opts.DestinationPort != nil {
+			s.DestinationPort = *opts.DestinationPort
+		}
+		if opts.HTTP != nil {
+			s.HTTP.Certificates = opts.HTTP.Certificates
+		}
+		if opts.HealthCheck != nil {
+			s.HealthCheck.Protocol = opts.HealthCheck.Protocol
+			if opts.HealthCheck.Port != nil {
+				s.HealthCheck.Port = *opts.HealthCheck.Port
+			}
+			if opts.HealthCheck.Interval != nil {
+				s.HealthCheck.Interval = *opts.HealthCheck.Interval
+			}
+			if opts.HealthCheck.Timeout != nil {
+				s.HealthCheck.Timeout = *opts.HealthCheck.Timeout
+			}
+			if opts.HealthCheck.Retries != nil {
+				s.HealthCheck.Retries = *opts.HealthCheck.Retries
+			}
+		}
+		c.loadBalancerCache.idMap[lb.ID].Services[i] = s
+		return &hcloud.Action{}, nil
+	}
+
+	return nil, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+}
+
 func (c *cacheHCloudClient) DeleteServiceFromLoadBalancer(ctx context.Context, lb *hcloud.LoadBalancer, listenPort int) (*hcloud.Action, error) {
 	// Check if loadBalancer exists
 	if _, found := c.loadBalancerCache.idMap[lb.ID]; !found {
@@ -390,6 +550,33 @@ func (c *cacheHCloudClient) ListImages(ctx context.Context, opts hcloud.ImageLis
 	return []*hcloud.Image{&defaultImage}, nil
 }
 
+func (c *cacheHCloudClient) ListImagesForArchitecture(ctx context.Context, opts hcloud.ImageListOpts, architecture string) ([]*hcloud.Image, error) {
+	if architecture != "x86" {
+		return nil, nil
+	}
+	return c.ListImages(ctx, opts)
+}
+
+func (c *cacheHCloudClient) CreateImage(ctx context.Context, server *hcloud.Server, opts hcloud.ServerCreateImageOpts) (hcloud.ServerCreateImageResult, error) {
+	if _, found := c.serverCache.idMap[server.ID]; !found {
+		return hcloud.ServerCreateImageResult{}, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+	}
+	image := &hcloud.Image{
+		ID:     defaultImage.ID + server.ID,
+		Type:   opts.Type,
+		Status: hcloud.ImageStatusAvailable,
+		Labels: opts.Labels,
+	}
+	if opts.Description != nil {
+		image.Description = *opts.Description
+	}
+	return hcloud.ServerCreateImageResult{Image: image, Action: &hcloud.Action{}}, nil
+}
+
+func (c *cacheHCloudClient) DeleteImage(ctx context.Context, image *hcloud.Image) error {
+	return nil
+}
+
 func (c *cacheHCloudClient) CreateServer(ctx context.Context, opts hcloud.ServerCreateOpts) (hcloud.ServerCreateResult, error) {
 	if _, found := c.serverCache.nameMap[opts.Name]; found {
 		return hcloud.ServerCreateResult{}, fmt.Errorf("already exists")
@@ -404,11 +591,49 @@ func (c *cacheHCloudClient) CreateServer(ctx context.Context, opts hcloud.Server
 		PlacementGroup: opts.PlacementGroup,
 		Status:         hcloud.ServerStatusRunning,
 	}
+	if opts.StartAfterCreate != nil && !*opts.StartAfterCreate {
+		server.Status = hcloud.ServerStatusOff
+	}
+
+	// servers are added to their placement group
+	if opts.PlacementGroup != nil {
+		if pg, found := c.placementGroupCache.idMap[opts.PlacementGroup.ID]; found {
+			pg.Servers = append(pg.Servers, server.ID)
+		}
+	}
 
 	for _, network := range opts.Networks {
 		server.PrivateNet = append(server.PrivateNet, hcloud.ServerPrivateNet{IP: c.networkCache.idMap[network.ID].IPRange.IP})
 	}
 
+	if opts.Location != nil {
+		server.Datacenter = &hcloud.Datacenter{
+			Name:     opts.Location.Name + "-dc1",
+			Location: opts.Location,
+		}
+	}
+
+	// The public network is enabled by default
+	publicNet := opts.PublicNet
+	if publicNet == nil {
+		publicNet = &hcloud.ServerCreatePublicNet{EnableIPv4: true, EnableIPv6: true}
+	}
+	if publicNet.EnableIPv4 {
+		c.assignPrimaryIPOnCreate(server, hcloud.PrimaryIPTypeIPv4, publicNet.IPv4)
+	}
+	if publicNet.EnableIPv6 {
+		c.assignPrimaryIPOnCreate(server, hcloud.PrimaryIPTypeIPv6, publicNet.IPv6)
+	}
+
+	for _, volume := range opts.Volumes {
+		cached, found := c.volumeCache.idMap[volume.ID]
+		if !found {
+			return hcloud.ServerCreateResult{}, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+		}
+		cached.Server = server
+		server.Volumes = append(server.Volumes, cached)
+	}
+
 	// Add server to cache
 	c.serverCache.idMap[server.ID] = server
 	c.serverCache.nameMap[server.Name] = struct{}{}
@@ -418,6 +643,38 @@ func (c *cacheHCloudClient) CreateServer(ctx context.Context, opts hcloud.Server
 	}, nil
 }
 
+// assignPrimaryIPOnCreate assigns the given primary IP to the server or creates a new one.
+func (c *cacheHCloudClient) assignPrimaryIPOnCreate(server *hcloud.Server, ipType hcloud.PrimaryIPType, primaryIP *hcloud.PrimaryIP) {
+	if primaryIP != nil {
+		primaryIP = c.primaryIPCache.idMap[primaryIP.ID]
+	}
+	if primaryIP == nil {
+		autoDelete := true
+		res, err := c.CreatePrimaryIP(context.Background(), hcloud.PrimaryIPCreateOpts{
+			Name:         fmt.Sprintf("%s-%s", server.Name, ipType),
+			Type:         ipType,
+			AssigneeType: "server",
+			AutoDelete:   &autoDelete,
+		})
+		if err != nil {
+			return
+		}
+		primaryIP = res.PrimaryIP
+		primaryIP.Datacenter = server.Datacenter
+	}
+	setServerPrimaryIP(server, primaryIP)
+}
+
+func setServerPrimaryIP(server *hcloud.Server, primaryIP *hcloud.PrimaryIP) {
+	primaryIP.AssigneeID = server.ID
+	primaryIP.AssigneeType = "server"
+	if primaryIP.Type == hcloud.PrimaryIPTypeIPv4 {
+		server.PublicNet.IPv4 = hcloud.ServerPublicNetIPv4{ID: primaryIP.ID, IP: primaryIP.IP}
+	} else {
+		server.PublicNet.IPv6 = hcloud.ServerPublicNetIPv6{ID: primaryIP.ID, IP: primaryIP.IP}
+	}
+}
+
 func (c *cacheHCloudClient) AttachServerToNetwork(ctx context.Context, server *hcloud.Server, opts hcloud.ServerAttachToNetworkOpts) (*hcloud.Action, error) {
 	// Check if network exists
 	if _, found := c.networkCache.idMap[opts.Network.ID]; !found {
@@ -432,7 +689,7 @@ func (c *cacheHCloudClient) AttachServerToNetwork(ctx context.Context, server *h
 	// check if already exists
 	for _, s := range c.networkCache.idMap[opts.Network.ID].Servers {
 		if s.ID == server.ID {
-			return nil, hcloud.Error{Code: hcloud.ErrorCodeServerAlreadyAdded, Message: "already added"}
+			return nil, hcloud.Error{Code: hcloud.ErrorCodeServerAlreadyAttached, Message: "already attached"}
 		}
 	}
 
@@ -472,6 +729,14 @@ func (c *cacheHCloudClient) ShutdownServer(ctx context.Context, server *hcloud.S
 	return &hcloud.Action{}, nil
 }
 
+func (c *cacheHCloudClient) PowerOffServer(ctx context.Context, server *hcloud.Server) (*hcloud.Action, error) {
+	if _, found := c.serverCache.idMap[server.ID]; !found {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+	}
+	c.serverCache.idMap[server.ID].Status = hcloud.ServerStatusOff
+	return &hcloud.Action{}, nil
+}
+
 func (c *cacheHCloudClient) PowerOnServer(ctx context.Context, server *hcloud.Server) (*hcloud.Action, error) {
 	if _, found := c.serverCache.idMap[server.ID]; !found {
 		return nil, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
@@ -480,16 +745,141 @@ func (c *cacheHCloudClient) PowerOnServer(ctx context.Context, server *hcloud.Se
 	return &hcloud.Action{}, nil
 }
 
+func (c *cacheHCloudClient) ChangeServerType(ctx context.Context, server *hcloud.Server, opts hcloud.ServerChangeTypeOpts) (*hcloud.Action, error) {
+	if _, found := c.serverCache.idMap[server.ID]; !found {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+	}
+	if c.serverCache.idMap[server.ID].Status != hcloud.ServerStatusOff {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeServerNotStopped, Message: "server is not stopped"}
+	}
+	c.serverCache.idMap[server.ID].ServerType = opts.ServerType
+	return &hcloud.Action{}, nil
+}
+
+func (c *cacheHCloudClient) ChangeServerProtection(ctx context.Context, server *hcloud.Server, opts hcloud.ServerChangeProtectionOpts) (*hcloud.Action, error) {
+	if _, found := c.serverCache.idMap[server.ID]; !found {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+	}
+	if opts.Delete != nil {
+		c.serverCache.idMap[server.ID].Protection.Delete = *opts.Delete
+	}
+	if opts.Rebuild != nil {
+		c.serverCache.idMap[server.ID].Protection.Rebuild = *opts.Rebuild
+	}
+	return &hcloud.Action{}, nil
+}
+
+func (c *cacheHCloudClient) EnableServerRescue(ctx context.Context, server *hcloud.Server, opts hcloud.ServerEnableRescueOpts) (hcloud.ServerEnableRescueResult, error) {
+	if _, found := c.serverCache.idMap[server.ID]; !found {
+		return hcloud.ServerEnableRescueResult{}, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+	}
+	c.serverCache.idMap[server.ID].RescueEnabled = true
+	return hcloud.ServerEnableRescueResult{Action: &hcloud.Action{}}, nil
+}
+
+func (c *cacheHCloudClient) DisableServerRescue(ctx context.Context, server *hcloud.Server) (*hcloud.Action, error) {
+	if _, found := c.serverCache.idMap[server.ID]; !found {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+	}
+	c.serverCache.idMap[server.ID].RescueEnabled = false
+	return &hcloud.Action{}, nil
+}
+
+func (c *cacheHCloudClient) ResetServer(ctx context.Context, server *hcloud.Server) (*hcloud.Action, error) {
+	if _, found := c.serverCache.idMap[server.ID]; !found {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+	}
+	c.serverCache.idMap[server.ID].Status = hcloud.ServerStatusRunning
+	return &hcloud.Action{}, nil
+}
+
+func (c *cacheHCloudClient) EnableServerBackup(ctx context.Context, server *hcloud.Server) (*hcloud.Action, error) {
+	if _, found := c.serverCache.idMap[server.ID]; !found {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+	}
+	c.serverCache.idMap[server.ID].BackupWindow = "22-02"
+	return &hcloud.Action{}, nil
+}
+
+func (c *cacheHCloudClient) DisableServerBackup(ctx context.Context, server *hcloud.Server) (*hcloud.Action, error) {
+	if _, found := c.serverCache.idMap[server.ID]; !found {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+	}
+	c.serverCache.idMap[server.ID].BackupWindow = ""
+	return &hcloud.Action{}, nil
+}
+
+func (c *cacheHCloudClient) GetISO(ctx context.Context, idOrName string) (*hcloud.ISO, error) {
+	// every ISO exists, except the ones that are named not-found
+	if idOrName == "not-found" {
+		return nil, nil
+	}
+	return &hcloud.ISO{ID: 1, Name: idOrName}, nil
+}
+
+func (c *cacheHCloudClient) AttachISO(ctx context.Context, server *hcloud.Server, iso *hcloud.ISO) (*hcloud.Action, error) {
+	if _, found := c.serverCache.idMap[server.ID]; !found {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+	}
+	c.serverCache.idMap[server.ID].ISO = iso
+	return &hcloud.Action{}, nil
+}
+
+func (c *cacheHCloudClient) DetachISO(ctx context.Context, server *hcloud.Server) (*hcloud.Action, error) {
+	if _, found := c.serverCache.idMap[server.ID]; !found {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+	}
+	c.serverCache.idMap[server.ID].ISO = nil
+	return &hcloud.Action{}, nil
+}
+
 func (c *cacheHCloudClient) DeleteServer(ctx context.Context, server *hcloud.Server) error {
 	if _, found := c.serverCache.idMap[server.ID]; !found {
 		return hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
 	}
 	n := c.serverCache.idMap[server.ID]
+	if n.Protection.Delete {
+		return hcloud.Error{Code: hcloud.ErrorCodeProtected, Message: "server is protected"}
+	}
 	delete(c.serverCache.nameMap, n.Name)
 	delete(c.serverCache.idMap, server.ID)
+
+	// floating IPs of a deleted server are unassigned
+	for _, floatingIP := range c.floatingIPCache.idMap {
+		if floatingIP.Server != nil && floatingIP.Server.ID == server.ID {
+			floatingIP.Server = nil
+		}
+	}
+
+	// deleted servers are removed from their placement group
+	for _, pg := range c.placementGroupCache.idMap {
+		for i, id := range pg.Servers {
+			if id == server.ID {
+				pg.Servers = append(pg.Servers[:i:i], pg.Servers[i+1:]...)
+				break
+			}
+		}
+	}
+
+	// volumes of a deleted server are detached
+	for _, volume := range c.volumeCache.idMap {
+		if volume.Server != nil && volume.Server.ID == server.ID {
+			volume.Server = nil
+		}
+	}
 	return nil
 }
 
+func (c *cacheHCloudClient) UpdateServer(ctx context.Context, server *hcloud.Server, opts hcloud.ServerUpdateOpts) (*hcloud.Server, error) {
+	if _, found := c.serverCache.idMap[server.ID]; !found {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+	}
+	if opts.Labels != nil {
+		c.serverCache.idMap[server.ID].Labels = opts.Labels
+	}
+	return c.serverCache.idMap[server.ID], nil
+}
+
 func (c *cacheHCloudClient) ListServerTypes(ctx context.Context) ([]*hcloud.ServerType, error) {
 	return []*hcloud.ServerType{
 		{
@@ -513,6 +903,10 @@ func (c *cacheHCloudClient) ListServerTypes(ctx context.Context) ([]*hcloud.Serv
 	}, nil
 }
 
+func (c *cacheHCloudClient) ListServerTypeDeprecations(ctx context.Context) (map[string]hcloudclient.ServerTypeDeprecation, error) {
+	return map[string]hcloudclient.ServerTypeDeprecation{}, nil
+}
+
 func (c *cacheHCloudClient) CreateNetwork(ctx context.Context, opts hcloud.NetworkCreateOpts) (*hcloud.Network, error) {
 	if _, found := c.networkCache.nameMap[opts.Name]; found {
 		return nil, fmt.Errorf("already exists")
@@ -556,6 +950,20 @@ func (c *cacheHCloudClient) ListNetworks(ctx context.Context, opts hcloud.Networ
 	return networks, nil
 }
 
+func (c *cacheHCloudClient) GetNetwork(ctx context.Context, idOrName string) (*hcloud.Network, error) {
+	if id, err := strconv.Atoi(idOrName); err == nil {
+		if network, found := c.networkCache.idMap[id]; found {
+			return network, nil
+		}
+	}
+	for _, network := range c.networkCache.idMap {
+		if network.Name == idOrName {
+			return network, nil
+		}
+	}
+	return nil, nil
+}
+
 func (c *cacheHCloudClient) DeleteNetwork(ctx context.Context, network *hcloud.Network) error {
 	if _, found := c.networkCache.idMap[network.ID]; !found {
 		return hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
@@ -566,35 +974,147 @@ func (c *cacheHCloudClient) DeleteNetwork(ctx context.Context, network *hcloud.N
 	return nil
 }
 
-func (c *cacheHCloudClient) ListSSHKeys(ctx context.Context, opts hcloud.SSHKeyListOpts) ([]*hcloud.SSHKey, error) {
-	return []*hcloud.SSHKey{&defaultSSHKey}, nil
+func (c *cacheHCloudClient) AddSubnetToNetwork(ctx context.Context, network *hcloud.Network, opts hcloud.NetworkAddSubnetOpts) (*hcloud.Action, error) {
+	n, found := c.networkCache.idMap[network.ID]
+	if !found {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+	}
+	for _, subnet := range n.Subnets {
+		if subnet.IPRange.Contains(opts.Subnet.IPRange.IP) || opts.Subnet.IPRange.Contains(subnet.IPRange.IP) {
+			return nil, hcloud.Error{Code: hcloud.ErrorCodeConflict, Message: "subnet overlaps"}
+		}
+	}
+	n.Subnets = append(n.Subnets, opts.Subnet)
+	return &hcloud.Action{}, nil
 }
 
-func (c *cacheHCloudClient) CreatePlacementGroup(ctx context.Context, opts hcloud.PlacementGroupCreateOpts) (hcloud.PlacementGroupCreateResult, error) {
-	if _, found := c.placementGroupCache.nameMap[opts.Name]; found {
-		return hcloud.PlacementGroupCreateResult{}, fmt.Errorf("already exists")
+func (c *cacheHCloudClient) DeleteSubnetFromNetwork(ctx context.Context, network *hcloud.Network, opts hcloud.NetworkDeleteSubnetOpts) (*hcloud.Action, error) {
+	n, found := c.networkCache.idMap[network.ID]
+	if !found {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
 	}
-
-	placementGroup := &hcloud.PlacementGroup{
-		ID:     len(c.placementGroupCache.idMap) + 1,
-		Name:   opts.Name,
-		Labels: opts.Labels,
-		Type:   opts.Type,
+	for i, subnet := range n.Subnets {
+		if subnet.IPRange.String() == opts.Subnet.IPRange.String() {
+			n.Subnets = append(n.Subnets[:i], n.Subnets[i+1:]...)
+			return &hcloud.Action{}, nil
+		}
 	}
+	return nil, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "subnet not found"}
+}
 
-	// Add placementGroup to cache
-	c.placementGroupCache.idMap[placementGroup.ID] = placementGroup
-	c.placementGroupCache.nameMap[placementGroup.Name] = struct{}{}
-
-	return hcloud.PlacementGroupCreateResult{
-		PlacementGroup: placementGroup,
-		Action:         &hcloud.Action{},
-	}, nil
+func (c *cacheHCloudClient) AddRouteToNetwork(ctx context.Context, network *hcloud.Network, opts hcloud.NetworkAddRouteOpts) (*hcloud.Action, error) {
+	n, found := c.networkCache.idMap[network.ID]
+	if !found {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+	}
+	for _, route := range n.Routes {
+		if route.Destination.String() == opts.Route.Destination.String() {
+			return nil, hcloud.Error{Code: hcloud.ErrorCodeConflict, Message: "route already exists"}
+		}
+	}
+	n.Routes = append(n.Routes, opts.Route)
+	return &hcloud.Action{}, nil
 }
 
-func (c *cacheHCloudClient) DeletePlacementGroup(ctx context.Context, id int) error {
-	if _, found := c.placementGroupCache.idMap[id]; !found {
-		return hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+func (c *cacheHCloudClient) DeleteRouteFromNetwork(ctx context.Context, network *hcloud.Network, opts hcloud.NetworkDeleteRouteOpts) (*hcloud.Action, error) {
+	n, found := c.networkCache.idMap[network.ID]
+	if !found {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+	}
+	for i, route := range n.Routes {
+		if route.Destination.String() == opts.Route.Destination.String() && route.Gateway.Equal(opts.Route.Gateway) {
+			n.Routes = append(n.Routes[:i], n.Routes[i+1:]...)
+			return &hcloud.Action{}, nil
+		}
+	}
+	return nil, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "route not found"}
+}
+
+func (c *cacheHCloudClient) ListSSHKeys(ctx context.Context, opts hcloud.SSHKeyListOpts) ([]*hcloud.SSHKey, error) {
+	labels, err := utils.LabelSelectorToLabels(opts.LabelSelector)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert label selector to labels")
+	}
+
+	var sshKeys []*hcloud.SSHKey
+	if len(labels) == 0 {
+		sshKeys = append(sshKeys, &defaultSSHKey)
+	}
+	for _, sshKey := range c.sshKeyCache.idMap {
+		allLabelsFound := true
+		for key, label := range labels {
+			if val, found := sshKey.Labels[key]; !found || val != label {
+				allLabelsFound = false
+				break
+			}
+		}
+		if allLabelsFound {
+			sshKeys = append(sshKeys, sshKey)
+		}
+	}
+	return sshKeys, nil
+}
+
+func (c *cacheHCloudClient) CreateSSHKey(ctx context.Context, opts hcloud.SSHKeyCreateOpts) (*hcloud.SSHKey, error) {
+	if _, found := c.sshKeyCache.nameMap[opts.Name]; found || opts.Name == defaultSSHKey.Name {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeUniquenessError, Message: "already exists"}
+	}
+
+	publicKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(opts.PublicKey))
+	if err != nil {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeInvalidInput, Message: "invalid public key"}
+	}
+
+	sshKey := &hcloud.SSHKey{
+		ID:          len(c.sshKeyCache.idMap) + 2,
+		Name:        opts.Name,
+		Fingerprint: ssh.FingerprintLegacyMD5(publicKey),
+		PublicKey:   opts.PublicKey,
+		Labels:      opts.Labels,
+	}
+
+	// Add ssh key to cache
+	c.sshKeyCache.idMap[sshKey.ID] = sshKey
+	c.sshKeyCache.nameMap[sshKey.Name] = struct{}{}
+
+	return sshKey, nil
+}
+
+func (c *cacheHCloudClient) DeleteSSHKey(ctx context.Context, sshKey *hcloud.SSHKey) error {
+	n, found := c.sshKeyCache.idMap[sshKey.ID]
+	if !found {
+		return hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+	}
+	delete(c.sshKeyCache.nameMap, n.Name)
+	delete(c.sshKeyCache.idMap, n.ID)
+	return nil
+}
+
+func (c *cacheHCloudClient) CreatePlacementGroup(ctx context.Context, opts hcloud.PlacementGroupCreateOpts) (hcloud.PlacementGroupCreateResult, error) {
+	if _, found := c.placementGroupCache.nameMap[opts.Name]; found {
+		return hcloud.PlacementGroupCreateResult{}, fmt.Errorf("already exists")
+	}
+
+	placementGroup := &hcloud.PlacementGroup{
+		ID:     len(c.placementGroupCache.idMap) + 1,
+		Name:   opts.Name,
+		Labels: opts.Labels,
+		Type:   opts.Type,
+	}
+
+	// Add placementGroup to cache
+	c.placementGroupCache.idMap[placementGroup.ID] = placementGroup
+	c.placementGroupCache.nameMap[placementGroup.Name] = struct{}{}
+
+	return hcloud.PlacementGroupCreateResult{
+		PlacementGroup: placementGroup,
+		Action:         &hcloud.Action{},
+	}, nil
+}
+
+func (c *cacheHCloudClient) DeletePlacementGroup(ctx context.Context, id int) error {
+	if _, found := c.placementGroupCache.idMap[id]; !found {
+		return hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
 	}
 	n := c.placementGroupCache.idMap[id]
 	delete(c.placementGroupCache.nameMap, n.Name)
@@ -647,6 +1167,251 @@ func (c *cacheHCloudClient) AddServerToPlacementGroup(ctx context.Context, serve
 	return &hcloud.Action{}, nil
 }
 
+func (c *cacheHCloudClient) CreateFirewall(ctx context.Context, opts hcloud.FirewallCreateOpts) (hcloud.FirewallCreateResult, error) {
+	if _, found := c.firewallCache.nameMap[opts.Name]; found {
+		return hcloud.FirewallCreateResult{}, hcloud.Error{Code: hcloud.ErrorCodeUniquenessError, Message: "already exists"}
+	}
+
+	firewall := &hcloud.Firewall{
+		ID:        len(c.firewallCache.idMap) + 1,
+		Name:      opts.Name,
+		Labels:    opts.Labels,
+		Rules:     opts.Rules,
+		AppliedTo: opts.ApplyTo,
+	}
+
+	// Add firewall to cache
+	c.firewallCache.idMap[firewall.ID] = firewall
+	c.firewallCache.nameMap[firewall.Name] = struct{}{}
+
+	return hcloud.FirewallCreateResult{Firewall: firewall}, nil
+}
+
+func (c *cacheHCloudClient) ListFirewalls(ctx context.Context, opts hcloud.FirewallListOpts) ([]*hcloud.Firewall, error) {
+	firewalls := make([]*hcloud.Firewall, 0, len(c.firewallCache.idMap))
+
+	labels, err := utils.LabelSelectorToLabels(opts.LabelSelector)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert label selector to labels")
+	}
+
+	for _, firewall := range c.firewallCache.idMap {
+		allLabelsFound := true
+		for key, label := range labels {
+			if val, found := firewall.Labels[key]; !found || val != label {
+				allLabelsFound = false
+				break
+			}
+		}
+		if allLabelsFound {
+			firewalls = append(firewalls, firewall)
+		}
+	}
+
+	return firewalls, nil
+}
+
+func (c *cacheHCloudClient) DeleteFirewall(ctx context.Context, firewall *hcloud.Firewall) error {
+	n, found := c.firewallCache.idMap[firewall.ID]
+	if !found {
+		return hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+	}
+	if len(n.AppliedTo) > 0 {
+		return hcloud.Error{Code: hcloud.ErrorCodeResourceInUse, Message: "firewall is still in use"}
+	}
+	delete(c.firewallCache.nameMap, n.Name)
+	delete(c.firewallCache.idMap, n.ID)
+	return nil
+}
+
+func (c *cacheHCloudClient) SetFirewallRules(ctx context.Context, firewall *hcloud.Firewall, opts hcloud.FirewallSetRulesOpts) ([]*hcloud.Action, error) {
+	if _, found := c.firewallCache.idMap[firewall.ID]; !found {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+	}
+	c.firewallCache.idMap[firewall.ID].Rules = opts.Rules
+	return []*hcloud.Action{}, nil
+}
+
+func (c *cacheHCloudClient) ApplyFirewallResources(ctx context.Context, firewall *hcloud.Firewall, resources []hcloud.FirewallResource) ([]*hcloud.Action, error) {
+	if _, found := c.firewallCache.idMap[firewall.ID]; !found {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+	}
+	c.firewallCache.idMap[firewall.ID].AppliedTo = append(c.firewallCache.idMap[firewall.ID].AppliedTo, resources...)
+	return []*hcloud.Action{}, nil
+}
+
+func (c *cacheHCloudClient) RemoveFirewallResources(ctx context.Context, firewall *hcloud.Firewall, resources []hcloud.FirewallResource) ([]*hcloud.Action, error) {
+	cached, found := c.firewallCache.idMap[firewall.ID]
+	if !found {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+	}
+	var appliedTo []hcloud.FirewallResource
+	for _, applied := range cached.AppliedTo {
+		removed := false
+		for _, resource := range resources {
+			if reflect.DeepEqual(applied, resource) {
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			appliedTo = append(appliedTo, applied)
+		}
+	}
+	cached.AppliedTo = appliedTo
+	return []*hcloud.Action{}, nil
+}
+
+func (c *cacheHCloudClient) CreatePrimaryIP(ctx context.Context, opts hcloud.PrimaryIPCreateOpts) (*hcloud.PrimaryIPCreateResult, error) {
+	if _, found := c.primaryIPCache.nameMap[opts.Name]; found {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeUniquenessError, Message: "already exists"}
+	}
+
+	ip := net.ParseIP(fmt.Sprintf("1.2.3.%d", len(c.primaryIPCache.idMap)+1))
+	if opts.Type == hcloud.PrimaryIPTypeIPv6 {
+		ip = net.ParseIP(fmt.Sprintf("2001:db8::%d", len(c.primaryIPCache.idMap)+1))
+	}
+
+	primaryIP := &hcloud.PrimaryIP{
+		ID:           len(c.primaryIPCache.idMap) + 1,
+		Name:         opts.Name,
+		Labels:       opts.Labels,
+		Type:         opts.Type,
+		IP:           ip,
+		AssigneeType: opts.AssigneeType,
+		Datacenter: &hcloud.Datacenter{
+			Name:     opts.Datacenter,
+			Location: &hcloud.Location{Name: strings.Split(opts.Datacenter, "-")[0]},
+		},
+	}
+	if opts.AutoDelete != nil {
+		primaryIP.AutoDelete = *opts.AutoDelete
+	}
+
+	if opts.AssigneeID != nil {
+		server, found := c.serverCache.idMap[*opts.AssigneeID]
+		if !found {
+			return nil, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+		}
+		if server.Status != hcloud.ServerStatusOff {
+			return nil, hcloud.Error{Code: hcloud.ErrorCodeServerNotStopped, Message: "server not stopped"}
+		}
+		primaryIP.Datacenter = server.Datacenter
+		setServerPrimaryIP(server, primaryIP)
+	}
+
+	// Add primary IP to cache
+	c.primaryIPCache.idMap[primaryIP.ID] = primaryIP
+	c.primaryIPCache.nameMap[primaryIP.Name] = struct{}{}
+
+	return &hcloud.PrimaryIPCreateResult{
+		PrimaryIP: primaryIP,
+		Action:    &hcloud.Action{},
+	}, nil
+}
+
+func (c *cacheHCloudClient) GetPrimaryIP(ctx context.Context, id int) (*hcloud.PrimaryIP, error) {
+	primaryIP, found := c.primaryIPCache.idMap[id]
+	if !found {
+		return nil, nil
+	}
+	return primaryIP, nil
+}
+
+func (c *cacheHCloudClient) ListPrimaryIPs(ctx context.Context, opts hcloud.PrimaryIPListOpts) ([]*hcloud.PrimaryIP, error) {
+	primaryIPs := make([]*hcloud.PrimaryIP, 0, len(c.primaryIPCache.idMap))
+
+	labels, err := utils.LabelSelectorToLabels(opts.LabelSelector)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert label selector to labels")
+	}
+
+	for _, primaryIP := range c.primaryIPCache.idMap {
+		if opts.Name != "" && primaryIP.Name != opts.Name {
+			continue
+		}
+		allLabelsFound := true
+		for key, label := range labels {
+			if val, found := primaryIP.Labels[key]; !found || val != label {
+				allLabelsFound = false
+				break
+			}
+		}
+		if allLabelsFound {
+			primaryIPs = append(primaryIPs, primaryIP)
+		}
+	}
+
+	return primaryIPs, nil
+}
+
+func (c *cacheHCloudClient) UpdatePrimaryIP(ctx context.Context, primaryIP *hcloud.PrimaryIP, opts hcloud.PrimaryIPUpdateOpts) (*hcloud.PrimaryIP, error) {
+	cached, found := c.primaryIPCache.idMap[primaryIP.ID]
+	if !found {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+	}
+	if opts.Labels != nil {
+		cached.Labels = *opts.Labels
+	}
+	if opts.AutoDelete != nil {
+		cached.AutoDelete = *opts.AutoDelete
+	}
+	if opts.Name != "" {
+		delete(c.primaryIPCache.nameMap, cached.Name)
+		cached.Name = opts.Name
+		c.primaryIPCache.nameMap[cached.Name] = struct{}{}
+	}
+	return cached, nil
+}
+
+func (c *cacheHCloudClient) DeletePrimaryIP(ctx context.Context, primaryIP *hcloud.PrimaryIP) error {
+	if _, found := c.primaryIPCache.idMap[primaryIP.ID]; !found {
+		return hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+	}
+	n := c.primaryIPCache.idMap[primaryIP.ID]
+	delete(c.primaryIPCache.nameMap, n.Name)
+	delete(c.primaryIPCache.idMap, primaryIP.ID)
+	return nil
+}
+
+func (c *cacheHCloudClient) AssignPrimaryIP(ctx context.Context, opts hcloud.PrimaryIPAssignOpts) (*hcloud.Action, error) {
+	primaryIP, found := c.primaryIPCache.idMap[opts.ID]
+	if !found {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+	}
+	server, found := c.serverCache.idMap[opts.AssigneeID]
+	if !found {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+	}
+	if server.Status != hcloud.ServerStatusOff {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeServerNotStopped, Message: "server not stopped"}
+	}
+	if primaryIP.AssigneeID != 0 {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeServerAlreadyAttached, Message: "already assigned"}
+	}
+	setServerPrimaryIP(server, primaryIP)
+	return &hcloud.Action{}, nil
+}
+
+func (c *cacheHCloudClient) UnassignPrimaryIP(ctx context.Context, id int) (*hcloud.Action, error) {
+	primaryIP, found := c.primaryIPCache.idMap[id]
+	if !found {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+	}
+	if server, found := c.serverCache.idMap[primaryIP.AssigneeID]; found {
+		if server.Status != hcloud.ServerStatusOff {
+			return nil, hcloud.Error{Code: hcloud.ErrorCodeServerNotStopped, Message: "server not stopped"}
+		}
+		if primaryIP.Type == hcloud.PrimaryIPTypeIPv4 {
+			server.PublicNet.IPv4 = hcloud.ServerPublicNetIPv4{}
+		} else {
+			server.PublicNet.IPv6 = hcloud.ServerPublicNetIPv6{}
+		}
+	}
+	primaryIP.AssigneeID = 0
+	return &hcloud.Action{}, nil
+}
+
 func isIntInList(list []int, str int) bool {
 	for _, s := range list {
 		if s == str {
@@ -655,3 +1420,228 @@ func isIntInList(list []int, str int) bool {
 	}
 	return false
 }
+
+func (c *cacheHCloudClient) CreateCertificate(ctx context.Context, opts hcloud.CertificateCreateOpts) (hcloud.CertificateCreateResult, error) {
+	if _, found := c.certificateCache.nameMap[opts.Name]; found {
+		return hcloud.CertificateCreateResult{}, hcloud.Error{Code: hcloud.ErrorCodeUniquenessError, Message: "already exists"}
+	}
+
+	id := len(c.certificateCache.idMap) + 1
+	for _, found := c.certificateCache.idMap[id]; found; _, found = c.certificateCache.idMap[id] {
+		id++
+	}
+
+	certificate := &hcloud.Certificate{
+		ID:          id,
+		Name:        opts.Name,
+		Labels:      opts.Labels,
+		Type:        opts.Type,
+		DomainNames: opts.DomainNames,
+	}
+	if opts.Type == hcloud.CertificateTypeManaged {
+		certificate.Status = &hcloud.CertificateStatus{Issuance: hcloud.CertificateStatusTypePending}
+	}
+
+	c.certificateCache.idMap[certificate.ID] = certificate
+	c.certificateCache.nameMap[certificate.Name] = struct{}{}
+	return hcloud.CertificateCreateResult{Certificate: certificate, Action: &hcloud.Action{}}, nil
+}
+
+func (c *cacheHCloudClient) ListCertificates(ctx context.Context, opts hcloud.CertificateListOpts) ([]*hcloud.Certificate, error) {
+	certificates := make([]*hcloud.Certificate, 0, len(c.certificateCache.idMap))
+
+	labels, err := utils.LabelSelectorToLabels(opts.LabelSelector)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert label selector to labels")
+	}
+
+	for _, certificate := range c.certificateCache.idMap {
+		if opts.Name != "" && certificate.Name != opts.Name {
+			continue
+		}
+		allLabelsFound := true
+		for key, label := range labels {
+			if val, found := certificate.Labels[key]; !found || val != label {
+				allLabelsFound = false
+				break
+			}
+		}
+		if allLabelsFound {
+			certificates = append(certificates, certificate)
+		}
+	}
+
+	return certificates, nil
+}
+
+func (c *cacheHCloudClient) DeleteCertificate(ctx context.Context, certificate *hcloud.Certificate) error {
+	n, found := c.certificateCache.idMap[certificate.ID]
+	if !found {
+		return hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+	}
+	delete(c.certificateCache.nameMap, n.Name)
+	delete(c.certificateCache.idMap, certificate.ID)
+	return nil
+}
+
+func (c *cacheHCloudClient) CreateFloatingIP(ctx context.Context, opts hcloud.FloatingIPCreateOpts) (hcloud.FloatingIPCreateResult, error) {
+	var name string
+	if opts.Name != nil {
+		name = *opts.Name
+	}
+	if _, found := c.floatingIPCache.nameMap[name]; found {
+		return hcloud.FloatingIPCreateResult{}, hcloud.Error{Code: hcloud.ErrorCodeUniquenessError, Message: "already exists"}
+	}
+
+	ip := net.ParseIP(fmt.Sprintf("5.6.7.%d", len(c.floatingIPCache.idMap)+1))
+	if opts.Type == hcloud.FloatingIPTypeIPv6 {
+		ip = net.ParseIP(fmt.Sprintf("2001:db8:1::%d", len(c.floatingIPCache.idMap)+1))
+	}
+
+	floatingIP := &hcloud.FloatingIP{
+		ID:           len(c.floatingIPCache.idMap) + 1,
+		Name:         name,
+		Labels:       opts.Labels,
+		Type:         opts.Type,
+		IP:           ip,
+		HomeLocation: opts.HomeLocation,
+	}
+	if opts.Server != nil {
+		floatingIP.Server = opts.Server
+	}
+
+	// Add floating IP to cache
+	c.floatingIPCache.idMap[floatingIP.ID] = floatingIP
+	c.floatingIPCache.nameMap[floatingIP.Name] = struct{}{}
+
+	return hcloud.FloatingIPCreateResult{
+		FloatingIP: floatingIP,
+		Action:     &hcloud.Action{},
+	}, nil
+}
+
+func (c *cacheHCloudClient) ListFloatingIPs(ctx context.Context, opts hcloud.FloatingIPListOpts) ([]*hcloud.FloatingIP, error) {
+	floatingIPs := make([]*hcloud.FloatingIP, 0, len(c.floatingIPCache.idMap))
+
+	labels, err := utils.LabelSelectorToLabels(opts.LabelSelector)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert label selector to labels")
+	}
+
+	for _, floatingIP := range c.floatingIPCache.idMap {
+		if opts.Name != "" && floatingIP.Name != opts.Name {
+			continue
+		}
+		allLabelsFound := true
+		for key, label := range labels {
+			if val, found := floatingIP.Labels[key]; !found || val != label {
+				allLabelsFound = false
+				break
+			}
+		}
+		if allLabelsFound {
+			floatingIPs = append(floatingIPs, floatingIP)
+		}
+	}
+
+	return floatingIPs, nil
+}
+
+func (c *cacheHCloudClient) DeleteFloatingIP(ctx context.Context, floatingIP *hcloud.FloatingIP) error {
+	n, found := c.floatingIPCache.idMap[floatingIP.ID]
+	if !found {
+		return hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+	}
+	delete(c.floatingIPCache.nameMap, n.Name)
+	delete(c.floatingIPCache.idMap, floatingIP.ID)
+	return nil
+}
+
+func (c *cacheHCloudClient) AssignFloatingIP(ctx context.Context, floatingIP *hcloud.FloatingIP, server *hcloud.Server) (*hcloud.Action, error) {
+	cached, found := c.floatingIPCache.idMap[floatingIP.ID]
+	if !found {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+	}
+	cachedServer, found := c.serverCache.idMap[server.ID]
+	if !found {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+	}
+	cached.Server = cachedServer
+	return &hcloud.Action{}, nil
+}
+
+func (c *cacheHCloudClient) UnassignFloatingIP(ctx context.Context, floatingIP *hcloud.FloatingIP) (*hcloud.Action, error) {
+	cached, found := c.floatingIPCache.idMap[floatingIP.ID]
+	if !found {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+	}
+	cached.Server = nil
+	return &hcloud.Action{}, nil
+}
+
+func (c *cacheHCloudClient) CreateVolume(ctx context.Context, opts hcloud.VolumeCreateOpts) (hcloud.VolumeCreateResult, error) {
+	if _, found := c.volumeCache.nameMap[opts.Name]; found {
+		return hcloud.VolumeCreateResult{}, hcloud.Error{Code: hcloud.ErrorCodeUniquenessError, Message: "already exists"}
+	}
+
+	id := len(c.volumeCache.idMap) + 1
+	volume := &hcloud.Volume{
+		ID:          id,
+		Name:        opts.Name,
+		Status:      hcloud.VolumeStatusAvailable,
+		Server:      opts.Server,
+		Location:    opts.Location,
+		Size:        opts.Size,
+		Labels:      opts.Labels,
+		LinuxDevice: fmt.Sprintf("/dev/disk/by-id/scsi-0HC_Volume_%d", id),
+	}
+
+	// Add volume to cache
+	c.volumeCache.idMap[volume.ID] = volume
+	c.volumeCache.nameMap[volume.Name] = struct{}{}
+
+	return hcloud.VolumeCreateResult{
+		Volume: volume,
+		Action: &hcloud.Action{},
+	}, nil
+}
+
+func (c *cacheHCloudClient) ListVolumes(ctx context.Context, opts hcloud.VolumeListOpts) ([]*hcloud.Volume, error) {
+	volumes := make([]*hcloud.Volume, 0, len(c.volumeCache.idMap))
+
+	labels, err := utils.LabelSelectorToLabels(opts.LabelSelector)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert label selector to labels")
+	}
+
+	for _, volume := range c.volumeCache.idMap {
+		if opts.Name != "" && volume.Name != opts.Name {
+			continue
+		}
+		allLabelsFound := true
+		for key, label := range labels {
+			if val, found := volume.Labels[key]; !found || val != label {
+				allLabelsFound = false
+				break
+			}
+		}
+		if allLabelsFound {
+			volumes = append(volumes, volume)
+		}
+	}
+
+	return volumes, nil
+}
+
+func (c *cacheHCloudClient) DeleteVolume(ctx context.Context, volume *hcloud.Volume) error {
+	n, found := c.volumeCache.idMap[volume.ID]
+	if !found {
+		return hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+	}
+	if n.Server != nil {
+		return hcloud.Error{Code: hcloud.ErrorCodeLocked, Message: "volume is attached"}
+	}
+	delete(c.volumeCache.nameMap, n.Name)
+	delete(c.volumeCache.idMap, volume.ID)
+	return nil
+}
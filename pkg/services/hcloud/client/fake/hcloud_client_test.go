@@ -322,6 +322,17 @@ var _ = Describe("Load balancer", func() {
 		Expect(err).ToNot(Succeed())
 	})
 
+	It("detaches the load balancer from a network", func() {
+		_, err := client.DetachLoadBalancerFromNetwork(ctx, lb, hcloud.LoadBalancerDetachFromNetworkOpts{Network: network})
+		Expect(err).To(Succeed())
+		resp, err := client.ListLoadBalancers(ctx, listOpts)
+		Expect(err).To(BeNil())
+		Expect(resp[0].PrivateNet).To(BeEmpty())
+
+		_, err = client.DetachLoadBalancerFromNetwork(ctx, lb, hcloud.LoadBalancerDetachFromNetworkOpts{Network: network})
+		Expect(hcloud.IsError(err, hcloud.ErrorCodeLoadBalancerNotAttachedToNetwork)).To(BeTrue())
+	})
+
 	It("adds service to load balancer", func() {
 		Expect(len(lb.Services)).To(Equal(0))
 		listenPort := 443
@@ -483,6 +494,18 @@ var _ = Describe("Server", func() {
 		Expect(err).To(Succeed())
 	})
 
+	It("gives an error when a server is added twice to a network", func() {
+		_, err := client.AttachServerToNetwork(ctx, server, hcloud.ServerAttachToNetworkOpts{
+			Network: network,
+		})
+		Expect(err).To(Succeed())
+		_, err = client.AttachServerToNetwork(ctx, server, hcloud.ServerAttachToNetworkOpts{
+			Network: network,
+		})
+		Expect(err).ToNot(Succeed())
+		Expect(hcloud.IsError(err, hcloud.ErrorCodeServerAlreadyAttached)).To(BeTrue())
+	})
+
 	It("gives an error when a server is added to a non-existing network", func() {
 		_, err := client.AttachServerToNetwork(ctx, server, hcloud.ServerAttachToNetworkOpts{
 			Network: &hcloud.Network{ID: 2},
@@ -578,6 +601,12 @@ var _ = Describe("Server", func() {
 			},
 		}))
 	})
+
+	It("lists no server type deprecations", func() {
+		deprecations, err := client.ListServerTypeDeprecations(ctx)
+		Expect(err).To(Succeed())
+		Expect(deprecations).To(BeEmpty())
+	})
 })
 
 var _ = Describe("Network", func() {
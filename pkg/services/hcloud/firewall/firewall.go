@@ -0,0 +1,346 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package firewall implements the lifecycle of HCloud firewalls.
+package firewall
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"github.com/pkg/errors"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/scope"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/utils"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// Service struct contains cluster scope to reconcile firewalls.
+type Service struct {
+	scope *scope.ClusterScope
+}
+
+// NewService creates new service object.
+func NewService(scope *scope.ClusterScope) *Service {
+	return &Service{
+		scope: scope,
+	}
+}
+
+// Reconcile implements life cycle of firewalls. Firewalls of the spec are created or updated, firewalls of
+// the cluster that are not in the spec anymore are deleted.
+func (s *Service) Reconcile(ctx context.Context) (err error) {
+	log := ctrl.LoggerFrom(ctx)
+	log.V(1).Info("Reconcile firewalls")
+
+	firewalls, err := s.findFirewalls(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to find firewalls")
+	}
+
+	firewallMap := make(map[string]*hcloud.Firewall, len(firewalls))
+	for _, firewall := range firewalls {
+		firewallMap[s.specName(firewall)] = firewall
+	}
+
+	var multierr []error
+	specNames := make(map[string]struct{})
+	for _, spec := range s.scope.HetznerCluster.Spec.HCloudFirewalls {
+		specNames[spec.Name] = struct{}{}
+		if err := s.reconcileFirewall(ctx, spec, firewallMap[spec.Name]); err != nil {
+			if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+				return err
+			}
+			multierr = append(multierr, errors.Wrapf(err, "firewall %s", spec.Name))
+		}
+	}
+
+	for name, firewall := range firewallMap {
+		if _, found := specNames[name]; found {
+			continue
+		}
+		if err := s.deleteFirewall(ctx, firewall); err != nil {
+			if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+				return err
+			}
+			multierr = append(multierr, errors.Wrapf(err, "firewall %s", name))
+		}
+	}
+
+	if err := kerrors.NewAggregate(multierr); err != nil {
+		return errors.Wrap(err, "aggregate error - creating/updating/deleting firewalls")
+	}
+
+	// Update status
+	firewalls, err = s.findFirewalls(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to find firewalls")
+	}
+	s.scope.HetznerCluster.Status.HCloudFirewalls = s.apiToStatus(firewalls)
+	return nil
+}
+
+// Delete implements deletion of firewalls.
+func (s *Service) Delete(ctx context.Context) (err error) {
+	log := ctrl.LoggerFrom(ctx)
+	log.V(1).Info("Delete firewalls")
+
+	firewalls, err := s.findFirewalls(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to find firewalls")
+	}
+
+	var multierr []error
+	for _, firewall := range firewalls {
+		if err := s.deleteFirewall(ctx, firewall); err != nil {
+			if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+				return err
+			}
+			if !hcloud.IsError(err, hcloud.ErrorCodeNotFound) {
+				multierr = append(multierr, err)
+			}
+		}
+	}
+
+	if err := kerrors.NewAggregate(multierr); err != nil {
+		log.Error(err, "aggregate error - deleting firewalls")
+		return err
+	}
+
+	s.scope.HetznerCluster.Status.HCloudFirewalls = nil
+	if len(firewalls) > 0 {
+		record.Eventf(s.scope.HetznerCluster, "FirewallsDeleted", "Deleted firewalls")
+	}
+	return nil
+}
+
+// reconcileFirewall creates the firewall of the spec or updates its rules and the servers it is applied to.
+func (s *Service) reconcileFirewall(ctx context.Context, spec infrav1.HCloudFirewallSpec, firewall *hcloud.Firewall) error {
+	rules, err := Rules(spec.Rules)
+	if err != nil {
+		return err
+	}
+	applyTo := hcloud.FirewallResource{
+		Type:          hcloud.FirewallResourceTypeLabelSelector,
+		LabelSelector: &hcloud.FirewallResourceLabelSelector{Selector: s.serverSelector(spec)},
+	}
+
+	if firewall == nil {
+		if _, err := s.scope.HCloudClient.CreateFirewall(ctx, hcloud.FirewallCreateOpts{
+			Name:    s.hcloudName(spec.Name),
+			Labels:  s.labels(),
+			Rules:   rules,
+			ApplyTo: []hcloud.FirewallResource{applyTo},
+		}); err != nil {
+			s.handleRateLimit(err, "CreateFirewall")
+			return errors.Wrap(err, "failed to create firewall")
+		}
+		record.Eventf(s.scope.HetznerCluster, "FirewallCreated", "Created firewall %s", spec.Name)
+		return nil
+	}
+
+	if !RulesEqual(firewall.Rules, rules) {
+		if _, err := s.scope.HCloudClient.SetFirewallRules(ctx, firewall, hcloud.FirewallSetRulesOpts{Rules: rules}); err != nil {
+			s.handleRateLimit(err, "SetFirewallRules")
+			return errors.Wrap(err, "failed to set rules")
+		}
+		record.Eventf(s.scope.HetznerCluster, "FirewallRulesUpdated", "Updated the rules of firewall %s", spec.Name)
+	}
+
+	var applied bool
+	var stale []hcloud.FirewallResource
+	for _, resource := range firewall.AppliedTo {
+		if reflect.DeepEqual(resource, applyTo) {
+			applied = true
+			continue
+		}
+		stale = append(stale, resource)
+	}
+	if !applied {
+		if _, err := s.scope.HCloudClient.ApplyFirewallResources(ctx, firewall, []hcloud.FirewallResource{applyTo}); err != nil {
+			s.handleRateLimit(err, "ApplyFirewallResources")
+			return errors.Wrap(err, "failed to apply firewall")
+		}
+	}
+	if len(stale) > 0 {
+		if _, err := s.scope.HCloudClient.RemoveFirewallResources(ctx, firewall, stale); err != nil {
+			s.handleRateLimit(err, "RemoveFirewallResources")
+			return errors.Wrap(err, "failed to remove resources from firewall")
+		}
+	}
+	return nil
+}
+
+// deleteFirewall removes the firewall from all resources and deletes it. HCloud removes it from the servers
+// asynchronously, so the deletion fails as long as it is in use and is retried with the next reconcile.
+func (s *Service) deleteFirewall(ctx context.Context, firewall *hcloud.Firewall) error {
+	if len(firewall.AppliedTo) > 0 {
+		if _, err := s.scope.HCloudClient.RemoveFirewallResources(ctx, firewall, firewall.AppliedTo); err != nil {
+			s.handleRateLimit(err, "RemoveFirewallResources")
+			if !hcloud.IsError(err, hcloud.ErrorCodeFirewallAlreadyRemoved) {
+				return errors.Wrap(err, "failed to remove resources from firewall")
+			}
+		}
+	}
+
+	if err := s.scope.HCloudClient.DeleteFirewall(ctx, firewall); err != nil {
+		s.handleRateLimit(err, "DeleteFirewall")
+		return errors.Wrap(err, "failed to delete firewall")
+	}
+	record.Eventf(s.scope.HetznerCluster, "FirewallDeleted", "Deleted firewall %s", s.specName(firewall))
+	return nil
+}
+
+func (s *Service) findFirewalls(ctx context.Context) ([]*hcloud.Firewall, error) {
+	opts := hcloud.FirewallListOpts{}
+	opts.LabelSelector = utils.LabelsToLabelSelector(s.labels())
+
+	firewalls, err := s.scope.HCloudClient.ListFirewalls(ctx, opts)
+	if err != nil {
+		s.handleRateLimit(err, "ListFirewalls")
+		return nil, errors.Wrap(err, "failed to list firewalls")
+	}
+	return firewalls, nil
+}
+
+func (s *Service) handleRateLimit(err error, function string) {
+	if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+		conditions.MarkTrue(s.scope.HetznerCluster, infrav1.RateLimitExceeded)
+		record.Event(s.scope.HetznerCluster,
+			"RateLimitExceeded",
+			fmt.Sprintf("exceeded rate limit with calling hcloud function %s", function),
+		)
+	}
+}
+
+func (s *Service) labels() map[string]string {
+	return map[string]string{infrav1.ClusterTagKey(s.scope.HetznerCluster.Name): string(infrav1.ResourceLifecycleOwned)}
+}
+
+func (s *Service) hcloudName(name string) string {
+	return fmt.Sprintf("%s-%s", s.scope.HetznerCluster.Name, name)
+}
+
+func (s *Service) specName(firewall *hcloud.Firewall) string {
+	return strings.TrimPrefix(firewall.Name, fmt.Sprintf("%s-", s.scope.HetznerCluster.Name))
+}
+
+// serverSelector selects the servers of the cluster that match the selector of the spec.
+func (s *Service) serverSelector(spec infrav1.HCloudFirewallSpec) string {
+	selector := utils.LabelsToLabelSelector(s.labels())
+	if spec.ApplyToLabelSelector != "" {
+		selector = fmt.Sprintf("%s,%s", selector, spec.ApplyToLabelSelector)
+	}
+	return selector
+}
+
+func (s *Service) apiToStatus(firewalls []*hcloud.Firewall) []infrav1.HCloudFirewallStatus {
+	status := make([]infrav1.HCloudFirewallStatus, len(firewalls))
+	for i, firewall := range firewalls {
+		status[i] = infrav1.HCloudFirewallStatus{
+			ID:   firewall.ID,
+			Name: s.specName(firewall),
+		}
+	}
+	sort.Slice(status, func(i, j int) bool { return status[i].Name < status[j].Name })
+	return status
+}
+
+// Rules converts the rules of the spec to HCloud firewall rules.
+func Rules(specRules []infrav1.HCloudFirewallRule) ([]hcloud.FirewallRule, error) {
+	rules := make([]hcloud.FirewallRule, len(specRules))
+	for i, spec := range specRules {
+		rule := hcloud.FirewallRule{
+			Direction: hcloud.FirewallRuleDirection(spec.Direction),
+			Protocol:  hcloud.FirewallRuleProtocol(spec.Protocol),
+		}
+		if spec.Port != "" {
+			port := spec.Port
+			rule.Port = &port
+		}
+		if spec.Description != "" {
+			description := spec.Description
+			rule.Description = &description
+		}
+
+		var err error
+		if rule.SourceIPs, err = parseCIDRs(spec.SourceIPs); err != nil {
+			return nil, errors.Wrapf(err, "invalid source IPs of rule %d", i)
+		}
+		if rule.DestinationIPs, err = parseCIDRs(spec.DestinationIPs); err != nil {
+			return nil, errors.Wrapf(err, "invalid destination IPs of rule %d", i)
+		}
+		rules[i] = rule
+	}
+	return rules, nil
+}
+
+func parseCIDRs(cidrs []string) ([]net.IPNet, error) {
+	ipNets := make([]net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		ipNets = append(ipNets, *ipNet)
+	}
+	return ipNets, nil
+}
+
+// RulesEqual returns whether two lists of rules are equal. The order of the rules and of their IPs does not matter.
+func RulesEqual(a, b []hcloud.FirewallRule) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	keysA := make([]string, len(a))
+	keysB := make([]string, len(b))
+	for i := range a {
+		keysA[i] = ruleKey(a[i])
+		keysB[i] = ruleKey(b[i])
+	}
+	sort.Strings(keysA)
+	sort.Strings(keysB)
+	return reflect.DeepEqual(keysA, keysB)
+}
+
+func ruleKey(rule hcloud.FirewallRule) string {
+	var port, description string
+	if rule.Port != nil {
+		port = *rule.Port
+	}
+	if rule.Description != nil {
+		description = *rule.Description
+	}
+	return fmt.Sprintf("%s|%s|%s|%s|%s|%s", rule.Direction, rule.Protocol, port,
+		ipNetsKey(rule.SourceIPs), ipNetsKey(rule.DestinationIPs), description)
+}
+
+func ipNetsKey(ipNets []net.IPNet) string {
+	keys := make([]string, len(ipNets))
+	for i := range ipNets {
+		keys[i] = ipNets[i].String()
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package firewall
+
+import (
+	"context"
+	"net"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/scope"
+	fakeclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var ctx = context.Background()
+
+var _ = Describe("Reconcile", func() {
+	var (
+		service        *Service
+		hetznerCluster *infrav1.HetznerCluster
+		sshRule        infrav1.HCloudFirewallRule
+	)
+
+	BeforeEach(func() {
+		hcloudClient := fakeclient.NewHCloudClientFactory().NewClient("")
+		hcloudClient.Close()
+
+		sshRule = infrav1.HCloudFirewallRule{
+			Direction: "in",
+			Protocol:  "tcp",
+			Port:      "22",
+			SourceIPs: []string{"10.0.0.0/8"},
+		}
+		hetznerCluster = &infrav1.HetznerCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "fw-test", Namespace: "default"},
+			Spec: infrav1.HetznerClusterSpec{
+				HCloudFirewalls: []infrav1.HCloudFirewallSpec{
+					{Name: "ssh", Rules: []infrav1.HCloudFirewallRule{sshRule}, ApplyToLabelSelector: "machine_type=control_plane"},
+				},
+			},
+		}
+		service = NewService(&scope.ClusterScope{HCloudClient: hcloudClient, HetznerCluster: hetznerCluster})
+	})
+
+	listFirewalls := func() []*hcloud.Firewall {
+		firewalls, err := service.scope.HCloudClient.ListFirewalls(ctx, hcloud.FirewallListOpts{})
+		Expect(err).To(Succeed())
+		return firewalls
+	}
+
+	It("creates the firewalls of the spec and applies them to the servers of the cluster", func() {
+		Expect(service.Reconcile(ctx)).To(Succeed())
+
+		firewalls := listFirewalls()
+		Expect(firewalls).To(HaveLen(1))
+		Expect(firewalls[0].Name).To(Equal("fw-test-ssh"))
+		Expect(firewalls[0].Rules).To(HaveLen(1))
+		Expect(firewalls[0].AppliedTo).To(HaveLen(1))
+		Expect(firewalls[0].AppliedTo[0].LabelSelector.Selector).To(Equal("caph-cluster-fw-test==owned,machine_type=control_plane"))
+
+		Expect(hetznerCluster.Status.HCloudFirewalls).To(Equal([]infrav1.HCloudFirewallStatus{{ID: firewalls[0].ID, Name: "ssh"}}))
+	})
+
+	It("updates the rules and the selector of an existing firewall", func() {
+		Expect(service.Reconcile(ctx)).To(Succeed())
+
+		sshRule.SourceIPs = []string{"192.168.0.0/16"}
+		hetznerCluster.Spec.HCloudFirewalls[0].Rules = []infrav1.HCloudFirewallRule{sshRule}
+		hetznerCluster.Spec.HCloudFirewalls[0].ApplyToLabelSelector = ""
+		Expect(service.Reconcile(ctx)).To(Succeed())
+
+		firewalls := listFirewalls()
+		Expect(firewalls).To(HaveLen(1))
+		Expect(firewalls[0].Rules[0].SourceIPs[0].String()).To(Equal("192.168.0.0/16"))
+		Expect(firewalls[0].AppliedTo).To(HaveLen(1))
+		Expect(firewalls[0].AppliedTo[0].LabelSelector.Selector).To(Equal("caph-cluster-fw-test==owned"))
+	})
+
+	It("deletes firewalls that have been removed from the spec", func() {
+		Expect(service.Reconcile(ctx)).To(Succeed())
+
+		hetznerCluster.Spec.HCloudFirewalls = nil
+		Expect(service.Reconcile(ctx)).To(Succeed())
+
+		Expect(listFirewalls()).To(BeEmpty())
+		Expect(hetznerCluster.Status.HCloudFirewalls).To(BeEmpty())
+	})
+
+	It("deletes all firewalls of the cluster on delete", func() {
+		Expect(service.Reconcile(ctx)).To(Succeed())
+		Expect(service.Delete(ctx)).To(Succeed())
+
+		Expect(listFirewalls()).To(BeEmpty())
+		Expect(hetznerCluster.Status.HCloudFirewalls).To(BeNil())
+	})
+})
+
+var _ = Describe("RulesEqual", func() {
+	ipNet := func(cidr string) net.IPNet {
+		_, n, err := net.ParseCIDR(cidr)
+		Expect(err).To(Succeed())
+		return *n
+	}
+	port := "80"
+
+	It("does not depend on the order of the rules and IPs", func() {
+		a := []hcloud.FirewallRule{
+			{Direction: hcloud.FirewallRuleDirectionIn, Protocol: hcloud.FirewallRuleProtocolTCP, Port: &port, SourceIPs: []net.IPNet{ipNet("10.0.0.0/8"), ipNet("::/0")}},
+			{Direction: hcloud.FirewallRuleDirectionIn, Protocol: hcloud.FirewallRuleProtocolICMP, SourceIPs: []net.IPNet{ipNet("0.0.0.0/0")}},
+		}
+		b := []hcloud.FirewallRule{
+			{Direction: hcloud.FirewallRuleDirectionIn, Protocol: hcloud.FirewallRuleProtocolICMP, SourceIPs: []net.IPNet{ipNet("0.0.0.0/0")}},
+			{Direction: hcloud.FirewallRuleDirectionIn, Protocol: hcloud.FirewallRuleProtocolTCP, Port: &port, SourceIPs: []net.IPNet{ipNet("::/0"), ipNet("10.0.0.0/8")}},
+		}
+		Expect(RulesEqual(a, b)).To(BeTrue())
+	})
+
+	It("detects changed rules", func() {
+		otherPort := "443"
+		a := []hcloud.FirewallRule{{Direction: hcloud.FirewallRuleDirectionIn, Protocol: hcloud.FirewallRuleProtocolTCP, Port: &port}}
+		b := []hcloud.FirewallRule{{Direction: hcloud.FirewallRuleDirectionIn, Protocol: hcloud.FirewallRuleProtocolTCP, Port: &otherPort}}
+		Expect(RulesEqual(a, b)).To(BeFalse())
+	})
+})
@@ -0,0 +1,293 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package floatingip implements the lifecycle of the HCloud floating IP of the control plane.
+package floatingip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"github.com/pkg/errors"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/scope"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/utils"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// controlPlaneMachineType is the value of the label machine_type of control plane servers.
+const controlPlaneMachineType = "control_plane"
+
+// Service struct contains cluster scope to reconcile the floating IP of the control plane.
+type Service struct {
+	scope *scope.ClusterScope
+}
+
+// NewService creates new service object.
+func NewService(scope *scope.ClusterScope) *Service {
+	return &Service{
+		scope: scope,
+	}
+}
+
+// Reconcile implements life cycle of the floating IP of the control plane. The floating IP is created and
+// assigned to a running control plane server. It is deleted if the spec does not contain it.
+func (s *Service) Reconcile(ctx context.Context) (err error) {
+	log := ctrl.LoggerFrom(ctx)
+	log.V(1).Info("Reconcile floating IP of the control plane")
+
+	hetznerCluster := s.scope.HetznerCluster
+	floatingIP, err := s.findFloatingIP(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to find floating IP")
+	}
+
+	if hetznerCluster.Spec.ControlPlaneFloatingIP == nil {
+		if floatingIP != nil {
+			if err := s.deleteFloatingIP(ctx, floatingIP); err != nil {
+				return err
+			}
+		}
+		hetznerCluster.Status.ControlPlaneFloatingIP = nil
+		conditions.Delete(hetznerCluster, infrav1.ControlPlaneFloatingIPReadyCondition)
+		return nil
+	}
+
+	if floatingIP == nil {
+		if floatingIP, err = s.createFloatingIP(ctx); err != nil {
+			return errors.Wrap(err, "failed to create floating IP")
+		}
+	}
+
+	serverID, err := s.assign(ctx, floatingIP, 0)
+	if err != nil {
+		return errors.Wrap(err, "failed to assign floating IP")
+	}
+
+	hetznerCluster.Status.ControlPlaneFloatingIP = &infrav1.FloatingIPStatus{
+		ID:       floatingIP.ID,
+		IP:       EndpointIP(floatingIP),
+		ServerID: serverID,
+	}
+	if serverID == 0 {
+		conditions.MarkFalse(
+			hetznerCluster,
+			infrav1.ControlPlaneFloatingIPReadyCondition,
+			infrav1.FloatingIPNotAssignedReason,
+			clusterv1.ConditionSeverityInfo,
+			"no running control plane server to assign the floating IP to",
+		)
+	} else {
+		conditions.MarkTrue(hetznerCluster, infrav1.ControlPlaneFloatingIPReadyCondition)
+	}
+	return nil
+}
+
+// Delete implements deletion of the floating IP of the control plane.
+func (s *Service) Delete(ctx context.Context) (err error) {
+	log := ctrl.LoggerFrom(ctx)
+	log.V(1).Info("Delete floating IP of the control plane")
+
+	floatingIP, err := s.findFloatingIP(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to find floating IP")
+	}
+	if floatingIP != nil {
+		if err := s.deleteFloatingIP(ctx, floatingIP); err != nil && !hcloud.IsError(err, hcloud.ErrorCodeNotFound) {
+			return err
+		}
+	}
+	s.scope.HetznerCluster.Status.ControlPlaneFloatingIP = nil
+	return nil
+}
+
+// Assign makes sure that the floating IP of the control plane is assigned to a running control plane server
+// other than the server with the excluded ID. It is called with the ID of a control plane server that is
+// deleted, so that the floating IP is moved before the server goes down, and with zero if a control plane
+// server has become ready. The HetznerCluster picks up the new assignment in its next reconcile.
+func (s *Service) Assign(ctx context.Context, excludedServerID int) error {
+	floatingIP, err := s.findFloatingIP(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to find floating IP")
+	}
+	if floatingIP == nil {
+		return nil
+	}
+	_, err = s.assign(ctx, floatingIP, excludedServerID)
+	return err
+}
+
+// assign keeps the floating IP on its server if the server is a running control plane and not excluded.
+// Otherwise, the floating IP is moved to the oldest running control plane server. If there is none, a floating IP
+// of the excluded server is unassigned. It returns the ID of the server the floating IP is assigned to.
+func (s *Service) assign(ctx context.Context, floatingIP *hcloud.FloatingIP, excludedServerID int) (int, error) {
+	servers, err := s.controlPlaneServers(ctx, excludedServerID)
+	if err != nil {
+		return 0, err
+	}
+
+	if floatingIP.Server != nil {
+		for _, server := range servers {
+			if server.ID == floatingIP.Server.ID {
+				return server.ID, nil
+			}
+		}
+	}
+
+	if len(servers) == 0 {
+		if floatingIP.Server == nil {
+			return 0, nil
+		}
+		if floatingIP.Server.ID != excludedServerID {
+			// keep the floating IP on a server that is not running until another control plane is available
+			return floatingIP.Server.ID, nil
+		}
+		if _, err := s.scope.HCloudClient.UnassignFloatingIP(ctx, floatingIP); err != nil {
+			s.handleRateLimit(err, "UnassignFloatingIP")
+			return 0, errors.Wrap(err, "failed to unassign floating IP")
+		}
+		record.Eventf(s.scope.HetznerCluster, "FloatingIPUnassigned", "Unassigned floating IP %s from server %d", floatingIP.Name, excludedServerID)
+		return 0, nil
+	}
+
+	server := servers[0]
+	if _, err := s.scope.HCloudClient.AssignFloatingIP(ctx, floatingIP, server); err != nil {
+		s.handleRateLimit(err, "AssignFloatingIP")
+		return 0, errors.Wrapf(err, "failed to assign floating IP to server %s", server.Name)
+	}
+	record.Eventf(s.scope.HetznerCluster, "FloatingIPAssigned", "Assigned floating IP %s to server %s", floatingIP.Name, server.Name)
+	return server.ID, nil
+}
+
+// controlPlaneServers returns the running control plane servers of the cluster, the oldest first.
+func (s *Service) controlPlaneServers(ctx context.Context, excludedServerID int) ([]*hcloud.Server, error) {
+	opts := hcloud.ServerListOpts{}
+	opts.LabelSelector = utils.LabelsToLabelSelector(map[string]string{
+		infrav1.ClusterTagKey(s.scope.HetznerCluster.Name): string(infrav1.ResourceLifecycleOwned),
+		"machine_type": controlPlaneMachineType,
+	})
+	servers, err := s.scope.HCloudClient.ListServers(ctx, opts)
+	if err != nil {
+		s.handleRateLimit(err, "ListServers")
+		return nil, errors.Wrap(err, "failed to list control plane servers")
+	}
+
+	running := make([]*hcloud.Server, 0, len(servers))
+	for _, server := range servers {
+		if server.Status == hcloud.ServerStatusRunning && server.ID != excludedServerID {
+			running = append(running, server)
+		}
+	}
+	sort.Slice(running, func(i, j int) bool {
+		if !running[i].Created.Equal(running[j].Created) {
+			return running[i].Created.Before(running[j].Created)
+		}
+		return running[i].ID < running[j].ID
+	})
+	return running, nil
+}
+
+func (s *Service) createFloatingIP(ctx context.Context) (*hcloud.FloatingIP, error) {
+	spec := s.scope.HetznerCluster.Spec.ControlPlaneFloatingIP
+
+	homeLocation := spec.HomeLocation
+	if homeLocation == "" && len(s.scope.HetznerCluster.Spec.ControlPlaneRegions) > 0 {
+		homeLocation = s.scope.HetznerCluster.Spec.ControlPlaneRegions[0]
+	}
+	ipType := hcloud.FloatingIPTypeIPv4
+	if spec.Type == infrav1.PrimaryIPTypeIPv6 {
+		ipType = hcloud.FloatingIPTypeIPv6
+	}
+	name := s.name()
+	description := fmt.Sprintf("control plane endpoint of cluster %s", s.scope.HetznerCluster.Name)
+
+	res, err := s.scope.HCloudClient.CreateFloatingIP(ctx, hcloud.FloatingIPCreateOpts{
+		Type:         ipType,
+		HomeLocation: &hcloud.Location{Name: string(homeLocation)},
+		Name:         &name,
+		Description:  &description,
+		Labels:       s.labels(),
+	})
+	if err != nil {
+		s.handleRateLimit(err, "CreateFloatingIP")
+		return nil, err
+	}
+	record.Eventf(s.scope.HetznerCluster, "FloatingIPCreated", "Created floating IP %s with IP %s", name, EndpointIP(res.FloatingIP))
+	return res.FloatingIP, nil
+}
+
+func (s *Service) deleteFloatingIP(ctx context.Context, floatingIP *hcloud.FloatingIP) error {
+	if err := s.scope.HCloudClient.DeleteFloatingIP(ctx, floatingIP); err != nil {
+		s.handleRateLimit(err, "DeleteFloatingIP")
+		return errors.Wrap(err, "failed to delete floating IP")
+	}
+	record.Eventf(s.scope.HetznerCluster, "FloatingIPDeleted", "Deleted floating IP %s", floatingIP.Name)
+	return nil
+}
+
+func (s *Service) findFloatingIP(ctx context.Context) (*hcloud.FloatingIP, error) {
+	opts := hcloud.FloatingIPListOpts{}
+	opts.LabelSelector = utils.LabelsToLabelSelector(s.labels())
+
+	floatingIPs, err := s.scope.HCloudClient.ListFloatingIPs(ctx, opts)
+	if err != nil {
+		s.handleRateLimit(err, "ListFloatingIPs")
+		return nil, errors.Wrap(err, "failed to list floating IPs")
+	}
+	if len(floatingIPs) > 1 {
+		return nil, errors.Errorf("found %d floating IPs of the control plane, expected at most one", len(floatingIPs))
+	}
+	if len(floatingIPs) == 0 {
+		return nil, nil
+	}
+	return floatingIPs[0], nil
+}
+
+func (s *Service) handleRateLimit(err error, function string) {
+	if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+		conditions.MarkTrue(s.scope.HetznerCluster, infrav1.RateLimitExceeded)
+		record.Event(s.scope.HetznerCluster,
+			"RateLimitExceeded",
+			fmt.Sprintf("exceeded rate limit with calling hcloud function %s", function),
+		)
+	}
+}
+
+func (s *Service) labels() map[string]string {
+	return map[string]string{infrav1.ClusterTagKey(s.scope.HetznerCluster.Name): string(infrav1.ResourceLifecycleOwned)}
+}
+
+func (s *Service) name() string {
+	return fmt.Sprintf("%s-control-plane", s.scope.HetznerCluster.Name)
+}
+
+// EndpointIP returns the address of the floating IP that is used as host of the control plane endpoint. HCloud
+// routes a whole /64 network for IPv6 floating IPs, of which the first host address is used.
+func EndpointIP(floatingIP *hcloud.FloatingIP) string {
+	if floatingIP.Type != hcloud.FloatingIPTypeIPv6 || floatingIP.Network == nil {
+		return floatingIP.IP.String()
+	}
+	ip := make(net.IP, len(floatingIP.Network.IP.To16()))
+	copy(ip, floatingIP.Network.IP.To16())
+	ip[len(ip)-1] |= 1
+	return ip.String()
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package floatingip
+
+import (
+	"context"
+	"net"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/scope"
+	hcloudclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client"
+	fakeclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+)
+
+var ctx = context.Background()
+
+var _ = Describe("Reconcile", func() {
+	var (
+		service        *Service
+		hcloudClient   hcloudclient.Client
+		hetznerCluster *infrav1.HetznerCluster
+	)
+
+	BeforeEach(func() {
+		hcloudClient = fakeclient.NewHCloudClientFactory().NewClient("")
+		hcloudClient.Close()
+
+		hetznerCluster = &infrav1.HetznerCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "fip-test", Namespace: "default"},
+			Spec: infrav1.HetznerClusterSpec{
+				ControlPlaneRegions:    []infrav1.Region{"fsn1"},
+				ControlPlaneFloatingIP: &infrav1.ControlPlaneFloatingIPSpec{Type: infrav1.PrimaryIPTypeIPv4, Port: 6443},
+			},
+		}
+		service = NewService(&scope.ClusterScope{HCloudClient: hcloudClient, HetznerCluster: hetznerCluster})
+	})
+
+	createServer := func(name, machineType string) *hcloud.Server {
+		res, err := hcloudClient.CreateServer(ctx, hcloud.ServerCreateOpts{
+			Name: name,
+			Labels: map[string]string{
+				infrav1.ClusterTagKey(hetznerCluster.Name): string(infrav1.ResourceLifecycleOwned),
+				"machine_type": machineType,
+			},
+		})
+		Expect(err).To(Succeed())
+		return res.Server
+	}
+
+	floatingIP := func() *hcloud.FloatingIP {
+		floatingIPs, err := hcloudClient.ListFloatingIPs(ctx, hcloud.FloatingIPListOpts{})
+		Expect(err).To(Succeed())
+		Expect(floatingIPs).To(HaveLen(1))
+		return floatingIPs[0]
+	}
+
+	It("creates the floating IP before any control plane exists", func() {
+		createServer("worker", "worker")
+		Expect(service.Reconcile(ctx)).To(Succeed())
+
+		fip := floatingIP()
+		Expect(fip.Name).To(Equal("fip-test-control-plane"))
+		Expect(fip.HomeLocation.Name).To(Equal("fsn1"))
+		Expect(fip.Server).To(BeNil())
+		Expect(hetznerCluster.Status.ControlPlaneFloatingIP).To(Equal(&infrav1.FloatingIPStatus{ID: fip.ID, IP: fip.IP.String()}))
+		Expect(conditions.IsFalse(hetznerCluster, infrav1.ControlPlaneFloatingIPReadyCondition)).To(BeTrue())
+	})
+
+	It("assigns the floating IP to a control plane and keeps it there", func() {
+		first := createServer("cp-1", "control_plane")
+		Expect(service.Reconcile(ctx)).To(Succeed())
+		Expect(floatingIP().Server.ID).To(Equal(first.ID))
+
+		createServer("cp-2", "control_plane")
+		Expect(service.Reconcile(ctx)).To(Succeed())
+		Expect(floatingIP().Server.ID).To(Equal(first.ID))
+		Expect(hetznerCluster.Status.ControlPlaneFloatingIP.ServerID).To(Equal(first.ID))
+		Expect(conditions.IsTrue(hetznerCluster, infrav1.ControlPlaneFloatingIPReadyCondition)).To(BeTrue())
+	})
+
+	It("moves the floating IP to another control plane before its server is deleted", func() {
+		first := createServer("cp-1", "control_plane")
+		Expect(service.Reconcile(ctx)).To(Succeed())
+		second := createServer("cp-2", "control_plane")
+
+		Expect(service.Assign(ctx, first.ID)).To(Succeed())
+		Expect(floatingIP().Server.ID).To(Equal(second.ID))
+	})
+
+	It("unassigns the floating IP from the last control plane that is deleted", func() {
+		first := createServer("cp-1", "control_plane")
+		Expect(service.Reconcile(ctx)).To(Succeed())
+
+		Expect(service.Assign(ctx, first.ID)).To(Succeed())
+		Expect(floatingIP().Server).To(BeNil())
+	})
+
+	It("deletes the floating IP", func() {
+		Expect(service.Reconcile(ctx)).To(Succeed())
+		Expect(service.Delete(ctx)).To(Succeed())
+
+		floatingIPs, err := hcloudClient.ListFloatingIPs(ctx, hcloud.FloatingIPListOpts{})
+		Expect(err).To(Succeed())
+		Expect(floatingIPs).To(BeEmpty())
+		Expect(hetznerCluster.Status.ControlPlaneFloatingIP).To(BeNil())
+	})
+})
+
+var _ = Describe("EndpointIP", func() {
+	It("returns the address of an IPv4 floating IP", func() {
+		Expect(EndpointIP(&hcloud.FloatingIP{Type: hcloud.FloatingIPTypeIPv4, IP: net.ParseIP("5.6.7.8")})).To(Equal("5.6.7.8"))
+	})
+
+	It("returns the first host address of the network of an IPv6 floating IP", func() {
+		ip, network, err := net.ParseCIDR("2001:db8:1234::/64")
+		Expect(err).To(Succeed())
+		Expect(EndpointIP(&hcloud.FloatingIP{Type: hcloud.FloatingIPTypeIPv6, IP: ip, Network: network})).To(Equal("2001:db8:1234::1"))
+	})
+})
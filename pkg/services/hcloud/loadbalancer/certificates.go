@@ -0,0 +1,173 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loadbalancer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"github.com/pkg/errors"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/utils"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/record"
+)
+
+// certificatesOfService returns the certificates that the service should use. Managed certificates
+// are created if they do not exist yet.
+func (s *Service) certificatesOfService(ctx context.Context, service infrav1.LoadBalancerServiceSpec) ([]*hcloud.Certificate, error) {
+	if service.Certificate == nil {
+		return nil, nil
+	}
+
+	switch service.Certificate.Type {
+	case infrav1.LoadBalancerCertificateTypeManaged:
+		certificate, err := s.ensureManagedCertificate(ctx, service.ListenPort, service.Certificate.DomainNames)
+		if err != nil {
+			return nil, err
+		}
+		return []*hcloud.Certificate{certificate}, nil
+	case infrav1.LoadBalancerCertificateTypeUploaded:
+		certificate, err := s.findCertificate(ctx, service.Certificate.Name)
+		if err != nil {
+			return nil, err
+		}
+		if certificate == nil {
+			record.Warnf(s.scope.HetznerCluster, "CertificateNotFound", "Uploaded certificate %q not found", service.Certificate.Name)
+			return nil, fmt.Errorf("uploaded certificate %q not found", service.Certificate.Name)
+		}
+		return []*hcloud.Certificate{certificate}, nil
+	default:
+		return nil, fmt.Errorf("unknown certificate type %q", service.Certificate.Type)
+	}
+}
+
+// ensureManagedCertificate returns the managed certificate of the listen port and the domain names.
+// The name contains a hash of the domain names, so that a changed list leads to a new certificate
+// which replaces the old one on the service.
+func (s *Service) ensureManagedCertificate(ctx context.Context, listenPort int, domainNames []string) (*hcloud.Certificate, error) {
+	name := managedCertificateName(s.scope.HetznerCluster.Name, listenPort, domainNames)
+
+	certificate, err := s.findCertificate(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if certificate != nil {
+		if certificate.Status != nil && certificate.Status.IsFailed() && certificate.Status.Error != nil {
+			record.Warnf(s.scope.HetznerCluster, "ManagedCertificateFailed",
+				"Issuance of managed certificate %s failed: %s", name, certificate.Status.Error.Message)
+		}
+		return certificate, nil
+	}
+
+	res, err := s.scope.HCloudClient.CreateCertificate(ctx, hcloud.CertificateCreateOpts{
+		Name:        name,
+		Type:        hcloud.CertificateTypeManaged,
+		DomainNames: domainNames,
+		Labels: map[string]string{
+			infrav1.ClusterTagKey(s.scope.HetznerCluster.Name): string(infrav1.ResourceLifecycleOwned),
+		},
+	})
+	if err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HetznerCluster, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HetznerCluster,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function CreateCertificate",
+			)
+		}
+		record.Warnf(s.scope.HetznerCluster, "FailedCreateCertificate", "Failed to create managed certificate %s: %s", name, err)
+		return nil, errors.Wrap(err, "failed to create managed certificate")
+	}
+
+	record.Eventf(s.scope.HetznerCluster, "CreateCertificate", "Created managed certificate %s for %s", name, strings.Join(domainNames, ", "))
+	return res.Certificate, nil
+}
+
+func (s *Service) findCertificate(ctx context.Context, name string) (*hcloud.Certificate, error) {
+	certificates, err := s.scope.HCloudClient.ListCertificates(ctx, hcloud.CertificateListOpts{Name: name})
+	if err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HetznerCluster, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HetznerCluster,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function ListCertificates",
+			)
+		}
+		return nil, errors.Wrap(err, "failed to list certificates")
+	}
+	if len(certificates) == 0 {
+		return nil, nil
+	}
+	return certificates[0], nil
+}
+
+// deleteUnusedCertificates deletes the managed certificates of the cluster that are not used by
+// any service anymore. If usedNames is nil, all certificates of the cluster are deleted.
+func (s *Service) deleteUnusedCertificates(ctx context.Context, usedNames map[string]struct{}) error {
+	clusterTagKey := infrav1.ClusterTagKey(s.scope.HetznerCluster.Name)
+	certificates, err := s.scope.HCloudClient.ListCertificates(ctx, hcloud.CertificateListOpts{
+		ListOpts: hcloud.ListOpts{
+			LabelSelector: utils.LabelsToLabelSelector(map[string]string{
+				clusterTagKey: string(infrav1.ResourceLifecycleOwned),
+			}),
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to list certificates")
+	}
+
+	var multierr []error
+	for _, certificate := range certificates {
+		if _, used := usedNames[certificate.Name]; used {
+			continue
+		}
+		if err := s.scope.HCloudClient.DeleteCertificate(ctx, certificate); err != nil && !hcloud.IsError(err, hcloud.ErrorCodeNotFound) {
+			multierr = append(multierr, errors.Wrapf(err, "failed to delete certificate %s", certificate.Name))
+			continue
+		}
+		record.Eventf(s.scope.HetznerCluster, "DeleteCertificate", "Deleted managed certificate %s", certificate.Name)
+	}
+	return kerrors.NewAggregate(multierr)
+}
+
+func managedCertificateName(clusterName string, listenPort int, domainNames []string) string {
+	sorted := append([]string(nil), domainNames...)
+	sort.Strings(sorted)
+	return fmt.Sprintf("%s-%d-%s", clusterName, listenPort, utils.SHA256Hash([]byte(strings.Join(sorted, ",")))[:8])
+}
+
+// certificateIDsEqual checks whether both lists contain the same certificates.
+func certificateIDsEqual(a, b []*hcloud.Certificate) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	ids := make(map[int]struct{}, len(a))
+	for _, certificate := range a {
+		ids[certificate.ID] = struct{}{}
+	}
+	for _, certificate := range b {
+		if _, found := ids[certificate.ID]; !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loadbalancer
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// reconcileTargetHealth sets the condition LoadBalancerTargetsHealthy from the health checks of the
+// load balancer. The message names the machines of the targets that fail and the listen ports of the
+// services whose health checks fail.
+func (s *Service) reconcileTargetHealth(ctx context.Context, targets []infrav1.LoadBalancerTarget) {
+	var unhealthy []infrav1.LoadBalancerTarget
+	for _, target := range targets {
+		if len(unhealthyListenPorts(target)) > 0 {
+			unhealthy = append(unhealthy, target)
+		}
+	}
+
+	if len(unhealthy) == 0 {
+		conditions.MarkTrue(s.scope.HetznerCluster, infrav1.LoadBalancerTargetsHealthyCondition)
+		return
+	}
+
+	machineNames := s.machineNamesOfTargets(ctx)
+	msgs := make([]string, 0, len(unhealthy))
+	for _, target := range unhealthy {
+		msgs = append(msgs, fmt.Sprintf("%s fails health checks of ports %s",
+			describeTarget(target, machineNames), strings.Join(unhealthyListenPorts(target), ", ")))
+	}
+	msg := strings.Join(msgs, "; ")
+
+	if !conditions.IsFalse(s.scope.HetznerCluster, infrav1.LoadBalancerTargetsHealthyCondition) ||
+		conditions.GetMessage(s.scope.HetznerCluster, infrav1.LoadBalancerTargetsHealthyCondition) != msg {
+		record.Warnf(s.scope.HetznerCluster, "LoadBalancerTargetsUnhealthy", "Load balancer targets are unhealthy: %s", msg)
+	}
+	conditions.MarkFalse(s.scope.HetznerCluster,
+		infrav1.LoadBalancerTargetsHealthyCondition,
+		infrav1.LoadBalancerTargetsUnhealthyReason,
+		clusterv1.ConditionSeverityWarning,
+		msg)
+}
+
+func unhealthyListenPorts(target infrav1.LoadBalancerTarget) []string {
+	var ports []string
+	for _, health := range target.HealthStatus {
+		if health.Status == string(hcloud.LoadBalancerTargetHealthStatusStatusUnhealthy) {
+			ports = append(ports, strconv.Itoa(health.ListenPort))
+		}
+	}
+	return ports
+}
+
+func describeTarget(target infrav1.LoadBalancerTarget, machineNames map[string]string) string {
+	switch target.Type {
+	case infrav1.LoadBalancerTargetTypeServer:
+		if name, found := machineNames[fmt.Sprintf("hcloud://%d", target.ServerID)]; found {
+			return fmt.Sprintf("machine %s (server %d)", name, target.ServerID)
+		}
+		return fmt.Sprintf("server %d", target.ServerID)
+	default:
+		if name, found := machineNames[target.IP]; found {
+			return fmt.Sprintf("machine %s (IP %s)", name, target.IP)
+		}
+		return fmt.Sprintf("IP %s", target.IP)
+	}
+}
+
+// machineNamesOfTargets maps the provider IDs of HCloud machines and the addresses of bare metal
+// machines of the cluster to the names of the machines.
+func (s *Service) machineNamesOfTargets(ctx context.Context) map[string]string {
+	log := ctrl.LoggerFrom(ctx)
+	names := make(map[string]string)
+	opts := []client.ListOption{
+		client.InNamespace(s.scope.Namespace()),
+		client.MatchingLabels{clusterv1.ClusterLabelName: s.scope.Cluster.Name},
+	}
+
+	var hcloudMachines infrav1.HCloudMachineList
+	if err := s.scope.Client.List(ctx, &hcloudMachines, opts...); err != nil {
+		log.Error(err, "failed to list HCloudMachines")
+	}
+	for _, machine := range hcloudMachines.Items {
+		if machine.Spec.ProviderID != nil {
+			names[*machine.Spec.ProviderID] = machine.Name
+		}
+	}
+
+	var bmMachines infrav1.HetznerBareMetalMachineList
+	if err := s.scope.Client.List(ctx, &bmMachines, opts...); err != nil {
+		log.Error(err, "failed to list HetznerBareMetalMachines")
+	}
+	for _, machine := range bmMachines.Items {
+		for _, address := range machine.Status.Addresses {
+			names[address.Address] = machine.Name
+		}
+	}
+	return names
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loadbalancer
+
+import (
+	"context"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"github.com/pkg/errors"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/record"
+)
+
+// reconcileHealthCheck changes the health check of the service of the API server to the spec. Changes that were
+// made outside of the controller are reverted.
+func (s *Service) reconcileHealthCheck(ctx context.Context, lb *hcloud.LoadBalancer) error {
+	spec := s.scope.HetznerCluster.Spec.ControlPlaneLoadBalancer
+	if spec.HealthCheck == nil {
+		return nil
+	}
+	desired := desiredHealthCheck(spec)
+
+	listenPort := int(s.scope.HetznerCluster.Spec.ControlPlaneEndpoint.Port)
+	for _, service := range lb.Services {
+		if service.ListenPort != listenPort {
+			continue
+		}
+		if healthCheckEqual(service.HealthCheck, desired) {
+			return nil
+		}
+
+		if _, err := s.scope.HCloudClient.UpdateServiceOfLoadBalancer(ctx, lb, listenPort, hcloud.LoadBalancerUpdateServiceOpts{
+			HealthCheck: &hcloud.LoadBalancerUpdateServiceOptsHealthCheck{
+				Protocol: desired.Protocol,
+				Port:     &desired.Port,
+				Interval: &desired.Interval,
+				Timeout:  &desired.Timeout,
+				Retries:  &desired.Retries,
+			},
+		}); err != nil {
+			if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+				conditions.MarkTrue(s.scope.HetznerCluster, infrav1.RateLimitExceeded)
+				record.Event(s.scope.HetznerCluster,
+					"RateLimitExceeded",
+					"exceeded rate limit with calling hcloud function UpdateServiceOfLoadBalancer",
+				)
+			}
+			record.Warnf(s.scope.HetznerCluster, "FailedUpdateLoadBalancerHealthCheck", "Failed to update health check of load balancer: %s", err)
+			return errors.Wrap(err, "failed to update health check of service of the API server")
+		}
+		record.Eventf(s.scope.HetznerCluster, "UpdateLoadBalancerHealthCheck",
+			"Changed health check of the API server to port %d every %s with a timeout of %s and %d retries",
+			desired.Port, desired.Interval, desired.Timeout, desired.Retries)
+		return nil
+	}
+	return nil
+}
+
+// desiredHealthCheck returns the TCP health check of the service of the API server.
+func desiredHealthCheck(spec infrav1.LoadBalancerSpec) hcloud.LoadBalancerServiceHealthCheck {
+	port := spec.HealthCheck.Port
+	if port == 0 {
+		port = spec.Port
+	}
+	return hcloud.LoadBalancerServiceHealthCheck{
+		Protocol: hcloud.LoadBalancerServiceProtocolTCP,
+		Port:     port,
+		Interval: spec.HealthCheck.IntervalOrDefault(),
+		Timeout:  spec.HealthCheck.TimeoutOrDefault(),
+		Retries:  spec.HealthCheck.RetriesOrDefault(),
+	}
+}
+
+func healthCheckEqual(current, desired hcloud.LoadBalancerServiceHealthCheck) bool {
+	return current.Protocol == desired.Protocol &&
+		current.Port == desired.Port &&
+		current.Interval == desired.Interval &&
+		current.Timeout == desired.Timeout &&
+		current.Retries == desired.Retries
+}
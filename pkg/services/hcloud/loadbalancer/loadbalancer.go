@@ -20,11 +20,14 @@ package loadbalancer
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"strings"
 
 	"github.com/hetznercloud/hcloud-go/hcloud"
 	"github.com/pkg/errors"
 	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
 	"github.com/syself/cluster-api-provider-hetzner/pkg/scope"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/quota"
 	"github.com/syself/cluster-api-provider-hetzner/pkg/utils"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
@@ -67,14 +70,25 @@ func (s *Service) Reconcile(ctx context.Context) (err error) {
 		}
 	}
 
+	// remove targets of machines that are gone
+	if err := s.reconcileStaleTargets(ctx, lb); err != nil {
+		return errors.Wrap(err, "failed to reconcile stale targets")
+	}
+
 	// update current status
 	lbStatus, err := apiToStatus(lb, s.scope.HetznerCluster.Status.Network != nil)
 	if err != nil {
 		return errors.Wrap(err, "failed to get status from api object")
 	}
 
+	if previous := s.scope.HetznerCluster.Status.ControlPlaneLoadBalancer; previous != nil {
+		lbStatus.AppliedProperties = previous.AppliedProperties
+	}
 	s.scope.HetznerCluster.Status.ControlPlaneLoadBalancer = &lbStatus
 
+	// Report targets that fail the health checks
+	s.reconcileTargetHealth(ctx, lbStatus.Target)
+
 	// Check whether load balancer name, algorithm or type has been changed
 	if err := s.reconcileLBProperties(ctx, lb); err != nil {
 		return errors.Wrap(err, "failed to reconcile load balancer properties")
@@ -90,6 +104,11 @@ func (s *Service) Reconcile(ctx context.Context) (err error) {
 		return errors.Wrap(err, "failed to reconcile targets")
 	}
 
+	// reconcile health check of the API server
+	if err := s.reconcileHealthCheck(ctx, lb); err != nil {
+		return errors.Wrap(err, "failed to reconcile health check")
+	}
+
 	return nil
 }
 
@@ -142,13 +161,35 @@ func (s *Service) reconcileNetworkAttachement(ctx context.Context, lb *hcloud.Lo
 	return nil
 }
 
+// reconcileLBProperties changes the type, algorithm, name and labels of the load balancer to the spec. Changes that
+// were made outside of the controller are kept if the drift policy is Adopt, until the spec is changed.
 func (s *Service) reconcileLBProperties(ctx context.Context, lb *hcloud.LoadBalancer) error {
+	spec := s.scope.HetznerCluster.Spec.ControlPlaneLoadBalancer
+	lbStatus := s.scope.HetznerCluster.Status.ControlPlaneLoadBalancer
+	adopt := s.scope.HetznerCluster.Spec.DriftPolicies.LoadBalancerPolicy() == infrav1.DriftPolicyAdopt
+
+	desired := infrav1.LoadBalancerProperties{Type: spec.Type, Algorithm: spec.Algorithm}
+	if spec.Name != nil {
+		desired.Name = *spec.Name
+	}
+	applied := desired
+	if lbStatus.AppliedProperties != nil {
+		applied = *lbStatus.AppliedProperties
+	}
+
 	var multierr []error
+	var adopted []string
+
 	// Check if type has been updated
-	if s.scope.HetznerCluster.Spec.ControlPlaneLoadBalancer.Type != lb.LoadBalancerType.Name {
+	switch {
+	case desired.Type == lb.LoadBalancerType.Name:
+		applied.Type = desired.Type
+	case adopt && desired.Type == applied.Type:
+		adopted = append(adopted, "type "+lb.LoadBalancerType.Name)
+	default:
 		if _, err := s.scope.HCloudClient.ChangeLoadBalancerType(ctx, lb, hcloud.LoadBalancerChangeTypeOpts{
 			LoadBalancerType: &hcloud.LoadBalancerType{
-				Name: s.scope.HetznerCluster.Spec.ControlPlaneLoadBalancer.Type,
+				Name: desired.Type,
 			},
 		}); err != nil {
 			if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
@@ -160,14 +201,21 @@ func (s *Service) reconcileLBProperties(ctx context.Context, lb *hcloud.LoadBala
 				return errors.Wrap(err, "rate limit exceeded while changing lb type")
 			}
 			multierr = append(multierr, errors.Wrap(err, "failed to change load balancer type"))
+		} else {
+			applied.Type = desired.Type
 		}
 		record.Eventf(s.scope.HetznerCluster, "ChangeLoadBalancerType", "Changed load balancer type")
 	}
 
 	// Check if algorithm has been updated
-	if string(s.scope.HetznerCluster.Spec.ControlPlaneLoadBalancer.Algorithm) != string(lb.Algorithm.Type) {
+	switch {
+	case desired.Algorithm.HCloudAlgorithmType() == lb.Algorithm.Type:
+		applied.Algorithm = desired.Algorithm
+	case adopt && desired.Algorithm == applied.Algorithm:
+		adopted = append(adopted, "algorithm "+string(lb.Algorithm.Type))
+	default:
 		if _, err := s.scope.HCloudClient.ChangeLoadBalancerAlgorithm(ctx, lb, hcloud.LoadBalancerChangeAlgorithmOpts{
-			Type: hcloud.LoadBalancerAlgorithmType(s.scope.HetznerCluster.Spec.ControlPlaneLoadBalancer.Algorithm),
+			Type: desired.Algorithm.HCloudAlgorithmType(),
 		}); err != nil {
 			if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
 				conditions.MarkTrue(s.scope.HetznerCluster, infrav1.RateLimitExceeded)
@@ -178,24 +226,70 @@ func (s *Service) reconcileLBProperties(ctx context.Context, lb *hcloud.LoadBala
 				return errors.Wrap(err, "rate limit exceeded while changing lb algorithm")
 			}
 			multierr = append(multierr, errors.Wrap(err, "failed to change load balancer algorithm"))
+		} else {
+			applied.Algorithm = desired.Algorithm
 		}
 		record.Eventf(s.scope.HetznerCluster, "ChangeLoadBalancerAlgorithm", "Changed load balancer algorithm")
 	}
 
-	// Check if name has been updated
-	if s.scope.HetznerCluster.Spec.ControlPlaneLoadBalancer.Name != nil &&
-		*s.scope.HetznerCluster.Spec.ControlPlaneLoadBalancer.Name != lb.Name {
+	// Check if name has been updated. A generated name is not changed.
+	switch {
+	case desired.Name == "" || desired.Name == lb.Name:
+		applied.Name = desired.Name
+	case adopt && desired.Name == applied.Name:
+		adopted = append(adopted, "name "+lb.Name)
+	default:
 		if _, err := s.scope.HCloudClient.UpdateLoadBalancer(ctx, lb, hcloud.LoadBalancerUpdateOpts{
-			Name: *s.scope.HetznerCluster.Spec.ControlPlaneLoadBalancer.Name,
+			Name: desired.Name,
 		}); err != nil {
 			multierr = append(multierr, errors.Wrap(err, "failed to update load balancer name"))
+		} else {
+			applied.Name = desired.Name
 		}
 		record.Eventf(s.scope.HetznerCluster, "ChangeLoadBalancerName", "Changed load balancer name")
 	}
 
+	// Check if labels have been edited. Only the label of the cluster is managed by the controller.
+	labels := map[string]string{
+		infrav1.ClusterTagKey(s.scope.HetznerCluster.Name): string(infrav1.ResourceLifecycleOwned),
+	}
+	if !reflect.DeepEqual(lb.Labels, labels) {
+		if adopt {
+			adopted = append(adopted, "labels")
+		} else if _, err := s.scope.HCloudClient.UpdateLoadBalancer(ctx, lb, hcloud.LoadBalancerUpdateOpts{
+			Labels: labels,
+		}); err != nil {
+			multierr = append(multierr, errors.Wrap(err, "failed to update load balancer labels"))
+		} else {
+			record.Eventf(s.scope.HetznerCluster, "ChangeLoadBalancerLabels", "Reverted labels of load balancer")
+		}
+	}
+
+	lbStatus.AppliedProperties = &applied
+	s.reconcileLBInSync(adopted)
 	return kerrors.NewAggregate(multierr)
 }
 
+// reconcileLBInSync sets the condition LoadBalancerInSync and records an event whenever other changes are adopted.
+func (s *Service) reconcileLBInSync(adopted []string) {
+	if len(adopted) == 0 {
+		conditions.MarkTrue(s.scope.HetznerCluster, infrav1.LoadBalancerInSyncCondition)
+		return
+	}
+
+	msg := "kept changes made outside of the controller: " + strings.Join(adopted, ", ")
+	if conditions.GetMessage(s.scope.HetznerCluster, infrav1.LoadBalancerInSyncCondition) != msg {
+		record.Eventf(s.scope.HetznerCluster, "AdoptLoadBalancerDrift", "Load balancer %s", msg)
+	}
+	conditions.MarkFalse(
+		s.scope.HetznerCluster,
+		infrav1.LoadBalancerInSyncCondition,
+		infrav1.DriftAdoptedReason,
+		clusterv1.ConditionSeverityInfo,
+		msg,
+	)
+}
+
 func max(x, y int) int {
 	if x > y {
 		return x
@@ -235,8 +329,27 @@ func (s *Service) reconcileServices(ctx context.Context, lb *hcloud.LoadBalancer
 		}
 	}
 
+	// Gather the certificates of the services in specs
+	certificates := make(map[int][]*hcloud.Certificate, len(specServiceListenPortsMap))
+	usedCertificateNames := make(map[string]struct{})
+	for listenPort, serviceInSpec := range specServiceListenPortsMap {
+		serviceCertificates, err := s.certificatesOfService(ctx, serviceInSpec)
+		if err != nil {
+			multierr = append(multierr, fmt.Errorf("error getting certificates of service with listen port %v: %w", listenPort, err))
+			continue
+		}
+		certificates[listenPort] = serviceCertificates
+		for _, certificate := range serviceCertificates {
+			usedCertificateNames[certificate.Name] = struct{}{}
+		}
+	}
+
 	// Create services which are in specs and not yet in API
 	for i, listenPort := range toCreate {
+		serviceCertificates, found := certificates[listenPort]
+		if !found {
+			continue
+		}
 		proxyProtocol := false
 		destinationPort := specServiceListenPortsMap[listenPort].DestinationPort
 		serviceOpts := hcloud.LoadBalancerAddServiceOpts{
@@ -245,11 +358,52 @@ func (s *Service) reconcileServices(ctx context.Context, lb *hcloud.LoadBalancer
 			DestinationPort: &destinationPort,
 			Proxyprotocol:   &proxyProtocol,
 		}
+		if len(serviceCertificates) > 0 {
+			serviceOpts.HTTP = &hcloud.LoadBalancerAddServiceOptsHTTP{Certificates: serviceCertificates}
+		}
 		if _, err := s.scope.HCloudClient.AddServiceToLoadBalancer(ctx, lb, serviceOpts); err != nil {
 			multierr = append(multierr, fmt.Errorf("error adding service to load balancer: %s", err))
 		}
 	}
 
+	// Update services which are in specs and in API but differ, e.g. in protocol or certificates
+	for _, service := range lb.Services {
+		serviceInSpec, found := specServiceListenPortsMap[service.ListenPort]
+		if !found {
+			continue
+		}
+		serviceCertificates, found := certificates[service.ListenPort]
+		if !found {
+			continue
+		}
+		if string(service.Protocol) == serviceInSpec.Protocol &&
+			service.DestinationPort == serviceInSpec.DestinationPort &&
+			certificateIDsEqual(service.HTTP.Certificates, serviceCertificates) {
+			continue
+		}
+
+		destinationPort := serviceInSpec.DestinationPort
+		serviceOpts := hcloud.LoadBalancerUpdateServiceOpts{
+			Protocol:        hcloud.LoadBalancerServiceProtocol(serviceInSpec.Protocol),
+			DestinationPort: &destinationPort,
+		}
+		if len(serviceCertificates) > 0 {
+			serviceOpts.HTTP = &hcloud.LoadBalancerUpdateServiceOptsHTTP{Certificates: serviceCertificates}
+		}
+		if _, err := s.scope.HCloudClient.UpdateServiceOfLoadBalancer(ctx, lb, service.ListenPort, serviceOpts); err != nil {
+			multierr = append(multierr, fmt.Errorf("error updating service of load balancer: %s", err))
+			continue
+		}
+		record.Eventf(s.scope.HetznerCluster, "UpdateLoadBalancerService", "Updated service with listen port %v of load balancer", service.ListenPort)
+	}
+
+	// Certificates can only be deleted once no service uses them anymore
+	if len(multierr) == 0 {
+		if err := s.deleteUnusedCertificates(ctx, usedCertificateNames); err != nil {
+			multierr = append(multierr, err)
+		}
+	}
+
 	return kerrors.NewAggregate(multierr)
 }
 
@@ -258,8 +412,13 @@ func (s *Service) createLoadBalancer(ctx context.Context) (*hcloud.LoadBalancer,
 
 	log.Info("Create a new loadbalancer", "algorithm type", s.scope.HetznerCluster.Spec.ControlPlaneLoadBalancer.Algorithm)
 
+	if err := quota.NewService(s.scope).Check(ctx, s.scope.HetznerCluster, quota.LoadBalancers); err != nil {
+		return nil, err
+	}
+
 	res, err := s.scope.HCloudClient.CreateLoadBalancer(ctx, buildLoadBalancerCreateOpts(s.scope.HetznerCluster))
 	if err != nil {
+		quota.RecordLimitError(s.scope.HetznerCluster, quota.LoadBalancers, err)
 		record.Warnf(
 			s.scope.HetznerCluster,
 			"FailedCreateLoadBalancer",
@@ -268,6 +427,7 @@ func (s *Service) createLoadBalancer(ctx context.Context) (*hcloud.LoadBalancer,
 		return nil, errors.Wrap(err, "error creating load balancer")
 	}
 
+	conditions.Delete(s.scope.HetznerCluster, infrav1.QuotaExceeded)
 	record.Eventf(s.scope.HetznerCluster, "CreateLoadBalancer", "Created load balancer")
 	return res.LoadBalancer, nil
 }
@@ -294,6 +454,23 @@ func buildLoadBalancerCreateOpts(hc *infrav1.HetznerCluster) hcloud.LoadBalancer
 	}
 
 	listenPort := int(hc.Spec.ControlPlaneEndpoint.Port)
+	apiServerService := hcloud.LoadBalancerCreateOptsService{
+		Protocol:        hcloud.LoadBalancerServiceProtocolTCP,
+		ListenPort:      &listenPort,
+		DestinationPort: &hc.Spec.ControlPlaneLoadBalancer.Port,
+		Proxyprotocol:   &proxyprotocol,
+	}
+	if hc.Spec.ControlPlaneLoadBalancer.HealthCheck != nil {
+		healthCheck := desiredHealthCheck(hc.Spec.ControlPlaneLoadBalancer)
+		apiServerService.HealthCheck = &hcloud.LoadBalancerCreateOptsServiceHealthCheck{
+			Protocol: healthCheck.Protocol,
+			Port:     &healthCheck.Port,
+			Interval: &healthCheck.Interval,
+			Timeout:  &healthCheck.Timeout,
+			Retries:  &healthCheck.Retries,
+		}
+	}
+
 	boolTrue := true
 	return hcloud.LoadBalancerCreateOpts{
 		LoadBalancerType: &hcloud.LoadBalancerType{
@@ -311,14 +488,7 @@ func buildLoadBalancerCreateOpts(hc *infrav1.HetznerCluster) hcloud.LoadBalancer
 			clusterTagKey: string(infrav1.ResourceLifecycleOwned),
 		},
 		PublicInterface: &boolTrue,
-		Services: []hcloud.LoadBalancerCreateOptsService{
-			{
-				Protocol:        hcloud.LoadBalancerServiceProtocolTCP,
-				ListenPort:      &listenPort,
-				DestinationPort: &hc.Spec.ControlPlaneLoadBalancer.Port,
-				Proxyprotocol:   &proxyprotocol,
-			},
-		},
+		Services:        []hcloud.LoadBalancerCreateOptsService{apiServerService},
 	}
 }
 
@@ -331,7 +501,8 @@ func (s *Service) Delete(ctx context.Context) (err error) {
 
 	if s.scope.HetznerCluster.Status.ControlPlaneLoadBalancer.Protected {
 		record.Eventf(s.scope.HetznerCluster, "LoadBalancerProtectedFromDeletion", "Cannot delete load balancer as it is protected")
-		return nil
+		// the load balancer is kept, but must not block the deletion of the network
+		return s.detachFromNetwork(ctx)
 	}
 
 	if err := s.scope.HCloudClient.DeleteLoadBalancer(ctx, s.scope.HetznerCluster.Status.ControlPlaneLoadBalancer.ID); err != nil {
@@ -346,10 +517,80 @@ func (s *Service) Delete(ctx context.Context) (err error) {
 	// Delete lb information from cluster status
 	s.scope.HetznerCluster.Status.ControlPlaneLoadBalancer = nil
 
+	// Delete the managed certificates of the services
+	if err := s.deleteUnusedCertificates(ctx, nil); err != nil {
+		return errors.Wrap(err, "failed to delete certificates")
+	}
+
 	record.Eventf(s.scope.HetznerCluster, "DeleteLoadBalancer", "Deleted load balancer")
 	return nil
 }
 
+// DeleteTargets removes the remaining server and IP targets from the load balancer, e.g. of bare metal hosts or
+// servers that are kept. Targets of the annotation KeepLoadBalancerTargetsAnnotation stay on a load balancer that
+// is protected from deletion.
+func (s *Service) DeleteTargets(ctx context.Context) error {
+	if s.scope.HetznerCluster.Status.ControlPlaneLoadBalancer == nil {
+		// nothing to do
+		return nil
+	}
+
+	lb, err := s.findLoadBalancer(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to find load balancer")
+	}
+	if lb == nil {
+		return nil
+	}
+
+	kept, err := keptTargets(s.scope.HetznerCluster)
+	if err != nil {
+		return errors.Wrapf(err, "invalid annotation %s", infrav1.KeepLoadBalancerTargetsAnnotation)
+	}
+
+	// the targets of the load balancer change while they are deleted
+	targets := append([]hcloud.LoadBalancerTarget(nil), lb.Targets...)
+	for _, target := range targets {
+		if _, found := kept[targetKey(target)]; found && lb.Protection.Delete {
+			continue
+		}
+		if err := s.deleteTarget(ctx, lb, target); err != nil {
+			return errors.Wrap(err, "failed to delete target of load balancer")
+		}
+	}
+
+	s.scope.HetznerCluster.Status.ControlPlaneLoadBalancer.Target = nil
+	return nil
+}
+
+// detachFromNetwork detaches the load balancer from the network of the cluster.
+func (s *Service) detachFromNetwork(ctx context.Context) error {
+	if s.scope.HetznerCluster.Status.Network == nil {
+		return nil
+	}
+
+	lb := &hcloud.LoadBalancer{ID: s.scope.HetznerCluster.Status.ControlPlaneLoadBalancer.ID}
+	if _, err := s.scope.HCloudClient.DetachLoadBalancerFromNetwork(ctx, lb, hcloud.LoadBalancerDetachFromNetworkOpts{
+		Network: &hcloud.Network{ID: s.scope.HetznerCluster.Status.Network.ID},
+	}); err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HetznerCluster, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HetznerCluster,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function DetachLoadBalancerFromNetwork",
+			)
+		}
+		if hcloud.IsError(err, hcloud.ErrorCodeNotFound) || hcloud.IsError(err, hcloud.ErrorCodeLoadBalancerNotAttachedToNetwork) {
+			return nil
+		}
+		record.Warnf(s.scope.HetznerCluster, "FailedDetachLoadBalancer", "Failed to detach load balancer from network: %s", err)
+		return errors.Wrap(err, "failed to detach load balancer from network")
+	}
+
+	record.Eventf(s.scope.HetznerCluster, "DetachedLoadBalancer", "Detached protected load balancer from network")
+	return nil
+}
+
 func (s *Service) findLoadBalancer(ctx context.Context) (*hcloud.LoadBalancer, error) {
 	clusterTagKey := infrav1.ClusterTagKey(s.scope.HetznerCluster.Name)
 	loadBalancers, err := s.scope.HCloudClient.ListLoadBalancers(ctx, hcloud.LoadBalancerListOpts{
@@ -384,17 +625,27 @@ func apiToStatus(lb *hcloud.LoadBalancer, hasNetwork bool) (infrav1.LoadBalancer
 
 	targets := make([]infrav1.LoadBalancerTarget, 0, len(lb.Targets))
 	for _, target := range lb.Targets {
+		var healthStatus []infrav1.LoadBalancerTargetHealthStatus
+		for _, health := range target.HealthStatus {
+			healthStatus = append(healthStatus, infrav1.LoadBalancerTargetHealthStatus{
+				ListenPort: health.ListenPort,
+				Status:     string(health.Status),
+			})
+		}
+
 		switch target.Type {
 		case hcloud.LoadBalancerTargetTypeServer:
 			targets = append(targets, infrav1.LoadBalancerTarget{
-				Type:     infrav1.LoadBalancerTargetTypeServer,
-				ServerID: target.Server.Server.ID,
+				Type:         infrav1.LoadBalancerTargetTypeServer,
+				ServerID:     target.Server.Server.ID,
+				HealthStatus: healthStatus,
 			},
 			)
 		case hcloud.LoadBalancerTargetTypeIP:
 			targets = append(targets, infrav1.LoadBalancerTarget{
-				Type: infrav1.LoadBalancerTargetTypeIP,
-				IP:   target.IP.IP,
+				Type:         infrav1.LoadBalancerTargetTypeIP,
+				IP:           target.IP.IP,
+				HealthStatus: healthStatus,
 			},
 			)
 		default:
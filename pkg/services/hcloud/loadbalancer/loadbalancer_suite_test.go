@@ -208,12 +208,14 @@ const internalIP = "10.0.0.2"
 
 var targets = []infrav1.LoadBalancerTarget{
 	{
-		Type:     infrav1.LoadBalancerTargetTypeServer,
-		ServerID: 80,
+		Type:         infrav1.LoadBalancerTargetTypeServer,
+		ServerID:     80,
+		HealthStatus: []infrav1.LoadBalancerTargetHealthStatus{{ListenPort: 443, Status: "healthy"}},
 	},
 	{
-		Type:     infrav1.LoadBalancerTargetTypeServer,
-		ServerID: 81,
+		Type:         infrav1.LoadBalancerTargetTypeServer,
+		ServerID:     81,
+		HealthStatus: []infrav1.LoadBalancerTargetHealthStatus{{ListenPort: 444, Status: "healthy"}},
 	},
 }
 
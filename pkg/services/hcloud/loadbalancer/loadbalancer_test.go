@@ -17,11 +17,28 @@ limitations under the License.
 package loadbalancer
 
 import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/scope"
+	hcloudclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client"
+	fakeclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakek8sclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
+var ctx = context.Background()
+
 var _ = Describe("Loadbalancer", func() {
 	Context("hcloud cluster has network attached", func() {
 		var sts infrav1.LoadBalancerStatus
@@ -68,3 +85,517 @@ var _ = Describe("Loadbalancer", func() {
 		})
 	})
 })
+
+var _ = Describe("reconcileServices", func() {
+	var (
+		service    *Service
+		lb         *hcloud.LoadBalancer
+		httpsSpec  infrav1.LoadBalancerServiceSpec
+		listenPort = 443
+	)
+
+	BeforeEach(func() {
+		hcloudClient := fakeclient.NewHCloudClientFactory().NewClient("")
+		hcloudClient.Close()
+
+		hetznerCluster := &infrav1.HetznerCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "lb-test", Namespace: "default"},
+			Spec: infrav1.HetznerClusterSpec{
+				ControlPlaneEndpoint: &clusterv1.APIEndpoint{Host: "1.2.3.4", Port: 6443},
+			},
+		}
+		service = NewService(&scope.ClusterScope{HCloudClient: hcloudClient, HetznerCluster: hetznerCluster})
+
+		res, err := hcloudClient.CreateLoadBalancer(ctx, buildLoadBalancerCreateOpts(hetznerCluster))
+		Expect(err).To(Succeed())
+		lb = res.LoadBalancer
+
+		httpsSpec = infrav1.LoadBalancerServiceSpec{
+			Protocol:        "https",
+			ListenPort:      listenPort,
+			DestinationPort: 8080,
+			Certificate: &infrav1.LoadBalancerCertificateSpec{
+				Type:        infrav1.LoadBalancerCertificateTypeManaged,
+				DomainNames: []string{"api.example.com"},
+			},
+		}
+	})
+
+	listCertificates := func() []*hcloud.Certificate {
+		certificates, err := service.scope.HCloudClient.ListCertificates(ctx, hcloud.CertificateListOpts{})
+		Expect(err).To(Succeed())
+		return certificates
+	}
+
+	serviceOfListenPort := func() hcloud.LoadBalancerService {
+		for _, s := range lb.Services {
+			if s.ListenPort == listenPort {
+				return s
+			}
+		}
+		Fail("service not found")
+		return hcloud.LoadBalancerService{}
+	}
+
+	It("adds a TLS terminating service with a managed certificate", func() {
+		service.scope.HetznerCluster.Spec.ControlPlaneLoadBalancer.ExtraServices = []infrav1.LoadBalancerServiceSpec{httpsSpec}
+		Expect(service.reconcileServices(ctx, lb)).To(Succeed())
+
+		Expect(lb.Services).To(HaveLen(1))
+		certificates := listCertificates()
+		Expect(certificates).To(HaveLen(1))
+		Expect(certificates[0].Type).To(Equal(hcloud.CertificateTypeManaged))
+		Expect(certificates[0].DomainNames).To(Equal([]string{"api.example.com"}))
+
+		svc := serviceOfListenPort()
+		Expect(svc.Protocol).To(Equal(hcloud.LoadBalancerServiceProtocolHTTPS))
+		Expect(svc.HTTP.Certificates).To(Equal(certificates))
+	})
+
+	It("replaces the managed certificate if the domain names change", func() {
+		service.scope.HetznerCluster.Spec.ControlPlaneLoadBalancer.ExtraServices = []infrav1.LoadBalancerServiceSpec{httpsSpec}
+		Expect(service.reconcileServices(ctx, lb)).To(Succeed())
+		oldName := listCertificates()[0].Name
+
+		httpsSpec.Certificate.DomainNames = []string{"kube.example.com"}
+		service.scope.HetznerCluster.Spec.ControlPlaneLoadBalancer.ExtraServices = []infrav1.LoadBalancerServiceSpec{httpsSpec}
+		Expect(service.reconcileServices(ctx, lb)).To(Succeed())
+
+		certificates := listCertificates()
+		Expect(certificates).To(HaveLen(1))
+		Expect(certificates[0].Name).ToNot(Equal(oldName))
+		Expect(serviceOfListenPort().HTTP.Certificates).To(Equal(certificates))
+	})
+
+	It("switches a service to TCP passthrough and deletes the unused certificate", func() {
+		service.scope.HetznerCluster.Spec.ControlPlaneLoadBalancer.ExtraServices = []infrav1.LoadBalancerServiceSpec{httpsSpec}
+		Expect(service.reconcileServices(ctx, lb)).To(Succeed())
+
+		service.scope.HetznerCluster.Spec.ControlPlaneLoadBalancer.ExtraServices = []infrav1.LoadBalancerServiceSpec{
+			{Protocol: "tcp", ListenPort: listenPort, DestinationPort: 6443},
+		}
+		Expect(service.reconcileServices(ctx, lb)).To(Succeed())
+
+		svc := serviceOfListenPort()
+		Expect(svc.Protocol).To(Equal(hcloud.LoadBalancerServiceProtocolTCP))
+		Expect(svc.DestinationPort).To(Equal(6443))
+		Expect(listCertificates()).To(BeEmpty())
+	})
+
+	It("uses an uploaded certificate and does not delete it", func() {
+		res, err := service.scope.HCloudClient.CreateCertificate(ctx, hcloud.CertificateCreateOpts{
+			Name: "uploaded", Type: hcloud.CertificateTypeUploaded, Certificate: "cert", PrivateKey: "key",
+		})
+		Expect(err).To(Succeed())
+
+		httpsSpec.Certificate = &infrav1.LoadBalancerCertificateSpec{Type: infrav1.LoadBalancerCertificateTypeUploaded, Name: "uploaded"}
+		service.scope.HetznerCluster.Spec.ControlPlaneLoadBalancer.ExtraServices = []infrav1.LoadBalancerServiceSpec{httpsSpec}
+		Expect(service.reconcileServices(ctx, lb)).To(Succeed())
+		Expect(serviceOfListenPort().HTTP.Certificates).To(Equal([]*hcloud.Certificate{res.Certificate}))
+
+		service.scope.HetznerCluster.Spec.ControlPlaneLoadBalancer.ExtraServices = nil
+		Expect(service.reconcileServices(ctx, lb)).To(Succeed())
+		Expect(lb.Services).To(BeEmpty())
+		Expect(listCertificates()).To(HaveLen(1))
+	})
+
+	It("fails if the uploaded certificate does not exist", func() {
+		httpsSpec.Certificate = &infrav1.LoadBalancerCertificateSpec{Type: infrav1.LoadBalancerCertificateTypeUploaded, Name: "missing"}
+		service.scope.HetznerCluster.Spec.ControlPlaneLoadBalancer.ExtraServices = []infrav1.LoadBalancerServiceSpec{httpsSpec}
+		Expect(service.reconcileServices(ctx, lb)).ToNot(Succeed())
+		Expect(lb.Services).To(BeEmpty())
+	})
+})
+
+var _ = Describe("managedCertificateName", func() {
+	It("does not depend on the order of the domain names", func() {
+		Expect(managedCertificateName("cluster", 443, []string{"a.example.com", "b.example.com"})).
+			To(Equal(managedCertificateName("cluster", 443, []string{"b.example.com", "a.example.com"})))
+	})
+	It("changes with the domain names", func() {
+		Expect(managedCertificateName("cluster", 443, []string{"a.example.com"})).
+			ToNot(Equal(managedCertificateName("cluster", 443, []string{"b.example.com"})))
+	})
+})
+
+var _ = Describe("reconcileTargetHealth", func() {
+	var service *Service
+
+	BeforeEach(func() {
+		providerID := "hcloud://80"
+		hcloudMachine := &infrav1.HCloudMachine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cp-1",
+				Namespace: "default",
+				Labels:    map[string]string{clusterv1.ClusterLabelName: "cluster"},
+			},
+			Spec: infrav1.HCloudMachineSpec{ProviderID: &providerID},
+		}
+		bmMachine := &infrav1.HetznerBareMetalMachine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "bm-cp-1",
+				Namespace: "default",
+				Labels:    map[string]string{clusterv1.ClusterLabelName: "cluster"},
+			},
+			Status: infrav1.HetznerBareMetalMachineStatus{
+				Addresses: []corev1.NodeAddress{{Type: corev1.NodeExternalIP, Address: "203.0.113.1"}},
+			},
+		}
+
+		scheme := runtime.NewScheme()
+		Expect(infrav1.AddToScheme(scheme)).To(Succeed())
+		service = NewService(&scope.ClusterScope{
+			Client:         fakek8sclient.NewClientBuilder().WithScheme(scheme).WithObjects(hcloudMachine, bmMachine).Build(),
+			Cluster:        &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default"}},
+			HetznerCluster: &infrav1.HetznerCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default"}},
+		})
+	})
+
+	It("marks the condition true if no target fails the health checks", func() {
+		service.reconcileTargetHealth(ctx, []infrav1.LoadBalancerTarget{
+			{
+				Type:         infrav1.LoadBalancerTargetTypeServer,
+				ServerID:     80,
+				HealthStatus: []infrav1.LoadBalancerTargetHealthStatus{{ListenPort: 6443, Status: "healthy"}, {ListenPort: 443, Status: "unknown"}},
+			},
+		})
+		Expect(conditions.IsTrue(service.scope.HetznerCluster, infrav1.LoadBalancerTargetsHealthyCondition)).To(BeTrue())
+	})
+
+	It("names the machines and ports of unhealthy targets", func() {
+		service.reconcileTargetHealth(ctx, []infrav1.LoadBalancerTarget{
+			{
+				Type:         infrav1.LoadBalancerTargetTypeServer,
+				ServerID:     80,
+				HealthStatus: []infrav1.LoadBalancerTargetHealthStatus{{ListenPort: 6443, Status: "unhealthy"}, {ListenPort: 443, Status: "unhealthy"}},
+			},
+			{
+				Type:         infrav1.LoadBalancerTargetTypeIP,
+				IP:           "203.0.113.1",
+				HealthStatus: []infrav1.LoadBalancerTargetHealthStatus{{ListenPort: 6443, Status: "healthy"}},
+			},
+			{
+				Type:         infrav1.LoadBalancerTargetTypeIP,
+				IP:           "203.0.113.2",
+				HealthStatus: []infrav1.LoadBalancerTargetHealthStatus{{ListenPort: 6443, Status: "unhealthy"}},
+			},
+		})
+
+		condition := conditions.Get(service.scope.HetznerCluster, infrav1.LoadBalancerTargetsHealthyCondition)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(infrav1.LoadBalancerTargetsUnhealthyReason))
+		Expect(condition.Message).To(Equal(
+			"machine cp-1 (server 80) fails health checks of ports 6443, 443; IP 203.0.113.2 fails health checks of ports 6443"))
+	})
+
+	It("names bare metal machines by their address", func() {
+		service.reconcileTargetHealth(ctx, []infrav1.LoadBalancerTarget{
+			{
+				Type:         infrav1.LoadBalancerTargetTypeIP,
+				IP:           "203.0.113.1",
+				HealthStatus: []infrav1.LoadBalancerTargetHealthStatus{{ListenPort: 6443, Status: "unhealthy"}},
+			},
+		})
+		Expect(conditions.GetMessage(service.scope.HetznerCluster, infrav1.LoadBalancerTargetsHealthyCondition)).
+			To(Equal("machine bm-cp-1 (IP 203.0.113.1) fails health checks of ports 6443"))
+	})
+})
+
+var _ = Describe("reconcileLBProperties", func() {
+	var (
+		service *Service
+		lb      *hcloud.LoadBalancer
+	)
+
+	BeforeEach(func() {
+		hcloudClient := fakeclient.NewHCloudClientFactory().NewClient("")
+		hcloudClient.Close()
+
+		hetznerCluster := &infrav1.HetznerCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "lb-test", Namespace: "default"},
+			Spec: infrav1.HetznerClusterSpec{
+				ControlPlaneEndpoint: &clusterv1.APIEndpoint{Host: "1.2.3.4", Port: 6443},
+				ControlPlaneLoadBalancer: infrav1.LoadBalancerSpec{
+					Type:      "lb11",
+					Algorithm: infrav1.LoadBalancerAlgorithmTypeRoundRobin,
+				},
+			},
+			Status: infrav1.HetznerClusterStatus{ControlPlaneLoadBalancer: &infrav1.LoadBalancerStatus{}},
+		}
+		service = NewService(&scope.ClusterScope{HCloudClient: hcloudClient, HetznerCluster: hetznerCluster})
+
+		res, err := hcloudClient.CreateLoadBalancer(ctx, buildLoadBalancerCreateOpts(hetznerCluster))
+		Expect(err).To(Succeed())
+		lb = res.LoadBalancer
+		Expect(service.reconcileLBProperties(ctx, lb)).To(Succeed())
+
+		// change the type and labels outside of the controller
+		lb.LoadBalancerType = &hcloud.LoadBalancerType{Name: "lb21"}
+		lb.Labels = map[string]string{"team": "a"}
+	})
+
+	It("reverts changes with the default drift policy", func() {
+		Expect(service.reconcileLBProperties(ctx, lb)).To(Succeed())
+
+		Expect(lb.LoadBalancerType.Name).To(Equal("lb11"))
+		Expect(lb.Labels).To(Equal(map[string]string{infrav1.ClusterTagKey("lb-test"): string(infrav1.ResourceLifecycleOwned)}))
+		Expect(conditions.IsTrue(service.scope.HetznerCluster, infrav1.LoadBalancerInSyncCondition)).To(BeTrue())
+	})
+
+	It("keeps changes with the drift policy Adopt", func() {
+		service.scope.HetznerCluster.Spec.DriftPolicies = &infrav1.DriftPolicies{LoadBalancer: infrav1.DriftPolicyAdopt}
+		Expect(service.reconcileLBProperties(ctx, lb)).To(Succeed())
+
+		Expect(lb.LoadBalancerType.Name).To(Equal("lb21"))
+		Expect(lb.Labels).To(Equal(map[string]string{"team": "a"}))
+
+		condition := conditions.Get(service.scope.HetznerCluster, infrav1.LoadBalancerInSyncCondition)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(infrav1.DriftAdoptedReason))
+		Expect(condition.Message).To(Equal("kept changes made outside of the controller: type lb21, labels"))
+	})
+
+	It("applies a changed spec with the drift policy Adopt", func() {
+		service.scope.HetznerCluster.Spec.DriftPolicies = &infrav1.DriftPolicies{LoadBalancer: infrav1.DriftPolicyAdopt}
+		service.scope.HetznerCluster.Spec.ControlPlaneLoadBalancer.Type = "lb31"
+		Expect(service.reconcileLBProperties(ctx, lb)).To(Succeed())
+
+		Expect(lb.LoadBalancerType.Name).To(Equal("lb31"))
+		Expect(service.scope.HetznerCluster.Status.ControlPlaneLoadBalancer.AppliedProperties.Type).To(Equal("lb31"))
+		Expect(conditions.GetMessage(service.scope.HetznerCluster, infrav1.LoadBalancerInSyncCondition)).
+			To(Equal("kept changes made outside of the controller: labels"))
+	})
+})
+
+var _ = Describe("createLoadBalancer", func() {
+	DescribeTable("creates the load balancer with the algorithm of the spec",
+		func(algorithm infrav1.LoadBalancerAlgorithmType, expected hcloud.LoadBalancerAlgorithmType) {
+			hcloudClient := fakeclient.NewHCloudClientFactory().NewClient("")
+			hetznerCluster := &infrav1.HetznerCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "lb-algorithm-" + string(algorithm), Namespace: "default"},
+				Spec: infrav1.HetznerClusterSpec{
+					ControlPlaneEndpoint:     &clusterv1.APIEndpoint{Host: "1.2.3.4", Port: 6443},
+					ControlPlaneLoadBalancer: infrav1.LoadBalancerSpec{Type: "lb11", Algorithm: algorithm},
+				},
+			}
+			service := NewService(&scope.ClusterScope{HCloudClient: hcloudClient, HetznerCluster: hetznerCluster})
+
+			lb, err := service.createLoadBalancer(ctx)
+			Expect(err).To(Succeed())
+			Expect(lb.Algorithm.Type).To(Equal(expected))
+
+			// the created load balancer is in sync with the spec
+			hetznerCluster.Status.ControlPlaneLoadBalancer = &infrav1.LoadBalancerStatus{}
+			Expect(service.reconcileLBProperties(ctx, lb)).To(Succeed())
+			Expect(lb.Algorithm.Type).To(Equal(expected))
+			Expect(hetznerCluster.Status.ControlPlaneLoadBalancer.AppliedProperties.Algorithm).To(Equal(algorithm))
+		},
+		Entry("round robin", infrav1.LoadBalancerAlgorithmTypeRoundRobin, hcloud.LoadBalancerAlgorithmTypeRoundRobin),
+		Entry("least connections", infrav1.LoadBalancerAlgorithmTypeLeastConnections, hcloud.LoadBalancerAlgorithmTypeLeastConnections),
+	)
+})
+
+var _ = Describe("DeleteTargets", func() {
+	It("removes the server and IP targets of the load balancer", func() {
+		hcloudClient := fakeclient.NewHCloudClientFactory().NewClient("")
+		hcloudClient.Close()
+
+		hetznerCluster := &infrav1.HetznerCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "lb-test", Namespace: "default"},
+			Spec: infrav1.HetznerClusterSpec{
+				ControlPlaneEndpoint:     &clusterv1.APIEndpoint{Host: "1.2.3.4", Port: 6443},
+				ControlPlaneLoadBalancer: infrav1.LoadBalancerSpec{Type: "lb11"},
+			},
+		}
+		service := NewService(&scope.ClusterScope{HCloudClient: hcloudClient, HetznerCluster: hetznerCluster})
+
+		res, err := hcloudClient.CreateLoadBalancer(ctx, buildLoadBalancerCreateOpts(hetznerCluster))
+		Expect(err).To(Succeed())
+		lb := res.LoadBalancer
+		_, err = hcloudClient.AddTargetServerToLoadBalancer(ctx, hcloud.LoadBalancerAddServerTargetOpts{Server: &hcloud.Server{ID: 1}}, lb)
+		Expect(err).To(Succeed())
+		_, err = hcloudClient.AddIPTargetToLoadBalancer(ctx, hcloud.LoadBalancerAddIPTargetOpts{IP: net.ParseIP("1.2.3.5")}, lb)
+		Expect(err).To(Succeed())
+		hetznerCluster.Status.ControlPlaneLoadBalancer = &infrav1.LoadBalancerStatus{ID: lb.ID}
+
+		Expect(service.DeleteTargets(ctx)).To(Succeed())
+
+		lbs, err := hcloudClient.ListLoadBalancers(ctx, hcloud.LoadBalancerListOpts{})
+		Expect(err).To(Succeed())
+		Expect(lbs).To(HaveLen(1))
+		Expect(lbs[0].Targets).To(BeEmpty())
+	})
+})
+
+var _ = Describe("reconcileHealthCheck", func() {
+	var (
+		service       *Service
+		lb            *hcloud.LoadBalancer
+		hcloudService func() hcloud.LoadBalancerService
+	)
+
+	BeforeEach(func() {
+		hcloudClient := fakeclient.NewHCloudClientFactory().NewClient("")
+		hcloudClient.Close()
+
+		retries := 1
+		hetznerCluster := &infrav1.HetznerCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "lb-test", Namespace: "default"},
+			Spec: infrav1.HetznerClusterSpec{
+				ControlPlaneEndpoint: &clusterv1.APIEndpoint{Host: "1.2.3.4", Port: 443},
+				ControlPlaneLoadBalancer: infrav1.LoadBalancerSpec{
+					Type: "lb11",
+					Port: 6443,
+					HealthCheck: &infrav1.LoadBalancerHealthCheckSpec{
+						Interval: &metav1.Duration{Duration: 5 * time.Second},
+						Timeout:  &metav1.Duration{Duration: 3 * time.Second},
+						Retries:  &retries,
+					},
+				},
+			},
+		}
+		service = NewService(&scope.ClusterScope{HCloudClient: hcloudClient, HetznerCluster: hetznerCluster})
+
+		res, err := hcloudClient.CreateLoadBalancer(ctx, buildLoadBalancerCreateOpts(hetznerCluster))
+		Expect(err).To(Succeed())
+		lb = res.LoadBalancer
+
+		listenPort, destinationPort := 443, 6443
+		_, err = hcloudClient.AddServiceToLoadBalancer(ctx, lb, hcloud.LoadBalancerAddServiceOpts{
+			Protocol:        hcloud.LoadBalancerServiceProtocolTCP,
+			ListenPort:      &listenPort,
+			DestinationPort: &destinationPort,
+		})
+		Expect(err).To(Succeed())
+
+		hcloudService = func() hcloud.LoadBalancerService {
+			Expect(lb.Services).To(HaveLen(1))
+			return lb.Services[0]
+		}
+	})
+
+	It("creates the load balancer with the health check of the spec", func() {
+		opts := buildLoadBalancerCreateOpts(service.scope.HetznerCluster)
+		Expect(opts.Services).To(HaveLen(1))
+		Expect(opts.Services[0].HealthCheck).ToNot(BeNil())
+		Expect(*opts.Services[0].HealthCheck.Port).To(Equal(6443))
+		Expect(*opts.Services[0].HealthCheck.Interval).To(Equal(5 * time.Second))
+	})
+
+	It("changes the health check of the API server and reverts changes", func() {
+		Expect(service.reconcileHealthCheck(ctx, lb)).To(Succeed())
+		Expect(hcloudService().HealthCheck).To(Equal(hcloud.LoadBalancerServiceHealthCheck{
+			Protocol: hcloud.LoadBalancerServiceProtocolTCP,
+			Port:     6443,
+			Interval: 5 * time.Second,
+			Timeout:  3 * time.Second,
+			Retries:  1,
+		}))
+
+		// change the interval outside of the controller
+		lb.Services[0].HealthCheck.Interval = 15 * time.Second
+		Expect(service.reconcileHealthCheck(ctx, lb)).To(Succeed())
+		Expect(hcloudService().HealthCheck.Interval).To(Equal(5 * time.Second))
+	})
+
+	It("does not change the health check without a spec", func() {
+		service.scope.HetznerCluster.Spec.ControlPlaneLoadBalancer.HealthCheck = nil
+		Expect(service.reconcileHealthCheck(ctx, lb)).To(Succeed())
+		Expect(hcloudService().HealthCheck).To(Equal(hcloud.LoadBalancerServiceHealthCheck{}))
+	})
+})
+
+var _ = Describe("reconcileStaleTargets", func() {
+	var (
+		service      *Service
+		hcloudClient hcloudclient.Client
+		lb           *hcloud.LoadBalancer
+		k8sClient    client.Client
+	)
+
+	targetKeys := func() []string {
+		keys := make([]string, 0, len(lb.Targets))
+		for _, target := range lb.Targets {
+			keys = append(keys, targetKey(target))
+		}
+		return keys
+	}
+
+	BeforeEach(func() {
+		providerID := "hcloud://80"
+		hcloudMachine := &infrav1.HCloudMachine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cp-1",
+				Namespace: "default",
+				Labels:    map[string]string{clusterv1.ClusterLabelName: "cluster"},
+			},
+			Spec: infrav1.HCloudMachineSpec{ProviderID: &providerID},
+		}
+		scheme := runtime.NewScheme()
+		Expect(infrav1.AddToScheme(scheme)).To(Succeed())
+		k8sClient = fakek8sclient.NewClientBuilder().WithScheme(scheme).WithObjects(hcloudMachine).Build()
+
+		hcloudClient = fakeclient.NewHCloudClientFactory().NewClient("")
+		hcloudClient.Close()
+
+		hetznerCluster := &infrav1.HetznerCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default"},
+			Spec: infrav1.HetznerClusterSpec{
+				ControlPlaneEndpoint:     &clusterv1.APIEndpoint{Host: "1.2.3.4", Port: 6443},
+				ControlPlaneLoadBalancer: infrav1.LoadBalancerSpec{Type: "lb11"},
+			},
+		}
+		service = NewService(&scope.ClusterScope{
+			Client:         k8sClient,
+			HCloudClient:   hcloudClient,
+			Cluster:        &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default"}},
+			HetznerCluster: hetznerCluster,
+		})
+
+		res, err := hcloudClient.CreateLoadBalancer(ctx, buildLoadBalancerCreateOpts(hetznerCluster))
+		Expect(err).To(Succeed())
+		lb = res.LoadBalancer
+		for _, id := range []int{80, 81, 82} {
+			_, err = hcloudClient.AddTargetServerToLoadBalancer(ctx, hcloud.LoadBalancerAddServerTargetOpts{Server: &hcloud.Server{ID: id}}, lb)
+			Expect(err).To(Succeed())
+		}
+		_, err = hcloudClient.AddIPTargetToLoadBalancer(ctx, hcloud.LoadBalancerAddIPTargetOpts{IP: net.ParseIP("203.0.113.7")}, lb)
+		Expect(err).To(Succeed())
+	})
+
+	It("removes targets that do not belong to a machine and keeps the annotated ones", func() {
+		service.scope.HetznerCluster.Annotations = map[string]string{infrav1.KeepLoadBalancerTargetsAnnotation: "81, 203.0.113.7"}
+		Expect(service.reconcileStaleTargets(ctx, lb)).To(Succeed())
+
+		Expect(targetKeys()).To(ConsistOf("hcloud://80", "hcloud://81", "203.0.113.7"))
+	})
+
+	It("removes no targets while a machine has no provider ID", func() {
+		Expect(k8sClient.Create(ctx, &infrav1.HCloudMachine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cp-2",
+				Namespace: "default",
+				Labels:    map[string]string{clusterv1.ClusterLabelName: "cluster"},
+			},
+		})).To(Succeed())
+		Expect(service.reconcileStaleTargets(ctx, lb)).To(Succeed())
+
+		Expect(lb.Targets).To(HaveLen(4))
+	})
+
+	It("removes no targets with the drift policy Adopt", func() {
+		service.scope.HetznerCluster.Spec.DriftPolicies = &infrav1.DriftPolicies{LoadBalancer: infrav1.DriftPolicyAdopt}
+		Expect(service.reconcileStaleTargets(ctx, lb)).To(Succeed())
+
+		Expect(lb.Targets).To(HaveLen(4))
+	})
+
+	It("removes no targets if the annotation is invalid", func() {
+		service.scope.HetznerCluster.Annotations = map[string]string{infrav1.KeepLoadBalancerTargetsAnnotation: "standby"}
+		Expect(service.reconcileStaleTargets(ctx, lb)).To(Succeed())
+
+		Expect(lb.Targets).To(HaveLen(4))
+	})
+})
@@ -0,0 +1,190 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loadbalancer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"github.com/pkg/errors"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	hcloudclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// reconcileStaleTargets removes the server and IP targets from the load balancer that neither belong to a machine
+// of the cluster nor are kept by the annotation KeepLoadBalancerTargetsAnnotation, e.g. targets of machines that
+// were deleted while their target could not be removed. With the drift policy Adopt, the targets are kept, as
+// they might have been added outside of the controller.
+func (s *Service) reconcileStaleTargets(ctx context.Context, lb *hcloud.LoadBalancer) error {
+	if s.scope.HetznerCluster.Spec.DriftPolicies.LoadBalancerPolicy() == infrav1.DriftPolicyAdopt {
+		return nil
+	}
+
+	known, complete, err := s.targetsOfMachines(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get targets of machines")
+	}
+	if !complete {
+		// a machine that is being provisioned might have added a target that is not known yet
+		return nil
+	}
+	kept, err := keptTargets(s.scope.HetznerCluster)
+	if err != nil {
+		// removing a target that should be kept could take down its traffic
+		record.Warnf(s.scope.HetznerCluster, "InvalidKeptLoadBalancerTargets",
+			"Stale targets of load balancer are not removed, annotation %s is invalid: %s", infrav1.KeepLoadBalancerTargetsAnnotation, err)
+		return nil
+	}
+
+	remaining := make([]hcloud.LoadBalancerTarget, 0, len(lb.Targets))
+	for _, target := range append([]hcloud.LoadBalancerTarget(nil), lb.Targets...) {
+		key := targetKey(target)
+		_, isKnown := known[key]
+		_, isKept := kept[key]
+		if key == "" || isKnown || isKept {
+			remaining = append(remaining, target)
+			continue
+		}
+		if err := s.deleteTarget(ctx, lb, target); err != nil {
+			return errors.Wrapf(err, "failed to delete stale target %s", key)
+		}
+		record.Eventf(s.scope.HetznerCluster, "DeletedStaleTargetOfLoadBalancer",
+			"Deleted target %s of load balancer that does not belong to a machine of the cluster", key)
+	}
+	lb.Targets = remaining
+	return nil
+}
+
+// deleteTarget removes a server or IP target from the load balancer. Targets that are already gone are ignored.
+func (s *Service) deleteTarget(ctx context.Context, lb *hcloud.LoadBalancer, target hcloud.LoadBalancerTarget) error {
+	var err error
+	switch target.Type {
+	case hcloud.LoadBalancerTargetTypeServer:
+		_, err = s.scope.HCloudClient.DeleteTargetServerOfLoadBalancer(ctx, lb, target.Server.Server)
+	case hcloud.LoadBalancerTargetTypeIP:
+		_, err = s.scope.HCloudClient.DeleteIPTargetOfLoadBalancer(ctx, lb, net.ParseIP(target.IP.IP))
+	default:
+		return nil
+	}
+	if err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HetznerCluster, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HetznerCluster,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function DeleteTargetOfLoadBalancer",
+			)
+		}
+		if hcloud.IsError(err, hcloud.ErrorCodeNotFound) || hcloud.IsError(err, hcloudclient.ErrorCodeLoadBalancerTargetNotFound) {
+			return nil
+		}
+		record.Warnf(s.scope.HetznerCluster, "FailedDeleteLoadBalancerTarget", "Failed to delete target of load balancer: %s", err)
+		return err
+	}
+	return nil
+}
+
+// targetsOfMachines returns the keys of the targets of the machines of the cluster and whether all machines are
+// known, i.e. have a provider ID or addresses.
+func (s *Service) targetsOfMachines(ctx context.Context) (map[string]struct{}, bool, error) {
+	opts := []client.ListOption{
+		client.InNamespace(s.scope.Namespace()),
+		client.MatchingLabels{clusterv1.ClusterLabelName: s.scope.Cluster.Name},
+	}
+	targets := make(map[string]struct{})
+	complete := true
+
+	var hcloudMachines infrav1.HCloudMachineList
+	if err := s.scope.Client.List(ctx, &hcloudMachines, opts...); err != nil {
+		return nil, false, errors.Wrap(err, "failed to list HCloudMachines")
+	}
+	for _, machine := range hcloudMachines.Items {
+		if machine.Spec.ProviderID == nil {
+			complete = false
+			continue
+		}
+		targets[*machine.Spec.ProviderID] = struct{}{}
+	}
+
+	var bmMachines infrav1.HetznerBareMetalMachineList
+	if err := s.scope.Client.List(ctx, &bmMachines, opts...); err != nil {
+		return nil, false, errors.Wrap(err, "failed to list HetznerBareMetalMachines")
+	}
+	for _, machine := range bmMachines.Items {
+		if len(machine.Status.Addresses) == 0 {
+			complete = false
+			continue
+		}
+		for _, address := range machine.Status.Addresses {
+			if ip := net.ParseIP(address.Address); ip != nil {
+				targets[ip.String()] = struct{}{}
+			}
+		}
+	}
+	return targets, complete, nil
+}
+
+// keptTargets returns the keys of the targets of the annotation KeepLoadBalancerTargetsAnnotation. Servers have the
+// key of their provider ID, IPs are normalized.
+func keptTargets(hetznerCluster *infrav1.HetznerCluster) (map[string]struct{}, error) {
+	kept := make(map[string]struct{})
+	value := hetznerCluster.Annotations[infrav1.KeepLoadBalancerTargetsAnnotation]
+	if strings.TrimSpace(value) == "" {
+		return kept, nil
+	}
+	for _, entry := range strings.Split(value, ",") {
+		key, err := keptTargetKey(entry)
+		if err != nil {
+			return nil, err
+		}
+		kept[key] = struct{}{}
+	}
+	return kept, nil
+}
+
+// keptTargetKey returns the key of an entry of the annotation KeepLoadBalancerTargetsAnnotation.
+func keptTargetKey(entry string) (string, error) {
+	entry = strings.TrimSpace(entry)
+	if ip := net.ParseIP(entry); ip != nil {
+		return ip.String(), nil
+	}
+	id, err := strconv.Atoi(entry)
+	if err != nil || id <= 0 {
+		return "", fmt.Errorf("%q is neither a server ID nor an IP address", entry)
+	}
+	return fmt.Sprintf("hcloud://%d", id), nil
+}
+
+// targetKey returns the provider ID of a server target and the normalized IP of an IP target.
+func targetKey(target hcloud.LoadBalancerTarget) string {
+	switch target.Type {
+	case hcloud.LoadBalancerTargetTypeServer:
+		return fmt.Sprintf("hcloud://%d", target.Server.Server.ID)
+	case hcloud.LoadBalancerTargetTypeIP:
+		if ip := net.ParseIP(target.IP.IP); ip != nil {
+			return ip.String()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,442 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package machineimage implements the builds of snapshots of HCloudMachineImages.
+package machineimage
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"github.com/pkg/errors"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/scope"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// buildPollInterval is the interval in which a running build is checked.
+const buildPollInterval = 30 * time.Second
+
+// Service defines struct with HCloudMachineImage scope to build snapshots.
+type Service struct {
+	scope *scope.HCloudMachineImageScope
+}
+
+// NewService outs a new service with HCloudMachineImage scope.
+func NewService(scope *scope.HCloudMachineImageScope) *Service {
+	return &Service{
+		scope: scope,
+	}
+}
+
+// Reconcile starts a build if a new snapshot is due and drives a running build. A build boots a temporary
+// server that runs the provisioning script and powers off. The snapshot is taken of the powered off server,
+// and the server is deleted afterwards.
+func (s *Service) Reconcile(ctx context.Context) (_ *ctrl.Result, err error) {
+	log := ctrl.LoggerFrom(ctx)
+	log.V(1).Info("Reconcile machine image")
+
+	image := s.scope.HCloudMachineImage
+	if image.Status.Build == nil {
+		next := image.NextBuildTime()
+		if next == nil {
+			return nil, nil
+		}
+		if wait := time.Until(*next); wait > 0 {
+			return &ctrl.Result{RequeueAfter: wait}, nil
+		}
+		if err := s.startBuild(ctx); err != nil {
+			return nil, errors.Wrap(err, "failed to start build")
+		}
+		return &ctrl.Result{RequeueAfter: buildPollInterval}, nil
+	}
+
+	server, err := s.findBuildServer(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find build server")
+	}
+
+	build := image.Status.Build
+	if build.SnapshotID == 0 {
+		if server == nil {
+			return nil, s.failBuild(ctx, nil, "build server %d does not exist anymore", build.ServerID)
+		}
+		if server.Status != hcloud.ServerStatusOff {
+			if time.Since(build.StartTime.Time) > image.BuildTimeout() {
+				return nil, s.failBuild(ctx, server, "build server %s did not power off within %s", server.Name, image.BuildTimeout())
+			}
+			return &ctrl.Result{RequeueAfter: buildPollInterval}, nil
+		}
+		if err := s.createSnapshot(ctx, server); err != nil {
+			return nil, errors.Wrap(err, "failed to create snapshot")
+		}
+		return &ctrl.Result{RequeueAfter: buildPollInterval}, nil
+	}
+
+	snapshots, err := s.listSnapshots(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list snapshots")
+	}
+	snapshot := findSnapshot(snapshots, build.SnapshotID)
+	if snapshot == nil {
+		return nil, s.failBuild(ctx, server, "snapshot %d does not exist anymore", build.SnapshotID)
+	}
+	if snapshot.Status != hcloud.ImageStatusAvailable {
+		return &ctrl.Result{RequeueAfter: buildPollInterval}, nil
+	}
+
+	if err := s.deleteBuildServer(ctx, server); err != nil {
+		return nil, err
+	}
+
+	image.Status.Ready = true
+	image.Status.Phase = infrav1.MachineImagePhaseReady
+	image.Status.SnapshotID = snapshot.ID
+	s.finishBuild()
+	conditions.MarkTrue(image, infrav1.MachineImageReadyCondition)
+	record.Eventf(image, "MachineImageBuilt", "Built snapshot %d from image %s", snapshot.ID, image.Spec.BaseImage)
+
+	if err := s.deleteOldSnapshots(ctx, snapshots); err != nil {
+		return nil, errors.Wrap(err, "failed to delete old snapshots")
+	}
+
+	next := image.NextBuildTime()
+	if next == nil {
+		return nil, nil
+	}
+	return &ctrl.Result{RequeueAfter: time.Until(*next)}, nil
+}
+
+// Delete deletes the build server of a running build. The snapshots are kept, as machines might still use them.
+func (s *Service) Delete(ctx context.Context) (_ *ctrl.Result, err error) {
+	server, err := s.findBuildServer(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find build server")
+	}
+	if err := s.deleteBuildServer(ctx, server); err != nil {
+		return nil, err
+	}
+	s.scope.HCloudMachineImage.Status.Build = nil
+	return nil, nil
+}
+
+func (s *Service) startBuild(ctx context.Context) error {
+	image := s.scope.HCloudMachineImage
+
+	// a server of a build whose start has not been persisted is reused
+	server, err := s.findBuildServer(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to find build server")
+	}
+	if server == nil {
+		server, err = s.createBuildServer(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to create build server")
+		}
+	}
+
+	image.Status.Build = &infrav1.HCloudMachineImageBuild{
+		ServerID:   server.ID,
+		StartTime:  metav1.Now(),
+		Generation: image.Generation,
+	}
+	image.Status.Phase = infrav1.MachineImagePhaseBuilding
+	conditions.MarkFalse(image,
+		infrav1.MachineImageReadyCondition,
+		infrav1.MachineImageBuildingReason,
+		clusterv1.ConditionSeverityInfo,
+		"build server %s is running the provisioning script",
+		server.Name,
+	)
+	return nil
+}
+
+func (s *Service) createBuildServer(ctx context.Context) (*hcloud.Server, error) {
+	image := s.scope.HCloudMachineImage
+
+	sshKeys, err := s.sshKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	startAfterCreate := true
+	opts := hcloud.ServerCreateOpts{
+		Name:             fmt.Sprintf("%s-%s-image-build", s.scope.Namespace(), s.scope.Name()),
+		ServerType:       &hcloud.ServerType{Name: image.Spec.ServerType},
+		Image:            &hcloud.Image{Name: image.Spec.BaseImage},
+		Location:         &hcloud.Location{Name: string(image.Spec.Location)},
+		SSHKeys:          sshKeys,
+		UserData:         buildUserData(image.Spec.Script),
+		Labels:           s.labels(nil),
+		StartAfterCreate: &startAfterCreate,
+	}
+
+	res, err := s.scope.HCloudClient.CreateServer(ctx, opts)
+	if err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(image, infrav1.RateLimitExceeded)
+			record.Event(image,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function CreateServer",
+			)
+		}
+		record.Warnf(image, "FailedCreateBuildServer", "Failed to create build server: %s", err)
+		return nil, err
+	}
+
+	record.Eventf(image, "BuildServerCreated", "Created build server %s from image %s", res.Server.Name, image.Spec.BaseImage)
+	return res.Server, nil
+}
+
+func (s *Service) createSnapshot(ctx context.Context, server *hcloud.Server) error {
+	image := s.scope.HCloudMachineImage
+
+	description := image.Spec.Description
+	if description == "" {
+		description = s.scope.Name()
+	}
+
+	res, err := s.scope.HCloudClient.CreateImage(ctx, server, hcloud.ServerCreateImageOpts{
+		Type:        hcloud.ImageTypeSnapshot,
+		Description: &description,
+		Labels:      s.labels(image.Spec.Labels),
+	})
+	if err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(image, infrav1.RateLimitExceeded)
+			record.Event(image,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function CreateImage",
+			)
+		}
+		record.Warnf(image, "FailedCreateSnapshot", "Failed to create snapshot of server %s: %s", server.Name, err)
+		return err
+	}
+
+	image.Status.Build.SnapshotID = res.Image.ID
+	image.Status.Phase = infrav1.MachineImagePhaseSnapshotting
+	record.Eventf(image, "SnapshotCreated", "Creating snapshot %d of build server %s", res.Image.ID, server.Name)
+	return nil
+}
+
+// failBuild deletes the build server and records the failure. A new build is started when the spec changes
+// or when the rebuild interval has passed.
+func (s *Service) failBuild(ctx context.Context, server *hcloud.Server, format string, args ...interface{}) error {
+	if err := s.deleteBuildServer(ctx, server); err != nil {
+		return err
+	}
+
+	image := s.scope.HCloudMachineImage
+	severity := clusterv1.ConditionSeverityError
+	if image.Status.SnapshotID != 0 {
+		// machines can still use the snapshot of the last successful build
+		severity = clusterv1.ConditionSeverityWarning
+	}
+	msg := fmt.Sprintf(format, args...)
+	conditions.MarkFalse(image, infrav1.MachineImageReadyCondition, infrav1.MachineImageBuildFailedReason, severity, msg)
+	record.Warnf(image, "MachineImageBuildFailed", "Build failed: %s", msg)
+
+	image.Status.Phase = infrav1.MachineImagePhaseFailed
+	s.finishBuild()
+	return nil
+}
+
+func (s *Service) finishBuild() {
+	status := &s.scope.HCloudMachineImage.Status
+	startTime := status.Build.StartTime
+	status.LastBuildTime = &startTime
+	status.ObservedGeneration = status.Build.Generation
+	status.Build = nil
+}
+
+func (s *Service) deleteBuildServer(ctx context.Context, server *hcloud.Server) error {
+	if server == nil {
+		return nil
+	}
+	if err := s.scope.HCloudClient.DeleteServer(ctx, server); err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeNotFound) {
+			return nil
+		}
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HCloudMachineImage, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HCloudMachineImage,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function DeleteServer",
+			)
+		}
+		record.Warnf(s.scope.HCloudMachineImage, "FailedDeleteBuildServer", "Failed to delete build server %s: %s", server.Name, err)
+		return errors.Wrap(err, "failed to delete build server")
+	}
+	record.Eventf(s.scope.HCloudMachineImage, "BuildServerDeleted", "Deleted build server %s", server.Name)
+	return nil
+}
+
+// deleteOldSnapshots deletes the oldest snapshots of this object that exceed spec.snapshotsToKeep.
+func (s *Service) deleteOldSnapshots(ctx context.Context, snapshots []*hcloud.Image) error {
+	keep := s.scope.HCloudMachineImage.Spec.SnapshotsToKeep
+	if keep == nil || len(snapshots) <= *keep {
+		return nil
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		if !snapshots[i].Created.Equal(snapshots[j].Created) {
+			return snapshots[i].Created.After(snapshots[j].Created)
+		}
+		return snapshots[i].ID > snapshots[j].ID
+	})
+
+	for _, snapshot := range snapshots[*keep:] {
+		if snapshot.ID == s.scope.HCloudMachineImage.Status.SnapshotID {
+			continue
+		}
+		if err := s.scope.HCloudClient.DeleteImage(ctx, snapshot); err != nil {
+			if hcloud.IsError(err, hcloud.ErrorCodeNotFound) {
+				continue
+			}
+			if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+				conditions.MarkTrue(s.scope.HCloudMachineImage, infrav1.RateLimitExceeded)
+				record.Event(s.scope.HCloudMachineImage,
+					"RateLimitExceeded",
+					"exceeded rate limit with calling hcloud function DeleteImage",
+				)
+			}
+			return err
+		}
+		record.Eventf(s.scope.HCloudMachineImage, "SnapshotDeleted", "Deleted old snapshot %d", snapshot.ID)
+	}
+	return nil
+}
+
+func (s *Service) findBuildServer(ctx context.Context) (*hcloud.Server, error) {
+	servers, err := s.scope.HCloudClient.ListServers(ctx, hcloud.ServerListOpts{
+		ListOpts: hcloud.ListOpts{LabelSelector: s.uidSelector()},
+	})
+	if err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HCloudMachineImage, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HCloudMachineImage,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function ListServers",
+			)
+		}
+		return nil, err
+	}
+	if len(servers) == 0 {
+		return nil, nil
+	}
+	return servers[0], nil
+}
+
+// listSnapshots lists the snapshots that have been built for this object.
+func (s *Service) listSnapshots(ctx context.Context) ([]*hcloud.Image, error) {
+	snapshots, err := s.scope.HCloudClient.ListImages(ctx, hcloud.ImageListOpts{
+		ListOpts: hcloud.ListOpts{LabelSelector: s.uidSelector()},
+		Type:     []hcloud.ImageType{hcloud.ImageTypeSnapshot},
+	})
+	if err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HCloudMachineImage, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HCloudMachineImage,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function ListImages",
+			)
+		}
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+// sshKeys returns the HCloud SSH keys of the cluster. Without SSH key, HCloud would send a root password by email.
+func (s *Service) sshKeys(ctx context.Context) ([]*hcloud.SSHKey, error) {
+	specs := s.scope.HetznerCluster.Spec.SSHKeys.HCloud
+	if len(specs) == 0 {
+		return nil, nil
+	}
+
+	sshKeys, err := s.scope.HCloudClient.ListSSHKeys(ctx, hcloud.SSHKeyListOpts{})
+	if err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HCloudMachineImage, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HCloudMachineImage,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function ListSSHKeys",
+			)
+		}
+		return nil, errors.Wrap(err, "failed to list SSH keys")
+	}
+
+	keys := make([]*hcloud.SSHKey, 0, len(specs))
+	for _, spec := range specs {
+		var found bool
+		for _, sshKey := range sshKeys {
+			if sshKey.Name == spec.Name {
+				keys = append(keys, sshKey)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, errors.Errorf("SSH key %s not found in HCloud", spec.Name)
+		}
+	}
+	return keys, nil
+}
+
+// labels returns the given labels with the labels that identify this object.
+func (s *Service) labels(extra map[string]string) map[string]string {
+	labels := make(map[string]string, len(extra)+2)
+	for key, val := range extra {
+		labels[key] = val
+	}
+	labels[infrav1.MachineImageNameLabel] = s.scope.Name()
+	labels[infrav1.MachineImageUIDLabel] = string(s.scope.HCloudMachineImage.UID)
+	return labels
+}
+
+func (s *Service) uidSelector() string {
+	return fmt.Sprintf("%s==%s", infrav1.MachineImageUIDLabel, s.scope.HCloudMachineImage.UID)
+}
+
+func findSnapshot(snapshots []*hcloud.Image, id int) *hcloud.Image {
+	for _, snapshot := range snapshots {
+		if snapshot.ID == id {
+			return snapshot
+		}
+	}
+	return nil
+}
+
+// buildUserData returns the user data of the build server. It runs the script and powers the server off if the
+// script succeeded. cloud-init is reset, so that it runs again on servers that are created from the snapshot.
+func buildUserData(script string) string {
+	return fmt.Sprintf(`#!/bin/bash
+set -o errexit -o pipefail
+echo %s | base64 -d > /root/caph-provision.sh
+chmod 700 /root/caph-provision.sh
+/root/caph-provision.sh
+rm -f /root/caph-provision.sh
+cloud-init clean --logs
+poweroff
+`, base64.StdEncoding.EncodeToString([]byte(script)))
+}
@@ -0,0 +1,263 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machineimage
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	hcloudclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client"
+	fakeclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/cluster-api/util/conditions"
+)
+
+// snapshotClient keeps the snapshots that are created from servers.
+type snapshotClient struct {
+	hcloudclient.Client
+	snapshots map[int]*hcloud.Image
+	nextID    int
+}
+
+func (c *snapshotClient) CreateImage(_ context.Context, _ *hcloud.Server, opts hcloud.ServerCreateImageOpts) (hcloud.ServerCreateImageResult, error) {
+	c.nextID++
+	image := &hcloud.Image{
+		ID:          c.nextID,
+		Type:        opts.Type,
+		Description: *opts.Description,
+		Labels:      opts.Labels,
+		Status:      hcloud.ImageStatusCreating,
+		Created:     time.Now().Add(time.Duration(c.nextID) * time.Second),
+	}
+	c.snapshots[image.ID] = image
+	return hcloud.ServerCreateImageResult{Image: image, Action: &hcloud.Action{}}, nil
+}
+
+func (c *snapshotClient) ListImages(_ context.Context, opts hcloud.ImageListOpts) ([]*hcloud.Image, error) {
+	key, value, _ := strings.Cut(opts.LabelSelector, "==")
+	var images []*hcloud.Image
+	for _, image := range c.snapshots {
+		if image.Labels[key] == value {
+			images = append(images, image)
+		}
+	}
+	return images, nil
+}
+
+func (c *snapshotClient) DeleteImage(_ context.Context, image *hcloud.Image) error {
+	delete(c.snapshots, image.ID)
+	return nil
+}
+
+var _ = Describe("Reconcile", func() {
+	var (
+		machineImage *infrav1.HCloudMachineImage
+		client       *snapshotClient
+		service      *Service
+	)
+	fake := fakeclient.NewHCloudClientFactory().NewClient("")
+
+	BeforeEach(func() {
+		fake.Close()
+		client = &snapshotClient{Client: fake, snapshots: make(map[int]*hcloud.Image)}
+		machineImage = &infrav1.HCloudMachineImage{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "node-image",
+				Namespace:  "default",
+				UID:        "6a3e5e5c-2b5f-4d1c-9a43-2f0c1d1e6f10",
+				Generation: 1,
+			},
+			Spec: infrav1.HCloudMachineImageSpec{
+				HetznerClusterRef: "hetzner-cluster",
+				BaseImage:         "ubuntu-22.04",
+				ServerType:        "cpx11",
+				Location:          "fsn1",
+				Script:            "apt-get update",
+				Labels:            map[string]string{"kubernetes-version": "v1.25.5"},
+			},
+		}
+		service = newTestService(machineImage, client)
+	})
+
+	// runBuild reconciles until the build of the snapshot has finished.
+	runBuild := func() *hcloud.Image {
+		_, err := service.Reconcile(context.Background())
+		Expect(err).To(Succeed())
+		Expect(machineImage.Status.Build).ToNot(BeNil())
+
+		_, err = fake.ShutdownServer(context.Background(), &hcloud.Server{ID: machineImage.Status.Build.ServerID})
+		Expect(err).To(Succeed())
+		_, err = service.Reconcile(context.Background())
+		Expect(err).To(Succeed())
+
+		snapshot := client.snapshots[machineImage.Status.Build.SnapshotID]
+		Expect(snapshot).ToNot(BeNil())
+		snapshot.Status = hcloud.ImageStatusAvailable
+		_, err = service.Reconcile(context.Background())
+		Expect(err).To(Succeed())
+		Expect(machineImage.Status.Build).To(BeNil())
+		return snapshot
+	}
+
+	It("builds a snapshot with a temporary server", func() {
+		res, err := service.Reconcile(context.Background())
+		Expect(err).To(Succeed())
+		Expect(res.RequeueAfter).To(Equal(buildPollInterval))
+		Expect(machineImage.Status.Phase).To(Equal(infrav1.MachineImagePhaseBuilding))
+		Expect(conditions.GetReason(machineImage, infrav1.MachineImageReadyCondition)).To(Equal(infrav1.MachineImageBuildingReason))
+
+		servers, err := fake.ListServers(context.Background(), hcloud.ServerListOpts{})
+		Expect(err).To(Succeed())
+		Expect(servers).To(HaveLen(1))
+		Expect(servers[0].ID).To(Equal(machineImage.Status.Build.ServerID))
+		Expect(servers[0].Labels).To(HaveKeyWithValue(infrav1.MachineImageNameLabel, "node-image"))
+
+		By("waiting for the server to power off")
+		res, err = service.Reconcile(context.Background())
+		Expect(err).To(Succeed())
+		Expect(res.RequeueAfter).To(Equal(buildPollInterval))
+		Expect(machineImage.Status.Build.SnapshotID).To(BeZero())
+
+		By("taking the snapshot of the powered off server")
+		_, err = fake.ShutdownServer(context.Background(), servers[0])
+		Expect(err).To(Succeed())
+		_, err = service.Reconcile(context.Background())
+		Expect(err).To(Succeed())
+		Expect(machineImage.Status.Phase).To(Equal(infrav1.MachineImagePhaseSnapshotting))
+		snapshot := client.snapshots[machineImage.Status.Build.SnapshotID]
+		Expect(snapshot).ToNot(BeNil())
+		Expect(snapshot.Description).To(Equal("node-image"))
+		Expect(snapshot.Labels).To(HaveKeyWithValue("kubernetes-version", "v1.25.5"))
+		Expect(snapshot.Labels).To(HaveKeyWithValue(infrav1.MachineImageNameLabel, "node-image"))
+
+		By("deleting the server after the snapshot is available")
+		snapshot.Status = hcloud.ImageStatusAvailable
+		res, err = service.Reconcile(context.Background())
+		Expect(err).To(Succeed())
+		Expect(res).To(BeNil())
+		Expect(machineImage.Status.Ready).To(BeTrue())
+		Expect(machineImage.Status.Phase).To(Equal(infrav1.MachineImagePhaseReady))
+		Expect(machineImage.Status.SnapshotID).To(Equal(snapshot.ID))
+		Expect(machineImage.Status.ObservedGeneration).To(Equal(int64(1)))
+		Expect(machineImage.Status.Build).To(BeNil())
+		Expect(conditions.IsTrue(machineImage, infrav1.MachineImageReadyCondition)).To(BeTrue())
+
+		servers, err = fake.ListServers(context.Background(), hcloud.ServerListOpts{})
+		Expect(err).To(Succeed())
+		Expect(servers).To(BeEmpty())
+
+		By("not building again without changes")
+		res, err = service.Reconcile(context.Background())
+		Expect(err).To(Succeed())
+		Expect(res).To(BeNil())
+		Expect(machineImage.Status.Build).To(BeNil())
+	})
+
+	It("rebuilds after the spec changed", func() {
+		first := runBuild()
+
+		machineImage.Generation = 2
+		second := runBuild()
+		Expect(second.ID).ToNot(Equal(first.ID))
+		Expect(machineImage.Status.SnapshotID).To(Equal(second.ID))
+		Expect(machineImage.Status.ObservedGeneration).To(Equal(int64(2)))
+		Expect(client.snapshots).To(HaveLen(2))
+	})
+
+	It("waits for the rebuild interval", func() {
+		machineImage.Spec.RebuildInterval = &metav1.Duration{Duration: 24 * time.Hour}
+		runBuild()
+
+		res, err := service.Reconcile(context.Background())
+		Expect(err).To(Succeed())
+		Expect(res.RequeueAfter).To(BeNumerically("~", 24*time.Hour, time.Minute))
+		Expect(machineImage.Status.Build).To(BeNil())
+
+		machineImage.Status.LastBuildTime = &metav1.Time{Time: time.Now().Add(-25 * time.Hour)}
+		_, err = service.Reconcile(context.Background())
+		Expect(err).To(Succeed())
+		Expect(machineImage.Status.Build).ToNot(BeNil())
+	})
+
+	It("deletes snapshots that exceed snapshotsToKeep", func() {
+		machineImage.Spec.SnapshotsToKeep = pointer.Int(2)
+		first := runBuild()
+		for generation := int64(2); generation <= 3; generation++ {
+			machineImage.Generation = generation
+			runBuild()
+		}
+
+		Expect(client.snapshots).To(HaveLen(2))
+		Expect(client.snapshots).ToNot(HaveKey(first.ID))
+		Expect(client.snapshots).To(HaveKey(machineImage.Status.SnapshotID))
+	})
+
+	It("fails the build if the server does not power off in time", func() {
+		machineImage.Spec.Timeout = &metav1.Duration{Duration: time.Minute}
+		_, err := service.Reconcile(context.Background())
+		Expect(err).To(Succeed())
+		machineImage.Status.Build.StartTime = metav1.NewTime(time.Now().Add(-2 * time.Minute))
+
+		res, err := service.Reconcile(context.Background())
+		Expect(err).To(Succeed())
+		Expect(res).To(BeNil())
+		Expect(machineImage.Status.Ready).To(BeFalse())
+		Expect(machineImage.Status.Phase).To(Equal(infrav1.MachineImagePhaseFailed))
+		Expect(machineImage.Status.Build).To(BeNil())
+		Expect(conditions.GetReason(machineImage, infrav1.MachineImageReadyCondition)).To(Equal(infrav1.MachineImageBuildFailedReason))
+
+		servers, err := fake.ListServers(context.Background(), hcloud.ServerListOpts{})
+		Expect(err).To(Succeed())
+		Expect(servers).To(BeEmpty())
+
+		By("not retrying until the spec changes")
+		res, err = service.Reconcile(context.Background())
+		Expect(err).To(Succeed())
+		Expect(res).To(BeNil())
+		Expect(machineImage.Status.Build).To(BeNil())
+	})
+
+	It("deletes the server of a running build", func() {
+		_, err := service.Reconcile(context.Background())
+		Expect(err).To(Succeed())
+
+		_, err = service.Delete(context.Background())
+		Expect(err).To(Succeed())
+		Expect(machineImage.Status.Build).To(BeNil())
+
+		servers, err := fake.ListServers(context.Background(), hcloud.ServerListOpts{})
+		Expect(err).To(Succeed())
+		Expect(servers).To(BeEmpty())
+	})
+})
+
+var _ = Describe("buildUserData", func() {
+	It("runs the script and powers off", func() {
+		script := "#!/bin/sh\necho 'hello'\n"
+		userData := buildUserData(script)
+		Expect(userData).To(HavePrefix("#!/bin/bash\n"))
+		Expect(userData).To(ContainSubstring("echo " + base64.StdEncoding.EncodeToString([]byte(script)) + " | base64 -d"))
+		Expect(userData).To(HaveSuffix("cloud-init clean --logs\npoweroff\n"))
+	})
+})
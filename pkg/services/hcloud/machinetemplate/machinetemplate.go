@@ -20,17 +20,22 @@ package machinetemplate
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/hetznercloud/hcloud-go/hcloud"
 	"github.com/pkg/errors"
 	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
 	"github.com/syself/cluster-api-provider-hetzner/pkg/scope"
 	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
+// serverTypeCheckInterval is the interval in which templates are reconciled to detect deprecations of their server type.
+const serverTypeCheckInterval = 6 * time.Hour
+
 // Service defines struct with HCloudMachineTemplate scope to reconcile HCloud machine templates.
 type Service struct {
 	scope *scope.HCloudMachineTemplateScope
@@ -51,7 +56,52 @@ func (s *Service) Reconcile(ctx context.Context) (_ *ctrl.Result, err error) {
 	}
 
 	s.scope.HCloudMachineTemplate.Status.Capacity = capacity
-	return nil, nil
+
+	if err := s.reconcileServerTypeDeprecation(ctx); err != nil {
+		return nil, errors.Wrap(err, "failed to reconcile deprecation of server type")
+	}
+	return &ctrl.Result{RequeueAfter: serverTypeCheckInterval}, nil
+}
+
+// reconcileServerTypeDeprecation sets the condition ServerTypeAvailable depending on whether HCloud has
+// deprecated the server type of the template.
+func (s *Service) reconcileServerTypeDeprecation(ctx context.Context) error {
+	deprecations, err := s.scope.HCloudClient.ListServerTypeDeprecations(ctx)
+	if err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HCloudMachineTemplate, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HCloudMachineTemplate,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function ListServerTypeDeprecations",
+			)
+		}
+		return errors.Wrap(err, "failed to list server type deprecations")
+	}
+
+	serverType := s.scope.HCloudMachineTemplate.Spec.Template.Spec.Type
+	deprecation, deprecated := deprecations[string(serverType)]
+	if !deprecated {
+		conditions.MarkTrue(s.scope.HCloudMachineTemplate, infrav1.ServerTypeAvailableCondition)
+		return nil
+	}
+
+	msg := fmt.Sprintf("server type %s is deprecated and unavailable after %s", serverType, deprecation.UnavailableAfter.Format(time.RFC3339))
+	if successor := s.scope.HetznerCluster.Spec.HCloudServerTypeSuccessors[string(serverType)]; successor != "" {
+		msg += fmt.Sprintf(", new servers use the successor %s", successor)
+	} else {
+		msg += ", change the type or set a successor in hcloudServerTypeSuccessors of the HetznerCluster"
+	}
+
+	if !conditions.IsFalse(s.scope.HCloudMachineTemplate, infrav1.ServerTypeAvailableCondition) ||
+		conditions.GetMessage(s.scope.HCloudMachineTemplate, infrav1.ServerTypeAvailableCondition) != msg {
+		record.Warnf(s.scope.HCloudMachineTemplate, "ServerTypeDeprecated", "Server type is deprecated: %s", msg)
+	}
+	conditions.MarkFalse(s.scope.HCloudMachineTemplate,
+		infrav1.ServerTypeAvailableCondition,
+		infrav1.ServerTypeDeprecatedReason,
+		clusterv1.ConditionSeverityWarning,
+		msg)
+	return nil
 }
 
 func (s *Service) getCapacity(ctx context.Context) (corev1.ResourceList, error) {
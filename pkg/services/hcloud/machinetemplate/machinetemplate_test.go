@@ -0,0 +1,101 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machinetemplate
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	hcloudclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client"
+	fakeclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client/fake"
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+)
+
+type deprecationsClient struct {
+	hcloudclient.Client
+	deprecations map[string]hcloudclient.ServerTypeDeprecation
+}
+
+func (c *deprecationsClient) ListServerTypeDeprecations(context.Context) (map[string]hcloudclient.ServerTypeDeprecation, error) {
+	return c.deprecations, nil
+}
+
+var _ = Describe("reconcileServerTypeDeprecation", func() {
+	var (
+		service         *Service
+		machineTemplate *infrav1.HCloudMachineTemplate
+	)
+
+	BeforeEach(func() {
+		client := &deprecationsClient{
+			Client: fakeclient.NewHCloudClientFactory().NewClient(""),
+			deprecations: map[string]hcloudclient.ServerTypeDeprecation{
+				"cx21": {
+					Announced:        time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+					UnavailableAfter: time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC),
+				},
+			},
+		}
+		machineTemplate = &infrav1.HCloudMachineTemplate{}
+		service = newTestService(machineTemplate, client)
+	})
+
+	It("marks the server type as available if it is not deprecated", func() {
+		machineTemplate.Spec.Template.Spec.Type = "cpx31"
+
+		Expect(service.reconcileServerTypeDeprecation(context.Background())).To(Succeed())
+		Expect(conditions.IsTrue(machineTemplate, infrav1.ServerTypeAvailableCondition)).To(BeTrue())
+	})
+
+	It("marks a deprecated server type without successor as unavailable", func() {
+		machineTemplate.Spec.Template.Spec.Type = "cx21"
+
+		Expect(service.reconcileServerTypeDeprecation(context.Background())).To(Succeed())
+		condition := conditions.Get(machineTemplate, infrav1.ServerTypeAvailableCondition)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(infrav1.ServerTypeDeprecatedReason))
+		Expect(condition.Severity).To(Equal(clusterv1.ConditionSeverityWarning))
+		Expect(condition.Message).To(Equal("server type cx21 is deprecated and unavailable after 2023-04-01T00:00:00Z, " +
+			"change the type or set a successor in hcloudServerTypeSuccessors of the HetznerCluster"))
+	})
+
+	It("names the successor of a deprecated server type", func() {
+		machineTemplate.Spec.Template.Spec.Type = "cx21"
+		service.scope.HetznerCluster.Spec.HCloudServerTypeSuccessors = map[string]infrav1.HCloudMachineType{"cx21": "cx22"}
+
+		Expect(service.reconcileServerTypeDeprecation(context.Background())).To(Succeed())
+		Expect(conditions.IsFalse(machineTemplate, infrav1.ServerTypeAvailableCondition)).To(BeTrue())
+		Expect(conditions.GetMessage(machineTemplate, infrav1.ServerTypeAvailableCondition)).To(
+			Equal("server type cx21 is deprecated and unavailable after 2023-04-01T00:00:00Z, new servers use the successor cx22"))
+	})
+
+	It("marks the server type as available again once it has been changed", func() {
+		machineTemplate.Spec.Template.Spec.Type = "cx21"
+		Expect(service.reconcileServerTypeDeprecation(context.Background())).To(Succeed())
+		Expect(conditions.IsFalse(machineTemplate, infrav1.ServerTypeAvailableCondition)).To(BeTrue())
+
+		machineTemplate.Spec.Template.Spec.Type = "cx22"
+		Expect(service.reconcileServerTypeDeprecation(context.Background())).To(Succeed())
+		Expect(conditions.IsTrue(machineTemplate, infrav1.ServerTypeAvailableCondition)).To(BeTrue())
+	})
+})
@@ -26,6 +26,7 @@ import (
 	"github.com/pkg/errors"
 	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
 	"github.com/syself/cluster-api-provider-hetzner/pkg/scope"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/quota"
 	"github.com/syself/cluster-api-provider-hetzner/pkg/utils"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util/conditions"
@@ -55,6 +56,19 @@ func (s *Service) Reconcile(ctx context.Context) (err error) {
 	log := ctrl.LoggerFrom(ctx)
 	log.V(1).Info("Reconciling network", "spec", s.scope.HetznerCluster.Spec.HCloudNetwork)
 
+	if ref := s.scope.HetznerCluster.Spec.HCloudNetwork.ExistingNetwork; ref != nil {
+		network, err := s.getExistingNetwork(ctx, ref)
+		if err != nil {
+			return errors.Wrap(err, "failed to get existing network")
+		}
+		conditions.MarkTrue(s.scope.HetznerCluster, infrav1.NetworkAttached)
+		s.scope.HetznerCluster.Status.Network = apiToStatus(network)
+		if err := s.reconcileSubnetIPs(ctx, network); err != nil {
+			return errors.Wrap(err, "failed to reconcile IPs of subnet")
+		}
+		return nil
+	}
+
 	network, err := s.findNetwork(ctx)
 	if err != nil {
 		return errors.Wrap(err, "failed to find network")
@@ -66,20 +80,328 @@ func (s *Service) Reconcile(ctx context.Context) (err error) {
 		}
 	}
 
+	if err := s.reconcileSubnets(ctx, network); err != nil {
+		return errors.Wrap(err, "failed to reconcile subnets")
+	}
+
+	if err := s.reconcileNATGatewayRoute(ctx, network); err != nil {
+		return errors.Wrap(err, "failed to reconcile route of NAT gateway")
+	}
+
+	routes, err := s.reconcileRoutes(ctx, network)
+	if err != nil {
+		return errors.Wrap(err, "failed to reconcile routes")
+	}
+
 	conditions.MarkTrue(s.scope.HetznerCluster, infrav1.NetworkAttached)
 	s.scope.HetznerCluster.Status.Network = apiToStatus(network)
+	s.scope.HetznerCluster.Status.Network.Routes = routes
+	if err := s.reconcileSubnetIPs(ctx, network); err != nil {
+		return errors.Wrap(err, "failed to reconcile IPs of subnet")
+	}
+	return nil
+}
+
+// reconcileSubnets adds the subnets of the spec that the network does not have yet.
+func (s *Service) reconcileSubnets(ctx context.Context, network *hcloud.Network) error {
+	spec := s.scope.HetznerCluster.Spec.HCloudNetwork
+	for _, subnetSpec := range spec.Subnets {
+		if Subnet(network, subnetSpec.CIDRBlock) != nil {
+			continue
+		}
+		_, ipRange, err := net.ParseCIDR(subnetSpec.CIDRBlock)
+		if err != nil {
+			return errors.Wrapf(err, "invalid subnet %q", subnetSpec.CIDRBlock)
+		}
+		subnet := hcloud.NetworkSubnet{
+			IPRange:     ipRange,
+			NetworkZone: hcloud.NetworkZone(spec.NetworkZone),
+			Type:        hcloud.NetworkSubnetTypeServer,
+		}
+		if _, err := s.scope.HCloudClient.AddSubnetToNetwork(ctx, network, hcloud.NetworkAddSubnetOpts{Subnet: subnet}); err != nil {
+			if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+				conditions.MarkTrue(s.scope.HetznerCluster, infrav1.RateLimitExceeded)
+				record.Event(s.scope.HetznerCluster,
+					"RateLimitExceeded",
+					"exceeded rate limit with calling hcloud function AddSubnetToNetwork",
+				)
+			}
+			record.Warnf(s.scope.HetznerCluster, "NetworkSubnetAddFailed", "Failed to add subnet %s %s: %s", subnetSpec.Name, ipRange, err)
+			return errors.Wrapf(err, "failed to add subnet %s", ipRange)
+		}
+		record.Eventf(s.scope.HetznerCluster, "NetworkSubnetAdded", "Added subnet %s %s", subnetSpec.Name, ipRange)
+		network.Subnets = append(network.Subnets, subnet)
+	}
+	return nil
+}
+
+// reconcileNATGatewayRoute makes sure that the default route of the network goes through the NAT gateway. A default
+// route via another gateway is replaced, as a network can only have one route per destination. Without NAT gateway,
+// the routes of the network are left as they are, so that a default route can also be managed outside of the cluster.
+func (s *Service) reconcileNATGatewayRoute(ctx context.Context, network *hcloud.Network) error {
+	natGateway := s.scope.HetznerCluster.Spec.HCloudNetwork.NATGateway
+	if natGateway == nil {
+		return nil
+	}
+	gateway := net.ParseIP(natGateway.IP)
+	if gateway == nil {
+		return fmt.Errorf("invalid IP %q of NAT gateway", natGateway.IP)
+	}
+
+	var current *hcloud.NetworkRoute
+	routes := make([]hcloud.NetworkRoute, 0, len(network.Routes))
+	for i, route := range network.Routes {
+		if !isDefaultRoute(route) {
+			routes = append(routes, route)
+			continue
+		}
+		if route.Gateway.Equal(gateway) {
+			return nil
+		}
+		current = &network.Routes[i]
+	}
+
+	// A network cannot have two routes to the same destination, so the route via the old gateway is deleted right
+	// before the new one is added. It is added again if the new route cannot be added, so that the servers do not
+	// lose their default route.
+	route := hcloud.NetworkRoute{Destination: defaultRouteDestination(), Gateway: gateway}
+	if current != nil {
+		if err := s.deleteRoute(ctx, network, *current); err != nil {
+			return err
+		}
+	}
+	if err := s.addRoute(ctx, network, route); err != nil {
+		if current != nil {
+			if restoreErr := s.addRoute(ctx, network, *current); restoreErr != nil {
+				network.Routes = routes
+				return errors.Wrapf(err, "failed to replace default route and to restore it: %s", restoreErr)
+			}
+			routes = append(routes, *current)
+		}
+		network.Routes = routes
+		return err
+	}
+	record.Eventf(s.scope.HetznerCluster, "NetworkRouteAdded", "Added route %s via NAT gateway %s", route.Destination, route.Gateway)
+	network.Routes = append(routes, route)
+	return nil
+}
+
+// reconcileRoutes makes sure that the network has the routes of the spec and deletes the routes that have been
+// added before and are no longer in the spec. A route to a destination of the spec via another gateway is replaced.
+// It returns the routes of the spec, which are recorded in the status.
+func (s *Service) reconcileRoutes(ctx context.Context, network *hcloud.Network) ([]infrav1.HCloudNetworkRouteSpec, error) {
+	specRoutes := s.scope.HetznerCluster.Spec.HCloudNetwork.Routes
+	var managedRoutes []infrav1.HCloudNetworkRouteSpec
+	if status := s.scope.HetznerCluster.Status.Network; status != nil {
+		managedRoutes = status.Routes
+	}
+
+	wanted := make(map[string]net.IP, len(specRoutes))
+	for _, route := range specRoutes {
+		_, destination, err := net.ParseCIDR(route.Destination)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid destination %q of route", route.Destination)
+		}
+		gateway := net.ParseIP(route.Gateway)
+		if gateway == nil {
+			return nil, fmt.Errorf("invalid gateway %q of route", route.Gateway)
+		}
+		wanted[destination.String()] = gateway
+	}
+	managed := make(map[string]string, len(managedRoutes))
+	for _, route := range managedRoutes {
+		if _, destination, err := net.ParseCIDR(route.Destination); err == nil {
+			managed[destination.String()] = route.Gateway
+		}
+	}
+
+	existing := make(map[string]struct{}, len(network.Routes))
+	routes := make([]hcloud.NetworkRoute, 0, len(network.Routes))
+	for _, route := range network.Routes {
+		if route.Destination == nil {
+			routes = append(routes, route)
+			continue
+		}
+		destination := route.Destination.String()
+		gateway, isWanted := wanted[destination]
+		managedGateway, isManaged := managed[destination]
+		switch {
+		case isWanted && route.Gateway.Equal(gateway):
+			existing[destination] = struct{}{}
+			routes = append(routes, route)
+			continue
+		case !isWanted && (!isManaged || !route.Gateway.Equal(net.ParseIP(managedGateway))):
+			// The route has not been added by the controller
+			routes = append(routes, route)
+			continue
+		}
+		if err := s.deleteRoute(ctx, network, route); err != nil {
+			return nil, err
+		}
+	}
+	network.Routes = routes
+
+	for _, specRoute := range specRoutes {
+		_, destination, _ := net.ParseCIDR(specRoute.Destination)
+		if _, found := existing[destination.String()]; found {
+			continue
+		}
+		route := hcloud.NetworkRoute{Destination: destination, Gateway: wanted[destination.String()]}
+		if err := s.addRoute(ctx, network, route); err != nil {
+			return nil, err
+		}
+		record.Eventf(s.scope.HetznerCluster, "NetworkRouteAdded", "Added route %s via %s", route.Destination, route.Gateway)
+		network.Routes = append(network.Routes, route)
+	}
+	return specRoutes, nil
+}
+
+func (s *Service) addRoute(ctx context.Context, network *hcloud.Network, route hcloud.NetworkRoute) error {
+	if _, err := s.scope.HCloudClient.AddRouteToNetwork(ctx, network, hcloud.NetworkAddRouteOpts{Route: route}); err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HetznerCluster, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HetznerCluster,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function AddRouteToNetwork",
+			)
+		}
+		return errors.Wrapf(err, "failed to add route %s via %s", route.Destination, route.Gateway)
+	}
+	return nil
+}
+
+func (s *Service) deleteRoute(ctx context.Context, network *hcloud.Network, route hcloud.NetworkRoute) error {
+	if _, err := s.scope.HCloudClient.DeleteRouteFromNetwork(ctx, network, hcloud.NetworkDeleteRouteOpts{Route: route}); err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HetznerCluster, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HetznerCluster,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function DeleteRouteFromNetwork",
+			)
+		}
+		// The route has been deleted already
+		if hcloud.IsError(err, hcloud.ErrorCodeNotFound) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to delete route %s via %s", route.Destination, route.Gateway)
+	}
+	record.Eventf(s.scope.HetznerCluster, "NetworkRouteDeleted", "Deleted route %s via %s", route.Destination, route.Gateway)
 	return nil
 }
 
+func defaultRouteDestination() *net.IPNet {
+	return &net.IPNet{IP: net.IPv4zero.To4(), Mask: net.CIDRMask(0, 32)}
+}
+
+func isDefaultRoute(route hcloud.NetworkRoute) bool {
+	if route.Destination == nil || route.Destination.IP.To4() == nil {
+		return false
+	}
+	ones, _ := route.Destination.Mask.Size()
+	return ones == 0
+}
+
+// reconcileSubnetIPs sets the condition SubnetIPsAvailable from the number of servers in the default subnet and
+// the load balancer, so that an exhausted default subnet is visible before servers fail to attach to the network.
+// The servers are only listed to find their private IPs if the number of servers in the whole network exceeds the
+// capacity of the subnet.
+func (s *Service) reconcileSubnetIPs(ctx context.Context, network *hcloud.Network) error {
+	serverSubnet := DefaultSubnet(network, s.scope.HetznerCluster.Spec.HCloudNetwork)
+	if serverSubnet == nil {
+		return nil
+	}
+	subnet := serverSubnet.IPRange
+
+	var loadBalancers int
+	if s.scope.HetznerCluster.Spec.ControlPlaneLoadBalancer.Enabled {
+		loadBalancers = 1
+	}
+	capacity := subnetCapacity(network.IPRange, subnet)
+
+	servers := len(network.Servers)
+	if servers+loadBalancers >= capacity {
+		var err error
+		servers, err = s.serversInSubnet(ctx, network, subnet)
+		if err != nil {
+			return err
+		}
+	}
+	used := servers + loadBalancers
+
+	if used < capacity {
+		conditions.MarkTrue(s.scope.HetznerCluster, infrav1.SubnetIPsAvailableCondition)
+		return nil
+	}
+
+	if !conditions.IsFalse(s.scope.HetznerCluster, infrav1.SubnetIPsAvailableCondition) {
+		record.Warnf(s.scope.HetznerCluster, "NetworkSubnetExhausted", "All %d IPs of subnet %s are used", capacity, subnet)
+	}
+	conditions.MarkFalse(
+		s.scope.HetznerCluster,
+		infrav1.SubnetIPsAvailableCondition,
+		infrav1.SubnetExhaustedReason,
+		clusterv1.ConditionSeverityError,
+		"all %d IPs of subnet %s are used",
+		capacity, subnet,
+	)
+	return nil
+}
+
+// serversInSubnet returns the number of servers whose private IP in the network is in the subnet.
+func (s *Service) serversInSubnet(ctx context.Context, network *hcloud.Network, subnet *net.IPNet) (int, error) {
+	servers, err := s.scope.HCloudClient.ListServers(ctx, hcloud.ServerListOpts{})
+	if err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HetznerCluster, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HetznerCluster,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function ListServers",
+			)
+		}
+		return 0, errors.Wrap(err, "failed to list servers")
+	}
+
+	var count int
+	for _, server := range servers {
+		for _, privateNet := range server.PrivateNet {
+			if privateNet.Network != nil && privateNet.Network.ID == network.ID && subnet.Contains(privateNet.IP) {
+				count++
+			}
+		}
+	}
+	return count, nil
+}
+
+// subnetCapacity returns the number of IPv4 addresses of the subnet that can be assigned, i.e. without the
+// network and broadcast addresses and the gateway, which is the first IP of the network.
+func subnetCapacity(networkRange, subnet *net.IPNet) int {
+	ones, bits := subnet.Mask.Size()
+	if bits != 32 || bits-ones < 2 {
+		return 0
+	}
+	capacity := (1 << (bits - ones)) - 2
+
+	if networkRange != nil {
+		gateway := networkRange.IP.Mask(networkRange.Mask).To4()
+		if gateway != nil {
+			gateway = net.IPv4(gateway[0], gateway[1], gateway[2], gateway[3]+1)
+			if subnet.Contains(gateway) {
+				capacity--
+			}
+		}
+	}
+	return capacity
+}
+
 func (s *Service) createNetwork(ctx context.Context, spec *infrav1.HCloudNetworkSpec) (*hcloud.Network, error) {
 	_, network, err := net.ParseCIDR(spec.CIDRBlock)
 	if err != nil {
 		return nil, errors.Wrapf(err, "invalid network '%s'", spec.CIDRBlock)
 	}
 
-	_, subnet, err := net.ParseCIDR(s.scope.HetznerCluster.Spec.HCloudNetwork.CIDRBlock)
+	_, subnet, err := net.ParseCIDR(spec.SubnetCIDRBlock)
 	if err != nil {
-		return nil, errors.Wrapf(err, "invalid network '%s'", s.scope.HetznerCluster.Spec.HCloudNetwork.CIDRBlock)
+		return nil, errors.Wrapf(err, "invalid subnet '%s'", spec.SubnetCIDRBlock)
 	}
 
 	opts := hcloud.NetworkCreateOpts{
@@ -95,8 +417,13 @@ func (s *Service) createNetwork(ctx context.Context, spec *infrav1.HCloudNetwork
 		},
 	}
 
+	if err := quota.NewService(s.scope).Check(ctx, s.scope.HetznerCluster, quota.Networks); err != nil {
+		return nil, err
+	}
+
 	resp, err := s.scope.HCloudClient.CreateNetwork(ctx, opts)
 	if err != nil {
+		quota.RecordLimitError(s.scope.HetznerCluster, quota.Networks, err)
 		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
 			conditions.MarkTrue(s.scope.HetznerCluster, infrav1.RateLimitExceeded)
 			record.Event(s.scope.HetznerCluster,
@@ -112,6 +439,7 @@ func (s *Service) createNetwork(ctx context.Context, spec *infrav1.HCloudNetwork
 		return nil, errors.Wrap(err, "error creating network")
 	}
 
+	conditions.Delete(s.scope.HetznerCluster, infrav1.QuotaExceeded)
 	record.Eventf(
 		s.scope.HetznerCluster,
 		"NetworkCreated",
@@ -121,12 +449,50 @@ func (s *Service) createNetwork(ctx context.Context, spec *infrav1.HCloudNetwork
 	return resp, nil
 }
 
+// DeleteSubnets deletes the additional subnets of the network. The default subnet is deleted with the network.
+func (s *Service) DeleteSubnets(ctx context.Context) error {
+	if s.scope.HetznerCluster.Status.Network == nil || s.scope.HetznerCluster.Spec.HCloudNetwork.ExistingNetwork != nil {
+		// Subnets are only added to networks that are owned by the cluster
+		return nil
+	}
+	network := &hcloud.Network{ID: s.scope.HetznerCluster.Status.Network.ID}
+	for _, subnetSpec := range s.scope.HetznerCluster.Spec.HCloudNetwork.Subnets {
+		_, ipRange, err := net.ParseCIDR(subnetSpec.CIDRBlock)
+		if err != nil {
+			return errors.Wrapf(err, "invalid subnet %q", subnetSpec.CIDRBlock)
+		}
+		if _, err := s.scope.HCloudClient.DeleteSubnetFromNetwork(ctx, network, hcloud.NetworkDeleteSubnetOpts{
+			Subnet: hcloud.NetworkSubnet{IPRange: ipRange},
+		}); err != nil {
+			if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+				conditions.MarkTrue(s.scope.HetznerCluster, infrav1.RateLimitExceeded)
+				record.Event(s.scope.HetznerCluster,
+					"RateLimitExceeded",
+					"exceeded rate limit with calling hcloud function DeleteSubnetFromNetwork",
+				)
+			}
+			if hcloud.IsError(err, hcloud.ErrorCodeNotFound) {
+				continue
+			}
+			record.Warnf(s.scope.HetznerCluster, "NetworkSubnetDeleteFailed", "Failed to delete subnet %s %s: %s", subnetSpec.Name, ipRange, err)
+			return errors.Wrapf(err, "failed to delete subnet %s", ipRange)
+		}
+		record.Eventf(s.scope.HetznerCluster, "NetworkSubnetDeleted", "Deleted subnet %s %s", subnetSpec.Name, ipRange)
+	}
+	return nil
+}
+
 // Delete implements deletion of the network.
 func (s *Service) Delete(ctx context.Context) error {
 	if s.scope.HetznerCluster.Status.Network == nil {
 		// Nothing to delete
 		return nil
 	}
+	if s.scope.HetznerCluster.Spec.HCloudNetwork.ExistingNetwork != nil {
+		// Existing networks are shared and are never deleted
+		s.scope.V(1).Info("Keep existing network", "id", s.scope.HetznerCluster.Status.Network.ID)
+		return nil
+	}
 	if err := s.scope.HCloudClient.DeleteNetwork(ctx, &hcloud.Network{ID: s.scope.HetznerCluster.Status.Network.ID}); err != nil {
 		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
 			conditions.MarkTrue(s.scope.HetznerCluster, infrav1.RateLimitExceeded)
@@ -157,6 +523,95 @@ func (s *Service) Delete(ctx context.Context) error {
 	return nil
 }
 
+// getExistingNetwork returns the referenced network, which has to have the subnet that the reference selects or a
+// subnet in the network zone of the cluster.
+func (s *Service) getExistingNetwork(ctx context.Context, ref *infrav1.HCloudNetworkRef) (*hcloud.Network, error) {
+	network, err := s.scope.HCloudClient.GetNetwork(ctx, ref.IDOrName())
+	if err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HetznerCluster, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HetznerCluster,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function GetNetwork",
+			)
+		}
+		return nil, errors.Wrapf(err, "failed to get network %s", ref.IDOrName())
+	}
+	if network == nil {
+		return nil, s.markExistingNetworkInvalid(infrav1.NetworkNotFoundReason, "network %s not found", ref.IDOrName())
+	}
+
+	if ref.SubnetCIDRBlock != "" {
+		if Subnet(network, ref.SubnetCIDRBlock) == nil {
+			return nil, s.markExistingNetworkInvalid(infrav1.NetworkSubnetNotFoundReason,
+				"network %s has no subnet %s", ref.IDOrName(), ref.SubnetCIDRBlock)
+		}
+	} else {
+		zone := hcloud.NetworkZone(s.scope.HetznerCluster.Spec.HCloudNetwork.NetworkZone)
+		if ServerSubnet(network, zone) == nil {
+			return nil, s.markExistingNetworkInvalid(infrav1.NetworkSubnetNotFoundReason,
+				"network %s has no subnet for servers in network zone %s", ref.IDOrName(), zone)
+		}
+	}
+	for _, subnetSpec := range s.scope.HetznerCluster.Spec.HCloudNetwork.Subnets {
+		if Subnet(network, subnetSpec.CIDRBlock) == nil {
+			return nil, s.markExistingNetworkInvalid(infrav1.NetworkSubnetNotFoundReason,
+				"network %s has no subnet %s %s", ref.IDOrName(), subnetSpec.Name, subnetSpec.CIDRBlock)
+		}
+	}
+	return network, nil
+}
+
+func (s *Service) markExistingNetworkInvalid(reason, messageFormat string, args ...interface{}) error {
+	conditions.MarkFalse(s.scope.HetznerCluster, infrav1.NetworkAttached, reason, clusterv1.ConditionSeverityError, messageFormat, args...)
+	record.Warnf(s.scope.HetznerCluster, "ExistingNetworkInvalid", messageFormat, args...)
+	return fmt.Errorf(messageFormat, args...)
+}
+
+// Subnet returns the subnet of the network with the IP range cidrBlock, or nil if the network has no such subnet.
+func Subnet(network *hcloud.Network, cidrBlock string) *hcloud.NetworkSubnet {
+	_, ipRange, err := net.ParseCIDR(cidrBlock)
+	if err != nil {
+		return nil
+	}
+	for i := range network.Subnets {
+		subnet := &network.Subnets[i]
+		if subnet.IPRange != nil && subnet.IPRange.String() == ipRange.String() {
+			return subnet
+		}
+	}
+	return nil
+}
+
+// DefaultSubnet returns the subnet of servers that do not select a subnet. It is the subnet subnetCidrBlock of
+// networks that are created by the controller, if the network has it, the subnet that the reference of an existing
+// network selects, and the first subnet for servers in the network zone otherwise.
+func DefaultSubnet(network *hcloud.Network, spec infrav1.HCloudNetworkSpec) *hcloud.NetworkSubnet {
+	if spec.ExistingNetwork == nil {
+		if subnet := Subnet(network, spec.SubnetCIDRBlock); subnet != nil {
+			return subnet
+		}
+	} else if spec.ExistingNetwork.SubnetCIDRBlock != "" {
+		return Subnet(network, spec.ExistingNetwork.SubnetCIDRBlock)
+	}
+	return ServerSubnet(network, hcloud.NetworkZone(spec.NetworkZone))
+}
+
+// ServerSubnet returns the first subnet of the network in the network zone to which servers can be attached.
+// The subnets of networks that are created by the controller are always of type server.
+func ServerSubnet(network *hcloud.Network, zone hcloud.NetworkZone) *hcloud.NetworkSubnet {
+	for i := range network.Subnets {
+		subnet := &network.Subnets[i]
+		if subnet.NetworkZone != zone || subnet.IPRange == nil {
+			continue
+		}
+		if subnet.Type == hcloud.NetworkSubnetTypeServer || subnet.Type == hcloud.NetworkSubnetTypeCloud {
+			return subnet
+		}
+	}
+	return nil
+}
+
 func (s *Service) findNetwork(ctx context.Context) (*hcloud.Network, error) {
 	opts := hcloud.NetworkListOpts{}
 	opts.LabelSelector = utils.LabelsToLabelSelector(s.labels())
@@ -180,10 +635,6 @@ func (s *Service) findNetwork(ctx context.Context) (*hcloud.Network, error) {
 		return nil, nil
 	}
 
-	if len(networks[0].Subnets) > 1 {
-		return nil, fmt.Errorf("multiple subnets not allowed")
-	}
-
 	return networks[0], nil
 }
 
@@ -193,11 +644,20 @@ func apiToStatus(network *hcloud.Network) *infrav1.NetworkStatus {
 		attachedServerIDs = append(attachedServerIDs, s.ID)
 	}
 
-	return &infrav1.NetworkStatus{
+	status := &infrav1.NetworkStatus{
 		ID:              network.ID,
 		Labels:          network.Labels,
 		AttachedServers: attachedServerIDs,
 	}
+	if network.IPRange != nil {
+		status.IPRange = network.IPRange.String()
+	}
+	for _, route := range network.Routes {
+		if isDefaultRoute(route) {
+			status.DefaultRouteGateway = route.Gateway.String()
+		}
+	}
+	return status
 }
 
 func (s *Service) labels() map[string]string {
@@ -0,0 +1,283 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/scope"
+	hcloudclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client"
+	fakeclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+)
+
+// failingRouteClient fails to add routes via the gateway.
+type failingRouteClient struct {
+	hcloudclient.Client
+	gateway net.IP
+}
+
+func (c *failingRouteClient) AddRouteToNetwork(ctx context.Context, network *hcloud.Network, opts hcloud.NetworkAddRouteOpts) (*hcloud.Action, error) {
+	if opts.Route.Gateway.Equal(c.gateway) {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeInvalidInput, Message: "invalid gateway"}
+	}
+	return c.Client.AddRouteToNetwork(ctx, network, opts)
+}
+
+func newTestService(hcloudClient hcloudclient.Client) *Service {
+	return NewService(&scope.ClusterScope{
+		HCloudClient: hcloudClient,
+		HetznerCluster: &infrav1.HetznerCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default"},
+			Spec: infrav1.HetznerClusterSpec{
+				HCloudNetwork: infrav1.HCloudNetworkSpec{Enabled: true, CIDRBlock: "10.0.0.0/16"},
+			},
+		},
+	})
+}
+
+func newTestNetwork(ctx context.Context, hcloudClient hcloudclient.Client, name string, routes ...hcloud.NetworkRoute) *hcloud.Network {
+	_, ipRange, err := net.ParseCIDR("10.0.0.0/16")
+	Expect(err).To(Succeed())
+	network, err := hcloudClient.CreateNetwork(ctx, hcloud.NetworkCreateOpts{Name: name, IPRange: ipRange})
+	Expect(err).To(Succeed())
+	for _, route := range routes {
+		_, err := hcloudClient.AddRouteToNetwork(ctx, network, hcloud.NetworkAddRouteOpts{Route: route})
+		Expect(err).To(Succeed())
+	}
+	// The controller works on a network read from the API, which the fake client does not copy
+	read := *network
+	read.Routes = append([]hcloud.NetworkRoute(nil), network.Routes...)
+	return &read
+}
+
+// storedRoutes returns the routes of the network in the fake client.
+func storedRoutes(ctx context.Context, hcloudClient hcloudclient.Client, network *hcloud.Network) []string {
+	stored, err := hcloudClient.GetNetwork(ctx, network.Name)
+	Expect(err).To(Succeed())
+	return routesOf(stored)
+}
+
+func routesOf(network *hcloud.Network) []string {
+	routes := make([]string, 0, len(network.Routes))
+	for _, route := range network.Routes {
+		routes = append(routes, route.Destination.String()+" via "+route.Gateway.String())
+	}
+	return routes
+}
+
+var _ = Describe("reconcileNATGatewayRoute", func() {
+	var (
+		ctx          context.Context
+		hcloudClient hcloudclient.Client
+		oldRoute     hcloud.NetworkRoute
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		hcloudClient = fakeclient.NewHCloudClientFactory().NewClient("")
+		oldRoute = hcloud.NetworkRoute{Destination: defaultRouteDestination(), Gateway: net.ParseIP("10.0.0.2")}
+	})
+
+	It("adds the default route via the NAT gateway", func() {
+		network := newTestNetwork(ctx, hcloudClient, "nat-add")
+		service := newTestService(hcloudClient)
+		service.scope.HetznerCluster.Spec.HCloudNetwork.NATGateway = &infrav1.HCloudNATGatewaySpec{IP: "10.0.0.3"}
+
+		Expect(service.reconcileNATGatewayRoute(ctx, network)).To(Succeed())
+		Expect(routesOf(network)).To(Equal([]string{"0.0.0.0/0 via 10.0.0.3"}))
+		Expect(storedRoutes(ctx, hcloudClient, network)).To(Equal(routesOf(network)))
+	})
+
+	It("replaces the default route via another gateway", func() {
+		network := newTestNetwork(ctx, hcloudClient, "nat-replace", oldRoute)
+		service := newTestService(hcloudClient)
+		service.scope.HetznerCluster.Spec.HCloudNetwork.NATGateway = &infrav1.HCloudNATGatewaySpec{IP: "10.0.0.3"}
+
+		Expect(service.reconcileNATGatewayRoute(ctx, network)).To(Succeed())
+		Expect(routesOf(network)).To(Equal([]string{"0.0.0.0/0 via 10.0.0.3"}))
+		Expect(storedRoutes(ctx, hcloudClient, network)).To(Equal(routesOf(network)))
+	})
+
+	It("restores the old default route if the new one cannot be added", func() {
+		network := newTestNetwork(ctx, hcloudClient, "nat-restore", oldRoute)
+		service := newTestService(&failingRouteClient{Client: hcloudClient, gateway: net.ParseIP("10.0.0.3")})
+		service.scope.HetznerCluster.Spec.HCloudNetwork.NATGateway = &infrav1.HCloudNATGatewaySpec{IP: "10.0.0.3"}
+
+		Expect(service.reconcileNATGatewayRoute(ctx, network)).ToNot(Succeed())
+		Expect(routesOf(network)).To(Equal([]string{"0.0.0.0/0 via 10.0.0.2"}))
+		Expect(storedRoutes(ctx, hcloudClient, network)).To(Equal(routesOf(network)))
+	})
+})
+
+var _ = Describe("reconcileRoutes", func() {
+	var (
+		ctx          context.Context
+		hcloudClient hcloudclient.Client
+		service      *Service
+		podRoute     hcloud.NetworkRoute
+		foreignRoute hcloud.NetworkRoute
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		hcloudClient = fakeclient.NewHCloudClientFactory().NewClient("")
+		service = newTestService(hcloudClient)
+		_, podCIDR, err := net.ParseCIDR("10.244.0.0/24")
+		Expect(err).To(Succeed())
+		podRoute = hcloud.NetworkRoute{Destination: podCIDR, Gateway: net.ParseIP("10.0.0.2")}
+		_, otherCIDR, err := net.ParseCIDR("192.168.0.0/24")
+		Expect(err).To(Succeed())
+		foreignRoute = hcloud.NetworkRoute{Destination: otherCIDR, Gateway: net.ParseIP("10.0.0.10")}
+	})
+
+	manageRoutes := func(routes ...infrav1.HCloudNetworkRouteSpec) {
+		service.scope.HetznerCluster.Status.Network = &infrav1.NetworkStatus{Routes: routes}
+	}
+
+	It("adds the routes of the spec", func() {
+		network := newTestNetwork(ctx, hcloudClient, "routes-add")
+		service.scope.HetznerCluster.Spec.HCloudNetwork.Routes = []infrav1.HCloudNetworkRouteSpec{
+			{Destination: "10.244.0.0/24", Gateway: "10.0.0.2"},
+		}
+
+		routes, err := service.reconcileRoutes(ctx, network)
+		Expect(err).To(Succeed())
+		Expect(routes).To(Equal(service.scope.HetznerCluster.Spec.HCloudNetwork.Routes))
+		Expect(routesOf(network)).To(Equal([]string{"10.244.0.0/24 via 10.0.0.2"}))
+		Expect(storedRoutes(ctx, hcloudClient, network)).To(Equal(routesOf(network)))
+	})
+
+	It("removes the routes that have been removed from the spec", func() {
+		network := newTestNetwork(ctx, hcloudClient, "routes-remove", podRoute)
+		manageRoutes(infrav1.HCloudNetworkRouteSpec{Destination: "10.244.0.0/24", Gateway: "10.0.0.2"})
+
+		routes, err := service.reconcileRoutes(ctx, network)
+		Expect(err).To(Succeed())
+		Expect(routes).To(BeEmpty())
+		Expect(routesOf(network)).To(BeEmpty())
+		Expect(storedRoutes(ctx, hcloudClient, network)).To(BeEmpty())
+	})
+
+	It("keeps routes that have not been added by the controller", func() {
+		network := newTestNetwork(ctx, hcloudClient, "routes-foreign", foreignRoute, podRoute)
+		// The route via another gateway has been changed outside of the cluster
+		manageRoutes(
+			infrav1.HCloudNetworkRouteSpec{Destination: "10.244.0.0/24", Gateway: "10.0.0.3"},
+		)
+
+		_, err := service.reconcileRoutes(ctx, network)
+		Expect(err).To(Succeed())
+		Expect(routesOf(network)).To(Equal([]string{"192.168.0.0/24 via 10.0.0.10", "10.244.0.0/24 via 10.0.0.2"}))
+		Expect(storedRoutes(ctx, hcloudClient, network)).To(Equal(routesOf(network)))
+	})
+
+	It("ignores routes that have been deleted already", func() {
+		network := newTestNetwork(ctx, hcloudClient, "routes-deleted")
+		manageRoutes(infrav1.HCloudNetworkRouteSpec{Destination: "10.244.0.0/24", Gateway: "10.0.0.2"})
+		// The network has been read before the route was deleted
+		network.Routes = []hcloud.NetworkRoute{podRoute}
+
+		routes, err := service.reconcileRoutes(ctx, network)
+		Expect(err).To(Succeed())
+		Expect(routes).To(BeEmpty())
+		Expect(routesOf(network)).To(BeEmpty())
+	})
+})
+
+var _ = Describe("reconcileSubnetIPs", func() {
+	var (
+		ctx          context.Context
+		hcloudClient hcloudclient.Client
+		service      *Service
+		network      *hcloud.Network
+		serverCount  int
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		hcloudClient = fakeclient.NewHCloudClientFactory().NewClient("")
+		service = newTestService(hcloudClient)
+		// The subnet has 5 IPs for servers, as the gateway 10.0.0.1 is in its range
+		service.scope.HetznerCluster.Spec.HCloudNetwork.SubnetCIDRBlock = "10.0.0.0/29"
+		serverCount++
+		network = newTestNetwork(ctx, hcloudClient, fmt.Sprintf("subnet-ips-%d", serverCount))
+		for _, cidrBlock := range []string{"10.0.0.0/29", "10.0.1.0/24"} {
+			_, ipRange, err := net.ParseCIDR(cidrBlock)
+			Expect(err).To(Succeed())
+			network.Subnets = append(network.Subnets, hcloud.NetworkSubnet{
+				Type: hcloud.NetworkSubnetTypeCloud, IPRange: ipRange, NetworkZone: hcloud.NetworkZoneEUCentral,
+			})
+		}
+	})
+
+	attachServers := func(subnetPrefix string, count int) {
+		for i := 0; i < count; i++ {
+			res, err := hcloudClient.CreateServer(ctx, hcloud.ServerCreateOpts{Name: fmt.Sprintf("%s-%d-%s-%d", network.Name, serverCount, subnetPrefix, i)})
+			Expect(err).To(Succeed())
+			res.Server.PrivateNet = []hcloud.ServerPrivateNet{{Network: network, IP: net.ParseIP(fmt.Sprintf("%s.%d", subnetPrefix, i+2))}}
+			network.Servers = append(network.Servers, res.Server)
+		}
+	}
+
+	It("does not count the servers of other subnets", func() {
+		attachServers("10.0.0", 2)
+		attachServers("10.0.1", 4)
+
+		Expect(service.reconcileSubnetIPs(ctx, network)).To(Succeed())
+		Expect(conditions.IsTrue(service.scope.HetznerCluster, infrav1.SubnetIPsAvailableCondition)).To(BeTrue())
+	})
+
+	It("reports an exhausted subnet", func() {
+		attachServers("10.0.0", 5)
+		attachServers("10.0.1", 1)
+
+		Expect(service.reconcileSubnetIPs(ctx, network)).To(Succeed())
+		Expect(conditions.GetReason(service.scope.HetznerCluster, infrav1.SubnetIPsAvailableCondition)).To(Equal(infrav1.SubnetExhaustedReason))
+	})
+})
+
+var _ = Describe("DefaultSubnet", func() {
+	network := &hcloud.Network{}
+	for _, cidrBlock := range []string{"10.0.0.0/24", "10.0.1.0/24"} {
+		_, ipRange, err := net.ParseCIDR(cidrBlock)
+		Expect(err).To(Succeed())
+		network.Subnets = append(network.Subnets, hcloud.NetworkSubnet{
+			Type: hcloud.NetworkSubnetTypeCloud, IPRange: ipRange, NetworkZone: hcloud.NetworkZoneEUCentral,
+		})
+	}
+
+	It("uses the first subnet of an existing network in the network zone", func() {
+		spec := infrav1.HCloudNetworkSpec{NetworkZone: "eu-central", ExistingNetwork: &infrav1.HCloudNetworkRef{Name: "shared"}}
+		Expect(DefaultSubnet(network, spec).IPRange.String()).To(Equal("10.0.0.0/24"))
+	})
+
+	It("uses the subnet that the reference of an existing network selects", func() {
+		spec := infrav1.HCloudNetworkSpec{
+			NetworkZone:     "eu-central",
+			ExistingNetwork: &infrav1.HCloudNetworkRef{Name: "shared", SubnetCIDRBlock: "10.0.1.0/24"},
+		}
+		Expect(DefaultSubnet(network, spec).IPRange.String()).To(Equal("10.0.1.0/24"))
+	})
+})
@@ -0,0 +1,176 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package orphan implements the cleanup of HCloud resources that have been orphaned.
+package orphan
+
+import (
+	"context"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"github.com/pkg/errors"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/scope"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/utils"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Service struct contains cluster scope to clean up orphaned resources.
+type Service struct {
+	scope *scope.ClusterScope
+}
+
+// NewService creates new service object.
+func NewService(scope *scope.ClusterScope) *Service {
+	return &Service{
+		scope: scope,
+	}
+}
+
+// Reconcile deletes all orphaned resources listed in the status of the HetznerCluster.
+// Resources that could be deleted or do not exist anymore are removed from the list.
+func (s *Service) Reconcile(ctx context.Context) error {
+	orphanedResources := s.scope.HetznerCluster.Status.OrphanedResources
+	if len(orphanedResources) == 0 {
+		return nil
+	}
+
+	log := ctrl.LoggerFrom(ctx)
+	log.V(1).Info("Reconcile orphaned resources", "count", len(orphanedResources))
+
+	remaining := make([]infrav1.OrphanedResource, 0, len(orphanedResources))
+	var errs []error
+	for _, res := range orphanedResources {
+		if err := s.deleteResource(ctx, res); err != nil {
+			remaining = append(remaining, res)
+			errs = append(errs, err)
+			continue
+		}
+
+		// the orphaned server kept its primary IPs until now
+		if res.Type == infrav1.OrphanedResourceTypeServer {
+			if err := s.releasePrimaryIPs(ctx, res.Name); err != nil {
+				remaining = append(remaining, res)
+				errs = append(errs, err)
+				continue
+			}
+		}
+
+		record.Eventf(
+			s.scope.HetznerCluster,
+			"OrphanedResourceDeleted",
+			"Deleted orphaned %s with ID %d of %s",
+			res.Type, res.ID, res.Name,
+		)
+	}
+
+	if len(remaining) == 0 {
+		remaining = nil
+	}
+	s.scope.HetznerCluster.Status.OrphanedResources = remaining
+
+	return kerrors.NewAggregate(errs)
+}
+
+func (s *Service) deleteResource(ctx context.Context, res infrav1.OrphanedResource) error {
+	switch res.Type {
+	case infrav1.OrphanedResourceTypeServer:
+		if res.ID != 0 {
+			return s.deleteServer(ctx, &hcloud.Server{ID: res.ID})
+		}
+
+		// the ID of a server that was orphaned before its providerID had been set is not known
+		opts := hcloud.ServerListOpts{}
+		opts.LabelSelector = utils.LabelsToLabelSelector(map[string]string{
+			infrav1.ClusterTagKey(s.scope.HetznerCluster.Name): string(infrav1.ResourceLifecycleOwned),
+			infrav1.MachineNameTagKey:                          res.Name,
+		})
+		servers, err := s.scope.HCloudClient.ListServers(ctx, opts)
+		if err != nil {
+			if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+				conditions.MarkTrue(s.scope.HetznerCluster, infrav1.RateLimitExceeded)
+				record.Event(s.scope.HetznerCluster,
+					"RateLimitExceeded",
+					"exceeded rate limit with calling hcloud function ListServers",
+				)
+			}
+			return errors.Wrapf(err, "failed to list orphaned servers of %s", res.Name)
+		}
+		for _, server := range servers {
+			if err := s.deleteServer(ctx, server); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return errors.Errorf("unknown type %q of orphaned resource", res.Type)
+	}
+}
+
+func (s *Service) deleteServer(ctx context.Context, server *hcloud.Server) error {
+	err := s.scope.HCloudClient.DeleteServer(ctx, server)
+	if hcloud.IsError(err, hcloud.ErrorCodeProtected) {
+		// the protection of control plane servers is lifted by the controller only to delete them
+		unprotected := false
+		_, err = s.scope.HCloudClient.ChangeServerProtection(ctx, server, hcloud.ServerChangeProtectionOpts{
+			Delete:  &unprotected,
+			Rebuild: &unprotected,
+		})
+		if err == nil {
+			err = s.scope.HCloudClient.DeleteServer(ctx, server)
+		}
+	}
+	if err == nil || hcloud.IsError(err, hcloud.ErrorCodeNotFound) {
+		return nil
+	}
+	if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+		conditions.MarkTrue(s.scope.HetznerCluster, infrav1.RateLimitExceeded)
+		record.Event(s.scope.HetznerCluster,
+			"RateLimitExceeded",
+			"exceeded rate limit with calling hcloud function DeleteServer",
+		)
+	}
+	return errors.Wrapf(err, "failed to delete orphaned server %d", server.ID)
+}
+
+// releasePrimaryIPs removes the consumer reference of all HCloudPrimaryIPs that are claimed by the machine.
+func (s *Service) releasePrimaryIPs(ctx context.Context, machineName string) error {
+	namespace := s.scope.HetznerCluster.Namespace
+	primaryIPs := &infrav1.HCloudPrimaryIPList{}
+	if err := s.scope.Client.List(ctx, primaryIPs, client.InNamespace(namespace)); err != nil {
+		return errors.Wrap(err, "failed to list HCloudPrimaryIPs")
+	}
+
+	for i := range primaryIPs.Items {
+		primaryIP := &primaryIPs.Items[i]
+		ref := primaryIP.Spec.ConsumerRef
+		if ref == nil || ref.Name != machineName || ref.Namespace != namespace {
+			continue
+		}
+
+		base := primaryIP.DeepCopy()
+		primaryIP.Spec.ConsumerRef = nil
+		if err := s.scope.Client.Patch(ctx, primaryIP, client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{})); err != nil {
+			return errors.Wrapf(err, "failed to release primary IP %s", primaryIP.Name)
+		}
+		record.Eventf(s.scope.HetznerCluster, "PrimaryIPReleased", "Released primary IP %s of orphaned server of %s", primaryIP.Name, machineName)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orphan
+
+import (
+	"context"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/scope"
+	hcloudclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client"
+	fakeclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakek8sclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var ctx = context.Background()
+
+var scheme = func() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(infrav1.AddToScheme(scheme))
+	return scheme
+}()
+
+var _ = Describe("Reconcile", func() {
+	var (
+		service        *Service
+		client         ctrlclient.Client
+		hcloudClient   hcloudclient.Client
+		hetznerCluster *infrav1.HetznerCluster
+	)
+
+	BeforeEach(func() {
+		hcloudClient = fakeclient.NewHCloudClientFactory().NewClient("")
+		hcloudClient.Close()
+
+		hetznerCluster = &infrav1.HetznerCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "orphan-test", Namespace: "default"},
+		}
+		client = fakek8sclient.NewClientBuilder().WithScheme(scheme).Build()
+		service = NewService(&scope.ClusterScope{Client: client, HCloudClient: hcloudClient, HetznerCluster: hetznerCluster})
+	})
+
+	createServer := func(name string) *hcloud.Server {
+		res, err := hcloudClient.CreateServer(ctx, hcloud.ServerCreateOpts{
+			Name: name,
+			Labels: map[string]string{
+				infrav1.ClusterTagKey(hetznerCluster.Name): string(infrav1.ResourceLifecycleOwned),
+				infrav1.MachineNameTagKey:                  name,
+			},
+		})
+		Expect(err).To(Succeed())
+		return res.Server
+	}
+
+	serverNames := func() []string {
+		servers, err := hcloudClient.ListServers(ctx, hcloud.ServerListOpts{})
+		Expect(err).To(Succeed())
+		names := make([]string, 0, len(servers))
+		for _, server := range servers {
+			names = append(names, server.Name)
+		}
+		return names
+	}
+
+	It("deletes orphaned servers by ID", func() {
+		server := createServer("orphaned")
+		createServer("other")
+		hetznerCluster.Status.OrphanedResources = []infrav1.OrphanedResource{
+			{Type: infrav1.OrphanedResourceTypeServer, ID: server.ID, Name: "orphaned"},
+		}
+
+		Expect(service.Reconcile(ctx)).To(Succeed())
+		Expect(serverNames()).To(ConsistOf("other"))
+		Expect(hetznerCluster.Status.OrphanedResources).To(BeEmpty())
+	})
+
+	It("finds orphaned servers without ID by the name of their machine", func() {
+		createServer("orphaned")
+		createServer("other")
+		hetznerCluster.Status.OrphanedResources = []infrav1.OrphanedResource{
+			{Type: infrav1.OrphanedResourceTypeServer, Name: "orphaned"},
+		}
+
+		Expect(service.Reconcile(ctx)).To(Succeed())
+		Expect(serverNames()).To(ConsistOf("other"))
+		Expect(hetznerCluster.Status.OrphanedResources).To(BeEmpty())
+	})
+
+	It("removes orphaned servers without ID that do not exist", func() {
+		createServer("other")
+		hetznerCluster.Status.OrphanedResources = []infrav1.OrphanedResource{
+			{Type: infrav1.OrphanedResourceTypeServer, Name: "never-created"},
+		}
+
+		Expect(service.Reconcile(ctx)).To(Succeed())
+		Expect(serverNames()).To(ConsistOf("other"))
+		Expect(hetznerCluster.Status.OrphanedResources).To(BeEmpty())
+	})
+
+	It("releases the primary IPs of orphaned servers after they have been deleted", func() {
+		server := createServer("orphaned")
+		hetznerCluster.Status.OrphanedResources = []infrav1.OrphanedResource{
+			{Type: infrav1.OrphanedResourceTypeServer, ID: server.ID, Name: "orphaned"},
+		}
+		for name, consumer := range map[string]string{"claimed": "orphaned", "other": "other"} {
+			Expect(client.Create(ctx, &infrav1.HCloudPrimaryIP{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+				Spec: infrav1.HCloudPrimaryIPSpec{
+					Type:        infrav1.PrimaryIPTypeIPv4,
+					ConsumerRef: &corev1.ObjectReference{Name: consumer, Namespace: "default"},
+				},
+			})).To(Succeed())
+		}
+
+		Expect(service.Reconcile(ctx)).To(Succeed())
+
+		var primaryIP infrav1.HCloudPrimaryIP
+		Expect(client.Get(ctx, ctrlclient.ObjectKey{Name: "claimed", Namespace: "default"}, &primaryIP)).To(Succeed())
+		Expect(primaryIP.Spec.ConsumerRef).To(BeNil())
+		Expect(client.Get(ctx, ctrlclient.ObjectKey{Name: "other", Namespace: "default"}, &primaryIP)).To(Succeed())
+		Expect(primaryIP.Spec.ConsumerRef.Name).To(Equal("other"))
+	})
+})
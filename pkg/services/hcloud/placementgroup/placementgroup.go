@@ -28,6 +28,7 @@ import (
 	"github.com/syself/cluster-api-provider-hetzner/pkg/scope"
 	"github.com/syself/cluster-api-provider-hetzner/pkg/utils"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/record"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -51,11 +52,16 @@ func (s *Service) Reconcile(ctx context.Context) (err error) {
 	log.V(1).Info("Reconcile placement groups")
 
 	// find placement groups
-	placementGroups, err := s.findPlacementGroups(ctx)
+	placementGroups, automaticPlacementGroups, err := s.findPlacementGroups(ctx)
 	if err != nil {
 		return errors.Wrap(err, "failed to find placement group")
 	}
 
+	// automatic placement groups of machines are deleted once they have no servers
+	if err := s.deleteEmptyPlacementGroups(ctx, automaticPlacementGroups); err != nil {
+		return errors.Wrap(err, "failed to delete empty automatic placement groups")
+	}
+
 	s.scope.HetznerCluster.Status.HCloudPlacementGroup = apiToStatus(placementGroups, s.scope.HetznerCluster.Name)
 
 	placementGroupsSpec := s.scope.HetznerCluster.Spec.HCloudPlacementGroup
@@ -122,7 +128,7 @@ func (s *Service) Reconcile(ctx context.Context) (err error) {
 	}
 
 	// Update status
-	placementGroups, err = s.findPlacementGroups(ctx)
+	placementGroups, _, err = s.findPlacementGroups(ctx)
 	if err != nil {
 		return errors.Wrap(err, "failed to find placement group")
 	}
@@ -159,18 +165,95 @@ func (s *Service) Delete(ctx context.Context) (err error) {
 		return err
 	}
 
+	_, automaticPlacementGroups, err := s.findPlacementGroups(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to find placement groups")
+	}
+	for _, pg := range automaticPlacementGroups {
+		if err := s.deletePlacementGroup(ctx, pg.ID); err != nil {
+			return errors.Wrapf(err, "failed to delete automatic placement group %s", pg.Name)
+		}
+	}
+
 	record.Eventf(s.scope.HetznerCluster, "PlacementGroupsDeleted", "Deleted placement groups")
 
 	return nil
 }
 
-func (s *Service) findPlacementGroups(ctx context.Context) ([]*hcloud.PlacementGroup, error) {
+// deleteEmptyPlacementGroups deletes the placement groups without servers. Placement groups of machine groups that
+// still have HCloudMachines are kept, as the server of such a machine might be created in it right now.
+func (s *Service) deleteEmptyPlacementGroups(ctx context.Context, placementGroups []*hcloud.PlacementGroup) error {
+	var referenced map[string]struct{}
+	for _, pg := range placementGroups {
+		if len(pg.Servers) > 0 {
+			continue
+		}
+		if referenced == nil {
+			var err error
+			if referenced, err = s.referencedMachineGroups(ctx); err != nil {
+				return err
+			}
+		}
+		if _, found := referenced[pg.Labels[infrav1.PlacementGroupMachineGroupTagKey]]; found {
+			continue
+		}
+		if err := s.deletePlacementGroup(ctx, pg.ID); err != nil {
+			return errors.Wrapf(err, "failed to delete placement group %s", pg.Name)
+		}
+		record.Eventf(s.scope.HetznerCluster, "PlacementGroupDeleted", "Deleted empty automatic placement group %s", pg.Name)
+	}
+	return nil
+}
+
+// referencedMachineGroups returns the machine groups of the HCloudMachines of the cluster that use an automatic
+// placement group.
+func (s *Service) referencedMachineGroups(ctx context.Context) (map[string]struct{}, error) {
+	machines, hcloudMachines, err := s.scope.ListMachines(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list machines")
+	}
+	machineGroups := make(map[string]struct{})
+	for i, hcloudMachine := range hcloudMachines {
+		if hcloudMachine.Spec.AutomaticPlacementGroup == nil {
+			continue
+		}
+		labelKey := hcloudMachine.Spec.AutomaticPlacementGroup.LabelKey
+		if labelKey == "" {
+			labelKey = clusterv1.MachineDeploymentLabelName
+		}
+		if machineGroup := machines[i].Labels[labelKey]; machineGroup != "" {
+			machineGroups[machineGroup] = struct{}{}
+		}
+	}
+	return machineGroups, nil
+}
+
+func (s *Service) deletePlacementGroup(ctx context.Context, id int) error {
+	if err := s.scope.HCloudClient.DeletePlacementGroup(ctx, id); err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HetznerCluster, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HetznerCluster,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function DeletePlacementGroup",
+			)
+			return err
+		}
+		if !hcloud.IsError(err, hcloud.ErrorCodeNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// findPlacementGroups returns the placement groups of the cluster spec and the automatic placement groups of
+// machines separately.
+func (s *Service) findPlacementGroups(ctx context.Context) (placementGroups, automatic []*hcloud.PlacementGroup, err error) {
 	clusterTagKey := infrav1.ClusterTagKey(s.scope.HetznerCluster.Name)
 	labels := map[string]string{clusterTagKey: string(infrav1.ResourceLifecycleOwned)}
 	opts := hcloud.PlacementGroupListOpts{}
 	opts.LabelSelector = utils.LabelsToLabelSelector(labels)
 
-	placementGroups, err := s.scope.HCloudClient.ListPlacementGroups(ctx, opts)
+	list, err := s.scope.HCloudClient.ListPlacementGroups(ctx, opts)
 	if err != nil {
 		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
 			conditions.MarkTrue(s.scope.HetznerCluster, infrav1.RateLimitExceeded)
@@ -179,9 +262,17 @@ func (s *Service) findPlacementGroups(ctx context.Context) ([]*hcloud.PlacementG
 				"exceeded rate limit with calling hcloud function ListPlacementGroups",
 			)
 		}
-		return nil, errors.Wrap(err, "failed to list placement groups")
+		return nil, nil, errors.Wrap(err, "failed to list placement groups")
+	}
+
+	for _, pg := range list {
+		if _, found := pg.Labels[infrav1.PlacementGroupMachineGroupTagKey]; found {
+			automatic = append(automatic, pg)
+		} else {
+			placementGroups = append(placementGroups, pg)
+		}
 	}
-	return placementGroups, nil
+	return placementGroups, automatic, nil
 }
 
 // gets the information of the Hetzner load balancer object and returns it in our status object.
@@ -17,9 +17,20 @@ limitations under the License.
 package placementgroup
 
 import (
+	"context"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/scope"
+	fakeclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	fakek8sclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 var _ = Describe("apiToStatus", func() {
@@ -51,3 +62,64 @@ var _ = Describe("apiToStatus", func() {
 		}
 	})
 })
+
+var _ = Describe("deleteEmptyPlacementGroups", func() {
+	It("keeps empty automatic placement groups that are used by HCloudMachines", func() {
+		ctx := context.Background()
+		scheme := runtime.NewScheme()
+		utilruntime.Must(infrav1.AddToScheme(scheme))
+		utilruntime.Must(clusterv1.AddToScheme(scheme))
+
+		cluster := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default"}}
+		hetznerCluster := &infrav1.HetznerCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default"}}
+		machine := &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "md-0-machine",
+				Namespace: "default",
+				Labels:    map[string]string{clusterv1.MachineDeploymentLabelName: "md-0"},
+			},
+			Spec: clusterv1.MachineSpec{
+				ClusterName: "cluster",
+				InfrastructureRef: corev1.ObjectReference{
+					APIVersion: infrav1.GroupVersion.String(),
+					Kind:       "HCloudMachine",
+					Name:       "md-0-machine",
+				},
+			},
+		}
+		hcloudMachine := &infrav1.HCloudMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: "md-0-machine", Namespace: "default"},
+			Spec:       infrav1.HCloudMachineSpec{AutomaticPlacementGroup: &infrav1.AutomaticPlacementGroupSpec{}},
+		}
+		client := fakek8sclient.NewClientBuilder().WithScheme(scheme).WithObjects(machine, hcloudMachine).Build()
+		hcloudClient := fakeclient.NewHCloudClientFactory().NewClient("")
+		service := NewService(&scope.ClusterScope{
+			Client:         client,
+			HCloudClient:   hcloudClient,
+			Cluster:        cluster,
+			HetznerCluster: hetznerCluster,
+		})
+
+		for _, machineGroup := range []string{"md-0", "md-1"} {
+			_, err := hcloudClient.CreatePlacementGroup(ctx, hcloud.PlacementGroupCreateOpts{
+				Name: "cluster-auto-" + machineGroup,
+				Type: hcloud.PlacementGroupTypeSpread,
+				Labels: map[string]string{
+					infrav1.ClusterTagKey("cluster"):         string(infrav1.ResourceLifecycleOwned),
+					infrav1.PlacementGroupMachineGroupTagKey: machineGroup,
+				},
+			})
+			Expect(err).To(Succeed())
+		}
+		_, automatic, err := service.findPlacementGroups(ctx)
+		Expect(err).To(Succeed())
+		Expect(automatic).To(HaveLen(2))
+
+		Expect(service.deleteEmptyPlacementGroups(ctx, automatic)).To(Succeed())
+
+		_, automatic, err = service.findPlacementGroups(ctx)
+		Expect(err).To(Succeed())
+		Expect(automatic).To(HaveLen(1))
+		Expect(automatic[0].Name).To(Equal("cluster-auto-md-0"))
+	})
+})
@@ -0,0 +1,279 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package primaryip implements the lifecycle of HCloud primary IPs.
+package primaryip
+
+import (
+	"context"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"github.com/pkg/errors"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/scope"
+	"k8s.io/apimachinery/pkg/api/equality"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// Service defines struct with HCloudPrimaryIP scope to reconcile HCloud primary IPs.
+type Service struct {
+	scope *scope.HCloudPrimaryIPScope
+}
+
+// NewService outs a new service with HCloudPrimaryIP scope.
+func NewService(scope *scope.HCloudPrimaryIPScope) *Service {
+	return &Service{
+		scope: scope,
+	}
+}
+
+// Reconcile implements the life cycle of HCloud primary IPs.
+func (s *Service) Reconcile(ctx context.Context) (_ *ctrl.Result, err error) {
+	log := ctrl.LoggerFrom(ctx)
+	log.V(1).Info("Reconcile primary IP")
+
+	primaryIP, err := s.findPrimaryIP(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find primary IP")
+	}
+
+	if primaryIP == nil {
+		// an explicitly referenced primary IP cannot be created
+		if s.scope.HCloudPrimaryIP.Spec.ID != nil {
+			conditions.MarkFalse(s.scope.HCloudPrimaryIP,
+				infrav1.PrimaryIPReadyCondition,
+				infrav1.PrimaryIPNotFoundReason,
+				clusterv1.ConditionSeverityError,
+				"primary IP with ID %d not found",
+				*s.scope.HCloudPrimaryIP.Spec.ID,
+			)
+			s.scope.HCloudPrimaryIP.Status.Ready = false
+			return &ctrl.Result{RequeueAfter: time.Minute}, nil
+		}
+
+		// a primary IP that is adopted by name cannot be created without datacenter
+		if s.scope.HCloudPrimaryIP.Spec.Datacenter == "" {
+			conditions.MarkFalse(s.scope.HCloudPrimaryIP,
+				infrav1.PrimaryIPReadyCondition,
+				infrav1.PrimaryIPNotFoundReason,
+				clusterv1.ConditionSeverityError,
+				"primary IP with name %s not found and no datacenter set to create it",
+				s.scope.Name(),
+			)
+			s.scope.HCloudPrimaryIP.Status.Ready = false
+			return &ctrl.Result{RequeueAfter: time.Minute}, nil
+		}
+
+		primaryIP, err = s.createPrimaryIP(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create primary IP")
+		}
+	}
+
+	if err := s.reconcileProperties(ctx, primaryIP); err != nil {
+		return nil, errors.Wrap(err, "failed to reconcile properties of primary IP")
+	}
+
+	s.scope.HCloudPrimaryIP.Status = apiToStatus(primaryIP, s.scope.HCloudPrimaryIP.Status)
+	conditions.MarkTrue(s.scope.HCloudPrimaryIP, infrav1.PrimaryIPReadyCondition)
+	return nil, nil
+}
+
+// Delete releases the primary IP. It is only deleted in HCloud if the reclaim policy says so.
+func (s *Service) Delete(ctx context.Context) (_ *ctrl.Result, err error) {
+	if ref := s.scope.HCloudPrimaryIP.Spec.ConsumerRef; ref != nil {
+		record.Eventf(s.scope.HCloudPrimaryIP,
+			"WaitingForConsumer",
+			"Primary IP is still used by HCloudMachine %s/%s, waiting with deletion",
+			ref.Namespace, ref.Name,
+		)
+		return &ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+	}
+
+	if s.scope.HCloudPrimaryIP.Spec.ReclaimPolicy != infrav1.PrimaryIPReclaimPolicyDelete || s.scope.HCloudPrimaryIP.Status.ID == 0 {
+		return nil, nil
+	}
+
+	if err := s.scope.HCloudClient.DeletePrimaryIP(ctx, &hcloud.PrimaryIP{ID: s.scope.HCloudPrimaryIP.Status.ID}); err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeNotFound) {
+			return nil, nil
+		}
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HCloudPrimaryIP, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HCloudPrimaryIP,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function DeletePrimaryIP",
+			)
+		}
+		record.Warnf(s.scope.HCloudPrimaryIP, "FailedDeletePrimaryIP", "Failed to delete primary IP %d: %s", s.scope.HCloudPrimaryIP.Status.ID, err)
+		return nil, errors.Wrap(err, "failed to delete primary IP")
+	}
+
+	record.Eventf(s.scope.HCloudPrimaryIP, "PrimaryIPDeleted", "Deleted primary IP %d", s.scope.HCloudPrimaryIP.Status.ID)
+	return nil, nil
+}
+
+func (s *Service) createPrimaryIP(ctx context.Context) (*hcloud.PrimaryIP, error) {
+	autoDelete := false
+	opts := hcloud.PrimaryIPCreateOpts{
+		Name:         s.scope.Name(),
+		Type:         hcloud.PrimaryIPType(s.scope.HCloudPrimaryIP.Spec.Type),
+		Datacenter:   s.scope.HCloudPrimaryIP.Spec.Datacenter,
+		AssigneeType: "server",
+		AutoDelete:   &autoDelete,
+		Labels:       s.desiredLabels(),
+	}
+
+	res, err := s.scope.HCloudClient.CreatePrimaryIP(ctx, opts)
+	if err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HCloudPrimaryIP, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HCloudPrimaryIP,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function CreatePrimaryIP",
+			)
+		}
+		conditions.MarkFalse(s.scope.HCloudPrimaryIP,
+			infrav1.PrimaryIPReadyCondition,
+			infrav1.PrimaryIPCreateFailedReason,
+			clusterv1.ConditionSeverityError,
+			err.Error(),
+		)
+		record.Warnf(s.scope.HCloudPrimaryIP, "FailedCreatePrimaryIP", "Failed to create primary IP: %s", err)
+		return nil, err
+	}
+
+	record.Eventf(s.scope.HCloudPrimaryIP, "PrimaryIPCreated", "Created primary IP %d with address %s", res.PrimaryIP.ID, res.PrimaryIP.IP)
+	return res.PrimaryIP, nil
+}
+
+// reconcileProperties makes sure that labels are set and that the primary IP is not deleted together with its server.
+func (s *Service) reconcileProperties(ctx context.Context, primaryIP *hcloud.PrimaryIP) error {
+	labels := make(map[string]string, len(primaryIP.Labels))
+	for key, val := range primaryIP.Labels {
+		labels[key] = val
+	}
+	for key, val := range s.desiredLabels() {
+		labels[key] = val
+	}
+
+	var opts hcloud.PrimaryIPUpdateOpts
+	var needsUpdate bool
+	if !equality.Semantic.DeepEqual(labels, primaryIP.Labels) {
+		opts.Labels = &labels
+		needsUpdate = true
+	}
+	if primaryIP.AutoDelete {
+		autoDelete := false
+		opts.AutoDelete = &autoDelete
+		needsUpdate = true
+	}
+	if !needsUpdate {
+		return nil
+	}
+
+	if _, err := s.scope.HCloudClient.UpdatePrimaryIP(ctx, primaryIP, opts); err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HCloudPrimaryIP, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HCloudPrimaryIP,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function UpdatePrimaryIP",
+			)
+		}
+		return errors.Wrap(err, "failed to update primary IP")
+	}
+
+	primaryIP.Labels = labels
+	primaryIP.AutoDelete = false
+	return nil
+}
+
+func (s *Service) desiredLabels() map[string]string {
+	labels := make(map[string]string, len(s.scope.HCloudPrimaryIP.Spec.Labels)+2)
+	for key, val := range s.scope.HCloudPrimaryIP.Spec.Labels {
+		labels[key] = val
+	}
+	labels[infrav1.ClusterTagKey(s.scope.HetznerCluster.Name)] = string(infrav1.ResourceLifecycleOwned)
+	labels[infrav1.PrimaryIPNameLabel] = s.scope.Name()
+	return labels
+}
+
+// findPrimaryIP looks for the primary IP in this order: by the ID in status, by the ID in spec, by name.
+func (s *Service) findPrimaryIP(ctx context.Context) (*hcloud.PrimaryIP, error) {
+	id := s.scope.HCloudPrimaryIP.Status.ID
+	if specID := s.scope.HCloudPrimaryIP.Spec.ID; specID != nil {
+		id = *specID
+	}
+
+	if id != 0 {
+		primaryIP, err := s.scope.HCloudClient.GetPrimaryIP(ctx, id)
+		if err != nil {
+			if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+				conditions.MarkTrue(s.scope.HCloudPrimaryIP, infrav1.RateLimitExceeded)
+				record.Event(s.scope.HCloudPrimaryIP,
+					"RateLimitExceeded",
+					"exceeded rate limit with calling hcloud function GetPrimaryIP",
+				)
+			}
+			return nil, err
+		}
+		if primaryIP != nil || s.scope.HCloudPrimaryIP.Spec.ID != nil {
+			return primaryIP, nil
+		}
+	}
+
+	// adopt an existing primary IP with the same name
+	primaryIPs, err := s.scope.HCloudClient.ListPrimaryIPs(ctx, hcloud.PrimaryIPListOpts{Name: s.scope.Name()})
+	if err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HCloudPrimaryIP, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HCloudPrimaryIP,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function ListPrimaryIPs",
+			)
+		}
+		return nil, err
+	}
+	if len(primaryIPs) == 0 {
+		return nil, nil
+	}
+
+	if primaryIPs[0].Type != hcloud.PrimaryIPType(s.scope.HCloudPrimaryIP.Spec.Type) {
+		return nil, errors.Errorf("existing primary IP %s has type %s, expected %s",
+			primaryIPs[0].Name, primaryIPs[0].Type, s.scope.HCloudPrimaryIP.Spec.Type)
+	}
+
+	record.Eventf(s.scope.HCloudPrimaryIP, "PrimaryIPAdopted", "Adopted existing primary IP %d with name %s", primaryIPs[0].ID, primaryIPs[0].Name)
+	return primaryIPs[0], nil
+}
+
+func apiToStatus(primaryIP *hcloud.PrimaryIP, status infrav1.HCloudPrimaryIPStatus) infrav1.HCloudPrimaryIPStatus {
+	status.Ready = true
+	status.ID = primaryIP.ID
+	status.IP = primaryIP.IP.String()
+	status.AssigneeID = primaryIP.AssigneeID
+	if primaryIP.Datacenter != nil {
+		status.Datacenter = primaryIP.Datacenter.Name
+		if primaryIP.Datacenter.Location != nil {
+			status.Location = infrav1.Region(primaryIP.Datacenter.Location.Name)
+		}
+	}
+	return status
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package primaryip
+
+import (
+	"context"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	fakeclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/cluster-api/util/conditions"
+)
+
+var _ = Describe("Reconcile", func() {
+	var primaryIP *infrav1.HCloudPrimaryIP
+	client := fakeclient.NewHCloudClientFactory().NewClient("")
+
+	BeforeEach(func() {
+		client.Close()
+		primaryIP = &infrav1.HCloudPrimaryIP{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "primary-ip",
+				Namespace: "default",
+			},
+			Spec: infrav1.HCloudPrimaryIPSpec{
+				HetznerClusterRef: "hetzner-cluster",
+				Type:              infrav1.PrimaryIPTypeIPv4,
+				Datacenter:        "fsn1-dc14",
+				Labels:            map[string]string{"key": "value"},
+				ReclaimPolicy:     infrav1.PrimaryIPReclaimPolicyRetain,
+			},
+		}
+	})
+
+	It("creates a new primary IP", func() {
+		service := newTestService(primaryIP, client)
+		res, err := service.Reconcile(context.Background())
+		Expect(err).To(Succeed())
+		Expect(res).To(BeNil())
+		Expect(primaryIP.Status.Ready).To(BeTrue())
+		Expect(primaryIP.Status.ID).ToNot(BeZero())
+		Expect(primaryIP.Status.Location).To(Equal(infrav1.Region("fsn1")))
+		Expect(conditions.IsTrue(primaryIP, infrav1.PrimaryIPReadyCondition)).To(BeTrue())
+
+		apiPrimaryIP, err := client.GetPrimaryIP(context.Background(), primaryIP.Status.ID)
+		Expect(err).To(Succeed())
+		Expect(apiPrimaryIP.AutoDelete).To(BeFalse())
+		Expect(apiPrimaryIP.Labels).To(HaveKeyWithValue("key", "value"))
+		Expect(apiPrimaryIP.Labels).To(HaveKeyWithValue(infrav1.PrimaryIPNameLabel, "primary-ip"))
+	})
+
+	It("adopts an existing primary IP with the same name and updates its properties", func() {
+		autoDelete := true
+		existing, err := client.CreatePrimaryIP(context.Background(), hcloud.PrimaryIPCreateOpts{
+			Name:       "primary-ip",
+			Type:       hcloud.PrimaryIPTypeIPv4,
+			Datacenter: "fsn1-dc14",
+			AutoDelete: &autoDelete,
+		})
+		Expect(err).To(Succeed())
+
+		service := newTestService(primaryIP, client)
+		_, err = service.Reconcile(context.Background())
+		Expect(err).To(Succeed())
+		Expect(primaryIP.Status.ID).To(Equal(existing.PrimaryIP.ID))
+
+		apiPrimaryIP, err := client.GetPrimaryIP(context.Background(), existing.PrimaryIP.ID)
+		Expect(err).To(Succeed())
+		Expect(apiPrimaryIP.AutoDelete).To(BeFalse())
+		Expect(apiPrimaryIP.Labels).To(HaveKeyWithValue("key", "value"))
+	})
+
+	It("does not create a primary IP if the referenced ID does not exist", func() {
+		primaryIP.Spec.ID = pointer.Int(42)
+		service := newTestService(primaryIP, client)
+		res, err := service.Reconcile(context.Background())
+		Expect(err).To(Succeed())
+		Expect(res).ToNot(BeNil())
+		Expect(primaryIP.Status.Ready).To(BeFalse())
+		Expect(conditions.GetReason(primaryIP, infrav1.PrimaryIPReadyCondition)).To(Equal(infrav1.PrimaryIPNotFoundReason))
+	})
+
+	It("adopts an existing primary IP by name without datacenter", func() {
+		existing, err := client.CreatePrimaryIP(context.Background(), hcloud.PrimaryIPCreateOpts{
+			Name:       "primary-ip",
+			Type:       hcloud.PrimaryIPTypeIPv4,
+			Datacenter: "fsn1-dc14",
+		})
+		Expect(err).To(Succeed())
+
+		primaryIP.Spec.Datacenter = ""
+		service := newTestService(primaryIP, client)
+		_, err = service.Reconcile(context.Background())
+		Expect(err).To(Succeed())
+		Expect(primaryIP.Status.ID).To(Equal(existing.PrimaryIP.ID))
+		Expect(primaryIP.Status.Datacenter).To(Equal("fsn1-dc14"))
+	})
+
+	It("does not create a primary IP without datacenter", func() {
+		primaryIP.Spec.Datacenter = ""
+		service := newTestService(primaryIP, client)
+		res, err := service.Reconcile(context.Background())
+		Expect(err).To(Succeed())
+		Expect(res).ToNot(BeNil())
+		Expect(primaryIP.Status.Ready).To(BeFalse())
+		Expect(conditions.GetReason(primaryIP, infrav1.PrimaryIPReadyCondition)).To(Equal(infrav1.PrimaryIPNotFoundReason))
+	})
+})
+
+var _ = Describe("Delete", func() {
+	var primaryIP *infrav1.HCloudPrimaryIP
+	client := fakeclient.NewHCloudClientFactory().NewClient("")
+
+	BeforeEach(func() {
+		client.Close()
+		primaryIP = &infrav1.HCloudPrimaryIP{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "primary-ip",
+				Namespace: "default",
+			},
+			Spec: infrav1.HCloudPrimaryIPSpec{
+				HetznerClusterRef: "hetzner-cluster",
+				Type:              infrav1.PrimaryIPTypeIPv4,
+				Datacenter:        "fsn1-dc14",
+				ReclaimPolicy:     infrav1.PrimaryIPReclaimPolicyDelete,
+			},
+		}
+		_, err := newTestService(primaryIP, client).Reconcile(context.Background())
+		Expect(err).To(Succeed())
+	})
+
+	It("waits while the primary IP is used by a machine", func() {
+		primaryIP.Spec.ConsumerRef = &corev1.ObjectReference{Name: "hcloud-machine", Namespace: "default"}
+		res, err := newTestService(primaryIP, client).Delete(context.Background())
+		Expect(err).To(Succeed())
+		Expect(res).ToNot(BeNil())
+	})
+
+	It("deletes the primary IP with reclaim policy delete", func() {
+		res, err := newTestService(primaryIP, client).Delete(context.Background())
+		Expect(err).To(Succeed())
+		Expect(res).To(BeNil())
+		apiPrimaryIP, err := client.GetPrimaryIP(context.Background(), primaryIP.Status.ID)
+		Expect(err).To(Succeed())
+		Expect(apiPrimaryIP).To(BeNil())
+	})
+
+	It("keeps the primary IP with reclaim policy retain", func() {
+		primaryIP.Spec.ReclaimPolicy = infrav1.PrimaryIPReclaimPolicyRetain
+		_, err := newTestService(primaryIP, client).Delete(context.Background())
+		Expect(err).To(Succeed())
+		apiPrimaryIP, err := client.GetPrimaryIP(context.Background(), primaryIP.Status.ID)
+		Expect(err).To(Succeed())
+		Expect(apiPrimaryIP).ToNot(BeNil())
+	})
+})
@@ -0,0 +1,153 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package quota checks whether HCloud resources fit into the limits of the HCloud project before they are created.
+package quota
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"github.com/pkg/errors"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/scope"
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/record"
+)
+
+// Resource is a kind of HCloud resource whose number is limited per project.
+type Resource string
+
+const (
+	// Servers are HCloud servers.
+	Servers = Resource("servers")
+	// LoadBalancers are HCloud load balancers.
+	LoadBalancers = Resource("load balancers")
+	// Networks are HCloud networks.
+	Networks = Resource("networks")
+)
+
+// ErrQuotaExceeded is returned if a resource does not fit into the limits of the project.
+var ErrQuotaExceeded = errors.New("quota of HCloud project exceeded")
+
+// Service checks the quota of the HCloud project of a cluster.
+type Service struct {
+	scope *scope.ClusterScope
+}
+
+// NewService creates new service object.
+func NewService(scope *scope.ClusterScope) *Service {
+	return &Service{scope: scope}
+}
+
+// Check returns ErrQuotaExceeded if another resource does not fit into the limit of the project that is configured
+// in the HetznerCluster. The condition QuotaExceeded of obj is then set with the missing quota.
+func (s *Service) Check(ctx context.Context, obj conditions.Setter, resource Resource) error {
+	limit := s.limit(resource)
+	if limit == 0 {
+		return nil
+	}
+
+	used, err := s.count(ctx, obj, resource)
+	if err != nil {
+		return errors.Wrapf(err, "failed to count %s of HCloud project", resource)
+	}
+	if used < limit {
+		return nil
+	}
+
+	msg := fmt.Sprintf("quota of %d %s is exhausted: %d %s exist in the HCloud project, creating another one needs a quota of %d",
+		limit, resource, used, resource, used+1)
+	markExceeded(obj, msg)
+	return errors.Wrap(ErrQuotaExceeded, msg)
+}
+
+// RecordLimitError sets the condition QuotaExceeded of obj if HCloud rejected the creation of a resource, because
+// the limit of the project is reached, e.g. as the limit is not configured in the HetznerCluster.
+func RecordLimitError(obj conditions.Setter, resource Resource, err error) {
+	if !hcloud.IsError(err, hcloud.ErrorCodeResourceLimitExceeded) {
+		return
+	}
+	markExceeded(obj, fmt.Sprintf("HCloud rejected creating one of the %s: %s", resource, err))
+}
+
+func markExceeded(obj conditions.Setter, msg string) {
+	conditions.Set(obj, &clusterv1.Condition{
+		Type:     infrav1.QuotaExceeded,
+		Status:   corev1.ConditionTrue,
+		Reason:   infrav1.ResourceLimitReachedReason,
+		Severity: clusterv1.ConditionSeverityWarning,
+		Message:  msg,
+	})
+	record.Warnf(obj, "QuotaExceeded", "Cannot create resource: %s", msg)
+}
+
+func (s *Service) limit(resource Resource) int {
+	quota := s.scope.HetznerCluster.Spec.HCloudQuota
+	if quota == nil {
+		return 0
+	}
+	switch resource {
+	case Servers:
+		return quota.Servers
+	case LoadBalancers:
+		return quota.LoadBalancers
+	case Networks:
+		return quota.Networks
+	}
+	return 0
+}
+
+func (s *Service) count(ctx context.Context, obj conditions.Setter, resource Resource) (int, error) {
+	var (
+		used     int
+		err      error
+		function string
+	)
+	switch resource {
+	case Servers:
+		function = "ListServers"
+		var servers []*hcloud.Server
+		servers, err = s.scope.HCloudClient.ListServers(ctx, hcloud.ServerListOpts{})
+		used = len(servers)
+	case LoadBalancers:
+		function = "ListLoadBalancers"
+		var loadBalancers []*hcloud.LoadBalancer
+		loadBalancers, err = s.scope.HCloudClient.ListLoadBalancers(ctx, hcloud.LoadBalancerListOpts{})
+		used = len(loadBalancers)
+	case Networks:
+		function = "ListNetworks"
+		var networks []*hcloud.Network
+		networks, err = s.scope.HCloudClient.ListNetworks(ctx, hcloud.NetworkListOpts{})
+		used = len(networks)
+	default:
+		return 0, errors.Errorf("unknown resource %s", resource)
+	}
+	if err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(obj, infrav1.RateLimitExceeded)
+			record.Event(obj,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function "+function,
+			)
+		}
+		return 0, err
+	}
+	return used, nil
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quota
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/scope"
+	hcloudclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client"
+	fakeclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client/fake"
+	"sigs.k8s.io/cluster-api/util/conditions"
+)
+
+var _ = Describe("Check", func() {
+	var service *Service
+	var client hcloudclient.Client
+	var hcloudMachine *infrav1.HCloudMachine
+
+	BeforeEach(func() {
+		client = fakeclient.NewHCloudClientFactory().NewClient("")
+		hcloudMachine = &infrav1.HCloudMachine{}
+		service = NewService(&scope.ClusterScope{
+			HCloudClient:   client,
+			HetznerCluster: &infrav1.HetznerCluster{},
+		})
+	})
+
+	It("does not check resources without limit", func() {
+		Expect(service.Check(context.Background(), hcloudMachine, Servers)).To(Succeed())
+		Expect(conditions.Has(hcloudMachine, infrav1.QuotaExceeded)).To(BeFalse())
+	})
+
+	It("reports the missing quota if the limit is reached", func() {
+		servers, err := client.ListServers(context.Background(), hcloud.ServerListOpts{})
+		Expect(err).To(Succeed())
+		used := len(servers)
+		service.scope.HetznerCluster.Spec.HCloudQuota = &infrav1.HCloudQuota{Servers: used + 1}
+		Expect(service.Check(context.Background(), hcloudMachine, Servers)).To(Succeed())
+
+		_, err = client.CreateServer(context.Background(), hcloud.ServerCreateOpts{Name: "quota-server"})
+		Expect(err).To(Succeed())
+
+		err = service.Check(context.Background(), hcloudMachine, Servers)
+		Expect(errors.Is(err, ErrQuotaExceeded)).To(BeTrue())
+		Expect(conditions.IsTrue(hcloudMachine, infrav1.QuotaExceeded)).To(BeTrue())
+		Expect(conditions.GetMessage(hcloudMachine, infrav1.QuotaExceeded)).To(Equal(fmt.Sprintf(
+			"quota of %d servers is exhausted: %d servers exist in the HCloud project, creating another one needs a quota of %d",
+			used+1, used+1, used+2,
+		)))
+	})
+})
+
+var _ = Describe("RecordLimitError", func() {
+	It("sets the condition only for errors of the resource limit", func() {
+		hetznerCluster := &infrav1.HetznerCluster{}
+		RecordLimitError(hetznerCluster, LoadBalancers, hcloud.Error{Code: hcloud.ErrorCodeConflict, Message: "conflict"})
+		Expect(conditions.Has(hetznerCluster, infrav1.QuotaExceeded)).To(BeFalse())
+
+		RecordLimitError(hetznerCluster, LoadBalancers, hcloud.Error{Code: hcloud.ErrorCodeResourceLimitExceeded, Message: "limit reached"})
+		Expect(conditions.IsTrue(hetznerCluster, infrav1.QuotaExceeded)).To(BeTrue())
+	})
+})
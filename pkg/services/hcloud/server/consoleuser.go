@@ -0,0 +1,66 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/scope"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/record"
+)
+
+// reconcileConsoleUser sets the condition ConsoleUserInSync, which is false if the console user of the
+// HetznerCluster has been changed or removed after the server was created. The controller has no access to
+// servers, so the change only takes effect when the machine is replaced.
+func (s *Service) reconcileConsoleUser(ctx context.Context) error {
+	applied := s.scope.HCloudMachine.Status.AppliedConfiguration
+	if applied == nil {
+		return nil
+	}
+
+	consoleUser, err := s.scope.ConsoleUser(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get console user")
+	}
+
+	change := scope.ConsoleUserChange(applied.ConsoleUser, consoleUser.Applied())
+	if change == "" {
+		if consoleUser == nil {
+			conditions.Delete(s.scope.HCloudMachine, infrav1.ConsoleUserInSyncCondition)
+		} else {
+			conditions.MarkTrue(s.scope.HCloudMachine, infrav1.ConsoleUserInSyncCondition)
+		}
+		return nil
+	}
+
+	msg := change + " after the server was created, replace the machine to apply it"
+	if conditions.GetMessage(s.scope.HCloudMachine, infrav1.ConsoleUserInSyncCondition) != msg {
+		record.Warn(s.scope.HCloudMachine, "ConsoleUserChanged", "The "+msg)
+	}
+	conditions.MarkFalse(
+		s.scope.HCloudMachine,
+		infrav1.ConsoleUserInSyncCondition,
+		infrav1.ConsoleUserChangedReason,
+		clusterv1.ConditionSeverityWarning,
+		msg,
+	)
+	return nil
+}
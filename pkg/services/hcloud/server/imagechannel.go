@@ -0,0 +1,109 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"github.com/pkg/errors"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/record"
+)
+
+// reconcileImageChannel sets the condition ImageUpToDate of machines with an image channel and requests the
+// replacement of the machine once another image has been promoted in the channel. A machine of a MachineDeployment
+// is only replaced while all other machines of the deployment are ready and none of them is replaced, so that the
+// image rolls out one machine at a time. Control planes are rolled out by their control plane provider, so they
+// only get the condition.
+func (s *Service) reconcileImageChannel(ctx context.Context) error {
+	hcloudMachine := s.scope.HCloudMachine
+	channel := hcloudMachine.Spec.ImageChannel
+	applied := hcloudMachine.Status.AppliedConfiguration
+	if channel == "" || applied == nil || applied.ImageID == 0 {
+		conditions.Delete(hcloudMachine, infrav1.ImageUpToDateCondition)
+		return nil
+	}
+
+	image, err := s.channelImage(ctx, channel, hcloudMachine.Spec.Type.Architecture())
+	if err != nil {
+		return errors.Wrapf(err, "failed to get image of channel %s", channel)
+	}
+	// Without image in the channel, there is nothing to roll out
+	if image == nil || image.ID == applied.ImageID {
+		conditions.MarkTrue(hcloudMachine, infrav1.ImageUpToDateCondition)
+		return nil
+	}
+
+	msg := fmt.Sprintf("image %d has been promoted in channel %s, the server has been created from image %d",
+		image.ID, channel, applied.ImageID)
+	if !conditions.IsFalse(hcloudMachine, infrav1.ImageUpToDateCondition) {
+		record.Eventf(hcloudMachine, "ImagePromoted", "Image %s with id %d has been promoted in channel %s",
+			image.Description, image.ID, channel)
+	}
+	if s.scope.IsControlPlane() {
+		conditions.MarkFalse(hcloudMachine, infrav1.ImageUpToDateCondition, infrav1.ImagePromotedReason,
+			clusterv1.ConditionSeverityInfo, "%s", msg)
+		return nil
+	}
+
+	blocker, err := s.replaceMachine(ctx, msg)
+	if err != nil {
+		return err
+	}
+	if blocker != "" {
+		conditions.MarkFalse(hcloudMachine, infrav1.ImageUpToDateCondition, infrav1.ImageRolloutPendingReason,
+			clusterv1.ConditionSeverityInfo, "%s, waiting for %s", msg, blocker)
+		return nil
+	}
+
+	conditions.MarkFalse(hcloudMachine, infrav1.ImageUpToDateCondition, infrav1.ImagePromotedReason,
+		clusterv1.ConditionSeverityInfo, "%s", msg)
+	return nil
+}
+
+// channelImage returns the most recent image of the channel for the architecture, or nil if the channel has no
+// image. The lookup is shared by the machines of the cluster.
+func (s *Service) channelImage(ctx context.Context, channel string, architecture infrav1.Architecture) (*hcloud.Image, error) {
+	image, err := s.sharedLookup(fmt.Sprintf("image-channel/%s/%s", architecture, channel), func() (interface{}, error) {
+		listOpts := hcloud.ImageListOpts{ListOpts: hcloud.ListOpts{
+			LabelSelector: fmt.Sprintf("%s==%s", infrav1.ImageChannelLabel, channel),
+		}}
+		images, err := s.scope.HCloudClient.ListImagesForArchitecture(ctx, listOpts, string(architecture))
+		if err != nil {
+			if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+				conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+				record.Event(s.scope.HCloudMachine,
+					"RateLimitExceeded",
+					"exceeded rate limit with calling hcloud function ListImagesForArchitecture",
+				)
+			}
+			return nil, err
+		}
+		if len(images) == 0 {
+			return (*hcloud.Image)(nil), nil
+		}
+		return newestImage(images), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return image.(*hcloud.Image), nil
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// sharedLookupTTL is the time for which the result of a shared lookup is reused.
+const sharedLookupTTL = time.Minute
+
+// clusterLookups is shared by all reconciles of HCloud machines.
+var clusterLookups = newSharedLookups(sharedLookupTTL)
+
+// sharedLookups caches API lookups that are the same for all servers of a cluster, e.g. the
+// image and the SSH keys. If many machines are scaled up at once, they are resolved only once
+// instead of once per server. Concurrent lookups of the same key wait for the first one.
+type sharedLookups struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*lookupEntry
+}
+
+type lookupEntry struct {
+	mu        sync.Mutex
+	value     interface{}
+	expiresAt time.Time
+}
+
+func newSharedLookups(ttl time.Duration) *sharedLookups {
+	return &sharedLookups{
+		ttl:     ttl,
+		entries: make(map[string]*lookupEntry),
+	}
+}
+
+// get returns the cached value of the key or calls lookup if there is none. Errors are not cached.
+func (c *sharedLookups) get(key string, lookup func() (interface{}, error)) (interface{}, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	// Remove expired entries that are not looked up at the moment
+	for k, e := range c.entries {
+		if k == key || !e.mu.TryLock() {
+			continue
+		}
+		if now.After(e.expiresAt) {
+			delete(c.entries, k)
+		}
+		e.mu.Unlock()
+	}
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &lookupEntry{}
+		c.entries[key] = entry
+	}
+	c.mu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if entry.value != nil && time.Now().Before(entry.expiresAt) {
+		return entry.value, nil
+	}
+
+	value, err := lookup()
+	if err != nil {
+		return nil, err
+	}
+	entry.value = value
+	entry.expiresAt = time.Now().Add(c.ttl)
+	return value, nil
+}
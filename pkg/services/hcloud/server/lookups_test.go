@@ -0,0 +1,81 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+var _ = Describe("sharedLookups", func() {
+	var (
+		mu    sync.Mutex
+		calls int
+	)
+	lookup := func() (interface{}, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		return calls, nil
+	}
+
+	BeforeEach(func() {
+		calls = 0
+	})
+
+	It("looks up a key only once for concurrent callers", func() {
+		lookups := newSharedLookups(time.Minute)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				value, err := lookups.get("cluster/image", lookup)
+				Expect(err).To(Succeed())
+				Expect(value).To(Equal(1))
+			}()
+		}
+		wg.Wait()
+		Expect(calls).To(Equal(1))
+
+		_, err := lookups.get("cluster/sshkeys", lookup)
+		Expect(err).To(Succeed())
+		Expect(calls).To(Equal(2))
+	})
+
+	It("looks up a key again after it expired", func() {
+		lookups := newSharedLookups(time.Millisecond)
+		Expect(lookups.get("cluster/image", lookup)).To(Equal(1))
+		time.Sleep(2 * time.Millisecond)
+		Expect(lookups.get("cluster/image", lookup)).To(Equal(2))
+	})
+
+	It("does not cache errors", func() {
+		lookups := newSharedLookups(time.Minute)
+		_, err := lookups.get("cluster/image", func() (interface{}, error) {
+			return nil, errors.New("rate limit exceeded")
+		})
+		Expect(err).ToNot(Succeed())
+		Expect(lookups.get("cluster/image", lookup)).To(Equal(1))
+	})
+})
@@ -0,0 +1,232 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"github.com/pkg/errors"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// defaultMaintenanceWindow is the duration of maintenance windows that have been announced without end.
+const defaultMaintenanceWindow = 24 * time.Hour
+
+// reconcileMaintenance sets the condition HostMaintenanceFree, which is false while the server is migrated and
+// once a maintenance of its host has been announced with the maintenance window annotation. HCloud offers no API
+// for announced maintenances, only the migration is visible in the status of the server. Workers are replaced one
+// at a time per MachineDeployment, like the rollout of an image channel.
+func (s *Service) reconcileMaintenance(ctx context.Context, server *hcloud.Server) error {
+	hcloudMachine := s.scope.HCloudMachine
+	if server.Status == hcloud.ServerStatusMigrating {
+		conditions.MarkFalse(
+			hcloudMachine,
+			infrav1.HostMaintenanceFreeCondition,
+			infrav1.ServerMigratingReason,
+			clusterv1.ConditionSeverityWarning,
+			"server %d is migrated to another host",
+			server.ID,
+		)
+		return nil
+	}
+
+	value, found := hcloudMachine.Annotations[infrav1.MaintenanceWindowAnnotation]
+	if !found {
+		conditions.Delete(hcloudMachine, infrav1.HostMaintenanceFreeCondition)
+		return nil
+	}
+
+	start, end, err := parseMaintenanceWindow(value)
+	if err != nil {
+		conditions.MarkFalse(
+			hcloudMachine,
+			infrav1.HostMaintenanceFreeCondition,
+			infrav1.MaintenanceWindowInvalidReason,
+			clusterv1.ConditionSeverityWarning,
+			"invalid annotation %s: %s",
+			infrav1.MaintenanceWindowAnnotation, err.Error(),
+		)
+		return nil
+	}
+
+	if end == nil {
+		defaultEnd := start.Add(defaultMaintenanceWindow)
+		end = &defaultEnd
+	}
+	now := time.Now()
+	if now.After(*end) {
+		conditions.Delete(hcloudMachine, infrav1.HostMaintenanceFreeCondition)
+		return nil
+	}
+
+	msg := fmt.Sprintf("maintenance of the host starts at %s", start.UTC().Format(time.RFC3339))
+	if conditions.GetMessage(hcloudMachine, infrav1.HostMaintenanceFreeCondition) != msg {
+		record.Eventf(hcloudMachine, "MaintenanceScheduled", "The %s", msg)
+	}
+	conditions.MarkFalse(
+		hcloudMachine,
+		infrav1.HostMaintenanceFreeCondition,
+		infrav1.MaintenanceScheduledReason,
+		clusterv1.ConditionSeverityInfo,
+		msg,
+	)
+
+	maintenance := s.scope.HetznerCluster.Spec.HCloudMaintenance
+	if maintenance == nil || !maintenance.ReplaceMachines || s.scope.IsControlPlane() || start.Sub(now) > maintenance.MaintenanceLeadTime() {
+		return nil
+	}
+
+	blocker, err := s.replaceMachine(ctx, msg)
+	if err != nil {
+		return err
+	}
+	if blocker != "" {
+		ctrl.LoggerFrom(ctx).V(1).Info("replacement of machine waits for its deployment", "blocker", blocker)
+	}
+	return nil
+}
+
+// replacementLocks serializes the replacements of the machines of a MachineDeployment across reconciles.
+var replacementLocks sync.Map
+
+// replaceMachine requests the replacement of the machine unless another replacement of its MachineDeployment is
+// still in progress. It returns what blocks the replacement, or an empty string if it has been requested. The
+// check and the request hold a lock of the deployment, so that concurrent reconciles see the requests of each
+// other.
+func (s *Service) replaceMachine(ctx context.Context, reason string) (string, error) {
+	machine := s.scope.Machine
+	if deployment, found := machine.Labels[clusterv1.MachineDeploymentLabelName]; found {
+		value, _ := replacementLocks.LoadOrStore(machine.Namespace+"/"+deployment, &sync.Mutex{})
+		mu := value.(*sync.Mutex)
+		mu.Lock()
+		defer mu.Unlock()
+	}
+
+	blocker, err := s.replacementBlocker(ctx)
+	if err != nil || blocker != "" {
+		return blocker, err
+	}
+	return "", s.requestReplacement(ctx, reason)
+}
+
+// requestReplacement marks the Machine as remediated by its owner, so that its MachineSet drains and deletes it
+// and creates a new one.
+func (s *Service) requestReplacement(ctx context.Context, reason string) error {
+	machine := s.scope.Machine
+	if conditions.IsFalse(machine, clusterv1.MachineOwnerRemediatedCondition) {
+		return nil
+	}
+
+	helper, err := patch.NewHelper(machine, s.scope.Client)
+	if err != nil {
+		return errors.Wrap(err, "failed to create patch helper for machine")
+	}
+	conditions.MarkFalse(
+		machine,
+		clusterv1.MachineOwnerRemediatedCondition,
+		clusterv1.WaitingForRemediationReason,
+		clusterv1.ConditionSeverityWarning,
+		reason,
+	)
+	if err := helper.Patch(ctx, machine); err != nil {
+		return errors.Wrap(err, "failed to patch machine")
+	}
+
+	record.Eventf(s.scope.HCloudMachine, "MachineReplacementRequested", "Requested the replacement of the machine, the %s", reason)
+	return nil
+}
+
+// replacementBlocker describes what blocks the replacement of the machine, so that the machines of a
+// MachineDeployment are replaced one at a time: another machine of the deployment that is deleted, replaced or
+// not ready, or a deployment with fewer machines than replicas, whose replacement has not been created yet. It is
+// empty if the machine can be replaced, which is always the case for machines that do not belong to a
+// MachineDeployment. The machines are read from the API server, as the cache might not contain the latest
+// replacement yet.
+func (s *Service) replacementBlocker(ctx context.Context) (string, error) {
+	machine := s.scope.Machine
+	deployment, found := machine.Labels[clusterv1.MachineDeploymentLabelName]
+	if !found {
+		return "", nil
+	}
+
+	var machines clusterv1.MachineList
+	if err := s.scope.APIReader.List(ctx, &machines, client.InNamespace(machine.Namespace), client.MatchingLabels{
+		clusterv1.ClusterLabelName:           machine.Spec.ClusterName,
+		clusterv1.MachineDeploymentLabelName: deployment,
+	}); err != nil {
+		return "", errors.Wrap(err, "failed to list machines of machine deployment")
+	}
+
+	for i := range machines.Items {
+		other := &machines.Items[i]
+		if other.Name == machine.Name {
+			continue
+		}
+		if !other.DeletionTimestamp.IsZero() ||
+			conditions.IsFalse(other, clusterv1.MachineOwnerRemediatedCondition) ||
+			!conditions.IsTrue(other, clusterv1.ReadyCondition) {
+			return fmt.Sprintf("machine %s", other.Name), nil
+		}
+	}
+
+	var machineDeployment clusterv1.MachineDeployment
+	key := client.ObjectKey{Namespace: machine.Namespace, Name: deployment}
+	if err := s.scope.APIReader.Get(ctx, key, &machineDeployment); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", errors.Wrap(err, "failed to get machine deployment")
+	}
+	if replicas := machineDeployment.Spec.Replicas; replicas != nil && len(machines.Items) < int(*replicas) {
+		return fmt.Sprintf("new machine of machine deployment %s", deployment), nil
+	}
+	return "", nil
+}
+
+// parseMaintenanceWindow parses the value of the maintenance window annotation, the start in RFC 3339 format,
+// optionally followed by "/" and the end.
+func parseMaintenanceWindow(value string) (start time.Time, end *time.Time, err error) {
+	startValue, endValue, hasEnd := strings.Cut(value, "/")
+	start, err = time.Parse(time.RFC3339, startValue)
+	if err != nil {
+		return time.Time{}, nil, errors.Wrap(err, "failed to parse start")
+	}
+	if !hasEnd {
+		return start, nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, endValue)
+	if err != nil {
+		return time.Time{}, nil, errors.Wrap(err, "failed to parse end")
+	}
+	if t.Before(start) {
+		return time.Time{}, nil, errors.New("end is before start")
+	}
+	return start, &t, nil
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"github.com/pkg/errors"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/utils"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/record"
+)
+
+// maxServersPerSpreadPlacementGroup is the number of servers that a spread placement group in HCloud holds.
+const maxServersPerSpreadPlacementGroup = 10
+
+// automaticPlacementGroupName returns the name of the automatic placement group of a group of machines.
+func automaticPlacementGroupName(clusterName, machineGroup string) string {
+	return fmt.Sprintf("%s-auto-%s", clusterName, machineGroup)
+}
+
+// automaticPlacementGroup returns the spread placement group of the group of the machine, which is the value of
+// the configured label of the Machine. The placement group is created if it does not exist.
+func (s *Service) automaticPlacementGroup(ctx context.Context) (*hcloud.PlacementGroup, error) {
+	labelKey := s.scope.HCloudMachine.Spec.AutomaticPlacementGroup.LabelKey
+	if labelKey == "" {
+		labelKey = clusterv1.MachineDeploymentLabelName
+	}
+	machineGroup := s.scope.Machine.Labels[labelKey]
+	if machineGroup == "" {
+		msg := fmt.Sprintf("machine has no label %s to determine its automatic placement group", labelKey)
+		conditions.MarkFalse(s.scope.HCloudMachine,
+			infrav1.InstanceReadyCondition,
+			infrav1.InstanceHasNoPlacementGroupLabelReason,
+			clusterv1.ConditionSeverityError,
+			msg,
+		)
+		return nil, errors.New(msg)
+	}
+
+	labels := map[string]string{
+		infrav1.ClusterTagKey(s.scope.HetznerCluster.Name): string(infrav1.ResourceLifecycleOwned),
+		infrav1.PlacementGroupMachineGroupTagKey:           machineGroup,
+	}
+	placementGroups, err := s.scope.HCloudClient.ListPlacementGroups(ctx, hcloud.PlacementGroupListOpts{
+		ListOpts: hcloud.ListOpts{LabelSelector: utils.LabelsToLabelSelector(labels)},
+	})
+	if err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HCloudMachine,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function ListPlacementGroups",
+			)
+		}
+		return nil, errors.Wrap(err, "failed to list placement groups")
+	}
+	if len(placementGroups) > 0 {
+		return placementGroups[0], nil
+	}
+
+	name := automaticPlacementGroupName(s.scope.HetznerCluster.Name, machineGroup)
+	result, err := s.scope.HCloudClient.CreatePlacementGroup(ctx, hcloud.PlacementGroupCreateOpts{
+		Name:   name,
+		Type:   hcloud.PlacementGroupTypeSpread,
+		Labels: labels,
+	})
+	if err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HCloudMachine,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function CreatePlacementGroup",
+			)
+		}
+		return nil, errors.Wrapf(err, "failed to create placement group %s", name)
+	}
+	record.Eventf(s.scope.HCloudMachine, "PlacementGroupCreated", "Created automatic placement group %s", name)
+	return result.PlacementGroup, nil
+}
+
+// selectedPlacementGroup returns the placement group with the fewest servers of the placement groups that match
+// the placement group selector of the machine. Spread placement groups that are full are skipped.
+func (s *Service) selectedPlacementGroup(ctx context.Context) (*hcloud.PlacementGroup, error) {
+	selector, err := utils.HCloudLabelSelector(s.scope.HCloudMachine.Spec.PlacementGroupSelector)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert placement group selector")
+	}
+
+	placementGroups, err := s.scope.HCloudClient.ListPlacementGroups(ctx, hcloud.PlacementGroupListOpts{
+		ListOpts: hcloud.ListOpts{LabelSelector: selector},
+	})
+	if err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HCloudMachine,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function ListPlacementGroups",
+			)
+		}
+		return nil, errors.Wrap(err, "failed to list placement groups")
+	}
+
+	var selected *hcloud.PlacementGroup
+	for _, placementGroup := range placementGroups {
+		if placementGroup.Type == hcloud.PlacementGroupTypeSpread && len(placementGroup.Servers) >= maxServersPerSpreadPlacementGroup {
+			continue
+		}
+		if selected == nil || len(placementGroup.Servers) < len(selected.Servers) ||
+			(len(placementGroup.Servers) == len(selected.Servers) && placementGroup.ID < selected.ID) {
+			selected = placementGroup
+		}
+	}
+
+	if selected == nil {
+		msg := fmt.Sprintf("no placement group with room left matches the selector %q", selector)
+		conditions.MarkFalse(s.scope.HCloudMachine,
+			infrav1.InstanceReadyCondition,
+			infrav1.InstanceHasNoMatchingPlacementGroupReason,
+			clusterv1.ConditionSeverityError,
+			msg,
+		)
+		record.Warnf(s.scope.HCloudMachine, "NoMatchingPlacementGroup", "Cannot create server: %s", msg)
+		return nil, errors.New(msg)
+	}
+	return selected, nil
+}
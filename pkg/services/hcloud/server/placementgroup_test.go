@@ -0,0 +1,144 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	hcloudclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client"
+	fakeclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+)
+
+var _ = Describe("automaticPlacementGroup", func() {
+	var service *Service
+	var clusterCount int
+
+	BeforeEach(func() {
+		clusterCount++
+		hcloudMachine := &infrav1.HCloudMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: "placement-group-machine", Namespace: "default"},
+			Spec: infrav1.HCloudMachineSpec{
+				Type:                    "cpx31",
+				AutomaticPlacementGroup: &infrav1.AutomaticPlacementGroupSpec{},
+			},
+		}
+		service = newTestService(hcloudMachine, fakeclient.NewHCloudClientFactory().NewClient(""))
+		service.scope.Machine = &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{clusterv1.MachineDeploymentLabelName: "md-0", "pool": "workers"},
+		}}
+		service.scope.HetznerCluster = &infrav1.HetznerCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("placement-group-cluster-%d", clusterCount)},
+		}
+	})
+
+	It("creates a spread placement group for the MachineDeployment and reuses it", func() {
+		created, err := service.automaticPlacementGroup(context.Background())
+		Expect(err).To(Succeed())
+		Expect(created.Name).To(Equal(service.scope.HetznerCluster.Name + "-auto-md-0"))
+		Expect(string(created.Type)).To(Equal("spread"))
+		Expect(created.Labels).To(HaveKeyWithValue(infrav1.PlacementGroupMachineGroupTagKey, "md-0"))
+
+		found, err := service.automaticPlacementGroup(context.Background())
+		Expect(err).To(Succeed())
+		Expect(found.ID).To(Equal(created.ID))
+	})
+
+	It("groups the machines by the configured label", func() {
+		service.scope.HCloudMachine.Spec.AutomaticPlacementGroup.LabelKey = "pool"
+
+		placementGroup, err := service.automaticPlacementGroup(context.Background())
+		Expect(err).To(Succeed())
+		Expect(placementGroup.Labels).To(HaveKeyWithValue(infrav1.PlacementGroupMachineGroupTagKey, "workers"))
+	})
+
+	It("fails if the Machine does not have the label", func() {
+		service.scope.Machine.Labels = nil
+
+		_, err := service.automaticPlacementGroup(context.Background())
+		Expect(err).ToNot(Succeed())
+		condition := conditions.Get(service.scope.HCloudMachine, infrav1.InstanceReadyCondition)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Reason).To(Equal(infrav1.InstanceHasNoPlacementGroupLabelReason))
+	})
+})
+
+var _ = Describe("selectedPlacementGroup", func() {
+	var service *Service
+	var client hcloudclient.Client
+	var pool string
+	var poolCount int
+
+	BeforeEach(func() {
+		poolCount++
+		pool = fmt.Sprintf("approved-%d", poolCount)
+		hcloudMachine := &infrav1.HCloudMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: "placement-group-machine", Namespace: "default"},
+			Spec: infrav1.HCloudMachineSpec{
+				Type: "cpx31",
+				PlacementGroupSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"pool": pool},
+				},
+			},
+		}
+		client = fakeclient.NewHCloudClientFactory().NewClient("")
+		service = newTestService(hcloudMachine, client)
+	})
+
+	createPlacementGroup := func(name string, servers int) *hcloud.PlacementGroup {
+		result, err := client.CreatePlacementGroup(context.Background(), hcloud.PlacementGroupCreateOpts{
+			Name:   fmt.Sprintf("%s-%s", pool, name),
+			Type:   hcloud.PlacementGroupTypeSpread,
+			Labels: map[string]string{"pool": pool},
+		})
+		Expect(err).To(Succeed())
+		for i := 0; i < servers; i++ {
+			result.PlacementGroup.Servers = append(result.PlacementGroup.Servers, i+1)
+		}
+		return result.PlacementGroup
+	}
+
+	It("selects the matching placement group with the fewest servers", func() {
+		createPlacementGroup("a", 3)
+		fewest := createPlacementGroup("b", 1)
+		_, err := client.CreatePlacementGroup(context.Background(), hcloud.PlacementGroupCreateOpts{
+			Name: pool + "-other",
+			Type: hcloud.PlacementGroupTypeSpread,
+		})
+		Expect(err).To(Succeed())
+
+		placementGroup, err := service.selectedPlacementGroup(context.Background())
+		Expect(err).To(Succeed())
+		Expect(placementGroup.ID).To(Equal(fewest.ID))
+	})
+
+	It("skips full spread placement groups", func() {
+		createPlacementGroup("full", maxServersPerSpreadPlacementGroup)
+
+		_, err := service.selectedPlacementGroup(context.Background())
+		Expect(err).ToNot(Succeed())
+		Expect(conditions.GetReason(service.scope.HCloudMachine, infrav1.InstanceReadyCondition)).
+			To(Equal(infrav1.InstanceHasNoMatchingPlacementGroupReason))
+	})
+})
@@ -20,26 +20,44 @@ package server
 import (
 	"context"
 	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/hetznercloud/hcloud-go/hcloud"
 	"github.com/pkg/errors"
 	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/metrics"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/propagation"
 	"github.com/syself/cluster-api-provider-hetzner/pkg/scope"
+	hcloudclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/floatingip"
+	hcloudnetwork "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/network"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/quota"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/userdata"
 	"github.com/syself/cluster-api-provider-hetzner/pkg/utils"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	capierrors "sigs.k8s.io/cluster-api/errors"
 	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/cluster-api/util/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 const maxShutDownTime = 2 * time.Minute
 const serverOffTimeout = 10 * time.Minute
 
+// serverTypeChangeBackoff is the time after a failed change of the server type until the server is shut down
+// again to retry the change.
+const serverTypeChangeBackoff = 15 * time.Minute
+
 // Service defines struct with machine scope to reconcile HCloud machines.
 type Service struct {
 	scope *scope.MachineScope
@@ -102,11 +120,103 @@ func (s *Service) Reconcile(ctx context.Context) (_ *ctrl.Result, err error) {
 			"Created new server with id %d",
 			server.ID,
 		)
+		// The bootstrap data is passed to the server as user data when it is created
+		now := metav1.Now()
+		s.scope.HCloudMachine.Status.Milestones = &infrav1.ProvisioningMilestones{
+			InfrastructureCreated:  &now,
+			BootstrapDataDelivered: &now,
+		}
 	}
 
 	c := s.scope.HCloudMachine.Status.Conditions.DeepCopy()
+	appliedConfiguration := s.scope.HCloudMachine.Status.AppliedConfiguration
+	kubeletServingCertificate := s.scope.HCloudMachine.Status.KubeletServingCertificate
+	volumes := s.scope.HCloudMachine.Status.Volumes
+	milestones := s.scope.HCloudMachine.Status.Milestones
+	isoDetached := s.scope.HCloudMachine.Status.ISODetached
+	rescueMode := s.scope.HCloudMachine.Status.RescueMode
 	s.scope.HCloudMachine.Status = setStatusFromAPI(server)
 	s.scope.HCloudMachine.Status.Conditions = c
+	s.scope.HCloudMachine.Status.AppliedConfiguration = appliedConfiguration
+	s.scope.HCloudMachine.Status.KubeletServingCertificate = kubeletServingCertificate
+	s.scope.HCloudMachine.Status.Volumes = volumes
+	s.scope.HCloudMachine.Status.Milestones = milestones
+	s.scope.HCloudMachine.Status.ISODetached = isoDetached
+	s.scope.HCloudMachine.Status.RescueMode = rescueMode
+
+	// Servers that are debugged in the rescue system are not reconciled
+	res, err := s.reconcileRescueMode(ctx, server)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to reconcile rescue mode")
+	}
+	if res != nil {
+		return res, nil
+	}
+
+	metrics.ObserveNodeReady(s.scope.Machine, s.scope.HCloudMachine, "HCloudMachine", string(s.scope.HCloudMachine.Spec.Type), milestones)
+
+	// Keep the labels of the server in sync with the labels propagated from the Machine
+	if err := s.reconcileLabels(ctx, server); err != nil {
+		return nil, errors.Wrap(err, "failed to reconcile labels")
+	}
+
+	if err := s.reconcileConsoleUser(ctx); err != nil {
+		return nil, errors.Wrap(err, "failed to reconcile console user")
+	}
+
+	if err := s.reconcileBackups(ctx, server); err != nil {
+		return nil, errors.Wrap(err, "failed to reconcile backups")
+	}
+
+	if err := s.reconcileProtection(ctx, server); err != nil {
+		return nil, errors.Wrap(err, "failed to reconcile protection")
+	}
+
+	if err := s.reconcileMaintenance(ctx, server); err != nil {
+		return nil, errors.Wrap(err, "failed to reconcile maintenance")
+	}
+
+	if err := s.reconcileImageChannel(ctx); err != nil {
+		return nil, errors.Wrap(err, "failed to reconcile image channel")
+	}
+
+	// Servers in a subnet are created without network and are attached before they are powered on
+	subnet, err := s.machineSubnet()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get subnet of machine")
+	}
+	if subnet != "" {
+		if err := s.reconcileNetworkAttachment(ctx, server); err != nil {
+			return nil, errors.Wrap(err, "failed to reconcile network attachement")
+		}
+	}
+
+	// Boot the server from the ISO once
+	res, err = s.reconcileISO(ctx, server)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to reconcile ISO")
+	}
+	if res != nil {
+		return res, nil
+	}
+
+	// Enable or disable the public IP families if the spec has changed
+	res, err = s.reconcilePublicNetwork(ctx, server)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to reconcile public network")
+	}
+	if res != nil {
+		return res, nil
+	}
+
+	// Resize the server if the type has been changed
+	res, err = s.reconcileServerType(ctx, server)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to reconcile server type")
+	}
+	if res != nil {
+		return res, nil
+	}
 
 	switch server.Status {
 	case hcloud.ServerStatusOff:
@@ -143,6 +253,14 @@ func (s *Service) Reconcile(ctx context.Context) (_ *ctrl.Result, err error) {
 		return nil, errors.Wrap(err, "failed to reconcile load balancer attachement")
 	}
 
+	// the first running control plane gets the floating IP, as it is the endpoint for kubeadm init
+	if status := s.scope.HetznerCluster.Status.ControlPlaneFloatingIP; s.scope.HetznerCluster.Spec.ControlPlaneFloatingIP != nil &&
+		status != nil && status.ServerID == 0 {
+		if err := floatingip.NewService(&s.scope.ClusterScope).Assign(ctx, 0); err != nil {
+			return nil, errors.Wrap(err, "failed to assign floating IP of the control plane")
+		}
+	}
+
 	s.scope.HCloudMachine.Spec.ProviderID = &providerID
 	s.scope.HCloudMachine.Status.Ready = true
 	conditions.MarkTrue(s.scope.HCloudMachine, infrav1.InstanceReadyCondition)
@@ -205,6 +323,21 @@ func (s *Service) reconcileNetworkAttachment(ctx context.Context, server *hcloud
 			return nil
 		}
 	}
+	// The network status is updated by the cluster controller, the server might be attached already
+	for _, privateNet := range server.PrivateNet {
+		if privateNet.Network != nil && privateNet.Network.ID == s.scope.HetznerCluster.Status.Network.ID {
+			return nil
+		}
+	}
+
+	subnet, err := s.machineSubnet()
+	if err != nil {
+		return err
+	}
+	// HCloud chooses the subnet of a server that is attached without IP, so it gets a free IP of the subnet
+	if subnet != "" {
+		return s.attachServerWithFreeIP(ctx, server, subnet, nil)
+	}
 
 	// Attach server to network
 	if _, err := s.scope.HCloudClient.AttachServerToNetwork(ctx, server, hcloud.ServerAttachToNetworkOpts{
@@ -223,14 +356,252 @@ func (s *Service) reconcileNetworkAttachment(ctx context.Context, server *hcloud
 		if hcloud.IsError(err, hcloud.ErrorCodeServerAlreadyAttached) {
 			return nil
 		}
+		// Retrying the same request fails again if the IP chosen by HCloud is taken
+		if hcloud.IsError(err, hcloud.ErrorCodeIPNotAvailable) || hcloud.IsError(err, hcloud.ErrorCodeConflict) {
+			return s.attachServerWithFreeIP(ctx, server, "", err)
+		}
+		if hcloud.IsError(err, hcloud.ErrorCodeNoSubnetAvailable) {
+			s.markSubnetExhausted()
+		}
 		return errors.Wrap(err, "failed to attach server to network")
 	}
 
 	return nil
 }
 
+// attachServerWithFreeIP attaches the server to the network with the next IP of the subnet that is not
+// used by servers or load balancers. Without cidrBlock, the default subnet of the network is used. attachErr
+// is the error of the attachment without IP, if there was one.
+func (s *Service) attachServerWithFreeIP(ctx context.Context, server *hcloud.Server, cidrBlock string, attachErr error) error {
+	networkID := s.scope.HetznerCluster.Status.Network.ID
+
+	network, err := s.scope.HCloudClient.GetNetwork(ctx, strconv.Itoa(networkID))
+	if err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HCloudMachine,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function GetNetwork",
+			)
+		}
+		return errors.Wrap(err, "failed to get network")
+	}
+	var subnet *hcloud.NetworkSubnet
+	if network != nil && cidrBlock != "" {
+		subnet = hcloudnetwork.Subnet(network, cidrBlock)
+	} else if network != nil {
+		subnet = hcloudnetwork.DefaultSubnet(network, s.scope.HetznerCluster.Spec.HCloudNetwork)
+	}
+	if subnet == nil {
+		return fmt.Errorf("network %d with a subnet not found", networkID)
+	}
+
+	usedIPs, err := s.usedNetworkIPs(ctx, networkID)
+	if err != nil {
+		return err
+	}
+
+	ip := nextFreeIP(network.IPRange, subnet.IPRange, usedIPs)
+	if ip == nil {
+		s.markSubnetExhausted()
+		if attachErr == nil {
+			return fmt.Errorf("failed to attach server to network: no free IP in subnet %s", subnet.IPRange)
+		}
+		return errors.Wrap(attachErr, "failed to attach server to network: no free IP in subnet")
+	}
+
+	if _, err := s.scope.HCloudClient.AttachServerToNetwork(ctx, server, hcloud.ServerAttachToNetworkOpts{
+		Network: &hcloud.Network{ID: networkID},
+		IP:      ip,
+	}); err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HCloudMachine,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function AttachServerToNetwork",
+			)
+		}
+		// Check if network status is old and server is in fact already attached
+		if hcloud.IsError(err, hcloud.ErrorCodeServerAlreadyAttached) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to attach server to network with IP %s", ip)
+	}
+
+	if attachErr == nil {
+		record.Eventf(s.scope.HCloudMachine, "AttachServerToSubnet", "Attached server to subnet %s with IP %s", subnet.IPRange, ip)
+		return nil
+	}
+	record.Eventf(s.scope.HCloudMachine,
+		"AttachServerToNetworkWithFreeIP",
+		"Attached server to network with IP %s, as the attachment failed: %s",
+		ip, attachErr,
+	)
+	return nil
+}
+
+// machineSubnet returns the IP range of the subnet to which the server is attached. It is empty if HCloud chooses
+// the subnet, which is the case if the network has no additional subnets or is an existing network without
+// selected subnet and the machine does not select a subnet.
+func (s *Service) machineSubnet() (string, error) {
+	spec := s.scope.HetznerCluster.Spec.HCloudNetwork
+	name := s.scope.HCloudMachine.Spec.Subnet
+	if name == "" {
+		if spec.ExistingNetwork != nil {
+			return spec.ExistingNetwork.SubnetCIDRBlock, nil
+		}
+		if len(spec.Subnets) == 0 {
+			return "", nil
+		}
+		return spec.SubnetCIDRBlock, nil
+	}
+
+	if spec.Enabled {
+		for _, subnet := range spec.Subnets {
+			if subnet.Name == name {
+				return subnet.CIDRBlock, nil
+			}
+		}
+	}
+	msg := fmt.Sprintf("subnet %q is not a subnet of the network of the cluster", name)
+	conditions.MarkFalse(
+		s.scope.HCloudMachine,
+		infrav1.InstanceReadyCondition,
+		infrav1.SubnetNotFoundReason,
+		clusterv1.ConditionSeverityError,
+		msg,
+	)
+	record.Warnf(s.scope.HCloudMachine, "SubnetNotFound", msg)
+	return "", errors.New(msg)
+}
+
+// usedNetworkIPs returns the IPs of the network that are used by servers and load balancers.
+func (s *Service) usedNetworkIPs(ctx context.Context, networkID int) (map[string]struct{}, error) {
+	usedIPs := make(map[string]struct{})
+
+	servers, err := s.scope.HCloudClient.ListServers(ctx, hcloud.ServerListOpts{})
+	if err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HCloudMachine,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function ListServers",
+			)
+		}
+		return nil, errors.Wrap(err, "failed to list servers")
+	}
+	for _, server := range servers {
+		for _, privateNet := range server.PrivateNet {
+			if privateNet.Network == nil || privateNet.Network.ID != networkID {
+				continue
+			}
+			usedIPs[privateNet.IP.String()] = struct{}{}
+			for _, alias := range privateNet.Aliases {
+				usedIPs[alias.String()] = struct{}{}
+			}
+		}
+	}
+
+	loadBalancers, err := s.scope.HCloudClient.ListLoadBalancers(ctx, hcloud.LoadBalancerListOpts{})
+	if err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HCloudMachine,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function ListLoadBalancers",
+			)
+		}
+		return nil, errors.Wrap(err, "failed to list load balancers")
+	}
+	for _, lb := range loadBalancers {
+		for _, privateNet := range lb.PrivateNet {
+			if privateNet.Network != nil && privateNet.Network.ID == networkID {
+				usedIPs[privateNet.IP.String()] = struct{}{}
+			}
+		}
+	}
+	return usedIPs, nil
+}
+
+// markSubnetExhausted reports that the server cannot be attached to the network, as its subnet has no free IPs.
+func (s *Service) markSubnetExhausted() {
+	record.Warnf(s.scope.HCloudMachine,
+		"NetworkSubnetExhausted",
+		"Failed to attach server to network %d: no free IP in subnet",
+		s.scope.HetznerCluster.Status.Network.ID,
+	)
+	conditions.MarkFalse(
+		s.scope.HCloudMachine,
+		infrav1.InstanceReadyCondition,
+		infrav1.SubnetExhaustedReason,
+		clusterv1.ConditionSeverityError,
+		"no free IP in subnet of network %d",
+		s.scope.HetznerCluster.Status.Network.ID,
+	)
+}
+
+// nextFreeIP returns the first IPv4 address of the subnet that is not used. The network and broadcast
+// addresses of the subnet and the gateway, the first IP of the network, are never used.
+func nextFreeIP(networkRange, subnet *net.IPNet, usedIPs map[string]struct{}) net.IP {
+	start := subnet.IP.Mask(subnet.Mask).To4()
+	if start == nil {
+		return nil
+	}
+	var gateway net.IP
+	if networkRange != nil {
+		gateway = nextIP(networkRange.IP.Mask(networkRange.Mask).To4())
+	}
+
+	for ip := nextIP(start); subnet.Contains(ip); ip = nextIP(ip) {
+		if !subnet.Contains(nextIP(ip)) {
+			// broadcast address
+			break
+		}
+		if ip.Equal(gateway) {
+			continue
+		}
+		if _, used := usedIPs[ip.String()]; !used {
+			return ip
+		}
+	}
+	return nil
+}
+
+func nextIP(ip net.IP) net.IP {
+	if ip == nil {
+		return nil
+	}
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			break
+		}
+	}
+	return next
+}
+
 func (s *Service) createServer(ctx context.Context, failureDomain string) (*hcloud.Server, error) {
 	log := ctrl.LoggerFrom(ctx)
+
+	if !s.scope.HetznerCluster.Spec.PlacementConstraints.IsLocationAllowed(infrav1.Region(failureDomain)) {
+		msg := fmt.Sprintf("location %s is not allowed by the placement constraints of the cluster", failureDomain)
+		conditions.MarkFalse(
+			s.scope.HCloudMachine,
+			infrav1.InstanceReadyCondition,
+			infrav1.LocationNotAllowedReason,
+			clusterv1.ConditionSeverityError,
+			msg,
+		)
+		record.Warnf(s.scope.HCloudMachine, "LocationNotAllowed", "Not creating server: %s", msg)
+		return nil, errors.New(msg)
+	}
+
+	if err := quota.NewService(&s.scope.ClusterScope).Check(ctx, s.scope.HCloudMachine, quota.Servers); err != nil {
+		return nil, err
+	}
+
 	// get userData
 	userData, err := s.scope.GetRawBootstrapData(ctx)
 	if err != nil {
@@ -242,26 +613,114 @@ func (s *Service) createServer(ctx context.Context, failureDomain string) (*hclo
 		return nil, fmt.Errorf("failed to get raw bootstrap data: %s", err)
 	}
 
-	image, err := s.getServerImage(ctx)
+	userData, err = userdata.AddResolverConfig(userData, s.scope.DNS())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to add resolver config to user data")
+	}
+
+	caBundle, err := s.scope.TrustedCABundle(ctx)
+	if err != nil {
+		record.Warnf(s.scope.HCloudMachine, "FailedGetTrustedCABundle", err.Error())
+		return nil, errors.Wrap(err, "failed to get trusted CA bundle")
+	}
+	userData, err = userdata.AddCABundle(userData, caBundle)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to add trusted CA bundle to user data")
+	}
+
+	consoleUser, err := s.scope.ConsoleUser(ctx)
+	if err != nil {
+		record.Warnf(s.scope.HCloudMachine, "FailedGetConsoleUser", err.Error())
+		return nil, errors.Wrap(err, "failed to get console user")
+	}
+	if consoleUser != nil {
+		userData, err = userdata.AddConsoleUser(userData, consoleUser.Name, consoleUser.PasswordHash)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to add console user to user data")
+		}
+	}
+
+	nodeProfile, err := s.scope.NodeProfile(ctx)
+	if err != nil {
+		record.Warnf(s.scope.HCloudMachine, "FailedGetNodeProfile", err.Error())
+		return nil, errors.Wrap(err, "failed to get node profile")
+	}
+	userData, err = userdata.AddNodeProfile(userData, nodeProfile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to add node profile to user data")
+	}
+
+	cloudInitParts, err := s.scope.CloudInitParts(ctx)
+	if err != nil {
+		record.Warnf(s.scope.HCloudMachine, "FailedGetCloudInitParts", err.Error())
+		return nil, errors.Wrap(err, "failed to get cloud-init parts")
+	}
+	userData, err = userdata.AddCloudInitParts(userData, cloudInitParts)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to add cloud-init parts to user data")
+	}
+
+	serverType, err := s.serverType(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get server type")
+	}
+
+	image, err := s.getServerImage(ctx, serverType.Architecture())
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get server image")
 	}
 
+	// the architecture file is optional, so user data like Ignition that cannot be combined is used as it is
+	if withArch, err := userdata.AddArchitecture(userData, serverType.Architecture().GoArch()); err == nil {
+		userData = withArch
+	} else if !errors.Is(err, userdata.ErrUnsupportedUserData) {
+		return nil, errors.Wrap(err, "failed to add architecture to user data")
+	}
+
+	// volumes are bound to a location, so they are created before the server in its failure domain
+	volumes, err := s.ensureVolumes(ctx, failureDomain)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to ensure volumes")
+	}
+	userData, err = userdata.AddVolumeMounts(userData, s.volumeMounts(volumes))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to add volume mounts to user data")
+	}
+
+	if s.privateOnly() {
+		gateway, err := s.privateNetworkGateway()
+		if err != nil {
+			return nil, err
+		}
+		userData, err = userdata.AddDefaultRoute(userData, gateway)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to add default route to user data")
+		}
+	}
+
 	automount := false
-	startAfterCreate := true
+	subnet, err := s.machineSubnet()
+	if err != nil {
+		return nil, err
+	}
+	if subnet != "" && s.scope.HetznerCluster.Status.Network == nil {
+		return nil, errors.New("network of the cluster is not ready to attach the server to its subnet")
+	}
+
+	// servers with an ISO are started after it has been attached, servers in a subnet after they have been
+	// attached to it
+	startAfterCreate := s.scope.HCloudMachine.Spec.ISO == "" && subnet == ""
 	opts := hcloud.ServerCreateOpts{
 		Name:   s.scope.Name(),
-		Labels: createLabels(s.scope.HetznerCluster.Name, s.scope.Name(), s.scope.IsControlPlane()),
+		Labels: s.serverLabels(),
 		Image:  image,
-		Location: &hcloud.Location{
-			Name: failureDomain,
-		},
 		ServerType: &hcloud.ServerType{
-			Name: string(s.scope.HCloudMachine.Spec.Type),
+			Name: string(serverType),
 		},
 		Automount:        &automount,
 		StartAfterCreate: &startAfterCreate,
 		UserData:         string(userData),
+		Volumes:          volumes,
 		PublicNet: &hcloud.ServerCreatePublicNet{
 			EnableIPv4: s.scope.HCloudMachine.Spec.PublicNetwork.EnableIPv4,
 			EnableIPv6: s.scope.HCloudMachine.Spec.PublicNetwork.EnableIPv6,
@@ -291,44 +750,73 @@ func (s *Service) createServer(ctx context.Context, failureDomain string) (*hclo
 			return nil, errors.New("failed to find server's placement group")
 		}
 	}
-
-	sshKeySpecs := s.scope.HCloudMachine.Spec.SSHKeys
-	if len(sshKeySpecs) == 0 {
-		sshKeySpecs = s.scope.HetznerCluster.Spec.SSHKeys.HCloud
+	if s.scope.HCloudMachine.Spec.AutomaticPlacementGroup != nil {
+		placementGroup, err := s.automaticPlacementGroup(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get automatic placement group")
+		}
+		opts.PlacementGroup = placementGroup
 	}
-	sshKeysAPI, err := s.scope.HCloudClient.ListSSHKeys(ctx, hcloud.SSHKeyListOpts{})
-	if err != nil {
-		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
-			conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
-			record.Event(s.scope.HCloudMachine,
-				"RateLimitExceeded",
-				"exceeded rate limit with calling hcloud function ListSSHKeys",
-			)
+	if s.scope.HCloudMachine.Spec.PlacementGroupSelector != nil {
+		placementGroup, err := s.selectedPlacementGroup(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get selected placement group")
 		}
-		return nil, errors.Wrap(err, "failed listing ssh heys from hcloud")
+		opts.PlacementGroup = placementGroup
 	}
 
-	sshKeys, err := getSSHKeys(sshKeysAPI, sshKeySpecs)
+	sshKeys, err := s.serverSSHKeys(ctx)
 	if err != nil {
-		return nil, errors.Wrap(err, "error with ssh keys")
+		return nil, err
 	}
 
 	opts.SSHKeys = sshKeys
 
 	// set up network if available
-	if net := s.scope.HetznerCluster.Status.Network; net != nil {
+	if net := s.scope.HetznerCluster.Status.Network; net != nil && subnet == "" {
 		opts.Networks = []*hcloud.Network{{
 			ID: net.ID,
 		}}
 	}
 
 	// if no private network exists there must be an IPv4 for the load balancer.
-	if !s.scope.HetznerCluster.Spec.HCloudNetwork.Enabled {
+	if s.publicIPv4Required() {
 		opts.PublicNet.EnableIPv4 = true
 	}
 
-	// Create the server
-	res, err := s.scope.HCloudClient.CreateServer(ctx, opts)
+	// assign primary IPs if the machine should get stable public addresses
+	if s.scope.HCloudMachine.Spec.PublicNetwork.PrimaryIPSelector != nil {
+		if err := s.assignPrimaryIPs(ctx, opts.PublicNet, failureDomain); err != nil {
+			return nil, errors.Wrap(err, "failed to assign primary IPs")
+		}
+	}
+	if err := s.assignExistingPrimaryIPs(ctx, opts.PublicNet, failureDomain); err != nil {
+		return nil, errors.Wrap(err, "failed to assign existing primary IPs")
+	}
+
+	// Create the server, falling back to other failure domains if a location ran out of capacity
+	var res hcloud.ServerCreateResult
+	locations, err := s.serverLocations(ctx, failureDomain)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get server locations")
+	}
+	for _, location := range locations {
+		opts.Location = &hcloud.Location{Name: location}
+		res, err = s.scope.HCloudClient.CreateServer(ctx, opts)
+		if !hcloud.IsError(err, hcloud.ErrorCodeResourceUnavailable) {
+			break
+		}
+		record.Warnf(s.scope.HCloudMachine,
+			"LocationCapacityExhausted",
+			"No capacity for server type %s in location %s: %s",
+			s.scope.HCloudMachine.Spec.Type,
+			location,
+			err,
+		)
+		if err := s.recordExhaustedLocation(ctx, location); err != nil {
+			return nil, errors.Wrap(err, "failed to record exhausted location")
+		}
+	}
 	if err != nil {
 		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
 			conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
@@ -337,6 +825,7 @@ func (s *Service) createServer(ctx context.Context, failureDomain string) (*hclo
 				"exceeded rate limit with calling hcloud function CreateServer",
 			)
 		}
+		quota.RecordLimitError(s.scope.HCloudMachine, quota.Servers, err)
 		record.Warnf(s.scope.HCloudMachine,
 			"FailedCreateHCloudServer",
 			"Failed to create HCloud server %s: %s",
@@ -346,45 +835,319 @@ func (s *Service) createServer(ctx context.Context, failureDomain string) (*hclo
 		return nil, fmt.Errorf("error while creating HCloud server %s: %s", s.scope.HCloudMachine.Name, err)
 	}
 
+	conditions.Delete(s.scope.HCloudMachine, infrav1.QuotaExceeded)
+	s.scope.HCloudMachine.Status.AppliedConfiguration = appliedConfiguration(image, userData, sshKeys)
+	s.scope.HCloudMachine.Status.AppliedConfiguration.ConsoleUser = consoleUser.Applied()
+	s.scope.HCloudMachine.Status.Volumes = s.volumeStatus(volumes)
+	s.scope.HCloudMachine.Status.AppliedLabels = labelKeys(opts.Labels)
+	s.scope.HCloudMachine.Status.AppliedPublicNetwork = &infrav1.AppliedPublicNetwork{
+		EnableIPv4: opts.PublicNet.EnableIPv4,
+		EnableIPv6: opts.PublicNet.EnableIPv6,
+	}
 	return res.Server, nil
 }
 
-func (s *Service) getServerImage(ctx context.Context) (*hcloud.Image, error) {
-	key := fmt.Sprintf("%s%s", infrav1.NameHetznerProviderPrefix, "image-name")
-
-	// query for an existing image by label this is needed because snapshots doesn't have any name only descriptions and labels.
-	listOpts := hcloud.ImageListOpts{
-		ListOpts: hcloud.ListOpts{
-			LabelSelector: fmt.Sprintf("%s==%s", key, s.scope.HCloudMachine.Spec.ImageName),
-		},
+// serverType returns the server type of a new server. A deprecated server type is replaced by its successor
+// if the HetznerCluster defines one.
+func (s *Service) serverType(ctx context.Context) (infrav1.HCloudMachineType, error) {
+	serverType := s.scope.HCloudMachine.Spec.Type
+	successor := s.scope.HetznerCluster.Spec.HCloudServerTypeSuccessors[string(serverType)]
+	if successor == "" {
+		return serverType, nil
 	}
 
-	imagesByLabel, err := s.scope.HCloudClient.ListImages(ctx, listOpts)
+	deprecations, err := s.scope.HCloudClient.ListServerTypeDeprecations(ctx)
 	if err != nil {
 		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
 			conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
 			record.Event(s.scope.HCloudMachine,
 				"RateLimitExceeded",
-				"exceeded rate limit with calling hcloud function ListImages",
+				"exceeded rate limit with calling hcloud function ListServerTypeDeprecations",
 			)
 		}
-		return nil, err
-	}
-
-	// query for an existing image by name.
-	listOpts = hcloud.ImageListOpts{
-		Name: s.scope.HCloudMachine.Spec.ImageName,
+		return "", errors.Wrap(err, "failed to list server type deprecations")
 	}
-	imagesByName, err := s.scope.HCloudClient.ListImages(ctx, listOpts)
-	if err != nil {
-		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
-			conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
-			record.Event(s.scope.HCloudMachine,
-				"RateLimitExceeded",
-				"exceeded rate limit with calling hcloud function ListImages",
-			)
-		}
-		return nil, err
+	if _, deprecated := deprecations[string(serverType)]; !deprecated {
+		return serverType, nil
+	}
+
+	record.Eventf(s.scope.HCloudMachine,
+		"UseServerTypeSuccessor",
+		"Server type %s is deprecated, creating server with successor %s",
+		serverType,
+		successor,
+	)
+	return successor, nil
+}
+
+// serverLocations returns the locations in which the server should be created in order of preference.
+// Other failure domains than the one of the machine are only used if the Machine does not set a failure domain
+// itself, which has to be respected, and if the machine does not need primary IPs, as they are bound to a location. Locations without capacity are skipped until the cooldown expired,
+// locations that are not allowed by the placement constraints of the cluster are skipped always.
+// Control planes fall back to the locations with the fewest control planes first, so that they stay
+// spread across the locations.
+func (s *Service) serverLocations(ctx context.Context, failureDomain string) ([]string, error) {
+	if s.scope.Machine.Spec.FailureDomain != nil {
+		return []string{failureDomain}, nil
+	}
+	if publicNetwork := s.scope.HCloudMachine.Spec.PublicNetwork; publicNetwork.PrimaryIPSelector != nil ||
+		publicNetwork.PrimaryIPv4ID != nil || publicNetwork.PrimaryIPv6ID != nil || len(s.scope.HCloudMachine.Spec.Volumes) > 0 {
+		return []string{failureDomain}, nil
+	}
+
+	now := time.Now()
+	serverType := s.scope.HCloudMachine.Spec.Type
+	exhaustedLocations := s.scope.HetznerCluster.Status.ExhaustedLocations
+
+	var locations []string
+	for _, location := range append([]string{failureDomain}, s.scope.FailureDomains()...) {
+		if utils.StringInList(locations, location) || isLocationExhausted(exhaustedLocations, location, serverType, now) ||
+			!s.scope.HetznerCluster.Spec.PlacementConstraints.IsLocationAllowed(infrav1.Region(location)) {
+			continue
+		}
+		locations = append(locations, location)
+	}
+
+	// Capacity might be available again before the cooldown expired
+	if len(locations) == 0 {
+		return []string{failureDomain}, nil
+	}
+
+	if s.scope.IsControlPlane() && len(locations) > 1 {
+		controlPlanes, err := s.controlPlanesPerLocation(ctx)
+		if err != nil {
+			return nil, err
+		}
+		fallbacks := locations
+		if locations[0] == failureDomain {
+			fallbacks = locations[1:]
+		}
+		sort.SliceStable(fallbacks, func(i, j int) bool {
+			return controlPlanes[fallbacks[i]] < controlPlanes[fallbacks[j]]
+		})
+	}
+	return locations, nil
+}
+
+// controlPlanesPerLocation returns the number of the other control plane machines of the cluster per location.
+func (s *Service) controlPlanesPerLocation(ctx context.Context) (map[string]int, error) {
+	var machines infrav1.HCloudMachineList
+	if err := s.scope.Client.List(ctx, &machines,
+		client.InNamespace(s.scope.Namespace()),
+		client.MatchingLabels{clusterv1.ClusterLabelName: s.scope.Cluster.Name},
+		client.HasLabels{clusterv1.MachineControlPlaneLabelName},
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to list control plane machines")
+	}
+
+	controlPlanes := make(map[string]int)
+	for _, machine := range machines.Items {
+		if machine.Name == s.scope.Name() || machine.Status.Region == "" {
+			continue
+		}
+		controlPlanes[string(machine.Status.Region)]++
+	}
+	return controlPlanes, nil
+}
+
+func isLocationExhausted(exhaustedLocations []infrav1.ExhaustedLocation, location string, serverType infrav1.HCloudMachineType, now time.Time) bool {
+	for _, exhausted := range exhaustedLocations {
+		if string(exhausted.Location) == location && exhausted.ServerType == serverType && now.Before(exhausted.RetryAfter.Time) {
+			return true
+		}
+	}
+	return false
+}
+
+// recordExhaustedLocation adds the location to the exhausted locations of the HetznerCluster, so that
+// other machines of the same type avoid it during the cooldown. Expired entries are removed.
+func (s *Service) recordExhaustedLocation(ctx context.Context, location string) error {
+	hetznerCluster := s.scope.HetznerCluster
+	helper, err := patch.NewHelper(hetznerCluster, s.scope.Client)
+	if err != nil {
+		return errors.Wrap(err, "failed to init patch helper")
+	}
+
+	now := time.Now()
+	serverType := s.scope.HCloudMachine.Spec.Type
+	exhaustedLocations := []infrav1.ExhaustedLocation{{
+		Location:   infrav1.Region(location),
+		ServerType: serverType,
+		RetryAfter: metav1.NewTime(now.Add(infrav1.ExhaustedLocationCooldown)),
+	}}
+	for _, exhausted := range hetznerCluster.Status.ExhaustedLocations {
+		if (string(exhausted.Location) == location && exhausted.ServerType == serverType) || !now.Before(exhausted.RetryAfter.Time) {
+			continue
+		}
+		exhaustedLocations = append(exhaustedLocations, exhausted)
+	}
+	hetznerCluster.Status.ExhaustedLocations = exhaustedLocations
+
+	return helper.Patch(ctx, hetznerCluster)
+}
+
+func appliedConfiguration(image *hcloud.Image, userData []byte, sshKeys []*hcloud.SSHKey) *infrav1.AppliedConfiguration {
+	now := metav1.Now()
+	config := &infrav1.AppliedConfiguration{
+		ImageID:      image.ID,
+		Image:        image.Name,
+		UserDataHash: utils.SHA256Hash(userData),
+		AppliedAt:    &now,
+	}
+	// Snapshots have no name, only a description
+	if config.Image == "" {
+		config.Image = image.Description
+	}
+	for _, sshKey := range sshKeys {
+		config.SSHKeyFingerprints = append(config.SSHKeyFingerprints, sshKey.Fingerprint)
+	}
+	return config
+}
+
+// getServerImage returns the image of the server for the architecture of its server type. The image is shared
+// by the servers of a cluster.
+func (s *Service) getServerImage(ctx context.Context, architecture infrav1.Architecture) (*hcloud.Image, error) {
+	key := fmt.Sprintf("image/%s/%s", architecture, s.scope.HCloudMachine.Spec.ImageName)
+	resolve := func() (interface{}, error) {
+		return s.resolveServerImage(ctx, architecture)
+	}
+	if imageSelector := s.imageSelector(); imageSelector != nil {
+		selector, err := utils.HCloudLabelSelector(imageSelector)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to convert image selector")
+		}
+		key = fmt.Sprintf("image/%s/selector/%s", architecture, selector)
+		resolve = func() (interface{}, error) {
+			return s.selectServerImage(ctx, architecture, selector)
+		}
+	}
+
+	image, err := s.sharedLookup(key, resolve)
+	if err != nil {
+		var mismatch *imageArchitectureMismatchError
+		if errors.As(err, &mismatch) {
+			conditions.MarkFalse(
+				s.scope.HCloudMachine,
+				infrav1.ImageArchitectureMatchesCondition,
+				infrav1.ImageArchitectureMismatchReason,
+				clusterv1.ConditionSeverityError,
+				mismatch.Error(),
+			)
+			record.Warnf(s.scope.HCloudMachine, "ImageArchitectureMismatch", mismatch.Error())
+		}
+		return nil, err
+	}
+	conditions.MarkTrue(s.scope.HCloudMachine, infrav1.ImageArchitectureMatchesCondition)
+	return image.(*hcloud.Image), nil
+}
+
+// imageSelector returns the selector of the image of the server, which is either the image selector or the label
+// of the image channel of the machine. It is nil if the image is referenced by its name.
+func (s *Service) imageSelector() *metav1.LabelSelector {
+	if channel := s.scope.HCloudMachine.Spec.ImageChannel; channel != "" {
+		return &metav1.LabelSelector{MatchLabels: map[string]string{infrav1.ImageChannelLabel: channel}}
+	}
+	return s.scope.HCloudMachine.Spec.ImageSelector
+}
+
+// imageArchitectureMismatchError is returned if the image exists, but not for the architecture of the server type.
+type imageArchitectureMismatchError struct {
+	imageName    string
+	architecture infrav1.Architecture
+}
+
+func (e *imageArchitectureMismatchError) Error() string {
+	return fmt.Sprintf("image %s does not exist for architecture %s of the server type", e.imageName, e.architecture)
+}
+
+// serverSSHKeys returns the SSH keys of the machine, which default to the HCloud SSH keys of the cluster, and the
+// additional SSH keys of the machine.
+func (s *Service) serverSSHKeys(ctx context.Context) ([]*hcloud.SSHKey, error) {
+	sshKeySpecs := s.scope.HCloudMachine.Spec.SSHKeys
+	if len(sshKeySpecs) == 0 {
+		sshKeySpecs = s.scope.HetznerCluster.Spec.SSHKeys.HCloud
+	}
+	sshKeysAPI, err := s.listSSHKeys(ctx)
+	if err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HCloudMachine,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function ListSSHKeys",
+			)
+		}
+		return nil, errors.Wrap(err, "failed listing ssh heys from hcloud")
+	}
+
+	sshKeys, err := getSSHKeys(sshKeysAPI, sshKeySpecs)
+	if err != nil {
+		return nil, errors.Wrap(err, "error with ssh keys")
+	}
+
+	additionalSSHKeys, err := s.additionalSSHKeys(ctx, sshKeysAPI)
+	if err != nil {
+		return nil, errors.Wrap(err, "error with additional ssh keys")
+	}
+	return appendSSHKeys(sshKeys, additionalSSHKeys...), nil
+}
+
+// listSSHKeys lists the SSH keys of the project. They are shared by the servers of a cluster.
+func (s *Service) listSSHKeys(ctx context.Context) ([]*hcloud.SSHKey, error) {
+	sshKeys, err := s.sharedLookup("sshkeys", func() (interface{}, error) {
+		return s.scope.HCloudClient.ListSSHKeys(ctx, hcloud.SSHKeyListOpts{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sshKeys.([]*hcloud.SSHKey), nil
+}
+
+// sharedLookup reuses the result of a lookup for all machines of the cluster, so that scale-ups
+// of many machines do not need the same API calls for every server.
+func (s *Service) sharedLookup(key string, lookup func() (interface{}, error)) (interface{}, error) {
+	uid := s.scope.HetznerCluster.UID
+	if uid == "" {
+		return lookup()
+	}
+	return clusterLookups.get(fmt.Sprintf("%s/%s", uid, key), lookup)
+}
+
+func (s *Service) resolveServerImage(ctx context.Context, architecture infrav1.Architecture) (*hcloud.Image, error) {
+	key := fmt.Sprintf("%s%s", infrav1.NameHetznerProviderPrefix, "image-name")
+
+	// query for an existing image by label this is needed because snapshots doesn't have any name only descriptions and labels.
+	listOpts := hcloud.ImageListOpts{
+		ListOpts: hcloud.ListOpts{
+			LabelSelector: fmt.Sprintf("%s==%s", key, s.scope.HCloudMachine.Spec.ImageName),
+		},
+	}
+
+	imagesByLabel, err := s.scope.HCloudClient.ListImagesForArchitecture(ctx, listOpts, string(architecture))
+	if err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HCloudMachine,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function ListImagesForArchitecture",
+			)
+		}
+		return nil, err
+	}
+
+	// query for an existing image by name.
+	nameListOpts := hcloud.ImageListOpts{
+		Name: s.scope.HCloudMachine.Spec.ImageName,
+	}
+	imagesByName, err := s.scope.HCloudClient.ListImagesForArchitecture(ctx, nameListOpts, string(architecture))
+	if err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HCloudMachine,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function ListImagesForArchitecture",
+			)
+		}
+		return nil, err
 	}
 
 	images := append(imagesByLabel, imagesByName...)
@@ -400,6 +1163,14 @@ func (s *Service) getServerImage(ctx context.Context) (*hcloud.Image, error) {
 			len(images), s.scope.HCloudMachine.Spec.ImageName)
 	}
 	if len(images) == 0 {
+		otherImages, err := s.listImagesOfAllArchitectures(ctx, listOpts, nameListOpts)
+		if err != nil {
+			return nil, err
+		}
+		if len(otherImages) > 0 {
+			return nil, &imageArchitectureMismatchError{imageName: s.scope.HCloudMachine.Spec.ImageName, architecture: architecture}
+		}
+
 		record.Warnf(s.scope.HCloudMachine,
 			"ImageNotFound",
 			"No image found with name %s",
@@ -408,56 +1179,888 @@ func (s *Service) getServerImage(ctx context.Context) (*hcloud.Image, error) {
 		return nil, fmt.Errorf("no image found with name %s", s.scope.HCloudMachine.Spec.ImageName)
 	}
 
-	return images[0], nil
+	return images[0], nil
+}
+
+// selectServerImage returns the most recent image of the architecture that matches the HCloud label selector.
+func (s *Service) selectServerImage(ctx context.Context, architecture infrav1.Architecture, selector string) (*hcloud.Image, error) {
+	listOpts := hcloud.ImageListOpts{ListOpts: hcloud.ListOpts{LabelSelector: selector}}
+	images, err := s.scope.HCloudClient.ListImagesForArchitecture(ctx, listOpts, string(architecture))
+	if err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HCloudMachine,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function ListImagesForArchitecture",
+			)
+		}
+		return nil, err
+	}
+
+	if len(images) == 0 {
+		otherImages, err := s.listImagesOfAllArchitectures(ctx, listOpts)
+		if err != nil {
+			return nil, err
+		}
+		if len(otherImages) > 0 {
+			return nil, &imageArchitectureMismatchError{imageName: selector, architecture: architecture}
+		}
+
+		record.Warnf(s.scope.HCloudMachine,
+			"ImageNotFound",
+			"No image found with labels %s",
+			selector,
+		)
+		return nil, fmt.Errorf("no image found with labels %s", selector)
+	}
+
+	image := newestImage(images)
+	record.Eventf(s.scope.HCloudMachine, "ImageSelected", "Selected image %s with id %d of %d images with labels %s",
+		image.Description, image.ID, len(images), selector)
+	return image, nil
+}
+
+// newestImage returns the image that has been created last. Images with the same creation time are ordered by
+// their ID, so that the choice is stable.
+func newestImage(images []*hcloud.Image) *hcloud.Image {
+	newest := images[0]
+	for _, image := range images[1:] {
+		if image.Created.After(newest.Created) || (image.Created.Equal(newest.Created) && image.ID > newest.ID) {
+			newest = image
+		}
+	}
+	return newest
+}
+
+// listImagesOfAllArchitectures lists the images that match one of the options regardless of their architecture.
+func (s *Service) listImagesOfAllArchitectures(ctx context.Context, opts ...hcloud.ImageListOpts) ([]*hcloud.Image, error) {
+	var images []*hcloud.Image
+	for _, o := range opts {
+		found, err := s.scope.HCloudClient.ListImages(ctx, o)
+		if err != nil {
+			if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+				conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+				record.Event(s.scope.HCloudMachine,
+					"RateLimitExceeded",
+					"exceeded rate limit with calling hcloud function ListImages",
+				)
+			}
+			return nil, err
+		}
+		images = append(images, found...)
+	}
+	return images, nil
+}
+
+func getSSHKeys(sshKeysAPI []*hcloud.SSHKey, sshKeysSpec []infrav1.SSHKey) ([]*hcloud.SSHKey, error) {
+	sshKeysAPIMap := make(map[string]*hcloud.SSHKey)
+	for i, sshKey := range sshKeysAPI {
+		sshKeysAPIMap[sshKey.Name] = sshKeysAPI[i]
+	}
+	sshKeys := make([]*hcloud.SSHKey, len(sshKeysSpec))
+
+	for i, sshKeySpec := range sshKeysSpec {
+		sshKey, ok := sshKeysAPIMap[sshKeySpec.Name]
+		if !ok {
+			return nil, fmt.Errorf("ssh key not found. Name: %s", sshKeySpec.Name)
+		}
+		sshKeys[i] = sshKey
+	}
+	return sshKeys, nil
+}
+
+// Delete implements delete method of server.
+func (s *Service) Delete(ctx context.Context) (_ *ctrl.Result, err error) {
+	// find current server
+	server, err := s.findServer(ctx)
+	if err != nil {
+		if isAPIUnreachable(err) {
+			return s.handleDeleteAPIUnreachable(ctx, err)
+		}
+		if hcloudclient.IsUnauthorized(err) && s.forceCleanupRequested() {
+			return nil, s.ForceCleanup(ctx, err)
+		}
+		return nil, errors.Wrap(err, "failed to find Server")
+	}
+
+	if conditions.IsFalse(s.scope.HCloudMachine, infrav1.HCloudAPIReachableCondition) {
+		conditions.MarkTrue(s.scope.HCloudMachine, infrav1.HCloudAPIReachableCondition)
+	}
+
+	// If no server has been found then nothing can be deleted
+	if server == nil {
+		s.scope.V(2).Info("Unable to locate HCloud server by ID or tags")
+		record.Warnf(s.scope.HCloudMachine, "NoInstanceFound", "Unable to find matching HCloud server for %s", s.scope.Name())
+		return s.releaseMachineResources(ctx)
+	}
+
+	if s.scope.IsControlPlane() && s.scope.HetznerCluster.Spec.ControlPlaneLoadBalancer.Enabled {
+		if err := s.deleteServerOfLoadBalancer(ctx, server); err != nil {
+			return &reconcile.Result{}, errors.Errorf("Error while deleting attached server of loadbalancer: %s", err)
+		}
+	}
+
+	// move the floating IP to another control plane before the server goes down
+	if s.scope.IsControlPlane() && s.scope.HetznerCluster.Spec.ControlPlaneFloatingIP != nil {
+		if err := floatingip.NewService(&s.scope.ClusterScope).Assign(ctx, server.ID); err != nil {
+			return nil, errors.Wrap(err, "failed to move floating IP of the control plane to another server")
+		}
+	}
+
+	// the protection is only removed right before the server is deleted
+	if err := s.removeProtection(ctx, server); err != nil {
+		return nil, errors.Wrap(err, "failed to remove protection of server")
+	}
+
+	// First shut the server down, then delete it
+	var res *ctrl.Result
+	switch status := server.Status; status {
+	case hcloud.ServerStatusRunning:
+		res, err = s.handleDeleteServerStatusRunning(ctx, server)
+	case hcloud.ServerStatusOff:
+		res, err = s.handleDeleteServerStatusOff(ctx, server)
+	default:
+		return &ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	}
+	if res != nil || err != nil {
+		return res, err
+	}
+
+	// the server is gone, so that a replacement can use its primary IPs and retained volumes
+	return s.releaseMachineResources(ctx)
+}
+
+// releaseMachineResources deletes or releases the resources of the machine after its server has been deleted.
+func (s *Service) releaseMachineResources(ctx context.Context) (*ctrl.Result, error) {
+	attached, err := s.deleteVolumes(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to delete volumes")
+	}
+	if attached {
+		return &ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	}
+	return nil, s.releasePrimaryIPs(ctx)
+}
+
+// desiredPublicNetwork returns the public network of the spec, with the public IPv4 that the server needs anyway.
+func (s *Service) desiredPublicNetwork() *infrav1.AppliedPublicNetwork {
+	publicNetwork := s.scope.HCloudMachine.Spec.PublicNetwork
+
+	// if no private network exists there must be an IPv4 for the load balancer.
+	return &infrav1.AppliedPublicNetwork{
+		EnableIPv4: publicNetwork.EnableIPv4 || s.publicIPv4Required(),
+		EnableIPv6: publicNetwork.EnableIPv6,
+	}
+}
+
+// publicNetworkChanges returns the IP families that have to be enabled and disabled on the server. An IP family is
+// only changed if the spec differs from the public network that has last been applied. Servers whose public network
+// has not been recorded keep theirs, e.g. workers that have got a public IPv4 because the cluster has no private
+// network, until the spec is changed.
+func (s *Service) publicNetworkChanges(server *hcloud.Server) (enable, disable []infrav1.PrimaryIPType) {
+	applied := s.scope.HCloudMachine.Status.AppliedPublicNetwork
+	if applied == nil {
+		return nil, nil
+	}
+	desired := s.desiredPublicNetwork()
+
+	hasIPv4 := !server.PublicNet.IPv4.IsUnspecified()
+	switch {
+	case desired.EnableIPv4 == applied.EnableIPv4:
+	case desired.EnableIPv4 && !hasIPv4:
+		enable = append(enable, infrav1.PrimaryIPTypeIPv4)
+	case !desired.EnableIPv4 && hasIPv4:
+		disable = append(disable, infrav1.PrimaryIPTypeIPv4)
+	}
+
+	hasIPv6 := !server.PublicNet.IPv6.IsUnspecified()
+	switch {
+	case desired.EnableIPv6 == applied.EnableIPv6:
+	case desired.EnableIPv6 && !hasIPv6:
+		enable = append(enable, infrav1.PrimaryIPTypeIPv6)
+	case !desired.EnableIPv6 && hasIPv6:
+		disable = append(disable, infrav1.PrimaryIPTypeIPv6)
+	}
+	return enable, disable
+}
+
+// publicIPv4Required returns whether the server gets a public IPv4 even if it is disabled in the spec. Hetzner load
+// balancers reach their targets only through the private network or a public IPv4, so control planes of clusters
+// with load balancer but without private network need one. All other servers can be IPv6-only.
+func (s *Service) publicIPv4Required() bool {
+	return !s.scope.HetznerCluster.Spec.HCloudNetwork.Enabled &&
+		s.scope.HetznerCluster.Spec.ControlPlaneLoadBalancer.Enabled &&
+		s.scope.IsControlPlane()
+}
+
+// privateOnly returns whether the server gets neither a public IPv4 nor IPv6, so that it is only reachable in the
+// private network.
+func (s *Service) privateOnly() bool {
+	publicNetwork := s.scope.HCloudMachine.Spec.PublicNetwork
+	return !publicNetwork.EnableIPv4 && !publicNetwork.EnableIPv6 && !s.publicIPv4Required()
+}
+
+// privateNetworkGateway returns the gateway of the private network, through which a server without public IPs
+// reaches the internet. The network needs a default route, e.g. via the NAT gateway of the cluster, as the server
+// could neither download anything nor join the cluster otherwise.
+func (s *Service) privateNetworkGateway() (string, error) {
+	network := s.scope.HetznerCluster.Status.Network
+	if !s.scope.HetznerCluster.Spec.HCloudNetwork.Enabled || network == nil {
+		msg := "server without public IPv4 and IPv6 requires a private network"
+		conditions.MarkFalse(
+			s.scope.HCloudMachine,
+			infrav1.InstanceReadyCondition,
+			infrav1.PrivateNetworkRequiredReason,
+			clusterv1.ConditionSeverityError,
+			msg,
+		)
+		record.Warnf(s.scope.HCloudMachine, "PrivateNetworkRequired", "Not creating server: %s", msg)
+		return "", errors.New(msg)
+	}
+	if network.DefaultRouteGateway == "" {
+		msg := "private network has no route 0.0.0.0/0 to reach the internet. Configure the NAT gateway of the network or add the route"
+		conditions.MarkFalse(
+			s.scope.HCloudMachine,
+			infrav1.InstanceReadyCondition,
+			infrav1.NoRouteToInternetReason,
+			clusterv1.ConditionSeverityError,
+			msg,
+		)
+		record.Warnf(s.scope.HCloudMachine, "NoRouteToInternet", "Not creating server: %s", msg)
+		return "", errors.New(msg)
+	}
+
+	// the gateway of a HCloud network is its first IP, which routes to the gateway of the default route
+	cidrBlock := s.scope.HetznerCluster.Spec.HCloudNetwork.CIDRBlock
+	if network.IPRange != "" {
+		cidrBlock = network.IPRange
+	}
+	_, ipRange, err := net.ParseCIDR(cidrBlock)
+	if err != nil || ipRange.IP.To4() == nil {
+		return "", errors.Errorf("invalid network %q", cidrBlock)
+	}
+	gateway := ipRange.IP.To4()
+	return net.IPv4(gateway[0], gateway[1], gateway[2], gateway[3]+1).String(), nil
+}
+
+// reconcilePublicNetwork assigns and unassigns the primary IPs of the server according to the spec.
+// Primary IPs can only be changed while the server is off, so a running server is shut down first.
+// It is powered on again afterwards like any other server that is switched off.
+func (s *Service) reconcilePublicNetwork(ctx context.Context, server *hcloud.Server) (*reconcile.Result, error) {
+	enable, disable := s.publicNetworkChanges(server)
+	if len(enable) == 0 && len(disable) == 0 {
+		s.scope.HCloudMachine.Status.AppliedPublicNetwork = s.desiredPublicNetwork()
+		return nil, nil
+	}
+
+	switch server.Status {
+	case hcloud.ServerStatusOff: // Change the primary IPs below
+	case hcloud.ServerStatusRunning:
+		if _, err := s.scope.HCloudClient.ShutdownServer(ctx, server); err != nil {
+			if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+				conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+				record.Event(s.scope.HCloudMachine,
+					"RateLimitExceeded",
+					"exceeded rate limit with calling hcloud function ShutdownServer",
+				)
+			}
+			return nil, errors.Wrap(err, "failed to shutdown server")
+		}
+		s.scope.HCloudMachine.Status.Ready = false
+		conditions.MarkFalse(s.scope.HCloudMachine,
+			infrav1.InstanceReadyCondition,
+			infrav1.PublicNetworkChangingReason,
+			clusterv1.ConditionSeverityInfo,
+			"server is shut down to change its public network",
+		)
+		record.Eventf(s.scope.HCloudMachine, "PublicNetworkChanging", "Shutting down server %d to change its public network", server.ID)
+		return &reconcile.Result{RequeueAfter: 10 * time.Second}, nil
+	default:
+		// Wait until the server has finished shutting down or starting
+		return &reconcile.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
+	for _, family := range disable {
+		if err := s.disablePublicIP(ctx, server, family); err != nil {
+			return nil, errors.Wrapf(err, "failed to disable public %s", family)
+		}
+	}
+	for _, family := range enable {
+		if err := s.enablePublicIP(ctx, server, family); err != nil {
+			return nil, errors.Wrapf(err, "failed to enable public %s", family)
+		}
+	}
+	s.scope.HCloudMachine.Status.AppliedPublicNetwork = s.desiredPublicNetwork()
+
+	// The server is powered on again with the next reconcile
+	return &reconcile.Result{RequeueAfter: 2 * time.Second}, nil
+}
+
+// reconcileServerType changes the type of the server to the type of the spec. As the type can only be changed while
+// the server is off, the server is shut down first and powered on again afterwards. The disk is not upgraded, so
+// that the server can be changed back to a smaller type. A server that has been created with the successor of its
+// deprecated type is not changed. After a failed change, the server runs with its previous type until the change is
+// retried after a backoff.
+func (s *Service) reconcileServerType(ctx context.Context, server *hcloud.Server) (*reconcile.Result, error) {
+	desired := string(s.scope.HCloudMachine.Spec.Type)
+	if server.ServerType == nil || server.ServerType.Name == desired ||
+		server.ServerType.Name == string(s.scope.HetznerCluster.Spec.HCloudServerTypeSuccessors[desired]) {
+		conditions.Delete(s.scope.HCloudMachine, infrav1.ServerTypeChangedCondition)
+		return nil, nil
+	}
+
+	if failed := conditions.Get(s.scope.HCloudMachine, infrav1.ServerTypeChangedCondition); failed != nil &&
+		failed.Status == corev1.ConditionFalse && time.Since(failed.LastTransitionTime.Time) < serverTypeChangeBackoff {
+		return nil, nil
+	}
+
+	switch server.Status {
+	case hcloud.ServerStatusOff: // Change the server type below
+	case hcloud.ServerStatusRunning:
+		// Servers that ignore the ACPI shutdown are powered off after maxShutDownTime
+		if condition := conditions.Get(s.scope.HCloudMachine, infrav1.InstanceReadyCondition); condition != nil &&
+			condition.Status == corev1.ConditionFalse && condition.Reason == infrav1.ServerTypeChangingReason &&
+			time.Since(condition.LastTransitionTime.Time) > maxShutDownTime {
+			if _, err := s.scope.HCloudClient.PowerOffServer(ctx, server); err != nil {
+				if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+					conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+					record.Event(s.scope.HCloudMachine,
+						"RateLimitExceeded",
+						"exceeded rate limit with calling hcloud function PowerOffServer",
+					)
+				}
+				return nil, errors.Wrap(err, "failed to power off server")
+			}
+			record.Warnf(s.scope.HCloudMachine, "ServerTypeChangingPowerOff",
+				"Server %d did not shut down within %s, powering it off to change its type", server.ID, maxShutDownTime)
+			return &reconcile.Result{RequeueAfter: 10 * time.Second}, nil
+		}
+		if _, err := s.scope.HCloudClient.ShutdownServer(ctx, server); err != nil {
+			if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+				conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+				record.Event(s.scope.HCloudMachine,
+					"RateLimitExceeded",
+					"exceeded rate limit with calling hcloud function ShutdownServer",
+				)
+			}
+			return nil, errors.Wrap(err, "failed to shutdown server")
+		}
+		s.scope.HCloudMachine.Status.Ready = false
+		conditions.MarkFalse(s.scope.HCloudMachine,
+			infrav1.InstanceReadyCondition,
+			infrav1.ServerTypeChangingReason,
+			clusterv1.ConditionSeverityInfo,
+			"server is shut down to change its type from %s to %s",
+			server.ServerType.Name, desired,
+		)
+		record.Eventf(s.scope.HCloudMachine, "ServerTypeChanging", "Shutting down server %d to change its type from %s to %s",
+			server.ID, server.ServerType.Name, desired)
+		return &reconcile.Result{RequeueAfter: 10 * time.Second}, nil
+	default:
+		// Wait until the server has finished shutting down or starting
+		return &reconcile.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
+	previous := server.ServerType.Name
+	if _, err := s.scope.HCloudClient.ChangeServerType(ctx, server, hcloud.ServerChangeTypeOpts{
+		ServerType:  &hcloud.ServerType{Name: desired},
+		UpgradeDisk: false,
+	}); err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HCloudMachine,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function ChangeServerType",
+			)
+		}
+		if hcloud.IsError(err, hcloud.ErrorCodeResourceUnavailable) {
+			record.Warnf(s.scope.HCloudMachine, "ServerTypeUnavailable",
+				"Server type %s is not available in the location of server %d", desired, server.ID)
+		}
+		return s.handleServerTypeChangeFailed(ctx, server, desired, err)
+	}
+	record.Eventf(s.scope.HCloudMachine, "ServerTypeChanged", "Changed type of server %d from %s to %s", server.ID, previous, desired)
+
+	// The server is powered on again with the next reconcile
+	return &reconcile.Result{RequeueAfter: 2 * time.Second}, nil
+}
+
+// handleServerTypeChangeFailed powers on the server with its previous type and marks the condition ServerTypeChanged
+// false, so that the change is only retried after serverTypeChangeBackoff.
+func (s *Service) handleServerTypeChangeFailed(ctx context.Context, server *hcloud.Server, desired string, changeErr error) (*reconcile.Result, error) {
+	// The condition is set again, so that the backoff starts with every failed change
+	conditions.Delete(s.scope.HCloudMachine, infrav1.ServerTypeChangedCondition)
+	conditions.MarkFalse(s.scope.HCloudMachine,
+		infrav1.ServerTypeChangedCondition,
+		infrav1.ServerTypeChangeFailedReason,
+		clusterv1.ConditionSeverityWarning,
+		"failed to change server type from %s to %s, retrying in %s: %s",
+		server.ServerType.Name, desired, serverTypeChangeBackoff, changeErr,
+	)
+	record.Warnf(s.scope.HCloudMachine, "ServerTypeChangeFailed", "Failed to change type of server %d from %s to %s, powering it on again: %s",
+		server.ID, server.ServerType.Name, desired, changeErr)
+
+	if _, err := s.scope.HCloudClient.PowerOnServer(ctx, server); err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HCloudMachine,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function PowerOnServer",
+			)
+		}
+		return nil, errors.Wrap(err, "failed to power on server")
+	}
+	return &reconcile.Result{RequeueAfter: 10 * time.Second}, nil
+}
+
+// disablePublicIP unassigns the primary IP of the family from the server. The primary IP is deleted
+// unless it is managed by an HCloudPrimaryIP, which is released instead.
+func (s *Service) disablePublicIP(ctx context.Context, server *hcloud.Server, family infrav1.PrimaryIPType) error {
+	id := server.PublicNet.IPv4.ID
+	if family == infrav1.PrimaryIPTypeIPv6 {
+		id = server.PublicNet.IPv6.ID
+	}
+
+	if _, err := s.scope.HCloudClient.UnassignPrimaryIP(ctx, id); err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HCloudMachine,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function UnassignPrimaryIP",
+			)
+		}
+		return errors.Wrapf(err, "failed to unassign primary IP %d", id)
+	}
+	record.Eventf(s.scope.HCloudMachine, "PublicIPDisabled", "Unassigned %s primary IP %d from server %d", family, id, server.ID)
+
+	// existing primary IPs of the spec are owned by the user and are not deleted
+	if configuredID := s.scope.HCloudMachine.Spec.PublicNetwork.PrimaryIPID(family); configuredID != nil && *configuredID == id {
+		return nil
+	}
+
+	primaryIPs, err := s.listPrimaryIPs(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for i := range primaryIPs {
+		if primaryIPs[i].Status.ID == id {
+			return s.releasePrimaryIP(ctx, &primaryIPs[i])
+		}
+	}
+
+	if err := s.scope.HCloudClient.DeletePrimaryIP(ctx, &hcloud.PrimaryIP{ID: id}); err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HCloudMachine,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function DeletePrimaryIP",
+			)
+		}
+		if hcloud.IsError(err, hcloud.ErrorCodeNotFound) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to delete primary IP %d", id)
+	}
+	return nil
+}
+
+// enablePublicIP assigns a primary IP of the family to the server. If the machine has a primary IP
+// selector, an HCloudPrimaryIP is claimed. Otherwise, a new primary IP is created.
+func (s *Service) enablePublicIP(ctx context.Context, server *hcloud.Server, family infrav1.PrimaryIPType) error {
+	if s.scope.HCloudMachine.Spec.PublicNetwork.PrimaryIPSelector == nil && s.scope.HCloudMachine.Spec.PublicNetwork.PrimaryIPID(family) == nil {
+		autoDelete := true
+		if _, err := s.scope.HCloudClient.CreatePrimaryIP(ctx, hcloud.PrimaryIPCreateOpts{
+			Name:         fmt.Sprintf("%s-%s", server.Name, family),
+			Type:         hcloud.PrimaryIPType(family),
+			AssigneeType: "server",
+			AssigneeID:   &server.ID,
+			AutoDelete:   &autoDelete,
+			Labels:       server.Labels,
+		}); err != nil {
+			if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+				conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+				record.Event(s.scope.HCloudMachine,
+					"RateLimitExceeded",
+					"exceeded rate limit with calling hcloud function CreatePrimaryIP",
+				)
+			}
+			return errors.Wrapf(err, "failed to create %s primary IP", family)
+		}
+		record.Eventf(s.scope.HCloudMachine, "PublicIPEnabled", "Created %s primary IP for server %d", family, server.ID)
+		return nil
+	}
+
+	publicNet := &hcloud.ServerCreatePublicNet{
+		EnableIPv4: family == infrav1.PrimaryIPTypeIPv4,
+		EnableIPv6: family == infrav1.PrimaryIPTypeIPv6,
+	}
+	if s.scope.HCloudMachine.Spec.PublicNetwork.PrimaryIPSelector != nil {
+		if err := s.assignPrimaryIPs(ctx, publicNet, string(s.scope.HCloudMachine.Status.Region)); err != nil {
+			return err
+		}
+	} else if err := s.assignExistingPrimaryIPs(ctx, publicNet, string(s.scope.HCloudMachine.Status.Region)); err != nil {
+		return err
+	}
+	primaryIP := publicNet.IPv4
+	if family == infrav1.PrimaryIPTypeIPv6 {
+		primaryIP = publicNet.IPv6
+	}
+
+	if _, err := s.scope.HCloudClient.AssignPrimaryIP(ctx, hcloud.PrimaryIPAssignOpts{
+		ID:           primaryIP.ID,
+		AssigneeID:   server.ID,
+		AssigneeType: "server",
+	}); err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HCloudMachine,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function AssignPrimaryIP",
+			)
+		}
+		return errors.Wrapf(err, "failed to assign primary IP %d", primaryIP.ID)
+	}
+	record.Eventf(s.scope.HCloudMachine, "PublicIPEnabled", "Assigned %s primary IP %d to server %d", family, primaryIP.ID, server.ID)
+	return nil
+}
+
+// assignPrimaryIPs claims a free HCloudPrimaryIP in the failure domain for every enabled IP family.
+func (s *Service) assignPrimaryIPs(ctx context.Context, publicNet *hcloud.ServerCreatePublicNet, failureDomain string) error {
+	primaryIPs, err := s.listPrimaryIPs(ctx, s.scope.HCloudMachine.Spec.PublicNetwork.PrimaryIPSelector)
+	if err != nil {
+		return err
+	}
+
+	families := make([]infrav1.PrimaryIPType, 0, 2)
+	if publicNet.EnableIPv4 {
+		families = append(families, infrav1.PrimaryIPTypeIPv4)
+	}
+	if publicNet.EnableIPv6 {
+		families = append(families, infrav1.PrimaryIPTypeIPv6)
+	}
+
+	for _, family := range families {
+		primaryIP := choosePrimaryIP(primaryIPs, s.scope.HCloudMachine, family, failureDomain)
+		if primaryIP == nil {
+			conditions.MarkFalse(s.scope.HCloudMachine,
+				infrav1.InstanceReadyCondition,
+				infrav1.PrimaryIPNotAvailableReason,
+				clusterv1.ConditionSeverityWarning,
+				"no free %s primary IP available in %s",
+				family, failureDomain,
+			)
+			return errors.Errorf("no free %s primary IP matching the selector available in %s", family, failureDomain)
+		}
+
+		if primaryIP.Spec.ConsumerRef == nil {
+			base := primaryIP.DeepCopy()
+			primaryIP.Spec.ConsumerRef = &corev1.ObjectReference{
+				Kind:       s.scope.HCloudMachine.Kind,
+				APIVersion: s.scope.HCloudMachine.APIVersion,
+				Name:       s.scope.HCloudMachine.Name,
+				Namespace:  s.scope.HCloudMachine.Namespace,
+			}
+			// the optimistic lock fails if another machine claimed the primary IP in the meantime
+			if err := s.scope.Client.Patch(ctx, primaryIP, client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{})); err != nil {
+				return errors.Wrapf(err, "failed to claim primary IP %s", primaryIP.Name)
+			}
+			record.Eventf(s.scope.HCloudMachine, "PrimaryIPClaimed", "Claimed primary IP %s with address %s", primaryIP.Name, primaryIP.Status.IP)
+		}
+
+		apiPrimaryIP := &hcloud.PrimaryIP{ID: primaryIP.Status.ID}
+		if family == infrav1.PrimaryIPTypeIPv4 {
+			publicNet.IPv4 = apiPrimaryIP
+		} else {
+			publicNet.IPv6 = apiPrimaryIP
+		}
+	}
+	return nil
+}
+
+// assignExistingPrimaryIPs assigns the existing primary IPs of the spec for every enabled IP family. A primary IP
+// that is still assigned to another server, e.g. to the server of the machine that is replaced, is waited for.
+// AutoDelete is disabled, so that the primary IP is not deleted together with the server.
+func (s *Service) assignExistingPrimaryIPs(ctx context.Context, publicNet *hcloud.ServerCreatePublicNet, failureDomain string) error {
+	for _, family := range []infrav1.PrimaryIPType{infrav1.PrimaryIPTypeIPv4, infrav1.PrimaryIPTypeIPv6} {
+		id := s.scope.HCloudMachine.Spec.PublicNetwork.PrimaryIPID(family)
+		if id == nil {
+			continue
+		}
+		if (family == infrav1.PrimaryIPTypeIPv4 && !publicNet.EnableIPv4) || (family == infrav1.PrimaryIPTypeIPv6 && !publicNet.EnableIPv6) {
+			continue
+		}
+
+		primaryIP, err := s.scope.HCloudClient.GetPrimaryIP(ctx, *id)
+		if err != nil {
+			if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+				conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+				record.Event(s.scope.HCloudMachine,
+					"RateLimitExceeded",
+					"exceeded rate limit with calling hcloud function GetPrimaryIP",
+				)
+			}
+			return errors.Wrapf(err, "failed to get primary IP %d", *id)
+		}
+
+		var msg, reason string
+		switch {
+		case primaryIP == nil:
+			msg, reason = fmt.Sprintf("%s primary IP %d not found", family, *id), infrav1.PrimaryIPNotFoundReason
+		case primaryIP.Type != hcloud.PrimaryIPType(family):
+			msg, reason = fmt.Sprintf("primary IP %d is not of type %s", *id, family), infrav1.PrimaryIPNotAvailableReason
+		case primaryIP.Datacenter == nil || primaryIP.Datacenter.Location == nil || primaryIP.Datacenter.Location.Name != failureDomain:
+			msg, reason = fmt.Sprintf("%s primary IP %d is not in %s", family, *id, failureDomain), infrav1.PrimaryIPNotAvailableReason
+		case primaryIP.AssigneeID != 0:
+			msg, reason = fmt.Sprintf("%s primary IP %d is still assigned to server %d", family, *id, primaryIP.AssigneeID), infrav1.PrimaryIPNotAvailableReason
+		}
+		if msg != "" {
+			conditions.MarkFalse(s.scope.HCloudMachine,
+				infrav1.InstanceReadyCondition,
+				reason,
+				clusterv1.ConditionSeverityWarning,
+				msg,
+			)
+			return errors.New(msg)
+		}
+
+		if primaryIP.AutoDelete {
+			autoDelete := false
+			if _, err := s.scope.HCloudClient.UpdatePrimaryIP(ctx, primaryIP, hcloud.PrimaryIPUpdateOpts{AutoDelete: &autoDelete}); err != nil {
+				if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+					conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+					record.Event(s.scope.HCloudMachine,
+						"RateLimitExceeded",
+						"exceeded rate limit with calling hcloud function UpdatePrimaryIP",
+					)
+				}
+				return errors.Wrapf(err, "failed to disable auto delete of primary IP %d", *id)
+			}
+			record.Eventf(s.scope.HCloudMachine, "PrimaryIPAutoDeleteDisabled", "Disabled auto delete of %s primary IP %d", family, *id)
+		}
+
+		apiPrimaryIP := &hcloud.PrimaryIP{ID: primaryIP.ID}
+		if family == infrav1.PrimaryIPTypeIPv4 {
+			publicNet.IPv4 = apiPrimaryIP
+		} else {
+			publicNet.IPv6 = apiPrimaryIP
+		}
+	}
+	return nil
+}
+
+// releasePrimaryIPs removes the consumer reference of all HCloudPrimaryIPs used by this machine.
+func (s *Service) releasePrimaryIPs(ctx context.Context) error {
+	primaryIPs, err := s.listPrimaryIPs(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for i := range primaryIPs {
+		if err := s.releasePrimaryIP(ctx, &primaryIPs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releasePrimaryIP removes the consumer reference of the HCloudPrimaryIP if it is used by this machine.
+func (s *Service) releasePrimaryIP(ctx context.Context, primaryIP *infrav1.HCloudPrimaryIP) error {
+	ref := primaryIP.Spec.ConsumerRef
+	if ref == nil || ref.Name != s.scope.HCloudMachine.Name || ref.Namespace != s.scope.HCloudMachine.Namespace {
+		return nil
+	}
+
+	base := primaryIP.DeepCopy()
+	primaryIP.Spec.ConsumerRef = nil
+	if err := s.scope.Client.Patch(ctx, primaryIP, client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{})); err != nil {
+		return errors.Wrapf(err, "failed to release primary IP %s", primaryIP.Name)
+	}
+	record.Eventf(s.scope.HCloudMachine, "PrimaryIPReleased", "Released primary IP %s", primaryIP.Name)
+	return nil
+}
+
+func (s *Service) listPrimaryIPs(ctx context.Context, selector *metav1.LabelSelector) ([]infrav1.HCloudPrimaryIP, error) {
+	opts := []client.ListOption{client.InNamespace(s.scope.Namespace())}
+	if selector != nil {
+		labelSelector, err := metav1.LabelSelectorAsSelector(selector)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse primary IP selector")
+		}
+		opts = append(opts, client.MatchingLabelsSelector{Selector: labelSelector})
+	}
+
+	primaryIPs := &infrav1.HCloudPrimaryIPList{}
+	if err := s.scope.Client.List(ctx, primaryIPs, opts...); err != nil {
+		return nil, errors.Wrap(err, "failed to list HCloudPrimaryIPs")
+	}
+	return primaryIPs.Items, nil
+}
+
+// choosePrimaryIP prefers a primary IP that is already claimed by the machine over a free one. Primary IPs
+// that are still assigned to a server in HCloud are skipped, e.g. the ones of orphaned servers.
+func choosePrimaryIP(primaryIPs []infrav1.HCloudPrimaryIP, hcloudMachine *infrav1.HCloudMachine, family infrav1.PrimaryIPType, failureDomain string) *infrav1.HCloudPrimaryIP {
+	var free *infrav1.HCloudPrimaryIP
+	for i := range primaryIPs {
+		primaryIP := &primaryIPs[i]
+		if primaryIP.Spec.Type != family || string(primaryIP.Status.Location) != failureDomain {
+			continue
+		}
+		if !primaryIP.IsAvailableFor(hcloudMachine) || primaryIP.Status.AssigneeID != 0 {
+			continue
+		}
+		if primaryIP.Spec.ConsumerRef != nil {
+			return primaryIP
+		}
+		if free == nil {
+			free = primaryIP
+		}
+	}
+	return free
+}
+
+// handleDeleteAPIUnreachable waits for the HCloud API to become reachable again. If the deletion policy
+// allows it, the server is orphaned after the timeout, so that the finalizer can be removed.
+func (s *Service) handleDeleteAPIUnreachable(ctx context.Context, apiErr error) (*ctrl.Result, error) {
+	// use a static message, so that the last transition time shows when the API became unreachable
+	conditions.MarkFalse(s.scope.HCloudMachine,
+		infrav1.HCloudAPIReachableCondition,
+		infrav1.HCloudAPIUnreachableReason,
+		clusterv1.ConditionSeverityWarning,
+		"HCloud API is unreachable",
+	)
+
+	if s.forceCleanupRequested() {
+		return nil, s.ForceCleanup(ctx, apiErr)
+	}
+
+	policy := s.scope.HCloudMachine.Spec.DeletionPolicy
+	if policy == nil || policy.Type != infrav1.DeletionPolicyTypeOrphanAfterTimeout {
+		return nil, errors.Wrap(apiErr, "failed to find server: HCloud API is unreachable")
+	}
+
+	unreachableSince := conditions.GetLastTransitionTime(s.scope.HCloudMachine, infrav1.HCloudAPIReachableCondition)
+	if unreachableSince != nil && time.Now().Before(unreachableSince.Time.Add(policy.GetTimeout())) {
+		log := ctrl.LoggerFrom(ctx)
+		log.V(1).Info("HCloud API is unreachable, waiting before orphaning server", "error", apiErr.Error())
+		return &ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+	}
+
+	serverID, err := s.serverIDFromProviderID()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get server ID")
+	}
+
+	// without a providerID the server might have been created anyway, so it is recorded by the name of the machine.
+	// Its primary IPs stay claimed, as the server still uses them, until the orphaned server is deleted.
+	if err := s.recordOrphanedServer(ctx, serverID); err != nil {
+		return nil, errors.Wrap(err, "failed to record orphaned server")
+	}
+
+	conditions.MarkFalse(s.scope.HCloudMachine,
+		infrav1.InstanceReadyCondition,
+		infrav1.ServerOrphanedReason,
+		clusterv1.ConditionSeverityWarning,
+		"HCloud API unreachable for %s, server has been orphaned",
+		policy.GetTimeout(),
+	)
+	record.Warnf(s.scope.HCloudMachine,
+		"HCloudServerOrphaned",
+		"HCloud API unreachable for %s. Orphaned server with ID %d of %s",
+		policy.GetTimeout(),
+		serverID,
+		s.scope.Name(),
+	)
+	return nil, nil
 }
 
-func getSSHKeys(sshKeysAPI []*hcloud.SSHKey, sshKeysSpec []infrav1.SSHKey) ([]*hcloud.SSHKey, error) {
-	sshKeysAPIMap := make(map[string]*hcloud.SSHKey)
-	for i, sshKey := range sshKeysAPI {
-		sshKeysAPIMap[sshKey.Name] = sshKeysAPI[i]
+// ForceCleanup gives up the server of an HCloudMachine with the force-cleanup annotation without deleting it,
+// as the HCloud API cannot be used. The server is recorded as orphaned in the HetznerCluster, so that it gets
+// deleted as soon as the API can be used again.
+func (s *Service) ForceCleanup(ctx context.Context, cause error) error {
+	serverID, err := s.serverIDFromProviderID()
+	if err != nil {
+		return errors.Wrap(err, "failed to get server ID")
 	}
-	sshKeys := make([]*hcloud.SSHKey, len(sshKeysSpec))
 
-	for i, sshKeySpec := range sshKeysSpec {
-		sshKey, ok := sshKeysAPIMap[sshKeySpec.Name]
-		if !ok {
-			return nil, fmt.Errorf("ssh key not found. Name: %s", sshKeySpec.Name)
-		}
-		sshKeys[i] = sshKey
+	// without a providerID the server might have been created anyway, so it is recorded by the name of the machine.
+	// Its primary IPs stay claimed, as the server still uses them, until the orphaned server is deleted.
+	if err := s.recordOrphanedServer(ctx, serverID); err != nil {
+		return errors.Wrap(err, "failed to record orphaned server")
 	}
-	return sshKeys, nil
+
+	conditions.MarkFalse(s.scope.HCloudMachine,
+		infrav1.InstanceReadyCondition,
+		infrav1.ForceCleanupReason,
+		clusterv1.ConditionSeverityWarning,
+		"server has been orphaned because of annotation %s: %s",
+		infrav1.ForceCleanupAnnotation,
+		cause.Error(),
+	)
+	record.Warnf(s.scope.HCloudMachine,
+		"HCloudServerForceCleanup",
+		"Skipped deletion of server with ID %d of %s because of annotation %s: %s",
+		serverID,
+		s.scope.Name(),
+		infrav1.ForceCleanupAnnotation,
+		cause.Error(),
+	)
+	return nil
 }
 
-// Delete implements delete method of server.
-func (s *Service) Delete(ctx context.Context) (_ *ctrl.Result, err error) {
-	// find current server
-	server, err := s.findServer(ctx)
+// forceCleanupRequested returns whether the HCloudMachine has the force-cleanup annotation.
+func (s *Service) forceCleanupRequested() bool {
+	return s.scope.HCloudMachine.Annotations[infrav1.ForceCleanupAnnotation] == "true"
+}
+
+// recordOrphanedServer adds the server to the orphaned resources of the HetznerCluster, so that it
+// gets deleted as soon as the HCloud API is reachable again. A server ID of 0 means that the ID is not known.
+func (s *Service) recordOrphanedServer(ctx context.Context, serverID int) error {
+	hetznerCluster := s.scope.HetznerCluster
+	for _, res := range hetznerCluster.Status.OrphanedResources {
+		if res.Type == infrav1.OrphanedResourceTypeServer && res.ID == serverID && res.Name == s.scope.Name() {
+			return nil
+		}
+	}
+
+	helper, err := patch.NewHelper(hetznerCluster, s.scope.Client)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to find Server")
+		return errors.Wrap(err, "failed to init patch helper")
 	}
 
-	// If no server has been found then nothing can be deleted
-	if server == nil {
-		s.scope.V(2).Info("Unable to locate HCloud server by ID or tags")
-		record.Warnf(s.scope.HCloudMachine, "NoInstanceFound", "Unable to find matching HCloud server for %s", s.scope.Name())
-		return nil, nil
+	hetznerCluster.Status.OrphanedResources = append(hetznerCluster.Status.OrphanedResources, infrav1.OrphanedResource{
+		Type:       infrav1.OrphanedResourceTypeServer,
+		ID:         serverID,
+		Name:       s.scope.Name(),
+		OrphanedAt: metav1.Now(),
+	})
+
+	return helper.Patch(ctx, hetznerCluster)
+}
+
+// serverIDFromProviderID returns the server ID from the providerID. It returns 0 if no providerID is set.
+func (s *Service) serverIDFromProviderID() (int, error) {
+	providerID := s.scope.HCloudMachine.Spec.ProviderID
+	if providerID == nil || *providerID == "" {
+		return 0, nil
 	}
+	return strconv.Atoi(strings.TrimPrefix(*providerID, "hcloud://"))
+}
 
-	if s.scope.IsControlPlane() && s.scope.HetznerCluster.Spec.ControlPlaneLoadBalancer.Enabled {
-		if err := s.deleteServerOfLoadBalancer(ctx, server); err != nil {
-			return &reconcile.Result{}, errors.Errorf("Error while deleting attached server of loadbalancer: %s", err)
+// isAPIUnreachable checks whether an error means that the HCloud API could not be reached,
+// in contrast to errors returned by a reachable API.
+func isAPIUnreachable(err error) bool {
+	var hcloudErr hcloud.Error
+	if errors.As(err, &hcloudErr) {
+		switch hcloudErr.Code {
+		case hcloud.ErrorCodeServiceError, hcloud.ErrorCodeUnknownError, hcloud.ErrorCodeMaintenance:
+			return true
+		default:
+			return false
 		}
 	}
 
-	// First shut the server down, then delete it
-	switch status := server.Status; status {
-	case hcloud.ServerStatusRunning:
-		return s.handleDeleteServerStatusRunning(ctx, server)
-	case hcloud.ServerStatusOff:
-		return s.handleDeleteServerStatusOff(ctx, server)
-	default:
-		return &ctrl.Result{RequeueAfter: 5 * time.Second}, nil
-	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded)
 }
 
 func (s *Service) handleDeleteServerStatusRunning(ctx context.Context, server *hcloud.Server) (*ctrl.Result, error) {
@@ -532,19 +2135,27 @@ func setStatusFromAPI(server *hcloud.Server) infrav1.HCloudMachineStatus {
 	var status infrav1.HCloudMachineStatus
 	s := server.Status
 	status.InstanceState = &s
+
+	if server.Datacenter != nil && server.Datacenter.Location != nil {
+		status.Region = infrav1.Region(server.Datacenter.Location.Name)
+	}
 	status.Addresses = []corev1.NodeAddress{}
 
-	if ip := server.PublicNet.IPv4.IP.String(); ip != "" {
+	// Servers without public IPv4 have no address
+	if !server.PublicNet.IPv4.IsUnspecified() {
 		status.Addresses = append(
 			status.Addresses,
 			corev1.NodeAddress{
 				Type:    corev1.NodeExternalIP,
-				Address: ip,
+				Address: server.PublicNet.IPv4.IP.String(),
 			},
 		)
 	}
 
-	if ip := server.PublicNet.IPv6.IP; ip.IsGlobalUnicast() {
+	// the first address of the IPv6 network of the server is configured on its interface
+	if network := server.PublicNet.IPv6.IP; network.IsGlobalUnicast() {
+		ip := make(net.IP, len(network.To16()))
+		copy(ip, network.To16())
 		ip[15]++
 		status.Addresses = append(
 			status.Addresses,
@@ -595,39 +2206,46 @@ func (s *Service) reconcileLoadBalancerAttachment(ctx context.Context, server *h
 	}
 
 	// Only if server has private IP or public IPv4, otherwise Hetzner cannot handle it
-	if server.PublicNet.IPv4.IP != nil || hasPrivateIP {
-		loadBalancerAddServerTargetOpts := hcloud.LoadBalancerAddServerTargetOpts{
-			Server:       server,
-			UsePrivateIP: &hasPrivateIP,
-		}
-
-		if _, err := s.scope.HCloudClient.AddTargetServerToLoadBalancer(
-			ctx,
-			loadBalancerAddServerTargetOpts,
-			&hcloud.LoadBalancer{
-				ID: s.scope.HetznerCluster.Status.ControlPlaneLoadBalancer.ID,
-			}); err != nil {
-			if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
-				conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
-				record.Event(s.scope.HCloudMachine,
-					"RateLimitExceeded",
-					"exceeded rate limit with calling hcloud function AddTargetServerToLoadBalancer",
-				)
-			}
-			if hcloud.IsError(err, hcloud.ErrorCodeTargetAlreadyDefined) {
-				return nil
-			}
-			s.scope.V(1).Info("Could not add server as target to load balancer",
-				"Server", server.ID, "Load Balancer", s.scope.HetznerCluster.Status.ControlPlaneLoadBalancer.ID)
-			return err
-		}
+	if server.PublicNet.IPv4.IsUnspecified() && !hasPrivateIP {
+		record.Warnf(s.scope.HCloudMachine,
+			"ServerNotTargetable",
+			"Server %d has neither a private IP nor a public IPv4 and cannot be added to the load balancer",
+			server.ID,
+		)
+		return nil
+	}
 
-		record.Eventf(
-			s.scope.HetznerCluster,
-			"AddedAsTargetToLoadBalancer",
-			"Added new server with id %d to the loadbalancer %v",
-			server.ID, s.scope.HetznerCluster.Status.ControlPlaneLoadBalancer.ID)
+	loadBalancerAddServerTargetOpts := hcloud.LoadBalancerAddServerTargetOpts{
+		Server:       server,
+		UsePrivateIP: &hasPrivateIP,
+	}
+
+	if _, err := s.scope.HCloudClient.AddTargetServerToLoadBalancer(
+		ctx,
+		loadBalancerAddServerTargetOpts,
+		&hcloud.LoadBalancer{
+			ID: s.scope.HetznerCluster.Status.ControlPlaneLoadBalancer.ID,
+		}); err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HCloudMachine,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function AddTargetServerToLoadBalancer",
+			)
+		}
+		if hcloud.IsError(err, hcloud.ErrorCodeTargetAlreadyDefined) {
+			return nil
+		}
+		s.scope.V(1).Info("Could not add server as target to load balancer",
+			"Server", server.ID, "Load Balancer", s.scope.HetznerCluster.Status.ControlPlaneLoadBalancer.ID)
+		return err
 	}
+
+	record.Eventf(
+		s.scope.HetznerCluster,
+		"AddedAsTargetToLoadBalancer",
+		"Added new server with id %d to the loadbalancer %v",
+		server.ID, s.scope.HetznerCluster.Status.ControlPlaneLoadBalancer.ID)
 	return nil
 }
 
@@ -690,6 +2308,372 @@ func (s *Service) findServer(ctx context.Context) (*hcloud.Server, error) {
 	return servers[0], nil
 }
 
+// serverLabels returns the labels of the server: the labels that identify the server and the labels that
+// have been propagated from the Machine, e.g. for cost allocation, as far as they are selected by the
+// serverLabels of the cluster. The labels that identify the server take precedence.
+func (s *Service) serverLabels() map[string]string {
+	labels := propagation.PropagatedLabels(s.scope.HCloudMachine)
+	for key := range labels {
+		if !s.scope.HetznerCluster.Spec.ServerLabels.Selects(key) {
+			delete(labels, key)
+		}
+	}
+	for key, value := range createLabels(s.scope.HetznerCluster.Name, s.scope.Name(), s.scope.IsControlPlane()) {
+		labels[key] = value
+	}
+	return labels
+}
+
+// reconcileLabels updates the labels of the server if they differ from serverLabels. With the drift policy Adopt,
+// labels that have been added outside of the controller are kept.
+func (s *Service) reconcileLabels(ctx context.Context, server *hcloud.Server) error {
+	desired := s.serverLabels()
+	labels := desired
+	if s.scope.HetznerCluster.Spec.DriftPolicies.ServersPolicy() == infrav1.DriftPolicyAdopt {
+		labels = adoptLabels(server.Labels, desired, s.scope.HCloudMachine.Status.AppliedLabels)
+	}
+	s.reconcileServerInSync(labels)
+	if reflect.DeepEqual(server.Labels, labels) {
+		s.scope.HCloudMachine.Status.AppliedLabels = labelKeys(desired)
+		return nil
+	}
+
+	if _, err := s.scope.HCloudClient.UpdateServer(ctx, server, hcloud.ServerUpdateOpts{Labels: labels}); err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HCloudMachine,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function UpdateServer",
+			)
+		}
+		return errors.Wrap(err, "failed to update labels of server")
+	}
+	server.Labels = labels
+	s.scope.HCloudMachine.Status.AppliedLabels = labelKeys(desired)
+	return nil
+}
+
+// reconcileRescueMode boots the server into the rescue system while the HCloudMachine has the rescue annotation and
+// returns a result without requeue, so that the controller does not interfere with the debugging. Once the annotation
+// is removed, the server is booted from its disk again and reconciled as usual.
+func (s *Service) reconcileRescueMode(ctx context.Context, server *hcloud.Server) (*reconcile.Result, error) {
+	hcloudMachine := s.scope.HCloudMachine
+	_, rescueRequested := hcloudMachine.Annotations[infrav1.RescueAnnotation]
+
+	switch {
+	case rescueRequested && !hcloudMachine.Status.RescueMode:
+		sshKeys, err := s.serverSSHKeys(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := s.scope.HCloudClient.EnableServerRescue(ctx, server, hcloud.ServerEnableRescueOpts{
+			Type:    hcloud.ServerRescueTypeLinux64,
+			SSHKeys: sshKeys,
+		}); err != nil {
+			if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+				conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+				record.Event(s.scope.HCloudMachine,
+					"RateLimitExceeded",
+					"exceeded rate limit with calling hcloud function EnableServerRescue",
+				)
+			}
+			return nil, errors.Wrap(err, "failed to enable rescue mode")
+		}
+		if err := s.restartServer(ctx, server); err != nil {
+			return nil, err
+		}
+		hcloudMachine.Status.RescueMode = true
+		record.Eventf(hcloudMachine, "RescueModeEnabled", "Booted server %d into the rescue system", server.ID)
+	case rescueRequested:
+	case hcloudMachine.Status.RescueMode:
+		if server.RescueEnabled {
+			if _, err := s.scope.HCloudClient.DisableServerRescue(ctx, server); err != nil {
+				if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+					conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+					record.Event(s.scope.HCloudMachine,
+						"RateLimitExceeded",
+						"exceeded rate limit with calling hcloud function DisableServerRescue",
+					)
+				}
+				return nil, errors.Wrap(err, "failed to disable rescue mode")
+			}
+		}
+		if err := s.restartServer(ctx, server); err != nil {
+			return nil, err
+		}
+		hcloudMachine.Status.RescueMode = false
+		record.Eventf(hcloudMachine, "RescueModeDisabled", "Booted server %d from its disk after the rescue annotation has been removed", server.ID)
+		return &reconcile.Result{RequeueAfter: 10 * time.Second}, nil
+	default:
+		return nil, nil
+	}
+
+	hcloudMachine.Status.Ready = false
+	conditions.MarkFalse(hcloudMachine,
+		infrav1.InstanceReadyCondition,
+		infrav1.InstanceInRescueModeReason,
+		clusterv1.ConditionSeverityInfo,
+		"server is in the rescue system and is not reconciled until the annotation %s is removed",
+		infrav1.RescueAnnotation,
+	)
+	return &reconcile.Result{}, nil
+}
+
+// restartServer resets a running server and powers on a server that is off.
+func (s *Service) restartServer(ctx context.Context, server *hcloud.Server) error {
+	if server.Status == hcloud.ServerStatusOff {
+		if _, err := s.scope.HCloudClient.PowerOnServer(ctx, server); err != nil {
+			if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+				conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+				record.Event(s.scope.HCloudMachine,
+					"RateLimitExceeded",
+					"exceeded rate limit with calling hcloud function PowerOnServer",
+				)
+			}
+			return errors.Wrap(err, "failed to power on server")
+		}
+		return nil
+	}
+	if _, err := s.scope.HCloudClient.ResetServer(ctx, server); err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HCloudMachine,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function ResetServer",
+			)
+		}
+		return errors.Wrap(err, "failed to reset server")
+	}
+	return nil
+}
+
+// reconcileISO attaches the ISO of the spec to the new server before it is powered on for the first time, so that
+// it boots from the ISO, and detaches the ISO once the server has joined the cluster as node. The ISO stays attached
+// until then, as it might still install the operating system and reboot. Servers that are running without ISO have
+// booted already.
+func (s *Service) reconcileISO(ctx context.Context, server *hcloud.Server) (*reconcile.Result, error) {
+	hcloudMachine := s.scope.HCloudMachine
+	if hcloudMachine.Spec.ISO == "" || hcloudMachine.Status.ISODetached {
+		return nil, nil
+	}
+
+	switch {
+	case server.ISO != nil && server.Status == hcloud.ServerStatusRunning:
+		if s.scope.Machine == nil || s.scope.Machine.Status.NodeRef == nil {
+			// the server has not joined the cluster yet
+			return nil, nil
+		}
+		isoName := server.ISO.Name
+		if _, err := s.scope.HCloudClient.DetachISO(ctx, server); err != nil {
+			if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+				conditions.MarkTrue(hcloudMachine, infrav1.RateLimitExceeded)
+				record.Event(hcloudMachine,
+					"RateLimitExceeded",
+					"exceeded rate limit with calling hcloud function DetachISO",
+				)
+			}
+			return nil, errors.Wrap(err, "failed to detach ISO")
+		}
+		server.ISO = nil
+		hcloudMachine.Status.ISODetached = true
+		record.Eventf(hcloudMachine, "ISODetached", "Detached ISO %s from server %d after it joined the cluster", isoName, server.ID)
+	case server.ISO != nil:
+		// the server is powered on with the ISO
+	case server.Status == hcloud.ServerStatusOff:
+		iso, err := s.scope.HCloudClient.GetISO(ctx, hcloudMachine.Spec.ISO)
+		if err != nil {
+			if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+				conditions.MarkTrue(hcloudMachine, infrav1.RateLimitExceeded)
+				record.Event(hcloudMachine,
+					"RateLimitExceeded",
+					"exceeded rate limit with calling hcloud function GetISO",
+				)
+			}
+			return nil, errors.Wrap(err, "failed to get ISO")
+		}
+		if iso == nil {
+			conditions.MarkFalse(hcloudMachine,
+				infrav1.InstanceReadyCondition,
+				infrav1.ISONotFoundReason,
+				clusterv1.ConditionSeverityError,
+				"ISO %s does not exist",
+				hcloudMachine.Spec.ISO,
+			)
+			record.Warnf(hcloudMachine, "ISONotFound", "ISO %s does not exist", hcloudMachine.Spec.ISO)
+			return &reconcile.Result{RequeueAfter: 5 * time.Minute}, nil
+		}
+		if _, err := s.scope.HCloudClient.AttachISO(ctx, server, iso); err != nil {
+			if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+				conditions.MarkTrue(hcloudMachine, infrav1.RateLimitExceeded)
+				record.Event(hcloudMachine,
+					"RateLimitExceeded",
+					"exceeded rate limit with calling hcloud function AttachISO",
+				)
+			}
+			return nil, errors.Wrap(err, "failed to attach ISO")
+		}
+		server.ISO = iso
+		record.Eventf(hcloudMachine, "ISOAttached", "Attached ISO %s to server %d", iso.Name, server.ID)
+	case server.Status == hcloud.ServerStatusRunning:
+		hcloudMachine.Status.ISODetached = true
+	default:
+		// wait until the server has been created
+		return &reconcile.Result{RequeueAfter: 2 * time.Second}, nil
+	}
+	return nil, nil
+}
+
+// reconcileBackups turns the backups of the server on or off according to enableBackups. HCloud reports the backup
+// window of servers with backups, which is empty if backups are turned off.
+func (s *Service) reconcileBackups(ctx context.Context, server *hcloud.Server) error {
+	enableBackups := s.scope.HCloudMachine.Spec.EnableBackups
+	if enableBackups == nil {
+		return nil
+	}
+	enabled := server.BackupWindow != ""
+	if enabled == *enableBackups {
+		return nil
+	}
+
+	if *enableBackups {
+		if _, err := s.scope.HCloudClient.EnableServerBackup(ctx, server); err != nil {
+			if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+				conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+				record.Event(s.scope.HCloudMachine,
+					"RateLimitExceeded",
+					"exceeded rate limit with calling hcloud function EnableServerBackup",
+				)
+			}
+			return errors.Wrap(err, "failed to enable backups of server")
+		}
+		record.Eventf(s.scope.HCloudMachine, "BackupsEnabled", "Enabled backups of server %d", server.ID)
+		return nil
+	}
+
+	if _, err := s.scope.HCloudClient.DisableServerBackup(ctx, server); err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HCloudMachine,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function DisableServerBackup",
+			)
+		}
+		return errors.Wrap(err, "failed to disable backups of server")
+	}
+	record.Warnf(s.scope.HCloudMachine, "BackupsDisabled", "Disabled backups of server %d, its backups are deleted", server.ID)
+	return nil
+}
+
+// reconcileProtection enables or disables the delete and rebuild protection of control plane servers as configured
+// in the HetznerCluster.
+func (s *Service) reconcileProtection(ctx context.Context, server *hcloud.Server) error {
+	protect := s.scope.HetznerCluster.Spec.ProtectControlPlaneServers
+	if protect == nil || !s.scope.IsControlPlane() {
+		return nil
+	}
+	if server.Protection.Delete == *protect && server.Protection.Rebuild == *protect {
+		return nil
+	}
+
+	if err := s.changeProtection(ctx, server, *protect); err != nil {
+		return err
+	}
+	if *protect {
+		record.Eventf(s.scope.HCloudMachine, "ServerProtectionEnabled", "Enabled delete and rebuild protection of server %d", server.ID)
+	} else {
+		record.Eventf(s.scope.HCloudMachine, "ServerProtectionDisabled", "Disabled delete and rebuild protection of server %d", server.ID)
+	}
+	return nil
+}
+
+// removeProtection removes the delete and rebuild protection of a server that is about to be deleted.
+func (s *Service) removeProtection(ctx context.Context, server *hcloud.Server) error {
+	if !server.Protection.Delete && !server.Protection.Rebuild {
+		return nil
+	}
+	if err := s.changeProtection(ctx, server, false); err != nil {
+		return err
+	}
+	record.Eventf(s.scope.HCloudMachine, "ServerProtectionRemoved", "Removed protection of server %d to delete it", server.ID)
+	return nil
+}
+
+func (s *Service) changeProtection(ctx context.Context, server *hcloud.Server, protect bool) error {
+	if _, err := s.scope.HCloudClient.ChangeServerProtection(ctx, server, hcloud.ServerChangeProtectionOpts{
+		Delete:  &protect,
+		Rebuild: &protect,
+	}); err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HCloudMachine,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function ChangeServerProtection",
+			)
+		}
+		return errors.Wrapf(err, "failed to change protection of server %d", server.ID)
+	}
+	server.Protection.Delete = protect
+	server.Protection.Rebuild = protect
+	return nil
+}
+
+// adoptLabels returns the labels of the server with the desired labels set. Labels that have been applied by the
+// controller before and are no longer desired, e.g. because they have been removed from the Machine, are removed.
+func adoptLabels(current, desired map[string]string, applied []string) map[string]string {
+	labels := make(map[string]string, len(current)+len(desired))
+	for key, value := range current {
+		labels[key] = value
+	}
+	for _, key := range applied {
+		if _, found := desired[key]; !found {
+			delete(labels, key)
+		}
+	}
+	for key, value := range desired {
+		labels[key] = value
+	}
+	return labels
+}
+
+// labelKeys returns the sorted keys of the labels.
+func labelKeys(labels map[string]string) []string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// reconcileServerInSync sets the condition ServerInSync, which is false if labels of the server are kept that are
+// not managed by the controller.
+func (s *Service) reconcileServerInSync(labels map[string]string) {
+	managed := s.serverLabels()
+	var adopted []string
+	for key := range labels {
+		if _, found := managed[key]; !found {
+			adopted = append(adopted, key)
+		}
+	}
+	if len(adopted) == 0 {
+		conditions.MarkTrue(s.scope.HCloudMachine, infrav1.ServerInSyncCondition)
+		return
+	}
+
+	sort.Strings(adopted)
+	msg := fmt.Sprintf("kept labels added outside of the controller: %s", strings.Join(adopted, ", "))
+	if conditions.GetMessage(s.scope.HCloudMachine, infrav1.ServerInSyncCondition) != msg {
+		record.Eventf(s.scope.HCloudMachine, "AdoptServerDrift", "Server %s", msg)
+	}
+	conditions.MarkFalse(
+		s.scope.HCloudMachine,
+		infrav1.ServerInSyncCondition,
+		infrav1.DriftAdoptedReason,
+		clusterv1.ConditionSeverityInfo,
+		msg,
+	)
+}
+
 func createLabels(hcloudClusterName, hcloudMachineName string, isControlPlane bool) map[string]string {
 	m := map[string]string{
 		infrav1.ClusterTagKey(hcloudClusterName): string(infrav1.ResourceLifecycleOwned),
@@ -191,7 +191,7 @@ var server *hcloud.Server
 
 const instanceState = hcloud.ServerStatusRunning
 
-var ips = []string{"1.2.3.4", "2001:db8::3", "10.0.0.2"}
+var ips = []string{"1.2.3.4", "2001:db8::1", "10.0.0.2"}
 var addressTypes = []corev1.NodeAddressType{corev1.NodeExternalIP, corev1.NodeExternalIP, corev1.NodeInternalIP}
 
 func TestServer(t *testing.T) {
@@ -18,17 +18,30 @@ package server
 
 import (
 	"context"
+	"fmt"
+	"net"
+	"net/url"
 	"time"
 
+	"github.com/go-logr/logr"
 	"github.com/hetznercloud/hcloud-go/hcloud"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
 	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	hcloudclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client"
 	fakeclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client/fake"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/utils/pointer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakek8sclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -54,6 +67,25 @@ var _ = Describe("setStatusFromAPI", func() {
 			Expect(addr.Type).To(Equal(addressTypes[i]))
 		}
 	})
+	It("should have no IPv4 address if the server has no public IPv4", func() {
+		sts := setStatusFromAPI(&hcloud.Server{
+			PublicNet: hcloud.ServerPublicNet{
+				IPv6: hcloud.ServerPublicNetIPv6{IP: net.ParseIP("2001:db8::")},
+			},
+		})
+		Expect(sts.Addresses).To(Equal([]corev1.NodeAddress{{Type: corev1.NodeExternalIP, Address: "2001:db8::1"}}))
+	})
+	It("should not change the IPv6 network of the server", func() {
+		ipv6Server := &hcloud.Server{
+			PublicNet: hcloud.ServerPublicNet{
+				IPv6: hcloud.ServerPublicNetIPv6{IP: net.ParseIP("2001:db8::")},
+			},
+		}
+		setStatusFromAPI(ipv6Server)
+		sts := setStatusFromAPI(ipv6Server)
+		Expect(sts.Addresses).To(Equal([]corev1.NodeAddress{{Type: corev1.NodeExternalIP, Address: "2001:db8::1"}}))
+		Expect(ipv6Server.PublicNet.IPv6.IP.String()).To(Equal("2001:db8::"))
+	})
 })
 
 var _ = DescribeTable("createLabels",
@@ -235,3 +267,1690 @@ var _ = Describe("handleServerStatusOff", func() {
 		Expect(server.Status).To(Equal(hcloud.ServerStatusRunning))
 	})
 })
+
+var _ = DescribeTable("isAPIUnreachable",
+	func(err error, expectedOutput bool) {
+		Expect(isAPIUnreachable(err)).To(Equal(expectedOutput))
+	},
+	Entry("service_error", hcloud.Error{Code: hcloud.ErrorCodeServiceError}, true),
+	Entry("wrapped_maintenance", errors.Wrap(hcloud.Error{Code: hcloud.ErrorCodeMaintenance}, "wrapped"), true),
+	Entry("not_found", hcloud.Error{Code: hcloud.ErrorCodeNotFound}, false),
+	Entry("network_error", &url.Error{Op: "Get", URL: "https://api.hetzner.cloud", Err: &net.OpError{Op: "dial"}}, true),
+	Entry("deadline_exceeded", errors.Wrap(context.DeadlineExceeded, "wrapped"), true),
+	Entry("other_error", errors.New("other error"), false),
+)
+
+var _ = Describe("handleDeleteAPIUnreachable", func() {
+	var hcloudMachine *infrav1.HCloudMachine
+	client := fakeclient.NewHCloudClientFactory().NewClient("")
+	apiErr := hcloud.Error{Code: hcloud.ErrorCodeServiceError}
+
+	BeforeEach(func() {
+		hcloudMachine = &infrav1.HCloudMachine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "hcloudMachineName",
+				Namespace: "default",
+			},
+			Spec: infrav1.HCloudMachineSpec{
+				ImageName:  "fedora-control-plane",
+				Type:       "cpx31",
+				ProviderID: pointer.String("hcloud://42"),
+			},
+		}
+	})
+
+	It("returns an error without deletion policy", func() {
+		service := newTestService(hcloudMachine, client)
+		_, err := service.handleDeleteAPIUnreachable(context.Background(), apiErr)
+		Expect(err).ToNot(Succeed())
+		Expect(conditions.IsFalse(hcloudMachine, infrav1.HCloudAPIReachableCondition)).To(BeTrue())
+	})
+
+	It("returns an error with policy wait", func() {
+		hcloudMachine.Spec.DeletionPolicy = &infrav1.DeletionPolicy{Type: infrav1.DeletionPolicyTypeWait}
+		service := newTestService(hcloudMachine, client)
+		_, err := service.handleDeleteAPIUnreachable(context.Background(), apiErr)
+		Expect(err).ToNot(Succeed())
+	})
+
+	It("requeues if the timeout is not reached yet", func() {
+		hcloudMachine.Spec.DeletionPolicy = &infrav1.DeletionPolicy{Type: infrav1.DeletionPolicyTypeOrphanAfterTimeout}
+		service := newTestService(hcloudMachine, client)
+		res, err := service.handleDeleteAPIUnreachable(context.Background(), apiErr)
+		Expect(err).To(Succeed())
+		Expect(res).Should(Equal(&reconcile.Result{RequeueAfter: 30 * time.Second}))
+		Expect(conditions.GetReason(hcloudMachine, infrav1.HCloudAPIReachableCondition)).To(Equal(infrav1.HCloudAPIUnreachableReason))
+	})
+
+	It("orphans the server immediately with the force-cleanup annotation", func() {
+		hcloudMachine.Annotations = map[string]string{infrav1.ForceCleanupAnnotation: "true"}
+		hetznerCluster := &infrav1.HetznerCluster{ObjectMeta: metav1.ObjectMeta{Name: "hetzner-cluster", Namespace: "default"}}
+
+		scheme := runtime.NewScheme()
+		utilruntime.Must(infrav1.AddToScheme(scheme))
+		service := newTestService(hcloudMachine, client)
+		service.scope.Client = fakek8sclient.NewClientBuilder().WithScheme(scheme).WithObjects(hetznerCluster).Build()
+		service.scope.HetznerCluster = hetznerCluster
+
+		res, err := service.handleDeleteAPIUnreachable(context.Background(), apiErr)
+		Expect(err).To(Succeed())
+		Expect(res).To(BeNil())
+		Expect(conditions.GetReason(hcloudMachine, infrav1.InstanceReadyCondition)).To(Equal(infrav1.ForceCleanupReason))
+		Expect(hetznerCluster.Status.OrphanedResources).To(HaveLen(1))
+		Expect(hetznerCluster.Status.OrphanedResources[0].ID).To(Equal(42))
+	})
+
+	It("records a server without providerID by the name of the machine", func() {
+		hcloudMachine.Spec.ProviderID = nil
+		hcloudMachine.Annotations = map[string]string{infrav1.ForceCleanupAnnotation: "true"}
+		hetznerCluster := &infrav1.HetznerCluster{ObjectMeta: metav1.ObjectMeta{Name: "hetzner-cluster", Namespace: "default"}}
+
+		scheme := runtime.NewScheme()
+		utilruntime.Must(infrav1.AddToScheme(scheme))
+		service := newTestService(hcloudMachine, client)
+		service.scope.Client = fakek8sclient.NewClientBuilder().WithScheme(scheme).WithObjects(hetznerCluster).Build()
+		service.scope.HetznerCluster = hetznerCluster
+
+		_, err := service.handleDeleteAPIUnreachable(context.Background(), apiErr)
+		Expect(err).To(Succeed())
+		Expect(hetznerCluster.Status.OrphanedResources).To(HaveLen(1))
+		Expect(hetznerCluster.Status.OrphanedResources[0].ID).To(BeZero())
+		Expect(hetznerCluster.Status.OrphanedResources[0].Name).To(Equal("hcloudMachineName"))
+	})
+
+	It("keeps the primary IPs of an orphaned server claimed", func() {
+		hcloudMachine.Annotations = map[string]string{infrav1.ForceCleanupAnnotation: "true"}
+		hetznerCluster := &infrav1.HetznerCluster{ObjectMeta: metav1.ObjectMeta{Name: "hetzner-cluster", Namespace: "default"}}
+		primaryIP := &infrav1.HCloudPrimaryIP{
+			ObjectMeta: metav1.ObjectMeta{Name: "primary-ip", Namespace: "default"},
+			Spec: infrav1.HCloudPrimaryIPSpec{
+				Type:        infrav1.PrimaryIPTypeIPv4,
+				ConsumerRef: &corev1.ObjectReference{Name: "hcloudMachineName", Namespace: "default"},
+			},
+		}
+
+		scheme := runtime.NewScheme()
+		utilruntime.Must(infrav1.AddToScheme(scheme))
+		service := newTestService(hcloudMachine, client)
+		service.scope.Client = fakek8sclient.NewClientBuilder().WithScheme(scheme).WithObjects(hetznerCluster, primaryIP).Build()
+		service.scope.HetznerCluster = hetznerCluster
+
+		_, err := service.handleDeleteAPIUnreachable(context.Background(), apiErr)
+		Expect(err).To(Succeed())
+		Expect(service.scope.Client.Get(context.Background(), types.NamespacedName{Name: "primary-ip", Namespace: "default"}, primaryIP)).To(Succeed())
+		Expect(primaryIP.Spec.ConsumerRef).ToNot(BeNil())
+	})
+})
+
+var _ = Describe("choosePrimaryIP", func() {
+	hcloudMachine := &infrav1.HCloudMachine{
+		ObjectMeta: metav1.ObjectMeta{Name: "hcloudMachineName", Namespace: "default"},
+	}
+
+	newPrimaryIP := func(name string, family infrav1.PrimaryIPType, location string, consumer *corev1.ObjectReference) infrav1.HCloudPrimaryIP {
+		return infrav1.HCloudPrimaryIP{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       infrav1.HCloudPrimaryIPSpec{Type: family, ConsumerRef: consumer},
+			Status:     infrav1.HCloudPrimaryIPStatus{Ready: true, Location: infrav1.Region(location)},
+		}
+	}
+
+	It("prefers a primary IP already claimed by the machine", func() {
+		primaryIPs := []infrav1.HCloudPrimaryIP{
+			newPrimaryIP("free", infrav1.PrimaryIPTypeIPv4, "fsn1", nil),
+			newPrimaryIP("claimed", infrav1.PrimaryIPTypeIPv4, "fsn1", &corev1.ObjectReference{Name: "hcloudMachineName", Namespace: "default"}),
+		}
+		Expect(choosePrimaryIP(primaryIPs, hcloudMachine, infrav1.PrimaryIPTypeIPv4, "fsn1").Name).To(Equal("claimed"))
+	})
+
+	It("ignores primary IPs of other machines, families and locations", func() {
+		primaryIPs := []infrav1.HCloudPrimaryIP{
+			newPrimaryIP("other-machine", infrav1.PrimaryIPTypeIPv4, "fsn1", &corev1.ObjectReference{Name: "other", Namespace: "default"}),
+			newPrimaryIP("ipv6", infrav1.PrimaryIPTypeIPv6, "fsn1", nil),
+			newPrimaryIP("nbg1", infrav1.PrimaryIPTypeIPv4, "nbg1", nil),
+		}
+		Expect(choosePrimaryIP(primaryIPs, hcloudMachine, infrav1.PrimaryIPTypeIPv4, "fsn1")).To(BeNil())
+		Expect(choosePrimaryIP(primaryIPs, hcloudMachine, infrav1.PrimaryIPTypeIPv6, "fsn1").Name).To(Equal("ipv6"))
+	})
+	It("skips primary IPs that are still assigned to a server", func() {
+		assigned := newPrimaryIP("assigned", infrav1.PrimaryIPTypeIPv4, "fsn1", nil)
+		assigned.Status.AssigneeID = 42
+		primaryIPs := []infrav1.HCloudPrimaryIP{assigned, newPrimaryIP("free", infrav1.PrimaryIPTypeIPv4, "fsn1", nil)}
+		Expect(choosePrimaryIP(primaryIPs, hcloudMachine, infrav1.PrimaryIPTypeIPv4, "fsn1").Name).To(Equal("free"))
+	})
+})
+
+var _ = Describe("assignPrimaryIPs", func() {
+	var k8sClient client.Client
+
+	newService := func(name string) *Service {
+		hcloudMachine := &infrav1.HCloudMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: infrav1.HCloudMachineSpec{
+				PublicNetwork: &infrav1.PublicNetworkSpec{EnableIPv4: true},
+			},
+		}
+		service := newTestService(hcloudMachine, fakeclient.NewHCloudClientFactory().NewClient(""))
+		service.scope.Client = k8sClient
+		return service
+	}
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		utilruntime.Must(infrav1.AddToScheme(scheme))
+		k8sClient = fakek8sclient.NewClientBuilder().WithScheme(scheme).Build()
+		for i, name := range []string{"first", "second"} {
+			primaryIP := &infrav1.HCloudPrimaryIP{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+				Spec:       infrav1.HCloudPrimaryIPSpec{Type: infrav1.PrimaryIPTypeIPv4},
+			}
+			Expect(k8sClient.Create(context.Background(), primaryIP)).To(Succeed())
+			primaryIP.Status = infrav1.HCloudPrimaryIPStatus{Ready: true, ID: i + 1, Location: "fsn1"}
+			Expect(k8sClient.Status().Update(context.Background(), primaryIP)).To(Succeed())
+		}
+	})
+
+	It("claims different primary IPs for different machines", func() {
+		first := &hcloud.ServerCreatePublicNet{EnableIPv4: true}
+		Expect(newService("machine-a").assignPrimaryIPs(context.Background(), first, "fsn1")).To(Succeed())
+		second := &hcloud.ServerCreatePublicNet{EnableIPv4: true}
+		Expect(newService("machine-b").assignPrimaryIPs(context.Background(), second, "fsn1")).To(Succeed())
+		Expect(first.IPv4.ID).ToNot(Equal(second.IPv4.ID))
+
+		var primaryIPs infrav1.HCloudPrimaryIPList
+		Expect(k8sClient.List(context.Background(), &primaryIPs)).To(Succeed())
+		consumers := make([]string, 0, len(primaryIPs.Items))
+		for _, primaryIP := range primaryIPs.Items {
+			Expect(primaryIP.Spec.ConsumerRef).ToNot(BeNil())
+			consumers = append(consumers, primaryIP.Spec.ConsumerRef.Name)
+		}
+		Expect(consumers).To(ConsistOf("machine-a", "machine-b"))
+	})
+
+	It("does not claim a primary IP that is still assigned to a server", func() {
+		var primaryIP infrav1.HCloudPrimaryIP
+		Expect(k8sClient.Get(context.Background(), client.ObjectKey{Name: "first", Namespace: "default"}, &primaryIP)).To(Succeed())
+		primaryIP.Status.AssigneeID = 42
+		Expect(k8sClient.Status().Update(context.Background(), &primaryIP)).To(Succeed())
+
+		publicNet := &hcloud.ServerCreatePublicNet{EnableIPv4: true}
+		Expect(newService("machine-a").assignPrimaryIPs(context.Background(), publicNet, "fsn1")).To(Succeed())
+		Expect(publicNet.IPv4.ID).To(Equal(2))
+	})
+})
+
+var _ = Describe("assignExistingPrimaryIPs", func() {
+	var service *Service
+	var primaryIP *hcloud.PrimaryIP
+	var primaryIPCount int
+
+	BeforeEach(func() {
+		primaryIPCount++
+		client := fakeclient.NewHCloudClientFactory().NewClient("")
+		autoDelete := true
+		res, err := client.CreatePrimaryIP(context.Background(), hcloud.PrimaryIPCreateOpts{
+			Name:         fmt.Sprintf("existing-primary-ip-%d", primaryIPCount),
+			Type:         hcloud.PrimaryIPTypeIPv4,
+			Datacenter:   "fsn1-dc14",
+			AssigneeType: "server",
+			AutoDelete:   &autoDelete,
+		})
+		Expect(err).To(Succeed())
+		primaryIP = res.PrimaryIP
+
+		hcloudMachine := &infrav1.HCloudMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: "hcloudMachineName", Namespace: "default"},
+			Spec: infrav1.HCloudMachineSpec{
+				Type: "cpx31",
+				PublicNetwork: &infrav1.PublicNetworkSpec{
+					EnableIPv4:    true,
+					PrimaryIPv4ID: pointer.Int(primaryIP.ID),
+				},
+			},
+		}
+		service = newTestService(hcloudMachine, client)
+	})
+
+	It("assigns the primary IP and disables its auto delete", func() {
+		publicNet := &hcloud.ServerCreatePublicNet{EnableIPv4: true}
+		Expect(service.assignExistingPrimaryIPs(context.Background(), publicNet, "fsn1")).To(Succeed())
+		Expect(publicNet.IPv4).To(Equal(&hcloud.PrimaryIP{ID: primaryIP.ID}))
+		Expect(publicNet.IPv6).To(BeNil())
+
+		updated, err := service.scope.HCloudClient.GetPrimaryIP(context.Background(), primaryIP.ID)
+		Expect(err).To(Succeed())
+		Expect(updated.AutoDelete).To(BeFalse())
+	})
+
+	It("does not assign a primary IP of another location", func() {
+		err := service.assignExistingPrimaryIPs(context.Background(), &hcloud.ServerCreatePublicNet{EnableIPv4: true}, "nbg1")
+		Expect(err).To(HaveOccurred())
+		Expect(conditions.GetReason(service.scope.HCloudMachine, infrav1.InstanceReadyCondition)).To(Equal(infrav1.PrimaryIPNotAvailableReason))
+	})
+
+	It("waits for a primary IP that is still assigned", func() {
+		primaryIP.AssigneeID = 42
+		err := service.assignExistingPrimaryIPs(context.Background(), &hcloud.ServerCreatePublicNet{EnableIPv4: true}, "fsn1")
+		Expect(err).To(HaveOccurred())
+		Expect(conditions.GetReason(service.scope.HCloudMachine, infrav1.InstanceReadyCondition)).To(Equal(infrav1.PrimaryIPNotAvailableReason))
+	})
+
+	It("reports a primary IP that does not exist", func() {
+		service.scope.HCloudMachine.Spec.PublicNetwork.PrimaryIPv4ID = pointer.Int(4242)
+		err := service.assignExistingPrimaryIPs(context.Background(), &hcloud.ServerCreatePublicNet{EnableIPv4: true}, "fsn1")
+		Expect(err).To(HaveOccurred())
+		Expect(conditions.GetReason(service.scope.HCloudMachine, infrav1.InstanceReadyCondition)).To(Equal(infrav1.PrimaryIPNotFoundReason))
+	})
+})
+
+var _ = Describe("appliedConfiguration", func() {
+	sshKeys := []*hcloud.SSHKey{
+		{Name: "sshkey1", Fingerprint: "b7:2f:30:a0:2f:6c:58:6c:21:04:58:61:ba:06:3b:2c"},
+		{Name: "sshkey2", Fingerprint: "b7:2f:30:a0:2f:6c:58:6c:21:04:58:61:ba:06:3b:2d"},
+	}
+
+	It("records image, user data hash and ssh key fingerprints", func() {
+		image := &hcloud.Image{ID: 42, Name: "ubuntu-22.04"}
+		config := appliedConfiguration(image, []byte("user data"), sshKeys)
+		Expect(config.ImageID).To(Equal(42))
+		Expect(config.Image).To(Equal("ubuntu-22.04"))
+		Expect(config.UserDataHash).To(Equal("52408ce928fcece4a50261fcbb1c3a1556b12bd3ad2c32ee0fd5a8d429b46193"))
+		Expect(config.SSHKeyFingerprints).To(Equal([]string{sshKeys[0].Fingerprint, sshKeys[1].Fingerprint}))
+		Expect(config.AppliedAt).ToNot(BeNil())
+	})
+
+	It("uses the description of snapshots without a name", func() {
+		image := &hcloud.Image{ID: 43, Description: "my-snapshot", Type: hcloud.ImageTypeSnapshot}
+		Expect(appliedConfiguration(image, nil, nil).Image).To(Equal("my-snapshot"))
+	})
+})
+
+var _ = Describe("serverLocations", func() {
+	var service *Service
+
+	BeforeEach(func() {
+		hcloudMachine := &infrav1.HCloudMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: "hcloudMachineName", Namespace: "default"},
+			Spec: infrav1.HCloudMachineSpec{
+				Type:          "cpx31",
+				PublicNetwork: &infrav1.PublicNetworkSpec{EnableIPv4: true},
+			},
+		}
+		service = newTestService(hcloudMachine, nil)
+		service.scope.Machine = &clusterv1.Machine{}
+		service.scope.Cluster = &clusterv1.Cluster{
+			Status: clusterv1.ClusterStatus{
+				FailureDomains: clusterv1.FailureDomains{
+					"fsn1": clusterv1.FailureDomainSpec{ControlPlane: true},
+					"hel1": clusterv1.FailureDomainSpec{ControlPlane: true},
+					"nbg1": clusterv1.FailureDomainSpec{ControlPlane: true},
+				},
+			},
+		}
+		service.scope.HetznerCluster = &infrav1.HetznerCluster{}
+	})
+
+	exhausted := func(location string, serverType infrav1.HCloudMachineType, retryAfter time.Time) infrav1.ExhaustedLocation {
+		return infrav1.ExhaustedLocation{
+			Location:   infrav1.Region(location),
+			ServerType: serverType,
+			RetryAfter: metav1.NewTime(retryAfter),
+		}
+	}
+
+	It("prefers the failure domain of the machine", func() {
+		Expect(service.serverLocations(context.Background(), "nbg1")).To(Equal([]string{"nbg1", "fsn1", "hel1"}))
+	})
+
+	It("skips exhausted locations of the same server type", func() {
+		service.scope.HetznerCluster.Status.ExhaustedLocations = []infrav1.ExhaustedLocation{
+			exhausted("nbg1", "cpx31", time.Now().Add(time.Minute)),
+			exhausted("fsn1", "cpx41", time.Now().Add(time.Minute)),
+			exhausted("hel1", "cpx31", time.Now().Add(-time.Minute)),
+		}
+		Expect(service.serverLocations(context.Background(), "nbg1")).To(Equal([]string{"fsn1", "hel1"}))
+	})
+
+	It("uses the failure domain of the machine if all locations are exhausted", func() {
+		for _, location := range []string{"fsn1", "hel1", "nbg1"} {
+			service.scope.HetznerCluster.Status.ExhaustedLocations = append(service.scope.HetznerCluster.Status.ExhaustedLocations,
+				exhausted(location, "cpx31", time.Now().Add(time.Minute)))
+		}
+		Expect(service.serverLocations(context.Background(), "hel1")).To(Equal([]string{"hel1"}))
+	})
+
+	It("does not fall back if the Machine sets a failure domain", func() {
+		service.scope.Machine.Spec.FailureDomain = pointer.String("nbg1")
+		Expect(service.serverLocations(context.Background(), "nbg1")).To(Equal([]string{"nbg1"}))
+	})
+
+	It("does not fall back if the machine uses primary IPs", func() {
+		service.scope.HCloudMachine.Spec.PublicNetwork.PrimaryIPSelector = &metav1.LabelSelector{}
+		Expect(service.serverLocations(context.Background(), "nbg1")).To(Equal([]string{"nbg1"}))
+	})
+
+	It("skips locations that are not allowed by the placement constraints", func() {
+		service.scope.HetznerCluster.Spec.PlacementConstraints = &infrav1.PlacementConstraints{
+			AllowedLocations: []infrav1.Region{"fsn1", "nbg1", "hel1"},
+			DeniedLocations:  []infrav1.Region{"hel1"},
+		}
+		Expect(service.serverLocations(context.Background(), "nbg1")).To(Equal([]string{"nbg1", "fsn1"}))
+	})
+
+	It("falls back to the locations with the fewest control planes for control planes", func() {
+		controlPlane := func(name string, region infrav1.Region) *infrav1.HCloudMachine {
+			return &infrav1.HCloudMachine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: "default",
+					Labels: map[string]string{
+						clusterv1.ClusterLabelName:             "cluster",
+						clusterv1.MachineControlPlaneLabelName: "",
+					},
+				},
+				Status: infrav1.HCloudMachineStatus{Region: region},
+			}
+		}
+
+		scheme := runtime.NewScheme()
+		utilruntime.Must(infrav1.AddToScheme(scheme))
+		service.scope.Client = fakek8sclient.NewClientBuilder().WithScheme(scheme).WithObjects(
+			controlPlane("control-plane-1", "fsn1"),
+			controlPlane("control-plane-2", "fsn1"),
+			controlPlane("control-plane-3", "hel1"),
+		).Build()
+		service.scope.Cluster.Name = "cluster"
+		service.scope.Machine.Labels = map[string]string{clusterv1.MachineControlPlaneLabelName: ""}
+
+		Expect(service.serverLocations(context.Background(), "nbg1")).To(Equal([]string{"nbg1", "hel1", "fsn1"}))
+	})
+
+	It("does not create a server in a location that is not allowed", func() {
+		service.scope.HetznerCluster.Spec.PlacementConstraints = &infrav1.PlacementConstraints{
+			AllowedLocations: []infrav1.Region{"fsn1", "nbg1"},
+		}
+		_, err := service.createServer(context.Background(), "hel1")
+		Expect(err).To(HaveOccurred())
+		Expect(conditions.GetReason(service.scope.HCloudMachine, infrav1.InstanceReadyCondition)).To(Equal(infrav1.LocationNotAllowedReason))
+	})
+})
+
+var _ = Describe("reconcilePublicNetwork", func() {
+	var service *Service
+	var server *hcloud.Server
+	var serverCount int
+
+	BeforeEach(func() {
+		serverCount++
+		client := fakeclient.NewHCloudClientFactory().NewClient("")
+		res, err := client.CreateServer(context.Background(), hcloud.ServerCreateOpts{
+			Name:      fmt.Sprintf("publicNetworkServer-%d", serverCount),
+			Location:  &hcloud.Location{Name: "fsn1"},
+			PublicNet: &hcloud.ServerCreatePublicNet{EnableIPv6: true},
+		})
+		Expect(err).To(Succeed())
+		server = res.Server
+
+		hcloudMachine := &infrav1.HCloudMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: "hcloudMachineName", Namespace: "default"},
+			Spec: infrav1.HCloudMachineSpec{
+				Type:          "cpx31",
+				PublicNetwork: &infrav1.PublicNetworkSpec{EnableIPv6: true},
+			},
+			Status: infrav1.HCloudMachineStatus{
+				AppliedPublicNetwork: &infrav1.AppliedPublicNetwork{EnableIPv6: true},
+			},
+		}
+
+		scheme := runtime.NewScheme()
+		utilruntime.Must(infrav1.AddToScheme(scheme))
+		service = newTestService(hcloudMachine, client)
+		service.scope.Client = fakek8sclient.NewClientBuilder().WithScheme(scheme).Build()
+		service.scope.HetznerCluster = &infrav1.HetznerCluster{
+			Spec: infrav1.HetznerClusterSpec{HCloudNetwork: infrav1.HCloudNetworkSpec{Enabled: true}},
+		}
+	})
+
+	It("does nothing if the public network matches the spec", func() {
+		res, err := service.reconcilePublicNetwork(context.Background(), server)
+		Expect(err).To(Succeed())
+		Expect(res).To(BeNil())
+		Expect(server.Status).To(Equal(hcloud.ServerStatusRunning))
+	})
+
+	It("enables IPv4 of control planes if the load balancer cannot reach them through a private network", func() {
+		service.scope.HetznerCluster.Spec.HCloudNetwork.Enabled = false
+		service.scope.HetznerCluster.Spec.ControlPlaneLoadBalancer.Enabled = true
+		service.scope.Machine = &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{clusterv1.MachineControlPlaneLabelName: ""},
+		}}
+		enable, disable := service.publicNetworkChanges(server)
+		Expect(enable).To(Equal([]infrav1.PrimaryIPType{infrav1.PrimaryIPTypeIPv4}))
+		Expect(disable).To(BeEmpty())
+	})
+
+	It("keeps workers IPv6-only if the cluster has no private network", func() {
+		service.scope.HetznerCluster.Spec.HCloudNetwork.Enabled = false
+		service.scope.HetznerCluster.Spec.ControlPlaneLoadBalancer.Enabled = true
+		service.scope.Machine = &clusterv1.Machine{}
+		enable, disable := service.publicNetworkChanges(server)
+		Expect(enable).To(BeEmpty())
+		Expect(disable).To(BeEmpty())
+	})
+
+	It("keeps the public network of a server until the spec differs from the one applied before", func() {
+		service.scope.HCloudMachine.Status.AppliedPublicNetwork = nil
+		service.scope.HCloudMachine.Spec.PublicNetwork.EnableIPv6 = false
+
+		res, err := service.reconcilePublicNetwork(context.Background(), server)
+		Expect(err).To(Succeed())
+		Expect(res).To(BeNil())
+		Expect(server.PublicNet.IPv6.IsUnspecified()).To(BeFalse())
+		Expect(service.scope.HCloudMachine.Status.AppliedPublicNetwork).To(Equal(&infrav1.AppliedPublicNetwork{}))
+
+		enable, disable := service.publicNetworkChanges(server)
+		Expect(enable).To(BeEmpty())
+		Expect(disable).To(BeEmpty())
+
+		service.scope.HCloudMachine.Spec.PublicNetwork.EnableIPv4 = true
+		enable, disable = service.publicNetworkChanges(server)
+		Expect(enable).To(Equal([]infrav1.PrimaryIPType{infrav1.PrimaryIPTypeIPv4}))
+		Expect(disable).To(BeEmpty())
+	})
+
+	It("shuts down a running server before changing the public network", func() {
+		service.scope.HCloudMachine.Spec.PublicNetwork.EnableIPv4 = true
+		res, err := service.reconcilePublicNetwork(context.Background(), server)
+		Expect(err).To(Succeed())
+		Expect(res).To(Equal(&reconcile.Result{RequeueAfter: 10 * time.Second}))
+		Expect(server.Status).To(Equal(hcloud.ServerStatusOff))
+		Expect(conditions.GetReason(service.scope.HCloudMachine, infrav1.InstanceReadyCondition)).To(Equal(infrav1.PublicNetworkChangingReason))
+		Expect(server.PublicNet.IPv4.IsUnspecified()).To(BeTrue())
+	})
+
+	It("changes the primary IPs of a server that is off", func() {
+		server.Status = hcloud.ServerStatusOff
+		service.scope.HCloudMachine.Spec.PublicNetwork = &infrav1.PublicNetworkSpec{EnableIPv4: true}
+		ipv6ID := server.PublicNet.IPv6.ID
+
+		res, err := service.reconcilePublicNetwork(context.Background(), server)
+		Expect(err).To(Succeed())
+		Expect(res).To(Equal(&reconcile.Result{RequeueAfter: 2 * time.Second}))
+		Expect(server.PublicNet.IPv4.IsUnspecified()).To(BeFalse())
+		Expect(server.PublicNet.IPv6.IsUnspecified()).To(BeTrue())
+
+		primaryIP, err := service.scope.HCloudClient.GetPrimaryIP(context.Background(), server.PublicNet.IPv4.ID)
+		Expect(err).To(Succeed())
+		Expect(primaryIP.AssigneeID).To(Equal(server.ID))
+		Expect(primaryIP.AutoDelete).To(BeTrue())
+		if ipv6ID != server.PublicNet.IPv4.ID {
+			deleted, err := service.scope.HCloudClient.GetPrimaryIP(context.Background(), ipv6ID)
+			Expect(err).To(Succeed())
+			Expect(deleted).To(BeNil())
+		}
+
+		res, err = service.reconcilePublicNetwork(context.Background(), server)
+		Expect(err).To(Succeed())
+		Expect(res).To(BeNil())
+	})
+})
+
+var _ = Describe("privateNetworkGateway", func() {
+	var service *Service
+
+	BeforeEach(func() {
+		hcloudMachine := &infrav1.HCloudMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: "hcloudMachineName", Namespace: "default"},
+			Spec: infrav1.HCloudMachineSpec{
+				Type:          "cpx31",
+				PublicNetwork: &infrav1.PublicNetworkSpec{},
+			},
+		}
+		service = newTestService(hcloudMachine, nil)
+		service.scope.Machine = &clusterv1.Machine{}
+		service.scope.HetznerCluster = &infrav1.HetznerCluster{
+			Spec: infrav1.HetznerClusterSpec{
+				HCloudNetwork: infrav1.HCloudNetworkSpec{Enabled: true, CIDRBlock: "10.0.0.0/16"},
+			},
+			Status: infrav1.HetznerClusterStatus{
+				Network: &infrav1.NetworkStatus{ID: 1, DefaultRouteGateway: "10.0.0.2"},
+			},
+		}
+	})
+
+	It("returns the gateway of the network for servers without public IPs", func() {
+		Expect(service.privateOnly()).To(BeTrue())
+		gateway, err := service.privateNetworkGateway()
+		Expect(err).To(Succeed())
+		Expect(gateway).To(Equal("10.0.0.1"))
+	})
+
+	It("requires a default route of the network", func() {
+		service.scope.HetznerCluster.Status.Network.DefaultRouteGateway = ""
+		_, err := service.privateNetworkGateway()
+		Expect(err).To(HaveOccurred())
+		Expect(conditions.GetReason(service.scope.HCloudMachine, infrav1.InstanceReadyCondition)).To(Equal(infrav1.NoRouteToInternetReason))
+	})
+
+	It("requires a private network", func() {
+		service.scope.HetznerCluster.Spec.HCloudNetwork.Enabled = false
+		service.scope.HetznerCluster.Status.Network = nil
+		_, err := service.privateNetworkGateway()
+		Expect(err).To(HaveOccurred())
+		Expect(conditions.GetReason(service.scope.HCloudMachine, infrav1.InstanceReadyCondition)).To(Equal(infrav1.PrivateNetworkRequiredReason))
+	})
+
+	It("does not treat control planes that need a public IPv4 as private", func() {
+		service.scope.HetznerCluster.Spec.HCloudNetwork.Enabled = false
+		service.scope.HetznerCluster.Spec.ControlPlaneLoadBalancer.Enabled = true
+		service.scope.Machine.Labels = map[string]string{clusterv1.MachineControlPlaneLabelName: ""}
+		Expect(service.privateOnly()).To(BeFalse())
+	})
+})
+
+var _ = Describe("reconcileBackups", func() {
+	var service *Service
+	var server *hcloud.Server
+	var serverCount int
+
+	BeforeEach(func() {
+		serverCount++
+		client := fakeclient.NewHCloudClientFactory().NewClient("")
+		res, err := client.CreateServer(context.Background(), hcloud.ServerCreateOpts{Name: fmt.Sprintf("backupServer-%d", serverCount)})
+		Expect(err).To(Succeed())
+		server = res.Server
+
+		hcloudMachine := &infrav1.HCloudMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: "hcloudMachineName", Namespace: "default"},
+			Spec:       infrav1.HCloudMachineSpec{Type: "cpx31"},
+		}
+		service = newTestService(hcloudMachine, client)
+	})
+
+	It("does not manage backups without enableBackups", func() {
+		server.BackupWindow = "22-02"
+		Expect(service.reconcileBackups(context.Background(), server)).To(Succeed())
+		Expect(server.BackupWindow).To(Equal("22-02"))
+	})
+
+	It("enables backups", func() {
+		service.scope.HCloudMachine.Spec.EnableBackups = pointer.Bool(true)
+		Expect(service.reconcileBackups(context.Background(), server)).To(Succeed())
+		Expect(server.BackupWindow).ToNot(BeEmpty())
+	})
+
+	It("disables backups that have been enabled outside of the controller", func() {
+		service.scope.HCloudMachine.Spec.EnableBackups = pointer.Bool(false)
+		server.BackupWindow = "22-02"
+		Expect(service.reconcileBackups(context.Background(), server)).To(Succeed())
+		Expect(server.BackupWindow).To(BeEmpty())
+	})
+})
+
+var _ = Describe("reconcileProtection", func() {
+	var service *Service
+	var client hcloudclient.Client
+	var server *hcloud.Server
+	var serverCount int
+
+	BeforeEach(func() {
+		serverCount++
+		client = fakeclient.NewHCloudClientFactory().NewClient("")
+		res, err := client.CreateServer(context.Background(), hcloud.ServerCreateOpts{Name: fmt.Sprintf("protectedServer-%d", serverCount)})
+		Expect(err).To(Succeed())
+		server = res.Server
+
+		hcloudMachine := &infrav1.HCloudMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: "hcloudMachineName", Namespace: "default"},
+			Spec:       infrav1.HCloudMachineSpec{Type: "cpx31"},
+		}
+		service = newTestService(hcloudMachine, client)
+		service.scope.Machine = &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{clusterv1.MachineControlPlaneLabelName: ""},
+		}}
+		service.scope.HetznerCluster = &infrav1.HetznerCluster{
+			Spec: infrav1.HetznerClusterSpec{ProtectControlPlaneServers: pointer.Bool(true)},
+		}
+	})
+
+	It("protects control plane servers", func() {
+		Expect(service.reconcileProtection(context.Background(), server)).To(Succeed())
+		Expect(server.Protection.Delete).To(BeTrue())
+		Expect(server.Protection.Rebuild).To(BeTrue())
+		Expect(client.DeleteServer(context.Background(), server)).ToNot(Succeed())
+	})
+
+	It("does not protect workers", func() {
+		service.scope.Machine.Labels = nil
+		Expect(service.reconcileProtection(context.Background(), server)).To(Succeed())
+		Expect(server.Protection.Delete).To(BeFalse())
+	})
+
+	It("removes the protection before the server is deleted", func() {
+		Expect(service.reconcileProtection(context.Background(), server)).To(Succeed())
+		Expect(service.removeProtection(context.Background(), server)).To(Succeed())
+		Expect(server.Protection.Delete).To(BeFalse())
+		Expect(server.Protection.Rebuild).To(BeFalse())
+		Expect(client.DeleteServer(context.Background(), server)).To(Succeed())
+	})
+})
+
+var _ = Describe("reconcileMaintenance", func() {
+	var service *Service
+	var hcloudMachine *infrav1.HCloudMachine
+	var machine *clusterv1.Machine
+	server := &hcloud.Server{ID: 42, Status: hcloud.ServerStatusRunning}
+
+	BeforeEach(func() {
+		hcloudMachine = &infrav1.HCloudMachine{ObjectMeta: metav1.ObjectMeta{Name: "maintenance-machine", Namespace: "default"}}
+		machine = &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{Name: "maintenance-machine", Namespace: "default"}}
+
+		scheme := runtime.NewScheme()
+		utilruntime.Must(clusterv1.AddToScheme(scheme))
+		service = newTestService(hcloudMachine, nil)
+		service.scope.Machine = machine
+		service.scope.Client = fakek8sclient.NewClientBuilder().WithScheme(scheme).WithObjects(machine).Build()
+		service.scope.HetznerCluster = &infrav1.HetznerCluster{Spec: infrav1.HetznerClusterSpec{
+			HCloudMaintenance: &infrav1.HCloudMaintenanceSpec{ReplaceMachines: true},
+		}}
+	})
+
+	setWindow := func(value string) {
+		hcloudMachine.Annotations = map[string]string{infrav1.MaintenanceWindowAnnotation: value}
+	}
+
+	It("reports a migrating server", func() {
+		Expect(service.reconcileMaintenance(context.Background(), &hcloud.Server{ID: 42, Status: hcloud.ServerStatusMigrating})).To(Succeed())
+		Expect(conditions.GetReason(hcloudMachine, infrav1.HostMaintenanceFreeCondition)).To(Equal(infrav1.ServerMigratingReason))
+	})
+
+	It("reports an announced maintenance without replacing the machine before the lead time", func() {
+		start := time.Now().Add(72 * time.Hour).UTC().Truncate(time.Second)
+		setWindow(start.Format(time.RFC3339))
+
+		Expect(service.reconcileMaintenance(context.Background(), server)).To(Succeed())
+		Expect(conditions.IsFalse(hcloudMachine, infrav1.HostMaintenanceFreeCondition)).To(BeTrue())
+		Expect(conditions.GetReason(hcloudMachine, infrav1.HostMaintenanceFreeCondition)).To(Equal(infrav1.MaintenanceScheduledReason))
+		Expect(conditions.GetMessage(hcloudMachine, infrav1.HostMaintenanceFreeCondition)).To(Equal("maintenance of the host starts at " + start.Format(time.RFC3339)))
+		Expect(conditions.Has(machine, clusterv1.MachineOwnerRemediatedCondition)).To(BeFalse())
+	})
+
+	It("replaces workers within the lead time", func() {
+		setWindow(time.Now().Add(time.Hour).Format(time.RFC3339))
+
+		Expect(service.reconcileMaintenance(context.Background(), server)).To(Succeed())
+		Expect(conditions.IsFalse(machine, clusterv1.MachineOwnerRemediatedCondition)).To(BeTrue())
+	})
+
+	It("does not replace control planes", func() {
+		machine.Labels = map[string]string{clusterv1.MachineControlPlaneLabelName: ""}
+		setWindow(time.Now().Add(time.Hour).Format(time.RFC3339))
+
+		Expect(service.reconcileMaintenance(context.Background(), server)).To(Succeed())
+		Expect(conditions.IsFalse(hcloudMachine, infrav1.HostMaintenanceFreeCondition)).To(BeTrue())
+		Expect(conditions.Has(machine, clusterv1.MachineOwnerRemediatedCondition)).To(BeFalse())
+	})
+
+	It("removes the condition once the window has ended", func() {
+		conditions.MarkFalse(hcloudMachine, infrav1.HostMaintenanceFreeCondition, infrav1.MaintenanceScheduledReason, clusterv1.ConditionSeverityInfo, "")
+		setWindow(time.Now().Add(-2*time.Hour).Format(time.RFC3339) + "/" + time.Now().Add(-time.Hour).Format(time.RFC3339))
+
+		Expect(service.reconcileMaintenance(context.Background(), server)).To(Succeed())
+		Expect(conditions.Has(hcloudMachine, infrav1.HostMaintenanceFreeCondition)).To(BeFalse())
+		Expect(conditions.Has(machine, clusterv1.MachineOwnerRemediatedCondition)).To(BeFalse())
+	})
+
+	It("removes the condition of a window without end after a day", func() {
+		setWindow(time.Now().Add(-defaultMaintenanceWindow - time.Hour).Format(time.RFC3339))
+
+		Expect(service.reconcileMaintenance(context.Background(), server)).To(Succeed())
+		Expect(conditions.Has(hcloudMachine, infrav1.HostMaintenanceFreeCondition)).To(BeFalse())
+		Expect(conditions.Has(machine, clusterv1.MachineOwnerRemediatedCondition)).To(BeFalse())
+	})
+
+	It("replaces one worker of a deployment at a time", func() {
+		machine.Labels = map[string]string{clusterv1.ClusterLabelName: "cluster", clusterv1.MachineDeploymentLabelName: "workers"}
+		machine.Spec.ClusterName = "cluster"
+		other := &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{
+			Name:      "other-maintenance-machine",
+			Namespace: "default",
+			Labels:    machine.Labels,
+		}}
+		conditions.MarkFalse(other, clusterv1.MachineOwnerRemediatedCondition, clusterv1.WaitingForRemediationReason,
+			clusterv1.ConditionSeverityWarning, "")
+		scheme := runtime.NewScheme()
+		utilruntime.Must(clusterv1.AddToScheme(scheme))
+		service.scope.Client = fakek8sclient.NewClientBuilder().WithScheme(scheme).WithObjects(machine, other).Build()
+		service.scope.APIReader = service.scope.Client
+		setWindow(time.Now().Add(time.Hour).Format(time.RFC3339))
+
+		Expect(service.reconcileMaintenance(context.Background(), server)).To(Succeed())
+		Expect(conditions.IsFalse(hcloudMachine, infrav1.HostMaintenanceFreeCondition)).To(BeTrue())
+		Expect(conditions.Has(machine, clusterv1.MachineOwnerRemediatedCondition)).To(BeFalse())
+	})
+
+	It("reports an invalid window", func() {
+		setWindow("next tuesday")
+
+		Expect(service.reconcileMaintenance(context.Background(), server)).To(Succeed())
+		Expect(conditions.GetReason(hcloudMachine, infrav1.HostMaintenanceFreeCondition)).To(Equal(infrav1.MaintenanceWindowInvalidReason))
+	})
+})
+
+type imageChannelClient struct {
+	hcloudclient.Client
+	images []*hcloud.Image
+}
+
+func (c *imageChannelClient) ListImagesForArchitecture(_ context.Context, _ hcloud.ImageListOpts, _ string) ([]*hcloud.Image, error) {
+	return c.images, nil
+}
+
+var _ = Describe("reconcileImageChannel", func() {
+	var service *Service
+	var hcloudMachine *infrav1.HCloudMachine
+	var machine *clusterv1.Machine
+	var scheme *runtime.Scheme
+	promoted := &hcloud.Image{ID: 2, Description: "ubuntu-v2", Created: time.Now()}
+
+	BeforeEach(func() {
+		hcloudMachine = &infrav1.HCloudMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: "channel-machine", Namespace: "default"},
+			Spec:       infrav1.HCloudMachineSpec{Type: "cpx31", ImageChannel: "stable"},
+			Status:     infrav1.HCloudMachineStatus{AppliedConfiguration: &infrav1.AppliedConfiguration{ImageID: 1}},
+		}
+		machine = &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{
+			Name:      "channel-machine",
+			Namespace: "default",
+			Labels:    map[string]string{clusterv1.ClusterLabelName: "cluster", clusterv1.MachineDeploymentLabelName: "workers"},
+		}, Spec: clusterv1.MachineSpec{ClusterName: "cluster"}}
+
+		scheme = runtime.NewScheme()
+		utilruntime.Must(clusterv1.AddToScheme(scheme))
+		service = newTestService(hcloudMachine, &imageChannelClient{images: []*hcloud.Image{promoted}})
+		service.scope.Machine = machine
+		service.scope.Client = fakek8sclient.NewClientBuilder().WithScheme(scheme).WithObjects(machine).Build()
+		service.scope.APIReader = service.scope.Client
+		service.scope.HetznerCluster = &infrav1.HetznerCluster{}
+	})
+
+	It("reports a server that has been created from the image of the channel", func() {
+		hcloudMachine.Status.AppliedConfiguration.ImageID = promoted.ID
+
+		Expect(service.reconcileImageChannel(context.Background())).To(Succeed())
+		Expect(conditions.IsTrue(hcloudMachine, infrav1.ImageUpToDateCondition)).To(BeTrue())
+	})
+
+	It("replaces a worker once another image has been promoted", func() {
+		Expect(service.reconcileImageChannel(context.Background())).To(Succeed())
+		Expect(conditions.GetReason(hcloudMachine, infrav1.ImageUpToDateCondition)).To(Equal(infrav1.ImagePromotedReason))
+		Expect(conditions.IsFalse(machine, clusterv1.MachineOwnerRemediatedCondition)).To(BeTrue())
+	})
+
+	It("waits while another machine of the deployment is not ready", func() {
+		other := &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{
+			Name:      "other-machine",
+			Namespace: "default",
+			Labels:    machine.Labels,
+		}}
+		service.scope.Client = fakek8sclient.NewClientBuilder().WithScheme(scheme).WithObjects(machine, other).Build()
+		service.scope.APIReader = service.scope.Client
+
+		Expect(service.reconcileImageChannel(context.Background())).To(Succeed())
+		Expect(conditions.GetReason(hcloudMachine, infrav1.ImageUpToDateCondition)).To(Equal(infrav1.ImageRolloutPendingReason))
+		Expect(conditions.Has(machine, clusterv1.MachineOwnerRemediatedCondition)).To(BeFalse())
+	})
+
+	It("waits for a replacement that is not in the cache yet", func() {
+		other := &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{
+			Name:      "other-replaced-machine",
+			Namespace: "default",
+			Labels:    machine.Labels,
+		}}
+		conditions.MarkTrue(other, clusterv1.ReadyCondition)
+		conditions.MarkFalse(other, clusterv1.MachineOwnerRemediatedCondition, clusterv1.WaitingForRemediationReason,
+			clusterv1.ConditionSeverityWarning, "")
+		service.scope.APIReader = fakek8sclient.NewClientBuilder().WithScheme(scheme).WithObjects(machine, other).Build()
+
+		Expect(service.reconcileImageChannel(context.Background())).To(Succeed())
+		Expect(conditions.GetReason(hcloudMachine, infrav1.ImageUpToDateCondition)).To(Equal(infrav1.ImageRolloutPendingReason))
+		Expect(conditions.GetMessage(hcloudMachine, infrav1.ImageUpToDateCondition)).To(HaveSuffix("waiting for machine other-replaced-machine"))
+		Expect(conditions.Has(machine, clusterv1.MachineOwnerRemediatedCondition)).To(BeFalse())
+	})
+
+	It("waits until the replacement of a deleted machine has been created", func() {
+		deployment := &clusterv1.MachineDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "workers", Namespace: "default"},
+			Spec:       clusterv1.MachineDeploymentSpec{ClusterName: "cluster", Replicas: pointer.Int32(2)},
+		}
+		service.scope.Client = fakek8sclient.NewClientBuilder().WithScheme(scheme).WithObjects(machine, deployment).Build()
+		service.scope.APIReader = service.scope.Client
+
+		Expect(service.reconcileImageChannel(context.Background())).To(Succeed())
+		Expect(conditions.GetReason(hcloudMachine, infrav1.ImageUpToDateCondition)).To(Equal(infrav1.ImageRolloutPendingReason))
+		Expect(conditions.GetMessage(hcloudMachine, infrav1.ImageUpToDateCondition)).To(HaveSuffix("waiting for new machine of machine deployment workers"))
+		Expect(conditions.Has(machine, clusterv1.MachineOwnerRemediatedCondition)).To(BeFalse())
+	})
+
+	It("does not replace control planes", func() {
+		machine.Labels = map[string]string{clusterv1.MachineControlPlaneLabelName: ""}
+
+		Expect(service.reconcileImageChannel(context.Background())).To(Succeed())
+		Expect(conditions.GetReason(hcloudMachine, infrav1.ImageUpToDateCondition)).To(Equal(infrav1.ImagePromotedReason))
+		Expect(conditions.Has(machine, clusterv1.MachineOwnerRemediatedCondition)).To(BeFalse())
+	})
+})
+
+var _ = Describe("reconcileISO", func() {
+	var service *Service
+	var server *hcloud.Server
+	var serverCount int
+
+	BeforeEach(func() {
+		serverCount++
+		client := fakeclient.NewHCloudClientFactory().NewClient("")
+		res, err := client.CreateServer(context.Background(), hcloud.ServerCreateOpts{
+			Name:             fmt.Sprintf("isoServer-%d", serverCount),
+			StartAfterCreate: pointer.Bool(false),
+		})
+		Expect(err).To(Succeed())
+		server = res.Server
+
+		hcloudMachine := &infrav1.HCloudMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: "hcloudMachineName", Namespace: "default"},
+			Spec:       infrav1.HCloudMachineSpec{Type: "cpx31", ISO: "rescue.iso"},
+		}
+		service = newTestService(hcloudMachine, client)
+		service.scope.Machine = &clusterv1.Machine{}
+	})
+
+	It("does nothing without ISO", func() {
+		service.scope.HCloudMachine.Spec.ISO = ""
+		Expect(service.reconcileISO(context.Background(), server)).To(BeNil())
+		Expect(server.ISO).To(BeNil())
+	})
+
+	It("attaches the ISO before the first boot", func() {
+		Expect(service.reconcileISO(context.Background(), server)).To(BeNil())
+		Expect(server.ISO).ToNot(BeNil())
+		Expect(server.ISO.Name).To(Equal("rescue.iso"))
+		Expect(service.scope.HCloudMachine.Status.ISODetached).To(BeFalse())
+	})
+
+	It("detaches the ISO once the server has joined the cluster", func() {
+		Expect(service.reconcileISO(context.Background(), server)).To(BeNil())
+		server.Status = hcloud.ServerStatusRunning
+
+		Expect(service.reconcileISO(context.Background(), server)).To(BeNil())
+		Expect(service.scope.HCloudMachine.Status.ISODetached).To(BeFalse())
+		Expect(server.ISO).ToNot(BeNil())
+
+		service.scope.Machine.Status.NodeRef = &corev1.ObjectReference{Kind: "Node", Name: "node"}
+		Expect(service.reconcileISO(context.Background(), server)).To(BeNil())
+		Expect(service.scope.HCloudMachine.Status.ISODetached).To(BeTrue())
+		Expect(server.ISO).To(BeNil())
+	})
+
+	It("does not attach the ISO to a server that booted without it", func() {
+		server.Status = hcloud.ServerStatusRunning
+		Expect(service.reconcileISO(context.Background(), server)).To(BeNil())
+		Expect(server.ISO).To(BeNil())
+		Expect(service.scope.HCloudMachine.Status.ISODetached).To(BeTrue())
+	})
+
+	It("reports an ISO that does not exist", func() {
+		service.scope.HCloudMachine.Spec.ISO = "not-found"
+		res, err := service.reconcileISO(context.Background(), server)
+		Expect(err).To(Succeed())
+		Expect(res).ToNot(BeNil())
+		Expect(conditions.GetReason(service.scope.HCloudMachine, infrav1.InstanceReadyCondition)).To(Equal(infrav1.ISONotFoundReason))
+	})
+})
+
+var _ = Describe("reconcileRescueMode", func() {
+	var service *Service
+	var server *hcloud.Server
+	var serverCount int
+
+	BeforeEach(func() {
+		serverCount++
+		client := fakeclient.NewHCloudClientFactory().NewClient("")
+		res, err := client.CreateServer(context.Background(), hcloud.ServerCreateOpts{Name: fmt.Sprintf("rescueServer-%d", serverCount)})
+		Expect(err).To(Succeed())
+		server = res.Server
+
+		hcloudMachine := &infrav1.HCloudMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: "hcloudMachineName", Namespace: "default"},
+			Spec:       infrav1.HCloudMachineSpec{Type: "cpx31"},
+		}
+		service = newTestService(hcloudMachine, client)
+		service.scope.HetznerCluster = &infrav1.HetznerCluster{}
+	})
+
+	It("does nothing without the rescue annotation", func() {
+		Expect(service.reconcileRescueMode(context.Background(), server)).To(BeNil())
+		Expect(server.RescueEnabled).To(BeFalse())
+	})
+
+	It("boots the server into the rescue system and pauses the reconciliation", func() {
+		service.scope.HCloudMachine.Annotations = map[string]string{infrav1.RescueAnnotation: ""}
+
+		res, err := service.reconcileRescueMode(context.Background(), server)
+		Expect(err).To(Succeed())
+		Expect(res).To(Equal(&reconcile.Result{}))
+		Expect(server.RescueEnabled).To(BeTrue())
+		Expect(service.scope.HCloudMachine.Status.RescueMode).To(BeTrue())
+		Expect(conditions.GetReason(service.scope.HCloudMachine, infrav1.InstanceReadyCondition)).To(Equal(infrav1.InstanceInRescueModeReason))
+
+		// the server is not booted into the rescue system again
+		server.RescueEnabled = false
+		Expect(service.reconcileRescueMode(context.Background(), server)).To(Equal(&reconcile.Result{}))
+		Expect(server.RescueEnabled).To(BeFalse())
+	})
+
+	It("boots the server from its disk once the annotation is removed", func() {
+		service.scope.HCloudMachine.Annotations = map[string]string{infrav1.RescueAnnotation: ""}
+		_, err := service.reconcileRescueMode(context.Background(), server)
+		Expect(err).To(Succeed())
+
+		delete(service.scope.HCloudMachine.Annotations, infrav1.RescueAnnotation)
+		res, err := service.reconcileRescueMode(context.Background(), server)
+		Expect(err).To(Succeed())
+		Expect(res.RequeueAfter).ToNot(BeZero())
+		Expect(server.RescueEnabled).To(BeFalse())
+		Expect(service.scope.HCloudMachine.Status.RescueMode).To(BeFalse())
+	})
+})
+
+var _ = Describe("reconcileServerType", func() {
+	var service *Service
+	var server *hcloud.Server
+	var serverCount int
+
+	BeforeEach(func() {
+		serverCount++
+		client := fakeclient.NewHCloudClientFactory().NewClient("")
+		res, err := client.CreateServer(context.Background(), hcloud.ServerCreateOpts{
+			Name:       fmt.Sprintf("resizeServer-%d", serverCount),
+			ServerType: &hcloud.ServerType{Name: "cpx21"},
+		})
+		Expect(err).To(Succeed())
+		server = res.Server
+
+		hcloudMachine := &infrav1.HCloudMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: "hcloudMachineName", Namespace: "default"},
+			Spec:       infrav1.HCloudMachineSpec{Type: "cpx21"},
+		}
+		service = newTestService(hcloudMachine, client)
+		service.scope.HetznerCluster = &infrav1.HetznerCluster{}
+	})
+
+	It("does nothing if the type matches the spec", func() {
+		Expect(service.reconcileServerType(context.Background(), server)).To(BeNil())
+		Expect(server.Status).To(Equal(hcloud.ServerStatusRunning))
+	})
+
+	It("does not change a server that has been created with the successor of its type", func() {
+		service.scope.HCloudMachine.Spec.Type = "cx21"
+		service.scope.HetznerCluster.Spec.HCloudServerTypeSuccessors = map[string]infrav1.HCloudMachineType{"cx21": "cpx21"}
+		Expect(service.reconcileServerType(context.Background(), server)).To(BeNil())
+	})
+
+	It("shuts down the server before its type is changed", func() {
+		service.scope.HCloudMachine.Spec.Type = "cpx31"
+		res, err := service.reconcileServerType(context.Background(), server)
+		Expect(err).To(Succeed())
+		Expect(res).ToNot(BeNil())
+		Expect(server.ServerType.Name).To(Equal("cpx21"))
+		Expect(conditions.GetReason(service.scope.HCloudMachine, infrav1.InstanceReadyCondition)).To(Equal(infrav1.ServerTypeChangingReason))
+	})
+
+	It("changes the type of a server that is off", func() {
+		service.scope.HCloudMachine.Spec.Type = "cpx31"
+		server.Status = hcloud.ServerStatusOff
+		res, err := service.reconcileServerType(context.Background(), server)
+		Expect(err).To(Succeed())
+		Expect(res).ToNot(BeNil())
+		Expect(server.ServerType.Name).To(Equal("cpx31"))
+	})
+
+	It("powers on the server with its previous type if the change fails and backs off", func() {
+		service.scope.HCloudMachine.Spec.Type = "cpx31"
+		service.scope.HCloudClient = &failingChangeServerTypeClient{Client: service.scope.HCloudClient}
+		server.Status = hcloud.ServerStatusOff
+
+		res, err := service.reconcileServerType(context.Background(), server)
+		Expect(err).To(Succeed())
+		Expect(res).ToNot(BeNil())
+		Expect(server.Status).To(Equal(hcloud.ServerStatusRunning))
+		Expect(server.ServerType.Name).To(Equal("cpx21"))
+		Expect(conditions.IsFalse(service.scope.HCloudMachine, infrav1.ServerTypeChangedCondition)).To(BeTrue())
+		Expect(conditions.GetReason(service.scope.HCloudMachine, infrav1.ServerTypeChangedCondition)).To(Equal(infrav1.ServerTypeChangeFailedReason))
+
+		// The running server is not shut down again during the backoff
+		Expect(service.reconcileServerType(context.Background(), server)).To(BeNil())
+		Expect(server.Status).To(Equal(hcloud.ServerStatusRunning))
+	})
+
+	It("powers off a server that does not shut down", func() {
+		service.scope.HCloudMachine.Spec.Type = "cpx31"
+		service.scope.HCloudClient = &ignoringShutdownClient{Client: service.scope.HCloudClient}
+
+		Expect(service.reconcileServerType(context.Background(), server)).ToNot(BeNil())
+		Expect(server.Status).To(Equal(hcloud.ServerStatusRunning))
+
+		// The shutdown is repeated until maxShutDownTime has passed
+		Expect(service.reconcileServerType(context.Background(), server)).ToNot(BeNil())
+		Expect(server.Status).To(Equal(hcloud.ServerStatusRunning))
+
+		service.scope.HCloudMachine.Status.Conditions[0].LastTransitionTime = metav1.NewTime(time.Now().Add(-maxShutDownTime - time.Second))
+		Expect(service.reconcileServerType(context.Background(), server)).ToNot(BeNil())
+		Expect(server.Status).To(Equal(hcloud.ServerStatusOff))
+	})
+
+	It("removes the condition of a failed change once the type matches", func() {
+		conditions.MarkFalse(service.scope.HCloudMachine, infrav1.ServerTypeChangedCondition, infrav1.ServerTypeChangeFailedReason,
+			clusterv1.ConditionSeverityWarning, "")
+		Expect(service.reconcileServerType(context.Background(), server)).To(BeNil())
+		Expect(conditions.Has(service.scope.HCloudMachine, infrav1.ServerTypeChangedCondition)).To(BeFalse())
+	})
+})
+
+// failingChangeServerTypeClient fails to change the type of servers, e.g. because the type is not available.
+type failingChangeServerTypeClient struct {
+	hcloudclient.Client
+}
+
+func (c *failingChangeServerTypeClient) ChangeServerType(context.Context, *hcloud.Server, hcloud.ServerChangeTypeOpts) (*hcloud.Action, error) {
+	return nil, hcloud.Error{Code: hcloud.ErrorCodeResourceUnavailable, Message: "server type is not available"}
+}
+
+// ignoringShutdownClient ignores the ACPI shutdown of servers, like an operating system without ACPI support.
+type ignoringShutdownClient struct {
+	hcloudclient.Client
+}
+
+func (c *ignoringShutdownClient) ShutdownServer(context.Context, *hcloud.Server) (*hcloud.Action, error) {
+	return &hcloud.Action{}, nil
+}
+
+type deprecationsClient struct {
+	hcloudclient.Client
+	deprecations map[string]hcloudclient.ServerTypeDeprecation
+}
+
+func (c *deprecationsClient) ListServerTypeDeprecations(context.Context) (map[string]hcloudclient.ServerTypeDeprecation, error) {
+	return c.deprecations, nil
+}
+
+var _ = Describe("serverType", func() {
+	var service *Service
+
+	BeforeEach(func() {
+		client := &deprecationsClient{
+			Client: fakeclient.NewHCloudClientFactory().NewClient(""),
+			deprecations: map[string]hcloudclient.ServerTypeDeprecation{
+				"cx21": {Announced: time.Now(), UnavailableAfter: time.Now().Add(90 * 24 * time.Hour)},
+			},
+		}
+		service = newTestService(&infrav1.HCloudMachine{Spec: infrav1.HCloudMachineSpec{Type: "cx21"}}, client)
+		service.scope.HetznerCluster = &infrav1.HetznerCluster{}
+	})
+
+	It("uses the type of the machine without successor", func() {
+		Expect(service.serverType(context.Background())).To(Equal(infrav1.HCloudMachineType("cx21")))
+	})
+
+	It("uses the successor of a deprecated type", func() {
+		service.scope.HetznerCluster.Spec.HCloudServerTypeSuccessors = map[string]infrav1.HCloudMachineType{"cx21": "cx22"}
+		Expect(service.serverType(context.Background())).To(Equal(infrav1.HCloudMachineType("cx22")))
+	})
+
+	It("does not use the successor as long as the type is not deprecated", func() {
+		service.scope.HCloudMachine.Spec.Type = "cpx21"
+		service.scope.HetznerCluster.Spec.HCloudServerTypeSuccessors = map[string]infrav1.HCloudMachineType{"cpx21": "cpx22"}
+		Expect(service.serverType(context.Background())).To(Equal(infrav1.HCloudMachineType("cpx21")))
+	})
+})
+
+var _ = Describe("getServerImage", func() {
+	var service *Service
+
+	BeforeEach(func() {
+		service = newTestService(&infrav1.HCloudMachine{Spec: infrav1.HCloudMachineSpec{ImageName: "myimage"}},
+			fakeclient.NewHCloudClientFactory().NewClient(""))
+		service.scope.HetznerCluster = &infrav1.HetznerCluster{}
+	})
+
+	It("returns the image of the architecture of the server type", func() {
+		image, err := service.getServerImage(context.Background(), infrav1.HCloudMachineType("cpx21").Architecture())
+		Expect(err).To(Succeed())
+		Expect(image.ID).To(Equal(42))
+		Expect(conditions.IsTrue(service.scope.HCloudMachine, infrav1.ImageArchitectureMatchesCondition)).To(BeTrue())
+	})
+
+	It("reports a mismatch if the image does not exist for the architecture of the server type", func() {
+		_, err := service.getServerImage(context.Background(), infrav1.HCloudMachineType("cax21").Architecture())
+		Expect(err).ToNot(Succeed())
+		Expect(conditions.IsFalse(service.scope.HCloudMachine, infrav1.ImageArchitectureMatchesCondition)).To(BeTrue())
+		Expect(conditions.GetReason(service.scope.HCloudMachine, infrav1.ImageArchitectureMatchesCondition)).
+			To(Equal(infrav1.ImageArchitectureMismatchReason))
+	})
+})
+
+type imageListClient struct {
+	hcloudclient.Client
+	images        []*hcloud.Image
+	labelSelector string
+}
+
+func (c *imageListClient) ListImagesForArchitecture(_ context.Context, opts hcloud.ImageListOpts, _ string) ([]*hcloud.Image, error) {
+	c.labelSelector = opts.LabelSelector
+	return c.images, nil
+}
+
+var _ = Describe("selectServerImage", func() {
+	It("selects the most recent image that matches the selector", func() {
+		now := time.Now()
+		client := &imageListClient{images: []*hcloud.Image{
+			{ID: 1, Created: now.Add(-time.Hour)},
+			{ID: 3, Created: now},
+			{ID: 2, Created: now},
+		}}
+		service := newTestService(&infrav1.HCloudMachine{Spec: infrav1.HCloudMachineSpec{
+			ImageSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"caph-image": "ubuntu-22.04", "k8s": "v1.29"}},
+		}}, client)
+		service.scope.HetznerCluster = &infrav1.HetznerCluster{}
+
+		image, err := service.getServerImage(context.Background(), infrav1.HCloudMachineType("cpx21").Architecture())
+		Expect(err).To(Succeed())
+		Expect(image.ID).To(Equal(3))
+		Expect(client.labelSelector).To(Equal("caph-image==ubuntu-22.04,k8s==v1.29"))
+	})
+})
+
+var _ = DescribeTable("nextFreeIP",
+	func(subnetCIDR string, usedIPs []string, expected string) {
+		_, networkRange, err := net.ParseCIDR("10.0.0.0/16")
+		Expect(err).To(Succeed())
+		_, subnet, err := net.ParseCIDR(subnetCIDR)
+		Expect(err).To(Succeed())
+
+		used := make(map[string]struct{})
+		for _, ip := range usedIPs {
+			used[ip] = struct{}{}
+		}
+
+		ip := nextFreeIP(networkRange, subnet, used)
+		if expected == "" {
+			Expect(ip).To(BeNil())
+			return
+		}
+		Expect(ip.String()).To(Equal(expected))
+	},
+	Entry("skips the gateway", "10.0.0.0/24", nil, "10.0.0.2"),
+	Entry("skips used IPs", "10.0.0.0/24", []string{"10.0.0.2", "10.0.0.3"}, "10.0.0.4"),
+	Entry("subnet without gateway", "10.0.1.0/24", []string{"10.0.1.1"}, "10.0.1.2"),
+	Entry("does not use the broadcast address", "10.0.1.0/30", []string{"10.0.1.1"}, "10.0.1.2"),
+	Entry("exhausted subnet", "10.0.1.0/30", []string{"10.0.1.1", "10.0.1.2"}, ""),
+)
+
+type networkConflictClient struct {
+	hcloudclient.Client
+	attachedIP net.IP
+}
+
+func (c *networkConflictClient) AttachServerToNetwork(ctx context.Context, server *hcloud.Server, opts hcloud.ServerAttachToNetworkOpts) (*hcloud.Action, error) {
+	if opts.IP == nil {
+		return nil, hcloud.Error{Code: hcloud.ErrorCodeIPNotAvailable, Message: "ip not available"}
+	}
+	c.attachedIP = opts.IP
+	return c.Client.AttachServerToNetwork(ctx, server, opts)
+}
+
+var _ = Describe("reconcileNetworkAttachment", func() {
+	It("attaches the server with a free IP if the IP chosen by HCloud is not available", func() {
+		ctx := context.Background()
+		client := &networkConflictClient{Client: fakeclient.NewHCloudClientFactory().NewClient("")}
+
+		_, networkRange, err := net.ParseCIDR("10.0.0.0/16")
+		Expect(err).To(Succeed())
+		_, subnet, err := net.ParseCIDR("10.0.0.0/24")
+		Expect(err).To(Succeed())
+		network, err := client.CreateNetwork(ctx, hcloud.NetworkCreateOpts{
+			Name:    "hetzner-cluster",
+			IPRange: networkRange,
+			Labels:  map[string]string{infrav1.ClusterTagKey("hetzner-cluster"): string(infrav1.ResourceLifecycleOwned)},
+			Subnets: []hcloud.NetworkSubnet{{IPRange: subnet, Type: hcloud.NetworkSubnetTypeServer}},
+		})
+		Expect(err).To(Succeed())
+
+		other, err := client.CreateServer(ctx, hcloud.ServerCreateOpts{Name: "other"})
+		Expect(err).To(Succeed())
+		other.Server.PrivateNet = []hcloud.ServerPrivateNet{{Network: &hcloud.Network{ID: network.ID}, IP: net.ParseIP("10.0.0.2")}}
+
+		res, err := client.CreateServer(ctx, hcloud.ServerCreateOpts{Name: "server"})
+		Expect(err).To(Succeed())
+
+		service := newTestService(&infrav1.HCloudMachine{}, client)
+		service.scope.HetznerCluster = &infrav1.HetznerCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "hetzner-cluster"},
+			Status:     infrav1.HetznerClusterStatus{Network: &infrav1.NetworkStatus{ID: network.ID}},
+		}
+
+		Expect(service.reconcileNetworkAttachment(ctx, res.Server)).To(Succeed())
+		Expect(client.attachedIP.String()).To(Equal("10.0.0.3"))
+	})
+
+	It("uses the subnet in the network zone of the cluster if an existing network has several subnets", func() {
+		ctx := context.Background()
+		client := &networkConflictClient{Client: fakeclient.NewHCloudClientFactory().NewClient("")}
+
+		_, networkRange, err := net.ParseCIDR("10.0.0.0/16")
+		Expect(err).To(Succeed())
+		_, usSubnet, err := net.ParseCIDR("10.0.0.0/24")
+		Expect(err).To(Succeed())
+		_, euSubnet, err := net.ParseCIDR("10.0.1.0/24")
+		Expect(err).To(Succeed())
+		network, err := client.CreateNetwork(ctx, hcloud.NetworkCreateOpts{
+			Name:    "shared",
+			IPRange: networkRange,
+			Subnets: []hcloud.NetworkSubnet{
+				{IPRange: usSubnet, NetworkZone: hcloud.NetworkZoneUSEast, Type: hcloud.NetworkSubnetTypeCloud},
+				{IPRange: euSubnet, NetworkZone: hcloud.NetworkZoneEUCentral, Type: hcloud.NetworkSubnetTypeCloud},
+			},
+		})
+		Expect(err).To(Succeed())
+
+		res, err := client.CreateServer(ctx, hcloud.ServerCreateOpts{Name: "server-in-shared-network"})
+		Expect(err).To(Succeed())
+
+		service := newTestService(&infrav1.HCloudMachine{}, client)
+		service.scope.HetznerCluster = &infrav1.HetznerCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "hetzner-cluster"},
+			Spec: infrav1.HetznerClusterSpec{HCloudNetwork: infrav1.HCloudNetworkSpec{
+				Enabled:         true,
+				NetworkZone:     "eu-central",
+				ExistingNetwork: &infrav1.HCloudNetworkRef{Name: "shared"},
+			}},
+			Status: infrav1.HetznerClusterStatus{Network: &infrav1.NetworkStatus{ID: network.ID}},
+		}
+
+		Expect(service.reconcileNetworkAttachment(ctx, res.Server)).To(Succeed())
+		Expect(client.attachedIP.String()).To(Equal("10.0.1.1"))
+	})
+
+	It("uses the subnet that an existing network selects", func() {
+		ctx := context.Background()
+		client := &networkConflictClient{Client: fakeclient.NewHCloudClientFactory().NewClient("")}
+
+		_, networkRange, err := net.ParseCIDR("10.0.0.0/16")
+		Expect(err).To(Succeed())
+		_, firstSubnet, err := net.ParseCIDR("10.0.0.0/24")
+		Expect(err).To(Succeed())
+		_, selectedSubnet, err := net.ParseCIDR("10.0.3.0/24")
+		Expect(err).To(Succeed())
+		network, err := client.CreateNetwork(ctx, hcloud.NetworkCreateOpts{
+			Name:    "shared-with-selected-subnet",
+			IPRange: networkRange,
+			Subnets: []hcloud.NetworkSubnet{
+				{IPRange: firstSubnet, NetworkZone: hcloud.NetworkZoneEUCentral, Type: hcloud.NetworkSubnetTypeCloud},
+				{IPRange: selectedSubnet, NetworkZone: hcloud.NetworkZoneEUCentral, Type: hcloud.NetworkSubnetTypeCloud},
+			},
+		})
+		Expect(err).To(Succeed())
+
+		res, err := client.CreateServer(ctx, hcloud.ServerCreateOpts{Name: "server-in-selected-subnet"})
+		Expect(err).To(Succeed())
+
+		service := newTestService(&infrav1.HCloudMachine{}, client)
+		service.scope.HetznerCluster = &infrav1.HetznerCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "hetzner-cluster"},
+			Spec: infrav1.HetznerClusterSpec{HCloudNetwork: infrav1.HCloudNetworkSpec{
+				Enabled:         true,
+				NetworkZone:     "eu-central",
+				ExistingNetwork: &infrav1.HCloudNetworkRef{Name: "shared-with-selected-subnet", SubnetCIDRBlock: "10.0.3.0/24"},
+			}},
+			Status: infrav1.HetznerClusterStatus{Network: &infrav1.NetworkStatus{ID: network.ID}},
+		}
+
+		Expect(service.reconcileNetworkAttachment(ctx, res.Server)).To(Succeed())
+		Expect(client.attachedIP.String()).To(Equal("10.0.3.1"))
+	})
+
+	It("attaches a server that selects a subnet with a free IP of the subnet", func() {
+		ctx := context.Background()
+		client := &networkConflictClient{Client: fakeclient.NewHCloudClientFactory().NewClient("")}
+
+		_, networkRange, err := net.ParseCIDR("10.0.0.0/16")
+		Expect(err).To(Succeed())
+		_, defaultSubnet, err := net.ParseCIDR("10.0.0.0/24")
+		Expect(err).To(Succeed())
+		_, infraSubnet, err := net.ParseCIDR("10.0.2.0/24")
+		Expect(err).To(Succeed())
+		network, err := client.CreateNetwork(ctx, hcloud.NetworkCreateOpts{
+			Name:    "subnets",
+			IPRange: networkRange,
+			Subnets: []hcloud.NetworkSubnet{
+				{IPRange: defaultSubnet, Type: hcloud.NetworkSubnetTypeServer},
+				{IPRange: infraSubnet, Type: hcloud.NetworkSubnetTypeServer},
+			},
+		})
+		Expect(err).To(Succeed())
+
+		res, err := client.CreateServer(ctx, hcloud.ServerCreateOpts{Name: "server-in-subnet"})
+		Expect(err).To(Succeed())
+
+		service := newTestService(&infrav1.HCloudMachine{Spec: infrav1.HCloudMachineSpec{Subnet: "infra"}}, client)
+		service.scope.HetznerCluster = &infrav1.HetznerCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "hetzner-cluster"},
+			Spec: infrav1.HetznerClusterSpec{HCloudNetwork: infrav1.HCloudNetworkSpec{
+				Enabled:         true,
+				CIDRBlock:       "10.0.0.0/16",
+				SubnetCIDRBlock: "10.0.0.0/24",
+				Subnets:         []infrav1.HCloudSubnetSpec{{Name: "infra", CIDRBlock: "10.0.2.0/24"}},
+			}},
+			Status: infrav1.HetznerClusterStatus{Network: &infrav1.NetworkStatus{ID: network.ID}},
+		}
+
+		Expect(service.reconcileNetworkAttachment(ctx, res.Server)).To(Succeed())
+		Expect(client.attachedIP.String()).To(Equal("10.0.2.1"))
+	})
+
+	It("tolerates a server that is already attached to the subnet", func() {
+		ctx := context.Background()
+		client := &networkConflictClient{Client: fakeclient.NewHCloudClientFactory().NewClient("")}
+
+		_, networkRange, err := net.ParseCIDR("10.0.0.0/16")
+		Expect(err).To(Succeed())
+		_, defaultSubnet, err := net.ParseCIDR("10.0.0.0/24")
+		Expect(err).To(Succeed())
+		_, infraSubnet, err := net.ParseCIDR("10.0.2.0/24")
+		Expect(err).To(Succeed())
+		network, err := client.CreateNetwork(ctx, hcloud.NetworkCreateOpts{
+			Name:    "subnets-with-attached-server",
+			IPRange: networkRange,
+			Subnets: []hcloud.NetworkSubnet{
+				{IPRange: defaultSubnet, Type: hcloud.NetworkSubnetTypeServer},
+				{IPRange: infraSubnet, Type: hcloud.NetworkSubnetTypeServer},
+			},
+		})
+		Expect(err).To(Succeed())
+
+		res, err := client.CreateServer(ctx, hcloud.ServerCreateOpts{Name: "attached-server-in-subnet"})
+		Expect(err).To(Succeed())
+		_, err = client.AttachServerToNetwork(ctx, res.Server, hcloud.ServerAttachToNetworkOpts{
+			Network: network,
+			IP:      net.ParseIP("10.0.2.1"),
+		})
+		Expect(err).To(Succeed())
+
+		service := newTestService(&infrav1.HCloudMachine{Spec: infrav1.HCloudMachineSpec{Subnet: "infra"}}, client)
+		service.scope.HetznerCluster = &infrav1.HetznerCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "hetzner-cluster"},
+			Spec: infrav1.HetznerClusterSpec{HCloudNetwork: infrav1.HCloudNetworkSpec{
+				Enabled:         true,
+				CIDRBlock:       "10.0.0.0/16",
+				SubnetCIDRBlock: "10.0.0.0/24",
+				Subnets:         []infrav1.HCloudSubnetSpec{{Name: "infra", CIDRBlock: "10.0.2.0/24"}},
+			}},
+			Status: infrav1.HetznerClusterStatus{Network: &infrav1.NetworkStatus{ID: network.ID}},
+		}
+
+		Expect(service.reconcileNetworkAttachment(ctx, res.Server)).To(Succeed())
+	})
+
+	It("does not attach a server whose private networks contain the network", func() {
+		ctx := context.Background()
+		client := fakeclient.NewHCloudClientFactory().NewClient("")
+
+		service := newTestService(&infrav1.HCloudMachine{}, client)
+		service.scope.HetznerCluster = &infrav1.HetznerCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "hetzner-cluster"},
+			Spec:       infrav1.HetznerClusterSpec{HCloudNetwork: infrav1.HCloudNetworkSpec{Enabled: true}},
+			Status:     infrav1.HetznerClusterStatus{Network: &infrav1.NetworkStatus{ID: 1}},
+		}
+
+		// the network does not exist in the fake client, so attaching the server would fail
+		server := &hcloud.Server{ID: 1, PrivateNet: []hcloud.ServerPrivateNet{{Network: &hcloud.Network{ID: 1}, IP: net.ParseIP("10.0.0.2")}}}
+		Expect(service.reconcileNetworkAttachment(ctx, server)).To(Succeed())
+	})
+
+	It("does not attach a server that selects an unknown subnet", func() {
+		ctx := context.Background()
+		client := fakeclient.NewHCloudClientFactory().NewClient("")
+
+		hcloudMachine := &infrav1.HCloudMachine{Spec: infrav1.HCloudMachineSpec{Subnet: "unknown"}}
+		service := newTestService(hcloudMachine, client)
+		service.scope.HetznerCluster = &infrav1.HetznerCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "hetzner-cluster"},
+			Spec:       infrav1.HetznerClusterSpec{HCloudNetwork: infrav1.HCloudNetworkSpec{Enabled: true}},
+			Status:     infrav1.HetznerClusterStatus{Network: &infrav1.NetworkStatus{ID: 1}},
+		}
+
+		Expect(service.reconcileNetworkAttachment(ctx, &hcloud.Server{ID: 1})).ToNot(Succeed())
+		Expect(conditions.GetReason(hcloudMachine, infrav1.InstanceReadyCondition)).To(Equal(infrav1.SubnetNotFoundReason))
+	})
+})
+
+var _ = Describe("reconcileLabels", func() {
+	It("adds the labels propagated from the Machine and removes stale ones", func() {
+		ctx := context.Background()
+		client := fakeclient.NewHCloudClientFactory().NewClient("")
+
+		hcloudMachine := &infrav1.HCloudMachine{ObjectMeta: metav1.ObjectMeta{
+			Name:        "hcloud-machine",
+			Labels:      map[string]string{"cost-center": "team-a", "not-propagated": "value"},
+			Annotations: map[string]string{infrav1.PropagatedLabelsAnnotation: "cost-center"},
+		}}
+		service := newTestService(hcloudMachine, client)
+		service.scope.Machine = &clusterv1.Machine{}
+		service.scope.HetznerCluster = &infrav1.HetznerCluster{ObjectMeta: metav1.ObjectMeta{Name: "hetzner-cluster"}}
+
+		baseLabels := createLabels("hetzner-cluster", "hcloud-machine", false)
+		serverLabels := map[string]string{"stale": "value"}
+		for key, value := range baseLabels {
+			serverLabels[key] = value
+		}
+		res, err := client.CreateServer(ctx, hcloud.ServerCreateOpts{Name: "hcloud-machine", Labels: serverLabels})
+		Expect(err).To(Succeed())
+
+		Expect(service.reconcileLabels(ctx, res.Server)).To(Succeed())
+
+		servers, err := client.ListServers(ctx, hcloud.ServerListOpts{
+			ListOpts: hcloud.ListOpts{LabelSelector: utils.LabelsToLabelSelector(baseLabels)},
+		})
+		Expect(err).To(Succeed())
+		Expect(servers).To(HaveLen(1))
+		Expect(servers[0].Labels).To(HaveKeyWithValue("cost-center", "team-a"))
+		Expect(servers[0].Labels).ToNot(HaveKey("not-propagated"))
+		Expect(servers[0].Labels).ToNot(HaveKey("stale"))
+		for key, value := range baseLabels {
+			Expect(servers[0].Labels).To(HaveKeyWithValue(key, value))
+		}
+	})
+
+	It("does not let propagated labels override the labels that identify the server", func() {
+		hcloudMachine := &infrav1.HCloudMachine{ObjectMeta: metav1.ObjectMeta{
+			Name:        "hcloud-machine",
+			Labels:      map[string]string{"machine_type": "control_plane"},
+			Annotations: map[string]string{infrav1.PropagatedLabelsAnnotation: "machine_type"},
+		}}
+		service := newTestService(hcloudMachine, nil)
+		service.scope.Machine = &clusterv1.Machine{}
+		service.scope.HetznerCluster = &infrav1.HetznerCluster{ObjectMeta: metav1.ObjectMeta{Name: "hetzner-cluster"}}
+
+		Expect(service.serverLabels()).To(HaveKeyWithValue("machine_type", "worker"))
+	})
+
+	It("only sets the propagated labels that are selected by the cluster", func() {
+		hcloudMachine := &infrav1.HCloudMachine{ObjectMeta: metav1.ObjectMeta{
+			Name:        "hcloud-machine",
+			Labels:      map[string]string{"cost-center": "team-a", "example.com/owner": "alice", "tier": "frontend"},
+			Annotations: map[string]string{infrav1.PropagatedLabelsAnnotation: "cost-center,example.com/owner,tier"},
+		}}
+		service := newTestService(hcloudMachine, nil)
+		service.scope.Machine = &clusterv1.Machine{}
+		service.scope.HetznerCluster = &infrav1.HetznerCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "hetzner-cluster"},
+			Spec: infrav1.HetznerClusterSpec{ServerLabels: &infrav1.ServerLabels{
+				Keys:     []string{"cost-center"},
+				Prefixes: []string{"example.com/"},
+			}},
+		}
+
+		labels := service.serverLabels()
+		Expect(labels).To(HaveKeyWithValue("cost-center", "team-a"))
+		Expect(labels).To(HaveKeyWithValue("example.com/owner", "alice"))
+		Expect(labels).ToNot(HaveKey("tier"))
+		Expect(labels).To(HaveKeyWithValue("machine_type", "worker"))
+	})
+
+	It("keeps labels added outside of the controller with the drift policy Adopt", func() {
+		ctx := context.Background()
+		client := fakeclient.NewHCloudClientFactory().NewClient("")
+
+		hcloudMachine := &infrav1.HCloudMachine{ObjectMeta: metav1.ObjectMeta{Name: "adopting-machine"}}
+		service := newTestService(hcloudMachine, client)
+		service.scope.Machine = &clusterv1.Machine{}
+		service.scope.HetznerCluster = &infrav1.HetznerCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "hetzner-cluster"},
+			Spec:       infrav1.HetznerClusterSpec{DriftPolicies: &infrav1.DriftPolicies{Servers: infrav1.DriftPolicyAdopt}},
+		}
+
+		res, err := client.CreateServer(ctx, hcloud.ServerCreateOpts{
+			Name:   "adopting-machine",
+			Labels: map[string]string{"backup": "daily", "machine_type": "control_plane"},
+		})
+		Expect(err).To(Succeed())
+
+		Expect(service.reconcileLabels(ctx, res.Server)).To(Succeed())
+
+		Expect(res.Server.Labels).To(HaveKeyWithValue("backup", "daily"))
+		for key, value := range createLabels("hetzner-cluster", "adopting-machine", false) {
+			Expect(res.Server.Labels).To(HaveKeyWithValue(key, value))
+		}
+		condition := conditions.Get(hcloudMachine, infrav1.ServerInSyncCondition)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(infrav1.DriftAdoptedReason))
+		Expect(condition.Message).To(Equal("kept labels added outside of the controller: backup"))
+	})
+
+	It("removes labels that have been removed from the Machine with the drift policy Adopt", func() {
+		ctx := context.Background()
+		client := fakeclient.NewHCloudClientFactory().NewClient("")
+
+		hcloudMachine := &infrav1.HCloudMachine{ObjectMeta: metav1.ObjectMeta{
+			Name:        "label-removing-machine",
+			Labels:      map[string]string{"cost-center": "team-a"},
+			Annotations: map[string]string{infrav1.PropagatedLabelsAnnotation: "cost-center"},
+		}}
+		service := newTestService(hcloudMachine, client)
+		service.scope.Machine = &clusterv1.Machine{}
+		service.scope.HetznerCluster = &infrav1.HetznerCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "hetzner-cluster"},
+			Spec:       infrav1.HetznerClusterSpec{DriftPolicies: &infrav1.DriftPolicies{Servers: infrav1.DriftPolicyAdopt}},
+		}
+
+		res, err := client.CreateServer(ctx, hcloud.ServerCreateOpts{
+			Name:   "label-removing-machine",
+			Labels: map[string]string{"backup": "daily"},
+		})
+		Expect(err).To(Succeed())
+
+		Expect(service.reconcileLabels(ctx, res.Server)).To(Succeed())
+		Expect(res.Server.Labels).To(HaveKeyWithValue("cost-center", "team-a"))
+		Expect(hcloudMachine.Status.AppliedLabels).To(ContainElement("cost-center"))
+
+		delete(hcloudMachine.Labels, "cost-center")
+		Expect(service.reconcileLabels(ctx, res.Server)).To(Succeed())
+
+		Expect(res.Server.Labels).ToNot(HaveKey("cost-center"))
+		Expect(res.Server.Labels).To(HaveKeyWithValue("backup", "daily"))
+		Expect(hcloudMachine.Status.AppliedLabels).ToNot(ContainElement("cost-center"))
+	})
+})
+
+var _ = Describe("reconcileConsoleUser", func() {
+	It("reports a console user that has been removed after the server was created", func() {
+		hcloudMachine := &infrav1.HCloudMachine{ObjectMeta: metav1.ObjectMeta{Name: "console-user-machine"}}
+		hcloudMachine.Status.AppliedConfiguration = &infrav1.AppliedConfiguration{
+			ConsoleUser: &infrav1.AppliedConsoleUser{Name: "console", PasswordHash: "hash"},
+		}
+		service := newTestService(hcloudMachine, nil)
+		service.scope.HetznerCluster = &infrav1.HetznerCluster{}
+
+		Expect(service.reconcileConsoleUser(context.Background())).To(Succeed())
+
+		condition := conditions.Get(hcloudMachine, infrav1.ConsoleUserInSyncCondition)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(infrav1.ConsoleUserChangedReason))
+		Expect(condition.Message).To(Equal(`console user "console" has been removed after the server was created, replace the machine to apply it`))
+	})
+})
+
+var _ = Describe("serverSSHKeys", func() {
+	const publicKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl team@example.com"
+
+	var service *Service
+
+	BeforeEach(func() {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "break-glass", Namespace: "default"},
+			Data:       map[string][]byte{"publicKey": []byte(publicKey)},
+		}
+		scheme := runtime.NewScheme()
+		utilruntime.Must(corev1.AddToScheme(scheme))
+		k8sClient := fakek8sclient.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+
+		hcloudMachine := &infrav1.HCloudMachine{ObjectMeta: metav1.ObjectMeta{Name: "hcloud-machine", Namespace: "default"}}
+		service = newTestService(hcloudMachine, fakeclient.NewHCloudClientFactory().NewClient(""))
+		service.scope.Client = k8sClient
+		service.scope.APIReader = k8sClient
+		logger := logr.Discard()
+		service.scope.Logger = &logger
+		service.scope.HetznerCluster = &infrav1.HetznerCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "ssh-keys-test", Namespace: "default"},
+			Spec: infrav1.HetznerClusterSpec{
+				SSHKeys: infrav1.HetznerSSHKeys{HCloud: []infrav1.SSHKey{{Name: "testsshkey"}}},
+			},
+		}
+	})
+
+	It("installs the additional ssh keys once in addition to the cluster keys", func() {
+		service.scope.HCloudMachine.Spec.AdditionalSSHKeys = []infrav1.SSHKeyReference{{Name: "testsshkey"}}
+
+		sshKeys, err := service.serverSSHKeys(context.Background())
+		Expect(err).To(Succeed())
+		Expect(sshKeys).To(HaveLen(1))
+		Expect(sshKeys[0].Name).To(Equal("testsshkey"))
+	})
+
+	It("creates the ssh keys of secrets once", func() {
+		service.scope.HCloudMachine.Spec.AdditionalSSHKeys = []infrav1.SSHKeyReference{
+			{SecretRef: &infrav1.SSHPublicKeySecretRef{Name: "break-glass"}},
+		}
+
+		sshKeys, err := service.serverSSHKeys(context.Background())
+		Expect(err).To(Succeed())
+		Expect(sshKeys).To(HaveLen(2))
+		Expect(sshKeys[1].Name).To(HavePrefix("ssh-keys-test-"))
+		Expect(sshKeys[1].Labels).To(HaveKeyWithValue(infrav1.ClusterTagKey("ssh-keys-test"), string(infrav1.ResourceLifecycleOwned)))
+
+		sshKeysAgain, err := service.serverSSHKeys(context.Background())
+		Expect(err).To(Succeed())
+		Expect(sshKeysAgain).To(HaveLen(2))
+		Expect(sshKeysAgain[1].ID).To(Equal(sshKeys[1].ID))
+	})
+
+	It("fails if the secret has no public key", func() {
+		service.scope.HCloudMachine.Spec.AdditionalSSHKeys = []infrav1.SSHKeyReference{
+			{SecretRef: &infrav1.SSHPublicKeySecretRef{Name: "break-glass", Key: "otherKey"}},
+		}
+
+		_, err := service.serverSSHKeys(context.Background())
+		Expect(err).To(MatchError(ContainSubstring("secret has no key otherKey")))
+	})
+})
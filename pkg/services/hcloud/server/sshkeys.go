@@ -0,0 +1,149 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"github.com/pkg/errors"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	secretutil "github.com/syself/cluster-api-provider-hetzner/pkg/secrets"
+	"golang.org/x/crypto/ssh"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/record"
+)
+
+// additionalSSHKeys returns the additional SSH keys of the machine. Public keys of secrets are added to HCloud if the
+// project has no SSH key with the same fingerprint.
+func (s *Service) additionalSSHKeys(ctx context.Context, sshKeysAPI []*hcloud.SSHKey) ([]*hcloud.SSHKey, error) {
+	sshKeys := make([]*hcloud.SSHKey, 0, len(s.scope.HCloudMachine.Spec.AdditionalSSHKeys))
+	for _, ref := range s.scope.HCloudMachine.Spec.AdditionalSSHKeys {
+		if ref.SecretRef == nil {
+			sshKey, err := getSSHKeys(sshKeysAPI, []infrav1.SSHKey{{Name: ref.Name}})
+			if err != nil {
+				return nil, err
+			}
+			sshKeys = append(sshKeys, sshKey...)
+			continue
+		}
+
+		sshKey, err := s.secretSSHKey(ctx, sshKeysAPI, ref.SecretRef)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get ssh key of secret %s", ref.SecretRef.Name)
+		}
+		sshKeys = append(sshKeys, sshKey)
+	}
+	return sshKeys, nil
+}
+
+// secretSSHKey returns the SSH key of HCloud with the fingerprint of the public key in the secret and creates it if
+// it does not exist yet.
+func (s *Service) secretSSHKey(ctx context.Context, sshKeysAPI []*hcloud.SSHKey, ref *infrav1.SSHPublicKeySecretRef) (*hcloud.SSHKey, error) {
+	key := types.NamespacedName{Namespace: s.scope.Namespace(), Name: ref.Name}
+	// The secret is shared, e.g. by the machines of a team, so it does not get an owner reference
+	secretManager := secretutil.NewSecretManager(*s.scope.Logger, s.scope.Client, s.scope.APIReader)
+	secret, err := secretManager.ObtainSecret(ctx, key)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain secret")
+	}
+
+	data, found := secret.Data[ref.SSHPublicKeyKey()]
+	if !found {
+		return nil, fmt.Errorf("secret has no key %s", ref.SSHPublicKeyKey())
+	}
+	publicKey, _, _, _, err := ssh.ParseAuthorizedKey(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse public key")
+	}
+	fingerprint := ssh.FingerprintLegacyMD5(publicKey)
+
+	if sshKey := findSSHKeyByFingerprint(sshKeysAPI, fingerprint); sshKey != nil {
+		return sshKey, nil
+	}
+
+	opts := hcloud.SSHKeyCreateOpts{
+		Name:      sshKeyName(s.scope.HetznerCluster.Name, fingerprint),
+		PublicKey: strings.TrimSpace(string(ssh.MarshalAuthorizedKey(publicKey))),
+		Labels: map[string]string{
+			infrav1.ClusterTagKey(s.scope.HetznerCluster.Name): string(infrav1.ResourceLifecycleOwned),
+		},
+	}
+	sshKey, err := s.scope.HCloudClient.CreateSSHKey(ctx, opts)
+	if err == nil {
+		record.Eventf(s.scope.HCloudMachine, "SSHKeyCreated", "Created ssh key %s of secret %s", sshKey.Name, ref.Name)
+		return sshKey, nil
+	}
+	if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+		conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+		record.Event(s.scope.HCloudMachine,
+			"RateLimitExceeded",
+			"exceeded rate limit with calling hcloud function CreateSSHKey",
+		)
+	}
+	if !hcloud.IsError(err, hcloud.ErrorCodeUniquenessError) {
+		return nil, errors.Wrap(err, "failed to create ssh key")
+	}
+
+	// The key has been created in the meantime, e.g. by another machine, and is not in the shared lookup yet
+	sshKeysAPI, err = s.scope.HCloudClient.ListSSHKeys(ctx, hcloud.SSHKeyListOpts{})
+	if err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HCloudMachine,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function ListSSHKeys",
+			)
+		}
+		return nil, errors.Wrap(err, "failed listing ssh keys from hcloud")
+	}
+	if sshKey := findSSHKeyByFingerprint(sshKeysAPI, fingerprint); sshKey != nil {
+		return sshKey, nil
+	}
+	return nil, fmt.Errorf("ssh key %s exists with another public key", opts.Name)
+}
+
+// sshKeyName returns the name of an SSH key that is created for a public key of a secret. It is unique per cluster
+// and public key.
+func sshKeyName(clusterName, fingerprint string) string {
+	hash := sha256.Sum256([]byte(fingerprint))
+	return fmt.Sprintf("%s-%s", clusterName, hex.EncodeToString(hash[:])[:10])
+}
+
+func findSSHKeyByFingerprint(sshKeys []*hcloud.SSHKey, fingerprint string) *hcloud.SSHKey {
+	for _, sshKey := range sshKeys {
+		if sshKey.Fingerprint == fingerprint {
+			return sshKey
+		}
+	}
+	return nil
+}
+
+// appendSSHKeys appends the SSH keys that are not in the list yet, as HCloud rejects duplicate keys.
+func appendSSHKeys(sshKeys []*hcloud.SSHKey, additional ...*hcloud.SSHKey) []*hcloud.SSHKey {
+	for _, sshKey := range additional {
+		if findSSHKeyByFingerprint(sshKeys, sshKey.Fingerprint) == nil {
+			sshKeys = append(sshKeys, sshKey)
+		}
+	}
+	return sshKeys
+}
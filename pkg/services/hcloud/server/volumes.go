@@ -0,0 +1,219 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"github.com/pkg/errors"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/userdata"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/utils"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/record"
+)
+
+// volumeName returns the name of the volume in HCloud.
+func (s *Service) volumeName(spec infrav1.HCloudVolumeSpec) string {
+	return fmt.Sprintf("%s-%s", s.scope.Name(), spec.Name)
+}
+
+// ensureVolumes returns the volumes of the spec in the location of the server. A volume with the name of the
+// volume that is not attached, e.g. a retained volume of a previous server of the machine, is adopted. Other
+// volumes are created.
+func (s *Service) ensureVolumes(ctx context.Context, location string) ([]*hcloud.Volume, error) {
+	volumes := make([]*hcloud.Volume, 0, len(s.scope.HCloudMachine.Spec.Volumes))
+	for _, spec := range s.scope.HCloudMachine.Spec.Volumes {
+		name := s.volumeName(spec)
+		existing, err := s.scope.HCloudClient.ListVolumes(ctx, hcloud.VolumeListOpts{Name: name})
+		if err != nil {
+			if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+				conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+				record.Event(s.scope.HCloudMachine,
+					"RateLimitExceeded",
+					"exceeded rate limit with calling hcloud function ListVolumes",
+				)
+			}
+			return nil, errors.Wrapf(err, "failed to list volume %s", name)
+		}
+
+		if len(existing) > 0 {
+			volume := existing[0]
+			var msg string
+			switch {
+			case volume.Server != nil:
+				msg = fmt.Sprintf("volume %s is attached to server %d", name, volume.Server.ID)
+			case volume.Location == nil || volume.Location.Name != location:
+				msg = fmt.Sprintf("volume %s is not in %s", name, location)
+			}
+			if msg != "" {
+				conditions.MarkFalse(s.scope.HCloudMachine,
+					infrav1.InstanceReadyCondition,
+					infrav1.VolumeNotAvailableReason,
+					clusterv1.ConditionSeverityWarning,
+					msg,
+				)
+				return nil, errors.New(msg)
+			}
+			record.Eventf(s.scope.HCloudMachine, "VolumeAdopted", "Adopted volume %s with id %d", name, volume.ID)
+			volumes = append(volumes, volume)
+			continue
+		}
+
+		format := string(spec.Format)
+		if format == "" {
+			format = string(infrav1.HCloudVolumeFormatExt4)
+		}
+		automount := false
+		res, err := s.scope.HCloudClient.CreateVolume(ctx, hcloud.VolumeCreateOpts{
+			Name:      name,
+			Size:      spec.Size,
+			Location:  &hcloud.Location{Name: location},
+			Labels:    createLabels(s.scope.HetznerCluster.Name, s.scope.Name(), s.scope.IsControlPlane()),
+			Automount: &automount,
+			Format:    &format,
+		})
+		if err != nil {
+			if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+				conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+				record.Event(s.scope.HCloudMachine,
+					"RateLimitExceeded",
+					"exceeded rate limit with calling hcloud function CreateVolume",
+				)
+			}
+			conditions.MarkFalse(s.scope.HCloudMachine,
+				infrav1.InstanceReadyCondition,
+				infrav1.VolumeCreateFailedReason,
+				clusterv1.ConditionSeverityWarning,
+				"failed to create volume %s: %s", name, err,
+			)
+			return nil, errors.Wrapf(err, "failed to create volume %s", name)
+		}
+		record.Eventf(s.scope.HCloudMachine, "VolumeCreated", "Created volume %s with id %d", name, res.Volume.ID)
+		volumes = append(volumes, res.Volume)
+	}
+	return volumes, nil
+}
+
+// volumeMounts returns the mounts of the volumes that have a mount path. The volumes are in the order of the spec.
+func (s *Service) volumeMounts(volumes []*hcloud.Volume) []userdata.VolumeMount {
+	var mounts []userdata.VolumeMount
+	for i, spec := range s.scope.HCloudMachine.Spec.Volumes {
+		if spec.MountPath == "" {
+			continue
+		}
+		format := string(spec.Format)
+		if format == "" {
+			format = string(infrav1.HCloudVolumeFormatExt4)
+		}
+		mounts = append(mounts, userdata.VolumeMount{
+			Device: volumeDevice(volumes[i]),
+			Path:   spec.MountPath,
+			Format: format,
+		})
+	}
+	return mounts
+}
+
+// volumeDevice returns the path of the device of the volume on the server.
+func volumeDevice(volume *hcloud.Volume) string {
+	if volume.LinuxDevice != "" {
+		return volume.LinuxDevice
+	}
+	return fmt.Sprintf("/dev/disk/by-id/scsi-0HC_Volume_%d", volume.ID)
+}
+
+// volumeStatus returns the status of the volumes, which are in the order of the spec.
+func (s *Service) volumeStatus(volumes []*hcloud.Volume) []infrav1.HCloudVolumeStatus {
+	var status []infrav1.HCloudVolumeStatus
+	for i, spec := range s.scope.HCloudMachine.Spec.Volumes {
+		status = append(status, infrav1.HCloudVolumeStatus{
+			Name:        spec.Name,
+			ID:          volumes[i].ID,
+			LinuxDevice: volumeDevice(volumes[i]),
+		})
+	}
+	return status
+}
+
+// deleteVolumes deletes the volumes of the machine with the reclaim policy Delete after the server has been
+// deleted. It returns true while a volume is still attached, as HCloud detaches the volumes of a deleted
+// server asynchronously.
+func (s *Service) deleteVolumes(ctx context.Context) (bool, error) {
+	if len(s.scope.HCloudMachine.Spec.Volumes) == 0 {
+		return false, nil
+	}
+
+	selector := utils.LabelsToLabelSelector(map[string]string{
+		infrav1.ClusterTagKey(s.scope.HetznerCluster.Name): string(infrav1.ResourceLifecycleOwned),
+		infrav1.MachineNameTagKey:                          s.scope.Name(),
+	})
+	volumes, err := s.scope.HCloudClient.ListVolumes(ctx, hcloud.VolumeListOpts{ListOpts: hcloud.ListOpts{LabelSelector: selector}})
+	if err != nil {
+		if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+			conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+			record.Event(s.scope.HCloudMachine,
+				"RateLimitExceeded",
+				"exceeded rate limit with calling hcloud function ListVolumes",
+			)
+		}
+		return false, errors.Wrap(err, "failed to list volumes")
+	}
+
+	var attached bool
+	for _, volume := range volumes {
+		if s.volumeReclaimPolicy(volume.Name) == infrav1.VolumeReclaimPolicyRetain {
+			continue
+		}
+		if volume.Server != nil {
+			attached = true
+			continue
+		}
+		if err := s.scope.HCloudClient.DeleteVolume(ctx, volume); err != nil {
+			if hcloud.IsError(err, hcloud.ErrorCodeNotFound) {
+				continue
+			}
+			if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+				conditions.MarkTrue(s.scope.HCloudMachine, infrav1.RateLimitExceeded)
+				record.Event(s.scope.HCloudMachine,
+					"RateLimitExceeded",
+					"exceeded rate limit with calling hcloud function DeleteVolume",
+				)
+			}
+			return false, errors.Wrapf(err, "failed to delete volume %s", volume.Name)
+		}
+		record.Eventf(s.scope.HCloudMachine, "VolumeDeleted", "Deleted volume %s", volume.Name)
+	}
+	return attached, nil
+}
+
+// volumeReclaimPolicy returns the reclaim policy of the volume with the name in HCloud. Volumes that are not in
+// the spec anymore are deleted.
+func (s *Service) volumeReclaimPolicy(name string) infrav1.VolumeReclaimPolicy {
+	for _, spec := range s.scope.HCloudMachine.Spec.Volumes {
+		if s.volumeName(spec) == name {
+			if spec.ReclaimPolicy == "" {
+				return infrav1.VolumeReclaimPolicyDelete
+			}
+			return spec.ReclaimPolicy
+		}
+	}
+	return infrav1.VolumeReclaimPolicyDelete
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	fakeclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+)
+
+var _ = Describe("volumes", func() {
+	var service *Service
+	var machineCount int
+
+	BeforeEach(func() {
+		machineCount++
+		hcloudMachine := &infrav1.HCloudMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("volumes-machine-%d", machineCount), Namespace: "default"},
+			Spec: infrav1.HCloudMachineSpec{
+				Type: "cpx31",
+				Volumes: []infrav1.HCloudVolumeSpec{
+					{Name: "etcd", Size: 10, Format: infrav1.HCloudVolumeFormatExt4, MountPath: "/var/lib/etcd", ReclaimPolicy: infrav1.VolumeReclaimPolicyDelete},
+					{Name: "data", Size: 20, Format: infrav1.HCloudVolumeFormatXFS, ReclaimPolicy: infrav1.VolumeReclaimPolicyRetain},
+				},
+			},
+		}
+		service = newTestService(hcloudMachine, fakeclient.NewHCloudClientFactory().NewClient(""))
+		service.scope.Machine = &clusterv1.Machine{}
+		service.scope.HetznerCluster = &infrav1.HetznerCluster{ObjectMeta: metav1.ObjectMeta{Name: "volumes-cluster"}}
+	})
+
+	It("creates the volumes and mounts the ones with a mount path", func() {
+		volumes, err := service.ensureVolumes(context.Background(), "fsn1")
+		Expect(err).To(Succeed())
+		Expect(volumes).To(HaveLen(2))
+		Expect(volumes[0].Name).To(Equal(service.scope.Name() + "-etcd"))
+		Expect(volumes[0].Size).To(Equal(10))
+		Expect(volumes[0].Location.Name).To(Equal("fsn1"))
+
+		mounts := service.volumeMounts(volumes)
+		Expect(mounts).To(HaveLen(1))
+		Expect(mounts[0].Device).To(Equal(volumes[0].LinuxDevice))
+		Expect(mounts[0].Path).To(Equal("/var/lib/etcd"))
+		Expect(mounts[0].Format).To(Equal("ext4"))
+
+		status := service.volumeStatus(volumes)
+		Expect(status).To(HaveLen(2))
+		Expect(status[1].Name).To(Equal("data"))
+		Expect(status[1].ID).To(Equal(volumes[1].ID))
+	})
+
+	It("adopts volumes that are not attached", func() {
+		created, err := service.ensureVolumes(context.Background(), "fsn1")
+		Expect(err).To(Succeed())
+
+		adopted, err := service.ensureVolumes(context.Background(), "fsn1")
+		Expect(err).To(Succeed())
+		Expect(adopted[0].ID).To(Equal(created[0].ID))
+		Expect(adopted[1].ID).To(Equal(created[1].ID))
+	})
+
+	It("does not use a volume that is attached to another server or in another location", func() {
+		created, err := service.ensureVolumes(context.Background(), "fsn1")
+		Expect(err).To(Succeed())
+
+		_, err = service.ensureVolumes(context.Background(), "nbg1")
+		Expect(err).To(HaveOccurred())
+		Expect(conditions.GetReason(service.scope.HCloudMachine, infrav1.InstanceReadyCondition)).To(Equal(infrav1.VolumeNotAvailableReason))
+
+		created[0].Server = &hcloud.Server{ID: 42}
+		_, err = service.ensureVolumes(context.Background(), "fsn1")
+		Expect(err).To(MatchError(ContainSubstring("is attached to server 42")))
+	})
+
+	It("deletes the volumes with the reclaim policy Delete once they are detached", func() {
+		created, err := service.ensureVolumes(context.Background(), "fsn1")
+		Expect(err).To(Succeed())
+
+		created[0].Server = &hcloud.Server{ID: 42}
+		attached, err := service.deleteVolumes(context.Background())
+		Expect(err).To(Succeed())
+		Expect(attached).To(BeTrue())
+
+		created[0].Server = nil
+		attached, err = service.deleteVolumes(context.Background())
+		Expect(err).To(Succeed())
+		Expect(attached).To(BeFalse())
+
+		volumes, err := service.scope.HCloudClient.ListVolumes(context.Background(), hcloud.VolumeListOpts{})
+		Expect(err).To(Succeed())
+		var names []string
+		for _, volume := range volumes {
+			names = append(names, volume.Name)
+		}
+		Expect(names).ToNot(ContainElement(service.scope.Name() + "-etcd"))
+		Expect(names).To(ContainElement(service.scope.Name() + "-data"))
+	})
+})
@@ -0,0 +1,93 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sshkey implements the deletion of the HCloud SSH keys that have been created for the public keys of
+// secrets referenced by HCloud machines.
+package sshkey
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"github.com/pkg/errors"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/scope"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/utils"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// Service struct contains cluster scope to delete SSH keys.
+type Service struct {
+	scope *scope.ClusterScope
+}
+
+// NewService creates new service object.
+func NewService(scope *scope.ClusterScope) *Service {
+	return &Service{
+		scope: scope,
+	}
+}
+
+// Delete deletes the SSH keys that are owned by the cluster. SSH keys that existed before and are referenced by name
+// are not touched.
+func (s *Service) Delete(ctx context.Context) error {
+	log := ctrl.LoggerFrom(ctx)
+	log.V(1).Info("Delete ssh keys")
+
+	opts := hcloud.SSHKeyListOpts{}
+	opts.LabelSelector = utils.LabelsToLabelSelector(map[string]string{
+		infrav1.ClusterTagKey(s.scope.HetznerCluster.Name): string(infrav1.ResourceLifecycleOwned),
+	})
+	sshKeys, err := s.scope.HCloudClient.ListSSHKeys(ctx, opts)
+	if err != nil {
+		s.handleRateLimit(err, "ListSSHKeys")
+		return errors.Wrap(err, "failed to list ssh keys")
+	}
+
+	var multierr []error
+	for _, sshKey := range sshKeys {
+		if err := s.scope.HCloudClient.DeleteSSHKey(ctx, sshKey); err != nil {
+			s.handleRateLimit(err, "DeleteSSHKey")
+			if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+				return err
+			}
+			if !hcloud.IsError(err, hcloud.ErrorCodeNotFound) {
+				multierr = append(multierr, errors.Wrapf(err, "failed to delete ssh key %s", sshKey.Name))
+			}
+			continue
+		}
+		record.Eventf(s.scope.HetznerCluster, "SSHKeyDeleted", "Deleted ssh key %s", sshKey.Name)
+	}
+
+	if err := kerrors.NewAggregate(multierr); err != nil {
+		return errors.Wrap(err, "aggregate error - deleting ssh keys")
+	}
+	return nil
+}
+
+func (s *Service) handleRateLimit(err error, function string) {
+	if hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+		conditions.MarkTrue(s.scope.HetznerCluster, infrav1.RateLimitExceeded)
+		record.Event(s.scope.HetznerCluster,
+			"RateLimitExceeded",
+			fmt.Sprintf("exceeded rate limit with calling hcloud function %s", function),
+		)
+	}
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sshkey
+
+import (
+	"context"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/scope"
+	fakeclient "github.com/syself/cluster-api-provider-hetzner/pkg/services/hcloud/client/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const publicKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl"
+
+var _ = Describe("Delete", func() {
+	It("deletes the ssh keys of the cluster only", func() {
+		ctx := context.Background()
+		hcloudClient := fakeclient.NewHCloudClientFactory().NewClient("")
+		hcloudClient.Close()
+
+		owned, err := hcloudClient.CreateSSHKey(ctx, hcloud.SSHKeyCreateOpts{
+			Name:      "sshkey-test-owned",
+			PublicKey: publicKey,
+			Labels:    map[string]string{infrav1.ClusterTagKey("sshkey-test"): string(infrav1.ResourceLifecycleOwned)},
+		})
+		Expect(err).To(Succeed())
+		_, err = hcloudClient.CreateSSHKey(ctx, hcloud.SSHKeyCreateOpts{
+			Name:      "sshkey-test-other",
+			PublicKey: publicKey,
+			Labels:    map[string]string{infrav1.ClusterTagKey("other"): string(infrav1.ResourceLifecycleOwned)},
+		})
+		Expect(err).To(Succeed())
+
+		hetznerCluster := &infrav1.HetznerCluster{ObjectMeta: metav1.ObjectMeta{Name: "sshkey-test", Namespace: "default"}}
+		service := NewService(&scope.ClusterScope{HCloudClient: hcloudClient, HetznerCluster: hetznerCluster})
+		Expect(service.Delete(ctx)).To(Succeed())
+
+		sshKeys, err := hcloudClient.ListSSHKeys(ctx, hcloud.SSHKeyListOpts{})
+		Expect(err).To(Succeed())
+		Expect(sshKeys).ToNot(ContainElement(owned))
+		Expect(sshKeys).To(HaveLen(2))
+	})
+})
@@ -0,0 +1,84 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sharding distributes the reconciliation of resources across several managers.
+package sharding
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// Shard is one of Count shards that reconcile the resources of a management cluster. Namespaces are
+// assigned to shards by the hash of their name, so that all resources of a namespace, e.g. the bare metal
+// hosts and the machines that consume them, are reconciled by the same manager.
+//
+// The zero value is a single shard that reconciles all namespaces.
+type Shard struct {
+	Index int
+	Count int
+}
+
+// Validate checks that the index is one of the shards.
+func (s Shard) Validate() error {
+	if s.Count < 0 {
+		return fmt.Errorf("shard count must not be negative, got %d", s.Count)
+	}
+	if s.Count <= 1 {
+		if s.Index != 0 {
+			return fmt.Errorf("shard index must be 0 without sharding, got %d", s.Index)
+		}
+		return nil
+	}
+	if s.Index < 0 || s.Index >= s.Count {
+		return fmt.Errorf("shard index must be between 0 and %d, got %d", s.Count-1, s.Index)
+	}
+	return nil
+}
+
+// Enabled returns whether the resources are distributed across more than one shard.
+func (s Shard) Enabled() bool {
+	return s.Count > 1
+}
+
+// Owns returns whether the resources of the namespace are reconciled by the shard.
+func (s Shard) Owns(namespace string) bool {
+	if !s.Enabled() {
+		return true
+	}
+	h := fnv.New32a()
+	// Writes to a hash never fail.
+	_, _ = h.Write([]byte(namespace))
+	return int(h.Sum32()%uint32(s.Count)) == s.Index
+}
+
+// Predicate filters the events of objects whose namespace is not owned by the shard.
+func (s Shard) Predicate() predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return s.Owns(obj.GetNamespace())
+	})
+}
+
+// LeaderElectionID returns the ID of the leader election of the shard, so that one manager per shard is active.
+func (s Shard) LeaderElectionID(id string) string {
+	if !s.Enabled() {
+		return id
+	}
+	return fmt.Sprintf("%s-shard-%d", id, s.Index)
+}
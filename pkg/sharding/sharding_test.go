@@ -0,0 +1,71 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/sharding"
+)
+
+var _ = Describe("Shard", func() {
+	It("owns all namespaces without sharding", func() {
+		Expect(sharding.Shard{}.Owns("default")).To(BeTrue())
+		Expect(sharding.Shard{Count: 1}.Owns("other")).To(BeTrue())
+	})
+
+	It("assigns every namespace to exactly one shard", func() {
+		owned := make([]int, 3)
+		for i := 0; i < 100; i++ {
+			namespace := fmt.Sprintf("cluster-%d", i)
+			var owners int
+			for index := range owned {
+				if (sharding.Shard{Index: index, Count: len(owned)}).Owns(namespace) {
+					owners++
+					owned[index]++
+				}
+			}
+			Expect(owners).To(Equal(1), namespace)
+		}
+		for _, count := range owned {
+			Expect(count).To(BeNumerically(">", 0))
+		}
+	})
+
+	It("uses one leader election per shard", func() {
+		Expect(sharding.Shard{}.LeaderElectionID("id")).To(Equal("id"))
+		Expect(sharding.Shard{Index: 2, Count: 3}.LeaderElectionID("id")).To(Equal("id-shard-2"))
+	})
+})
+
+var _ = DescribeTable("Validate",
+	func(shard sharding.Shard, valid bool) {
+		if valid {
+			Expect(shard.Validate()).To(Succeed())
+		} else {
+			Expect(shard.Validate()).ToNot(Succeed())
+		}
+	},
+	Entry("no sharding", sharding.Shard{}, true),
+	Entry("last shard", sharding.Shard{Index: 2, Count: 3}, true),
+	Entry("index out of range", sharding.Shard{Index: 3, Count: 3}, false),
+	Entry("negative index", sharding.Shard{Index: -1, Count: 3}, false),
+	Entry("index without sharding", sharding.Shard{Index: 1}, false),
+	Entry("negative count", sharding.Shard{Count: -1}, false),
+)
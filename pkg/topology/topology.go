@@ -0,0 +1,72 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package topology contains functions to derive the topology labels of nodes.
+package topology
+
+import (
+	"strconv"
+	"strings"
+
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// bareMetalProviderIDPrefix is the prefix of the provider ID of nodes that run on bare metal hosts.
+const bareMetalProviderIDPrefix = "hcloud://" + infrav1.BareMetalHostNamePrefix
+
+// BareMetalServerID returns the ID of the bare metal server of the node. The second return value
+// is false if the node does not run on a bare metal host.
+func BareMetalServerID(node *corev1.Node) (int, bool) {
+	if !strings.HasPrefix(node.Spec.ProviderID, bareMetalProviderIDPrefix) {
+		return 0, false
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(node.Spec.ProviderID, bareMetalProviderIDPrefix))
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+// BareMetalNodeLabels returns the topology labels of the node of a bare metal host. Like for HCloud
+// servers, the zone is the datacenter, e.g. fsn1-dc14, and the region is its location, e.g. fsn1.
+func BareMetalNodeLabels(host *infrav1.HetznerBareMetalHost) map[string]string {
+	labels := make(map[string]string)
+	if dc := strings.ToLower(host.Spec.Status.Datacenter); dc != "" {
+		labels[corev1.LabelTopologyZone] = dc
+		labels[corev1.LabelTopologyRegion] = strings.SplitN(dc, "-", 2)[0]
+	}
+	if host.Spec.Rack != "" {
+		labels[infrav1.RackLabel] = host.Spec.Rack
+	}
+	return labels
+}
+
+// SetLabels sets the labels on the node and returns whether the node has been changed.
+func SetLabels(node *corev1.Node, labels map[string]string) bool {
+	var changed bool
+	for key, value := range labels {
+		if current, ok := node.Labels[key]; ok && current == value {
+			continue
+		}
+		if node.Labels == nil {
+			node.Labels = make(map[string]string)
+		}
+		node.Labels[key] = value
+		changed = true
+	}
+	return changed
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package topology_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+	"github.com/syself/cluster-api-provider-hetzner/pkg/topology"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = DescribeTable("BareMetalServerID",
+	func(providerID string, expectedID int, expectedOK bool) {
+		id, ok := topology.BareMetalServerID(&corev1.Node{Spec: corev1.NodeSpec{ProviderID: providerID}})
+		Expect(ok).To(Equal(expectedOK))
+		Expect(id).To(Equal(expectedID))
+	},
+	Entry("bare metal node", "hcloud://bm-1234", 1234, true),
+	Entry("hcloud node", "hcloud://1234", 0, false),
+	Entry("invalid server id", "hcloud://bm-abc", 0, false),
+	Entry("no provider id", "", 0, false),
+)
+
+var _ = Describe("BareMetalNodeLabels", func() {
+	It("sets zone, region and rack", func() {
+		host := &infrav1.HetznerBareMetalHost{Spec: infrav1.HetznerBareMetalHostSpec{
+			Rack:   "rack-12",
+			Status: infrav1.ControllerGeneratedStatus{Datacenter: "FSN1-DC14"},
+		}}
+		Expect(topology.BareMetalNodeLabels(host)).To(Equal(map[string]string{
+			corev1.LabelTopologyZone:   "fsn1-dc14",
+			corev1.LabelTopologyRegion: "fsn1",
+			infrav1.RackLabel:          "rack-12",
+		}))
+	})
+
+	It("sets no labels if neither datacenter nor rack are known", func() {
+		Expect(topology.BareMetalNodeLabels(&infrav1.HetznerBareMetalHost{})).To(BeEmpty())
+	})
+})
+
+var _ = Describe("SetLabels", func() {
+	It("adds missing and changes outdated labels", func() {
+		node := &corev1.Node{}
+		node.Labels = map[string]string{"foo": "bar", corev1.LabelTopologyZone: "nbg1-dc3"}
+		Expect(topology.SetLabels(node, map[string]string{corev1.LabelTopologyZone: "fsn1-dc14", infrav1.RackLabel: "rack-12"})).To(BeTrue())
+		Expect(node.Labels).To(Equal(map[string]string{
+			"foo":                    "bar",
+			corev1.LabelTopologyZone: "fsn1-dc14",
+			infrav1.RackLabel:        "rack-12",
+		}))
+	})
+
+	It("does not change nodes that have all labels", func() {
+		node := &corev1.Node{}
+		node.Labels = map[string]string{corev1.LabelTopologyZone: "fsn1-dc14"}
+		Expect(topology.SetLabels(node, map[string]string{corev1.LabelTopologyZone: "fsn1-dc14"})).To(BeFalse())
+	})
+
+	It("handles nodes without labels", func() {
+		node := &corev1.Node{}
+		Expect(topology.SetLabels(node, map[string]string{infrav1.RackLabel: "rack-12"})).To(BeTrue())
+		Expect(node.Labels).To(HaveKeyWithValue(infrav1.RackLabel, "rack-12"))
+	})
+})
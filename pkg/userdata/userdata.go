@@ -0,0 +1,577 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package userdata contains functions to add configuration of the provider to the user data of nodes.
+package userdata
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+)
+
+// ErrUnsupportedUserData indicates that the user data cannot be combined with further cloud-init configuration.
+var ErrUnsupportedUserData = errors.New("user data is neither cloud-config, a shell script nor multipart")
+
+// ResolverConfigPath is the path of the systemd-resolved drop-in with the resolver configuration.
+const ResolverConfigPath = "/etc/systemd/resolved.conf.d/99-caph.conf"
+
+// KubeletResolvConfPath is the path of the resolv.conf of kubelet, as set with its flag resolv-conf.
+const KubeletResolvConfPath = "/etc/kubernetes/resolv.conf"
+
+// kubeletResolvConfSource is the path to which the resolv.conf of kubelet is written first. Files of the
+// bootstrap data are written after the added ones, so the file is copied to KubeletResolvConfPath by a command
+// that runs before the commands of the bootstrap data.
+const kubeletResolvConfSource = "/etc/caph/kubelet-resolv.conf"
+
+// HetznerNameservers are the recursive resolvers of Hetzner, which kubelet uses if no nameservers are set.
+var HetznerNameservers = []string{"185.12.64.1", "185.12.64.2", "2a01:4ff:ff00::add:1"}
+
+// SwapFilePath is the path of the swap file of bare metal hosts with swap type file.
+const SwapFilePath = "/swapfile"
+
+// SwappinessConfigPath is the path of the sysctl drop-in with the swappiness.
+const SwappinessConfigPath = "/etc/sysctl.d/99-caph-swap.conf"
+
+// ConsoleUserSudoersPath is the path of the sudoers drop-in of the console user.
+const ConsoleUserSudoersPath = "/etc/sudoers.d/90-caph-console-user"
+
+// ArchitectureEnvPath is the path of the environment file with the CPU architecture of the node.
+const ArchitectureEnvPath = "/etc/caph/architecture.env"
+
+// NodeProfileSysctlPath is the path of the sysctl drop-in with the kernel parameters of the node profile.
+const NodeProfileSysctlPath = "/etc/sysctl.d/90-caph-node-profile.conf"
+
+// SSHHardeningConfigPath is the path of the sshd drop-in with the SSH hardening of the node profile. The first value
+// of a setting wins in sshd, so the drop-in is read before the ones of the image.
+const SSHHardeningConfigPath = "/etc/ssh/sshd_config.d/10-caph-hardening.conf"
+
+// KernelModuleHardeningPath is the path of the modprobe drop-in with the disabled kernel modules of the node profile.
+const KernelModuleHardeningPath = "/etc/modprobe.d/90-caph-hardening.conf"
+
+// RegistryConfigDir is the directory in which containerd looks for the configuration of registries.
+const RegistryConfigDir = "/etc/containerd/certs.d"
+
+// mergeType makes sure that the lists of the bootstrap data, e.g. write_files and runcmd, are
+// not replaced. The added configuration is applied before any command of the bootstrap data runs.
+const mergeType = "dict(recurse_array,no_replace)+list(prepend)+str()"
+
+const (
+	cloudConfigPrefix = "#cloud-config"
+	shellScriptPrefix = "#!"
+)
+
+var mimePrefixes = []string{"content-type:", "mime-version:"}
+
+type writeFile struct {
+	Path        string `json:"path"`
+	Content     string `json:"content"`
+	Permissions string `json:"permissions"`
+}
+
+type swapConfig struct {
+	Filename string `json:"filename"`
+	Size     int64  `json:"size"`
+}
+
+type caCertsConfig struct {
+	Trusted []string `json:"trusted"`
+}
+
+type userConfig struct {
+	Name       string `json:"name"`
+	Passwd     string `json:"passwd"`
+	LockPasswd bool   `json:"lock_passwd"`
+	Shell      string `json:"shell"`
+}
+
+type ntpConfig struct {
+	Enabled bool     `json:"enabled"`
+	Servers []string `json:"servers"`
+}
+
+type cloudConfig struct {
+	BootCmd    [][]string     `json:"bootcmd,omitempty"`
+	WriteFiles []writeFile    `json:"write_files,omitempty"`
+	RunCmd     [][]string     `json:"runcmd,omitempty"`
+	Swap       *swapConfig    `json:"swap,omitempty"`
+	CACerts    *caCertsConfig `json:"ca_certs,omitempty"`
+	// Users contains "default" or the configuration of a user
+	Users []interface{} `json:"users,omitempty"`
+	// Mounts contains fstab entries as lists of device, mount point, filesystem, options, dump and pass
+	Mounts [][]string `json:"mounts,omitempty"`
+	// SSHAuthorizedKeys are added to the default user
+	SSHAuthorizedKeys []string   `json:"ssh_authorized_keys,omitempty"`
+	NTP               *ntpConfig `json:"ntp,omitempty"`
+
+	PackageUpdate           *bool `json:"package_update,omitempty"`
+	PackageUpgrade          *bool `json:"package_upgrade,omitempty"`
+	PackageRebootIfRequired *bool `json:"package_reboot_if_required,omitempty"`
+}
+
+// VolumeMount is a block device that is mounted on the node.
+type VolumeMount struct {
+	// Device is the path of the block device, e.g. /dev/disk/by-id/scsi-0HC_Volume_1234.
+	Device string
+	// Path is the mount point.
+	Path string
+	// Format is the filesystem of the device.
+	Format string
+}
+
+// AddResolverConfig returns the user data combined with a cloud-config that configures
+// the DNS servers and search domains of the node and optionally the resolv.conf of kubelet.
+// The user data is returned unchanged if no resolver configuration is given.
+func AddResolverConfig(userData []byte, dns *infrav1.DNSSpec) ([]byte, error) {
+	if dns.IsZero() {
+		return userData, nil
+	}
+
+	config, err := resolverCloudConfig(dns)
+	if err != nil {
+		return nil, err
+	}
+	return addCloudConfig(userData, config)
+}
+
+// AddSwapConfig returns the user data combined with a cloud-config that creates the swap file
+// and sets the swappiness of the host. Swap partitions are created by installimage, so only the
+// swappiness is set for them. The user data is returned unchanged if no swap is configured.
+func AddSwapConfig(userData []byte, swap *infrav1.SwapSpec) ([]byte, error) {
+	if swap == nil || (swap.Type == infrav1.SwapTypePartition && swap.Swappiness == nil) {
+		return userData, nil
+	}
+
+	config, err := swapCloudConfig(swap)
+	if err != nil {
+		return nil, err
+	}
+	return addCloudConfig(userData, config)
+}
+
+// AddCABundle returns the user data combined with a cloud-config that adds the CA certificates to the trust
+// store of the operating system. Containerd is restarted, so that it uses them to pull images. The user data
+// is returned unchanged if the bundle is empty.
+func AddCABundle(userData, bundle []byte) ([]byte, error) {
+	if len(bundle) == 0 {
+		return userData, nil
+	}
+
+	config, err := json.Marshal(cloudConfig{
+		CACerts: &caCertsConfig{Trusted: []string{string(bundle)}},
+		RunCmd:  [][]string{{"systemctl", "try-restart", "containerd"}},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal CA bundle config")
+	}
+	return addCloudConfig(userData, append([]byte(cloudConfigPrefix+"\n"), config...))
+}
+
+// AddConsoleUser returns the user data combined with a cloud-config that creates a user with the hashed
+// password, who can log in on the console and use sudo. The default user of the image is kept. The user
+// data is returned unchanged if the name is empty.
+func AddConsoleUser(userData []byte, name, passwordHash string) ([]byte, error) {
+	if name == "" {
+		return userData, nil
+	}
+
+	config, err := json.Marshal(cloudConfig{
+		Users: []interface{}{
+			"default",
+			userConfig{Name: name, Passwd: passwordHash, LockPasswd: false, Shell: "/bin/bash"},
+		},
+		WriteFiles: []writeFile{{
+			Path:        ConsoleUserSudoersPath,
+			Content:     ConsoleUserSudoers(name),
+			Permissions: "0440",
+		}},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal console user config")
+	}
+	return addCloudConfig(userData, append([]byte(cloudConfigPrefix+"\n"), config...))
+}
+
+// AddArchitecture returns the user data combined with a cloud-config that writes the CPU architecture of the
+// node, as used by Go and the kubernetes.io/arch label, e.g. amd64 or arm64, to ArchitectureEnvPath. Commands
+// of the bootstrap data can source the file to download binaries of the right architecture, so that the same
+// bootstrap config works for server types of both architectures.
+func AddArchitecture(userData []byte, arch string) ([]byte, error) {
+	if arch == "" {
+		return userData, nil
+	}
+
+	config, err := json.Marshal(cloudConfig{
+		WriteFiles: []writeFile{{
+			Path:        ArchitectureEnvPath,
+			Content:     fmt.Sprintf("ARCH=%s\n", arch),
+			Permissions: "0644",
+		}},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal architecture config")
+	}
+	return addCloudConfig(userData, append([]byte(cloudConfigPrefix+"\n"), config...))
+}
+
+// AddVolumeMounts returns the user data combined with a cloud-config that mounts the volumes. The mounts module of
+// cloud-init adds them to /etc/fstab and creates the mount points, before any command of the bootstrap data runs.
+// The mounts have the option nofail, so that a missing volume does not block the boot. The user data is returned
+// unchanged if no volume is given.
+func AddVolumeMounts(userData []byte, mounts []VolumeMount) ([]byte, error) {
+	if len(mounts) == 0 {
+		return userData, nil
+	}
+
+	var config cloudConfig
+	for _, mount := range mounts {
+		config.Mounts = append(config.Mounts, []string{mount.Device, mount.Path, mount.Format, "defaults,nofail,discard", "0", "2"})
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal volume mount config")
+	}
+	return addCloudConfig(userData, append([]byte(cloudConfigPrefix+"\n"), data...))
+}
+
+// AddDefaultRoute returns the user data combined with a cloud-config that sets the default route of the node via the
+// gateway. Nodes without public network reach the internet through the gateway of the private network. The route is
+// set on every boot, before any command of the bootstrap data runs. The user data is returned unchanged if the
+// gateway is empty.
+func AddDefaultRoute(userData []byte, gateway string) ([]byte, error) {
+	if gateway == "" {
+		return userData, nil
+	}
+
+	config, err := json.Marshal(cloudConfig{
+		BootCmd: [][]string{{"ip", "route", "replace", "default", "via", gateway}},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal default route config")
+	}
+	return addCloudConfig(userData, append([]byte(cloudConfigPrefix+"\n"), config...))
+}
+
+// AddNodeProfile returns the user data combined with a cloud-config that applies the settings of the node profile:
+// the authorized SSH keys of the default user, the time servers, the registry mirrors of containerd, the kernel
+// parameters and the hardening of SSH and kernel modules. The user data is returned unchanged if no profile is given.
+func AddNodeProfile(userData []byte, profile *infrav1.HetznerNodeProfileSpec) ([]byte, error) {
+	if profile == nil {
+		return userData, nil
+	}
+
+	config, err := nodeProfileCloudConfig(profile)
+	if err != nil {
+		return nil, err
+	}
+	return addCloudConfig(userData, config)
+}
+
+// AddPreBakedImageConfig returns the user data combined with a cloud-config that keeps cloud-init from updating
+// and upgrading the packages of an image that already contains kubelet, containerd and kubeadm, so that the first
+// boot only runs the join. Settings of the bootstrap data take precedence. The user data is returned unchanged if
+// the image is not pre-baked.
+func AddPreBakedImageConfig(userData []byte, preBaked bool) ([]byte, error) {
+	if !preBaked {
+		return userData, nil
+	}
+
+	disabled := false
+	config, err := json.Marshal(cloudConfig{
+		PackageUpdate:           &disabled,
+		PackageUpgrade:          &disabled,
+		PackageRebootIfRequired: &disabled,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal pre-baked image config")
+	}
+	return addCloudConfig(userData, append([]byte(cloudConfigPrefix+"\n"), config...))
+}
+
+// AddCloudInitParts returns the user data combined with the cloud-configs of the infrastructure. Settings of the
+// user data take precedence, followed by the later parts, and the commands of the parts run in order before the
+// commands of the user data. As cloud-init keeps the settings that have been merged first and prepends the lists
+// of every part, the parts are added in reverse.
+func AddCloudInitParts(userData []byte, parts [][]byte) ([]byte, error) {
+	for i := len(parts) - 1; i >= 0; i-- {
+		var err error
+		userData, err = addCloudConfig(userData, parts[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to add cloud-init part %d", i)
+		}
+	}
+	return userData, nil
+}
+
+// ConsoleUserSudoers returns the content of the sudoers drop-in of the console user.
+func ConsoleUserSudoers(name string) string {
+	return fmt.Sprintf("%s ALL=(ALL) ALL\n", name)
+}
+
+func addCloudConfig(userData, config []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\nContent-Type: multipart/mixed; boundary=%q\r\n\r\n", writer.Boundary())
+
+	if err := writeUserDataPart(writer, userData); err != nil {
+		return nil, err
+	}
+
+	part, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {`text/cloud-config; charset="us-ascii"`},
+		"Merge-Type":   {mergeType},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create part for cloud-config")
+	}
+	if _, err := part.Write(config); err != nil {
+		return nil, errors.Wrap(err, "failed to write cloud-config")
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, errors.Wrap(err, "failed to close multipart writer")
+	}
+	return buf.Bytes(), nil
+}
+
+func writeUserDataPart(writer *multipart.Writer, userData []byte) error {
+	var header textproto.MIMEHeader
+	body := io.Reader(bytes.NewReader(userData))
+
+	switch {
+	case bytes.HasPrefix(userData, []byte(cloudConfigPrefix)):
+		header = textproto.MIMEHeader{"Content-Type": {`text/cloud-config; charset="us-ascii"`}}
+	case bytes.HasPrefix(userData, []byte(shellScriptPrefix)):
+		header = textproto.MIMEHeader{"Content-Type": {`text/x-shellscript; charset="us-ascii"`}}
+	case isMIMEMessage(userData):
+		// Cloud-init walks through nested multipart messages, so the message is added as it is
+		msg, err := mail.ReadMessage(bytes.NewReader(userData))
+		if err != nil {
+			return errors.Wrap(err, "failed to read multipart user data")
+		}
+		header = textproto.MIMEHeader(msg.Header)
+		body = msg.Body
+	default:
+		return ErrUnsupportedUserData
+	}
+
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return errors.Wrap(err, "failed to create part for user data")
+	}
+	if _, err := io.Copy(part, body); err != nil {
+		return errors.Wrap(err, "failed to write user data")
+	}
+	return nil
+}
+
+func isMIMEMessage(userData []byte) bool {
+	for _, prefix := range mimePrefixes {
+		if len(userData) >= len(prefix) && strings.EqualFold(string(userData[:len(prefix)]), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolverCloudConfig writes the resolver configuration as systemd-resolved drop-in and the resolv.conf
+// of kubelet. JSON is valid YAML, so the config does not need any further escaping.
+func resolverCloudConfig(dns *infrav1.DNSSpec) ([]byte, error) {
+	var config cloudConfig
+	if len(dns.Nameservers) > 0 || len(dns.SearchDomains) > 0 {
+		content := "[Resolve]\n"
+		if len(dns.Nameservers) > 0 {
+			content += fmt.Sprintf("DNS=%s\n", strings.Join(dns.Nameservers, " "))
+		}
+		if len(dns.SearchDomains) > 0 {
+			content += fmt.Sprintf("Domains=%s\n", strings.Join(dns.SearchDomains, " "))
+		}
+		config.WriteFiles = append(config.WriteFiles, writeFile{Path: ResolverConfigPath, Content: content, Permissions: "0644"})
+		config.RunCmd = append(config.RunCmd, []string{"systemctl", "try-restart", "systemd-resolved"})
+	}
+
+	if dns.KubeletResolvConf {
+		config.WriteFiles = append(config.WriteFiles, writeFile{
+			Path:        kubeletResolvConfSource,
+			Content:     kubeletResolvConf(dns),
+			Permissions: "0644",
+		})
+		config.RunCmd = append(config.RunCmd,
+			[]string{"install", "-D", "-m", "0644", kubeletResolvConfSource, KubeletResolvConfPath},
+		)
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal resolver config")
+	}
+	return append([]byte(cloudConfigPrefix+"\n"), data...), nil
+}
+
+// kubeletResolvConf returns the resolv.conf of kubelet with the nameservers, or the resolvers of Hetzner,
+// and the search domains.
+func kubeletResolvConf(dns *infrav1.DNSSpec) string {
+	nameservers := dns.Nameservers
+	if len(nameservers) == 0 {
+		nameservers = HetznerNameservers
+	}
+	var content strings.Builder
+	for _, nameserver := range nameservers {
+		fmt.Fprintf(&content, "nameserver %s\n", nameserver)
+	}
+	if len(dns.SearchDomains) > 0 {
+		fmt.Fprintf(&content, "search %s\n", strings.Join(dns.SearchDomains, " "))
+	}
+	return content.String()
+}
+
+// swapCloudConfig creates the swap file with the swap module of cloud-init, which also adds it to
+// /etc/fstab. It runs before runcmd, so the swap is active when the kubelet starts.
+func swapCloudConfig(swap *infrav1.SwapSpec) ([]byte, error) {
+	var config cloudConfig
+	if swap.Type != infrav1.SwapTypePartition {
+		size, err := sizeToBytes(swap.Size)
+		if err != nil {
+			return nil, err
+		}
+		config.Swap = &swapConfig{Filename: SwapFilePath, Size: size}
+	}
+	if swap.Swappiness != nil {
+		config.WriteFiles = []writeFile{{
+			Path:        SwappinessConfigPath,
+			Content:     fmt.Sprintf("vm.swappiness = %d\n", *swap.Swappiness),
+			Permissions: "0644",
+		}}
+		config.RunCmd = [][]string{{"sysctl", "-p", SwappinessConfigPath}}
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal swap config")
+	}
+	return append([]byte(cloudConfigPrefix+"\n"), data...), nil
+}
+
+func nodeProfileCloudConfig(profile *infrav1.HetznerNodeProfileSpec) ([]byte, error) {
+	config := cloudConfig{SSHAuthorizedKeys: profile.SSHAuthorizedKeys}
+	if len(profile.NTPServers) > 0 {
+		config.NTP = &ntpConfig{Enabled: true, Servers: profile.NTPServers}
+	}
+
+	for _, mirror := range profile.RegistryMirrors {
+		config.WriteFiles = append(config.WriteFiles, writeFile{
+			Path:        fmt.Sprintf("%s/%s/hosts.toml", RegistryConfigDir, mirror.Registry),
+			Content:     RegistryHostsConfig(mirror),
+			Permissions: "0644",
+		})
+	}
+
+	if len(profile.Sysctls) > 0 {
+		keys := make([]string, 0, len(profile.Sysctls))
+		for key := range profile.Sysctls {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var content strings.Builder
+		for _, key := range keys {
+			fmt.Fprintf(&content, "%s = %s\n", key, profile.Sysctls[key])
+		}
+		config.WriteFiles = append(config.WriteFiles, writeFile{
+			Path:        NodeProfileSysctlPath,
+			Content:     content.String(),
+			Permissions: "0644",
+		})
+		config.RunCmd = append(config.RunCmd, []string{"sysctl", "-p", NodeProfileSysctlPath})
+	}
+
+	if hardening := profile.Hardening; hardening != nil {
+		var sshdConfig strings.Builder
+		if hardening.DisableSSHPasswordAuthentication {
+			sshdConfig.WriteString("PasswordAuthentication no\nKbdInteractiveAuthentication no\n")
+		}
+		if hardening.DisableSSHForwarding {
+			sshdConfig.WriteString("AllowTcpForwarding no\nAllowAgentForwarding no\nX11Forwarding no\n")
+		}
+		if sshdConfig.Len() > 0 {
+			config.WriteFiles = append(config.WriteFiles, writeFile{
+				Path:        SSHHardeningConfigPath,
+				Content:     sshdConfig.String(),
+				Permissions: "0644",
+			})
+			config.RunCmd = append(config.RunCmd, []string{"systemctl", "try-reload-or-restart", "ssh", "sshd"})
+		}
+
+		if len(hardening.DisabledKernelModules) > 0 {
+			var modprobeConfig strings.Builder
+			for _, module := range hardening.DisabledKernelModules {
+				fmt.Fprintf(&modprobeConfig, "install %s /bin/false\nblacklist %s\n", module, module)
+			}
+			config.WriteFiles = append(config.WriteFiles, writeFile{
+				Path:        KernelModuleHardeningPath,
+				Content:     modprobeConfig.String(),
+				Permissions: "0644",
+			})
+		}
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal node profile config")
+	}
+	return append([]byte(cloudConfigPrefix+"\n"), data...), nil
+}
+
+// RegistryHostsConfig returns the hosts.toml of containerd that pulls the images of the registry from its mirrors.
+func RegistryHostsConfig(mirror infrav1.RegistryMirror) string {
+	server := "https://" + mirror.Registry
+	if mirror.Registry == "docker.io" {
+		server = "https://registry-1.docker.io"
+	}
+
+	var config strings.Builder
+	fmt.Fprintf(&config, "server = %q\n", server)
+	for _, endpoint := range mirror.Endpoints {
+		fmt.Fprintf(&config, "\n[host.%q]\n  capabilities = [\"pull\", \"resolve\"]\n", endpoint)
+	}
+	return config.String()
+}
+
+// sizeToBytes converts a size with the unit M, G or T as used by installimage to bytes.
+func sizeToBytes(size string) (int64, error) {
+	units := map[string]int64{"M": 1 << 20, "G": 1 << 30, "T": 1 << 40}
+	if len(size) < 2 {
+		return 0, fmt.Errorf("invalid size %q", size)
+	}
+	unit, found := units[size[len(size)-1:]]
+	if !found {
+		return 0, fmt.Errorf("invalid unit of size %q", size)
+	}
+	value, err := strconv.ParseInt(size[:len(size)-1], 10, 64)
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("invalid size %q", size)
+	}
+	return value * unit, nil
+}
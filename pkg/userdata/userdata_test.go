@@ -0,0 +1,377 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package userdata
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	infrav1 "github.com/syself/cluster-api-provider-hetzner/api/v1beta1"
+)
+
+type testPart struct {
+	contentType string
+	mergeType   string
+	body        string
+}
+
+func readParts(userData []byte) []testPart {
+	msg, err := mail.ReadMessage(bytes.NewReader(userData))
+	Expect(err).To(Succeed())
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	Expect(err).To(Succeed())
+	Expect(mediaType).To(Equal("multipart/mixed"))
+
+	var parts []testPart
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return parts
+		}
+		Expect(err).To(Succeed())
+		body, err := io.ReadAll(part)
+		Expect(err).To(Succeed())
+		parts = append(parts, testPart{
+			contentType: part.Header.Get("Content-Type"),
+			mergeType:   part.Header.Get("Merge-Type"),
+			body:        string(body),
+		})
+	}
+}
+
+var _ = Describe("AddResolverConfig", func() {
+	dns := &infrav1.DNSSpec{
+		Nameservers:   []string{"10.0.0.53", "10.0.1.53"},
+		SearchDomains: []string{"corp.example.com"},
+	}
+
+	It("returns the user data unchanged without resolver configuration", func() {
+		userData := []byte("#cloud-config\nruncmd:\n- kubeadm init\n")
+		Expect(AddResolverConfig(userData, nil)).To(Equal(userData))
+		Expect(AddResolverConfig(userData, &infrav1.DNSSpec{})).To(Equal(userData))
+	})
+
+	It("adds the resolver configuration to cloud-config", func() {
+		userData := "#cloud-config\nruncmd:\n- kubeadm init\n"
+		result, err := AddResolverConfig([]byte(userData), dns)
+		Expect(err).To(Succeed())
+
+		parts := readParts(result)
+		Expect(parts).To(HaveLen(2))
+		Expect(parts[0].contentType).To(HavePrefix("text/cloud-config"))
+		Expect(parts[0].body).To(Equal(userData))
+		Expect(parts[1].contentType).To(HavePrefix("text/cloud-config"))
+		Expect(parts[1].mergeType).To(Equal(mergeType))
+		Expect(parts[1].body).To(HavePrefix("#cloud-config\n"))
+		Expect(parts[1].body).To(ContainSubstring(ResolverConfigPath))
+		Expect(parts[1].body).To(ContainSubstring(`DNS=10.0.0.53 10.0.1.53\nDomains=corp.example.com\n`))
+	})
+
+	It("writes the resolv.conf of kubelet with the resolvers of Hetzner", func() {
+		result, err := AddResolverConfig([]byte("#cloud-config\n"), &infrav1.DNSSpec{KubeletResolvConf: true})
+		Expect(err).To(Succeed())
+
+		parts := readParts(result)
+		Expect(parts).To(HaveLen(2))
+		Expect(parts[1].body).ToNot(ContainSubstring(ResolverConfigPath))
+		Expect(parts[1].body).ToNot(ContainSubstring("systemd-resolved"))
+		Expect(parts[1].body).To(ContainSubstring(`nameserver 185.12.64.1\nnameserver 185.12.64.2\nnameserver 2a01:4ff:ff00::add:1\n`))
+		Expect(parts[1].body).To(ContainSubstring(KubeletResolvConfPath))
+	})
+
+	It("writes the resolv.conf of kubelet with the configured resolvers", func() {
+		result, err := AddResolverConfig([]byte("#cloud-config\n"), &infrav1.DNSSpec{
+			Nameservers:       dns.Nameservers,
+			SearchDomains:     dns.SearchDomains,
+			KubeletResolvConf: true,
+		})
+		Expect(err).To(Succeed())
+
+		parts := readParts(result)
+		Expect(parts).To(HaveLen(2))
+		Expect(parts[1].body).To(ContainSubstring(ResolverConfigPath))
+		Expect(parts[1].body).To(ContainSubstring(`nameserver 10.0.0.53\nnameserver 10.0.1.53\nsearch corp.example.com\n`))
+		Expect(parts[1].body).ToNot(ContainSubstring("185.12.64.1"))
+	})
+
+	It("adds the resolver configuration to shell scripts", func() {
+		result, err := AddResolverConfig([]byte("#!/bin/bash\nkubeadm join\n"), dns)
+		Expect(err).To(Succeed())
+
+		parts := readParts(result)
+		Expect(parts).To(HaveLen(2))
+		Expect(parts[0].contentType).To(HavePrefix("text/x-shellscript"))
+		Expect(parts[0].body).To(Equal("#!/bin/bash\nkubeadm join\n"))
+	})
+
+	It("nests multipart user data", func() {
+		inner, err := AddResolverConfig([]byte("#cloud-config\n"), &infrav1.DNSSpec{Nameservers: []string{"1.1.1.1"}})
+		Expect(err).To(Succeed())
+		result, err := AddResolverConfig(inner, dns)
+		Expect(err).To(Succeed())
+
+		parts := readParts(result)
+		Expect(parts).To(HaveLen(2))
+		Expect(parts[0].contentType).To(HavePrefix("multipart/mixed"))
+		Expect(parts[0].body).To(ContainSubstring("DNS=1.1.1.1"))
+	})
+
+	It("fails for user data that cannot be combined", func() {
+		_, err := AddResolverConfig([]byte(`{"ignition":{"version":"3.3.0"}}`), dns)
+		Expect(err).To(MatchError(ErrUnsupportedUserData))
+	})
+})
+
+var _ = Describe("AddSwapConfig", func() {
+	userData := []byte("#cloud-config\nruncmd:\n- kubeadm join\n")
+	swappiness := 10
+
+	It("returns the user data unchanged without swap", func() {
+		Expect(AddSwapConfig(userData, nil)).To(Equal(userData))
+		Expect(AddSwapConfig(userData, &infrav1.SwapSpec{Type: infrav1.SwapTypePartition, Size: "8G"})).To(Equal(userData))
+	})
+
+	It("creates the swap file and sets the swappiness", func() {
+		result, err := AddSwapConfig(userData, &infrav1.SwapSpec{Type: infrav1.SwapTypeFile, Size: "8G", Swappiness: &swappiness})
+		Expect(err).To(Succeed())
+
+		parts := readParts(result)
+		Expect(parts).To(HaveLen(2))
+		Expect(parts[1].mergeType).To(Equal(mergeType))
+		Expect(parts[1].body).To(ContainSubstring(`"swap":{"filename":"/swapfile","size":8589934592}`))
+		Expect(parts[1].body).To(ContainSubstring(SwappinessConfigPath))
+		Expect(parts[1].body).To(ContainSubstring(`vm.swappiness = 10\n`))
+	})
+
+	It("only sets the swappiness of swap partitions", func() {
+		result, err := AddSwapConfig(userData, &infrav1.SwapSpec{Type: infrav1.SwapTypePartition, Size: "8G", Swappiness: &swappiness})
+		Expect(err).To(Succeed())
+
+		parts := readParts(result)
+		Expect(parts).To(HaveLen(2))
+		Expect(parts[1].body).ToNot(ContainSubstring(`"swap"`))
+		Expect(parts[1].body).To(ContainSubstring(`vm.swappiness = 10\n`))
+	})
+
+	It("fails for invalid sizes", func() {
+		_, err := AddSwapConfig(userData, &infrav1.SwapSpec{Size: "8X"})
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("AddCABundle", func() {
+	userData := []byte("#cloud-config\nruncmd:\n- kubeadm join\n")
+
+	It("returns the user data unchanged without CA bundle", func() {
+		Expect(AddCABundle(userData, nil)).To(Equal(userData))
+	})
+
+	It("adds the CA certificates and restarts containerd", func() {
+		bundle := "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"
+		result, err := AddCABundle(userData, []byte(bundle))
+		Expect(err).To(Succeed())
+
+		parts := readParts(result)
+		Expect(parts).To(HaveLen(2))
+		Expect(parts[1].mergeType).To(Equal(mergeType))
+		Expect(parts[1].body).To(ContainSubstring(`"ca_certs":{"trusted":["-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"]}`))
+		Expect(parts[1].body).To(ContainSubstring(`"runcmd":[["systemctl","try-restart","containerd"]]`))
+	})
+})
+
+var _ = Describe("AddConsoleUser", func() {
+	userData := []byte("#cloud-config\nruncmd:\n- kubeadm join\n")
+
+	It("returns the user data unchanged without console user", func() {
+		Expect(AddConsoleUser(userData, "", "")).To(Equal(userData))
+	})
+
+	It("creates the user with the hashed password and keeps the default user", func() {
+		result, err := AddConsoleUser(userData, "console", "$6$salt$hash")
+		Expect(err).To(Succeed())
+
+		parts := readParts(result)
+		Expect(parts).To(HaveLen(2))
+		Expect(parts[1].mergeType).To(Equal(mergeType))
+		Expect(parts[1].body).To(ContainSubstring(`"users":["default",{"name":"console","passwd":"$6$salt$hash","lock_passwd":false,"shell":"/bin/bash"}]`))
+		Expect(parts[1].body).To(ContainSubstring(ConsoleUserSudoersPath))
+		Expect(parts[1].body).To(ContainSubstring(`"content":"console ALL=(ALL) ALL\n"`))
+	})
+})
+
+var _ = Describe("AddArchitecture", func() {
+	userData := []byte("#cloud-config\nruncmd:\n- kubeadm join\n")
+
+	It("returns the user data unchanged without architecture", func() {
+		Expect(AddArchitecture(userData, "")).To(Equal(userData))
+	})
+
+	It("writes the architecture to the environment file", func() {
+		result, err := AddArchitecture(userData, "arm64")
+		Expect(err).To(Succeed())
+
+		parts := readParts(result)
+		Expect(parts).To(HaveLen(2))
+		Expect(parts[0].body).To(Equal(string(userData)))
+		Expect(parts[1].mergeType).To(Equal(mergeType))
+		Expect(parts[1].body).To(ContainSubstring(ArchitectureEnvPath))
+		Expect(parts[1].body).To(ContainSubstring(`"content":"ARCH=arm64\n"`))
+	})
+})
+
+var _ = Describe("AddVolumeMounts", func() {
+	userData := []byte("#cloud-config\nruncmd:\n- kubeadm join\n")
+
+	It("returns the user data unchanged without volumes", func() {
+		Expect(AddVolumeMounts(userData, nil)).To(Equal(userData))
+	})
+
+	It("mounts the volumes", func() {
+		result, err := AddVolumeMounts(userData, []VolumeMount{
+			{Device: "/dev/disk/by-id/scsi-0HC_Volume_1", Path: "/var/lib/etcd", Format: "ext4"},
+			{Device: "/dev/disk/by-id/scsi-0HC_Volume_2", Path: "/data", Format: "xfs"},
+		})
+		Expect(err).To(Succeed())
+
+		parts := readParts(result)
+		Expect(parts).To(HaveLen(2))
+		Expect(parts[0].body).To(Equal(string(userData)))
+		Expect(parts[1].mergeType).To(Equal(mergeType))
+		Expect(parts[1].body).To(ContainSubstring(`"mounts":[["/dev/disk/by-id/scsi-0HC_Volume_1","/var/lib/etcd","ext4","defaults,nofail,discard","0","2"],["/dev/disk/by-id/scsi-0HC_Volume_2","/data","xfs","defaults,nofail,discard","0","2"]]`))
+	})
+})
+
+var _ = Describe("AddDefaultRoute", func() {
+	userData := []byte("#cloud-config\nruncmd:\n- kubeadm join\n")
+
+	It("returns the user data unchanged without gateway", func() {
+		Expect(AddDefaultRoute(userData, "")).To(Equal(userData))
+	})
+
+	It("sets the default route on boot", func() {
+		result, err := AddDefaultRoute(userData, "10.0.0.1")
+		Expect(err).To(Succeed())
+
+		parts := readParts(result)
+		Expect(parts).To(HaveLen(2))
+		Expect(parts[0].body).To(Equal(string(userData)))
+		Expect(parts[1].mergeType).To(Equal(mergeType))
+		Expect(parts[1].body).To(ContainSubstring(`"bootcmd":[["ip","route","replace","default","via","10.0.0.1"]]`))
+	})
+})
+
+var _ = Describe("AddPreBakedImageConfig", func() {
+	userData := []byte("#cloud-config\nruncmd:\n- kubeadm join\n")
+
+	It("returns the user data unchanged for images that are not pre-baked", func() {
+		Expect(AddPreBakedImageConfig(userData, false)).To(Equal(userData))
+	})
+
+	It("disables the package management of cloud-init", func() {
+		result, err := AddPreBakedImageConfig(userData, true)
+		Expect(err).To(Succeed())
+
+		parts := readParts(result)
+		Expect(parts).To(HaveLen(2))
+		Expect(parts[0].body).To(Equal(string(userData)))
+		Expect(parts[1].mergeType).To(Equal(mergeType))
+		Expect(parts[1].body).To(ContainSubstring(`"package_update":false,"package_upgrade":false,"package_reboot_if_required":false`))
+	})
+})
+
+var _ = Describe("AddNodeProfile", func() {
+	userData := []byte("#cloud-config\nruncmd:\n- kubeadm join\n")
+
+	It("returns the user data unchanged without node profile", func() {
+		Expect(AddNodeProfile(userData, nil)).To(Equal(userData))
+	})
+
+	It("applies the settings of the node profile", func() {
+		result, err := AddNodeProfile(userData, &infrav1.HetznerNodeProfileSpec{
+			SSHAuthorizedKeys: []string{"ssh-ed25519 AAAA team@example.com"},
+			NTPServers:        []string{"ntp1.hetzner.de"},
+			RegistryMirrors:   []infrav1.RegistryMirror{{Registry: "docker.io", Endpoints: []string{"https://mirror.example.com"}}},
+			Sysctls:           map[string]string{"vm.max_map_count": "262144", "net.core.somaxconn": "4096"},
+			Hardening: &infrav1.NodeHardening{
+				DisableSSHPasswordAuthentication: true,
+				DisabledKernelModules:            []string{"cramfs"},
+			},
+		})
+		Expect(err).To(Succeed())
+
+		parts := readParts(result)
+		Expect(parts).To(HaveLen(2))
+		Expect(parts[0].body).To(Equal(string(userData)))
+		Expect(parts[1].mergeType).To(Equal(mergeType))
+		Expect(parts[1].body).To(ContainSubstring(`"ssh_authorized_keys":["ssh-ed25519 AAAA team@example.com"]`))
+		Expect(parts[1].body).To(ContainSubstring(`"ntp":{"enabled":true,"servers":["ntp1.hetzner.de"]}`))
+		Expect(parts[1].body).To(ContainSubstring(RegistryConfigDir + "/docker.io/hosts.toml"))
+		Expect(parts[1].body).To(ContainSubstring(`server = \"https://registry-1.docker.io\"`))
+		Expect(parts[1].body).To(ContainSubstring(`"content":"net.core.somaxconn = 4096\nvm.max_map_count = 262144\n"`))
+		Expect(parts[1].body).To(ContainSubstring(`"content":"PasswordAuthentication no\nKbdInteractiveAuthentication no\n"`))
+		Expect(parts[1].body).To(ContainSubstring(`"content":"install cramfs /bin/false\nblacklist cramfs\n"`))
+	})
+})
+
+var _ = Describe("AddCloudInitParts", func() {
+	userData := []byte("#cloud-config\nruncmd:\n- kubeadm join\n")
+
+	It("returns the user data unchanged without parts", func() {
+		Expect(AddCloudInitParts(userData, nil)).To(Equal(userData))
+	})
+
+	It("merges the parts in reverse, so that later parts take precedence and commands run in order", func() {
+		proxy := "#cloud-config\nwrite_files:\n- path: /etc/environment\n  content: HTTPS_PROXY=http://proxy:3128\n"
+		sysctl := "#cloud-config\nruncmd:\n- sysctl --system\n"
+		result, err := AddCloudInitParts(userData, [][]byte{[]byte(proxy), []byte(sysctl)})
+		Expect(err).To(Succeed())
+
+		parts := readParts(result)
+		Expect(parts).To(HaveLen(2))
+		Expect(parts[0].contentType).To(HavePrefix("multipart/mixed"))
+		Expect(parts[1].mergeType).To(Equal(mergeType))
+		Expect(parts[1].body).To(Equal(proxy))
+
+		inner := readParts([]byte("Content-Type: " + parts[0].contentType + "\r\n\r\n" + parts[0].body))
+		Expect(inner).To(HaveLen(2))
+		Expect(inner[0].body).To(Equal(string(userData)))
+		Expect(inner[1].mergeType).To(Equal(mergeType))
+		Expect(inner[1].body).To(Equal(sysctl))
+	})
+})
+
+var _ = Describe("RegistryHostsConfig", func() {
+	It("pulls from the mirrors in order", func() {
+		Expect(RegistryHostsConfig(infrav1.RegistryMirror{
+			Registry:  "registry.k8s.io",
+			Endpoints: []string{"https://mirror-a.example.com", "http://mirror-b.example.com:5000"},
+		})).To(Equal(`server = "https://registry.k8s.io"
+
+[host."https://mirror-a.example.com"]
+  capabilities = ["pull", "resolve"]
+
+[host."http://mirror-b.example.com:5000"]
+  capabilities = ["pull", "resolve"]
+`))
+	})
+})
@@ -18,9 +18,12 @@ limitations under the License.
 package utils
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 
 	"github.com/go-logr/logr"
@@ -28,6 +31,7 @@ import (
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apiserver/pkg/storage/names"
 )
 
@@ -44,6 +48,37 @@ func LabelsToLabelSelector(labels map[string]string) string {
 	return strings.Join(parts, ",")
 }
 
+// HCloudLabelSelector is converting a Kubernetes label selector to an HCloud
+// label selector. The requirements are sorted, so that equal selectors result
+// in the same string.
+func HCloudLabelSelector(selector *metav1.LabelSelector) (string, error) {
+	if selector == nil {
+		return "", nil
+	}
+	parts := make([]string, 0, len(selector.MatchLabels)+len(selector.MatchExpressions))
+	for key, val := range selector.MatchLabels {
+		parts = append(parts, fmt.Sprintf("%s==%s", key, val))
+	}
+	for _, expr := range selector.MatchExpressions {
+		values := append([]string(nil), expr.Values...)
+		sort.Strings(values)
+		switch expr.Operator {
+		case metav1.LabelSelectorOpIn:
+			parts = append(parts, fmt.Sprintf("%s in (%s)", expr.Key, strings.Join(values, ",")))
+		case metav1.LabelSelectorOpNotIn:
+			parts = append(parts, fmt.Sprintf("%s notin (%s)", expr.Key, strings.Join(values, ",")))
+		case metav1.LabelSelectorOpExists:
+			parts = append(parts, expr.Key)
+		case metav1.LabelSelectorOpDoesNotExist:
+			parts = append(parts, "!"+expr.Key)
+		default:
+			return "", errors.Errorf("unsupported operator %q of label selector", expr.Operator)
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ","), nil
+}
+
 // LabelSelectorToLabels is converting an HCloud label
 // selector to a map of labels.
 func LabelSelectorToLabels(str string) (map[string]string, error) {
@@ -133,6 +168,12 @@ func FilterStringFromList(list []string, strToFilter string) (newList []string)
 	return
 }
 
+// SHA256Hash returns the hex encoded SHA256 hash of data.
+func SHA256Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
 // GenerateName takes a name as string pointer. It returns name if pointer is not nil, otherwise it returns fallback with random suffix.
 func GenerateName(name *string, fallback string) string {
 	if name != nil {
@@ -20,6 +20,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/syself/cluster-api-provider-hetzner/pkg/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var _ = DescribeTable("LabelsToLabelSelector",
@@ -33,6 +34,24 @@ var _ = DescribeTable("LabelsToLabelSelector",
 	Entry("no keys", map[string]string{}, "", ""),
 )
 
+var _ = DescribeTable("HCloudLabelSelector",
+	func(selector *metav1.LabelSelector, expectedOutput string) {
+		Expect(utils.HCloudLabelSelector(selector)).To(Equal(expectedOutput))
+	},
+	Entry("nil selector", nil, ""),
+	Entry("match labels", &metav1.LabelSelector{
+		MatchLabels: map[string]string{"k8s": "v1.29", "caph-image": "ubuntu-22.04"},
+	}, "caph-image==ubuntu-22.04,k8s==v1.29"),
+	Entry("match expressions", &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "os", Operator: metav1.LabelSelectorOpIn, Values: []string{"ubuntu", "debian"}},
+			{Key: "deprecated", Operator: metav1.LabelSelectorOpDoesNotExist},
+			{Key: "track", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"beta"}},
+			{Key: "k8s", Operator: metav1.LabelSelectorOpExists},
+		},
+	}, "!deprecated,k8s,os in (debian,ubuntu),track notin (beta)"),
+)
+
 var _ = DescribeTable("LabelSelectorToLabels",
 	func(str string, expectedOutput map[string]string) {
 		Expect(utils.LabelSelectorToLabels(str)).To(Equal(expectedOutput))
@@ -0,0 +1,116 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package main renders the embedded cluster templates. The values of the variables are taken from the
+// environment, like clusterctl does, and from --set flags, which take precedence.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	clustertemplates "github.com/syself/cluster-api-provider-hetzner/templates/cluster-templates"
+)
+
+// setFlags collects the values of repeated --set flags.
+type setFlags map[string]string
+
+func (f setFlags) String() string {
+	return fmt.Sprint(map[string]string(f))
+}
+
+func (f setFlags) Set(value string) error {
+	name, v, found := strings.Cut(value, "=")
+	if !found || name == "" {
+		return fmt.Errorf("expected NAME=VALUE, got %q", value)
+	}
+	f[name] = v
+	return nil
+}
+
+func main() {
+	var (
+		flavor        string
+		output        string
+		listFlavors   bool
+		listVariables bool
+		raw           bool
+	)
+	values := setFlags{}
+
+	flag.StringVar(&flavor, "flavor", "", fmt.Sprintf("Flavor of the template. Defaults to %s.", clustertemplates.DefaultFlavor))
+	flag.StringVar(&output, "output", "", "File to write the template to. Defaults to stdout.")
+	flag.BoolVar(&listFlavors, "list-flavors", false, "List the available flavors.")
+	flag.BoolVar(&listVariables, "list-variables", false, "List the variables of the flavor.")
+	flag.BoolVar(&raw, "raw", false, "Print the template without substituting the variables.")
+	flag.Var(values, "set", "Value of a variable as NAME=VALUE. Can be repeated. Overrides the environment.")
+	flag.Parse()
+
+	if err := run(flavor, output, listFlavors, listVariables, raw, values); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(flavor, output string, listFlavors, listVariables, raw bool, values setFlags) error {
+	switch {
+	case listFlavors:
+		fmt.Println(strings.Join(clustertemplates.Flavors(), "\n"))
+		return nil
+	case listVariables:
+		variables, err := clustertemplates.Variables(flavor)
+		if err != nil {
+			return err
+		}
+		fmt.Println(strings.Join(variables, "\n"))
+		return nil
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+	if raw {
+		data, err = clustertemplates.Template(flavor)
+	} else {
+		data, err = clustertemplates.Render(flavor, variableValues(values))
+	}
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(output, data, 0o600)
+}
+
+// variableValues returns the values of the environment combined with the values of --set flags.
+func variableValues(values setFlags) map[string]string {
+	result := make(map[string]string)
+	for _, env := range os.Environ() {
+		if name, value, found := strings.Cut(env, "="); found {
+			result[name] = value
+		}
+	}
+	for name, value := range values {
+		result[name] = value
+	}
+	return result
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clustertemplates_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	clustertemplates "github.com/syself/cluster-api-provider-hetzner/templates/cluster-templates"
+)
+
+var _ = Describe("Parity with kustomize", func() {
+	It("embeds every flavor directory", func() {
+		entries, err := os.ReadDir(".")
+		Expect(err).ToNot(HaveOccurred())
+		var flavors []string
+		for _, entry := range entries {
+			if !entry.IsDir() || entry.Name() == "bases" {
+				continue
+			}
+			if _, err := os.Stat(filepath.Join(entry.Name(), "kustomization.yaml")); err == nil {
+				flavors = append(flavors, entry.Name())
+			}
+		}
+		Expect(clustertemplates.Flavors()).To(Equal(flavors))
+	})
+
+	It("builds every flavor like kustomize build", func() {
+		// make test sets the kustomize binary of the tools, which builds the released templates
+		kustomize := os.Getenv("KUSTOMIZE")
+		if kustomize == "" {
+			Skip("KUSTOMIZE is not set, run make test to compare the flavors with kustomize build")
+		}
+		for _, flavor := range clustertemplates.Flavors() {
+			expected, err := exec.Command(kustomize, "build", flavor, "--load-restrictor", "LoadRestrictionsNone").Output() //nolint:gosec
+			Expect(err).ToNot(HaveOccurred(), flavor)
+			template, err := clustertemplates.Template(flavor)
+			Expect(err).ToNot(HaveOccurred(), flavor)
+			Expect(objectsOf(template)).To(ConsistOf(objectsOf(expected)), flavor)
+		}
+	})
+})
@@ -0,0 +1,322 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package clustertemplates embeds the flavors of the cluster templates and renders them, so that manifests
+// can be generated programmatically instead of copying the templates of a release.
+//
+// The flavors are built from the same kustomizations as the released templates. Only the subset of kustomize
+// that the flavors use is supported: resources and bases, patchesStrategicMerge and JSON 6902 patches.
+// make test compares every flavor with the output of kustomize build.
+package clustertemplates
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/yamlprocessor"
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultFlavor is the flavor of the template without flavor, cluster-template.yaml.
+const DefaultFlavor = "hcloud"
+
+const (
+	kustomizationFile = "kustomization.yaml"
+	basesDir          = "bases"
+)
+
+//go:embed bases */kustomization.yaml */*/kustomization.yaml
+var sources embed.FS
+
+// ErrUnknownFlavor is returned for a flavor that does not exist.
+var ErrUnknownFlavor = errors.New("unknown flavor")
+
+// kustomization is the part of a kustomization that is supported.
+type kustomization struct {
+	Resources             []string `json:"resources,omitempty"`
+	Bases                 []string `json:"bases,omitempty"`
+	PatchesStrategicMerge []string `json:"patchesStrategicMerge,omitempty"`
+	Patches               []patch  `json:"patches,omitempty"`
+}
+
+type patch struct {
+	Patch  string      `json:"patch"`
+	Target patchTarget `json:"target"`
+}
+
+type patchTarget struct {
+	Kind string `json:"kind,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// object is a resource of a template.
+type object map[string]interface{}
+
+func (o object) kind() string {
+	kind, _ := o["kind"].(string)
+	return kind
+}
+
+func (o object) name() string {
+	metadata, _ := o["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	return name
+}
+
+func (o object) group() string {
+	apiVersion, _ := o["apiVersion"].(string)
+	group, _, found := strings.Cut(apiVersion, "/")
+	if !found {
+		return ""
+	}
+	return group
+}
+
+// Flavors returns the names of the available flavors in alphabetical order.
+func Flavors() []string {
+	entries, err := fs.ReadDir(sources, ".")
+	if err != nil {
+		return nil
+	}
+	var flavors []string
+	for _, entry := range entries {
+		if entry.IsDir() && entry.Name() != basesDir {
+			flavors = append(flavors, entry.Name())
+		}
+	}
+	sort.Strings(flavors)
+	return flavors
+}
+
+// Template returns the template of a flavor with its variables, as it is published as
+// cluster-template-<flavor>.yaml with a release. The empty flavor returns the template of DefaultFlavor.
+func Template(flavor string) ([]byte, error) {
+	if flavor == "" {
+		flavor = DefaultFlavor
+	}
+	if flavor == basesDir || strings.Contains(flavor, "/") {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFlavor, flavor)
+	}
+	if _, err := fs.Stat(sources, path.Join(flavor, kustomizationFile)); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFlavor, flavor)
+	}
+
+	objects, err := build(flavor)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to build flavor %s", flavor)
+	}
+
+	var buf bytes.Buffer
+	for i, obj := range objects {
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to marshal %s %s", obj.kind(), obj.name())
+		}
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		buf.Write(data)
+	}
+	return buf.Bytes(), nil
+}
+
+// Variables returns the names of the variables of the template of a flavor in alphabetical order.
+// Variables with a default value, e.g. ${VAR:=default}, are included.
+func Variables(flavor string) ([]string, error) {
+	template, err := Template(flavor)
+	if err != nil {
+		return nil, err
+	}
+	return yamlprocessor.NewSimpleProcessor().GetVariables(template)
+}
+
+// Render returns the template of a flavor with the given values of its variables. Variables without a value
+// fall back to their default value. An error lists all variables that have neither.
+func Render(flavor string, values map[string]string) ([]byte, error) {
+	template, err := Template(flavor)
+	if err != nil {
+		return nil, err
+	}
+	return yamlprocessor.NewSimpleProcessor().Process(template, func(name string) (string, error) {
+		value, found := values[name]
+		if !found {
+			return "", errors.Errorf("value for variable %q is not set", name)
+		}
+		return value, nil
+	})
+}
+
+// build builds the objects of the kustomization in dir.
+func build(dir string) ([]object, error) {
+	data, err := sources.ReadFile(path.Join(dir, kustomizationFile))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read kustomization")
+	}
+	var k kustomization
+	if err := yaml.UnmarshalStrict(data, &k); err != nil {
+		return nil, errors.Wrapf(err, "unsupported kustomization in %s", dir)
+	}
+
+	var objects []object
+	for _, resource := range append(k.Resources, k.Bases...) {
+		resourcePath := path.Join(dir, resource)
+		info, err := fs.Stat(sources, resourcePath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to find resource %s", resource)
+		}
+
+		var resourceObjects []object
+		if info.IsDir() {
+			resourceObjects, err = build(resourcePath)
+		} else {
+			resourceObjects, err = readObjects(resourcePath)
+		}
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, resourceObjects...)
+	}
+
+	for _, patchFile := range k.PatchesStrategicMerge {
+		patches, err := readObjects(path.Join(dir, patchFile))
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range patches {
+			if err := applyMergePatch(objects, p); err != nil {
+				return nil, errors.Wrapf(err, "failed to apply patch %s", patchFile)
+			}
+		}
+	}
+
+	for i, p := range k.Patches {
+		if err := applyJSONPatch(objects, p); err != nil {
+			return nil, errors.Wrapf(err, "failed to apply patch %d", i)
+		}
+	}
+	return objects, nil
+}
+
+// readObjects reads the objects of a file with one or more YAML documents.
+func readObjects(file string) ([]object, error) {
+	data, err := sources.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", file)
+	}
+
+	var objects []object
+	for _, doc := range splitDocuments(data) {
+		var obj object
+		if err := yaml.Unmarshal(doc, &obj); err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal %s", file)
+		}
+		if len(obj) == 0 {
+			continue
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+func splitDocuments(data []byte) [][]byte {
+	var docs [][]byte
+	var current bytes.Buffer
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		if strings.TrimRight(line, " \r\n") == "---" {
+			docs = append(docs, []byte(current.String()))
+			current.Reset()
+			continue
+		}
+		current.WriteString(line)
+	}
+	return append(docs, current.Bytes())
+}
+
+// applyMergePatch patches the object with the group, kind and name of the patch. Like kustomize does for
+// custom resources, maps are merged and lists are replaced.
+func applyMergePatch(objects []object, p object) error {
+	for i, obj := range objects {
+		if obj.group() != p.group() || obj.kind() != p.kind() || obj.name() != p.name() {
+			continue
+		}
+		patchJSON, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		patched, err := patchObject(obj, func(doc []byte) ([]byte, error) {
+			return jsonpatch.MergePatch(doc, patchJSON)
+		})
+		if err != nil {
+			return err
+		}
+		objects[i] = patched
+		return nil
+	}
+	return errors.Errorf("no object %s %s to patch", p.kind(), p.name())
+}
+
+// applyJSONPatch applies a JSON 6902 patch to all objects that match its target.
+func applyJSONPatch(objects []object, p patch) error {
+	patchJSON, err := yaml.YAMLToJSON([]byte(p.Patch))
+	if err != nil {
+		return errors.Wrap(err, "failed to convert patch")
+	}
+	decoded, err := jsonpatch.DecodePatch(patchJSON)
+	if err != nil {
+		return errors.Wrap(err, "failed to decode patch")
+	}
+
+	var matched bool
+	for i, obj := range objects {
+		if (p.Target.Kind != "" && obj.kind() != p.Target.Kind) || (p.Target.Name != "" && obj.name() != p.Target.Name) {
+			continue
+		}
+		patched, err := patchObject(obj, decoded.Apply)
+		if err != nil {
+			return err
+		}
+		objects[i] = patched
+		matched = true
+	}
+	if !matched {
+		return errors.Errorf("no object matches the target %+v", p.Target)
+	}
+	return nil
+}
+
+func patchObject(obj object, apply func([]byte) ([]byte, error)) (object, error) {
+	doc, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	doc, err = apply(doc)
+	if err != nil {
+		return nil, err
+	}
+	var patched object
+	if err := json.Unmarshal(doc, &patched); err != nil {
+		return nil, err
+	}
+	return patched, nil
+}
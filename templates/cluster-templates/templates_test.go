@@ -0,0 +1,132 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clustertemplates_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	clustertemplates "github.com/syself/cluster-api-provider-hetzner/templates/cluster-templates"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+func objectsOf(template []byte) []*unstructured.Unstructured {
+	var objects []*unstructured.Unstructured
+	for _, doc := range strings.Split(string(template), "\n---\n") {
+		obj := &unstructured.Unstructured{}
+		Expect(yaml.Unmarshal([]byte(doc), &obj.Object)).To(Succeed())
+		objects = append(objects, obj)
+	}
+	return objects
+}
+
+func findObject(objects []*unstructured.Unstructured, kind, name string) *unstructured.Unstructured {
+	for _, obj := range objects {
+		if obj.GetKind() == kind && obj.GetName() == name {
+			return obj
+		}
+	}
+	return nil
+}
+
+var _ = Describe("Flavors", func() {
+	It("contains the flavors of the release", func() {
+		Expect(clustertemplates.Flavors()).To(ContainElements(
+			"hcloud",
+			"hcloud-network",
+			"hetzner-baremetal-control-planes",
+			"hetzner-hcloud-control-planes",
+		))
+		Expect(clustertemplates.Flavors()).ToNot(ContainElement("bases"))
+	})
+
+	It("builds every flavor", func() {
+		for _, flavor := range clustertemplates.Flavors() {
+			template, err := clustertemplates.Template(flavor)
+			Expect(err).ToNot(HaveOccurred(), flavor)
+			Expect(findObject(objectsOf(template), "Cluster", "${CLUSTER_NAME}")).ToNot(BeNil(), flavor)
+		}
+	})
+})
+
+var _ = Describe("Template", func() {
+	It("returns the default flavor for the empty flavor", func() {
+		Expect(clustertemplates.Template("")).To(Equal(must(clustertemplates.Template(clustertemplates.DefaultFlavor))))
+	})
+
+	It("fails for an unknown flavor", func() {
+		for _, flavor := range []string{"does-not-exist", "bases", "../bases"} {
+			_, err := clustertemplates.Template(flavor)
+			Expect(err).To(MatchError(clustertemplates.ErrUnknownFlavor), flavor)
+		}
+	})
+
+	It("applies the strategic merge patches", func() {
+		objects := objectsOf(must(clustertemplates.Template("hcloud")))
+
+		machineTemplate := findObject(objects, "HCloudMachineTemplate", "${CLUSTER_NAME}-md-0")
+		Expect(machineTemplate).ToNot(BeNil())
+		placementGroupName, _, _ := unstructured.NestedString(machineTemplate.Object, "spec", "template", "spec", "placementGroupName")
+		Expect(placementGroupName).To(Equal("md-0"))
+		machineType, _, _ := unstructured.NestedString(machineTemplate.Object, "spec", "template", "spec", "type")
+		Expect(machineType).To(Equal("${HCLOUD_WORKER_MACHINE_TYPE}"))
+	})
+
+	It("builds the resources of nested kustomizations with their patches", func() {
+		objects := objectsOf(must(clustertemplates.Template("hetzner-baremetal-control-planes")))
+		Expect(findObject(objects, "KubeadmConfigTemplate", "${CLUSTER_NAME}-md-0")).ToNot(BeNil())
+		Expect(findObject(objects, "KubeadmConfigTemplate", "${CLUSTER_NAME}-md-1")).ToNot(BeNil())
+	})
+})
+
+var _ = Describe("Variables", func() {
+	It("returns the variables of a flavor", func() {
+		variables, err := clustertemplates.Variables("hcloud-packer")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(variables).To(ContainElements("CLUSTER_NAME", "HCLOUD_IMAGE_NAME", "KUBERNETES_VERSION"))
+	})
+})
+
+var _ = Describe("Render", func() {
+	It("substitutes the variables", func() {
+		values := map[string]string{}
+		variables, err := clustertemplates.Variables("hcloud")
+		Expect(err).ToNot(HaveOccurred())
+		for _, variable := range variables {
+			values[variable] = "value"
+		}
+		values["CLUSTER_NAME"] = "my-cluster"
+
+		rendered, err := clustertemplates.Render("hcloud", values)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(rendered)).ToNot(ContainSubstring("${"))
+		Expect(findObject(objectsOf(rendered), "HetznerCluster", "my-cluster")).ToNot(BeNil())
+	})
+
+	It("fails if variables are missing", func() {
+		_, err := clustertemplates.Render("hcloud", map[string]string{"CLUSTER_NAME": "my-cluster"})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("KUBERNETES_VERSION"))
+	})
+})
+
+func must(data []byte, err error) []byte {
+	Expect(err).ToNot(HaveOccurred())
+	return data
+}
@@ -171,6 +171,31 @@ func WithConsumerRef() HostOpts {
 	}
 }
 
+// WithForceDeleteAnnotation gives the option to define a host that can be deleted while it is consumed.
+func WithForceDeleteAnnotation() HostOpts {
+	return func(host *infrav1.HetznerBareMetalHost) {
+		if host.Annotations == nil {
+			host.Annotations = make(map[string]string)
+		}
+		host.Annotations[infrav1.ForceDeleteAnnotation] = "true"
+	}
+}
+
+// WithPrivateProvisioning gives the option to define a host that is provisioned via its private IP through a bastion.
+// It has to be specified after the SSH spec.
+func WithPrivateProvisioning() HostOpts {
+	return func(host *infrav1.HetznerBareMetalHost) {
+		host.Spec.PrivateIP = "10.0.0.2"
+		host.Spec.Status.SSHSpec.PrivateProvisioning = &infrav1.PrivateProvisioning{
+			Bastion: infrav1.Bastion{
+				Address: "5.6.7.8",
+				Port:    2222,
+				User:    "jump",
+			},
+		}
+	}
+}
+
 // GetDefaultHetznerClusterSpec returns the default Hetzner cluster spec.
 func GetDefaultHetznerClusterSpec() infrav1.HetznerClusterSpec {
 	return infrav1.HetznerClusterSpec{